@@ -13,6 +13,8 @@ import (
 	"kasir-api/internal/config"
 	"kasir-api/internal/database"
 	"kasir-api/internal/handler"
+	"kasir-api/internal/model"
+	"kasir-api/internal/receipt"
 	"kasir-api/internal/repository"
 	"kasir-api/internal/repository/memory"
 	"kasir-api/internal/repository/postgres"
@@ -52,6 +54,11 @@ func main() {
 		return
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "stock-check" {
+		runStockCheck()
+		return
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -62,10 +69,39 @@ func main() {
 	// Initialize repositories
 	var productRepo repository.ProductReader
 	var productWriter repository.ProductWriter
+	var stockReader repository.StockReader
+	var stockWriter repository.StockWriter
 	var categoryRepo repository.CategoryReader
 	var categoryWriter repository.CategoryWriter
+	var promotionReader repository.PromotionReader
+	var promotionWriter repository.PromotionWriter
+	var transactionReader repository.TransactionReader
 	var transactionWriter repository.TransactionWriter
+	var cartReader repository.CartReader
+	var cartWriter repository.CartWriter
+	var shiftReader repository.ShiftReader
+	var shiftWriter repository.ShiftWriter
+	var stocktakeReader repository.StocktakeReader
+	var stocktakeWriter repository.StocktakeWriter
+	var supplierReader repository.SupplierReader
+	var supplierWriter repository.SupplierWriter
+	var purchaseOrderReader repository.PurchaseOrderReader
+	var purchaseOrderWriter repository.PurchaseOrderWriter
+	var purchaseReturnReader repository.PurchaseReturnReader
+	var purchaseReturnWriter repository.PurchaseReturnWriter
+	var customerReader repository.CustomerReader
+	var customerWriter repository.CustomerWriter
+	var outletReader repository.OutletReader
+	var outletWriter repository.OutletWriter
+	var userReader repository.UserReader
+	var userWriter repository.UserWriter
+	var sessionStore repository.SessionStore
+	var deviceStore repository.DeviceStore
+	var returnReader repository.ReturnReader
+	var returnWriter repository.ReturnWriter
 	var reportReader repository.ReportReader
+	var auditReader repository.AuditReader
+	var idempotencyStore repository.IdempotencyStore
 	var db *database.DB
 
 	// Check if database is configured
@@ -85,59 +121,274 @@ func main() {
 		pgProductRepo := postgres.NewProductRepository(db.DB)
 		productRepo = pgProductRepo
 		productWriter = pgProductRepo
+		stockReader = pgProductRepo
+		stockWriter = pgProductRepo
 
 		pgCategoryRepo := postgres.NewCategoryRepository(db.DB)
 		categoryRepo = pgCategoryRepo
 		categoryWriter = pgCategoryRepo
 
+		pgPromotionRepo := postgres.NewPromotionRepository(db.DB)
+		promotionReader = pgPromotionRepo
+		promotionWriter = pgPromotionRepo
+
 		pgTransactionRepo := postgres.NewTransactionRepository(db.DB)
+		transactionReader = pgTransactionRepo
 		transactionWriter = pgTransactionRepo
 
+		pgCartRepo := postgres.NewCartRepository(db.DB)
+		cartReader = pgCartRepo
+		cartWriter = pgCartRepo
+
+		pgShiftRepo := postgres.NewShiftRepository(db.DB)
+		shiftReader = pgShiftRepo
+		shiftWriter = pgShiftRepo
+
+		pgStocktakeRepo := postgres.NewStocktakeRepository(db.DB)
+		stocktakeReader = pgStocktakeRepo
+		stocktakeWriter = pgStocktakeRepo
+
+		pgSupplierRepo := postgres.NewSupplierRepository(db.DB)
+		supplierReader = pgSupplierRepo
+		supplierWriter = pgSupplierRepo
+
+		pgPurchaseOrderRepo := postgres.NewPurchaseOrderRepository(db.DB)
+		purchaseOrderReader = pgPurchaseOrderRepo
+		purchaseOrderWriter = pgPurchaseOrderRepo
+
+		pgPurchaseReturnRepo := postgres.NewPurchaseReturnRepository(db.DB)
+		purchaseReturnReader = pgPurchaseReturnRepo
+		purchaseReturnWriter = pgPurchaseReturnRepo
+
+		pgCustomerRepo := postgres.NewCustomerRepository(db.DB)
+		customerReader = pgCustomerRepo
+		customerWriter = pgCustomerRepo
+
+		pgOutletRepo := postgres.NewOutletRepository(db.DB)
+		outletReader = pgOutletRepo
+		outletWriter = pgOutletRepo
+
+		pgUserRepo := postgres.NewUserRepository(db.DB)
+		userReader = pgUserRepo
+		userWriter = pgUserRepo
+		sessionStore = postgres.NewSessionRepository(db.DB)
+		deviceStore = postgres.NewDeviceRepository(db.DB)
+
+		pgReturnRepo := postgres.NewReturnRepository(db.DB)
+		returnReader = pgReturnRepo
+		returnWriter = pgReturnRepo
+
 		pgReportRepo := postgres.NewReportRepository(db.DB)
 		reportReader = pgReportRepo
+
+		auditReader = postgres.NewAuditRepository(db.DB)
+
+		idempotencyStore = postgres.NewIdempotencyRepository(db.DB)
 	} else {
 		// Use in-memory repositories
 		logger.Info("Using in-memory storage")
+		memAuditRepo := memory.NewAuditRepository()
+		auditReader = memAuditRepo
+
 		memProductRepo := memory.NewProductRepository()
 		memCategoryRepo := memory.NewCategoryRepository()
+		memProductRepo.SetAuditLog(memAuditRepo)
+		memCategoryRepo.SetAuditLog(memAuditRepo)
 
 		// Wire category repo to product repo for JOIN simulation
 		memProductRepo.SetCategoryRepo(memCategoryRepo)
 
 		productRepo = memProductRepo
 		productWriter = memProductRepo
+		stockReader = memProductRepo
+		stockWriter = memProductRepo
 
 		categoryRepo = memCategoryRepo
 		categoryWriter = memCategoryRepo
+
+		memPromotionRepo := memory.NewPromotionRepository()
+		memPromotionRepo.SetAuditLog(memAuditRepo)
+		memPromotionRepo.SetTargets(memProductRepo, memCategoryRepo)
+		memProductRepo.SetPromotionRepo(memPromotionRepo)
+		memCategoryRepo.SetPromotionRepo(memPromotionRepo)
+		promotionReader = memPromotionRepo
+		promotionWriter = memPromotionRepo
+
+		memTransactionRepo := memory.NewTransactionRepository(memProductRepo)
+		memTransactionRepo.SetAuditLog(memAuditRepo)
+		transactionReader = memTransactionRepo
+		transactionWriter = memTransactionRepo
+
+		memCartRepo := memory.NewCartRepository()
+		memCartRepo.SetAuditLog(memAuditRepo)
+		cartReader = memCartRepo
+		cartWriter = memCartRepo
+
+		memShiftRepo := memory.NewShiftRepository(memTransactionRepo)
+		memTransactionRepo.SetShiftRepo(memShiftRepo)
+		memShiftRepo.SetAuditLog(memAuditRepo)
+		shiftReader = memShiftRepo
+		shiftWriter = memShiftRepo
+
+		memStocktakeRepo := memory.NewStocktakeRepository(memProductRepo)
+		memStocktakeRepo.SetAuditLog(memAuditRepo)
+		stocktakeReader = memStocktakeRepo
+		stocktakeWriter = memStocktakeRepo
+
+		memSupplierRepo := memory.NewSupplierRepository()
+		memSupplierRepo.SetAuditLog(memAuditRepo)
+		supplierReader = memSupplierRepo
+		supplierWriter = memSupplierRepo
+
+		memPurchaseOrderRepo := memory.NewPurchaseOrderRepository(memProductRepo, memSupplierRepo)
+		memPurchaseOrderRepo.SetAuditLog(memAuditRepo)
+		purchaseOrderReader = memPurchaseOrderRepo
+		purchaseOrderWriter = memPurchaseOrderRepo
+
+		memPurchaseReturnRepo := memory.NewPurchaseReturnRepository(memProductRepo, memSupplierRepo, memPurchaseOrderRepo)
+		memPurchaseReturnRepo.SetAuditLog(memAuditRepo)
+		purchaseReturnReader = memPurchaseReturnRepo
+		purchaseReturnWriter = memPurchaseReturnRepo
+
+		memCustomerRepo := memory.NewCustomerRepository()
+		memTransactionRepo.SetCustomerRepo(memCustomerRepo)
+		memCustomerRepo.SetAuditLog(memAuditRepo)
+		customerReader = memCustomerRepo
+		customerWriter = memCustomerRepo
+
+		// The default outlet takes the configured store details, as the schema's does
+		memOutletRepo := memory.NewOutletRepository(model.Outlet{
+			Code:    cfg.Store.Code,
+			Name:    cfg.Store.Name,
+			Address: cfg.Store.Address,
+			Phone:   cfg.Store.Phone,
+		})
+		memTransactionRepo.SetOutletRepo(memOutletRepo)
+		memOutletRepo.SetAuditLog(memAuditRepo)
+		outletReader = memOutletRepo
+		outletWriter = memOutletRepo
+
+		memUserRepo := memory.NewUserRepository()
+		memUserRepo.SetAuditLog(memAuditRepo)
+		userReader = memUserRepo
+		userWriter = memUserRepo
+		sessionStore = memory.NewSessionRepository()
+
+		memDeviceRepo := memory.NewDeviceRepository()
+		memDeviceRepo.SetAuditLog(memAuditRepo)
+		deviceStore = memDeviceRepo
+
+		memReturnRepo := memory.NewReturnRepository(memTransactionRepo)
+		memTransactionRepo.SetReturnRepo(memReturnRepo)
+		memReturnRepo.SetAuditLog(memAuditRepo)
+		returnReader = memReturnRepo
+		returnWriter = memReturnRepo
+
+		memReportRepo := memory.NewReportRepository(memTransactionRepo)
+		memReportRepo.SetPurchaseReturnRepo(memPurchaseReturnRepo)
+		reportReader = memReportRepo
+
+		memIdempotencyRepo := memory.NewIdempotencyRepository()
+		memTransactionRepo.SetIdempotencyStore(memIdempotencyRepo)
+		idempotencyStore = memIdempotencyRepo
 	}
 
 	// Initialize services
 	productService := service.NewProductService(productRepo, productWriter)
+	stockService := service.NewStockService(stockReader, stockWriter, productRepo, outletReader)
 	categoryService := service.NewCategoryService(categoryRepo, categoryWriter)
+	promotionService := service.NewPromotionService(promotionReader, promotionWriter)
+
+	transactionService := service.NewTransactionService(transactionReader, transactionWriter)
+	transactionService.SetIdempotencyStore(idempotencyStore, cfg.Idempotency.TTL)
+	transactionService.SetPromotionReader(promotionReader)
+	transactionService.SetTaxRate(cfg.Tax.RateBasisPoints())
 
-	var transactionService *service.TransactionService
-	if transactionWriter != nil {
-		transactionService = service.NewTransactionService(transactionWriter)
+	invoiceNumbering, err := model.NewInvoiceNumbering(cfg.Invoice.Pattern, cfg.Store.Code)
+	if err != nil {
+		logger.Error("Invalid invoice numbering", "error", err)
+		log.Fatalf("Invalid invoice numbering: %v", err)
+	}
+	transactionService.SetInvoiceNumbering(invoiceNumbering)
+	transactionService.SetShiftReader(shiftReader)
+	transactionService.SetLoyaltyRule(cfg.Loyalty.Rule())
+	transactionService.SetStockAlertHook(service.NewStockAlertNotifier(cfg.Alerts.WebhookURL, cfg.Alerts.WebhookTimeout))
+	cartService := service.NewCartService(cartReader, cartWriter, productRepo, transactionService, cfg.Cart.TTL)
+	shiftService := service.NewShiftService(shiftReader, shiftWriter)
+	stocktakeService := service.NewStocktakeService(stocktakeReader, stocktakeWriter, categoryRepo)
+	supplierService := service.NewSupplierService(supplierReader, supplierWriter)
+	purchaseOrderService := service.NewPurchaseOrderService(purchaseOrderReader, purchaseOrderWriter, supplierReader)
+	purchaseReturnService := service.NewPurchaseReturnService(purchaseReturnReader, purchaseReturnWriter, supplierReader)
+	customerService := service.NewCustomerService(customerReader, customerWriter, transactionReader)
+	outletService := service.NewOutletService(outletReader, outletWriter)
+	userService := service.NewUserService(userReader, userWriter, sessionStore)
+
+	secret := []byte(cfg.Auth.Secret)
+	if len(secret) == 0 {
+		logger.Warn("APP_AUTH_SECRET is not set; using a random secret, so tokens stop working on restart")
+		secret = service.GenerateSecret()
+	}
+	authService := service.NewAuthService(userReader, userWriter, sessionStore, secret)
+	authService.SetTokenTTLs(cfg.Auth.AccessTTL, cfg.Auth.RefreshTTL)
+	authService.SetLockoutPolicy(cfg.Auth.LockoutPolicy())
+
+	// A fresh install has no users, so create the configured one to sign in with
+	if cfg.Auth.AdminUsername != "" && cfg.Auth.AdminPassword != "" {
+		created, err := userService.CreateInitial(context.Background(), model.UserRequest{
+			Username: cfg.Auth.AdminUsername,
+			Name:     cfg.Auth.AdminUsername,
+			Password: cfg.Auth.AdminPassword,
+		})
+		if err != nil {
+			logger.Error("Failed to create the initial user", "error", err)
+			log.Fatalf("Failed to create the initial user: %v", err)
+		}
+		if created {
+			logger.Info("Created the initial user", "username", cfg.Auth.AdminUsername)
+		}
 	}
 
-	var reportService *service.ReportService
-	if reportReader != nil {
-		reportService = service.NewReportService(reportReader)
+	deviceService := service.NewDeviceService(deviceStore, outletReader)
+	deviceService.SetPairingCodeTTL(cfg.Auth.PairingCodeTTL)
+
+	returnService := service.NewReturnService(returnReader, returnWriter)
+	reportService := service.NewReportService(reportReader)
+	auditService := service.NewAuditService(auditReader)
+
+	receiptRenderer, err := receipt.NewRenderer(receipt.Store{
+		Name:    cfg.Store.Name,
+		Address: cfg.Store.Address,
+		Phone:   cfg.Store.Phone,
+		Footer:  cfg.Receipt.Footer,
+	}, cfg.Receipt.TemplateDir)
+	if err != nil {
+		logger.Error("Failed to load receipt templates", "error", err)
+		log.Fatalf("Failed to load receipt templates: %v", err)
 	}
+	receiptService := service.NewReceiptService(transactionReader, outletReader, receiptRenderer)
 
 	// Initialize handlers
 	productHandler := handler.NewProductHandler(productService)
+	stockHandler := handler.NewStockHandler(stockService)
 	categoryHandler := handler.NewCategoryHandler(categoryService)
-
-	var transactionHandler *handler.TransactionHandler
-	if transactionService != nil {
-		transactionHandler = handler.NewTransactionHandler(transactionService)
-	}
-
-	var reportHandler *handler.ReportHandler
-	if reportService != nil {
-		reportHandler = handler.NewReportHandler(reportService)
-	}
+	promotionHandler := handler.NewPromotionHandler(promotionService)
+
+	transactionHandler := handler.NewTransactionHandler(transactionService)
+	cartHandler := handler.NewCartHandler(cartService)
+	shiftHandler := handler.NewShiftHandler(shiftService)
+	stocktakeHandler := handler.NewStocktakeHandler(stocktakeService)
+	supplierHandler := handler.NewSupplierHandler(supplierService)
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(purchaseOrderService)
+	purchaseReturnHandler := handler.NewPurchaseReturnHandler(purchaseReturnService)
+	customerHandler := handler.NewCustomerHandler(customerService)
+	outletHandler := handler.NewOutletHandler(outletService)
+	userHandler := handler.NewUserHandler(userService)
+	authHandler := handler.NewAuthHandler(authService)
+	deviceHandler := handler.NewDeviceHandler(deviceService)
+	returnHandler := handler.NewReturnHandler(returnService)
+	receiptHandler := handler.NewReceiptHandler(receiptService)
+	reportHandler := handler.NewReportHandler(reportService)
+	auditHandler := handler.NewAuditHandler(auditService)
 
 	var healthHandler *handler.HealthHandler
 	if db != nil {
@@ -148,7 +399,7 @@ func main() {
 
 	// Setup routes
 	mux := http.NewServeMux()
-	handlerWithMiddleware := handler.SetupRoutes(mux, productHandler, categoryHandler, transactionHandler, reportHandler, healthHandler)
+	handlerWithMiddleware := handler.SetupRoutes(mux, productHandler, stockHandler, categoryHandler, promotionHandler, transactionHandler, cartHandler, shiftHandler, stocktakeHandler, supplierHandler, purchaseOrderHandler, purchaseReturnHandler, customerHandler, outletHandler, userHandler, authHandler, deviceHandler, auditHandler, returnHandler, receiptHandler, reportHandler, healthHandler, authService, deviceService)
 
 	// Create server
 	server := &http.Server{
@@ -200,6 +451,7 @@ func printHelp() {
 	fmt.Println("  api migrate-reset  Reset all migrations (drop all tables)")
 	fmt.Println("  api seed           Seed database with sample data")
 	fmt.Println("  api rls [on|off]   Enable or disable Row Level Security")
+	fmt.Println("  api stock-check    Recompute stock from the stock ledger and report differences")
 	fmt.Println("  api help           Show this help message")
 	fmt.Println()
 	fmt.Println("Options:")
@@ -212,6 +464,10 @@ func printHelp() {
 	fmt.Println("  APP_DATABASE_USER  Database user")
 	fmt.Println("  APP_DATABASE_PASSWORD  Database password")
 	fmt.Println("  APP_DATABASE_DBNAME    Database name")
+	fmt.Println("  APP_AUTH_SECRET        Secret for signing access tokens")
+	fmt.Println("  APP_AUTH_ADMINUSERNAME First user, created while there are none")
+	fmt.Println("  APP_AUTH_ADMINPASSWORD Password of the first user")
+	fmt.Println("  APP_AUTH_PAIRINGCODETTL How long a device pairing code is valid (default: 10m)")
 }
 
 func runMigrations() {
@@ -415,3 +671,60 @@ func runRLS() {
 		logger.Info("RLS disabled successfully")
 	}
 }
+
+func runStockCheck() {
+	// Check for help flags
+	if len(os.Args) > 2 && (os.Args[2] == "-h" || os.Args[2] == "--help") {
+		fmt.Println("Check the stock ledger")
+		fmt.Println()
+		fmt.Println("Usage:")
+		fmt.Println("  api stock-check    Recompute every balance from the stock movements")
+		fmt.Println()
+		fmt.Println("Lists each stock and movement balance that differs from the ledger,")
+		fmt.Println("and exits with status 1 if there is any.")
+		fmt.Println()
+		fmt.Println("Required Environment Variables:")
+		fmt.Println("  APP_DATABASE_HOST      Database host")
+		fmt.Println("  APP_DATABASE_USER      Database user")
+		fmt.Println("  APP_DATABASE_PASSWORD  Database password")
+		fmt.Println("  APP_DATABASE_DBNAME    Database name")
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("Failed to load config", "error", err)
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.Database.Host == "" || cfg.Database.DBName == "" {
+		logger.Error("Database configuration is required for the stock check")
+		log.Fatal("Database configuration is required for the stock check")
+	}
+
+	db, err := database.NewPool(cfg.Database)
+	if err != nil {
+		logger.Error("Failed to connect to database", "error", err)
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	logger.Info("Checking stock ledger...")
+	stockService := service.NewStockService(postgres.NewProductRepository(db.DB), nil, nil, nil)
+	discrepancies, err := stockService.Check(context.Background())
+	if err != nil {
+		logger.Error("Stock check failed", "error", err)
+		log.Fatalf("Stock check failed: %v", err)
+	}
+
+	if len(discrepancies) == 0 {
+		logger.Info("Stock ledger is consistent")
+		return
+	}
+	for _, d := range discrepancies {
+		fmt.Println(d)
+	}
+	logger.Error("Stock does not match the ledger", "discrepancies", len(discrepancies))
+	db.Close()
+	os.Exit(1)
+}
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE transactions ADD COLUMN status TEXT NOT NULL DEFAULT 'completed'
+    CHECK (status IN ('completed', 'voided', 'refunded'));
+ALTER TABLE transactions ADD COLUMN cancelled_at TIMESTAMP;
+ALTER TABLE transactions ADD COLUMN cancelled_by TEXT;
+ALTER TABLE transactions ADD COLUMN cancel_reason TEXT;
+CREATE INDEX idx_transactions_status ON transactions(status);
+CREATE INDEX idx_transactions_created_at ON transactions(created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_transactions_created_at;
+DROP INDEX IF EXISTS idx_transactions_status;
+ALTER TABLE transactions DROP COLUMN cancel_reason;
+ALTER TABLE transactions DROP COLUMN cancelled_by;
+ALTER TABLE transactions DROP COLUMN cancelled_at;
+ALTER TABLE transactions DROP COLUMN status;
@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS returns (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id),
+    total_amount INT NOT NULL CHECK (total_amount <= 0),
+    reason TEXT NOT NULL,
+    performed_by TEXT NOT NULL,
+    restock BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS return_items (
+    id SERIAL PRIMARY KEY,
+    return_id INT NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    amount INT NOT NULL CHECK (amount <= 0)
+);
+
+CREATE INDEX idx_returns_transaction_id ON returns(transaction_id);
+CREATE INDEX idx_returns_created_at ON returns(created_at);
+CREATE INDEX idx_return_items_return_id ON return_items(return_id);
+CREATE INDEX idx_return_items_transaction_detail_id ON return_items(transaction_detail_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_return_items_transaction_detail_id;
+DROP INDEX IF EXISTS idx_return_items_return_id;
+DROP INDEX IF EXISTS idx_returns_created_at;
+DROP INDEX IF EXISTS idx_returns_transaction_id;
+DROP TABLE IF EXISTS return_items;
+DROP TABLE IF EXISTS returns;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE transactions
+    ADD COLUMN paid_amount INT NOT NULL DEFAULT 0,
+    ADD COLUMN change_amount INT NOT NULL DEFAULT 0;
+
+-- Existing sales were settled exactly
+UPDATE transactions SET paid_amount = total_amount;
+
+CREATE TABLE IF NOT EXISTS payments (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('cash', 'debit', 'credit', 'qris', 'ewallet', 'transfer')),
+    amount INT NOT NULL CHECK (amount > 0),
+    reference VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_payments_transaction_id ON payments(transaction_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_payments_transaction_id;
+DROP TABLE IF EXISTS payments;
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS change_amount,
+    DROP COLUMN IF EXISTS paid_amount;
@@ -0,0 +1,51 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS promotions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('percent', 'fixed', 'buy_x_get_y', 'order')),
+    value INT NOT NULL DEFAULT 0,
+    product_id INT REFERENCES products(id) ON DELETE CASCADE,
+    category_id INT REFERENCES categories(id) ON DELETE CASCADE,
+    buy_qty INT NOT NULL DEFAULT 0,
+    get_qty INT NOT NULL DEFAULT 0,
+    min_spend INT NOT NULL DEFAULT 0,
+    priority INT NOT NULL DEFAULT 0,
+    stackable BOOLEAN NOT NULL DEFAULT FALSE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_promotions_active ON promotions(active, starts_at, ends_at);
+
+ALTER TABLE transactions
+    ADD COLUMN gross_amount INT NOT NULL DEFAULT 0,
+    ADD COLUMN discount_amount INT NOT NULL DEFAULT 0;
+UPDATE transactions SET gross_amount = total_amount;
+
+ALTER TABLE transaction_details
+    ADD COLUMN gross_amount INT NOT NULL DEFAULT 0,
+    ADD COLUMN discount_amount INT NOT NULL DEFAULT 0;
+UPDATE transaction_details SET gross_amount = subtotal;
+
+-- Promotion name and amount are copied so history survives the promotion being deleted
+CREATE TABLE IF NOT EXISTS transaction_promotions (
+    id SERIAL PRIMARY KEY,
+    transaction_id INT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    promotion_id INT REFERENCES promotions(id) ON DELETE SET NULL,
+    name VARCHAR(255) NOT NULL,
+    amount INT NOT NULL
+);
+
+CREATE INDEX idx_transaction_promotions_transaction_id ON transaction_promotions(transaction_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS transaction_promotions;
+ALTER TABLE transaction_details
+    DROP COLUMN IF EXISTS discount_amount,
+    DROP COLUMN IF EXISTS gross_amount;
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS discount_amount,
+    DROP COLUMN IF EXISTS gross_amount;
+DROP TABLE IF EXISTS promotions;
@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE products
+    ADD COLUMN tax_exempt BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN tax_inclusive BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE transactions ADD COLUMN tax_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN tax_amount INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE products
+    DROP COLUMN IF EXISTS tax_inclusive,
+    DROP COLUMN IF EXISTS tax_exempt;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS carts (
+    id SERIAL PRIMARY KEY,
+    label VARCHAR(100) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'held' CHECK (status IN ('held', 'checking_out', 'checked_out')),
+    transaction_id INT REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_carts_status_expires_at ON carts(status, expires_at);
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    cart_id INT NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    position INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (cart_id, product_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS cart_items;
+DROP INDEX IF EXISTS idx_carts_status_expires_at;
+DROP TABLE IF EXISTS carts;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- One row per store and business day; checkout increments it inside its own
+-- transaction, so the row lock serialises numbering and a rollback leaves no gap
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    store_code VARCHAR(50) NOT NULL,
+    business_date DATE NOT NULL,
+    last_value INT NOT NULL,
+    PRIMARY KEY (store_code, business_date)
+);
+
+-- Transactions created before numbering existed keep a NULL invoice number
+ALTER TABLE transactions ADD COLUMN invoice_number VARCHAR(100);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_invoice_number ON transactions (invoice_number);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_transactions_invoice_number;
+ALTER TABLE transactions DROP COLUMN IF EXISTS invoice_number;
+DROP TABLE IF EXISTS invoice_sequences;
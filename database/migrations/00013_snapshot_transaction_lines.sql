@@ -0,0 +1,33 @@
+-- +goose Up
+ALTER TABLE products ADD COLUMN cost INT NOT NULL DEFAULT 0 CHECK (cost >= 0);
+
+-- Lines keep what was sold as it was at sale time, independent of later product edits
+ALTER TABLE transaction_details
+    ADD COLUMN product_name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN category_id INT,
+    ADD COLUMN category_name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN unit_price INT NOT NULL DEFAULT 0,
+    ADD COLUMN unit_cost INT NOT NULL DEFAULT 0;
+
+-- Backfill existing lines. The unit price comes from the line's own gross amount;
+-- name and category are the best record left, the product as it is now. Cost was
+-- never recorded, so it stays 0 for old sales.
+UPDATE transaction_details td
+SET product_name = COALESCE(p.name, ''),
+    category_id = p.category_id,
+    category_name = COALESCE(c.name, ''),
+    unit_price = CASE WHEN td.quantity > 0 THEN td.gross_amount / td.quantity ELSE 0 END
+FROM transaction_details d
+LEFT JOIN products p ON d.product_id = p.id
+LEFT JOIN categories c ON p.category_id = c.id
+WHERE td.id = d.id;
+
+-- +goose Down
+ALTER TABLE transaction_details
+    DROP COLUMN IF EXISTS unit_cost,
+    DROP COLUMN IF EXISTS unit_price,
+    DROP COLUMN IF EXISTS category_name,
+    DROP COLUMN IF EXISTS category_id,
+    DROP COLUMN IF EXISTS product_name;
+
+ALTER TABLE products DROP COLUMN IF EXISTS cost;
@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS shifts (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+    opened_by VARCHAR(255) NOT NULL,
+    opening_float INT NOT NULL CHECK (opening_float >= 0),
+    opened_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    closed_by VARCHAR(255),
+    closed_at TIMESTAMP,
+    counted_cash INT,
+    expected_cash INT,
+    note TEXT
+);
+
+-- Only one shift can be open at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_open ON shifts (status) WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS cash_movements (
+    id SERIAL PRIMARY KEY,
+    shift_id INT NOT NULL REFERENCES shifts(id) ON DELETE CASCADE,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('in', 'out')),
+    amount INT NOT NULL CHECK (amount > 0),
+    reason VARCHAR(500) NOT NULL,
+    performed_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cash_movements_shift_id ON cash_movements (shift_id);
+
+-- Transactions created before shifts existed keep a NULL shift
+ALTER TABLE transactions ADD COLUMN shift_id INT REFERENCES shifts(id);
+CREATE INDEX IF NOT EXISTS idx_transactions_shift_id ON transactions (shift_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_transactions_shift_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS shift_id;
+DROP TABLE IF EXISTS cash_movements;
+DROP TABLE IF EXISTS shifts;
@@ -0,0 +1,47 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS customers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    phone VARCHAR(20),
+    email VARCHAR(255),
+    member_code VARCHAR(50),
+    notes TEXT NOT NULL DEFAULT '',
+    points INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    -- Set when the customer's personal data is erased on request; the row stays
+    -- so transactions and the points ledger keep pointing at it
+    erased_at TIMESTAMP
+);
+
+-- Erasing sets phone and member code to NULL, which frees them for reuse
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_phone ON customers (phone);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_member_code ON customers (member_code);
+
+-- Every change to customers.points, with the balance after it
+CREATE TABLE IF NOT EXISTS loyalty_ledger (
+    id SERIAL PRIMARY KEY,
+    customer_id INT NOT NULL REFERENCES customers(id),
+    transaction_id INT REFERENCES transactions(id),
+    type VARCHAR(20) NOT NULL CHECK (type IN ('earn', 'redeem', 'reversal')),
+    points INT NOT NULL,
+    balance INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_customer_id ON loyalty_ledger (customer_id);
+
+ALTER TABLE transactions ADD COLUMN customer_id INT REFERENCES customers(id);
+ALTER TABLE transactions ADD COLUMN points_earned INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN points_redeemed INT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN points_discount INT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_transactions_customer_id ON transactions (customer_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_transactions_customer_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS points_discount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS points_redeemed;
+ALTER TABLE transactions DROP COLUMN IF EXISTS points_earned;
+ALTER TABLE transactions DROP COLUMN IF EXISTS customer_id;
+DROP TABLE IF EXISTS loyalty_ledger;
+DROP TABLE IF EXISTS customers;
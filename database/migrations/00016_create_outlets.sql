@@ -0,0 +1,56 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS outlets (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    address VARCHAR(500) NOT NULL DEFAULT '',
+    phone VARCHAR(20) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outlets_code ON outlets (code);
+
+-- Everything that exists today belongs to the default outlet
+INSERT INTO outlets (id, code, name) VALUES (1, 'MAIN', 'Main');
+SELECT setval(pg_get_serial_sequence('outlets', 'id'), (SELECT MAX(id) FROM outlets));
+
+-- Every product has a row at every outlet: creating a product or an outlet fills in
+-- the missing pairs, so checkout can lock the stock row it sells from
+CREATE TABLE IF NOT EXISTS product_stocks (
+    outlet_id INT NOT NULL REFERENCES outlets(id),
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    PRIMARY KEY (outlet_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_stocks_product_id ON product_stocks (product_id);
+
+INSERT INTO product_stocks (outlet_id, product_id, stock) SELECT 1, id, stock FROM products;
+ALTER TABLE products DROP COLUMN stock;
+
+ALTER TABLE transactions ADD COLUMN outlet_id INT NOT NULL DEFAULT 1 REFERENCES outlets(id);
+ALTER TABLE transactions ALTER COLUMN outlet_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS idx_transactions_outlet_id ON transactions (outlet_id);
+
+-- Each outlet runs its own till, so one shift can be open per outlet
+ALTER TABLE shifts ADD COLUMN outlet_id INT NOT NULL DEFAULT 1 REFERENCES outlets(id);
+ALTER TABLE shifts ALTER COLUMN outlet_id DROP DEFAULT;
+DROP INDEX IF EXISTS idx_shifts_open;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_open ON shifts (outlet_id) WHERE status = 'open';
+
+-- +goose Down
+-- Only the default outlet's stock survives the rollback
+DROP INDEX IF EXISTS idx_shifts_open;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_open ON shifts (status) WHERE status = 'open';
+ALTER TABLE shifts DROP COLUMN IF EXISTS outlet_id;
+
+DROP INDEX IF EXISTS idx_transactions_outlet_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS outlet_id;
+
+ALTER TABLE products ADD COLUMN stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0);
+UPDATE products p SET stock = ps.stock FROM product_stocks ps WHERE ps.product_id = p.id AND ps.outlet_id = 1;
+ALTER TABLE products ALTER COLUMN stock DROP DEFAULT;
+
+DROP TABLE IF EXISTS product_stocks;
+DROP TABLE IF EXISTS outlets;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'cashier'
+    CHECK (role IN ('owner', 'manager', 'cashier', 'stock_clerk'));
+
+-- Someone has to be able to hand out roles: the first user becomes the owner
+UPDATE users SET role = 'owner' WHERE id = (SELECT MIN(id) FROM users);
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- POS devices sign in with an API key bound to an outlet. A device is created with
+-- a short-lived pairing code, which the device exchanges once for its key. Only
+-- SHA-256 hashes of codes and keys are stored.
+CREATE TABLE IF NOT EXISTS devices (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    outlet_id INT NOT NULL REFERENCES outlets(id),
+    pairing_code_hash VARCHAR(64),
+    pairing_expires_at TIMESTAMP,
+    key_hash VARCHAR(64),
+    key_prefix VARCHAR(16),
+    paired_at TIMESTAMP,
+    last_seen_at TIMESTAMP,
+    request_count BIGINT NOT NULL DEFAULT 0,
+    revoked_at TIMESTAMP,
+    created_by VARCHAR(50),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_devices_pairing_code_hash ON devices (pairing_code_hash) WHERE pairing_code_hash IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_devices_key_hash ON devices (key_hash) WHERE key_hash IS NOT NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS devices;
@@ -0,0 +1,36 @@
+-- +goose Up
+-- Every write appends an entry here in the same transaction as the change: who
+-- made it, in which request, and the JSON of the fields before and after. The log
+-- is append-only; the trigger below rejects any UPDATE or DELETE.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor_type VARCHAR(20) NOT NULL,
+    actor_id INT,
+    actor VARCHAR(100),
+    request_id VARCHAR(100),
+    entity VARCHAR(30) NOT NULL,
+    entity_id INT NOT NULL,
+    action VARCHAR(30) NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log (entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_occurred_at ON audit_log (occurred_at);
+CREATE INDEX IF NOT EXISTS idx_audit_log_request_id ON audit_log (request_id) WHERE request_id IS NOT NULL;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
@@ -0,0 +1,30 @@
+-- +goose Up
+-- The stock ledger: every change to product_stocks is posted here in the same
+-- transaction, with the signed quantity, the stock after it and the document it
+-- was posted for, so product_stocks.stock is always the sum of its movements.
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    outlet_id INT NOT NULL REFERENCES outlets(id),
+    type VARCHAR(20) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity <> 0),
+    balance INT NOT NULL CHECK (balance >= 0),
+    reference_type VARCHAR(20),
+    reference_id INT,
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    actor VARCHAR(100),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product ON stock_movements (product_id, outlet_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_reference ON stock_movements (reference_type, reference_id) WHERE reference_id IS NOT NULL;
+
+-- Stock held before the ledger existed becomes its opening balance
+INSERT INTO stock_movements (product_id, outlet_id, type, quantity, balance, note)
+SELECT product_id, outlet_id, 'adjustment', stock, stock, 'opening balance'
+FROM product_stocks
+WHERE stock > 0
+ORDER BY outlet_id, product_id;
+
+-- +goose Down
+DROP TABLE IF EXISTS stock_movements;
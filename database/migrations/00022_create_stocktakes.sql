@@ -0,0 +1,39 @@
+-- +goose Up
+-- Adjustments and write-offs carry a reason code
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS reason VARCHAR(30);
+
+-- A stocktake (stock opname) counts the stock of an outlet, or of one category at
+-- it. Each line keeps the system stock when the stocktake opened; approving posts
+-- counted - snapshot as an adjustment, so sales made during the count still count.
+CREATE TABLE IF NOT EXISTS stocktakes (
+    id SERIAL PRIMARY KEY,
+    outlet_id INT NOT NULL REFERENCES outlets(id),
+    category_id INT REFERENCES categories(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'approved', 'cancelled')),
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    opened_by VARCHAR(100),
+    opened_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_by VARCHAR(100),
+    closed_at TIMESTAMP
+);
+
+-- Only one stocktake can be open at an outlet
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stocktakes_open ON stocktakes (outlet_id) WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS stocktake_lines (
+    stocktake_id INT NOT NULL REFERENCES stocktakes(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    product_name VARCHAR(255) NOT NULL,
+    unit_cost INT NOT NULL DEFAULT 0,
+    snapshot_stock INT NOT NULL,
+    counted_quantity INT CHECK (counted_quantity >= 0),
+    reason VARCHAR(30),
+    counted_by VARCHAR(100),
+    counted_at TIMESTAMP,
+    PRIMARY KEY (stocktake_id, product_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS stocktake_lines;
+DROP TABLE IF EXISTS stocktakes;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS reason;
@@ -0,0 +1,71 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS suppliers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    contact_name VARCHAR(255) NOT NULL DEFAULT '',
+    phone VARCHAR(20) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    address VARCHAR(500) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A purchase order is placed by one outlet, which receives the goods
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id SERIAL PRIMARY KEY,
+    supplier_id INT NOT NULL REFERENCES suppliers(id),
+    outlet_id INT NOT NULL REFERENCES outlets(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'sent', 'partially_received', 'closed')),
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    created_by VARCHAR(100),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP,
+    closed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_outlet ON purchase_orders (outlet_id, status);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier ON purchase_orders (supplier_id);
+
+-- received_quantity is the sum of the goods receipt items and may exceed the
+-- ordered quantity when an over-delivery was accepted
+CREATE TABLE IF NOT EXISTS purchase_order_items (
+    purchase_order_id INT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    product_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    unit_cost INT NOT NULL DEFAULT 0 CHECK (unit_cost >= 0),
+    received_quantity INT NOT NULL DEFAULT 0 CHECK (received_quantity >= 0),
+    PRIMARY KEY (purchase_order_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_order_items_product ON purchase_order_items (product_id);
+
+-- Each delivery against an order; its items are posted to the stock ledger as
+-- receipt movements referencing the goods receipt
+CREATE TABLE IF NOT EXISTS goods_receipts (
+    id SERIAL PRIMARY KEY,
+    purchase_order_id INT NOT NULL REFERENCES purchase_orders(id),
+    outlet_id INT NOT NULL REFERENCES outlets(id),
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    received_by VARCHAR(100),
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_goods_receipts_order ON goods_receipts (purchase_order_id);
+
+CREATE TABLE IF NOT EXISTS goods_receipt_items (
+    goods_receipt_id INT NOT NULL REFERENCES goods_receipts(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    quantity INT NOT NULL CHECK (quantity > 0),
+    unit_cost INT NOT NULL DEFAULT 0 CHECK (unit_cost >= 0),
+    over_delivered INT NOT NULL DEFAULT 0 CHECK (over_delivered >= 0),
+    PRIMARY KEY (goods_receipt_id, product_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS goods_receipt_items;
+DROP TABLE IF EXISTS goods_receipts;
+DROP TABLE IF EXISTS purchase_order_items;
+DROP TABLE IF EXISTS purchase_orders;
+DROP TABLE IF EXISTS suppliers;
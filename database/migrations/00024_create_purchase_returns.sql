@@ -0,0 +1,34 @@
+-- +goose Up
+-- Goods sent back to a supplier from one outlet. The returned items are posted to
+-- the stock ledger as purchase_return movements referencing the return; the
+-- supplier owes their value as credit until credit_received covers it.
+CREATE TABLE IF NOT EXISTS purchase_returns (
+    id SERIAL PRIMARY KEY,
+    supplier_id INT NOT NULL REFERENCES suppliers(id),
+    outlet_id INT NOT NULL REFERENCES outlets(id),
+    goods_receipt_id INT REFERENCES goods_receipts(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'credited')),
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    credit_received INT NOT NULL DEFAULT 0 CHECK (credit_received >= 0),
+    created_by VARCHAR(100),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    credited_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_returns_outlet ON purchase_returns (outlet_id, status);
+CREATE INDEX IF NOT EXISTS idx_purchase_returns_supplier ON purchase_returns (supplier_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_returns_receipt ON purchase_returns (goods_receipt_id);
+
+CREATE TABLE IF NOT EXISTS purchase_return_items (
+    purchase_return_id INT NOT NULL REFERENCES purchase_returns(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id),
+    product_name VARCHAR(255) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    unit_cost INT NOT NULL DEFAULT 0 CHECK (unit_cost >= 0),
+    reason VARCHAR(20) NOT NULL,
+    PRIMARY KEY (purchase_return_id, product_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS purchase_return_items;
+DROP TABLE IF EXISTS purchase_returns;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- A product needs reordering once its stock at an outlet is at or below its
+-- reorder point; 0 turns alerts off. reorder_qty is the quantity usually ordered.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_point INT NOT NULL DEFAULT 0 CHECK (reorder_point >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_qty INT NOT NULL DEFAULT 0 CHECK (reorder_qty >= 0);
+
+-- One row per checkout that took a product's stock at an outlet to its reorder
+-- point, with the product as it was at the time
+CREATE TABLE IF NOT EXISTS stock_alerts (
+    id SERIAL PRIMARY KEY,
+    outlet_id INT NOT NULL REFERENCES outlets(id),
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    product_name VARCHAR(255) NOT NULL,
+    stock INT NOT NULL,
+    reorder_point INT NOT NULL,
+    reorder_qty INT NOT NULL,
+    transaction_id INT REFERENCES transactions(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_outlet ON stock_alerts (outlet_id, id);
+
+-- +goose Down
+DROP TABLE IF EXISTS stock_alerts;
+ALTER TABLE products DROP COLUMN IF EXISTS reorder_qty;
+ALTER TABLE products DROP COLUMN IF EXISTS reorder_point;
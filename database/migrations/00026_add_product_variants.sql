@@ -0,0 +1,28 @@
+-- +goose Up
+-- A variant is a product of its own with a parent, so it keeps its own price,
+-- stock and ledger. options on the parent list the dimensions its variants differ
+-- in; attributes on a variant give its value for each of them.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES products(id) ON DELETE CASCADE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS barcode VARCHAR(64);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS options JSONB;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS attributes JSONB;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_barcode ON products (barcode);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_variant_attributes ON products (parent_id, attributes) WHERE parent_id IS NOT NULL;
+
+-- Sales of a variant roll up to its parent in reports
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS parent_product_id INT;
+
+-- +goose Down
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS parent_product_id;
+DROP INDEX IF EXISTS idx_products_variant_attributes;
+DROP INDEX IF EXISTS idx_products_barcode;
+DROP INDEX IF EXISTS idx_products_sku;
+DELETE FROM products WHERE parent_id IS NOT NULL;
+ALTER TABLE products DROP COLUMN IF EXISTS attributes;
+ALTER TABLE products DROP COLUMN IF EXISTS options;
+ALTER TABLE products DROP COLUMN IF EXISTS barcode;
+ALTER TABLE products DROP COLUMN IF EXISTS sku;
+ALTER TABLE products DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- The PPN refunded by a return, so tax reports can net it out. Like the amounts,
+-- it is negative and prorated from the line returned.
+ALTER TABLE return_items ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0;
+ALTER TABLE returns ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0;
+
+UPDATE return_items ri
+SET tax_amount = -(td.tax_amount * ri.quantity / td.quantity)
+FROM transaction_details td
+WHERE td.id = ri.transaction_detail_id AND td.quantity > 0;
+
+UPDATE returns r
+SET tax_amount = ri.tax_amount
+FROM (SELECT return_id, SUM(tax_amount) AS tax_amount FROM return_items GROUP BY return_id) ri
+WHERE ri.return_id = r.id;
+
+-- +goose Down
+ALTER TABLE returns DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE return_items DROP COLUMN IF EXISTS tax_amount;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Deleting a product or category no longer silently deletes its promotions, which
+-- left no audit entry. A product with promotions is deactivated instead, and a
+-- category with promotions cannot be deleted until they are.
+ALTER TABLE promotions DROP CONSTRAINT IF EXISTS promotions_product_id_fkey;
+ALTER TABLE promotions ADD CONSTRAINT promotions_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE RESTRICT;
+ALTER TABLE promotions DROP CONSTRAINT IF EXISTS promotions_category_id_fkey;
+ALTER TABLE promotions ADD CONSTRAINT promotions_category_id_fkey
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE RESTRICT;
+
+-- +goose Down
+ALTER TABLE promotions DROP CONSTRAINT IF EXISTS promotions_category_id_fkey;
+ALTER TABLE promotions ADD CONSTRAINT promotions_category_id_fkey
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE;
+ALTER TABLE promotions DROP CONSTRAINT IF EXISTS promotions_product_id_fkey;
+ALTER TABLE promotions ADD CONSTRAINT promotions_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Sales of a variant roll up in reports under the parent's name at the time of
+-- sale. Lines sold before this fall back to the parent's current name.
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS parent_product_name VARCHAR(255);
+
+UPDATE transaction_details td
+SET parent_product_name = p.name
+FROM products p
+WHERE p.id = td.parent_product_id AND td.parent_product_name IS NULL;
+
+-- +goose Down
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS parent_product_name;
@@ -7,14 +7,40 @@ INSERT INTO categories (name, description) VALUES
 ON CONFLICT DO NOTHING;
 
 -- Seed products
-INSERT INTO products (name, price, stock, category_id) VALUES
-    ('Indomie Goreng', 3500, 100, (SELECT id FROM categories WHERE name = 'Food' LIMIT 1)),
-    ('Indomie Soto', 3500, 100, (SELECT id FROM categories WHERE name = 'Food' LIMIT 1)),
-    ('Chitato', 12000, 50, (SELECT id FROM categories WHERE name = 'Food' LIMIT 1)),
-    ('Coca Cola 330ml', 5000, 80, (SELECT id FROM categories WHERE name = 'Beverage' LIMIT 1)),
-    ('Aqua 600ml', 3000, 120, (SELECT id FROM categories WHERE name = 'Beverage' LIMIT 1)),
-    ('USB Cable Type-C', 25000, 30, (SELECT id FROM categories WHERE name = 'Electronics' LIMIT 1)),
-    ('Power Bank 10000mAh', 150000, 15, (SELECT id FROM categories WHERE name = 'Electronics' LIMIT 1)),
-    ('Ballpoint Pen', 2500, 200, (SELECT id FROM categories WHERE name = 'Stationery' LIMIT 1)),
-    ('Notebook A5', 15000, 50, (SELECT id FROM categories WHERE name = 'Stationery' LIMIT 1))
+INSERT INTO products (name, price, cost, category_id) VALUES
+    ('Indomie Goreng', 3500, 2800, (SELECT id FROM categories WHERE name = 'Food' LIMIT 1)),
+    ('Indomie Soto', 3500, 2800, (SELECT id FROM categories WHERE name = 'Food' LIMIT 1)),
+    ('Chitato', 12000, 9500, (SELECT id FROM categories WHERE name = 'Food' LIMIT 1)),
+    ('Coca Cola 330ml', 5000, 3800, (SELECT id FROM categories WHERE name = 'Beverage' LIMIT 1)),
+    ('Aqua 600ml', 3000, 2200, (SELECT id FROM categories WHERE name = 'Beverage' LIMIT 1)),
+    ('USB Cable Type-C', 25000, 15000, (SELECT id FROM categories WHERE name = 'Electronics' LIMIT 1)),
+    ('Power Bank 10000mAh', 150000, 110000, (SELECT id FROM categories WHERE name = 'Electronics' LIMIT 1)),
+    ('Ballpoint Pen', 2500, 1500, (SELECT id FROM categories WHERE name = 'Stationery' LIMIT 1)),
+    ('Notebook A5', 15000, 10000, (SELECT id FROM categories WHERE name = 'Stationery' LIMIT 1))
 ON CONFLICT DO NOTHING;
+
+-- Seed stock at the default outlet; every other outlet starts empty
+INSERT INTO product_stocks (outlet_id, product_id, stock)
+SELECT o.id, p.id, CASE WHEN o.id = 1 THEN s.stock ELSE 0 END
+FROM (VALUES
+    ('Indomie Goreng', 100),
+    ('Indomie Soto', 100),
+    ('Chitato', 50),
+    ('Coca Cola 330ml', 80),
+    ('Aqua 600ml', 120),
+    ('USB Cable Type-C', 30),
+    ('Power Bank 10000mAh', 15),
+    ('Ballpoint Pen', 200),
+    ('Notebook A5', 50)
+) AS s (name, stock)
+JOIN products p ON p.name = s.name
+CROSS JOIN outlets o
+ON CONFLICT DO NOTHING;
+
+-- Post the seeded stock to the ledger as opening balances
+INSERT INTO stock_movements (product_id, outlet_id, type, quantity, balance, note)
+SELECT ps.product_id, ps.outlet_id, 'adjustment', ps.stock, ps.stock, 'opening balance'
+FROM product_stocks ps
+WHERE ps.stock > 0
+  AND NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.outlet_id = ps.outlet_id AND m.product_id = ps.product_id)
+ORDER BY ps.outlet_id, ps.product_id;
@@ -2,10 +2,13 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"strings"
 	"time"
 
+	"kasir-api/internal/model"
+
 	"github.com/knadh/koanf/parsers/dotenv"
 	"github.com/knadh/koanf/providers/env/v2"
 	"github.com/knadh/koanf/providers/file"
@@ -13,8 +16,17 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Idempotency IdempotencyConfig
+	Tax         TaxConfig
+	Cart        CartConfig
+	Store       StoreConfig
+	Receipt     ReceiptConfig
+	Invoice     InvoiceConfig
+	Loyalty     LoyaltyConfig
+	Auth        AuthConfig
+	Alerts      AlertsConfig
 }
 
 type ServerConfig struct {
@@ -38,6 +50,72 @@ type DatabaseConfig struct {
 	ConnTimeout     int // in seconds
 }
 
+type IdempotencyConfig struct {
+	TTL time.Duration // how long an Idempotency-Key is remembered
+}
+
+type TaxConfig struct {
+	Rate float64 // store-wide PPN in percent, e.g. 11; 0 disables tax
+}
+
+type CartConfig struct {
+	TTL time.Duration // how long a held cart is kept without changes before it expires
+}
+
+// StoreConfig identifies the store on receipts
+type StoreConfig struct {
+	Code    string // short code used in invoice numbers
+	Name    string
+	Address string
+	Phone   string
+}
+
+type ReceiptConfig struct {
+	Footer      string // printed at the bottom of every receipt
+	TemplateDir string // directory with receipt.txt.tmpl / receipt.html.tmpl overriding the defaults
+}
+
+type InvoiceConfig struct {
+	Pattern string // e.g. INV/{STORE}/{YYYYMMDD}/{SEQ:5}
+}
+
+type LoyaltyConfig struct {
+	SpendPerPoint int // rupiah spent per point earned; 0 disables earning
+	PointValue    int // rupiah discount per redeemed point; 0 disables redemption
+}
+
+type AuthConfig struct {
+	Secret          string        // HMAC key for signing access tokens; a random one is used when empty
+	AccessTTL       time.Duration // how long an access token is valid
+	RefreshTTL      time.Duration // how long a session may go without being refreshed
+	MaxFailedLogins int           // failed logins in a row that lock an account
+	LockoutDuration time.Duration // how long a locked account stays locked
+	AdminUsername   string        // first user created when there are no users yet
+	AdminPassword   string
+	PairingCodeTTL  time.Duration // how long a device pairing code can be used
+}
+
+// AlertsConfig controls where low stock alerts go besides the log
+type AlertsConfig struct {
+	WebhookURL     string        // receives a POST for every checkout that leaves products low; empty disables it
+	WebhookTimeout time.Duration // how long to wait for the webhook to respond
+}
+
+// LockoutPolicy returns the account lockout policy applied at login
+func (c AuthConfig) LockoutPolicy() model.LockoutPolicy {
+	return model.LockoutPolicy{MaxFailedLogins: c.MaxFailedLogins, Duration: c.LockoutDuration}
+}
+
+// Rule returns the loyalty rule applied at checkout
+func (c LoyaltyConfig) Rule() model.LoyaltyRule {
+	return model.LoyaltyRule{SpendPerPoint: c.SpendPerPoint, PointValue: c.PointValue}
+}
+
+// RateBasisPoints returns the rate in basis points so tax can be computed with integers
+func (c TaxConfig) RateBasisPoints() int {
+	return int(math.Round(c.Rate * 100))
+}
+
 func Load() (*Config, error) {
 	k := koanf.New(".")
 
@@ -83,6 +161,46 @@ func Load() (*Config, error) {
 			ConnMaxIdleTime: k.Int("database.connmaxidletime"),
 			ConnTimeout:     k.Int("database.conntimeout"),
 		},
+		Idempotency: IdempotencyConfig{
+			TTL: k.Duration("idempotency.ttl"),
+		},
+		Tax: TaxConfig{
+			Rate: k.Float64("tax.rate"),
+		},
+		Cart: CartConfig{
+			TTL: k.Duration("cart.ttl"),
+		},
+		Store: StoreConfig{
+			Code:    k.String("store.code"),
+			Name:    k.String("store.name"),
+			Address: k.String("store.address"),
+			Phone:   k.String("store.phone"),
+		},
+		Receipt: ReceiptConfig{
+			Footer:      k.String("receipt.footer"),
+			TemplateDir: k.String("receipt.templatedir"),
+		},
+		Invoice: InvoiceConfig{
+			Pattern: k.String("invoice.pattern"),
+		},
+		Loyalty: LoyaltyConfig{
+			SpendPerPoint: k.Int("loyalty.spendperpoint"),
+			PointValue:    k.Int("loyalty.pointvalue"),
+		},
+		Auth: AuthConfig{
+			Secret:          k.String("auth.secret"),
+			AccessTTL:       k.Duration("auth.accessttl"),
+			RefreshTTL:      k.Duration("auth.refreshttl"),
+			MaxFailedLogins: k.Int("auth.maxfailedlogins"),
+			LockoutDuration: k.Duration("auth.lockoutduration"),
+			AdminUsername:   k.String("auth.adminusername"),
+			AdminPassword:   k.String("auth.adminpassword"),
+			PairingCodeTTL:  k.Duration("auth.pairingcodettl"),
+		},
+		Alerts: AlertsConfig{
+			WebhookURL:     k.String("alerts.webhookurl"),
+			WebhookTimeout: k.Duration("alerts.webhooktimeout"),
+		},
 	}
 
 	setDefaults(cfg)
@@ -123,4 +241,34 @@ func setDefaults(cfg *Config) {
 	if cfg.Database.ConnTimeout == 0 {
 		cfg.Database.ConnTimeout = 30 // 30 seconds
 	}
+	if cfg.Idempotency.TTL == 0 {
+		cfg.Idempotency.TTL = 24 * time.Hour
+	}
+	if cfg.Cart.TTL == 0 {
+		cfg.Cart.TTL = 12 * time.Hour
+	}
+	if cfg.Store.Code == "" {
+		cfg.Store.Code = model.DefaultStoreCode
+	}
+	if cfg.Invoice.Pattern == "" {
+		cfg.Invoice.Pattern = model.DefaultInvoicePattern
+	}
+	if cfg.Auth.AccessTTL == 0 {
+		cfg.Auth.AccessTTL = 15 * time.Minute
+	}
+	if cfg.Auth.RefreshTTL == 0 {
+		cfg.Auth.RefreshTTL = 30 * 24 * time.Hour
+	}
+	if cfg.Auth.MaxFailedLogins == 0 {
+		cfg.Auth.MaxFailedLogins = 5
+	}
+	if cfg.Auth.LockoutDuration == 0 {
+		cfg.Auth.LockoutDuration = 15 * time.Minute
+	}
+	if cfg.Auth.PairingCodeTTL == 0 {
+		cfg.Auth.PairingCodeTTL = 10 * time.Minute
+	}
+	if cfg.Alerts.WebhookTimeout == 0 {
+		cfg.Alerts.WebhookTimeout = 5 * time.Second
+	}
 }
@@ -26,6 +26,27 @@ func TestLoad_Defaults(t *testing.T) {
 	if cfg.Database.SSLMode != "require" {
 		t.Errorf("Database.SSLMode = %v, want require", cfg.Database.SSLMode)
 	}
+	if cfg.Idempotency.TTL != 24*time.Hour {
+		t.Errorf("Idempotency.TTL = %v, want 24h", cfg.Idempotency.TTL)
+	}
+	if cfg.Cart.TTL != 12*time.Hour {
+		t.Errorf("Cart.TTL = %v, want 12h", cfg.Cart.TTL)
+	}
+	if cfg.Invoice.Pattern != "INV/{STORE}/{YYYYMMDD}/{SEQ:5}" || cfg.Store.Code != "MAIN" {
+		t.Errorf("Invoice.Pattern = %v, Store.Code = %v, want the defaults", cfg.Invoice.Pattern, cfg.Store.Code)
+	}
+	if cfg.Auth.AccessTTL != 15*time.Minute || cfg.Auth.RefreshTTL != 30*24*time.Hour {
+		t.Errorf("Auth TTLs = %v, %v, want 15m and 720h", cfg.Auth.AccessTTL, cfg.Auth.RefreshTTL)
+	}
+	if p := cfg.Auth.LockoutPolicy(); p.MaxFailedLogins != 5 || p.Duration != 15*time.Minute {
+		t.Errorf("Auth.LockoutPolicy() = %+v, want 5 failures locking for 15m", p)
+	}
+	if cfg.Auth.PairingCodeTTL != 10*time.Minute {
+		t.Errorf("Auth.PairingCodeTTL = %v, want 10m", cfg.Auth.PairingCodeTTL)
+	}
+	if cfg.Alerts.WebhookURL != "" || cfg.Alerts.WebhookTimeout != 5*time.Second {
+		t.Errorf("Alerts = %+v, want no webhook and a 5s timeout", cfg.Alerts)
+	}
 }
 
 func TestLoad_FromEnv(t *testing.T) {
@@ -37,6 +58,10 @@ func TestLoad_FromEnv(t *testing.T) {
 	t.Setenv("APP_DATABASE_PASSWORD", "testpass")
 	t.Setenv("APP_DATABASE_DBNAME", "testdb")
 	t.Setenv("APP_DATABASE_MAXCONNS", "50")
+	t.Setenv("APP_TAX_RATE", "11")
+	t.Setenv("APP_AUTH_SECRET", "s3cret")
+	t.Setenv("APP_AUTH_ACCESSTTL", "5m")
+	t.Setenv("APP_AUTH_MAXFAILEDLOGINS", "3")
 
 	cfg, err := Load()
 	if err != nil {
@@ -61,4 +86,10 @@ func TestLoad_FromEnv(t *testing.T) {
 	if cfg.Database.MaxConns != 50 {
 		t.Errorf("Database.MaxConns = %v, want 50", cfg.Database.MaxConns)
 	}
+	if cfg.Tax.RateBasisPoints() != 1100 {
+		t.Errorf("Tax.RateBasisPoints() = %v, want 1100", cfg.Tax.RateBasisPoints())
+	}
+	if cfg.Auth.Secret != "s3cret" || cfg.Auth.AccessTTL != 5*time.Minute || cfg.Auth.MaxFailedLogins != 3 {
+		t.Errorf("Auth = %+v, want the secret, a 5m access TTL and 3 failed logins", cfg.Auth)
+	}
 }
@@ -0,0 +1,11 @@
+package dto
+
+import "kasir-api/internal/model"
+
+// AuditListResponse represents a paginated page of audit entries for API responses
+type AuditListResponse struct {
+	Data  []model.AuditEntry `json:"data"`
+	Page  int                `json:"page"`
+	Limit int                `json:"limit"`
+	Total int                `json:"total"`
+}
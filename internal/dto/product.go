@@ -9,10 +9,40 @@ type CategoryResponse struct {
 
 // ProductResponse represents product data with category information for API responses
 type ProductResponse struct {
-	ID       int               `json:"id"`
-	Name     string            `json:"name"`
-	Price    int               `json:"price"`
-	Stock    int               `json:"stock"`
-	Active   bool              `json:"active"`
-	Category *CategoryResponse `json:"category,omitempty"`
+	ID           int                      `json:"id"`
+	Name         string                   `json:"name"`
+	Price        int                      `json:"price"`
+	Cost         int                      `json:"cost"`
+	Stock        int                      `json:"stock"`
+	Active       bool                     `json:"active"`
+	TaxExempt    bool                     `json:"tax_exempt"`
+	TaxInclusive bool                     `json:"tax_inclusive"`
+	ReorderPoint int                      `json:"reorder_point"`
+	ReorderQty   int                      `json:"reorder_qty"`
+	SKU          string                   `json:"sku,omitempty"`
+	Barcode      string                   `json:"barcode,omitempty"`
+	Options      []ProductOptionResponse  `json:"options,omitempty"`
+	ParentID     *int                     `json:"parent_id,omitempty"`
+	Attributes   map[string]string        `json:"attributes,omitempty"`
+	Variants     []ProductVariantResponse `json:"variants,omitempty"`
+	Category     *CategoryResponse        `json:"category,omitempty"`
+}
+
+// ProductOptionResponse represents a dimension a product's variants differ in
+type ProductOptionResponse struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// ProductVariantResponse represents a variant of a product; its stock is at the outlet of the request
+type ProductVariantResponse struct {
+	ID         int               `json:"id"`
+	Name       string            `json:"name"`
+	SKU        string            `json:"sku,omitempty"`
+	Barcode    string            `json:"barcode,omitempty"`
+	Attributes map[string]string `json:"attributes"`
+	Price      int               `json:"price"`
+	Cost       int               `json:"cost"`
+	Stock      int               `json:"stock"`
+	Active     bool              `json:"active"`
 }
@@ -0,0 +1,11 @@
+package dto
+
+import "kasir-api/internal/model"
+
+// StockMovementListResponse represents a paginated page of stock movements for API responses
+type StockMovementListResponse struct {
+	Data  []model.StockMovement `json:"data"`
+	Page  int                   `json:"page"`
+	Limit int                   `json:"limit"`
+	Total int                   `json:"total"`
+}
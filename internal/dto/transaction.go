@@ -0,0 +1,11 @@
+package dto
+
+import "kasir-api/internal/model"
+
+// TransactionListResponse represents a paginated page of transactions for API responses
+type TransactionListResponse struct {
+	Data  []model.Transaction `json:"data"`
+	Page  int                 `json:"page"`
+	Limit int                 `json:"limit"`
+	Total int                 `json:"total"`
+}
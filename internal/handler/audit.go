@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"kasir-api/internal/dto"
+	"kasir-api/internal/model"
+	"kasir-api/pkg/httputil"
+)
+
+type AuditService interface {
+	GetAll(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, int, error)
+}
+
+type AuditHandler struct {
+	svc AuditService
+}
+
+func NewAuditHandler(svc AuditService) *AuditHandler {
+	return &AuditHandler{svc: svc}
+}
+
+func (h *AuditHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	entries, total, err := h.svc.GetAll(r.Context(), filter)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	filter = filter.WithDefaults()
+	httputil.WriteJSON(w, http.StatusOK, dto.AuditListResponse{
+		Data:  entries,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	})
+}
+
+// parseAuditFilter reads the audit log filters and pagination from the query string
+func parseAuditFilter(r *http.Request) (model.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := model.AuditFilter{
+		Entity:    query.Get("entity"),
+		Action:    query.Get("action"),
+		Actor:     query.Get("actor"),
+		RequestID: query.Get("request_id"),
+		StartDate: query.Get("start_date"),
+		EndDate:   query.Get("end_date"),
+	}
+
+	var err error
+	if filter.EntityID, err = httputil.QueryInt(r, "entity_id"); err != nil {
+		return filter, err
+	}
+
+	page, err := httputil.QueryInt(r, "page")
+	if err != nil {
+		return filter, err
+	}
+	if page != nil {
+		filter.Page = *page
+	}
+
+	limit, err := httputil.QueryInt(r, "limit")
+	if err != nil {
+		return filter, err
+	}
+	if limit != nil {
+		filter.Limit = *limit
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kasir-api/internal/dto"
+	"kasir-api/internal/model"
+)
+
+type mockAuditService struct {
+	getAllFunc func(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, int, error)
+}
+
+func (m *mockAuditService) GetAll(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, int, error) {
+	return m.getAllFunc(ctx, filter)
+}
+
+func TestAuditHandler_GetAll(t *testing.T) {
+	var gotFilter model.AuditFilter
+	mockSvc := &mockAuditService{
+		getAllFunc: func(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, int, error) {
+			gotFilter = filter
+			return []model.AuditEntry{{ID: 9, Entity: model.AuditEntityProduct, EntityID: 3, Action: model.AuditActionUpdate}}, 1, nil
+		},
+	}
+
+	handler := NewAuditHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/api/audit?entity=product&entity_id=3&action=update&actor=siti&start_date=2026-01-01&page=2&limit=10", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetAll(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if gotFilter.Entity != "product" || gotFilter.Action != "update" || gotFilter.Actor != "siti" || gotFilter.StartDate != "2026-01-01" {
+		t.Errorf("Unexpected filter: %+v", gotFilter)
+	}
+	if gotFilter.EntityID == nil || *gotFilter.EntityID != 3 {
+		t.Errorf("EntityID = %v, want 3", gotFilter.EntityID)
+	}
+
+	var resp dto.AuditListResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Total != 1 || len(resp.Data) != 1 || resp.Data[0].ID != 9 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+	if resp.Page != 2 || resp.Limit != 10 {
+		t.Errorf("Expected page 2 limit 10, got page %d limit %d", resp.Page, resp.Limit)
+	}
+}
+
+func TestAuditHandler_GetAll_InvalidEntityID(t *testing.T) {
+	handler := NewAuditHandler(&mockAuditService{})
+	req := httptest.NewRequest(http.MethodGet, "/api/audit?entity=product&entity_id=abc", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetAll(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
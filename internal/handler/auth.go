@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/httputil"
+)
+
+type AuthService interface {
+	Login(ctx context.Context, req model.LoginRequest) (*model.TokenResponse, error)
+	Refresh(ctx context.Context, req model.RefreshRequest) (*model.TokenResponse, error)
+	Logout(ctx context.Context) error
+	Me(ctx context.Context) (*model.User, error)
+}
+
+type AuthHandler struct {
+	svc AuthService
+}
+
+func NewAuthHandler(svc AuthService) *AuthHandler {
+	return &AuthHandler{svc: svc}
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req model.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	resp, err := h.svc.Login(r.Context(), req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req model.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	resp, err := h.svc.Refresh(r.Context(), req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.Logout(r.Context()); err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	user, err := h.svc.Me(r.Context())
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, user)
+}
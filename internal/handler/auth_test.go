@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/middleware"
+)
+
+// Mock service for testing
+type mockAuthService struct {
+	loginFunc   func(ctx context.Context, req model.LoginRequest) (*model.TokenResponse, error)
+	refreshFunc func(ctx context.Context, req model.RefreshRequest) (*model.TokenResponse, error)
+	logoutFunc  func(ctx context.Context) error
+	meFunc      func(ctx context.Context) (*model.User, error)
+}
+
+func (m *mockAuthService) Login(ctx context.Context, req model.LoginRequest) (*model.TokenResponse, error) {
+	return m.loginFunc(ctx, req)
+}
+
+func (m *mockAuthService) Refresh(ctx context.Context, req model.RefreshRequest) (*model.TokenResponse, error) {
+	return m.refreshFunc(ctx, req)
+}
+
+func (m *mockAuthService) Logout(ctx context.Context) error {
+	return m.logoutFunc(ctx)
+}
+
+func (m *mockAuthService) Me(ctx context.Context) (*model.User, error) {
+	return m.meFunc(ctx)
+}
+
+// mockAuthenticator accepts a role name as the token and signs in with that role
+type mockAuthenticator struct{}
+
+func (mockAuthenticator) Authenticate(ctx context.Context, token string) (*middleware.Principal, error) {
+	if !model.Role(token).IsValid() {
+		return nil, model.InvalidTokenError()
+	}
+	return &middleware.Principal{UserID: 7, Username: "siti", Role: token, SessionID: "s1"}, nil
+}
+
+func TestAuthHandler_Login_InvalidCredentials(t *testing.T) {
+	mockSvc := &mockAuthService{
+		loginFunc: func(ctx context.Context, req model.LoginRequest) (*model.TokenResponse, error) {
+			return nil, model.InvalidCredentialsError()
+		},
+	}
+
+	handler := NewAuthHandler(mockSvc)
+	body := bytes.NewBufferString(`{"username":"siti","password":"wrong"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", body)
+	w := httptest.NewRecorder()
+
+	handler.Login(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+}
+
+func TestSetupRoutes_RequiresTokenAndPermission(t *testing.T) {
+	mockSvc := &mockAuthService{
+		loginFunc: func(ctx context.Context, req model.LoginRequest) (*model.TokenResponse, error) {
+			return &model.TokenResponse{AccessToken: "cashier", TokenType: "Bearer"}, nil
+		},
+		meFunc: func(ctx context.Context) (*model.User, error) {
+			principal, ok := middleware.PrincipalFromContext(ctx)
+			if !ok {
+				t.Fatal("Me() called without a principal in the context")
+			}
+			return &model.User{ID: principal.UserID, Username: principal.Username}, nil
+		},
+	}
+	userSvc := &mockUserService{
+		getRolesFunc: func(ctx context.Context) []model.RoleInfo {
+			return []model.RoleInfo{{Role: model.RoleOwner}}
+		},
+	}
+	routes := SetupRoutes(http.NewServeMux(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, NewUserHandler(userSvc), NewAuthHandler(mockSvc), nil, nil, nil, nil, nil, NewHealthHandler(nil), mockAuthenticator{}, nil)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{name: "login is public", method: http.MethodPost, path: "/api/auth/login", wantStatus: http.StatusOK},
+		{name: "health is public", method: http.MethodGet, path: "/health", wantStatus: http.StatusOK},
+		{name: "no token", method: http.MethodGet, path: "/api/auth/me", wantStatus: http.StatusUnauthorized},
+		{name: "bad token", method: http.MethodGet, path: "/api/products", token: "forged", wantStatus: http.StatusUnauthorized},
+		{name: "valid token", method: http.MethodGet, path: "/api/auth/me", token: "cashier", wantStatus: http.StatusOK},
+		{name: "cashier deleting a product", method: http.MethodDelete, path: "/api/products/1", token: "cashier", wantStatus: http.StatusForbidden},
+		{name: "cashier reading reports", method: http.MethodGet, path: "/api/reports/today", token: "cashier", wantStatus: http.StatusForbidden},
+		{name: "cashier reading the audit log", method: http.MethodGet, path: "/api/audit", token: "cashier", wantStatus: http.StatusForbidden},
+		{name: "manager assigning roles", method: http.MethodPut, path: "/api/users/2/role", token: "manager", wantStatus: http.StatusForbidden},
+		{name: "manager listing roles", method: http.MethodGet, path: "/api/roles", token: "manager", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(`{"username":"siti","password":"rahasia123"}`))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+
+			routes.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
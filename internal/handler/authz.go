@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/httputil"
+	"kasir-api/pkg/middleware"
+)
+
+// requirePermission wraps a handler so it only runs when the role of the
+// authenticated caller holds the permission; see model.RolePermissions
+func requirePermission(p model.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := middleware.PrincipalFromContext(r.Context())
+		if !ok {
+			httputil.HandleError(w, model.ErrUnauthorized)
+			return
+		}
+
+		role := model.Role(principal.Role)
+		if !role.Can(p) {
+			httputil.HandleError(w, model.PermissionDeniedError(role, p))
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/httputil"
+)
+
+type CartService interface {
+	Create(ctx context.Context, req model.CartRequest) (*model.Cart, error)
+	GetByID(ctx context.Context, id int) (*model.Cart, error)
+	GetHeld(ctx context.Context) ([]model.Cart, error)
+	AddItem(ctx context.Context, id int, req model.CartItemRequest) (*model.Cart, error)
+	UpdateItem(ctx context.Context, id, productID int, req model.CartItemRequest) (*model.Cart, error)
+	RemoveItem(ctx context.Context, id, productID int) (*model.Cart, error)
+	Delete(ctx context.Context, id int) error
+	Checkout(ctx context.Context, id int, key string, req model.CartCheckoutRequest) (*model.Transaction, bool, error)
+}
+
+type CartHandler struct {
+	svc CartService
+}
+
+func NewCartHandler(svc CartService) *CartHandler {
+	return &CartHandler{svc: svc}
+}
+
+func (h *CartHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	cart, err := h.svc.Create(r.Context(), req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, cart)
+}
+
+func (h *CartHandler) GetHeld(w http.ResponseWriter, r *http.Request) {
+	carts, err := h.svc.GetHeld(r.Context())
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, carts)
+}
+
+func (h *CartHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParseID(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	cart, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, cart)
+}
+
+func (h *CartHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParseID(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.CartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	cart, err := h.svc.AddItem(r.Context(), id, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, cart)
+}
+
+func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	productID, err := httputil.ParsePathID(r, "product_id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.CartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	cart, err := h.svc.UpdateItem(r.Context(), id, productID, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, cart)
+}
+
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	productID, err := httputil.ParsePathID(r, "product_id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	cart, err := h.svc.RemoveItem(r.Context(), id, productID)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, cart)
+}
+
+func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	// The body is optional; without payments the total is recorded as exact cash
+	var req model.CartCheckoutRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+			return
+		}
+	}
+
+	transaction, replayed, err := h.svc.Checkout(r.Context(), id, r.Header.Get(IdempotencyKeyHeader), req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	if replayed {
+		w.Header().Set(IdempotentReplayedHeader, "true")
+	}
+	httputil.WriteJSON(w, http.StatusCreated, transaction)
+}
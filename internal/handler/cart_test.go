@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kasir-api/internal/model"
+)
+
+// Mock service for testing
+type mockCartService struct {
+	createFunc     func(ctx context.Context, req model.CartRequest) (*model.Cart, error)
+	getByIDFunc    func(ctx context.Context, id int) (*model.Cart, error)
+	getHeldFunc    func(ctx context.Context) ([]model.Cart, error)
+	addItemFunc    func(ctx context.Context, id int, req model.CartItemRequest) (*model.Cart, error)
+	updateItemFunc func(ctx context.Context, id, productID int, req model.CartItemRequest) (*model.Cart, error)
+	removeItemFunc func(ctx context.Context, id, productID int) (*model.Cart, error)
+	deleteFunc     func(ctx context.Context, id int) error
+	checkoutFunc   func(ctx context.Context, id int, key string, req model.CartCheckoutRequest) (*model.Transaction, bool, error)
+}
+
+func (m *mockCartService) Create(ctx context.Context, req model.CartRequest) (*model.Cart, error) {
+	return m.createFunc(ctx, req)
+}
+
+func (m *mockCartService) GetByID(ctx context.Context, id int) (*model.Cart, error) {
+	return m.getByIDFunc(ctx, id)
+}
+
+func (m *mockCartService) GetHeld(ctx context.Context) ([]model.Cart, error) {
+	return m.getHeldFunc(ctx)
+}
+
+func (m *mockCartService) AddItem(ctx context.Context, id int, req model.CartItemRequest) (*model.Cart, error) {
+	return m.addItemFunc(ctx, id, req)
+}
+
+func (m *mockCartService) UpdateItem(ctx context.Context, id, productID int, req model.CartItemRequest) (*model.Cart, error) {
+	return m.updateItemFunc(ctx, id, productID, req)
+}
+
+func (m *mockCartService) RemoveItem(ctx context.Context, id, productID int) (*model.Cart, error) {
+	return m.removeItemFunc(ctx, id, productID)
+}
+
+func (m *mockCartService) Delete(ctx context.Context, id int) error {
+	return m.deleteFunc(ctx, id)
+}
+
+func (m *mockCartService) Checkout(ctx context.Context, id int, key string, req model.CartCheckoutRequest) (*model.Transaction, bool, error) {
+	return m.checkoutFunc(ctx, id, key, req)
+}
+
+func TestCartHandler_UpdateItem(t *testing.T) {
+	mockSvc := &mockCartService{
+		updateItemFunc: func(ctx context.Context, id, productID int, req model.CartItemRequest) (*model.Cart, error) {
+			if id != 3 || productID != 5 || req.Quantity != 2 {
+				t.Errorf("Unexpected update of cart %d product %d: %+v", id, productID, req)
+			}
+			return &model.Cart{ID: id, Status: model.CartStatusHeld}, nil
+		},
+	}
+
+	handler := NewCartHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodPut, "/api/carts/3/items/5", bytes.NewBufferString(`{"quantity":2}`))
+	req.SetPathValue("id", "3")
+	req.SetPathValue("product_id", "5")
+	w := httptest.NewRecorder()
+
+	handler.UpdateItem(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+func TestCartHandler_Checkout(t *testing.T) {
+	mockSvc := &mockCartService{
+		checkoutFunc: func(ctx context.Context, id int, key string, req model.CartCheckoutRequest) (*model.Transaction, bool, error) {
+			if id != 3 || key != "abc" {
+				t.Errorf("Unexpected checkout of cart %d with key %q", id, key)
+			}
+			return &model.Transaction{ID: 1, TotalAmount: 20000}, true, nil
+		},
+	}
+
+	handler := NewCartHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodPost, "/api/carts/3/checkout", nil)
+	req.SetPathValue("id", "3")
+	req.Header.Set(IdempotencyKeyHeader, "abc")
+	w := httptest.NewRecorder()
+
+	handler.Checkout(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", w.Code)
+	}
+	if w.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("Expected %s header on replay", IdempotentReplayedHeader)
+	}
+}
+
+func TestCartHandler_Checkout_NotHeld(t *testing.T) {
+	mockSvc := &mockCartService{
+		checkoutFunc: func(ctx context.Context, id int, key string, req model.CartCheckoutRequest) (*model.Transaction, bool, error) {
+			return nil, false, model.ErrConflict
+		},
+	}
+
+	handler := NewCartHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodPost, "/api/carts/3/checkout", bytes.NewBufferString(`{}`))
+	req.SetPathValue("id", "3")
+	w := httptest.NewRecorder()
+
+	handler.Checkout(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", w.Code)
+	}
+}
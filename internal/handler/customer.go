@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"kasir-api/internal/dto"
+	"kasir-api/internal/model"
+	"kasir-api/pkg/httputil"
+)
+
+type CustomerService interface {
+	Create(ctx context.Context, req model.CustomerRequest) (*model.Customer, error)
+	GetByID(ctx context.Context, id int) (*model.Customer, error)
+	GetAll(ctx context.Context, search string) ([]model.Customer, error)
+	Update(ctx context.Context, id int, req model.CustomerRequest) (*model.Customer, error)
+	Erase(ctx context.Context, id int) (*model.Customer, error)
+	GetLedger(ctx context.Context, id int) ([]model.LoyaltyEntry, error)
+	GetTransactions(ctx context.Context, id int, filter model.TransactionFilter) ([]model.Transaction, int, error)
+}
+
+type CustomerHandler struct {
+	svc CustomerService
+}
+
+func NewCustomerHandler(svc CustomerService) *CustomerHandler {
+	return &CustomerHandler{svc: svc}
+}
+
+func (h *CustomerHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	customers, err := h.svc.GetAll(r.Context(), r.URL.Query().Get("search"))
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, customers)
+}
+
+func (h *CustomerHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	customer, err := h.svc.Create(r.Context(), req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, customer)
+}
+
+func (h *CustomerHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParseID(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	customer, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, customer)
+}
+
+func (h *CustomerHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParseID(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.CustomerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	customer, err := h.svc.Update(r.Context(), id, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, customer)
+}
+
+func (h *CustomerHandler) Erase(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	customer, err := h.svc.Erase(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, customer)
+}
+
+func (h *CustomerHandler) GetLedger(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	entries, err := h.svc.GetLedger(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, entries)
+}
+
+func (h *CustomerHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	transactions, total, err := h.svc.GetTransactions(r.Context(), id, filter)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	filter = filter.WithDefaults()
+	httputil.WriteJSON(w, http.StatusOK, dto.TransactionListResponse{
+		Data:  transactions,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	})
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kasir-api/internal/model"
+)
+
+// Mock service for testing
+type mockCustomerService struct {
+	createFunc          func(ctx context.Context, req model.CustomerRequest) (*model.Customer, error)
+	getByIDFunc         func(ctx context.Context, id int) (*model.Customer, error)
+	getAllFunc          func(ctx context.Context, search string) ([]model.Customer, error)
+	updateFunc          func(ctx context.Context, id int, req model.CustomerRequest) (*model.Customer, error)
+	eraseFunc           func(ctx context.Context, id int) (*model.Customer, error)
+	getLedgerFunc       func(ctx context.Context, id int) ([]model.LoyaltyEntry, error)
+	getTransactionsFunc func(ctx context.Context, id int, filter model.TransactionFilter) ([]model.Transaction, int, error)
+}
+
+func (m *mockCustomerService) Create(ctx context.Context, req model.CustomerRequest) (*model.Customer, error) {
+	return m.createFunc(ctx, req)
+}
+
+func (m *mockCustomerService) GetByID(ctx context.Context, id int) (*model.Customer, error) {
+	return m.getByIDFunc(ctx, id)
+}
+
+func (m *mockCustomerService) GetAll(ctx context.Context, search string) ([]model.Customer, error) {
+	return m.getAllFunc(ctx, search)
+}
+
+func (m *mockCustomerService) Update(ctx context.Context, id int, req model.CustomerRequest) (*model.Customer, error) {
+	return m.updateFunc(ctx, id, req)
+}
+
+func (m *mockCustomerService) Erase(ctx context.Context, id int) (*model.Customer, error) {
+	return m.eraseFunc(ctx, id)
+}
+
+func (m *mockCustomerService) GetLedger(ctx context.Context, id int) ([]model.LoyaltyEntry, error) {
+	return m.getLedgerFunc(ctx, id)
+}
+
+func (m *mockCustomerService) GetTransactions(ctx context.Context, id int, filter model.TransactionFilter) ([]model.Transaction, int, error) {
+	return m.getTransactionsFunc(ctx, id, filter)
+}
+
+func TestCustomerHandler_Create_PhoneTaken(t *testing.T) {
+	mockSvc := &mockCustomerService{
+		createFunc: func(ctx context.Context, req model.CustomerRequest) (*model.Customer, error) {
+			return nil, model.PhoneTakenError(req.Phone)
+		},
+	}
+
+	handler := NewCustomerHandler(mockSvc)
+	body := bytes.NewBufferString(`{"name":"Budi","phone":"081234567890"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/customers", body)
+	w := httptest.NewRecorder()
+
+	handler.Create(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", w.Code)
+	}
+}
+
+func TestCustomerHandler_Erase(t *testing.T) {
+	mockSvc := &mockCustomerService{
+		eraseFunc: func(ctx context.Context, id int) (*model.Customer, error) {
+			if id != 5 {
+				t.Errorf("Erase() id = %d, want 5", id)
+			}
+			return &model.Customer{ID: id}, nil
+		},
+	}
+
+	handler := NewCustomerHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodPost, "/api/customers/5/erase", nil)
+	req.SetPathValue("id", "5")
+	w := httptest.NewRecorder()
+
+	handler.Erase(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+func TestCustomerHandler_GetTransactions(t *testing.T) {
+	mockSvc := &mockCustomerService{
+		getTransactionsFunc: func(ctx context.Context, id int, filter model.TransactionFilter) ([]model.Transaction, int, error) {
+			if id != 5 || filter.Page != 2 {
+				t.Errorf("Unexpected request for customer %d: %+v", id, filter)
+			}
+			return []model.Transaction{}, 0, nil
+		},
+	}
+
+	handler := NewCustomerHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/api/customers/5/transactions?page=2", nil)
+	req.SetPathValue("id", "5")
+	w := httptest.NewRecorder()
+
+	handler.GetTransactions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/httputil"
+)
+
+type DeviceService interface {
+	Register(ctx context.Context, req model.DeviceRequest) (*model.PairingCodeResponse, error)
+	Pair(ctx context.Context, req model.PairRequest) (*model.PairResponse, error)
+	GetByID(ctx context.Context, id int) (*model.Device, error)
+	GetAll(ctx context.Context) ([]model.Device, error)
+	Revoke(ctx context.Context, id int) (*model.Device, error)
+}
+
+type DeviceHandler struct {
+	svc DeviceService
+}
+
+func NewDeviceHandler(svc DeviceService) *DeviceHandler {
+	return &DeviceHandler{svc: svc}
+}
+
+func (h *DeviceHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	devices, err := h.svc.GetAll(r.Context())
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, devices)
+}
+
+func (h *DeviceHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req model.DeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	resp, err := h.svc.Register(r.Context(), req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (h *DeviceHandler) Pair(w http.ResponseWriter, r *http.Request) {
+	var req model.PairRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	resp, err := h.svc.Pair(r.Context(), req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *DeviceHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParseID(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	device, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, device)
+}
+
+func (h *DeviceHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	device, err := h.svc.Revoke(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, device)
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/middleware"
+)
+
+// Mock service for testing
+type mockDeviceService struct {
+	registerFunc func(ctx context.Context, req model.DeviceRequest) (*model.PairingCodeResponse, error)
+	pairFunc     func(ctx context.Context, req model.PairRequest) (*model.PairResponse, error)
+	getByIDFunc  func(ctx context.Context, id int) (*model.Device, error)
+	getAllFunc   func(ctx context.Context) ([]model.Device, error)
+	revokeFunc   func(ctx context.Context, id int) (*model.Device, error)
+}
+
+func (m *mockDeviceService) Register(ctx context.Context, req model.DeviceRequest) (*model.PairingCodeResponse, error) {
+	return m.registerFunc(ctx, req)
+}
+
+func (m *mockDeviceService) Pair(ctx context.Context, req model.PairRequest) (*model.PairResponse, error) {
+	return m.pairFunc(ctx, req)
+}
+
+func (m *mockDeviceService) GetByID(ctx context.Context, id int) (*model.Device, error) {
+	return m.getByIDFunc(ctx, id)
+}
+
+func (m *mockDeviceService) GetAll(ctx context.Context) ([]model.Device, error) {
+	return m.getAllFunc(ctx)
+}
+
+func (m *mockDeviceService) Revoke(ctx context.Context, id int) (*model.Device, error) {
+	return m.revokeFunc(ctx, id)
+}
+
+// mockDeviceAuthenticator accepts the single key "kdk_till" for a device bound to outlet 2
+type mockDeviceAuthenticator struct{}
+
+func (mockDeviceAuthenticator) Authenticate(ctx context.Context, key string) (*middleware.Principal, error) {
+	if key != "kdk_till" {
+		return nil, model.InvalidDeviceKeyError()
+	}
+	return &middleware.Principal{Username: "Till 1", Role: string(model.DeviceRole), DeviceID: 4, OutletID: 2}, nil
+}
+
+func TestDeviceHandler_Register(t *testing.T) {
+	mockSvc := &mockDeviceService{
+		registerFunc: func(ctx context.Context, req model.DeviceRequest) (*model.PairingCodeResponse, error) {
+			if req.Name != "Till 1" || req.OutletID != 2 {
+				t.Errorf("Unexpected request: %+v", req)
+			}
+			return &model.PairingCodeResponse{Device: model.Device{ID: 1, Name: req.Name, OutletID: req.OutletID}, PairingCode: "ABCD-EFGH"}, nil
+		},
+	}
+
+	handler := NewDeviceHandler(mockSvc)
+	body := bytes.NewBufferString(`{"name":"Till 1","outlet_id":2}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/devices", body)
+	w := httptest.NewRecorder()
+
+	handler.Register(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", w.Code)
+	}
+}
+
+func TestSetupRoutes_DeviceKey(t *testing.T) {
+	authSvc := &mockAuthService{
+		meFunc: func(ctx context.Context) (*model.User, error) {
+			if outletID, _ := middleware.OutletIDFromContext(ctx); outletID != 2 {
+				t.Errorf("Request scoped to outlet %d, want the device's outlet 2", outletID)
+			}
+			return &model.User{}, nil
+		},
+	}
+	deviceSvc := &mockDeviceService{
+		pairFunc: func(ctx context.Context, req model.PairRequest) (*model.PairResponse, error) {
+			if req.PairingCode != "ABCD-EFGH" {
+				return nil, model.InvalidPairingCodeError()
+			}
+			return &model.PairResponse{APIKey: "kdk_till"}, nil
+		},
+	}
+	routes := SetupRoutes(http.NewServeMux(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, NewAuthHandler(authSvc), NewDeviceHandler(deviceSvc), nil, nil, nil, nil, NewHealthHandler(nil), mockAuthenticator{}, mockDeviceAuthenticator{})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		key        string
+		outlet     string
+		body       string
+		wantStatus int
+	}{
+		{name: "pairing is public", method: http.MethodPost, path: "/api/devices/pair", body: `{"pairing_code":"ABCD-EFGH"}`, wantStatus: http.StatusOK},
+		{name: "wrong pairing code", method: http.MethodPost, path: "/api/devices/pair", body: `{"pairing_code":"ZZZZ-ZZZZ"}`, wantStatus: http.StatusUnauthorized},
+		{name: "device key", method: http.MethodGet, path: "/api/auth/me", key: "kdk_till", wantStatus: http.StatusOK},
+		{name: "device key for its own outlet", method: http.MethodGet, path: "/api/auth/me", key: "kdk_till", outlet: "2", wantStatus: http.StatusOK},
+		{name: "device key for another outlet", method: http.MethodGet, path: "/api/auth/me", key: "kdk_till", outlet: "3", wantStatus: http.StatusForbidden},
+		{name: "revoked device key", method: http.MethodGet, path: "/api/auth/me", key: "kdk_gone", wantStatus: http.StatusUnauthorized},
+		{name: "device managing devices", method: http.MethodGet, path: "/api/devices", key: "kdk_till", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			if tt.key != "" {
+				req.Header.Set(middleware.DeviceKeyHeader, tt.key)
+			}
+			if tt.outlet != "" {
+				req.Header.Set(middleware.OutletHeader, tt.outlet)
+			}
+			w := httptest.NewRecorder()
+
+			routes.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/errors"
+	"kasir-api/pkg/httputil"
+	"kasir-api/pkg/middleware"
+)
+
+type OutletService interface {
+	Create(ctx context.Context, req model.OutletRequest) (*model.Outlet, error)
+	GetByID(ctx context.Context, id int) (*model.Outlet, error)
+	GetAll(ctx context.Context) ([]model.Outlet, error)
+	Update(ctx context.Context, id int, req model.OutletRequest) (*model.Outlet, error)
+}
+
+type OutletHandler struct {
+	svc OutletService
+}
+
+func NewOutletHandler(svc OutletService) *OutletHandler {
+	return &OutletHandler{svc: svc}
+}
+
+func (h *OutletHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	outlets, err := h.svc.GetAll(r.Context())
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, outlets)
+}
+
+func (h *OutletHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.OutletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	outlet, err := h.svc.Create(r.Context(), req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, outlet)
+}
+
+func (h *OutletHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParseID(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	outlet, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, outlet)
+}
+
+func (h *OutletHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParseID(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.OutletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	outlet, err := h.svc.Update(r.Context(), id, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, outlet)
+}
+
+// queryOutletID reads the outlet_id query parameter used to look at another outlet.
+// A device bound to an outlet may only name its own, as with X-Outlet-ID.
+func queryOutletID(r *http.Request) (*int, error) {
+	outletID, err := httputil.QueryInt(r, "outlet_id")
+	if err != nil || outletID == nil {
+		return outletID, err
+	}
+	if *outletID <= 0 {
+		return nil, errors.ValidationError("outlet_id must be positive")
+	}
+	if principal, ok := middleware.PrincipalFromContext(r.Context()); ok && principal.OutletID != 0 && principal.OutletID != *outletID {
+		return nil, errors.ForbiddenError("device is bound to another outlet")
+	}
+	return outletID, nil
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kasir-api/internal/model"
+)
+
+// Mock service for testing
+type mockOutletService struct {
+	createFunc  func(ctx context.Context, req model.OutletRequest) (*model.Outlet, error)
+	getByIDFunc func(ctx context.Context, id int) (*model.Outlet, error)
+	getAllFunc  func(ctx context.Context) ([]model.Outlet, error)
+	updateFunc  func(ctx context.Context, id int, req model.OutletRequest) (*model.Outlet, error)
+}
+
+func (m *mockOutletService) Create(ctx context.Context, req model.OutletRequest) (*model.Outlet, error) {
+	return m.createFunc(ctx, req)
+}
+
+func (m *mockOutletService) GetByID(ctx context.Context, id int) (*model.Outlet, error) {
+	return m.getByIDFunc(ctx, id)
+}
+
+func (m *mockOutletService) GetAll(ctx context.Context) ([]model.Outlet, error) {
+	return m.getAllFunc(ctx)
+}
+
+func (m *mockOutletService) Update(ctx context.Context, id int, req model.OutletRequest) (*model.Outlet, error) {
+	return m.updateFunc(ctx, id, req)
+}
+
+func TestOutletHandler_Create_CodeTaken(t *testing.T) {
+	mockSvc := &mockOutletService{
+		createFunc: func(ctx context.Context, req model.OutletRequest) (*model.Outlet, error) {
+			return nil, model.OutletCodeTakenError(req.Code)
+		},
+	}
+
+	handler := NewOutletHandler(mockSvc)
+	body := bytes.NewBufferString(`{"code":"MAIN","name":"Bandung"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/outlets", body)
+	w := httptest.NewRecorder()
+
+	handler.Create(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", w.Code)
+	}
+}
+
+func TestOutletHandler_Update(t *testing.T) {
+	mockSvc := &mockOutletService{
+		updateFunc: func(ctx context.Context, id int, req model.OutletRequest) (*model.Outlet, error) {
+			if id != 2 || req.Active == nil || *req.Active {
+				t.Errorf("Unexpected update of outlet %d: %+v", id, req)
+			}
+			return &model.Outlet{ID: id, Code: req.Code, Name: req.Name}, nil
+		},
+	}
+
+	handler := NewOutletHandler(mockSvc)
+	body := bytes.NewBufferString(`{"code":"BDG","name":"Bandung","active":false}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/outlets/2", body)
+	w := httptest.NewRecorder()
+
+	handler.Update(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
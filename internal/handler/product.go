@@ -17,6 +17,8 @@ type ProductService interface {
 	Create(ctx context.Context, p model.Product) (*model.Product, error)
 	Update(ctx context.Context, id int, p model.Product) (*model.Product, error)
 	Delete(ctx context.Context, id int) error
+	CreateVariant(ctx context.Context, productID int, req model.ProductVariantRequest) (*model.Product, error)
+	GetVariants(ctx context.Context, productID int) ([]model.ProductVariant, error)
 }
 
 type ProductHandler struct {
@@ -52,22 +54,7 @@ func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 
 	response := make([]dto.ProductResponse, len(products))
 	for i, p := range products {
-		var catResp *dto.CategoryResponse
-		if p.Category != nil {
-			catResp = &dto.CategoryResponse{
-				ID:          p.Category.ID,
-				Name:        p.Category.Name,
-				Description: p.Category.Description,
-			}
-		}
-		response[i] = dto.ProductResponse{
-			ID:       p.ID,
-			Name:     p.Name,
-			Price:    p.Price,
-			Stock:    p.Stock,
-			Active:   p.Active,
-			Category: catResp,
-		}
+		response[i] = productResponse(p)
 	}
 
 	httputil.WriteJSON(w, http.StatusOK, response)
@@ -86,23 +73,7 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var categoryResp *dto.CategoryResponse
-	if product.Category != nil {
-		categoryResp = &dto.CategoryResponse{
-			ID:          product.Category.ID,
-			Name:        product.Category.Name,
-			Description: product.Category.Description,
-		}
-	}
-
-	response := dto.ProductResponse{
-		ID:       product.ID,
-		Name:     product.Name,
-		Price:    product.Price,
-		Stock:    product.Stock,
-		Active:   product.Active,
-		Category: categoryResp,
-	}
+	response := productResponse(*product)
 
 	httputil.WriteJSON(w, http.StatusOK, response)
 }
@@ -156,3 +127,91 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 	httputil.WriteJSON(w, http.StatusOK, map[string]string{"message": "Data successfully deleted"})
 }
+
+func (h *ProductHandler) Variants(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	variants, err := h.svc.GetVariants(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	response := make([]dto.ProductVariantResponse, len(variants))
+	for i, v := range variants {
+		response[i] = variantResponse(v)
+	}
+	httputil.WriteJSON(w, http.StatusOK, response)
+}
+
+func (h *ProductHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.ProductVariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	created, err := h.svc.CreateVariant(r.Context(), id, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusCreated, productResponse(*created))
+}
+
+func productResponse(p model.Product) dto.ProductResponse {
+	response := dto.ProductResponse{
+		ID:           p.ID,
+		Name:         p.Name,
+		Price:        p.Price,
+		Cost:         p.Cost,
+		Stock:        p.Stock,
+		Active:       p.Active,
+		TaxExempt:    p.TaxExempt,
+		TaxInclusive: p.TaxInclusive,
+		ReorderPoint: p.ReorderPoint,
+		ReorderQty:   p.ReorderQty,
+		SKU:          p.SKU,
+		Barcode:      p.Barcode,
+		ParentID:     p.ParentID,
+		Attributes:   p.Attributes,
+	}
+	for _, o := range p.Options {
+		response.Options = append(response.Options, dto.ProductOptionResponse{Name: o.Name, Values: o.Values})
+	}
+	for _, v := range p.Variants {
+		response.Variants = append(response.Variants, variantResponse(v))
+	}
+	if p.Category != nil {
+		response.Category = &dto.CategoryResponse{
+			ID:          p.Category.ID,
+			Name:        p.Category.Name,
+			Description: p.Category.Description,
+		}
+	}
+	return response
+}
+
+func variantResponse(v model.ProductVariant) dto.ProductVariantResponse {
+	return dto.ProductVariantResponse{
+		ID:         v.ID,
+		Name:       v.Name,
+		SKU:        v.SKU,
+		Barcode:    v.Barcode,
+		Attributes: v.Attributes,
+		Price:      v.Price,
+		Cost:       v.Cost,
+		Stock:      v.Stock,
+		Active:     v.Active,
+	}
+}
@@ -19,6 +19,8 @@ type mockProductService struct {
 	createFunc       func(ctx context.Context, p model.Product) (*model.Product, error)
 	updateFunc       func(ctx context.Context, id int, p model.Product) (*model.Product, error)
 	deleteFunc       func(ctx context.Context, id int) error
+	createVariantFn  func(ctx context.Context, productID int, req model.ProductVariantRequest) (*model.Product, error)
+	getVariantsFunc  func(ctx context.Context, productID int) ([]model.ProductVariant, error)
 }
 
 func (m *mockProductService) GetByID(ctx context.Context, id int) (*model.Product, error) {
@@ -48,6 +50,14 @@ func (m *mockProductService) Delete(ctx context.Context, id int) error {
 	return m.deleteFunc(ctx, id)
 }
 
+func (m *mockProductService) CreateVariant(ctx context.Context, productID int, req model.ProductVariantRequest) (*model.Product, error) {
+	return m.createVariantFn(ctx, productID, req)
+}
+
+func (m *mockProductService) GetVariants(ctx context.Context, productID int) ([]model.ProductVariant, error) {
+	return m.getVariantsFunc(ctx, productID)
+}
+
 func TestProductHandler_GetAll(t *testing.T) {
 	mockSvc := &mockProductService{
 		getAllFunc: func(ctx context.Context) ([]model.Product, error) {
@@ -182,3 +192,79 @@ func TestProductHandler_Delete(t *testing.T) {
 		t.Errorf("Expected status 200, got %d", w.Code)
 	}
 }
+
+func TestProductHandler_GetByID_Variants(t *testing.T) {
+	mockSvc := &mockProductService{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Product, error) {
+			return &model.Product{
+				ID: 1, Name: "Indomie", Price: 3500,
+				Options:  []model.ProductOption{{Name: "flavour", Values: []string{"Goreng", "Soto"}}},
+				Variants: []model.ProductVariant{{ID: 2, Name: "Indomie Soto", Attributes: map[string]string{"flavour": "Soto"}, Price: 3500, Stock: 7}},
+			}, nil
+		},
+	}
+
+	handler := NewProductHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/api/products/1", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetByID(w, req)
+
+	var resp struct {
+		Options  []model.ProductOption  `json:"options"`
+		Variants []model.ProductVariant `json:"variants"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Options) != 1 || len(resp.Variants) != 1 || resp.Variants[0].Attributes["flavour"] != "Soto" || resp.Variants[0].Stock != 7 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+}
+
+func TestProductHandler_CreateVariant(t *testing.T) {
+	mockSvc := &mockProductService{
+		createVariantFn: func(ctx context.Context, productID int, req model.ProductVariantRequest) (*model.Product, error) {
+			if productID != 1 || req.Attributes["size"] != "2L" || req.SKU != "AQ-2L" {
+				t.Errorf("CreateVariant(%d, %+v)", productID, req)
+			}
+			return &model.Product{ID: 5, Name: "Aqua 2L", SKU: req.SKU, ParentID: &productID, Attributes: req.Attributes}, nil
+		},
+	}
+
+	handler := NewProductHandler(mockSvc)
+	body := bytes.NewBufferString(`{"sku":"AQ-2L","attributes":{"size":"2L"},"price":7000}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/products/1/variants", body)
+	req.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+
+	handler.CreateVariant(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	var resp struct {
+		ParentID *int `json:"parent_id"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.ParentID == nil || *resp.ParentID != 1 {
+		t.Errorf("parent_id = %v, want 1", resp.ParentID)
+	}
+}
+
+func TestProductHandler_CreateVariant_Conflict(t *testing.T) {
+	mockSvc := &mockProductService{
+		createVariantFn: func(ctx context.Context, productID int, req model.ProductVariantRequest) (*model.Product, error) {
+			return nil, model.VariantTakenError("Aqua", "Aqua 2L")
+		},
+	}
+
+	handler := NewProductHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodPost, "/api/products/1/variants", bytes.NewBufferString(`{"attributes":{"size":"2L"}}`))
+	req.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+
+	handler.CreateVariant(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", w.Code)
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/httputil"
+)
+
+type PromotionService interface {
+	GetByID(ctx context.Context, id int) (*model.Promotion, error)
+	GetAll(ctx context.Context) ([]model.Promotion, error)
+	Create(ctx context.Context, p model.Promotion) (*model.Promotion, error)
+	Update(ctx context.Context, id int, p model.Promotion) (*model.Promotion, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type PromotionHandler struct {
+	svc PromotionService
+}
+
+func NewPromotionHandler(svc PromotionService) *PromotionHandler {
+	return &PromotionHandler{svc: svc}
+}
+
+func (h *PromotionHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	promotions, err := h.svc.GetAll(r.Context())
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, promotions)
+}
+
+func (h *PromotionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParseID(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	promotion, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, promotion)
+}
+
+func (h *PromotionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var promotion model.Promotion
+	if err := json.NewDecoder(r.Body).Decode(&promotion); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	created, err := h.svc.Create(r.Context(), promotion)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusCreated, created)
+}
+
+func (h *PromotionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParseID(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var promotion model.Promotion
+	if err := json.NewDecoder(r.Body).Decode(&promotion); err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	updated, err := h.svc.Update(r.Context(), id, promotion)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, updated)
+}
+
+func (h *PromotionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParseID(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	if err := h.svc.Delete(r.Context(), id); err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"message": "Data successfully deleted"})
+}
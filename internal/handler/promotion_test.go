@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kasir-api/internal/model"
+	errorsPkg "kasir-api/pkg/errors"
+)
+
+// Mock service for testing
+type mockPromotionService struct {
+	getByIDFunc func(ctx context.Context, id int) (*model.Promotion, error)
+	getAllFunc  func(ctx context.Context) ([]model.Promotion, error)
+	createFunc  func(ctx context.Context, p model.Promotion) (*model.Promotion, error)
+	updateFunc  func(ctx context.Context, id int, p model.Promotion) (*model.Promotion, error)
+	deleteFunc  func(ctx context.Context, id int) error
+}
+
+func (m *mockPromotionService) GetByID(ctx context.Context, id int) (*model.Promotion, error) {
+	return m.getByIDFunc(ctx, id)
+}
+
+func (m *mockPromotionService) GetAll(ctx context.Context) ([]model.Promotion, error) {
+	return m.getAllFunc(ctx)
+}
+
+func (m *mockPromotionService) Create(ctx context.Context, p model.Promotion) (*model.Promotion, error) {
+	return m.createFunc(ctx, p)
+}
+
+func (m *mockPromotionService) Update(ctx context.Context, id int, p model.Promotion) (*model.Promotion, error) {
+	return m.updateFunc(ctx, id, p)
+}
+
+func (m *mockPromotionService) Delete(ctx context.Context, id int) error {
+	return m.deleteFunc(ctx, id)
+}
+
+func TestPromotionHandler_Create(t *testing.T) {
+	var got model.Promotion
+	mockSvc := &mockPromotionService{
+		createFunc: func(ctx context.Context, p model.Promotion) (*model.Promotion, error) {
+			got = p
+			p.ID = 1
+			return &p, nil
+		},
+	}
+
+	handler := NewPromotionHandler(mockSvc)
+	body := `{"name":"Drinks 10%","type":"percent","value":10,"category_id":2,"starts_at":"2026-03-01T00:00:00Z","active":true}`
+	req := httptest.NewRequest(http.MethodPost, "/api/promotions", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	handler.Create(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	if got.Type != model.PromotionTypePercent || got.CategoryID == nil || *got.CategoryID != 2 || got.StartsAt == nil {
+		t.Errorf("Unexpected promotion passed to service: %+v", got)
+	}
+}
+
+func TestPromotionHandler_Create_ValidationError(t *testing.T) {
+	mockSvc := &mockPromotionService{
+		createFunc: func(ctx context.Context, p model.Promotion) (*model.Promotion, error) {
+			return nil, errorsPkg.ValidationError("value must be between 1 and 100 for percent promotions")
+		},
+	}
+
+	handler := NewPromotionHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodPost, "/api/promotions", bytes.NewBufferString(`{"name":"x","type":"percent","value":150}`))
+	w := httptest.NewRecorder()
+
+	handler.Create(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/httputil"
+)
+
+type PurchaseOrderService interface {
+	Create(ctx context.Context, req model.PurchaseOrderRequest) (*model.PurchaseOrder, error)
+	GetByID(ctx context.Context, id int) (*model.PurchaseOrder, error)
+	GetAll(ctx context.Context, filter model.PurchaseOrderFilter) ([]model.PurchaseOrder, error)
+	Update(ctx context.Context, id int, req model.PurchaseOrderRequest) (*model.PurchaseOrder, error)
+	Send(ctx context.Context, id int) (*model.PurchaseOrder, error)
+	Receive(ctx context.Context, id int, req model.GoodsReceiptRequest) (*model.PurchaseOrder, error)
+	Close(ctx context.Context, id int) (*model.PurchaseOrder, error)
+}
+
+type PurchaseOrderHandler struct {
+	svc PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(svc PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{svc: svc}
+}
+
+func (h *PurchaseOrderHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	filter, err := parsePurchaseOrderFilter(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	orders, err := h.svc.GetAll(r.Context(), filter)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, orders)
+}
+
+func parsePurchaseOrderFilter(r *http.Request) (model.PurchaseOrderFilter, error) {
+	filter := model.PurchaseOrderFilter{
+		Status: model.PurchaseOrderStatus(r.URL.Query().Get("status")),
+		Open:   r.URL.Query().Get("open") == "true",
+	}
+
+	supplierID, err := httputil.QueryInt(r, "supplier_id")
+	if err != nil {
+		return filter, err
+	}
+	if supplierID != nil {
+		filter.SupplierID = *supplierID
+	}
+
+	productID, err := httputil.QueryInt(r, "product_id")
+	if err != nil {
+		return filter, err
+	}
+	if productID != nil {
+		filter.ProductID = *productID
+	}
+
+	return filter, nil
+}
+
+func (h *PurchaseOrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.PurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	po, err := h.svc.Create(r.Context(), req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, po)
+}
+
+func (h *PurchaseOrderHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	po, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, po)
+}
+
+func (h *PurchaseOrderHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.PurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	po, err := h.svc.Update(r.Context(), id, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, po)
+}
+
+func (h *PurchaseOrderHandler) Send(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	po, err := h.svc.Send(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, po)
+}
+
+func (h *PurchaseOrderHandler) Receive(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.GoodsReceiptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	po, err := h.svc.Receive(r.Context(), id, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, po)
+}
+
+func (h *PurchaseOrderHandler) Close(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	po, err := h.svc.Close(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, po)
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/httputil"
+)
+
+type PurchaseReturnService interface {
+	Create(ctx context.Context, req model.PurchaseReturnRequest) (*model.PurchaseReturn, error)
+	GetByID(ctx context.Context, id int) (*model.PurchaseReturn, error)
+	GetAll(ctx context.Context, filter model.PurchaseReturnFilter) ([]model.PurchaseReturn, error)
+	RecordCredit(ctx context.Context, id int, req model.SupplierCreditRequest) (*model.PurchaseReturn, error)
+}
+
+type PurchaseReturnHandler struct {
+	svc PurchaseReturnService
+}
+
+func NewPurchaseReturnHandler(svc PurchaseReturnService) *PurchaseReturnHandler {
+	return &PurchaseReturnHandler{svc: svc}
+}
+
+func (h *PurchaseReturnHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	filter := model.PurchaseReturnFilter{Status: model.PurchaseReturnStatus(r.URL.Query().Get("status"))}
+	supplierID, err := httputil.QueryInt(r, "supplier_id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	if supplierID != nil {
+		filter.SupplierID = *supplierID
+	}
+
+	returns, err := h.svc.GetAll(r.Context(), filter)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, returns)
+}
+
+func (h *PurchaseReturnHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.PurchaseReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	pr, err := h.svc.Create(r.Context(), req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, pr)
+}
+
+func (h *PurchaseReturnHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	pr, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, pr)
+}
+
+func (h *PurchaseReturnHandler) RecordCredit(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.SupplierCreditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	pr, err := h.svc.RecordCredit(r.Context(), id, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, pr)
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"kasir-api/internal/model"
+)
+
+type mockPurchaseReturnService struct {
+	createFunc       func(ctx context.Context, req model.PurchaseReturnRequest) (*model.PurchaseReturn, error)
+	getByIDFunc      func(ctx context.Context, id int) (*model.PurchaseReturn, error)
+	getAllFunc       func(ctx context.Context, filter model.PurchaseReturnFilter) ([]model.PurchaseReturn, error)
+	recordCreditFunc func(ctx context.Context, id int, req model.SupplierCreditRequest) (*model.PurchaseReturn, error)
+}
+
+func (m *mockPurchaseReturnService) Create(ctx context.Context, req model.PurchaseReturnRequest) (*model.PurchaseReturn, error) {
+	return m.createFunc(ctx, req)
+}
+
+func (m *mockPurchaseReturnService) GetByID(ctx context.Context, id int) (*model.PurchaseReturn, error) {
+	return m.getByIDFunc(ctx, id)
+}
+
+func (m *mockPurchaseReturnService) GetAll(ctx context.Context, filter model.PurchaseReturnFilter) ([]model.PurchaseReturn, error) {
+	return m.getAllFunc(ctx, filter)
+}
+
+func (m *mockPurchaseReturnService) RecordCredit(ctx context.Context, id int, req model.SupplierCreditRequest) (*model.PurchaseReturn, error) {
+	return m.recordCreditFunc(ctx, id, req)
+}
+
+func TestPurchaseReturnHandler_Create(t *testing.T) {
+	var gotReq model.PurchaseReturnRequest
+	mockSvc := &mockPurchaseReturnService{
+		createFunc: func(ctx context.Context, req model.PurchaseReturnRequest) (*model.PurchaseReturn, error) {
+			gotReq = req
+			return &model.PurchaseReturn{ID: 1, Status: model.PurchaseReturnStatusOpen}, nil
+		},
+	}
+	handler := NewPurchaseReturnHandler(mockSvc)
+
+	body := `{"supplier_id":3,"goods_receipt_id":7,"items":[{"product_id":1,"quantity":6,"reason":"expired"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/purchase-returns", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.Create(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	if gotReq.SupplierID != 3 || *gotReq.GoodsReceiptID != 7 || gotReq.Items[0].Reason != model.StockReasonExpired {
+		t.Errorf("Unexpected request: %+v", gotReq)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/purchase-returns", strings.NewReader(`{`))
+	w = httptest.NewRecorder()
+	handler.Create(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid JSON, got %d", w.Code)
+	}
+}
+
+func TestPurchaseReturnHandler_RecordCredit(t *testing.T) {
+	mockSvc := &mockPurchaseReturnService{
+		recordCreditFunc: func(ctx context.Context, id int, req model.SupplierCreditRequest) (*model.PurchaseReturn, error) {
+			if id == 9 {
+				return nil, model.ErrConflict
+			}
+			return &model.PurchaseReturn{ID: id, CreditReceived: req.Amount}, nil
+		},
+	}
+	handler := NewPurchaseReturnHandler(mockSvc)
+
+	tests := []struct {
+		name string
+		id   string
+		body string
+		want int
+	}{
+		{"credited", "4", `{"amount":15000}`, http.StatusOK},
+		{"already credited", "9", `{"amount":1}`, http.StatusConflict},
+		{"bad id", "abc", `{}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/purchase-returns/"+tt.id+"/credits", strings.NewReader(tt.body))
+			req.SetPathValue("id", tt.id)
+			w := httptest.NewRecorder()
+			handler.RecordCredit(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"kasir-api/internal/model"
+)
+
+type mockPurchaseOrderService struct {
+	createFunc  func(ctx context.Context, req model.PurchaseOrderRequest) (*model.PurchaseOrder, error)
+	getByIDFunc func(ctx context.Context, id int) (*model.PurchaseOrder, error)
+	getAllFunc  func(ctx context.Context, filter model.PurchaseOrderFilter) ([]model.PurchaseOrder, error)
+	updateFunc  func(ctx context.Context, id int, req model.PurchaseOrderRequest) (*model.PurchaseOrder, error)
+	sendFunc    func(ctx context.Context, id int) (*model.PurchaseOrder, error)
+	receiveFunc func(ctx context.Context, id int, req model.GoodsReceiptRequest) (*model.PurchaseOrder, error)
+	closeFunc   func(ctx context.Context, id int) (*model.PurchaseOrder, error)
+}
+
+func (m *mockPurchaseOrderService) Create(ctx context.Context, req model.PurchaseOrderRequest) (*model.PurchaseOrder, error) {
+	return m.createFunc(ctx, req)
+}
+
+func (m *mockPurchaseOrderService) GetByID(ctx context.Context, id int) (*model.PurchaseOrder, error) {
+	return m.getByIDFunc(ctx, id)
+}
+
+func (m *mockPurchaseOrderService) GetAll(ctx context.Context, filter model.PurchaseOrderFilter) ([]model.PurchaseOrder, error) {
+	return m.getAllFunc(ctx, filter)
+}
+
+func (m *mockPurchaseOrderService) Update(ctx context.Context, id int, req model.PurchaseOrderRequest) (*model.PurchaseOrder, error) {
+	return m.updateFunc(ctx, id, req)
+}
+
+func (m *mockPurchaseOrderService) Send(ctx context.Context, id int) (*model.PurchaseOrder, error) {
+	return m.sendFunc(ctx, id)
+}
+
+func (m *mockPurchaseOrderService) Receive(ctx context.Context, id int, req model.GoodsReceiptRequest) (*model.PurchaseOrder, error) {
+	return m.receiveFunc(ctx, id, req)
+}
+
+func (m *mockPurchaseOrderService) Close(ctx context.Context, id int) (*model.PurchaseOrder, error) {
+	return m.closeFunc(ctx, id)
+}
+
+func TestPurchaseOrderHandler_GetAll(t *testing.T) {
+	var gotFilter model.PurchaseOrderFilter
+	mockSvc := &mockPurchaseOrderService{
+		getAllFunc: func(ctx context.Context, filter model.PurchaseOrderFilter) ([]model.PurchaseOrder, error) {
+			gotFilter = filter
+			return []model.PurchaseOrder{}, nil
+		},
+	}
+	handler := NewPurchaseOrderHandler(mockSvc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/purchase-orders?supplier_id=3&product_id=7&open=true", nil)
+	w := httptest.NewRecorder()
+	handler.GetAll(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if gotFilter != (model.PurchaseOrderFilter{SupplierID: 3, ProductID: 7, Open: true}) {
+		t.Errorf("Unexpected filter: %+v", gotFilter)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/purchase-orders?supplier_id=x", nil)
+	w = httptest.NewRecorder()
+	handler.GetAll(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a bad supplier_id, got %d", w.Code)
+	}
+}
+
+func TestPurchaseOrderHandler_Receive(t *testing.T) {
+	var gotReq model.GoodsReceiptRequest
+	mockSvc := &mockPurchaseOrderService{
+		receiveFunc: func(ctx context.Context, id int, req model.GoodsReceiptRequest) (*model.PurchaseOrder, error) {
+			if id == 9 {
+				return nil, model.PurchaseOrderStatusError(id, model.PurchaseOrderStatusClosed, "received")
+			}
+			gotReq = req
+			return &model.PurchaseOrder{ID: id, Status: model.PurchaseOrderStatusPartiallyReceived}, nil
+		},
+	}
+	handler := NewPurchaseOrderHandler(mockSvc)
+
+	tests := []struct {
+		name string
+		id   string
+		body string
+		want int
+	}{
+		{"received", "4", `{"items":[{"product_id":1,"quantity":12,"unit_cost":2700}],"accept_over_delivery":true}`, http.StatusCreated},
+		{"closed order", "9", `{"items":[{"product_id":1,"quantity":1}]}`, http.StatusConflict},
+		{"invalid JSON", "4", `{`, http.StatusBadRequest},
+		{"bad id", "abc", `{}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/purchase-orders/"+tt.id+"/receipts", strings.NewReader(tt.body))
+			req.SetPathValue("id", tt.id)
+			w := httptest.NewRecorder()
+			handler.Receive(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+
+	if len(gotReq.Items) != 1 || *gotReq.Items[0].UnitCost != 2700 || !gotReq.AcceptOverDelivery {
+		t.Errorf("Unexpected request: %+v", gotReq)
+	}
+}
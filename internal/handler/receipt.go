@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"kasir-api/internal/receipt"
+	"kasir-api/pkg/httputil"
+)
+
+type ReceiptService interface {
+	Render(ctx context.Context, transactionID int, opts receipt.Options) ([]byte, error)
+}
+
+type ReceiptHandler struct {
+	svc ReceiptService
+}
+
+func NewReceiptHandler(svc ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{svc: svc}
+}
+
+func (h *ReceiptHandler) Get(w http.ResponseWriter, r *http.Request) {
+	transactionID, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	opts, err := receipt.ParseOptions(r.URL.Query().Get("format"), r.URL.Query().Get("width"))
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	out, err := h.svc.Render(r.Context(), transactionID, opts)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", opts.Format.ContentType())
+	if opts.Format == receipt.FormatESCPOS {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"receipt-%d.bin\"", transactionID))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/receipt"
+)
+
+// Mock service for testing
+type mockReceiptService struct {
+	renderFunc func(ctx context.Context, transactionID int, opts receipt.Options) ([]byte, error)
+}
+
+func (m *mockReceiptService) Render(ctx context.Context, transactionID int, opts receipt.Options) ([]byte, error) {
+	return m.renderFunc(ctx, transactionID, opts)
+}
+
+func TestReceiptHandler_Get_ESCPOS(t *testing.T) {
+	mockSvc := &mockReceiptService{
+		renderFunc: func(ctx context.Context, transactionID int, opts receipt.Options) ([]byte, error) {
+			if transactionID != 7 || opts.Format != receipt.FormatESCPOS || opts.Width != receipt.Width80 {
+				t.Errorf("Unexpected render of transaction %d: %+v", transactionID, opts)
+			}
+			return []byte{0x1b, 0x40}, nil
+		},
+	}
+
+	handler := NewReceiptHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions/7/receipt?format=escpos&width=80", nil)
+	req.SetPathValue("id", "7")
+	w := httptest.NewRecorder()
+
+	handler.Get(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/octet-stream" {
+		t.Errorf("Content-Type = %q, want application/octet-stream", w.Header().Get("Content-Type"))
+	}
+}
+
+func TestReceiptHandler_Get_InvalidFormat(t *testing.T) {
+	handler := NewReceiptHandler(&mockReceiptService{})
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions/7/receipt?format=pdf", nil)
+	req.SetPathValue("id", "7")
+	w := httptest.NewRecorder()
+
+	handler.Get(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestReceiptHandler_Get_NotFound(t *testing.T) {
+	mockSvc := &mockReceiptService{
+		renderFunc: func(ctx context.Context, transactionID int, opts receipt.Options) ([]byte, error) {
+			return nil, model.ErrNotFound
+		},
+	}
+
+	handler := NewReceiptHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions/7/receipt", nil)
+	req.SetPathValue("id", "7")
+	w := httptest.NewRecorder()
+
+	handler.Get(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
@@ -7,11 +7,13 @@ import (
 	"kasir-api/internal/model"
 	"kasir-api/pkg/errors"
 	"kasir-api/pkg/httputil"
+	"kasir-api/pkg/middleware"
 )
 
 type ReportService interface {
 	GetTodayReport(ctx context.Context) (*model.ReportSummary, error)
 	GetReportByDateRange(ctx context.Context, startDate, endDate string) (*model.ReportSummary, error)
+	GetInventoryValuation(ctx context.Context) (*model.InventoryValuation, error)
 }
 
 type ReportHandler struct {
@@ -23,7 +25,13 @@ func NewReportHandler(svc ReportService) *ReportHandler {
 }
 
 func (h *ReportHandler) Today(w http.ResponseWriter, r *http.Request) {
-	report, err := h.svc.GetTodayReport(r.Context())
+	ctx, err := reportContext(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	report, err := h.svc.GetTodayReport(ctx)
 	if err != nil {
 		httputil.HandleError(w, err)
 		return
@@ -40,10 +48,45 @@ func (h *ReportHandler) ByDateRange(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	report, err := h.svc.GetReportByDateRange(r.Context(), startDate, endDate)
+	ctx, err := reportContext(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	report, err := h.svc.GetReportByDateRange(ctx, startDate, endDate)
 	if err != nil {
 		httputil.HandleError(w, err)
 		return
 	}
 	httputil.WriteJSON(w, http.StatusOK, report)
 }
+
+func (h *ReportHandler) Inventory(w http.ResponseWriter, r *http.Request) {
+	ctx, err := reportContext(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	valuation, err := h.svc.GetInventoryValuation(ctx)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, valuation)
+}
+
+// reportContext scopes the report to the outlet_id query parameter when given, so a
+// head office can look at any outlet; otherwise the X-Outlet-ID header applies, and
+// without either the report consolidates all outlets
+func reportContext(r *http.Request) (context.Context, error) {
+	outletID, err := queryOutletID(r)
+	if err != nil {
+		return nil, err
+	}
+	if outletID == nil {
+		return r.Context(), nil
+	}
+	return middleware.WithOutletID(r.Context(), *outletID), nil
+}
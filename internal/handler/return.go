@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/httputil"
+)
+
+type ReturnService interface {
+	Create(ctx context.Context, transactionID int, req model.ReturnRequest) (*model.Return, error)
+	GetByID(ctx context.Context, id int) (*model.Return, error)
+	GetByTransactionID(ctx context.Context, transactionID int) ([]model.Return, error)
+}
+
+type ReturnHandler struct {
+	svc ReturnService
+}
+
+func NewReturnHandler(svc ReturnService) *ReturnHandler {
+	return &ReturnHandler{svc: svc}
+}
+
+func (h *ReturnHandler) Create(w http.ResponseWriter, r *http.Request) {
+	transactionID, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.ReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	ret, err := h.svc.Create(r.Context(), transactionID, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, ret)
+}
+
+func (h *ReturnHandler) GetByTransactionID(w http.ResponseWriter, r *http.Request) {
+	transactionID, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	returns, err := h.svc.GetByTransactionID(r.Context(), transactionID)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, returns)
+}
+
+func (h *ReturnHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParseID(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	ret, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, ret)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kasir-api/internal/model"
+)
+
+// Mock service for testing
+type mockReturnService struct {
+	createFunc             func(ctx context.Context, transactionID int, req model.ReturnRequest) (*model.Return, error)
+	getByIDFunc            func(ctx context.Context, id int) (*model.Return, error)
+	getByTransactionIDFunc func(ctx context.Context, transactionID int) ([]model.Return, error)
+}
+
+func (m *mockReturnService) Create(ctx context.Context, transactionID int, req model.ReturnRequest) (*model.Return, error) {
+	return m.createFunc(ctx, transactionID, req)
+}
+
+func (m *mockReturnService) GetByID(ctx context.Context, id int) (*model.Return, error) {
+	return m.getByIDFunc(ctx, id)
+}
+
+func (m *mockReturnService) GetByTransactionID(ctx context.Context, transactionID int) ([]model.Return, error) {
+	return m.getByTransactionIDFunc(ctx, transactionID)
+}
+
+func TestReturnHandler_Create(t *testing.T) {
+	mockSvc := &mockReturnService{
+		createFunc: func(ctx context.Context, transactionID int, req model.ReturnRequest) (*model.Return, error) {
+			if transactionID != 4 || len(req.Items) != 1 || !req.Restock {
+				t.Errorf("Unexpected request for transaction %d: %+v", transactionID, req)
+			}
+			return &model.Return{ID: 1, TransactionID: transactionID, TotalAmount: -3500}, nil
+		},
+	}
+
+	handler := NewReturnHandler(mockSvc)
+	body := bytes.NewBufferString(`{"items":[{"product_id":1,"quantity":1}],"reason":"damaged","restock":true}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions/4/returns", body)
+	req.SetPathValue("id", "4")
+	w := httptest.NewRecorder()
+
+	handler.Create(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", w.Code)
+	}
+}
+
+func TestReturnHandler_Create_OverReturn(t *testing.T) {
+	mockSvc := &mockReturnService{
+		createFunc: func(ctx context.Context, transactionID int, req model.ReturnRequest) (*model.Return, error) {
+			return nil, model.ErrValidation
+		},
+	}
+
+	handler := NewReturnHandler(mockSvc)
+	body := bytes.NewBufferString(`{"items":[{"product_id":1,"quantity":5}],"reason":"damaged"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions/4/returns", body)
+	req.SetPathValue("id", "4")
+	w := httptest.NewRecorder()
+
+	handler.Create(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestReturnHandler_GetByID_NotFound(t *testing.T) {
+	mockSvc := &mockReturnService{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Return, error) {
+			return nil, model.ErrNotFound
+		},
+	}
+
+	handler := NewReturnHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/api/returns/999", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetByID(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
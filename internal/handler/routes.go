@@ -3,10 +3,14 @@ package handler
 import (
 	"net/http"
 
+	"kasir-api/internal/model"
 	"kasir-api/pkg/middleware"
 )
 
-func SetupRoutes(mux *http.ServeMux, productHandler *ProductHandler, categoryHandler *CategoryHandler, transactionHandler *TransactionHandler, reportHandler *ReportHandler, healthHandler *HealthHandler) http.Handler {
+// SetupRoutes registers the API. Every /api handler except the auth endpoints and
+// device pairing is wrapped in requirePermission, so the caller's role must allow
+// the action. Users authenticate with authenticator, devices with deviceAuthenticator.
+func SetupRoutes(mux *http.ServeMux, productHandler *ProductHandler, stockHandler *StockHandler, categoryHandler *CategoryHandler, promotionHandler *PromotionHandler, transactionHandler *TransactionHandler, cartHandler *CartHandler, shiftHandler *ShiftHandler, stocktakeHandler *StocktakeHandler, supplierHandler *SupplierHandler, purchaseOrderHandler *PurchaseOrderHandler, purchaseReturnHandler *PurchaseReturnHandler, customerHandler *CustomerHandler, outletHandler *OutletHandler, userHandler *UserHandler, authHandler *AuthHandler, deviceHandler *DeviceHandler, auditHandler *AuditHandler, returnHandler *ReturnHandler, receiptHandler *ReceiptHandler, reportHandler *ReportHandler, healthHandler *HealthHandler, authenticator, deviceAuthenticator middleware.Authenticator) http.Handler {
 	// Health endpoints
 	mux.HandleFunc("/", healthHandler.Root)
 	mux.HandleFunc("/health", healthHandler.Check)
@@ -14,13 +18,46 @@ func SetupRoutes(mux *http.ServeMux, productHandler *ProductHandler, categoryHan
 	// Documentation
 	mux.Handle("/docs/", http.StripPrefix("/docs/", http.FileServer(http.Dir("./docs"))))
 
+	// Auth endpoints; login and refresh are reachable without a token, like device pairing
+	mux.HandleFunc("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			authHandler.Login(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/auth/refresh", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			authHandler.Refresh(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/auth/logout", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			authHandler.Logout(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/auth/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			authHandler.Me(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	// Product endpoints
 	mux.HandleFunc("/api/products", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			productHandler.GetAll(w, r)
+			requirePermission(model.PermissionProductsRead, productHandler.GetAll)(w, r)
 		case http.MethodPost:
-			productHandler.Create(w, r)
+			requirePermission(model.PermissionProductsWrite, productHandler.Create)(w, r)
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -29,23 +66,64 @@ func SetupRoutes(mux *http.ServeMux, productHandler *ProductHandler, categoryHan
 	mux.HandleFunc("/api/products/", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			productHandler.GetByID(w, r)
+			requirePermission(model.PermissionProductsRead, productHandler.GetByID)(w, r)
 		case http.MethodPut:
-			productHandler.Update(w, r)
+			requirePermission(model.PermissionProductsWrite, productHandler.Update)(w, r)
 		case http.MethodDelete:
-			productHandler.Delete(w, r)
+			requirePermission(model.PermissionProductsDelete, productHandler.Delete)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Variant endpoints; a variant is otherwise read and changed as a product by its own id
+	mux.HandleFunc("/api/products/{id}/variants", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionProductsRead, productHandler.Variants)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionProductsWrite, productHandler.CreateVariant)(w, r)
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
 
+	// Stock ledger endpoints; sales and returns post their own movements
+	mux.HandleFunc("/api/products/{id}/stock-movements", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionProductsRead, stockHandler.GetMovements)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionProductsWrite, stockHandler.Post)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Inventory endpoints; stock below reorder points at the outlet of the request
+	mux.HandleFunc("/api/inventory/low-stock", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionProductsRead, stockHandler.LowStock)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/inventory/alerts", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionProductsRead, stockHandler.Alerts)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	// Category endpoints
 	mux.HandleFunc("/api/categories", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			categoryHandler.GetAll(w, r)
+			requirePermission(model.PermissionCategoriesRead, categoryHandler.GetAll)(w, r)
 		case http.MethodPost:
-			categoryHandler.Create(w, r)
+			requirePermission(model.PermissionCategoriesWrite, categoryHandler.Create)(w, r)
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -54,20 +132,494 @@ func SetupRoutes(mux *http.ServeMux, productHandler *ProductHandler, categoryHan
 	mux.HandleFunc("/api/categories/", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			categoryHandler.GetByID(w, r)
+			requirePermission(model.PermissionCategoriesRead, categoryHandler.GetByID)(w, r)
 		case http.MethodPut:
-			categoryHandler.Update(w, r)
+			requirePermission(model.PermissionCategoriesWrite, categoryHandler.Update)(w, r)
 		case http.MethodDelete:
-			categoryHandler.Delete(w, r)
+			requirePermission(model.PermissionCategoriesWrite, categoryHandler.Delete)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Promotion endpoints
+	mux.HandleFunc("/api/promotions", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionPromotionsRead, promotionHandler.GetAll)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionPromotionsWrite, promotionHandler.Create)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/promotions/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionPromotionsRead, promotionHandler.GetByID)(w, r)
+		case http.MethodPut:
+			requirePermission(model.PermissionPromotionsWrite, promotionHandler.Update)(w, r)
+		case http.MethodDelete:
+			requirePermission(model.PermissionPromotionsWrite, promotionHandler.Delete)(w, r)
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
 
 	// Transaction endpoints
+	mux.HandleFunc("/api/transactions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionTransactionsRead, transactionHandler.GetAll)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/transactions/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionTransactionsRead, transactionHandler.GetByID)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/transactions/{id}/void", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionTransactionsVoid, transactionHandler.Void)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/transactions/{id}/refund", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionTransactionsVoid, transactionHandler.Refund)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/transactions/{id}/receipt", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionTransactionsRead, receiptHandler.Get)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Return endpoints
+	mux.HandleFunc("/api/transactions/{id}/returns", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionReturnsRead, returnHandler.GetByTransactionID)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionReturnsWrite, returnHandler.Create)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/returns/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionReturnsRead, returnHandler.GetByID)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	mux.HandleFunc("/api/transactions/checkout", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
-			transactionHandler.Checkout(w, r)
+			requirePermission(model.PermissionCheckout, transactionHandler.Checkout)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Cart endpoints
+	mux.HandleFunc("/api/carts", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionCartsUse, cartHandler.GetHeld)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionCartsUse, cartHandler.Create)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/carts/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionCartsUse, cartHandler.GetByID)(w, r)
+		case http.MethodDelete:
+			requirePermission(model.PermissionCartsUse, cartHandler.Delete)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/carts/{id}/items", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionCartsUse, cartHandler.AddItem)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/carts/{id}/items/{product_id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			requirePermission(model.PermissionCartsUse, cartHandler.UpdateItem)(w, r)
+		case http.MethodDelete:
+			requirePermission(model.PermissionCartsUse, cartHandler.RemoveItem)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/carts/{id}/checkout", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionCheckout, cartHandler.Checkout)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Shift endpoints
+	mux.HandleFunc("/api/shifts", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionShiftsRead, shiftHandler.GetAll)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionShiftsWrite, shiftHandler.Open)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/shifts/current", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionShiftsRead, shiftHandler.GetCurrent)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/shifts/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionShiftsRead, shiftHandler.GetByID)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/shifts/{id}/cash-movements", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionShiftsWrite, shiftHandler.AddCashMovement)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/shifts/{id}/close", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionShiftsWrite, shiftHandler.Close)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Stocktake endpoints
+	mux.HandleFunc("/api/stocktakes", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionStocktakesCount, stocktakeHandler.GetAll)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionStocktakesManage, stocktakeHandler.Open)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/stocktakes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionStocktakesCount, stocktakeHandler.GetByID)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/stocktakes/{id}/counts", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionStocktakesCount, stocktakeHandler.SaveCounts)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/stocktakes/{id}/approve", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionStocktakesManage, stocktakeHandler.Approve)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/stocktakes/{id}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionStocktakesManage, stocktakeHandler.Cancel)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Supplier and purchase order endpoints
+	mux.HandleFunc("/api/suppliers", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionSuppliersRead, supplierHandler.GetAll)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionSuppliersWrite, supplierHandler.Create)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/suppliers/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionSuppliersRead, supplierHandler.GetByID)(w, r)
+		case http.MethodPut:
+			requirePermission(model.PermissionSuppliersWrite, supplierHandler.Update)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/purchase-orders", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionPurchasesRead, purchaseOrderHandler.GetAll)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionPurchasesWrite, purchaseOrderHandler.Create)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/purchase-orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionPurchasesRead, purchaseOrderHandler.GetByID)(w, r)
+		case http.MethodPut:
+			requirePermission(model.PermissionPurchasesWrite, purchaseOrderHandler.Update)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/purchase-orders/{id}/send", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionPurchasesWrite, purchaseOrderHandler.Send)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/purchase-orders/{id}/receipts", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionPurchasesReceive, purchaseOrderHandler.Receive)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/purchase-orders/{id}/close", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionPurchasesWrite, purchaseOrderHandler.Close)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Purchase return endpoints
+	mux.HandleFunc("/api/purchase-returns", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionPurchasesRead, purchaseReturnHandler.GetAll)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionPurchasesReceive, purchaseReturnHandler.Create)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/purchase-returns/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionPurchasesRead, purchaseReturnHandler.GetByID)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/purchase-returns/{id}/credits", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionPurchasesWrite, purchaseReturnHandler.RecordCredit)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Customer endpoints
+	mux.HandleFunc("/api/customers", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionCustomersRead, customerHandler.GetAll)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionCustomersWrite, customerHandler.Create)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/customers/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionCustomersRead, customerHandler.GetByID)(w, r)
+		case http.MethodPut:
+			requirePermission(model.PermissionCustomersWrite, customerHandler.Update)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/customers/{id}/erase", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionCustomersErase, customerHandler.Erase)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/customers/{id}/points", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionCustomersRead, customerHandler.GetLedger)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/customers/{id}/transactions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionCustomersRead, customerHandler.GetTransactions)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Outlet endpoints
+	mux.HandleFunc("/api/outlets", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionOutletsRead, outletHandler.GetAll)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionOutletsWrite, outletHandler.Create)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/outlets/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionOutletsRead, outletHandler.GetByID)(w, r)
+		case http.MethodPut:
+			requirePermission(model.PermissionOutletsWrite, outletHandler.Update)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// User endpoints
+	mux.HandleFunc("/api/users", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionUsersRead, userHandler.GetAll)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionUsersWrite, userHandler.Create)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/users/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionUsersRead, userHandler.GetByID)(w, r)
+		case http.MethodPut:
+			requirePermission(model.PermissionUsersWrite, userHandler.Update)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/users/{id}/role", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			requirePermission(model.PermissionRolesManage, userHandler.AssignRole)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/roles", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionUsersRead, userHandler.GetRoles)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Device endpoints; pairing is reached without a token, the pairing code is the credential
+	mux.HandleFunc("/api/devices/pair", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			deviceHandler.Pair(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/devices", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			requirePermission(model.PermissionDevicesManage, deviceHandler.GetAll)(w, r)
+		case http.MethodPost:
+			requirePermission(model.PermissionDevicesManage, deviceHandler.Register)(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/devices/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionDevicesManage, deviceHandler.GetByID)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/devices/{id}/revoke", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			requirePermission(model.PermissionDevicesManage, deviceHandler.Revoke)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Audit log endpoint; the log is read-only, entries are appended by every change
+	mux.HandleFunc("/api/audit", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionAuditRead, auditHandler.GetAll)(w, r)
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -76,7 +628,7 @@ func SetupRoutes(mux *http.ServeMux, productHandler *ProductHandler, categoryHan
 	// Report endpoints
 	mux.HandleFunc("/api/reports/today", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
-			reportHandler.Today(w, r)
+			requirePermission(model.PermissionReportsRead, reportHandler.Today)(w, r)
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -84,7 +636,15 @@ func SetupRoutes(mux *http.ServeMux, productHandler *ProductHandler, categoryHan
 
 	mux.HandleFunc("/api/reports", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
-			reportHandler.ByDateRange(w, r)
+			requirePermission(model.PermissionReportsRead, reportHandler.ByDateRange)(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/reports/inventory", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requirePermission(model.PermissionReportsRead, reportHandler.Inventory)(w, r)
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -93,7 +653,11 @@ func SetupRoutes(mux *http.ServeMux, productHandler *ProductHandler, categoryHan
 	// Apply middleware and return wrapped handler
 	return middleware.LoggingMiddleware(
 		middleware.CORSMiddleware(
-			middleware.RecoveryMiddleware(mux),
+			middleware.RecoveryMiddleware(
+				middleware.AuthMiddleware(authenticator, deviceAuthenticator)(
+					middleware.OutletMiddleware(mux),
+				),
+			),
 		),
 	)
 }
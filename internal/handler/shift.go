@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/httputil"
+)
+
+type ShiftService interface {
+	Open(ctx context.Context, req model.OpenShiftRequest) (*model.Shift, error)
+	GetCurrent(ctx context.Context) (*model.Shift, error)
+	GetByID(ctx context.Context, id int) (*model.Shift, error)
+	GetAll(ctx context.Context) ([]model.Shift, error)
+	AddCashMovement(ctx context.Context, shiftID int, req model.CashMovementRequest) (*model.CashMovement, error)
+	Close(ctx context.Context, id int, req model.CloseShiftRequest) (*model.Shift, error)
+}
+
+type ShiftHandler struct {
+	svc ShiftService
+}
+
+func NewShiftHandler(svc ShiftService) *ShiftHandler {
+	return &ShiftHandler{svc: svc}
+}
+
+func (h *ShiftHandler) Open(w http.ResponseWriter, r *http.Request) {
+	var req model.OpenShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	shift, err := h.svc.Open(r.Context(), req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, shift)
+}
+
+func (h *ShiftHandler) GetCurrent(w http.ResponseWriter, r *http.Request) {
+	shift, err := h.svc.GetCurrent(r.Context())
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, shift)
+}
+
+func (h *ShiftHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParseID(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	shift, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, shift)
+}
+
+func (h *ShiftHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	shifts, err := h.svc.GetAll(r.Context())
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, shifts)
+}
+
+func (h *ShiftHandler) AddCashMovement(w http.ResponseWriter, r *http.Request) {
+	shiftID, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.CashMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	movement, err := h.svc.AddCashMovement(r.Context(), shiftID, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, movement)
+}
+
+func (h *ShiftHandler) Close(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.CloseShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	shift, err := h.svc.Close(r.Context(), id, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, shift)
+}
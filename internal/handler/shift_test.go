@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+	"kasir-api/internal/service"
+	"kasir-api/pkg/middleware"
+)
+
+// Mock service for testing
+type mockShiftService struct {
+	openFunc            func(ctx context.Context, req model.OpenShiftRequest) (*model.Shift, error)
+	getCurrentFunc      func(ctx context.Context) (*model.Shift, error)
+	getByIDFunc         func(ctx context.Context, id int) (*model.Shift, error)
+	getAllFunc          func(ctx context.Context) ([]model.Shift, error)
+	addCashMovementFunc func(ctx context.Context, shiftID int, req model.CashMovementRequest) (*model.CashMovement, error)
+	closeFunc           func(ctx context.Context, id int, req model.CloseShiftRequest) (*model.Shift, error)
+}
+
+func (m *mockShiftService) Open(ctx context.Context, req model.OpenShiftRequest) (*model.Shift, error) {
+	return m.openFunc(ctx, req)
+}
+
+func (m *mockShiftService) GetCurrent(ctx context.Context) (*model.Shift, error) {
+	return m.getCurrentFunc(ctx)
+}
+
+func (m *mockShiftService) GetByID(ctx context.Context, id int) (*model.Shift, error) {
+	return m.getByIDFunc(ctx, id)
+}
+
+func (m *mockShiftService) GetAll(ctx context.Context) ([]model.Shift, error) {
+	return m.getAllFunc(ctx)
+}
+
+func (m *mockShiftService) AddCashMovement(ctx context.Context, shiftID int, req model.CashMovementRequest) (*model.CashMovement, error) {
+	return m.addCashMovementFunc(ctx, shiftID, req)
+}
+
+func (m *mockShiftService) Close(ctx context.Context, id int, req model.CloseShiftRequest) (*model.Shift, error) {
+	return m.closeFunc(ctx, id, req)
+}
+
+func TestShiftHandler_Open_AlreadyOpen(t *testing.T) {
+	mockSvc := &mockShiftService{
+		openFunc: func(ctx context.Context, req model.OpenShiftRequest) (*model.Shift, error) {
+			return nil, model.ErrConflict
+		},
+	}
+
+	handler := NewShiftHandler(mockSvc)
+	body := bytes.NewBufferString(`{"opening_float":100000}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/shifts", body)
+	w := httptest.NewRecorder()
+
+	handler.Open(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", w.Code)
+	}
+}
+
+func TestShiftHandler_AddCashMovement(t *testing.T) {
+	mockSvc := &mockShiftService{
+		addCashMovementFunc: func(ctx context.Context, shiftID int, req model.CashMovementRequest) (*model.CashMovement, error) {
+			if shiftID != 3 || req.Type != model.CashMovementOut || req.Amount != 200000 {
+				t.Errorf("Unexpected request for shift %d: %+v", shiftID, req)
+			}
+			return &model.CashMovement{ID: 1, ShiftID: shiftID, Type: req.Type, Amount: req.Amount}, nil
+		},
+	}
+
+	handler := NewShiftHandler(mockSvc)
+	body := bytes.NewBufferString(`{"type":"out","amount":200000,"reason":"Safe drop"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/shifts/3/cash-movements", body)
+	req.SetPathValue("id", "3")
+	w := httptest.NewRecorder()
+
+	handler.AddCashMovement(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", w.Code)
+	}
+}
+
+func TestShiftHandler_Close(t *testing.T) {
+	mockSvc := &mockShiftService{
+		closeFunc: func(ctx context.Context, id int, req model.CloseShiftRequest) (*model.Shift, error) {
+			if id != 3 || req.CountedCash == nil || *req.CountedCash != 150000 {
+				t.Errorf("Unexpected request for shift %d: %+v", id, req)
+			}
+			return &model.Shift{ID: id, Status: model.ShiftStatusClosed}, nil
+		},
+	}
+
+	handler := NewShiftHandler(mockSvc)
+	body := bytes.NewBufferString(`{"counted_cash":150000}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/shifts/3/close", body)
+	req.SetPathValue("id", "3")
+	w := httptest.NewRecorder()
+
+	handler.Close(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+func TestShiftHandler_ActorFromPrincipal(t *testing.T) {
+	repo := memory.NewShiftRepository(memory.NewTransactionRepository(memory.NewProductRepository()))
+	handler := NewShiftHandler(service.NewShiftService(repo, repo))
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{UserID: 2, Username: "siti"})
+
+	// Names in the body are not trusted; the shift is recorded against the signed-in user
+	req := httptest.NewRequest(http.MethodPost, "/api/shifts", bytes.NewBufferString(`{"opened_by":"budi","opening_float":100000}`)).WithContext(ctx)
+	w := httptest.NewRecorder()
+	handler.Open(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Open: expected status 201, got %d: %s", w.Code, w.Body)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/shifts/1/cash-movements",
+		bytes.NewBufferString(`{"type":"out","amount":50000,"reason":"Petty cash","performed_by":"budi"}`)).WithContext(ctx)
+	req.SetPathValue("id", "1")
+	w = httptest.NewRecorder()
+	handler.AddCashMovement(w, req)
+	var movement model.CashMovement
+	json.NewDecoder(w.Body).Decode(&movement)
+	if movement.PerformedBy != "siti" {
+		t.Errorf("PerformedBy = %q, want siti", movement.PerformedBy)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/shifts/1/close", bytes.NewBufferString(`{"closed_by":"budi","counted_cash":50000}`)).WithContext(ctx)
+	req.SetPathValue("id", "1")
+	w = httptest.NewRecorder()
+	handler.Close(w, req)
+	var shift model.Shift
+	json.NewDecoder(w.Body).Decode(&shift)
+	if shift.OpenedBy != "siti" || shift.ClosedBy != "siti" {
+		t.Errorf("OpenedBy = %q, ClosedBy = %q, want siti", shift.OpenedBy, shift.ClosedBy)
+	}
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"kasir-api/internal/dto"
+	"kasir-api/internal/model"
+	"kasir-api/pkg/httputil"
+)
+
+type StockService interface {
+	GetMovements(ctx context.Context, productID int, filter model.StockMovementFilter) ([]model.StockMovement, int, error)
+	Post(ctx context.Context, productID int, req model.StockMovementRequest) ([]model.StockMovement, error)
+	GetLowStock(ctx context.Context) ([]model.LowStockItem, error)
+	GetAlerts(ctx context.Context, filter model.StockAlertFilter) ([]model.StockAlert, error)
+}
+
+type StockHandler struct {
+	svc StockService
+}
+
+func NewStockHandler(svc StockService) *StockHandler {
+	return &StockHandler{svc: svc}
+}
+
+func (h *StockHandler) GetMovements(w http.ResponseWriter, r *http.Request) {
+	productID, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	filter, err := parseStockMovementFilter(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	movements, total, err := h.svc.GetMovements(r.Context(), productID, filter)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	filter = filter.WithDefaults()
+	httputil.WriteJSON(w, http.StatusOK, dto.StockMovementListResponse{
+		Data:  movements,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	})
+}
+
+func (h *StockHandler) Post(w http.ResponseWriter, r *http.Request) {
+	productID, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.StockMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	movements, err := h.svc.Post(r.Context(), productID, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusCreated, movements)
+}
+
+func (h *StockHandler) LowStock(w http.ResponseWriter, r *http.Request) {
+	items, err := h.svc.GetLowStock(r.Context())
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, items)
+}
+
+func (h *StockHandler) Alerts(w http.ResponseWriter, r *http.Request) {
+	var filter model.StockAlertFilter
+	productID, err := httputil.QueryInt(r, "product_id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	if productID != nil {
+		filter.ProductID = *productID
+	}
+	limit, err := httputil.QueryInt(r, "limit")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	if limit != nil {
+		filter.Limit = *limit
+	}
+
+	alerts, err := h.svc.GetAlerts(r.Context(), filter)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, alerts)
+}
+
+// parseStockMovementFilter reads the movement filters and pagination from the query
+// string. The outlet_id parameter picks an outlet; otherwise the X-Outlet-ID header
+// applies, and without either the movements of every outlet are listed.
+func parseStockMovementFilter(r *http.Request) (model.StockMovementFilter, error) {
+	filter := model.StockMovementFilter{
+		Type: r.URL.Query().Get("type"),
+	}
+	if outletID, ok := model.ScopedOutletID(r.Context()); ok {
+		filter.OutletID = outletID
+	}
+
+	outletID, err := queryOutletID(r)
+	if err != nil {
+		return filter, err
+	}
+	if outletID != nil {
+		filter.OutletID = *outletID
+	}
+
+	page, err := httputil.QueryInt(r, "page")
+	if err != nil {
+		return filter, err
+	}
+	if page != nil {
+		filter.Page = *page
+	}
+
+	limit, err := httputil.QueryInt(r, "limit")
+	if err != nil {
+		return filter, err
+	}
+	if limit != nil {
+		filter.Limit = *limit
+	}
+
+	return filter, nil
+}
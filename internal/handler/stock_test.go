@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"kasir-api/internal/dto"
+	"kasir-api/internal/model"
+	"kasir-api/pkg/middleware"
+)
+
+type mockStockService struct {
+	getMovementsFunc func(ctx context.Context, productID int, filter model.StockMovementFilter) ([]model.StockMovement, int, error)
+	postFunc         func(ctx context.Context, productID int, req model.StockMovementRequest) ([]model.StockMovement, error)
+	getLowStockFunc  func(ctx context.Context) ([]model.LowStockItem, error)
+	getAlertsFunc    func(ctx context.Context, filter model.StockAlertFilter) ([]model.StockAlert, error)
+}
+
+func (m *mockStockService) GetMovements(ctx context.Context, productID int, filter model.StockMovementFilter) ([]model.StockMovement, int, error) {
+	return m.getMovementsFunc(ctx, productID, filter)
+}
+
+func (m *mockStockService) Post(ctx context.Context, productID int, req model.StockMovementRequest) ([]model.StockMovement, error) {
+	return m.postFunc(ctx, productID, req)
+}
+
+func (m *mockStockService) GetLowStock(ctx context.Context) ([]model.LowStockItem, error) {
+	return m.getLowStockFunc(ctx)
+}
+
+func (m *mockStockService) GetAlerts(ctx context.Context, filter model.StockAlertFilter) ([]model.StockAlert, error) {
+	return m.getAlertsFunc(ctx, filter)
+}
+
+func TestStockHandler_GetMovements(t *testing.T) {
+	var gotProductID int
+	var gotFilter model.StockMovementFilter
+	mockSvc := &mockStockService{
+		getMovementsFunc: func(ctx context.Context, productID int, filter model.StockMovementFilter) ([]model.StockMovement, int, error) {
+			gotProductID, gotFilter = productID, filter
+			return []model.StockMovement{{ID: 4, ProductID: productID, Type: model.StockMovementSale, Quantity: -2, Balance: 8}}, 1, nil
+		},
+	}
+
+	handler := NewStockHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/api/products/3/stock-movements?type=sale&page=2&limit=10", nil)
+	req.SetPathValue("id", "3")
+	req = req.WithContext(middleware.WithOutletID(req.Context(), 2))
+	w := httptest.NewRecorder()
+
+	handler.GetMovements(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if gotProductID != 3 || gotFilter.Type != "sale" || gotFilter.OutletID != 2 {
+		t.Errorf("Unexpected product %d or filter %+v", gotProductID, gotFilter)
+	}
+
+	var resp dto.StockMovementListResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Total != 1 || len(resp.Data) != 1 || resp.Data[0].Balance != 8 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+	if resp.Page != 2 || resp.Limit != 10 {
+		t.Errorf("Expected page 2 limit 10, got page %d limit %d", resp.Page, resp.Limit)
+	}
+
+	// outlet_id picks another outlet than the one the request is scoped to
+	req = httptest.NewRequest(http.MethodGet, "/api/products/3/stock-movements?outlet_id=5", nil)
+	req.SetPathValue("id", "3")
+	req = req.WithContext(middleware.WithOutletID(req.Context(), 2))
+	handler.GetMovements(httptest.NewRecorder(), req)
+	if gotFilter.OutletID != 5 {
+		t.Errorf("OutletID = %d, want 5", gotFilter.OutletID)
+	}
+}
+
+func TestStockHandler_Post(t *testing.T) {
+	var gotReq model.StockMovementRequest
+	mockSvc := &mockStockService{
+		postFunc: func(ctx context.Context, productID int, req model.StockMovementRequest) ([]model.StockMovement, error) {
+			gotReq = req
+			return []model.StockMovement{{ID: 1, ProductID: productID, Type: req.Type, Quantity: -req.Quantity, Balance: 7}}, nil
+		},
+	}
+
+	handler := NewStockHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodPost, "/api/products/3/stock-movements", strings.NewReader(`{"type":"write_off","quantity":3,"reason":"expired","note":"past date"}`))
+	req.SetPathValue("id", "3")
+	w := httptest.NewRecorder()
+
+	handler.Post(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	if gotReq.Type != model.StockMovementWriteOff || gotReq.Quantity != 3 || gotReq.Reason != model.StockReasonExpired {
+		t.Errorf("Unexpected request: %+v", gotReq)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/products/abc/stock-movements", strings.NewReader(`{}`))
+	req.SetPathValue("id", "abc")
+	w = httptest.NewRecorder()
+	handler.Post(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a bad id, got %d", w.Code)
+	}
+}
+
+func TestStockHandler_LowStock(t *testing.T) {
+	mockSvc := &mockStockService{
+		getLowStockFunc: func(ctx context.Context) ([]model.LowStockItem, error) {
+			return []model.LowStockItem{{ProductID: 1, ProductName: "Indomie", Stock: 3, ReorderPoint: 5, ReorderQty: 24}}, nil
+		},
+	}
+
+	handler := NewStockHandler(mockSvc)
+	w := httptest.NewRecorder()
+	handler.LowStock(w, httptest.NewRequest(http.MethodGet, "/api/inventory/low-stock", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp []model.LowStockItem
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp) != 1 || resp[0].ReorderQty != 24 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+}
+
+func TestStockHandler_Alerts(t *testing.T) {
+	var gotFilter model.StockAlertFilter
+	mockSvc := &mockStockService{
+		getAlertsFunc: func(ctx context.Context, filter model.StockAlertFilter) ([]model.StockAlert, error) {
+			gotFilter = filter
+			return []model.StockAlert{{ID: 1, ProductID: filter.ProductID, Stock: 4, ReorderPoint: 5}}, nil
+		},
+	}
+
+	handler := NewStockHandler(mockSvc)
+	w := httptest.NewRecorder()
+	handler.Alerts(w, httptest.NewRequest(http.MethodGet, "/api/inventory/alerts?product_id=7&limit=10", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if gotFilter.ProductID != 7 || gotFilter.Limit != 10 {
+		t.Errorf("Unexpected filter %+v", gotFilter)
+	}
+
+	w = httptest.NewRecorder()
+	handler.Alerts(w, httptest.NewRequest(http.MethodGet, "/api/inventory/alerts?limit=abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a bad limit, got %d", w.Code)
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/httputil"
+)
+
+type StocktakeService interface {
+	Open(ctx context.Context, req model.OpenStocktakeRequest) (*model.Stocktake, error)
+	GetByID(ctx context.Context, id int) (*model.Stocktake, error)
+	GetAll(ctx context.Context) ([]model.Stocktake, error)
+	SaveCounts(ctx context.Context, id int, req model.StocktakeCountRequest) (*model.Stocktake, error)
+	Approve(ctx context.Context, id int) (*model.Stocktake, error)
+	Cancel(ctx context.Context, id int) (*model.Stocktake, error)
+}
+
+type StocktakeHandler struct {
+	svc StocktakeService
+}
+
+func NewStocktakeHandler(svc StocktakeService) *StocktakeHandler {
+	return &StocktakeHandler{svc: svc}
+}
+
+func (h *StocktakeHandler) Open(w http.ResponseWriter, r *http.Request) {
+	var req model.OpenStocktakeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	stocktake, err := h.svc.Open(r.Context(), req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, stocktake)
+}
+
+func (h *StocktakeHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	stocktake, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, stocktake)
+}
+
+func (h *StocktakeHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	stocktakes, err := h.svc.GetAll(r.Context())
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, stocktakes)
+}
+
+func (h *StocktakeHandler) SaveCounts(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.StocktakeCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	stocktake, err := h.svc.SaveCounts(r.Context(), id, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, stocktake)
+}
+
+func (h *StocktakeHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	stocktake, err := h.svc.Approve(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, stocktake)
+}
+
+func (h *StocktakeHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	stocktake, err := h.svc.Cancel(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, stocktake)
+}
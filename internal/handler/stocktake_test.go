@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"kasir-api/internal/model"
+)
+
+type mockStocktakeService struct {
+	openFunc       func(ctx context.Context, req model.OpenStocktakeRequest) (*model.Stocktake, error)
+	getByIDFunc    func(ctx context.Context, id int) (*model.Stocktake, error)
+	getAllFunc     func(ctx context.Context) ([]model.Stocktake, error)
+	saveCountsFunc func(ctx context.Context, id int, req model.StocktakeCountRequest) (*model.Stocktake, error)
+	approveFunc    func(ctx context.Context, id int) (*model.Stocktake, error)
+	cancelFunc     func(ctx context.Context, id int) (*model.Stocktake, error)
+}
+
+func (m *mockStocktakeService) Open(ctx context.Context, req model.OpenStocktakeRequest) (*model.Stocktake, error) {
+	return m.openFunc(ctx, req)
+}
+
+func (m *mockStocktakeService) GetByID(ctx context.Context, id int) (*model.Stocktake, error) {
+	return m.getByIDFunc(ctx, id)
+}
+
+func (m *mockStocktakeService) GetAll(ctx context.Context) ([]model.Stocktake, error) {
+	return m.getAllFunc(ctx)
+}
+
+func (m *mockStocktakeService) SaveCounts(ctx context.Context, id int, req model.StocktakeCountRequest) (*model.Stocktake, error) {
+	return m.saveCountsFunc(ctx, id, req)
+}
+
+func (m *mockStocktakeService) Approve(ctx context.Context, id int) (*model.Stocktake, error) {
+	return m.approveFunc(ctx, id)
+}
+
+func (m *mockStocktakeService) Cancel(ctx context.Context, id int) (*model.Stocktake, error) {
+	return m.cancelFunc(ctx, id)
+}
+
+func TestStocktakeHandler_Open(t *testing.T) {
+	var gotReq model.OpenStocktakeRequest
+	mockSvc := &mockStocktakeService{
+		openFunc: func(ctx context.Context, req model.OpenStocktakeRequest) (*model.Stocktake, error) {
+			gotReq = req
+			return &model.Stocktake{ID: 1, Status: model.StocktakeStatusOpen, CategoryID: req.CategoryID}, nil
+		},
+	}
+
+	handler := NewStocktakeHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodPost, "/api/stocktakes", strings.NewReader(`{"category_id":2,"note":"drinks aisle"}`))
+	w := httptest.NewRecorder()
+
+	handler.Open(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	if gotReq.CategoryID == nil || *gotReq.CategoryID != 2 || gotReq.Note != "drinks aisle" {
+		t.Errorf("Unexpected request: %+v", gotReq)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/stocktakes", strings.NewReader(`{`))
+	w = httptest.NewRecorder()
+	handler.Open(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid JSON, got %d", w.Code)
+	}
+}
+
+func TestStocktakeHandler_SaveCounts(t *testing.T) {
+	var gotID int
+	var gotReq model.StocktakeCountRequest
+	mockSvc := &mockStocktakeService{
+		saveCountsFunc: func(ctx context.Context, id int, req model.StocktakeCountRequest) (*model.Stocktake, error) {
+			gotID, gotReq = id, req
+			return &model.Stocktake{ID: id, Summary: &model.StocktakeSummary{Counted: 1}}, nil
+		},
+	}
+
+	handler := NewStocktakeHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodPost, "/api/stocktakes/4/counts", strings.NewReader(`{"items":[{"product_id":1,"counted_quantity":0,"reason":"expired"}]}`))
+	req.SetPathValue("id", "4")
+	w := httptest.NewRecorder()
+
+	handler.SaveCounts(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if gotID != 4 || len(gotReq.Items) != 1 || gotReq.Items[0].CountedQuantity == nil || *gotReq.Items[0].CountedQuantity != 0 {
+		t.Errorf("Unexpected id %d or request %+v", gotID, gotReq)
+	}
+
+	var resp model.Stocktake
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Summary == nil || resp.Summary.Counted != 1 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+}
+
+func TestStocktakeHandler_Approve(t *testing.T) {
+	mockSvc := &mockStocktakeService{
+		approveFunc: func(ctx context.Context, id int) (*model.Stocktake, error) {
+			if id == 9 {
+				return nil, model.StocktakeClosedError(id, model.StocktakeStatusCancelled)
+			}
+			return &model.Stocktake{ID: id, Status: model.StocktakeStatusApproved}, nil
+		},
+	}
+	handler := NewStocktakeHandler(mockSvc)
+
+	tests := []struct {
+		name string
+		id   string
+		want int
+	}{
+		{"approved", "4", http.StatusOK},
+		{"already cancelled", "9", http.StatusConflict},
+		{"bad id", "abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/stocktakes/"+tt.id+"/approve", nil)
+			req.SetPathValue("id", tt.id)
+			w := httptest.NewRecorder()
+			handler.Approve(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
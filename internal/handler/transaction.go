@@ -5,12 +5,25 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"kasir-api/internal/dto"
 	"kasir-api/internal/model"
 	"kasir-api/pkg/httputil"
 )
 
+const (
+	// IdempotencyKeyHeader lets clients retry checkout without charging twice
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response that was replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
 type TransactionService interface {
 	Checkout(ctx context.Context, req model.CheckoutRequest) (*model.Transaction, error)
+	CheckoutWithKey(ctx context.Context, key string, req model.CheckoutRequest) (*model.Transaction, bool, error)
+	GetByID(ctx context.Context, id int) (*model.Transaction, error)
+	GetAll(ctx context.Context, filter model.TransactionFilter) ([]model.Transaction, int, error)
+	Void(ctx context.Context, id int, req model.CancelRequest) (*model.Transaction, error)
+	Refund(ctx context.Context, id int, req model.CancelRequest) (*model.Transaction, error)
 }
 
 type TransactionHandler struct {
@@ -28,11 +41,144 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transaction, err := h.svc.Checkout(r.Context(), req)
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		transaction, err := h.svc.Checkout(r.Context(), req)
+		if err != nil {
+			httputil.HandleError(w, err)
+			return
+		}
+		httputil.WriteJSON(w, http.StatusCreated, transaction)
+		return
+	}
+
+	transaction, replayed, err := h.svc.CheckoutWithKey(r.Context(), key, req)
 	if err != nil {
 		httputil.HandleError(w, err)
 		return
 	}
 
+	if replayed {
+		w.Header().Set(IdempotentReplayedHeader, "true")
+	}
 	httputil.WriteJSON(w, http.StatusCreated, transaction)
 }
+
+func (h *TransactionHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	transactions, total, err := h.svc.GetAll(r.Context(), filter)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	filter = filter.WithDefaults()
+	httputil.WriteJSON(w, http.StatusOK, dto.TransactionListResponse{
+		Data:  transactions,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	})
+}
+
+func (h *TransactionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := httputil.ParseID(r)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	transaction, err := h.svc.GetByID(r.Context(), id)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, transaction)
+}
+
+func (h *TransactionHandler) Void(w http.ResponseWriter, r *http.Request) {
+	h.cancel(w, r, h.svc.Void)
+}
+
+func (h *TransactionHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	h.cancel(w, r, h.svc.Refund)
+}
+
+func (h *TransactionHandler) cancel(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, id int, req model.CancelRequest) (*model.Transaction, error)) {
+	id, err := httputil.ParsePathID(r, "id")
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	var req model.CancelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	transaction, err := fn(r.Context(), id, req)
+	if err != nil {
+		httputil.HandleError(w, err)
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, transaction)
+}
+
+// parseTransactionFilter reads the list filters and pagination from the query string
+func parseTransactionFilter(r *http.Request) (model.TransactionFilter, error) {
+	query := r.URL.Query()
+	filter := model.TransactionFilter{
+		StartDate: query.Get("start_date"),
+		EndDate:   query.Get("end_date"),
+		Status:    query.Get("status"),
+		Invoice:   query.Get("invoice_number"),
+	}
+
+	var err error
+	if filter.MinAmount, err = httputil.QueryInt(r, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = httputil.QueryInt(r, "max_amount"); err != nil {
+		return filter, err
+	}
+	if filter.ProductID, err = httputil.QueryInt(r, "product_id"); err != nil {
+		return filter, err
+	}
+	if filter.CustomerID, err = httputil.QueryInt(r, "customer_id"); err != nil {
+		return filter, err
+	}
+	if filter.OutletID, err = queryOutletID(r); err != nil {
+		return filter, err
+	}
+	if filter.OutletID == nil {
+		if outletID, ok := model.ScopedOutletID(r.Context()); ok {
+			filter.OutletID = &outletID
+		}
+	}
+
+	page, err := httputil.QueryInt(r, "page")
+	if err != nil {
+		return filter, err
+	}
+	if page != nil {
+		filter.Page = *page
+	}
+
+	limit, err := httputil.QueryInt(r, "limit")
+	if err != nil {
+		return filter, err
+	}
+	if limit != nil {
+		filter.Limit = *limit
+	}
+
+	return filter, nil
+}
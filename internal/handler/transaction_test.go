@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kasir-api/internal/dto"
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+	"kasir-api/internal/service"
+	"kasir-api/pkg/middleware"
+)
+
+// Mock service for testing
+type mockTransactionService struct {
+	checkoutFunc        func(ctx context.Context, req model.CheckoutRequest) (*model.Transaction, error)
+	checkoutWithKeyFunc func(ctx context.Context, key string, req model.CheckoutRequest) (*model.Transaction, bool, error)
+	getByIDFunc         func(ctx context.Context, id int) (*model.Transaction, error)
+	getAllFunc          func(ctx context.Context, filter model.TransactionFilter) ([]model.Transaction, int, error)
+	voidFunc            func(ctx context.Context, id int, req model.CancelRequest) (*model.Transaction, error)
+	refundFunc          func(ctx context.Context, id int, req model.CancelRequest) (*model.Transaction, error)
+}
+
+func (m *mockTransactionService) Checkout(ctx context.Context, req model.CheckoutRequest) (*model.Transaction, error) {
+	return m.checkoutFunc(ctx, req)
+}
+
+func (m *mockTransactionService) CheckoutWithKey(ctx context.Context, key string, req model.CheckoutRequest) (*model.Transaction, bool, error) {
+	return m.checkoutWithKeyFunc(ctx, key, req)
+}
+
+func (m *mockTransactionService) GetByID(ctx context.Context, id int) (*model.Transaction, error) {
+	return m.getByIDFunc(ctx, id)
+}
+
+func (m *mockTransactionService) GetAll(ctx context.Context, filter model.TransactionFilter) ([]model.Transaction, int, error) {
+	return m.getAllFunc(ctx, filter)
+}
+
+func (m *mockTransactionService) Void(ctx context.Context, id int, req model.CancelRequest) (*model.Transaction, error) {
+	return m.voidFunc(ctx, id, req)
+}
+
+func (m *mockTransactionService) Refund(ctx context.Context, id int, req model.CancelRequest) (*model.Transaction, error) {
+	return m.refundFunc(ctx, id, req)
+}
+
+func TestTransactionHandler_Checkout_IdempotencyKey(t *testing.T) {
+	var gotKey string
+	mockSvc := &mockTransactionService{
+		checkoutWithKeyFunc: func(ctx context.Context, key string, req model.CheckoutRequest) (*model.Transaction, bool, error) {
+			gotKey = key
+			return &model.Transaction{ID: 9, TotalAmount: 3500}, true, nil
+		},
+	}
+
+	handler := NewTransactionHandler(mockSvc)
+	body := `{"items":[{"product_id":1,"quantity":1}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions/checkout", bytes.NewBufferString(body))
+	req.Header.Set(IdempotencyKeyHeader, "abc-123")
+	w := httptest.NewRecorder()
+
+	handler.Checkout(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	if gotKey != "abc-123" {
+		t.Errorf("Expected key abc-123, got %q", gotKey)
+	}
+	if w.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("Expected %s header on replay", IdempotentReplayedHeader)
+	}
+}
+
+func TestTransactionHandler_GetAll(t *testing.T) {
+	var gotFilter model.TransactionFilter
+	mockSvc := &mockTransactionService{
+		getAllFunc: func(ctx context.Context, filter model.TransactionFilter) ([]model.Transaction, int, error) {
+			gotFilter = filter
+			return []model.Transaction{{ID: 1, TotalAmount: 7000}}, 1, nil
+		},
+	}
+
+	handler := NewTransactionHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions?start_date=2026-01-01&end_date=2026-01-31&min_amount=1000&product_id=3&page=2&limit=10", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetAll(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if gotFilter.StartDate != "2026-01-01" || gotFilter.EndDate != "2026-01-31" {
+		t.Errorf("Unexpected date filter: %+v", gotFilter)
+	}
+	if gotFilter.MinAmount == nil || *gotFilter.MinAmount != 1000 {
+		t.Errorf("MinAmount = %v, want 1000", gotFilter.MinAmount)
+	}
+	if gotFilter.ProductID == nil || *gotFilter.ProductID != 3 {
+		t.Errorf("ProductID = %v, want 3", gotFilter.ProductID)
+	}
+
+	var resp dto.TransactionListResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Total != 1 || len(resp.Data) != 1 {
+		t.Errorf("Expected 1 transaction, got total %d data %d", resp.Total, len(resp.Data))
+	}
+	if resp.Page != 2 || resp.Limit != 10 {
+		t.Errorf("Expected page 2 limit 10, got page %d limit %d", resp.Page, resp.Limit)
+	}
+}
+
+func TestTransactionHandler_GetAll_DefaultsToContextOutlet(t *testing.T) {
+	var gotFilter model.TransactionFilter
+	mockSvc := &mockTransactionService{
+		getAllFunc: func(ctx context.Context, filter model.TransactionFilter) ([]model.Transaction, int, error) {
+			gotFilter = filter
+			return []model.Transaction{}, 0, nil
+		},
+	}
+
+	handler := NewTransactionHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions", nil)
+	req = req.WithContext(middleware.WithOutletID(req.Context(), 2))
+	w := httptest.NewRecorder()
+
+	handler.GetAll(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if gotFilter.OutletID == nil || *gotFilter.OutletID != 2 {
+		t.Errorf("OutletID = %v, want 2", gotFilter.OutletID)
+	}
+}
+
+func TestTransactionHandler_GetAll_DeviceOutlet(t *testing.T) {
+	handler := NewTransactionHandler(&mockTransactionService{
+		getAllFunc: func(ctx context.Context, filter model.TransactionFilter) ([]model.Transaction, int, error) {
+			return []model.Transaction{}, 0, nil
+		},
+	})
+	device := &middleware.Principal{DeviceID: 4, Role: string(model.DeviceRole), OutletID: 1}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"?outlet_id=1", http.StatusOK},
+		{"?outlet_id=2", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions"+tt.query, nil)
+		req = req.WithContext(middleware.WithPrincipal(req.Context(), device))
+		w := httptest.NewRecorder()
+
+		handler.GetAll(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("GetAll(%s) status = %d, want %d", tt.query, w.Code, tt.want)
+		}
+	}
+}
+
+func TestTransactionHandler_GetAll_InvalidAmount(t *testing.T) {
+	mockSvc := &mockTransactionService{}
+	handler := NewTransactionHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions?min_amount=abc", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetAll(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestTransactionHandler_GetByID(t *testing.T) {
+	mockSvc := &mockTransactionService{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Transaction, error) {
+			return &model.Transaction{
+				ID:          id,
+				TotalAmount: 7000,
+				Details: []model.TransactionDetail{
+					{ID: 1, TransactionID: id, ProductID: 1, Quantity: 2, Subtotal: 7000},
+				},
+			}, nil
+		},
+	}
+
+	handler := NewTransactionHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions/5", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetByID(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var transaction model.Transaction
+	json.NewDecoder(w.Body).Decode(&transaction)
+	if transaction.ID != 5 || len(transaction.Details) != 1 {
+		t.Errorf("Unexpected transaction: %+v", transaction)
+	}
+}
+
+func TestTransactionHandler_GetByID_NotFound(t *testing.T) {
+	mockSvc := &mockTransactionService{
+		getByIDFunc: func(ctx context.Context, id int) (*model.Transaction, error) {
+			return nil, model.ErrNotFound
+		},
+	}
+
+	handler := NewTransactionHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions/999", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetByID(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestTransactionHandler_Void(t *testing.T) {
+	mockSvc := &mockTransactionService{
+		voidFunc: func(ctx context.Context, id int, req model.CancelRequest) (*model.Transaction, error) {
+			if req.Reason != "wrong item" {
+				t.Errorf("Unexpected request: %+v", req)
+			}
+			return &model.Transaction{ID: id, Status: model.TransactionStatusVoided}, nil
+		},
+	}
+
+	handler := NewTransactionHandler(mockSvc)
+	body := bytes.NewBufferString(`{"reason":"wrong item"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions/7/void", body)
+	req.SetPathValue("id", "7")
+	w := httptest.NewRecorder()
+
+	handler.Void(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var transaction model.Transaction
+	json.NewDecoder(w.Body).Decode(&transaction)
+	if transaction.ID != 7 || transaction.Status != model.TransactionStatusVoided {
+		t.Errorf("Unexpected transaction: %+v", transaction)
+	}
+}
+
+func TestTransactionHandler_Void_ActorFromPrincipal(t *testing.T) {
+	productRepo := memory.NewProductRepository()
+	transactionRepo := memory.NewTransactionRepository(productRepo)
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{UserID: 2, Username: "siti"})
+	productRepo.Create(ctx, model.Product{Name: "Indomie", Price: 3500, Stock: 10, Active: true})
+	sale, _ := transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}}, model.CheckoutOptions{})
+
+	handler := NewTransactionHandler(service.NewTransactionService(transactionRepo, transactionRepo))
+	// A name in the body is not trusted; the void is recorded against the signed-in user
+	body := bytes.NewBufferString(`{"reason":"wrong item","performed_by":"budi"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions/1/void", body).WithContext(ctx)
+	req.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+
+	handler.Void(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+	}
+	var transaction model.Transaction
+	json.NewDecoder(w.Body).Decode(&transaction)
+	if transaction.ID != sale.ID || transaction.CancelledBy != "siti" {
+		t.Errorf("CancelledBy = %q, want siti", transaction.CancelledBy)
+	}
+}
+
+func TestTransactionHandler_Refund_Conflict(t *testing.T) {
+	mockSvc := &mockTransactionService{
+		refundFunc: func(ctx context.Context, id int, req model.CancelRequest) (*model.Transaction, error) {
+			return nil, model.ErrConflict
+		},
+	}
+
+	handler := NewTransactionHandler(mockSvc)
+	body := bytes.NewBufferString(`{"reason":"damaged"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions/7/refund", body)
+	req.SetPathValue("id", "7")
+	w := httptest.NewRecorder()
+
+	handler.Refund(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", w.Code)
+	}
+}
+
+func TestTransactionHandler_Void_InvalidID(t *testing.T) {
+	mockSvc := &mockTransactionService{}
+	handler := NewTransactionHandler(mockSvc)
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions/abc/void", bytes.NewBufferString(`{}`))
+	req.SetPathValue("id", "abc")
+	w := httptest.NewRecorder()
+
+	handler.Void(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
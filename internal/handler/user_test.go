@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kasir-api/internal/model"
+)
+
+// Mock service for testing
+type mockUserService struct {
+	createFunc     func(ctx context.Context, req model.UserRequest) (*model.User, error)
+	getByIDFunc    func(ctx context.Context, id int) (*model.User, error)
+	getAllFunc     func(ctx context.Context) ([]model.User, error)
+	updateFunc     func(ctx context.Context, id int, req model.UserRequest) (*model.User, error)
+	assignRoleFunc func(ctx context.Context, id int, req model.RoleRequest) (*model.User, error)
+	getRolesFunc   func(ctx context.Context) []model.RoleInfo
+}
+
+func (m *mockUserService) Create(ctx context.Context, req model.UserRequest) (*model.User, error) {
+	return m.createFunc(ctx, req)
+}
+
+func (m *mockUserService) GetByID(ctx context.Context, id int) (*model.User, error) {
+	return m.getByIDFunc(ctx, id)
+}
+
+func (m *mockUserService) GetAll(ctx context.Context) ([]model.User, error) {
+	return m.getAllFunc(ctx)
+}
+
+func (m *mockUserService) Update(ctx context.Context, id int, req model.UserRequest) (*model.User, error) {
+	return m.updateFunc(ctx, id, req)
+}
+
+func (m *mockUserService) AssignRole(ctx context.Context, id int, req model.RoleRequest) (*model.User, error) {
+	return m.assignRoleFunc(ctx, id, req)
+}
+
+func (m *mockUserService) GetRoles(ctx context.Context) []model.RoleInfo {
+	return m.getRolesFunc(ctx)
+}
+
+func TestUserHandler_AssignRole(t *testing.T) {
+	mockSvc := &mockUserService{
+		assignRoleFunc: func(ctx context.Context, id int, req model.RoleRequest) (*model.User, error) {
+			if id != 2 || req.Role != model.RoleManager {
+				t.Errorf("Unexpected role assignment for user %d: %+v", id, req)
+			}
+			return &model.User{ID: id, Username: "budi", Role: req.Role}, nil
+		},
+	}
+
+	handler := NewUserHandler(mockSvc)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/users/{id}/role", handler.AssignRole)
+
+	body := bytes.NewBufferString(`{"role":"manager"}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/users/2/role", body)
+	w := httptest.NewRecorder()
+
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
+
+func TestUserHandler_AssignRole_LastOwner(t *testing.T) {
+	mockSvc := &mockUserService{
+		assignRoleFunc: func(ctx context.Context, id int, req model.RoleRequest) (*model.User, error) {
+			return nil, model.LastOwnerError()
+		},
+	}
+
+	handler := NewUserHandler(mockSvc)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/users/{id}/role", handler.AssignRole)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/users/1/role", bytes.NewBufferString(`{"role":"cashier"}`))
+	w := httptest.NewRecorder()
+
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", w.Code)
+	}
+}
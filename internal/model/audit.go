@@ -0,0 +1,274 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/middleware"
+)
+
+// AuditEntity names the kind of record an audit entry is about
+type AuditEntity string
+
+const (
+	AuditEntityProduct        AuditEntity = "product"
+	AuditEntityCategory       AuditEntity = "category"
+	AuditEntityPromotion      AuditEntity = "promotion"
+	AuditEntityTransaction    AuditEntity = "transaction"
+	AuditEntityCart           AuditEntity = "cart"
+	AuditEntityReturn         AuditEntity = "return"
+	AuditEntityShift          AuditEntity = "shift"
+	AuditEntityCustomer       AuditEntity = "customer"
+	AuditEntityOutlet         AuditEntity = "outlet"
+	AuditEntityUser           AuditEntity = "user"
+	AuditEntityDevice         AuditEntity = "device"
+	AuditEntityStocktake      AuditEntity = "stocktake"
+	AuditEntitySupplier       AuditEntity = "supplier"
+	AuditEntityPurchaseOrder  AuditEntity = "purchase_order"
+	AuditEntityPurchaseReturn AuditEntity = "purchase_return"
+)
+
+// AuditAction is what was done to the record
+type AuditAction string
+
+const (
+	AuditActionCreate        AuditAction = "create"
+	AuditActionUpdate        AuditAction = "update"
+	AuditActionDelete        AuditAction = "delete"
+	AuditActionVoid          AuditAction = "void"
+	AuditActionRefund        AuditAction = "refund"
+	AuditActionErase         AuditAction = "erase"
+	AuditActionCashMovement  AuditAction = "cash_movement"
+	AuditActionClose         AuditAction = "close"
+	AuditActionAssignRole    AuditAction = "assign_role"
+	AuditActionPair          AuditAction = "pair"
+	AuditActionRevoke        AuditAction = "revoke"
+	AuditActionStockMovement AuditAction = "stock_movement" // adjustment, write-off or transfer posted by hand
+	AuditActionCount         AuditAction = "count"          // counts submitted to a stocktake
+	AuditActionApprove       AuditAction = "approve"
+	AuditActionCancel        AuditAction = "cancel"
+	AuditActionSend          AuditAction = "send"    // purchase order sent to the supplier
+	AuditActionReceive       AuditAction = "receive" // goods receipt against a purchase order
+	AuditActionCredit        AuditAction = "credit"  // supplier credit booked against a purchase return
+)
+
+// CancelAuditAction is the action logged for cancelling a transaction into status
+func CancelAuditAction(status TransactionStatus) AuditAction {
+	if status == TransactionStatusVoided {
+		return AuditActionVoid
+	}
+	return AuditActionRefund
+}
+
+// AuditActorType tells who made a change: a signed-in user, a paired device, or
+// the system itself, such as the startup that creates the first user
+type AuditActorType string
+
+const (
+	AuditActorUser   AuditActorType = "user"
+	AuditActorDevice AuditActorType = "device"
+	AuditActorSystem AuditActorType = "system"
+)
+
+// AuditEntry records one change to a record: who made it, in which request, and
+// the fields that changed. Entries are only ever appended, never changed or removed.
+type AuditEntry struct {
+	ID         int                    `json:"id"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	ActorType  AuditActorType         `json:"actor_type"`
+	ActorID    int                    `json:"actor_id,omitempty"` // user or device ID
+	Actor      string                 `json:"actor,omitempty"`    // username or device name
+	RequestID  string                 `json:"request_id,omitempty"`
+	Entity     AuditEntity            `json:"entity"`
+	EntityID   int                    `json:"entity_id"`
+	Action     AuditAction            `json:"action"`
+	Changes    map[string]AuditChange `json:"changes"`
+}
+
+// AuditChange is one field's value before and after a change. Before is null for
+// created records and After is null for deleted ones.
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// auditIgnoredFields change on every write and would only add noise
+var auditIgnoredFields = map[string]bool{"updated_at": true}
+
+// NewAuditEntry describes a change to a record, taking the actor and request ID
+// from ctx. before is nil for a new record and after is nil for a deleted one;
+// both are compared as their JSON, so fields hidden from the API are not logged.
+func NewAuditEntry(ctx context.Context, entity AuditEntity, entityID int, action AuditAction, before, after any) (AuditEntry, error) {
+	changes, err := AuditDiff(before, after)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+
+	entry := AuditEntry{
+		OccurredAt: time.Now(),
+		ActorType:  AuditActorSystem,
+		Entity:     entity,
+		EntityID:   entityID,
+		Action:     action,
+		Changes:    changes,
+	}
+	if principal, ok := middleware.PrincipalFromContext(ctx); ok {
+		entry.ActorType, entry.ActorID = AuditActorUser, principal.UserID
+		if principal.IsDevice() {
+			entry.ActorType, entry.ActorID = AuditActorDevice, principal.DeviceID
+		}
+		entry.Actor = principal.Username
+	}
+	if requestID, ok := ctx.Value(middleware.RequestIDCtxKey).(string); ok {
+		entry.RequestID = requestID
+	}
+	return entry, nil
+}
+
+// AuditDiff returns the top-level JSON fields that differ between before and
+// after. Nested objects and lists are compared, and reported, as a whole.
+func AuditDiff(before, after any) (map[string]AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]AuditChange)
+	for name, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[name]) {
+			changes[name] = AuditChange{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok && value != nil {
+			changes[name] = AuditChange{After: value}
+		}
+	}
+	return changes, nil
+}
+
+func auditFields(v any) (map[string]any, error) {
+	fields := make(map[string]any)
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil()) {
+		return fields, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// Numbers stay json.Number so large IDs and amounts are compared and logged exactly
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+	for name := range auditIgnoredFields {
+		delete(fields, name)
+	}
+	return fields, nil
+}
+
+// Page sizes for listing audit entries
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 200
+)
+
+// AuditFilter holds the filter and pagination options for listing audit entries
+type AuditFilter struct {
+	Entity    string `json:"entity,omitempty"`
+	EntityID  *int   `json:"entity_id,omitempty"`
+	Action    string `json:"action,omitempty"`
+	Actor     string `json:"actor,omitempty"` // username or device name
+	RequestID string `json:"request_id,omitempty"`
+	StartDate string `json:"start_date,omitempty"`
+	EndDate   string `json:"end_date,omitempty"`
+	Page      int    `json:"page"`
+	Limit     int    `json:"limit"`
+}
+
+// WithDefaults returns a copy of the filter with page and limit defaults applied
+func (f AuditFilter) WithDefaults() AuditFilter {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Limit <= 0 {
+		f.Limit = DefaultAuditPageSize
+	}
+	if f.Limit > MaxAuditPageSize {
+		f.Limit = MaxAuditPageSize
+	}
+	return f
+}
+
+// Offset returns the number of rows to skip for the current page
+func (f AuditFilter) Offset() int {
+	if f.Page <= 1 {
+		return 0
+	}
+	return (f.Page - 1) * f.Limit
+}
+
+func (f AuditFilter) Validate() error {
+	var start, end time.Time
+	var err error
+
+	if f.StartDate != "" {
+		if start, err = time.Parse(time.DateOnly, f.StartDate); err != nil {
+			return errorsPkg.ValidationError("start_date must be in YYYY-MM-DD format")
+		}
+	}
+	if f.EndDate != "" {
+		if end, err = time.Parse(time.DateOnly, f.EndDate); err != nil {
+			return errorsPkg.ValidationError("end_date must be in YYYY-MM-DD format")
+		}
+	}
+	if f.StartDate != "" && f.EndDate != "" && end.Before(start) {
+		return errorsPkg.ValidationError("end_date must not be before start_date")
+	}
+	if f.EntityID != nil && *f.EntityID <= 0 {
+		return errorsPkg.ValidationError("entity_id must be positive")
+	}
+	if f.EntityID != nil && f.Entity == "" {
+		return errorsPkg.ValidationError("entity_id needs entity")
+	}
+
+	return nil
+}
+
+// Matches reports whether the entry passes the filter. Dates are compared in the
+// entry's own time zone, the same as DATE() in PostgreSQL.
+func (f AuditFilter) Matches(e AuditEntry) bool {
+	if f.Entity != "" && string(e.Entity) != f.Entity {
+		return false
+	}
+	if f.EntityID != nil && e.EntityID != *f.EntityID {
+		return false
+	}
+	if f.Action != "" && string(e.Action) != f.Action {
+		return false
+	}
+	if f.Actor != "" && e.Actor != f.Actor {
+		return false
+	}
+	if f.RequestID != "" && e.RequestID != f.RequestID {
+		return false
+	}
+	day := e.OccurredAt.Format(time.DateOnly)
+	if f.StartDate != "" && day < f.StartDate {
+		return false
+	}
+	if f.EndDate != "" && day > f.EndDate {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"kasir-api/pkg/middleware"
+)
+
+func TestAuditDiff(t *testing.T) {
+	before := Product{ID: 1, Name: "Indomie", Price: 3500, Stock: 10}
+	after := Product{ID: 1, Name: "Indomie", Price: 4000, Stock: 10}
+
+	changes, err := AuditDiff(before, after)
+	if err != nil {
+		t.Fatalf("AuditDiff() error = %v", err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("Expected only price to change, got %v", changes)
+	}
+	if changes["price"].Before != json.Number("3500") || changes["price"].After != json.Number("4000") {
+		t.Errorf("Unexpected price change: %+v", changes["price"])
+	}
+}
+
+func TestAuditDiff_CreateAndDelete(t *testing.T) {
+	c := Category{ID: 2, Name: "Minuman"}
+
+	created, err := AuditDiff(nil, c)
+	if err != nil {
+		t.Fatalf("AuditDiff() error = %v", err)
+	}
+	if created["name"].Before != nil || created["name"].After != "Minuman" {
+		t.Errorf("Unexpected change on create: %+v", created["name"])
+	}
+
+	deleted, err := AuditDiff(&c, (*Category)(nil))
+	if err != nil {
+		t.Fatalf("AuditDiff() error = %v", err)
+	}
+	if deleted["name"].Before != "Minuman" || deleted["name"].After != nil {
+		t.Errorf("Unexpected change on delete: %+v", deleted["name"])
+	}
+}
+
+func TestNewAuditEntry_ActorAndRequestID(t *testing.T) {
+	ctx := context.WithValue(context.Background(), middleware.RequestIDCtxKey, "req-1")
+
+	entry, err := NewAuditEntry(ctx, AuditEntityCategory, 2, AuditActionCreate, nil, Category{ID: 2, Name: "Minuman"})
+	if err != nil {
+		t.Fatalf("NewAuditEntry() error = %v", err)
+	}
+	if entry.ActorType != AuditActorSystem || entry.RequestID != "req-1" {
+		t.Errorf("Unexpected entry without a principal: %+v", entry)
+	}
+
+	ctx = middleware.WithPrincipal(ctx, &middleware.Principal{Username: "Till 1", DeviceID: 4, OutletID: 2})
+	entry, err = NewAuditEntry(ctx, AuditEntityCategory, 2, AuditActionCreate, nil, Category{ID: 2, Name: "Minuman"})
+	if err != nil {
+		t.Fatalf("NewAuditEntry() error = %v", err)
+	}
+	if entry.ActorType != AuditActorDevice || entry.ActorID != 4 || entry.Actor != "Till 1" {
+		t.Errorf("Unexpected device actor: %+v", entry)
+	}
+}
+
+func TestCustomer_AuditRecord(t *testing.T) {
+	c := Customer{ID: 1, Name: "Budi", Phone: "08123", Points: 5}
+
+	record := c.AuditRecord()
+	if record.Name == "Budi" || record.Phone == "08123" {
+		t.Errorf("Personal data leaked into the audit record: %+v", record)
+	}
+	if record.Email != "" || record.Points != 5 {
+		t.Errorf("Unexpected audit record: %+v", record)
+	}
+}
+
+func TestAuditFilter_Validate(t *testing.T) {
+	id := 3
+	tests := []struct {
+		name    string
+		filter  AuditFilter
+		wantErr bool
+	}{
+		{name: "empty", filter: AuditFilter{}},
+		{name: "entity and id", filter: AuditFilter{Entity: "product", EntityID: &id}},
+		{name: "id without entity", filter: AuditFilter{EntityID: &id}, wantErr: true},
+		{name: "bad date", filter: AuditFilter{StartDate: "01-01-2026"}, wantErr: true},
+		{name: "end before start", filter: AuditFilter{StartDate: "2026-02-01", EndDate: "2026-01-01"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.filter.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
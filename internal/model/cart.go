@@ -0,0 +1,126 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/validation"
+)
+
+// CartStatus is the lifecycle state of a held cart
+type CartStatus string
+
+const (
+	CartStatusHeld        CartStatus = "held"
+	CartStatusCheckingOut CartStatus = "checking_out"
+	CartStatusCheckedOut  CartStatus = "checked_out"
+	// CartStatusExpired is never stored; it is reported for held carts past ExpiresAt
+	CartStatusExpired CartStatus = "expired"
+)
+
+// Cart is a parked basket (open bill) that can be resumed and checked out later.
+// Only product IDs and quantities are stored; names and prices are filled in from
+// the current products whenever the cart is read.
+type Cart struct {
+	ID             int        `json:"id"`
+	Label          string     `json:"label,omitempty"`
+	Status         CartStatus `json:"status"`
+	TransactionID  *int       `json:"transaction_id,omitempty"`
+	Items          []CartItem `json:"items"`
+	EstimatedTotal int        `json:"estimated_total"` // at current prices, before promotions and tax
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+}
+
+// IsExpired reports whether a held cart has been abandoned past its expiry
+func (c Cart) IsExpired(at time.Time) bool {
+	return c.Status == CartStatusHeld && !at.Before(c.ExpiresAt)
+}
+
+type CartItem struct {
+	ProductID   int    `json:"product_id" validate:"min=1"`
+	Quantity    int    `json:"quantity" validate:"min=1"`
+	ProductName string `json:"product_name,omitempty"`
+	UnitPrice   int    `json:"unit_price"`
+	Subtotal    int    `json:"subtotal"`
+	Available   bool   `json:"available"` // false when the product was deleted or deactivated
+}
+
+// CartRequest is the input for creating a cart
+type CartRequest struct {
+	Label string         `json:"label" validate:"max=100"`
+	Items []CheckoutItem `json:"items" validate:"dive"`
+}
+
+func (c CartRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(c); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+	for i, item := range c.Items {
+		if item.CatalogID() <= 0 {
+			return errorsPkg.ValidationError(fmt.Sprintf("item[%d] product_id must be positive", i))
+		}
+	}
+
+	return nil
+}
+
+// CartItemRequest is the input for adding a product to a cart or changing its quantity
+type CartItemRequest struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity" validate:"min=1"`
+}
+
+func (c CartItemRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(c); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+// CartCheckoutRequest carries the tenders used when checking out a cart
+type CartCheckoutRequest struct {
+	Payments []CheckoutPayment `json:"payments,omitempty"`
+}
+
+// CheckoutRequest converts the cart lines into a checkout request
+func (c Cart) CheckoutRequest(payments []CheckoutPayment) CheckoutRequest {
+	items := make([]CheckoutItem, 0, len(c.Items))
+	for _, item := range c.Items {
+		items = append(items, CheckoutItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	return CheckoutRequest{Items: items, Payments: payments}
+}
+
+// MergeCartItems adds quantities of the same product together, keeping first-seen order.
+// A variant is held as a line of its own, under its own ID.
+func MergeCartItems(items []CheckoutItem) []CartItem {
+	merged := make([]CartItem, 0, len(items))
+	index := make(map[int]int)
+	for _, item := range items {
+		id := item.CatalogID()
+		if i, ok := index[id]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[id] = len(merged)
+		merged = append(merged, CartItem{ProductID: id, Quantity: item.Quantity})
+	}
+	return merged
+}
+
+// CartNotHeldError reports an operation on a cart that is no longer held
+func CartNotHeldError(c Cart, at time.Time) error {
+	status := c.Status
+	if c.IsExpired(at) {
+		status = CartStatusExpired
+	}
+	return fmt.Errorf("%w: cart %d is %s", ErrConflict, c.ID, status)
+}
@@ -0,0 +1,140 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/validation"
+)
+
+// Customer is a member of the store's customer directory. Points is the current
+// loyalty balance; every change to it is recorded as a LoyaltyEntry.
+type Customer struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Phone      string     `json:"phone,omitempty"`
+	Email      string     `json:"email,omitempty"`
+	MemberCode string     `json:"member_code,omitempty"`
+	Notes      string     `json:"notes,omitempty"`
+	Points     int        `json:"points"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ErasedAt   *time.Time `json:"erased_at,omitempty"`
+}
+
+// IsErased reports whether the customer's personal data has been erased
+func (c Customer) IsErased() bool {
+	return c.ErasedAt != nil
+}
+
+// auditRedacted stands in for personal data in the audit log
+const auditRedacted = "[redacted]"
+
+// AuditRecord returns the customer as the audit log keeps it. The log is never
+// changed, so nothing in it could be erased; personal fields only show whether
+// they are set, not what they hold.
+func (c Customer) AuditRecord() Customer {
+	for _, field := range []*string{&c.Name, &c.Phone, &c.Email, &c.MemberCode, &c.Notes} {
+		if *field != "" {
+			*field = auditRedacted
+		}
+	}
+	return c
+}
+
+// Erase clears the personal data while keeping the ID, so transactions and
+// the points ledger stay consistent
+func (c *Customer) Erase(at time.Time) {
+	c.Name = ""
+	c.Phone = ""
+	c.Email = ""
+	c.MemberCode = ""
+	c.Notes = ""
+	c.UpdatedAt = at
+	c.ErasedAt = &at
+}
+
+// MemberCodeFor returns the member code given to a customer registered without one
+func MemberCodeFor(id int) string {
+	return fmt.Sprintf("MBR%06d", id)
+}
+
+// CustomerRequest is the input for creating or updating a customer
+type CustomerRequest struct {
+	Name       string `json:"name" validate:"required,min=1,max=255"`
+	Phone      string `json:"phone" validate:"max=20"`
+	Email      string `json:"email" validate:"omitempty,email,max=255"`
+	MemberCode string `json:"member_code" validate:"max=50"`
+	Notes      string `json:"notes" validate:"max=1000"`
+}
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{6,19}$`)
+
+// Normalize trims the fields and writes the phone number in one canonical form so
+// that "+62 812-3456-7890" and "081234567890" are recognised as the same number
+func (r CustomerRequest) Normalize() CustomerRequest {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Email = strings.ToLower(strings.TrimSpace(r.Email))
+	r.MemberCode = strings.ToUpper(strings.TrimSpace(r.MemberCode))
+	r.Notes = strings.TrimSpace(r.Notes)
+	r.Phone = NormalizePhone(r.Phone)
+	return r
+}
+
+// NormalizePhone strips separators and rewrites the +62 country code as a leading 0
+func NormalizePhone(phone string) string {
+	phone = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, phone)
+	if rest, ok := strings.CutPrefix(phone, "+62"); ok {
+		phone = "0" + rest
+	}
+	return phone
+}
+
+func (r CustomerRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	if r.Phone != "" && !phonePattern.MatchString(r.Phone) {
+		return errorsPkg.ValidationError("phone must contain only digits, optionally starting with +")
+	}
+
+	return nil
+}
+
+// Customer returns the customer described by the request
+func (r CustomerRequest) Customer() Customer {
+	return Customer{
+		Name:       r.Name,
+		Phone:      r.Phone,
+		Email:      r.Email,
+		MemberCode: r.MemberCode,
+		Notes:      r.Notes,
+	}
+}
+
+// PhoneTakenError reports a phone number already registered to another customer
+func PhoneTakenError(phone string) error {
+	return fmt.Errorf("%w: phone %s is already registered to another customer", ErrConflict, phone)
+}
+
+// MemberCodeTakenError reports a member code already given to another customer
+func MemberCodeTakenError(code string) error {
+	return fmt.Errorf("%w: member code %s is already in use", ErrConflict, code)
+}
+
+// CustomerErasedError reports an operation on a customer whose data has been erased
+func CustomerErasedError(id int) error {
+	return fmt.Errorf("%w: customer %d has been erased", ErrConflict, id)
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"081234567890", "081234567890"},
+		{"+62 812-3456-7890", "081234567890"},
+		{"(021) 555.1234", "0215551234"},
+		{"+65 6123 4567", "+6561234567"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizePhone(tt.in); got != tt.want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCustomerRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CustomerRequest
+		wantErr bool
+	}{
+		{name: "valid request", req: CustomerRequest{Name: "Budi", Phone: "+62 812 3456 7890", Email: "Budi@Example.com"}, wantErr: false},
+		{name: "name only", req: CustomerRequest{Name: "Budi"}, wantErr: false},
+		{name: "missing name", req: CustomerRequest{Phone: "081234567890"}, wantErr: true},
+		{name: "letters in phone", req: CustomerRequest{Name: "Budi", Phone: "0812-CALL-ME"}, wantErr: true},
+		{name: "invalid email", req: CustomerRequest{Name: "Budi", Email: "budi"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Normalize().Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/validation"
+)
+
+// DeviceRole is the role a paired device acts with; tablets at the till sell,
+// they do not manage the store
+const DeviceRole = RoleCashier
+
+// DeviceStatus is where a device is in its life: waiting to be paired, paired and
+// usable, or shut out
+type DeviceStatus string
+
+const (
+	DeviceStatusPending DeviceStatus = "pending" // pairing code issued, not yet used
+	DeviceStatusExpired DeviceStatus = "expired" // pairing code expired unused
+	DeviceStatusActive  DeviceStatus = "active"
+	DeviceStatusRevoked DeviceStatus = "revoked"
+)
+
+// Device is a POS terminal that signs in with an API key instead of a user's
+// password. The key is bound to one outlet and only its SHA-256 hash is stored;
+// KeyPrefix is kept in the clear so a key can be matched to its device.
+type Device struct {
+	ID               int          `json:"id"`
+	Name             string       `json:"name"`
+	OutletID         int          `json:"outlet_id"`
+	Status           DeviceStatus `json:"status"`
+	KeyPrefix        string       `json:"key_prefix,omitempty"`
+	KeyHash          string       `json:"-"`
+	PairingCodeHash  string       `json:"-"`
+	PairingExpiresAt *time.Time   `json:"pairing_expires_at,omitempty"`
+	PairedAt         *time.Time   `json:"paired_at,omitempty"`
+	LastSeenAt       *time.Time   `json:"last_seen_at,omitempty"`
+	RequestCount     int64        `json:"request_count"` // requests made with the key
+	RevokedAt        *time.Time   `json:"revoked_at,omitempty"`
+	CreatedBy        string       `json:"created_by,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+}
+
+// StatusAt returns the status of the device at the given time
+func (d Device) StatusAt(at time.Time) DeviceStatus {
+	switch {
+	case d.RevokedAt != nil:
+		return DeviceStatusRevoked
+	case d.PairedAt != nil:
+		return DeviceStatusActive
+	case d.PairingExpiresAt != nil && at.Before(*d.PairingExpiresAt):
+		return DeviceStatusPending
+	default:
+		return DeviceStatusExpired
+	}
+}
+
+// CanPair reports whether the device's pairing code can still be exchanged for a key
+func (d Device) CanPair(at time.Time) bool {
+	return d.StatusAt(at) == DeviceStatusPending
+}
+
+// DeviceRequest registers a device and issues its pairing code. OutletID defaults
+// to the outlet the request is scoped to.
+type DeviceRequest struct {
+	Name     string `json:"name" validate:"required,min=1,max=100"`
+	OutletID int    `json:"outlet_id" validate:"omitempty,min=1"`
+}
+
+func (r DeviceRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+// PairingCodeResponse is returned when a device is registered. The code is shown
+// once and is entered on the device before it expires.
+type PairingCodeResponse struct {
+	Device      Device    `json:"device"`
+	PairingCode string    `json:"pairing_code"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// PairRequest exchanges a pairing code for the device's API key
+type PairRequest struct {
+	PairingCode string `json:"pairing_code" validate:"required"`
+}
+
+func (r PairRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+// NormalizePairingCode upper-cases the code and drops the separators people type
+// or leave out, so "abcd efgh" and "ABCD-EFGH" are the same code
+func NormalizePairingCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// PairResponse hands the API key to the device. It is never shown again; a lost
+// key means revoking the device and pairing it anew.
+type PairResponse struct {
+	APIKey string `json:"api_key"`
+	Device Device `json:"device"`
+}
+
+// InvalidPairingCodeError reports a pairing code that is unknown, expired or used
+func InvalidPairingCodeError() error {
+	return fmt.Errorf("%w: invalid or expired pairing code", ErrUnauthorized)
+}
+
+// InvalidDeviceKeyError reports an API key that is unknown or revoked
+func InvalidDeviceKeyError() error {
+	return fmt.Errorf("%w: invalid or revoked device key", ErrUnauthorized)
+}
+
+// UserOnlyError reports a device calling an endpoint that is about a user account
+func UserOnlyError() error {
+	return fmt.Errorf("%w: only users can do this, not devices", ErrForbidden)
+}
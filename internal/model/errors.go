@@ -8,6 +8,9 @@ var (
 	ErrValidation = errorsPkg.ValidationError("validation error")
 	ErrNotFound   = errorsPkg.NotFoundError("not found")
 	ErrConflict   = errorsPkg.ConflictError("conflict")
+
+	ErrUnauthorized = errorsPkg.UnauthorizedError("unauthorized")
+	ErrForbidden    = errorsPkg.ForbiddenError("forbidden")
 )
 
 // IsValidationError checks if the error is a validation error
@@ -24,3 +27,13 @@ func IsNotFoundError(err error) bool {
 func IsConflictError(err error) bool {
 	return errorsPkg.IsType(err, errorsPkg.ErrorTypeConflict)
 }
+
+// IsUnauthorizedError checks if the error is an authentication error
+func IsUnauthorizedError(err error) bool {
+	return errorsPkg.IsType(err, errorsPkg.ErrorTypeUnauthorized)
+}
+
+// IsForbiddenError checks if the error is a permission error
+func IsForbiddenError(err error) bool {
+	return errorsPkg.IsType(err, errorsPkg.ErrorTypeForbidden)
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"kasir-api/pkg/middleware"
+)
+
+// MaxIdempotencyKeyLength is the longest Idempotency-Key header value accepted
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyRecord remembers which request an Idempotency-Key was first used for
+// and, once checkout has finished, the transaction it produced
+type IdempotencyRecord struct {
+	Key           string    `json:"key"`
+	RequestHash   string    `json:"request_hash"`
+	TransactionID *int      `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// IdempotencyClaim is a reserved Idempotency-Key, together with the hash of the
+// request it was reserved for
+type IdempotencyClaim struct {
+	Key         string
+	RequestHash string
+}
+
+// IdempotencyReservationLostError reports a key whose reservation expired or was
+// released before checkout could record its transaction
+func IdempotencyReservationLostError() error {
+	return fmt.Errorf("%w: the Idempotency-Key reservation expired before checkout completed, retry the request", ErrConflict)
+}
+
+// ScopedIdempotencyKey namespaces an Idempotency-Key by the caller and outlet of
+// ctx, so clients that happen to pick the same key never replay each other's
+// checkouts
+func ScopedIdempotencyKey(ctx context.Context, key string) string {
+	caller := "anonymous"
+	if p, ok := middleware.PrincipalFromContext(ctx); ok {
+		caller = fmt.Sprintf("user:%d", p.UserID)
+		if p.IsDevice() {
+			caller = fmt.Sprintf("device:%d", p.DeviceID)
+		}
+	}
+	outletID, _ := ScopedOutletID(ctx)
+	return fmt.Sprintf("%s/outlet:%d/%s", caller, outletID, key)
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/pkg/middleware"
+)
+
+func TestScopedIdempotencyKey(t *testing.T) {
+	user := middleware.WithPrincipal(context.Background(), &middleware.Principal{UserID: 2, Username: "siti"})
+	device := middleware.WithPrincipal(context.Background(), &middleware.Principal{DeviceID: 2, OutletID: 1})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"user", user, "user:2/outlet:0/key-1"},
+		{"user at an outlet", middleware.WithOutletID(user, 3), "user:2/outlet:3/key-1"},
+		{"device with the same ID", middleware.WithOutletID(device, 1), "device:2/outlet:1/key-1"},
+		{"no principal", context.Background(), "anonymous/outlet:0/key-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScopedIdempotencyKey(tt.ctx, "key-1"); got != tt.want {
+				t.Errorf("ScopedIdempotencyKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
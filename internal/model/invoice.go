@@ -0,0 +1,179 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+)
+
+// DefaultInvoicePattern is used when no pattern is configured
+const DefaultInvoicePattern = "INV/{STORE}/{YYYYMMDD}/{SEQ:5}"
+
+// DefaultStoreCode identifies the store in invoice numbers when none is configured
+const DefaultStoreCode = "MAIN"
+
+const (
+	MaxInvoiceNumberLength = 100
+	MaxStoreCodeLength     = 50
+	maxInvoiceSeqWidth     = 12
+)
+
+const (
+	invoiceTokenSeq       = "SEQ"
+	invoiceTokenStore     = "STORE"
+	invoiceTokenFullDate  = "YYYYMMDD"
+	invoiceTokenYear      = "YYYY"
+	invoiceTokenShortYear = "YY"
+	invoiceTokenMonth     = "MM"
+	invoiceTokenDay       = "DD"
+)
+
+// invoicePart is either literal text or a {TOKEN} placeholder
+type invoicePart struct {
+	literal string
+	token   string
+	width   int // zero padding for SEQ
+}
+
+// InvoicePattern formats invoice numbers such as INV/{STORE}/{YYYYMMDD}/{SEQ:5}.
+// Supported tokens are {STORE}, {YYYYMMDD}, {YYYY}, {YY}, {MM}, {DD} and {SEQ} or
+// {SEQ:n} for a sequence zero-padded to n digits. The sequence restarts every
+// business day for every store, so a pattern must contain {STORE}, the full date
+// and {SEQ} for numbers to stay unique.
+type InvoicePattern struct {
+	raw   string
+	parts []invoicePart
+}
+
+// ParseInvoicePattern parses and validates an invoice number pattern
+func ParseInvoicePattern(pattern string) (InvoicePattern, error) {
+	p := InvoicePattern{raw: pattern}
+	seen := make(map[string]bool)
+
+	rest := pattern
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			p.parts = append(p.parts, invoicePart{literal: rest})
+			break
+		}
+		if open > 0 {
+			p.parts = append(p.parts, invoicePart{literal: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return p, errorsPkg.ValidationError(fmt.Sprintf("invoice pattern %q has an unclosed {", pattern))
+		}
+
+		part, err := parseInvoiceToken(rest[open+1 : open+end])
+		if err != nil {
+			return p, err
+		}
+		p.parts = append(p.parts, part)
+		seen[part.token] = true
+		rest = rest[open+end+1:]
+	}
+
+	hasDate := seen[invoiceTokenFullDate] ||
+		((seen[invoiceTokenYear] || seen[invoiceTokenShortYear]) && seen[invoiceTokenMonth] && seen[invoiceTokenDay])
+	if !seen[invoiceTokenSeq] || !seen[invoiceTokenStore] || !hasDate {
+		return p, errorsPkg.ValidationError(fmt.Sprintf("invoice pattern %q must contain {STORE}, the date and {SEQ}", pattern))
+	}
+
+	return p, nil
+}
+
+func parseInvoiceToken(token string) (invoicePart, error) {
+	name, width, hasWidth := strings.Cut(token, ":")
+	switch name {
+	case invoiceTokenStore, invoiceTokenFullDate, invoiceTokenYear, invoiceTokenShortYear, invoiceTokenMonth, invoiceTokenDay:
+		if hasWidth {
+			return invoicePart{}, errorsPkg.ValidationError(fmt.Sprintf("invoice token {%s} does not take a width", token))
+		}
+		return invoicePart{token: name}, nil
+	case invoiceTokenSeq:
+		part := invoicePart{token: name}
+		if hasWidth {
+			n, err := strconv.Atoi(width)
+			if err != nil || n < 1 || n > maxInvoiceSeqWidth {
+				return part, errorsPkg.ValidationError(fmt.Sprintf("invoice token {%s} must have a width between 1 and %d", token, maxInvoiceSeqWidth))
+			}
+			part.width = n
+		}
+		return part, nil
+	}
+	return invoicePart{}, errorsPkg.ValidationError(fmt.Sprintf("unknown invoice token {%s}", token))
+}
+
+// String returns the pattern as configured
+func (p InvoicePattern) String() string {
+	return p.raw
+}
+
+// IsZero reports whether no pattern has been set
+func (p InvoicePattern) IsZero() bool {
+	return len(p.parts) == 0
+}
+
+// Format renders the invoice number for a store, business day and sequence value
+func (p InvoicePattern) Format(store string, day time.Time, seq int) string {
+	var b strings.Builder
+	for _, part := range p.parts {
+		switch part.token {
+		case "":
+			b.WriteString(part.literal)
+		case invoiceTokenStore:
+			b.WriteString(store)
+		case invoiceTokenFullDate:
+			b.WriteString(day.Format("20060102"))
+		case invoiceTokenYear:
+			b.WriteString(day.Format("2006"))
+		case invoiceTokenShortYear:
+			b.WriteString(day.Format("06"))
+		case invoiceTokenMonth:
+			b.WriteString(day.Format("01"))
+		case invoiceTokenDay:
+			b.WriteString(day.Format("02"))
+		case invoiceTokenSeq:
+			fmt.Fprintf(&b, "%0*d", part.width, seq)
+		}
+	}
+	return b.String()
+}
+
+// InvoiceNumbering tells checkout how to number a new transaction. Numbers are
+// allocated from a sequence per store and business day inside the checkout, so
+// a failed checkout does not use one up.
+type InvoiceNumbering struct {
+	Pattern   InvoicePattern
+	StoreCode string
+}
+
+// NewInvoiceNumbering validates the configured pattern and store code
+func NewInvoiceNumbering(pattern, storeCode string) (InvoiceNumbering, error) {
+	p, err := ParseInvoicePattern(pattern)
+	if err != nil {
+		return InvoiceNumbering{}, err
+	}
+	if storeCode == "" || len(storeCode) > MaxStoreCodeLength || strings.ContainsAny(storeCode, " \t\n/") {
+		return InvoiceNumbering{}, errorsPkg.ValidationError(fmt.Sprintf("store code must be 1 to %d characters without spaces or slashes", MaxStoreCodeLength))
+	}
+	return InvoiceNumbering{Pattern: p, StoreCode: storeCode}, nil
+}
+
+// DefaultInvoiceNumbering numbers invoices with DefaultInvoicePattern for DefaultStoreCode
+func DefaultInvoiceNumbering() InvoiceNumbering {
+	numbering, err := NewInvoiceNumbering(DefaultInvoicePattern, DefaultStoreCode)
+	if err != nil {
+		panic(err)
+	}
+	return numbering
+}
+
+// BusinessDate returns the day a sale at the given time belongs to
+func BusinessDate(at time.Time) string {
+	return at.Local().Format(time.DateOnly)
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInvoicePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{DefaultInvoicePattern, false},
+		{"{STORE}-{YY}{MM}{DD}-{SEQ}", false},
+		{"INV/{STORE}/{SEQ:5}", true},    // no date, numbers would repeat every day
+		{"INV/{YYYYMMDD}/{SEQ:5}", true}, // no store
+		{"INV/{STORE}/{YYYYMMDD}", true}, // no sequence
+		{"INV/{STORE}/{YYYYMMDD}/{SEQ:0}", true},
+		{"INV/{STORE}/{YYYYMMDD}/{SEQ:x}", true},
+		{"INV/{STORE}/{DATE}/{SEQ}", true},
+		{"INV/{STORE}/{YYYYMMDD/{SEQ}", true},
+		{"INV/{STORE}/{YYYYMMDD}/{SEQ", true},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseInvoicePattern(tt.pattern)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseInvoicePattern(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+		}
+		if err != nil && !IsValidationError(err) {
+			t.Errorf("ParseInvoicePattern(%q) error = %v, want validation error", tt.pattern, err)
+		}
+	}
+}
+
+func TestInvoicePattern_Format(t *testing.T) {
+	day := time.Date(2026, 3, 7, 15, 4, 5, 0, time.Local)
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{DefaultInvoicePattern, "INV/BDG01/20260307/00042"},
+		{"{STORE}-{YY}{MM}{DD}-{SEQ}", "BDG01-260307-42"},
+		{"{YYYY}.{MM}.{DD}/{STORE}/{SEQ:2}", "2026.03.07/BDG01/42"},
+	}
+
+	for _, tt := range tests {
+		p, err := ParseInvoicePattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("ParseInvoicePattern(%q) error = %v", tt.pattern, err)
+		}
+		if got := p.Format("BDG01", day, 42); got != tt.want {
+			t.Errorf("Format(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestNewInvoiceNumbering_StoreCode(t *testing.T) {
+	for _, code := range []string{"", "BDG 01", "BDG/01"} {
+		if _, err := NewInvoiceNumbering(DefaultInvoicePattern, code); !IsValidationError(err) {
+			t.Errorf("NewInvoiceNumbering(%q) error = %v, want validation error", code, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoyaltyRule decides how many points a sale earns and what a point is worth
+// when it is redeemed. The zero value disables both.
+type LoyaltyRule struct {
+	SpendPerPoint int // rupiah of the transaction total per point earned, 0 disables earning
+	PointValue    int // rupiah taken off per redeemed point, 0 disables redemption
+}
+
+// Earn returns the points earned by a transaction with the given total
+func (r LoyaltyRule) Earn(total int) int {
+	if r.SpendPerPoint <= 0 || total <= 0 {
+		return 0
+	}
+	return total / r.SpendPerPoint
+}
+
+// RedeemPoints takes up to points off the basket as a discount spread over the
+// lines, after promotions and before tax. Only whole points are used and never
+// more than the basket's net amount, so the points actually used can be fewer
+// than requested. It returns the points used and the discount they gave.
+func RedeemPoints(lines []BasketLine, points int, rule LoyaltyRule) (used, amount int, err error) {
+	if points <= 0 {
+		return 0, 0, nil
+	}
+	if rule.PointValue <= 0 {
+		return 0, 0, fmt.Errorf("%w: points redemption is disabled", ErrValidation)
+	}
+
+	net := 0
+	for _, l := range lines {
+		net += l.Net()
+	}
+	used = min(points, net/rule.PointValue)
+	amount = used * rule.PointValue
+	allocateDiscount(lines, amount)
+
+	return used, amount, nil
+}
+
+// allocateDiscount spreads an order-level discount over the lines in proportion
+// to their net amounts. The rounding remainder goes to the first lines that can
+// still take it, so the lines always add up to the full amount.
+func allocateDiscount(lines []BasketLine, amount int) {
+	net := 0
+	for _, l := range lines {
+		net += l.Net()
+	}
+	if amount <= 0 || net <= 0 {
+		return
+	}
+	amount = min(amount, net)
+
+	remaining := amount
+	for i := range lines {
+		share := lines[i].Net() * amount / net
+		lines[i].Discount += share
+		remaining -= share
+	}
+	for i := range lines {
+		if remaining == 0 {
+			break
+		}
+		extra := min(remaining, lines[i].Net())
+		lines[i].Discount += extra
+		remaining -= extra
+	}
+}
+
+// LoyaltyEntryType says why a customer's points balance changed
+type LoyaltyEntryType string
+
+const (
+	LoyaltyEntryEarn     LoyaltyEntryType = "earn"
+	LoyaltyEntryRedeem   LoyaltyEntryType = "redeem"
+	LoyaltyEntryReversal LoyaltyEntryType = "reversal" // points given back or taken back when a sale is voided or refunded
+)
+
+// LoyaltyEntry is one line of a customer's points ledger. Points is signed and
+// Balance is the customer's balance after the entry.
+type LoyaltyEntry struct {
+	ID            int              `json:"id"`
+	CustomerID    int              `json:"customer_id"`
+	TransactionID *int             `json:"transaction_id,omitempty"`
+	Type          LoyaltyEntryType `json:"type"`
+	Points        int              `json:"points"`
+	Balance       int              `json:"balance"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+// InsufficientPointsError reports a redemption larger than the customer's balance
+func InsufficientPointsError(balance, requested int) error {
+	return fmt.Errorf("%w: insufficient points (available: %d, requested: %d)", ErrValidation, balance, requested)
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestLoyaltyRule_Earn(t *testing.T) {
+	rule := LoyaltyRule{SpendPerPoint: 10000}
+
+	if got := rule.Earn(25000); got != 2 {
+		t.Errorf("Earn(25000) = %d, want 2", got)
+	}
+	if got := (LoyaltyRule{}).Earn(25000); got != 0 {
+		t.Errorf("Earn() with earning disabled = %d, want 0", got)
+	}
+}
+
+func TestRedeemPoints(t *testing.T) {
+	lines := []BasketLine{
+		{ProductID: 1, Gross: 7000},
+		{ProductID: 2, Gross: 5000, Discount: 2000},
+	}
+
+	used, amount, err := RedeemPoints(lines, 25, LoyaltyRule{PointValue: 100})
+	if err != nil {
+		t.Fatalf("RedeemPoints() error = %v", err)
+	}
+	if used != 25 || amount != 2500 {
+		t.Errorf("RedeemPoints() = %d points, %d, want 25 points, 2500", used, amount)
+	}
+
+	// Spread by net amount: 7000 and 3000 out of 10000
+	if lines[0].Discount != 1750 || lines[1].Discount != 2750 {
+		t.Errorf("Discounts = %d, %d, want 1750, 2750", lines[0].Discount, lines[1].Discount)
+	}
+}
+
+func TestRedeemPoints_CappedAtBasket(t *testing.T) {
+	lines := []BasketLine{{ProductID: 1, Gross: 3550}}
+
+	used, amount, _ := RedeemPoints(lines, 100, LoyaltyRule{PointValue: 100})
+	if used != 35 || amount != 3500 || lines[0].Net() != 50 {
+		t.Errorf("RedeemPoints() = %d points, %d, net %d, want 35 points, 3500, net 50", used, amount, lines[0].Net())
+	}
+}
+
+func TestRedeemPoints_Disabled(t *testing.T) {
+	lines := []BasketLine{{ProductID: 1, Gross: 3500}}
+
+	if _, _, err := RedeemPoints(lines, 10, LoyaltyRule{}); !IsValidationError(err) {
+		t.Errorf("RedeemPoints() with redemption disabled error = %v, want validation error", err)
+	}
+}
+
+func TestAllocateDiscount_Remainder(t *testing.T) {
+	lines := []BasketLine{{Gross: 1000}, {Gross: 1000}, {Gross: 1000}}
+
+	allocateDiscount(lines, 100)
+
+	total := 0
+	for _, l := range lines {
+		total += l.Discount
+	}
+	if total != 100 || lines[0].Discount != 34 {
+		t.Errorf("Discounts = %+v, want 34, 33, 33", lines)
+	}
+}
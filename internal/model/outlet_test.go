@@ -0,0 +1,48 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/pkg/middleware"
+)
+
+func TestOutletRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     OutletRequest
+		wantErr bool
+	}{
+		{name: "valid", req: OutletRequest{Code: "BDG", Name: "Bandung"}},
+		{name: "missing code", req: OutletRequest{Name: "Bandung"}, wantErr: true},
+		{name: "missing name", req: OutletRequest{Code: "BDG"}, wantErr: true},
+		{name: "slash in code", req: OutletRequest{Code: "BDG/1", Name: "Bandung"}, wantErr: true},
+		{name: "space in code", req: OutletRequest{Code: "BDG 1", Name: "Bandung"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Normalize().Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOutletID(t *testing.T) {
+	if got := OutletID(context.Background()); got != DefaultOutletID {
+		t.Errorf("OutletID() without outlet = %d, want %d", got, DefaultOutletID)
+	}
+	if _, ok := ScopedOutletID(context.Background()); ok {
+		t.Error("ScopedOutletID() without outlet reported a scope")
+	}
+
+	ctx := middleware.WithOutletID(context.Background(), 3)
+	if got := OutletID(ctx); got != 3 {
+		t.Errorf("OutletID() = %d, want 3", got)
+	}
+	if got, ok := ScopedOutletID(ctx); !ok || got != 3 {
+		t.Errorf("ScopedOutletID() = %d, %v, want 3, true", got, ok)
+	}
+}
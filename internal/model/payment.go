@@ -0,0 +1,78 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// PaymentMethod is how a customer tendered part or all of a transaction
+type PaymentMethod string
+
+const (
+	PaymentMethodCash     PaymentMethod = "cash"
+	PaymentMethodDebit    PaymentMethod = "debit"
+	PaymentMethodCredit   PaymentMethod = "credit"
+	PaymentMethodQRIS     PaymentMethod = "qris"
+	PaymentMethodEWallet  PaymentMethod = "ewallet"
+	PaymentMethodTransfer PaymentMethod = "transfer"
+)
+
+// IsValid reports whether the method is one of the accepted payment methods
+func (m PaymentMethod) IsValid() bool {
+	switch m {
+	case PaymentMethodCash, PaymentMethodDebit, PaymentMethodCredit,
+		PaymentMethodQRIS, PaymentMethodEWallet, PaymentMethodTransfer:
+		return true
+	}
+	return false
+}
+
+// Payment is a single tender recorded against a transaction
+type Payment struct {
+	ID            int           `json:"id"`
+	TransactionID int           `json:"transaction_id"`
+	Method        PaymentMethod `json:"method"`
+	Amount        int           `json:"amount"`
+	Reference     string        `json:"reference,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// CheckoutPayment is a tender submitted with a checkout request
+type CheckoutPayment struct {
+	Method    PaymentMethod `json:"method" validate:"required"`
+	Amount    int           `json:"amount" validate:"min=1"`
+	Reference string        `json:"reference,omitempty" validate:"max=255"`
+}
+
+// SettlePayments checks the tenders against the amount due and returns the payments
+// to record together with the change owed. When no tenders are given the total is
+// recorded as exact cash. Only cash can be overpaid; card, QRIS, e-wallet and
+// transfer amounts must not exceed what is due.
+func SettlePayments(total int, tenders []CheckoutPayment) ([]Payment, int, error) {
+	if len(tenders) == 0 {
+		return []Payment{{Method: PaymentMethodCash, Amount: total}}, 0, nil
+	}
+
+	paid, nonCash := 0, 0
+	payments := make([]Payment, 0, len(tenders))
+	for _, tender := range tenders {
+		paid += tender.Amount
+		if tender.Method != PaymentMethodCash {
+			nonCash += tender.Amount
+		}
+		payments = append(payments, Payment{
+			Method:    tender.Method,
+			Amount:    tender.Amount,
+			Reference: tender.Reference,
+		})
+	}
+
+	if paid < total {
+		return nil, 0, fmt.Errorf("%w: payment of %d is less than the total of %d", ErrValidation, paid, total)
+	}
+	if nonCash > total {
+		return nil, 0, fmt.Errorf("%w: non-cash payments of %d exceed the total of %d, change can only be given for cash", ErrValidation, nonCash, total)
+	}
+
+	return payments, paid - total, nil
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestSettlePayments(t *testing.T) {
+	tests := []struct {
+		name       string
+		total      int
+		tenders    []CheckoutPayment
+		wantChange int
+		wantCount  int
+		wantErr    bool
+	}{
+		{
+			name:      "no tenders records exact cash",
+			total:     12000,
+			wantCount: 1,
+		},
+		{
+			name:       "cash with change",
+			total:      12000,
+			tenders:    []CheckoutPayment{{Method: PaymentMethodCash, Amount: 20000}},
+			wantChange: 8000,
+			wantCount:  1,
+		},
+		{
+			name:  "split card and cash",
+			total: 12000,
+			tenders: []CheckoutPayment{
+				{Method: PaymentMethodDebit, Amount: 10000, Reference: "APPR-1"},
+				{Method: PaymentMethodCash, Amount: 5000},
+			},
+			wantChange: 3000,
+			wantCount:  2,
+		},
+		{
+			name:    "underpayment",
+			total:   12000,
+			tenders: []CheckoutPayment{{Method: PaymentMethodQRIS, Amount: 10000}},
+			wantErr: true,
+		},
+		{
+			name:    "card overpayment",
+			total:   12000,
+			tenders: []CheckoutPayment{{Method: PaymentMethodCredit, Amount: 15000}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payments, change, err := SettlePayments(tt.total, tt.tenders)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SettlePayments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !IsValidationError(err) {
+					t.Errorf("SettlePayments() error = %v, want validation error", err)
+				}
+				return
+			}
+			if change != tt.wantChange {
+				t.Errorf("change = %v, want %v", change, tt.wantChange)
+			}
+			if len(payments) != tt.wantCount {
+				t.Errorf("payments = %d, want %d", len(payments), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestCheckoutRequest_Validate_PaymentMethod(t *testing.T) {
+	req := CheckoutRequest{
+		Items:    []CheckoutItem{{ProductID: 1, Quantity: 1}},
+		Payments: []CheckoutPayment{{Method: "cheque", Amount: 1000}},
+	}
+	if err := req.Validate(); err == nil {
+		t.Error("Validate() should reject unknown payment methods")
+	}
+}
@@ -8,13 +8,48 @@ import (
 )
 
 type Product struct {
-	ID         int       `json:"id" validate:"omitempty,min=1"`
-	Name       string    `json:"name" validate:"required,min=1,max=255"`
-	Price      int       `json:"price" validate:"min=0"`
-	Stock      int       `json:"stock" validate:"min=0"`
-	Active     bool      `json:"active"`
-	CategoryID *int      `json:"category_id,omitempty" validate:"omitempty,min=1"`
-	Category   *Category `json:"category,omitempty"`
+	ID           int       `json:"id" validate:"omitempty,min=1"`
+	Name         string    `json:"name" validate:"required,min=1,max=255"`
+	Price        int       `json:"price" validate:"min=0"`
+	Cost         int       `json:"cost" validate:"min=0"` // purchase cost per unit, recorded on sales for margin
+	Stock        int       `json:"stock" validate:"min=0"`
+	Active       bool      `json:"active"`
+	TaxExempt    bool      `json:"tax_exempt"`                     // not subject to PPN
+	TaxInclusive bool      `json:"tax_inclusive"`                  // Price already includes PPN
+	ReorderPoint int       `json:"reorder_point" validate:"min=0"` // stock at or below which the product needs reordering; 0 disables alerts
+	ReorderQty   int       `json:"reorder_qty" validate:"min=0"`   // quantity usually ordered when it does
+	CategoryID   *int      `json:"category_id,omitempty" validate:"omitempty,min=1"`
+	Category     *Category `json:"category,omitempty"`
+	SKU          string    `json:"sku,omitempty" validate:"max=64"`
+	Barcode      string    `json:"barcode,omitempty" validate:"max=64"`
+
+	// Options are the dimensions the product's variants differ in. A variant is a
+	// product of its own with ParentID set and one of each option's values in
+	// Attributes; both are fixed when the variant is created.
+	Options    []ProductOption   `json:"options,omitempty" validate:"omitempty,max=3,dive"`
+	ParentID   *int              `json:"parent_id,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Variants   []ProductVariant  `json:"variants,omitempty"` // read only, filled in on reads
+}
+
+// AuditRecord returns the product as the audit log keeps it: the category by its
+// ID only, since the category has its own entries
+func (p Product) AuditRecord() Product {
+	p.Category = nil
+	p.Variants = nil
+	return p
+}
+
+// IsLowStock reports whether the product's stock is at or below its reorder point
+func (p Product) IsLowStock() bool {
+	return p.ReorderPoint > 0 && p.Stock <= p.ReorderPoint
+}
+
+// ReachesReorderPoint reports whether stock going from before to after takes the
+// product to or below its reorder point. Stock already below it does not reach it
+// again, so a product alerts once until it is restocked.
+func (p Product) ReachesReorderPoint(before, after int) bool {
+	return p.ReorderPoint > 0 && before > p.ReorderPoint && after <= p.ReorderPoint
 }
 
 func (p Product) Validate() error {
@@ -32,5 +67,5 @@ func (p Product) Validate() error {
 		return errorsPkg.ValidationError(err.Error())
 	}
 
-	return nil
+	return validateOptions(p.Options)
 }
@@ -37,6 +37,15 @@ func TestProduct_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "negative reorder point",
+			product: Product{
+				Name:         "Indomie",
+				Price:        3500,
+				ReorderPoint: -1,
+			},
+			wantErr: true,
+		},
 		{
 			name: "negative stock",
 			product: Product{
@@ -57,3 +66,30 @@ func TestProduct_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestProduct_ReachesReorderPoint(t *testing.T) {
+	p := Product{ReorderPoint: 5}
+	tests := []struct {
+		name          string
+		before, after int
+		want          bool
+	}{
+		{"stays above", 10, 6, false},
+		{"lands on it", 10, 5, true},
+		{"drops below", 7, 2, true},
+		{"already below", 4, 2, false},
+		{"already at it", 5, 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.ReachesReorderPoint(tt.before, tt.after); got != tt.want {
+				t.Errorf("ReachesReorderPoint(%d, %d) = %v, want %v", tt.before, tt.after, got, tt.want)
+			}
+		})
+	}
+
+	if (Product{}).ReachesReorderPoint(10, 0) {
+		t.Error("a product without a reorder point should never alert")
+	}
+}
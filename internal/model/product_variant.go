@@ -0,0 +1,201 @@
+package model
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/validation"
+)
+
+// ProductOption is a dimension a product's variants differ in, such as flavour or
+// size, with the values it can take
+type ProductOption struct {
+	Name   string   `json:"name" validate:"required,max=50"`
+	Values []string `json:"values" validate:"required,min=1,max=50,dive,required,max=50"`
+}
+
+// ProductVariant is one sellable version of a product, such as Indomie Soto or a
+// 2L bottle. It is kept in the catalog as a product of its own, so it has its own
+// price, stock, stock ledger and barcode, and is sold and returned by its own ID;
+// reports roll its sales up to the parent product.
+type ProductVariant struct {
+	ID         int               `json:"id"`
+	Name       string            `json:"name"`
+	SKU        string            `json:"sku,omitempty"`
+	Barcode    string            `json:"barcode,omitempty"`
+	Attributes map[string]string `json:"attributes"`
+	Price      int               `json:"price"`
+	Cost       int               `json:"cost"`
+	Stock      int               `json:"stock"`
+	Active     bool              `json:"active"`
+}
+
+// Variant returns the product as a variant of its parent, with Stock as read for the outlet
+func (p Product) Variant() ProductVariant {
+	return ProductVariant{
+		ID:         p.ID,
+		Name:       p.Name,
+		SKU:        p.SKU,
+		Barcode:    p.Barcode,
+		Attributes: p.Attributes,
+		Price:      p.Price,
+		Cost:       p.Cost,
+		Stock:      p.Stock,
+		Active:     p.Active,
+	}
+}
+
+// ProductVariantRequest adds a variant to a product. Price, cost and active
+// default to the product's; the name defaults to the product's name followed by
+// the attribute values in option order.
+type ProductVariantRequest struct {
+	Name         string            `json:"name" validate:"max=255"`
+	SKU          string            `json:"sku" validate:"max=64"`
+	Barcode      string            `json:"barcode" validate:"max=64"`
+	Attributes   map[string]string `json:"attributes" validate:"required,min=1"`
+	Price        *int              `json:"price" validate:"omitempty,min=0"`
+	Cost         *int              `json:"cost" validate:"omitempty,min=0"`
+	Stock        int               `json:"stock" validate:"min=0"`
+	Active       *bool             `json:"active"`
+	ReorderPoint int               `json:"reorder_point" validate:"min=0"`
+	ReorderQty   int               `json:"reorder_qty" validate:"min=0"`
+}
+
+func (r ProductVariantRequest) Validate() error {
+	if err := validation.NewValidator().ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+	return nil
+}
+
+// NewVariant returns the catalog entry for a new variant of p, which must have
+// been read with its variants. The attributes must give one of the values of
+// every option of p, and no other variant of p may have the same ones.
+func (p Product) NewVariant(req ProductVariantRequest) (Product, error) {
+	if p.ParentID != nil {
+		return Product{}, errorsPkg.ValidationError(fmt.Sprintf("product %d is itself a variant", p.ID))
+	}
+	if len(p.Options) == 0 {
+		return Product{}, errorsPkg.ValidationError(fmt.Sprintf("product %s has no options to make variants from", p.Name))
+	}
+	if err := checkAttributes(p.Options, req.Attributes); err != nil {
+		return Product{}, errorsPkg.ValidationError(err.Error())
+	}
+	for _, v := range p.Variants {
+		if maps.Equal(v.Attributes, req.Attributes) {
+			return Product{}, VariantTakenError(p.Name, v.Name)
+		}
+	}
+
+	variant := Product{
+		Name:         req.Name,
+		Price:        p.Price,
+		Cost:         p.Cost,
+		Stock:        req.Stock,
+		Active:       p.Active,
+		TaxExempt:    p.TaxExempt,
+		TaxInclusive: p.TaxInclusive,
+		ReorderPoint: req.ReorderPoint,
+		ReorderQty:   req.ReorderQty,
+		CategoryID:   p.CategoryID,
+		SKU:          req.SKU,
+		Barcode:      req.Barcode,
+		ParentID:     &p.ID,
+		Attributes:   maps.Clone(req.Attributes),
+	}
+	if variant.Name == "" {
+		parts := []string{p.Name}
+		for _, option := range p.Options {
+			parts = append(parts, req.Attributes[option.Name])
+		}
+		variant.Name = strings.Join(parts, " ")
+	}
+	if req.Price != nil {
+		variant.Price = *req.Price
+	}
+	if req.Cost != nil {
+		variant.Cost = *req.Cost
+	}
+	if req.Active != nil {
+		variant.Active = *req.Active
+	}
+	return variant, nil
+}
+
+// CheckUpdate checks that p, read with its variants, can take the options of
+// next: a variant has none of its own, and the options of a product with variants
+// must still allow the attributes of every variant
+func (p Product) CheckUpdate(next Product) error {
+	if p.ParentID != nil && len(next.Options) > 0 {
+		return errorsPkg.ValidationError(fmt.Sprintf("product %d is a variant and cannot have options", p.ID))
+	}
+	for _, v := range p.Variants {
+		if checkAttributes(next.Options, v.Attributes) != nil {
+			return fmt.Errorf("%w: options no longer allow the attributes of variant %s", ErrConflict, v.Name)
+		}
+	}
+	return nil
+}
+
+// validateOptions refuses options or values that are given twice
+func validateOptions(options []ProductOption) error {
+	names := make(map[string]bool, len(options))
+	for _, option := range options {
+		if names[option.Name] {
+			return errorsPkg.ValidationError(fmt.Sprintf("option %s is given twice", option.Name))
+		}
+		names[option.Name] = true
+
+		values := make(map[string]bool, len(option.Values))
+		for _, value := range option.Values {
+			if values[value] {
+				return errorsPkg.ValidationError(fmt.Sprintf("option %s has value %s twice", option.Name, value))
+			}
+			values[value] = true
+		}
+	}
+	return nil
+}
+
+// checkAttributes reports attributes that do not give exactly one allowed value
+// for every option
+func checkAttributes(options []ProductOption, attributes map[string]string) error {
+	if len(attributes) != len(options) {
+		names := make([]string, len(options))
+		for i, option := range options {
+			names[i] = option.Name
+		}
+		return fmt.Errorf("attributes must give a value for each of [%s]", strings.Join(names, " "))
+	}
+	for _, option := range options {
+		value, ok := attributes[option.Name]
+		if !ok {
+			return fmt.Errorf("attributes must give a value for option %s", option.Name)
+		}
+		allowed := false
+		for _, v := range option.Values {
+			allowed = allowed || v == value
+		}
+		if !allowed {
+			return fmt.Errorf("%s is not a value of option %s", value, option.Name)
+		}
+	}
+	return nil
+}
+
+// VariantTakenError reports a variant whose attributes another variant of the product already has
+func VariantTakenError(productName, variantName string) error {
+	return fmt.Errorf("%w: product %s already has a variant with these attributes (%s)", ErrConflict, productName, variantName)
+}
+
+// SKUTakenError reports a SKU already given to another product
+func SKUTakenError(sku string) error {
+	return fmt.Errorf("%w: sku %s is already in use", ErrConflict, sku)
+}
+
+// BarcodeTakenError reports a barcode already given to another product
+func BarcodeTakenError(barcode string) error {
+	return fmt.Errorf("%w: barcode %s is already in use", ErrConflict, barcode)
+}
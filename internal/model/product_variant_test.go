@@ -0,0 +1,131 @@
+package model
+
+import "testing"
+
+func newIndomie() Product {
+	return Product{
+		ID: 1, Name: "Indomie", Price: 3500, Cost: 2800, Active: true, TaxExempt: true,
+		Options: []ProductOption{{Name: "flavour", Values: []string{"Goreng", "Soto", "Kari"}}},
+		Variants: []ProductVariant{
+			{ID: 2, Name: "Indomie Goreng", Attributes: map[string]string{"flavour": "Goreng"}},
+		},
+	}
+}
+
+func TestProduct_NewVariant(t *testing.T) {
+	p := newIndomie()
+	price := 3700
+
+	v, err := p.NewVariant(ProductVariantRequest{SKU: "IDM-SOTO", Attributes: map[string]string{"flavour": "Soto"}, Price: &price, Stock: 12})
+	if err != nil {
+		t.Fatalf("NewVariant() error = %v", err)
+	}
+	if v.Name != "Indomie Soto" || v.Price != 3700 || v.Cost != 2800 || v.Stock != 12 || !v.Active || !v.TaxExempt {
+		t.Errorf("Unexpected variant: %+v", v)
+	}
+	if v.ParentID == nil || *v.ParentID != 1 || v.SKU != "IDM-SOTO" {
+		t.Errorf("ParentID = %v, SKU = %q", v.ParentID, v.SKU)
+	}
+}
+
+func TestProduct_NewVariant_Errors(t *testing.T) {
+	parentID := 1
+	tests := []struct {
+		name       string
+		product    Product
+		attributes map[string]string
+		want       func(error) bool
+	}{
+		{"unknown value", newIndomie(), map[string]string{"flavour": "Rendang"}, IsValidationError},
+		{"unknown option", newIndomie(), map[string]string{"size": "1L"}, IsValidationError},
+		{"extra option", newIndomie(), map[string]string{"flavour": "Soto", "size": "1L"}, IsValidationError},
+		{"taken", newIndomie(), map[string]string{"flavour": "Goreng"}, IsConflictError},
+		{"no options", Product{ID: 1, Name: "Aqua"}, map[string]string{"size": "1L"}, IsValidationError},
+		{"variant of a variant", Product{ID: 2, ParentID: &parentID, Options: newIndomie().Options}, map[string]string{"flavour": "Soto"}, IsValidationError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.product.NewVariant(ProductVariantRequest{Attributes: tt.attributes})
+			if !tt.want(err) {
+				t.Errorf("NewVariant() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestProduct_CheckUpdate(t *testing.T) {
+	p := newIndomie()
+
+	wider := Product{Options: []ProductOption{{Name: "flavour", Values: []string{"Goreng", "Soto", "Kari", "Rendang"}}}}
+	if err := p.CheckUpdate(wider); err != nil {
+		t.Errorf("CheckUpdate() adding a value error = %v", err)
+	}
+
+	narrower := Product{Options: []ProductOption{{Name: "flavour", Values: []string{"Soto", "Kari"}}}}
+	if err := p.CheckUpdate(narrower); !IsConflictError(err) {
+		t.Errorf("CheckUpdate() dropping a used value error = %v, want a conflict", err)
+	}
+
+	parentID := 1
+	variant := Product{ID: 2, ParentID: &parentID}
+	if err := variant.CheckUpdate(wider); !IsValidationError(err) {
+		t.Errorf("CheckUpdate() giving a variant options error = %v, want a validation error", err)
+	}
+}
+
+func TestProduct_Validate_Options(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []ProductOption
+		wantErr bool
+	}{
+		{"two options", []ProductOption{{Name: "flavour", Values: []string{"Goreng"}}, {Name: "size", Values: []string{"1L", "2L"}}}, false},
+		{"option twice", []ProductOption{{Name: "size", Values: []string{"1L"}}, {Name: "size", Values: []string{"2L"}}}, true},
+		{"value twice", []ProductOption{{Name: "size", Values: []string{"1L", "1L"}}}, true},
+		{"no values", []ProductOption{{Name: "size"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Product{Name: "Aqua", Price: 3000, Options: tt.options}
+			if err := p.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckoutItem_CheckProduct(t *testing.T) {
+	parentID, variantID := 1, 2
+	variant := Product{ID: 2, Name: "Indomie Soto", Active: true, ParentID: &parentID}
+	plain := Product{ID: 1, Name: "Indomie", Active: true}
+	otherParent := 9
+
+	tests := []struct {
+		name        string
+		item        CheckoutItem
+		product     Product
+		hasVariants bool
+		wantErr     bool
+	}{
+		{"variant by variant_id", CheckoutItem{VariantID: &variantID}, variant, false, false},
+		{"variant with its product", CheckoutItem{ProductID: 1, VariantID: &variantID}, variant, false, false},
+		{"variant by its own id", CheckoutItem{ProductID: 2}, variant, false, false},
+		{"variant of another product", CheckoutItem{ProductID: otherParent, VariantID: &variantID}, variant, false, true},
+		{"variant_id of a plain product", CheckoutItem{VariantID: &parentID}, plain, false, true},
+		{"product with variants", CheckoutItem{ProductID: 1}, plain, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.item.CheckProduct(tt.product, tt.hasVariants); (err != nil) != tt.wantErr {
+				t.Errorf("CheckProduct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if got := (CheckoutItem{ProductID: 1, VariantID: &variantID}).CatalogID(); got != 2 {
+		t.Errorf("CatalogID() = %d, want 2", got)
+	}
+}
@@ -0,0 +1,304 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/validation"
+)
+
+// PromotionType determines how a promotion computes its discount
+type PromotionType string
+
+const (
+	// PromotionTypePercent takes Value percent off every matching line
+	PromotionTypePercent PromotionType = "percent"
+	// PromotionTypeFixed takes Value rupiah off every matching unit
+	PromotionTypeFixed PromotionType = "fixed"
+	// PromotionTypeBuyXGetY gives GetQty free units for every BuyQty+GetQty units of a matching product.
+	// The variants of a product count together, and the cheapest units go free.
+	PromotionTypeBuyXGetY PromotionType = "buy_x_get_y"
+	// PromotionTypeOrder takes Value rupiah off the whole basket, usually combined with MinSpend
+	PromotionTypeOrder PromotionType = "order"
+)
+
+// IsValid reports whether the type is one of the known promotion types
+func (t PromotionType) IsValid() bool {
+	switch t {
+	case PromotionTypePercent, PromotionTypeFixed, PromotionTypeBuyXGetY, PromotionTypeOrder:
+		return true
+	}
+	return false
+}
+
+// Promotion is a discount rule evaluated against the basket at checkout.
+// ProductID or CategoryID narrow the lines it applies to; with neither set it
+// applies to every line. Promotions are evaluated from the highest Priority down.
+// A promotion that is not Stackable only applies when nothing else has been
+// applied yet, and stops evaluation once it does.
+type Promotion struct {
+	ID         int           `json:"id"`
+	Name       string        `json:"name" validate:"required,min=1,max=255"`
+	Type       PromotionType `json:"type" validate:"required"`
+	Value      int           `json:"value" validate:"min=0"`
+	ProductID  *int          `json:"product_id,omitempty" validate:"omitempty,min=1"`
+	CategoryID *int          `json:"category_id,omitempty" validate:"omitempty,min=1"`
+	BuyQty     int           `json:"buy_qty,omitempty" validate:"min=0"`
+	GetQty     int           `json:"get_qty,omitempty" validate:"min=0"`
+	MinSpend   int           `json:"min_spend,omitempty" validate:"min=0"`
+	Priority   int           `json:"priority"`
+	Stackable  bool          `json:"stackable"`
+	Active     bool          `json:"active"`
+	StartsAt   *time.Time    `json:"starts_at,omitempty"`
+	EndsAt     *time.Time    `json:"ends_at,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+func (p Promotion) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(p); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	switch p.Type {
+	case PromotionTypePercent:
+		if p.Value < 1 || p.Value > 100 {
+			return errorsPkg.ValidationError("value must be between 1 and 100 for percent promotions")
+		}
+	case PromotionTypeFixed, PromotionTypeOrder:
+		if p.Value < 1 {
+			return errorsPkg.ValidationError("value must be positive")
+		}
+	case PromotionTypeBuyXGetY:
+		if p.BuyQty < 1 || p.GetQty < 1 {
+			return errorsPkg.ValidationError("buy_qty and get_qty must be positive for buy_x_get_y promotions")
+		}
+	default:
+		return errorsPkg.ValidationError("type must be one of [percent fixed buy_x_get_y order]")
+	}
+
+	if p.ProductID != nil && p.CategoryID != nil {
+		return errorsPkg.ValidationError("only one of product_id and category_id may be set")
+	}
+	if p.Type == PromotionTypeOrder && (p.ProductID != nil || p.CategoryID != nil) {
+		return errorsPkg.ValidationError("order promotions apply to the whole basket and cannot target a product or category")
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return errorsPkg.ValidationError("ends_at must be after starts_at")
+	}
+
+	return nil
+}
+
+// IsActiveAt reports whether the promotion is enabled and inside its validity window
+func (p Promotion) IsActiveAt(at time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && at.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !at.Before(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// matches reports whether the promotion targets the line
+func (p Promotion) matches(line BasketLine) bool {
+	if p.ProductID != nil {
+		return line.ProductID == *p.ProductID || (line.ParentID != nil && *line.ParentID == *p.ProductID)
+	}
+	if p.CategoryID != nil {
+		return line.CategoryID != nil && *line.CategoryID == *p.CategoryID
+	}
+	return true
+}
+
+// AppliedPromotion records how much a promotion took off a transaction
+type AppliedPromotion struct {
+	PromotionID int    `json:"promotion_id"`
+	Name        string `json:"name"`
+	Amount      int    `json:"amount"`
+}
+
+// BasketLine is a checkout line being priced. Discount accumulates the amount
+// taken off Gross by promotions, and Tax is filled in by ApplyTax.
+type BasketLine struct {
+	ProductID    int
+	ParentID     *int // product of a variant, so promotions on the product cover its variants
+	CategoryID   *int
+	UnitPrice    int
+	Quantity     int
+	TaxExempt    bool
+	TaxInclusive bool
+	Gross        int
+	Discount     int
+	Tax          int
+}
+
+// catalogID returns the product a line is sold as: the parent of a variant,
+// otherwise the product itself
+func (l BasketLine) catalogID() int {
+	if l.ParentID != nil {
+		return *l.ParentID
+	}
+	return l.ProductID
+}
+
+// Net returns the line amount after discounts
+func (l BasketLine) Net() int {
+	return l.Gross - l.Discount
+}
+
+// Total returns what the customer pays for the line: the net amount plus tax
+// when the price is tax-exclusive
+func (l BasketLine) Total() int {
+	if l.TaxInclusive {
+		return l.Net()
+	}
+	return l.Net() + l.Tax
+}
+
+// ApplyPromotions evaluates the promotions active at the given time against the
+// basket, adding their discounts to the lines, and returns the promotions that
+// took effect. A line's discount never exceeds its gross amount.
+func ApplyPromotions(lines []BasketLine, promotions []Promotion, at time.Time) []AppliedPromotion {
+	ordered := make([]Promotion, 0, len(promotions))
+	for _, p := range promotions {
+		if p.IsActiveAt(at) {
+			ordered = append(ordered, p)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Priority != ordered[j].Priority {
+			return ordered[i].Priority > ordered[j].Priority
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	gross := 0
+	for _, l := range lines {
+		gross += l.Gross
+	}
+
+	applied := make([]AppliedPromotion, 0)
+	for _, p := range ordered {
+		if !p.Stackable && len(applied) > 0 {
+			continue
+		}
+		if gross < p.MinSpend {
+			continue
+		}
+
+		amount := applyPromotion(lines, p)
+		if amount == 0 {
+			continue
+		}
+		applied = append(applied, AppliedPromotion{PromotionID: p.ID, Name: p.Name, Amount: amount})
+
+		if !p.Stackable {
+			break
+		}
+	}
+
+	return applied
+}
+
+// applyPromotion adds one promotion's discount to the lines and returns the total taken off
+func applyPromotion(lines []BasketLine, p Promotion) int {
+	total := 0
+	discount := func(i, amount int) {
+		amount = min(amount, lines[i].Net())
+		if amount > 0 {
+			lines[i].Discount += amount
+			total += amount
+		}
+	}
+
+	switch p.Type {
+	case PromotionTypePercent:
+		for i := range lines {
+			if p.matches(lines[i]) {
+				discount(i, lines[i].Net()*p.Value/100)
+			}
+		}
+
+	case PromotionTypeFixed:
+		for i := range lines {
+			if p.matches(lines[i]) {
+				discount(i, p.Value*lines[i].Quantity)
+			}
+		}
+
+	case PromotionTypeBuyXGetY:
+		// Count units per catalog product so split lines and different variants of
+		// the same product still qualify together
+		units := make(map[int]int)
+		for _, l := range lines {
+			if p.matches(l) {
+				units[l.catalogID()] += l.Quantity
+			}
+		}
+		free := make(map[int]int)
+		for catalogID, qty := range units {
+			free[catalogID] = qty / (p.BuyQty + p.GetQty) * p.GetQty
+		}
+		// The cheapest units of each product are the free ones
+		order := make([]int, len(lines))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return lines[order[a]].UnitPrice < lines[order[b]].UnitPrice
+		})
+		for _, i := range order {
+			id := lines[i].catalogID()
+			n := min(free[id], lines[i].Quantity)
+			if n > 0 && p.matches(lines[i]) {
+				discount(i, n*lines[i].UnitPrice)
+				free[id] -= n
+			}
+		}
+
+	case PromotionTypeOrder:
+		// Spread the order discount over the lines in proportion to what is left of each
+		remaining := 0
+		for _, l := range lines {
+			remaining += l.Net()
+		}
+		amount := min(p.Value, remaining)
+		if amount == 0 {
+			return 0
+		}
+		shares := make([]int, len(lines))
+		allocated := 0
+		last := -1
+		for i, l := range lines {
+			if l.Net() > 0 {
+				shares[i] = amount * l.Net() / remaining
+				allocated += shares[i]
+				last = i
+			}
+		}
+		shares[last] += amount - allocated
+		for i, share := range shares {
+			discount(i, share)
+		}
+	}
+
+	return total
+}
+
+// PromotionTargetError reports a promotion aimed at a product or category that does not exist
+func PromotionTargetError(field string, id int) error {
+	return fmt.Errorf("%w: %s %d does not exist", ErrValidation, field, id)
+}
+
+// CategoryPromotedError reports a category that cannot be deleted while promotions target it
+func CategoryPromotedError(id int) error {
+	return fmt.Errorf("%w: category %d is the target of promotions, delete them first", ErrConflict, id)
+}
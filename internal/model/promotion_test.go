@@ -0,0 +1,158 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestPromotion_Validate(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		promo   Promotion
+		wantErr bool
+	}{
+		{name: "valid percent", promo: Promotion{Name: "10% drinks", Type: PromotionTypePercent, Value: 10, CategoryID: intPtr(2)}},
+		{name: "percent over 100", promo: Promotion{Name: "x", Type: PromotionTypePercent, Value: 150}, wantErr: true},
+		{name: "buy x get y without quantities", promo: Promotion{Name: "x", Type: PromotionTypeBuyXGetY}, wantErr: true},
+		{name: "unknown type", promo: Promotion{Name: "x", Type: "bogus", Value: 1}, wantErr: true},
+		{name: "order with product", promo: Promotion{Name: "x", Type: PromotionTypeOrder, Value: 1, ProductID: intPtr(1)}, wantErr: true},
+		{name: "window ends before start", promo: Promotion{Name: "x", Type: PromotionTypeFixed, Value: 1, StartsAt: &start, EndsAt: &end}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.promo.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyPromotions(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	expired := now.Add(-time.Hour)
+
+	// Indomie 3500 x3 (category 1), Teh Botol 5000 x2 (category 2)
+	basket := func() []BasketLine {
+		return []BasketLine{
+			{ProductID: 1, CategoryID: intPtr(1), UnitPrice: 3500, Quantity: 3, Gross: 10500},
+			{ProductID: 2, CategoryID: intPtr(2), UnitPrice: 5000, Quantity: 2, Gross: 10000},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		promotions    []Promotion
+		wantDiscounts []int
+		wantApplied   int
+	}{
+		{
+			name:          "percent off a category",
+			promotions:    []Promotion{{ID: 1, Name: "Drinks 10%", Type: PromotionTypePercent, Value: 10, CategoryID: intPtr(2), Active: true}},
+			wantDiscounts: []int{0, 1000},
+			wantApplied:   1,
+		},
+		{
+			name:          "fixed off a product per unit",
+			promotions:    []Promotion{{ID: 1, Name: "Indomie -500", Type: PromotionTypeFixed, Value: 500, ProductID: intPtr(1), Active: true}},
+			wantDiscounts: []int{1500, 0},
+			wantApplied:   1,
+		},
+		{
+			name:          "buy 2 get 1",
+			promotions:    []Promotion{{ID: 1, Name: "Indomie B2G1", Type: PromotionTypeBuyXGetY, BuyQty: 2, GetQty: 1, ProductID: intPtr(1), Active: true}},
+			wantDiscounts: []int{3500, 0},
+			wantApplied:   1,
+		},
+		{
+			name:          "minimum spend order discount is spread over lines",
+			promotions:    []Promotion{{ID: 1, Name: "Spend 20k save 2k", Type: PromotionTypeOrder, Value: 2050, MinSpend: 20000, Active: true}},
+			wantDiscounts: []int{1050, 1000},
+			wantApplied:   1,
+		},
+		{
+			name:          "minimum spend not met",
+			promotions:    []Promotion{{ID: 1, Name: "Spend 50k", Type: PromotionTypeOrder, Value: 5000, MinSpend: 50000, Active: true}},
+			wantDiscounts: []int{0, 0},
+		},
+		{
+			name: "higher priority exclusive promotion blocks the rest",
+			promotions: []Promotion{
+				{ID: 1, Name: "Drinks 10%", Type: PromotionTypePercent, Value: 10, CategoryID: intPtr(2), Active: true, Stackable: true},
+				{ID: 2, Name: "Indomie B2G1", Type: PromotionTypeBuyXGetY, BuyQty: 2, GetQty: 1, ProductID: intPtr(1), Active: true, Priority: 10},
+			},
+			wantDiscounts: []int{3500, 0},
+			wantApplied:   1,
+		},
+		{
+			name: "stackable promotions combine",
+			promotions: []Promotion{
+				{ID: 1, Name: "Drinks 10%", Type: PromotionTypePercent, Value: 10, CategoryID: intPtr(2), Active: true, Stackable: true},
+				{ID: 2, Name: "Indomie -500", Type: PromotionTypeFixed, Value: 500, ProductID: intPtr(1), Active: true, Stackable: true},
+			},
+			wantDiscounts: []int{1500, 1000},
+			wantApplied:   2,
+		},
+		{
+			name: "inactive and expired promotions are ignored",
+			promotions: []Promotion{
+				{ID: 1, Name: "Off", Type: PromotionTypePercent, Value: 50, Active: false},
+				{ID: 2, Name: "Expired", Type: PromotionTypePercent, Value: 50, Active: true, EndsAt: &expired},
+			},
+			wantDiscounts: []int{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := basket()
+			applied := ApplyPromotions(lines, tt.promotions, now)
+
+			if len(applied) != tt.wantApplied {
+				t.Errorf("applied = %d, want %d", len(applied), tt.wantApplied)
+			}
+			for i, want := range tt.wantDiscounts {
+				if lines[i].Discount != want {
+					t.Errorf("line %d discount = %d, want %d", i, lines[i].Discount, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyPromotions_BuyXGetYPoolsVariants(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	// Aqua 600ml (3000) and 1.5L (6000), both variants of product 1
+	lines := []BasketLine{
+		{ProductID: 2, ParentID: intPtr(1), UnitPrice: 6000, Quantity: 2, Gross: 12000},
+		{ProductID: 3, ParentID: intPtr(1), UnitPrice: 3000, Quantity: 1, Gross: 3000},
+	}
+	promotions := []Promotion{{ID: 1, Name: "Aqua B2G1", Type: PromotionTypeBuyXGetY, BuyQty: 2, GetQty: 1, ProductID: intPtr(1), Active: true}}
+
+	applied := ApplyPromotions(lines, promotions, now)
+
+	// Three units of the product qualify together, and the cheapest one is free
+	if len(applied) != 1 || applied[0].Amount != 3000 {
+		t.Fatalf("applied = %+v, want 3000 off", applied)
+	}
+	if lines[0].Discount != 0 || lines[1].Discount != 3000 {
+		t.Errorf("discounts = %d, %d, want 0, 3000", lines[0].Discount, lines[1].Discount)
+	}
+}
+
+func TestApplyPromotions_NeverNegative(t *testing.T) {
+	lines := []BasketLine{{ProductID: 1, UnitPrice: 1000, Quantity: 1, Gross: 1000}}
+	ApplyPromotions(lines, []Promotion{
+		{ID: 1, Name: "Huge", Type: PromotionTypeFixed, Value: 5000, Active: true, Stackable: true},
+		{ID: 2, Name: "Order", Type: PromotionTypeOrder, Value: 5000, Active: true, Stackable: true},
+	}, time.Now())
+
+	if lines[0].Net() != 0 {
+		t.Errorf("Net() = %d, want 0", lines[0].Net())
+	}
+}
@@ -0,0 +1,318 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/validation"
+)
+
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderStatusDraft             PurchaseOrderStatus = "draft"
+	PurchaseOrderStatusSent              PurchaseOrderStatus = "sent"
+	PurchaseOrderStatusPartiallyReceived PurchaseOrderStatus = "partially_received"
+	PurchaseOrderStatusClosed            PurchaseOrderStatus = "closed"
+)
+
+// PurchaseOrderStatuses lists every purchase order status
+var PurchaseOrderStatuses = []PurchaseOrderStatus{
+	PurchaseOrderStatusDraft, PurchaseOrderStatusSent,
+	PurchaseOrderStatusPartiallyReceived, PurchaseOrderStatusClosed,
+}
+
+// PurchaseOrder orders stock from a supplier for an outlet. A draft can be edited
+// until it is sent; goods receipts against a sent order post the delivered stock
+// at the order's outlet. The order closes once everything has arrived, or when it
+// is closed by hand, e.g. because the supplier will not deliver the rest.
+type PurchaseOrder struct {
+	ID           int                 `json:"id"`
+	SupplierID   int                 `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name"`
+	OutletID     int                 `json:"outlet_id"`
+	Status       PurchaseOrderStatus `json:"status"`
+	Note         string              `json:"note,omitempty"`
+	CreatedBy    string              `json:"created_by,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	SentAt       *time.Time          `json:"sent_at,omitempty"`
+	ClosedAt     *time.Time          `json:"closed_at,omitempty"`
+	Items        []PurchaseOrderItem `json:"items"`
+	Receipts     []GoodsReceipt      `json:"receipts"`
+	TotalCost    int                 `json:"total_cost"`    // ordered quantity at the ordered cost
+	ReceivedCost int                 `json:"received_cost"` // received quantity at the receipt cost
+}
+
+type PurchaseOrderItem struct {
+	ProductID        int    `json:"product_id"`
+	ProductName      string `json:"product_name"`
+	Quantity         int    `json:"quantity"`
+	UnitCost         int    `json:"unit_cost"`
+	ReceivedQuantity int    `json:"received_quantity"`
+	Outstanding      int    `json:"outstanding"` // still to come; 0 once fully or over-delivered
+}
+
+// GoodsReceipt is one delivery against a purchase order
+type GoodsReceipt struct {
+	ID              int                `json:"id"`
+	PurchaseOrderID int                `json:"purchase_order_id"`
+	OutletID        int                `json:"outlet_id"`
+	Note            string             `json:"note,omitempty"`
+	ReceivedBy      string             `json:"received_by,omitempty"`
+	ReceivedAt      time.Time          `json:"received_at"`
+	Items           []GoodsReceiptItem `json:"items"`
+}
+
+type GoodsReceiptItem struct {
+	ProductID     int `json:"product_id"`
+	Quantity      int `json:"quantity"`
+	UnitCost      int `json:"unit_cost"`                // purchase cost per unit of this delivery
+	OverDelivered int `json:"over_delivered,omitempty"` // units beyond what was outstanding
+}
+
+// IsOpen reports whether the order is with the supplier and awaiting delivery
+func (po PurchaseOrder) IsOpen() bool {
+	return po.Status == PurchaseOrderStatusSent || po.Status == PurchaseOrderStatusPartiallyReceived
+}
+
+// WithTotals returns the order with the outstanding quantity of each item and the
+// order's totals filled in
+func (po PurchaseOrder) WithTotals() PurchaseOrder {
+	items := make([]PurchaseOrderItem, len(po.Items))
+	po.TotalCost, po.ReceivedCost = 0, 0
+	for i, item := range po.Items {
+		item.Outstanding = max(item.Quantity-item.ReceivedQuantity, 0)
+		po.TotalCost += item.Quantity * item.UnitCost
+		items[i] = item
+	}
+	for _, receipt := range po.Receipts {
+		for _, item := range receipt.Items {
+			po.ReceivedCost += item.Quantity * item.UnitCost
+		}
+	}
+	po.Items = items
+	return po
+}
+
+// AuditRecord returns the order as the audit log keeps it: the supplier by its ID
+// only, and without its receipts, which are logged as they are posted
+func (po PurchaseOrder) AuditRecord() PurchaseOrder {
+	po.SupplierName = ""
+	po.Receipts = nil
+	return po
+}
+
+// Send marks a draft as sent to the supplier
+func (po *PurchaseOrder) Send(at time.Time) error {
+	if po.Status != PurchaseOrderStatusDraft {
+		return PurchaseOrderStatusError(po.ID, po.Status, "sent")
+	}
+	po.Status = PurchaseOrderStatusSent
+	po.SentAt = &at
+	return nil
+}
+
+// Close closes an order that is not closed yet. Whatever is still outstanding
+// will not be received.
+func (po *PurchaseOrder) Close(at time.Time) error {
+	if po.Status == PurchaseOrderStatusClosed {
+		return PurchaseOrderStatusError(po.ID, po.Status, "closed")
+	}
+	po.Status = PurchaseOrderStatusClosed
+	po.ClosedAt = &at
+	return nil
+}
+
+// Receive books a delivery against a sent order and returns the receipt, without
+// an ID yet. Delivering more than is outstanding needs req.AcceptOverDelivery; a
+// short delivery leaves the order partially received unless req.Close is set.
+func (po *PurchaseOrder) Receive(ctx context.Context, req GoodsReceiptRequest, at time.Time) (GoodsReceipt, error) {
+	if !po.IsOpen() {
+		return GoodsReceipt{}, PurchaseOrderStatusError(po.ID, po.Status, "received")
+	}
+
+	receipt := GoodsReceipt{
+		PurchaseOrderID: po.ID,
+		OutletID:        po.OutletID,
+		Note:            req.Note,
+		ReceivedBy:      actorName(ctx),
+		ReceivedAt:      at,
+		Items:           make([]GoodsReceiptItem, 0, len(req.Items)),
+	}
+
+	items := slices.Clone(po.Items)
+	for _, ri := range req.Items {
+		i := slices.IndexFunc(items, func(item PurchaseOrderItem) bool { return item.ProductID == ri.ProductID })
+		if i < 0 {
+			return GoodsReceipt{}, errorsPkg.ValidationError(fmt.Sprintf("product %d is not on purchase order %d", ri.ProductID, po.ID))
+		}
+
+		outstanding := max(items[i].Quantity-items[i].ReceivedQuantity, 0)
+		over := max(ri.Quantity-outstanding, 0)
+		if over > 0 && !req.AcceptOverDelivery {
+			return GoodsReceipt{}, errorsPkg.ValidationError(fmt.Sprintf(
+				"%s: %d received but only %d outstanding; set accept_over_delivery to take the extra units",
+				items[i].ProductName, ri.Quantity, outstanding))
+		}
+
+		cost := items[i].UnitCost
+		if ri.UnitCost != nil {
+			cost = *ri.UnitCost
+		}
+		items[i].ReceivedQuantity += ri.Quantity
+		receipt.Items = append(receipt.Items, GoodsReceiptItem{
+			ProductID:     ri.ProductID,
+			Quantity:      ri.Quantity,
+			UnitCost:      cost,
+			OverDelivered: over,
+		})
+	}
+
+	po.Items = items
+	complete := !slices.ContainsFunc(items, func(item PurchaseOrderItem) bool { return item.ReceivedQuantity < item.Quantity })
+	if complete || req.Close {
+		po.Status = PurchaseOrderStatusClosed
+		po.ClosedAt = &at
+	} else {
+		po.Status = PurchaseOrderStatusPartiallyReceived
+	}
+	return receipt, nil
+}
+
+// Movements returns the stock the receipt posts: one receipt movement per item
+// at the order's outlet. The receipt must have its ID.
+func (g GoodsReceipt) Movements(ctx context.Context) []StockMovement {
+	movements := make([]StockMovement, 0, len(g.Items))
+	for _, item := range g.Items {
+		m := NewStockMovement(ctx, g.OutletID, item.ProductID, StockMovementReceipt, item.Quantity).For(StockReferenceReceipt, g.ID)
+		m.Note = fmt.Sprintf("purchase order %d", g.PurchaseOrderID)
+		movements = append(movements, m)
+	}
+	return movements
+}
+
+// PurchaseOrderRequest creates a draft order or replaces the contents of one
+type PurchaseOrderRequest struct {
+	SupplierID int                        `json:"supplier_id" validate:"required,min=1"`
+	Note       string                     `json:"note" validate:"max=500"`
+	Items      []PurchaseOrderItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+type PurchaseOrderItemRequest struct {
+	ProductID int `json:"product_id" validate:"required,min=1"`
+	Quantity  int `json:"quantity" validate:"min=1"`
+	UnitCost  int `json:"unit_cost" validate:"min=0"`
+}
+
+func (r PurchaseOrderRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	seen := make(map[int]bool, len(r.Items))
+	for _, item := range r.Items {
+		if seen[item.ProductID] {
+			return errorsPkg.ValidationError(fmt.Sprintf("product %d is on the order twice", item.ProductID))
+		}
+		seen[item.ProductID] = true
+	}
+
+	return nil
+}
+
+// PurchaseOrder returns the draft order described by the request, for the outlet
+// of ctx. Product names are filled in by the repository.
+func (r PurchaseOrderRequest) PurchaseOrder(ctx context.Context) PurchaseOrder {
+	items := make([]PurchaseOrderItem, len(r.Items))
+	for i, item := range r.Items {
+		items[i] = PurchaseOrderItem{ProductID: item.ProductID, Quantity: item.Quantity, UnitCost: item.UnitCost}
+	}
+	return PurchaseOrder{
+		SupplierID: r.SupplierID,
+		OutletID:   OutletID(ctx),
+		Status:     PurchaseOrderStatusDraft,
+		Note:       r.Note,
+		CreatedBy:  actorName(ctx),
+		Items:      items,
+	}
+}
+
+// GoodsReceiptRequest books a delivery. UnitCost defaults to the ordered cost.
+type GoodsReceiptRequest struct {
+	Items              []GoodsReceiptItemRequest `json:"items" validate:"required,min=1,dive"`
+	Note               string                    `json:"note" validate:"max=500"`
+	AcceptOverDelivery bool                      `json:"accept_over_delivery"`
+	Close              bool                      `json:"close"` // close the order even if items are still outstanding
+}
+
+type GoodsReceiptItemRequest struct {
+	ProductID int  `json:"product_id" validate:"required,min=1"`
+	Quantity  int  `json:"quantity" validate:"min=1"`
+	UnitCost  *int `json:"unit_cost,omitempty" validate:"omitempty,min=0"`
+}
+
+func (r GoodsReceiptRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	seen := make(map[int]bool, len(r.Items))
+	for _, item := range r.Items {
+		if seen[item.ProductID] {
+			return errorsPkg.ValidationError(fmt.Sprintf("product %d is received twice", item.ProductID))
+		}
+		seen[item.ProductID] = true
+	}
+
+	return nil
+}
+
+// PurchaseOrderFilter narrows the purchase orders of an outlet. Open keeps the
+// orders awaiting delivery (sent or partially received).
+type PurchaseOrderFilter struct {
+	SupplierID int
+	ProductID  int
+	Status     PurchaseOrderStatus
+	Open       bool
+}
+
+func (f PurchaseOrderFilter) Validate() error {
+	if f.Status != "" && !slices.Contains(PurchaseOrderStatuses, f.Status) {
+		return errorsPkg.ValidationError(fmt.Sprintf("unknown status %q", f.Status))
+	}
+	return nil
+}
+
+// Matches reports whether the order passes the filter
+func (f PurchaseOrderFilter) Matches(po PurchaseOrder) bool {
+	if f.SupplierID != 0 && po.SupplierID != f.SupplierID {
+		return false
+	}
+	if f.Status != "" && po.Status != f.Status {
+		return false
+	}
+	if f.Open && !po.IsOpen() {
+		return false
+	}
+	if f.ProductID != 0 && !slices.ContainsFunc(po.Items, func(item PurchaseOrderItem) bool { return item.ProductID == f.ProductID }) {
+		return false
+	}
+	return true
+}
+
+// PurchaseOrderStatusError reports an order that cannot be changed in its status
+func PurchaseOrderStatusError(id int, status PurchaseOrderStatus, action string) error {
+	return fmt.Errorf("%w: purchase order %d is %s and cannot be %s", ErrConflict, id, status, action)
+}
+
+// GoodsReceiptNotFoundError reports a goods receipt that does not exist
+func GoodsReceiptNotFoundError(id int) error {
+	return fmt.Errorf("%w: goods receipt id %d not found", ErrNotFound, id)
+}
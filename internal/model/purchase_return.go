@@ -0,0 +1,239 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/validation"
+)
+
+type PurchaseReturnStatus string
+
+const (
+	PurchaseReturnStatusOpen     PurchaseReturnStatus = "open"     // credit still owed by the supplier
+	PurchaseReturnStatusCredited PurchaseReturnStatus = "credited" // credit received in full
+)
+
+// PurchaseReturnStatuses lists every purchase return status
+var PurchaseReturnStatuses = []PurchaseReturnStatus{PurchaseReturnStatusOpen, PurchaseReturnStatusCredited}
+
+// PurchaseReturn sends goods back to a supplier, e.g. because they expired or
+// arrived damaged. The returned stock leaves the outlet when the return is
+// created, and the supplier owes the returned units at their unit cost as credit
+// until it is booked in full.
+type PurchaseReturn struct {
+	ID             int                  `json:"id"`
+	SupplierID     int                  `json:"supplier_id"`
+	SupplierName   string               `json:"supplier_name"`
+	OutletID       int                  `json:"outlet_id"`
+	GoodsReceiptID *int                 `json:"goods_receipt_id,omitempty"` // the delivery the goods came with, if known
+	Status         PurchaseReturnStatus `json:"status"`
+	Note           string               `json:"note,omitempty"`
+	CreatedBy      string               `json:"created_by,omitempty"`
+	CreatedAt      time.Time            `json:"created_at"`
+	CreditedAt     *time.Time           `json:"credited_at,omitempty"`
+	Items          []PurchaseReturnItem `json:"items"`
+	CreditTotal    int                  `json:"credit_total"`    // returned quantity at the unit cost
+	CreditReceived int                  `json:"credit_received"` // credit booked so far
+	CreditOwed     int                  `json:"credit_owed"`     // CreditTotal less CreditReceived
+}
+
+type PurchaseReturnItem struct {
+	ProductID   int         `json:"product_id"`
+	ProductName string      `json:"product_name"`
+	Quantity    int         `json:"quantity"`
+	UnitCost    int         `json:"unit_cost"`
+	Reason      StockReason `json:"reason"`
+}
+
+// ReturnableReceipt is a goods receipt as a purchase return against it sees it:
+// the supplier of its order and how much of each product was already returned
+type ReturnableReceipt struct {
+	GoodsReceipt
+	SupplierID int
+	Returned   map[int]int // product ID to units returned by earlier purchase returns
+}
+
+// NewPurchaseReturn returns the return described by req for the outlet of ctx,
+// without an ID yet. products holds the returned products by ID. Against a
+// receipt, only products on it can be returned, no more than were received, and
+// the unit cost defaults to the receipt's; otherwise it defaults to the product's
+// current cost.
+func NewPurchaseReturn(ctx context.Context, req PurchaseReturnRequest, products map[int]Product, receipt *ReturnableReceipt) (PurchaseReturn, error) {
+	ret := PurchaseReturn{
+		SupplierID: req.SupplierID,
+		OutletID:   OutletID(ctx),
+		Status:     PurchaseReturnStatusOpen,
+		Note:       req.Note,
+		CreatedBy:  actorName(ctx),
+		Items:      make([]PurchaseReturnItem, 0, len(req.Items)),
+	}
+
+	if receipt != nil {
+		if receipt.SupplierID != req.SupplierID {
+			return PurchaseReturn{}, errorsPkg.ValidationError(fmt.Sprintf("goods receipt %d is not from supplier %d", receipt.ID, req.SupplierID))
+		}
+		if receipt.OutletID != ret.OutletID {
+			return PurchaseReturn{}, errorsPkg.ValidationError(fmt.Sprintf("goods receipt %d was received at another outlet", receipt.ID))
+		}
+		ret.GoodsReceiptID = &receipt.ID
+	}
+
+	for _, ri := range req.Items {
+		product, ok := products[ri.ProductID]
+		if !ok {
+			return PurchaseReturn{}, fmt.Errorf("%w: product id %d not found", ErrNotFound, ri.ProductID)
+		}
+
+		cost := product.Cost
+		if receipt != nil {
+			i := slices.IndexFunc(receipt.Items, func(item GoodsReceiptItem) bool { return item.ProductID == ri.ProductID })
+			if i < 0 {
+				return PurchaseReturn{}, errorsPkg.ValidationError(fmt.Sprintf("product %d is not on goods receipt %d", ri.ProductID, receipt.ID))
+			}
+			if returnable := receipt.Items[i].Quantity - receipt.Returned[ri.ProductID]; ri.Quantity > returnable {
+				return PurchaseReturn{}, errorsPkg.ValidationError(fmt.Sprintf(
+					"%s: %d returned but only %d of goods receipt %d are left to return", product.Name, ri.Quantity, returnable, receipt.ID))
+			}
+			cost = receipt.Items[i].UnitCost
+		}
+		if ri.UnitCost != nil {
+			cost = *ri.UnitCost
+		}
+
+		ret.Items = append(ret.Items, PurchaseReturnItem{
+			ProductID:   ri.ProductID,
+			ProductName: product.Name,
+			Quantity:    ri.Quantity,
+			UnitCost:    cost,
+			Reason:      ri.Reason,
+		})
+	}
+	return ret, nil
+}
+
+// WithTotals returns the return with its credit totals filled in
+func (pr PurchaseReturn) WithTotals() PurchaseReturn {
+	pr.CreditTotal = 0
+	for _, item := range pr.Items {
+		pr.CreditTotal += item.Quantity * item.UnitCost
+	}
+	pr.CreditOwed = max(pr.CreditTotal-pr.CreditReceived, 0)
+	return pr
+}
+
+// AuditRecord returns the return as the audit log keeps it: the supplier by its
+// ID only
+func (pr PurchaseReturn) AuditRecord() PurchaseReturn {
+	pr.SupplierName = ""
+	return pr.WithTotals()
+}
+
+// RecordCredit books credit received from the supplier. The return is credited
+// once the credit owed is paid in full; more than is owed is refused.
+func (pr *PurchaseReturn) RecordCredit(amount int, at time.Time) error {
+	if pr.Status == PurchaseReturnStatusCredited {
+		return fmt.Errorf("%w: purchase return %d is already credited", ErrConflict, pr.ID)
+	}
+	owed := pr.WithTotals().CreditOwed
+	if amount > owed {
+		return errorsPkg.ValidationError(fmt.Sprintf("credit of %d exceeds the %d owed on purchase return %d", amount, owed, pr.ID))
+	}
+	pr.CreditReceived += amount
+	if pr.CreditReceived >= pr.WithTotals().CreditTotal {
+		pr.Status = PurchaseReturnStatusCredited
+		pr.CreditedAt = &at
+	}
+	return nil
+}
+
+// Movements returns the stock the return takes out of its outlet, one movement
+// per item under the item's reason. The return must have its ID.
+func (pr PurchaseReturn) Movements(ctx context.Context) []StockMovement {
+	movements := make([]StockMovement, 0, len(pr.Items))
+	for _, item := range pr.Items {
+		m := NewStockMovement(ctx, pr.OutletID, item.ProductID, StockMovementPurchaseReturn, -item.Quantity).For(StockReferencePurchaseReturn, pr.ID)
+		m.Reason = item.Reason
+		m.Note = pr.Note
+		movements = append(movements, m)
+	}
+	return movements
+}
+
+// PurchaseReturnRequest creates a purchase return. UnitCost defaults to the cost
+// on the goods receipt, or without one to the product's current cost.
+type PurchaseReturnRequest struct {
+	SupplierID     int                         `json:"supplier_id" validate:"required,min=1"`
+	GoodsReceiptID *int                        `json:"goods_receipt_id,omitempty" validate:"omitempty,min=1"`
+	Note           string                      `json:"note" validate:"max=500"`
+	Items          []PurchaseReturnItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+type PurchaseReturnItemRequest struct {
+	ProductID int         `json:"product_id" validate:"required,min=1"`
+	Quantity  int         `json:"quantity" validate:"min=1"`
+	UnitCost  *int        `json:"unit_cost,omitempty" validate:"omitempty,min=0"`
+	Reason    StockReason `json:"reason" validate:"required"`
+}
+
+func (r PurchaseReturnRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	seen := make(map[int]bool, len(r.Items))
+	for _, item := range r.Items {
+		if seen[item.ProductID] {
+			return errorsPkg.ValidationError(fmt.Sprintf("product %d is returned twice", item.ProductID))
+		}
+		seen[item.ProductID] = true
+		if !item.Reason.IsValid() || item.Reason == StockReasonFound {
+			return errorsPkg.ValidationError(fmt.Sprintf("unknown reason %q for a purchase return", item.Reason))
+		}
+	}
+
+	return nil
+}
+
+// SupplierCreditRequest books credit received against a purchase return
+type SupplierCreditRequest struct {
+	Amount int `json:"amount" validate:"min=1"`
+}
+
+func (r SupplierCreditRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+	return nil
+}
+
+// PurchaseReturnFilter narrows the purchase returns of an outlet
+type PurchaseReturnFilter struct {
+	SupplierID int
+	Status     PurchaseReturnStatus
+}
+
+func (f PurchaseReturnFilter) Validate() error {
+	if f.Status != "" && !slices.Contains(PurchaseReturnStatuses, f.Status) {
+		return errorsPkg.ValidationError(fmt.Sprintf("unknown status %q", f.Status))
+	}
+	return nil
+}
+
+// Matches reports whether the return passes the filter
+func (f PurchaseReturnFilter) Matches(pr PurchaseReturn) bool {
+	if f.SupplierID != 0 && pr.SupplierID != f.SupplierID {
+		return false
+	}
+	if f.Status != "" && pr.Status != f.Status {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/pkg/middleware"
+)
+
+func TestNewPurchaseReturn(t *testing.T) {
+	ctx := middleware.WithOutletID(middleware.WithPrincipal(context.Background(), &middleware.Principal{Username: "gudang"}), 2)
+	products := map[int]Product{
+		1: {ID: 1, Name: "Indomie", Cost: 2600},
+		2: {ID: 2, Name: "Teh Botol", Cost: 3100},
+	}
+	receipt := &ReturnableReceipt{
+		GoodsReceipt: GoodsReceipt{ID: 7, OutletID: 2, Items: []GoodsReceiptItem{{ProductID: 1, Quantity: 30, UnitCost: 2500}}},
+		SupplierID:   3,
+		Returned:     map[int]int{1: 25},
+	}
+	req := PurchaseReturnRequest{SupplierID: 3, Items: []PurchaseReturnItemRequest{{ProductID: 1, Quantity: 5, Reason: StockReasonExpired}}}
+
+	// Without a receipt the product's current cost applies
+	pr, err := NewPurchaseReturn(ctx, req, products, nil)
+	if err != nil {
+		t.Fatalf("NewPurchaseReturn() error = %v", err)
+	}
+	if pr.OutletID != 2 || pr.CreatedBy != "gudang" || pr.Status != PurchaseReturnStatusOpen || pr.Items[0].UnitCost != 2600 || pr.Items[0].ProductName != "Indomie" {
+		t.Errorf("Unexpected return: %+v", pr)
+	}
+
+	// Against the receipt its cost applies, and only the 5 not yet returned can go back
+	pr, err = NewPurchaseReturn(ctx, req, products, receipt)
+	if err != nil {
+		t.Fatalf("NewPurchaseReturn() against a receipt error = %v", err)
+	}
+	if *pr.GoodsReceiptID != 7 || pr.Items[0].UnitCost != 2500 || pr.WithTotals().CreditTotal != 12500 {
+		t.Errorf("Unexpected return: %+v", pr.WithTotals())
+	}
+
+	tests := []struct {
+		name    string
+		req     PurchaseReturnRequest
+		receipt *ReturnableReceipt
+		check   func(error) bool
+	}{
+		{"more than left on the receipt", PurchaseReturnRequest{SupplierID: 3, Items: []PurchaseReturnItemRequest{{ProductID: 1, Quantity: 6, Reason: StockReasonDamaged}}}, receipt, IsValidationError},
+		{"product not on the receipt", PurchaseReturnRequest{SupplierID: 3, Items: []PurchaseReturnItemRequest{{ProductID: 2, Quantity: 1, Reason: StockReasonDamaged}}}, receipt, IsValidationError},
+		{"receipt of another supplier", PurchaseReturnRequest{SupplierID: 4, Items: req.Items}, receipt, IsValidationError},
+		{"missing product", PurchaseReturnRequest{SupplierID: 3, Items: []PurchaseReturnItemRequest{{ProductID: 9, Quantity: 1, Reason: StockReasonDamaged}}}, nil, IsNotFoundError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPurchaseReturn(ctx, tt.req, products, tt.receipt); !tt.check(err) {
+				t.Errorf("NewPurchaseReturn() error = %v", err)
+			}
+		})
+	}
+
+	other := *receipt
+	other.OutletID = 1
+	if _, err := NewPurchaseReturn(ctx, req, products, &other); !IsValidationError(err) {
+		t.Errorf("NewPurchaseReturn() against another outlet's receipt error = %v, want validation", err)
+	}
+}
+
+func TestPurchaseReturn_RecordCredit(t *testing.T) {
+	pr := PurchaseReturn{ID: 5, Status: PurchaseReturnStatusOpen, Items: []PurchaseReturnItem{{ProductID: 1, Quantity: 4, UnitCost: 2500}}}
+
+	if err := pr.RecordCredit(6000, time.Now()); err != nil {
+		t.Fatalf("RecordCredit() error = %v", err)
+	}
+	if totals := pr.WithTotals(); totals.Status != PurchaseReturnStatusOpen || totals.CreditOwed != 4000 {
+		t.Errorf("Unexpected return after a partial credit: %+v", totals)
+	}
+	if err := pr.RecordCredit(4001, time.Now()); !IsValidationError(err) {
+		t.Errorf("RecordCredit() over the credit owed error = %v, want validation", err)
+	}
+	if err := pr.RecordCredit(4000, time.Now()); err != nil {
+		t.Fatalf("RecordCredit() error = %v", err)
+	}
+	if pr.Status != PurchaseReturnStatusCredited || pr.CreditedAt == nil {
+		t.Errorf("Expected the return credited, got %+v", pr)
+	}
+	if err := pr.RecordCredit(1, time.Now()); !IsConflictError(err) {
+		t.Errorf("RecordCredit() on a credited return error = %v, want conflict", err)
+	}
+}
+
+func TestPurchaseReturn_Movements(t *testing.T) {
+	pr := PurchaseReturn{ID: 5, OutletID: 2, Note: "RMA-0042", Items: []PurchaseReturnItem{{ProductID: 1, Quantity: 4, Reason: StockReasonExpired}}}
+	movements := pr.Movements(context.Background())
+
+	if len(movements) != 1 {
+		t.Fatalf("Expected 1 movement, got %+v", movements)
+	}
+	m := movements[0]
+	if m.OutletID != 2 || m.Quantity != -4 || m.Type != StockMovementPurchaseReturn || m.Reason != StockReasonExpired || m.ReferenceType != StockReferencePurchaseReturn || *m.ReferenceID != 5 {
+		t.Errorf("Unexpected movement: %+v", m)
+	}
+}
+
+func TestPurchaseReturnRequest_Validate(t *testing.T) {
+	item := PurchaseReturnItemRequest{ProductID: 1, Quantity: 2, Reason: StockReasonDamaged}
+	tests := []struct {
+		name    string
+		req     PurchaseReturnRequest
+		wantErr bool
+	}{
+		{name: "valid", req: PurchaseReturnRequest{SupplierID: 1, Items: []PurchaseReturnItemRequest{item}}, wantErr: false},
+		{name: "no supplier", req: PurchaseReturnRequest{Items: []PurchaseReturnItemRequest{item}}, wantErr: true},
+		{name: "no items", req: PurchaseReturnRequest{SupplierID: 1}, wantErr: true},
+		{name: "no reason", req: PurchaseReturnRequest{SupplierID: 1, Items: []PurchaseReturnItemRequest{{ProductID: 1, Quantity: 2}}}, wantErr: true},
+		{name: "found goods", req: PurchaseReturnRequest{SupplierID: 1, Items: []PurchaseReturnItemRequest{{ProductID: 1, Quantity: 2, Reason: StockReasonFound}}}, wantErr: true},
+		{name: "product twice", req: PurchaseReturnRequest{SupplierID: 1, Items: []PurchaseReturnItemRequest{item, item}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
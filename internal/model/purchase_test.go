@@ -0,0 +1,142 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/pkg/middleware"
+)
+
+func newTestPurchaseOrder() PurchaseOrder {
+	return PurchaseOrder{
+		ID:       4,
+		OutletID: 1,
+		Status:   PurchaseOrderStatusSent,
+		Items: []PurchaseOrderItem{
+			{ProductID: 1, ProductName: "Indomie", Quantity: 40, UnitCost: 2500},
+			{ProductID: 2, ProductName: "Teh Botol", Quantity: 24, UnitCost: 3000},
+		},
+	}
+}
+
+func TestPurchaseOrder_Receive(t *testing.T) {
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{Username: "gudang"})
+	po := newTestPurchaseOrder()
+	now := time.Now()
+
+	// The first delivery is short on noodles and at a higher price for the tea
+	receipt, err := po.Receive(ctx, GoodsReceiptRequest{Items: []GoodsReceiptItemRequest{
+		{ProductID: 1, Quantity: 30},
+		{ProductID: 2, Quantity: 24, UnitCost: intPtr(3200)},
+	}}, now)
+	if err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	if po.Status != PurchaseOrderStatusPartiallyReceived || po.Items[0].ReceivedQuantity != 30 {
+		t.Errorf("Unexpected order after a short delivery: %+v", po)
+	}
+	if receipt.ReceivedBy != "gudang" || receipt.Items[0].UnitCost != 2500 || receipt.Items[1].UnitCost != 3200 {
+		t.Errorf("Unexpected receipt: %+v", receipt)
+	}
+
+	// 12 arrive where 10 are outstanding
+	over := GoodsReceiptRequest{Items: []GoodsReceiptItemRequest{{ProductID: 1, Quantity: 12}}}
+	if _, err := po.Receive(ctx, over, now); !IsValidationError(err) {
+		t.Errorf("Receive() over the outstanding quantity error = %v, want validation", err)
+	}
+	if po.Items[0].ReceivedQuantity != 30 {
+		t.Error("Expected a refused delivery to leave the order unchanged")
+	}
+	over.AcceptOverDelivery = true
+	receipt, err = po.Receive(ctx, over, now)
+	if err != nil {
+		t.Fatalf("Receive() with accept_over_delivery error = %v", err)
+	}
+	if receipt.Items[0].OverDelivered != 2 || po.Status != PurchaseOrderStatusClosed || po.ClosedAt == nil {
+		t.Errorf("Expected the order closed with 2 over-delivered, got %+v / %+v", po, receipt)
+	}
+
+	po.Receipts = []GoodsReceipt{{Items: []GoodsReceiptItem{{Quantity: 30, UnitCost: 2500}, {Quantity: 24, UnitCost: 3200}, {Quantity: 12, UnitCost: 2500}}}}
+	totals := po.WithTotals()
+	if totals.TotalCost != 172000 || totals.ReceivedCost != 181800 || totals.Items[0].Outstanding != 0 {
+		t.Errorf("Unexpected totals: total %d, received %d, items %+v", totals.TotalCost, totals.ReceivedCost, totals.Items)
+	}
+
+	if _, err := po.Receive(ctx, over, now); !IsConflictError(err) {
+		t.Errorf("Receive() on a closed order error = %v, want conflict", err)
+	}
+}
+
+func TestPurchaseOrder_ReceiveAndClose(t *testing.T) {
+	ctx := context.Background()
+	po := newTestPurchaseOrder()
+
+	if _, err := po.Receive(ctx, GoodsReceiptRequest{Items: []GoodsReceiptItemRequest{{ProductID: 9, Quantity: 1}}}, time.Now()); !IsValidationError(err) {
+		t.Errorf("Receive() of a product not on the order error = %v, want validation", err)
+	}
+
+	// The supplier cannot deliver the rest; close the order with this delivery
+	_, err := po.Receive(ctx, GoodsReceiptRequest{Items: []GoodsReceiptItemRequest{{ProductID: 1, Quantity: 20}}, Close: true}, time.Now())
+	if err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	if po.Status != PurchaseOrderStatusClosed {
+		t.Errorf("Status = %s, want closed", po.Status)
+	}
+	if err := po.Close(time.Now()); !IsConflictError(err) {
+		t.Errorf("Close() twice error = %v, want conflict", err)
+	}
+}
+
+func TestPurchaseOrder_Send(t *testing.T) {
+	po := newTestPurchaseOrder()
+	po.Status = PurchaseOrderStatusDraft
+
+	if _, err := po.Receive(context.Background(), GoodsReceiptRequest{Items: []GoodsReceiptItemRequest{{ProductID: 1, Quantity: 1}}}, time.Now()); !IsConflictError(err) {
+		t.Errorf("Receive() on a draft error = %v, want conflict", err)
+	}
+	if err := po.Send(time.Now()); err != nil || po.Status != PurchaseOrderStatusSent || po.SentAt == nil {
+		t.Fatalf("Send() = %v, order %+v", err, po)
+	}
+	if err := po.Send(time.Now()); !IsConflictError(err) {
+		t.Errorf("Send() twice error = %v, want conflict", err)
+	}
+}
+
+func TestGoodsReceipt_Movements(t *testing.T) {
+	receipt := GoodsReceipt{ID: 7, PurchaseOrderID: 4, OutletID: 2, Items: []GoodsReceiptItem{{ProductID: 1, Quantity: 30, UnitCost: 2500}}}
+	movements := receipt.Movements(context.Background())
+
+	if len(movements) != 1 {
+		t.Fatalf("Expected 1 movement, got %+v", movements)
+	}
+	m := movements[0]
+	if m.OutletID != 2 || m.Quantity != 30 || m.Type != StockMovementReceipt || m.ReferenceType != StockReferenceReceipt || *m.ReferenceID != 7 {
+		t.Errorf("Unexpected movement: %+v", m)
+	}
+}
+
+func TestPurchaseOrderRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     PurchaseOrderRequest
+		wantErr bool
+	}{
+		{name: "valid", req: PurchaseOrderRequest{SupplierID: 1, Items: []PurchaseOrderItemRequest{{ProductID: 1, Quantity: 10, UnitCost: 2500}}}, wantErr: false},
+		{name: "no supplier", req: PurchaseOrderRequest{Items: []PurchaseOrderItemRequest{{ProductID: 1, Quantity: 10}}}, wantErr: true},
+		{name: "no items", req: PurchaseOrderRequest{SupplierID: 1}, wantErr: true},
+		{name: "zero quantity", req: PurchaseOrderRequest{SupplierID: 1, Items: []PurchaseOrderItemRequest{{ProductID: 1}}}, wantErr: true},
+		{name: "negative cost", req: PurchaseOrderRequest{SupplierID: 1, Items: []PurchaseOrderItemRequest{{ProductID: 1, Quantity: 1, UnitCost: -1}}}, wantErr: true},
+		{name: "product twice", req: PurchaseOrderRequest{SupplierID: 1, Items: []PurchaseOrderItemRequest{{ProductID: 1, Quantity: 1}, {ProductID: 1, Quantity: 2}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
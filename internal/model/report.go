@@ -1,12 +1,57 @@
 package model
 
+// ReportSummary aggregates completed sales for a period. GrossSales is before
+// promotions, TotalDiscount is what promotions took off, and TotalRevenue is net of
+// both discounts and the customer returns made in the same period (TotalReturns).
+// A report for one outlet sets OutletID; a consolidated report leaves it nil and
+// breaks the figures down per outlet in Outlets.
 type ReportSummary struct {
-	TotalRevenue     int         `json:"total_revenue"`
-	TotalTransaction int         `json:"total_transaction"`
-	TopProduct       *TopProduct `json:"top_product"`
+	OutletID         *int          `json:"outlet_id,omitempty"`
+	GrossSales       int           `json:"gross_sales"`
+	TotalDiscount    int           `json:"total_discount"`
+	TotalTax         int           `json:"total_tax"` // PPN on completed sales net of returns, for monthly filing
+	TotalRevenue     int           `json:"total_revenue"`
+	TotalReturns     int           `json:"total_returns"`
+	TotalTransaction int           `json:"total_transaction"`
+	TopProduct       *TopProduct   `json:"top_product"`
+	Outlets          []OutletSales `json:"outlets,omitempty"`
+}
+
+// OutletSales is one outlet's share of a consolidated report
+type OutletSales struct {
+	OutletID         int    `json:"outlet_id"`
+	OutletCode       string `json:"outlet_code"`
+	GrossSales       int    `json:"gross_sales"`
+	TotalDiscount    int    `json:"total_discount"`
+	TotalTax         int    `json:"total_tax"`
+	TotalRevenue     int    `json:"total_revenue"`
+	TotalReturns     int    `json:"total_returns"`
+	TotalTransaction int    `json:"total_transaction"`
 }
 
 type TopProduct struct {
 	Name    string `json:"name"`
 	SoldQty int    `json:"sold_qty"`
 }
+
+// InventoryValuation values the stock on hand at each product's current cost. A
+// valuation for one outlet sets OutletID; a consolidated one leaves it nil and
+// sums the stock of every outlet. PendingSupplierCredit is what suppliers still
+// owe for goods returned to them, which has left the stock but not yet come back
+// as credit.
+type InventoryValuation struct {
+	OutletID              *int                     `json:"outlet_id,omitempty"`
+	TotalUnits            int                      `json:"total_units"`
+	TotalValue            int                      `json:"total_value"`
+	PendingSupplierCredit int                      `json:"pending_supplier_credit"`
+	Products              []InventoryValuationLine `json:"products"`
+}
+
+type InventoryValuationLine struct {
+	ProductID       int    `json:"product_id"`
+	ProductName     string `json:"product_name"`
+	ParentProductID *int   `json:"parent_product_id,omitempty"` // product a variant belongs to
+	Stock           int    `json:"stock"`
+	UnitCost        int    `json:"unit_cost"`
+	Value           int    `json:"value"`
+}
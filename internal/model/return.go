@@ -0,0 +1,162 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/validation"
+)
+
+// Return is a customer return document against a single transaction.
+// Amounts are negative because they reduce revenue.
+type Return struct {
+	ID            int          `json:"id"`
+	TransactionID int          `json:"transaction_id"`
+	TotalAmount   int          `json:"total_amount"`
+	TaxAmount     int          `json:"tax_amount"` // PPN included in TotalAmount
+	Reason        string       `json:"reason"`
+	PerformedBy   string       `json:"performed_by"`
+	Restock       bool         `json:"restock"`
+	CreatedAt     time.Time    `json:"created_at"`
+	Items         []ReturnItem `json:"items"`
+}
+
+type ReturnItem struct {
+	ID                  int    `json:"id"`
+	ReturnID            int    `json:"return_id"`
+	TransactionDetailID int    `json:"transaction_detail_id"`
+	ProductID           int    `json:"product_id"`
+	ProductName         string `json:"product_name,omitempty"`
+	Quantity            int    `json:"quantity"`
+	Amount              int    `json:"amount"`
+	TaxAmount           int    `json:"tax_amount"` // the line's PPN refunded within Amount
+}
+
+type ReturnRequestItem struct {
+	ProductID int `json:"product_id" validate:"min=1"`
+	Quantity  int `json:"quantity" validate:"min=1"`
+}
+
+type ReturnRequest struct {
+	Items   []ReturnRequestItem `json:"items" validate:"required,min=1,dive"`
+	Reason  string              `json:"reason" validate:"required,min=1,max=500"`
+	Restock bool                `json:"restock"`
+}
+
+func (r ReturnRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+// NewReturn returns the return of items from transactionID, performed by the actor of ctx
+func NewReturn(ctx context.Context, transactionID int, req ReturnRequest, items []ReturnItem) Return {
+	ret := Return{
+		TransactionID: transactionID,
+		Reason:        req.Reason,
+		PerformedBy:   actorName(ctx),
+		Restock:       req.Restock,
+		CreatedAt:     time.Now(),
+		Items:         items,
+	}
+	for _, item := range items {
+		ret.TotalAmount += item.Amount
+		ret.TaxAmount += item.TaxAmount
+	}
+	return ret
+}
+
+// ReturnableLine is a transaction line together with what has already been returned from it
+type ReturnableLine struct {
+	DetailID       int
+	ProductID      int
+	ProductName    string
+	Quantity       int
+	Amount         int
+	Tax            int
+	ReturnedQty    int
+	ReturnedAmount int // negative, as stored on return items
+	ReturnedTax    int // negative, as stored on return items
+}
+
+// AllocateReturn spreads the requested quantities over the transaction lines of each
+// product, in line order, and prices every returned unit, and its share of the line's
+// tax, from the line it came from.
+// It fails when a product was not sold in the transaction or when the requested
+// quantity exceeds what was sold minus earlier returns.
+func AllocateReturn(lines []ReturnableLine, items []ReturnRequestItem) ([]ReturnItem, error) {
+	requested := make(map[int]int)
+	order := make([]int, 0, len(items))
+	for _, item := range items {
+		if _, seen := requested[item.ProductID]; !seen {
+			order = append(order, item.ProductID)
+		}
+		requested[item.ProductID] += item.Quantity
+	}
+
+	result := make([]ReturnItem, 0, len(items))
+	for _, productID := range order {
+		remaining := requested[productID]
+
+		if !hasProduct(lines, productID) {
+			return nil, fmt.Errorf("%w: product id %d was not sold in this transaction", ErrValidation, productID)
+		}
+
+		available := 0
+		for _, line := range lines {
+			if line.ProductID == productID {
+				available += line.Quantity - line.ReturnedQty
+			}
+		}
+		if remaining > available {
+			return nil, fmt.Errorf("%w: cannot return %d of product id %d, only %d returnable", ErrValidation, remaining, productID, available)
+		}
+
+		for _, line := range lines {
+			if remaining == 0 {
+				break
+			}
+			if line.ProductID != productID || line.ReturnedQty >= line.Quantity {
+				continue
+			}
+
+			qty := min(remaining, line.Quantity-line.ReturnedQty)
+			result = append(result, ReturnItem{
+				TransactionDetailID: line.DetailID,
+				ProductID:           line.ProductID,
+				ProductName:         line.ProductName,
+				Quantity:            qty,
+				Amount:              -lineShare(line, qty, line.Amount, line.ReturnedAmount),
+				TaxAmount:           -lineShare(line, qty, line.Tax, line.ReturnedTax),
+			})
+			remaining -= qty
+		}
+	}
+
+	return result, nil
+}
+
+// lineShare prorates total, an amount of the whole line of which returned has already
+// been returned, over qty units. The last units returned from a line take whatever is
+// left so that rounding never refunds more or less than was paid in total.
+func lineShare(line ReturnableLine, qty, total, returned int) int {
+	if line.ReturnedQty+qty == line.Quantity {
+		return total + returned
+	}
+	return total * qty / line.Quantity
+}
+
+func hasProduct(lines []ReturnableLine, productID int) bool {
+	for _, line := range lines {
+		if line.ProductID == productID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestReturnRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ReturnRequest
+		wantErr bool
+	}{
+		{
+			name: "valid request",
+			req: ReturnRequest{
+				Items:  []ReturnRequestItem{{ProductID: 1, Quantity: 1}},
+				Reason: "damaged",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "no items",
+			req:     ReturnRequest{Reason: "damaged"},
+			wantErr: true,
+		},
+		{
+			name: "zero quantity",
+			req: ReturnRequest{
+				Items:  []ReturnRequestItem{{ProductID: 1, Quantity: 0}},
+				Reason: "damaged",
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing reason",
+			req: ReturnRequest{
+				Items: []ReturnRequestItem{{ProductID: 1, Quantity: 1}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReturnRequest.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAllocateReturn(t *testing.T) {
+	lines := []ReturnableLine{
+		{DetailID: 1, ProductID: 1, Quantity: 2, Amount: 7000},
+		{DetailID: 2, ProductID: 2, Quantity: 3, Amount: 10000, ReturnedQty: 1, ReturnedAmount: -3333},
+		{DetailID: 3, ProductID: 1, Quantity: 1, Amount: 3500},
+	}
+
+	items, err := AllocateReturn(lines, []ReturnRequestItem{{ProductID: 1, Quantity: 3}, {ProductID: 2, Quantity: 2}})
+	if err != nil {
+		t.Fatalf("AllocateReturn() error = %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("AllocateReturn() returned %d items, want 3", len(items))
+	}
+
+	// Product 1 is spread over both of its lines
+	if items[0].TransactionDetailID != 1 || items[0].Quantity != 2 || items[0].Amount != -7000 {
+		t.Errorf("items[0] = %+v", items[0])
+	}
+	if items[1].TransactionDetailID != 3 || items[1].Quantity != 1 || items[1].Amount != -3500 {
+		t.Errorf("items[1] = %+v", items[1])
+	}
+	// The final units of a line refund exactly what is left of it
+	if items[2].TransactionDetailID != 2 || items[2].Quantity != 2 || items[2].Amount != -6667 {
+		t.Errorf("items[2] = %+v", items[2])
+	}
+}
+
+func TestAllocateReturn_Tax(t *testing.T) {
+	lines := []ReturnableLine{{DetailID: 1, ProductID: 1, Quantity: 3, Amount: 11655, Tax: 1155}}
+
+	items, _ := AllocateReturn(lines, []ReturnRequestItem{{ProductID: 1, Quantity: 1}})
+	if items[0].Amount != -3885 || items[0].TaxAmount != -385 {
+		t.Errorf("items[0] = %+v, want -3885 with -385 tax", items[0])
+	}
+
+	lines[0].ReturnedQty, lines[0].ReturnedAmount, lines[0].ReturnedTax = 1, -3885, -386
+	items, _ = AllocateReturn(lines, []ReturnRequestItem{{ProductID: 1, Quantity: 2}})
+	if items[0].TaxAmount != -769 {
+		t.Errorf("TaxAmount = %d, want what is left of the line's tax, -769", items[0].TaxAmount)
+	}
+}
+
+func TestAllocateReturn_Errors(t *testing.T) {
+	lines := []ReturnableLine{
+		{DetailID: 1, ProductID: 1, Quantity: 2, Amount: 7000, ReturnedQty: 1, ReturnedAmount: -3500},
+	}
+
+	if _, err := AllocateReturn(lines, []ReturnRequestItem{{ProductID: 1, Quantity: 2}}); !IsValidationError(err) {
+		t.Errorf("AllocateReturn() over-return error = %v, want validation error", err)
+	}
+	if _, err := AllocateReturn(lines, []ReturnRequestItem{{ProductID: 9, Quantity: 1}}); !IsValidationError(err) {
+		t.Errorf("AllocateReturn() unknown product error = %v, want validation error", err)
+	}
+}
@@ -0,0 +1,181 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/validation"
+)
+
+// Role decides what a user may do; see RolePermissions
+type Role string
+
+const (
+	RoleOwner      Role = "owner"
+	RoleManager    Role = "manager"
+	RoleCashier    Role = "cashier"
+	RoleStockClerk Role = "stock_clerk"
+)
+
+// Roles lists every role, most privileged first
+var Roles = []Role{RoleOwner, RoleManager, RoleCashier, RoleStockClerk}
+
+// DefaultRole is given to new users until an owner assigns another
+const DefaultRole = RoleCashier
+
+// IsValid reports whether the role is one of Roles
+func (r Role) IsValid() bool {
+	return slices.Contains(Roles, r)
+}
+
+// Permission is one kind of action guarded by the routes
+type Permission string
+
+const (
+	PermissionProductsRead     Permission = "products:read"
+	PermissionProductsWrite    Permission = "products:write" // create and update, including stock
+	PermissionProductsDelete   Permission = "products:delete"
+	PermissionCategoriesRead   Permission = "categories:read"
+	PermissionCategoriesWrite  Permission = "categories:write"
+	PermissionPromotionsRead   Permission = "promotions:read"
+	PermissionPromotionsWrite  Permission = "promotions:write"
+	PermissionTransactionsRead Permission = "transactions:read" // including receipts
+	PermissionCheckout         Permission = "transactions:checkout"
+	PermissionTransactionsVoid Permission = "transactions:void" // voids and refunds
+	PermissionReturnsRead      Permission = "returns:read"
+	PermissionReturnsWrite     Permission = "returns:write"
+	PermissionCartsUse         Permission = "carts:use"
+	PermissionShiftsRead       Permission = "shifts:read"
+	PermissionShiftsWrite      Permission = "shifts:write"      // open, cash movements and close
+	PermissionStocktakesCount  Permission = "stocktakes:count"  // view stocktakes and submit counts
+	PermissionStocktakesManage Permission = "stocktakes:manage" // open, approve and cancel
+	PermissionSuppliersRead    Permission = "suppliers:read"
+	PermissionSuppliersWrite   Permission = "suppliers:write"
+	PermissionPurchasesRead    Permission = "purchases:read"
+	PermissionPurchasesWrite   Permission = "purchases:write"   // create, edit, send and close purchase orders; book supplier credit
+	PermissionPurchasesReceive Permission = "purchases:receive" // book goods receipts and purchase returns
+	PermissionCustomersRead    Permission = "customers:read"
+	PermissionCustomersWrite   Permission = "customers:write"
+	PermissionCustomersErase   Permission = "customers:erase"
+	PermissionOutletsRead      Permission = "outlets:read"
+	PermissionOutletsWrite     Permission = "outlets:write"
+	PermissionReportsRead      Permission = "reports:read"
+	PermissionUsersRead        Permission = "users:read"
+	PermissionUsersWrite       Permission = "users:write"
+	PermissionRolesManage      Permission = "roles:manage"
+	PermissionDevicesManage    Permission = "devices:manage" // pairing, listing and revoking devices
+	PermissionAuditRead        Permission = "audit:read"
+)
+
+// RolePermissions is the permission matrix. Owners may do everything; the other
+// roles only what is listed.
+var RolePermissions = map[Role][]Permission{
+	RoleManager: {
+		PermissionProductsRead, PermissionProductsWrite, PermissionProductsDelete,
+		PermissionCategoriesRead, PermissionCategoriesWrite,
+		PermissionPromotionsRead, PermissionPromotionsWrite,
+		PermissionTransactionsRead, PermissionCheckout, PermissionTransactionsVoid,
+		PermissionReturnsRead, PermissionReturnsWrite,
+		PermissionCartsUse,
+		PermissionShiftsRead, PermissionShiftsWrite,
+		PermissionStocktakesCount, PermissionStocktakesManage,
+		PermissionSuppliersRead, PermissionSuppliersWrite,
+		PermissionPurchasesRead, PermissionPurchasesWrite, PermissionPurchasesReceive,
+		PermissionCustomersRead, PermissionCustomersWrite, PermissionCustomersErase,
+		PermissionOutletsRead,
+		PermissionReportsRead,
+		PermissionUsersRead,
+		PermissionDevicesManage,
+		PermissionAuditRead,
+	},
+	RoleCashier: {
+		PermissionProductsRead,
+		PermissionCategoriesRead,
+		PermissionPromotionsRead,
+		PermissionTransactionsRead, PermissionCheckout,
+		PermissionReturnsRead,
+		PermissionCartsUse,
+		PermissionShiftsRead, PermissionShiftsWrite,
+		PermissionStocktakesCount,
+		PermissionCustomersRead, PermissionCustomersWrite,
+		PermissionOutletsRead,
+	},
+	RoleStockClerk: {
+		PermissionProductsRead, PermissionProductsWrite,
+		PermissionCategoriesRead, PermissionCategoriesWrite,
+		PermissionStocktakesCount,
+		PermissionSuppliersRead,
+		PermissionPurchasesRead, PermissionPurchasesReceive,
+		PermissionOutletsRead,
+	},
+}
+
+// AllPermissions lists every permission, which is what owners hold
+var AllPermissions = []Permission{
+	PermissionProductsRead, PermissionProductsWrite, PermissionProductsDelete,
+	PermissionCategoriesRead, PermissionCategoriesWrite,
+	PermissionPromotionsRead, PermissionPromotionsWrite,
+	PermissionTransactionsRead, PermissionCheckout, PermissionTransactionsVoid,
+	PermissionReturnsRead, PermissionReturnsWrite,
+	PermissionCartsUse,
+	PermissionShiftsRead, PermissionShiftsWrite,
+	PermissionStocktakesCount, PermissionStocktakesManage,
+	PermissionSuppliersRead, PermissionSuppliersWrite,
+	PermissionPurchasesRead, PermissionPurchasesWrite, PermissionPurchasesReceive,
+	PermissionCustomersRead, PermissionCustomersWrite, PermissionCustomersErase,
+	PermissionOutletsRead, PermissionOutletsWrite,
+	PermissionReportsRead,
+	PermissionUsersRead, PermissionUsersWrite,
+	PermissionRolesManage,
+	PermissionDevicesManage,
+	PermissionAuditRead,
+}
+
+// Permissions returns what the role may do
+func (r Role) Permissions() []Permission {
+	if r == RoleOwner {
+		return AllPermissions
+	}
+	return RolePermissions[r]
+}
+
+// Can reports whether the role holds the permission
+func (r Role) Can(p Permission) bool {
+	return slices.Contains(r.Permissions(), p)
+}
+
+// RoleInfo describes a role and its permissions for the API
+type RoleInfo struct {
+	Role        Role         `json:"role"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// RoleRequest assigns a role to a user
+type RoleRequest struct {
+	Role Role `json:"role" validate:"required"`
+}
+
+func (r RoleRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	if !r.Role.IsValid() {
+		return errorsPkg.ValidationError(fmt.Sprintf("role must be one of %v", Roles))
+	}
+
+	return nil
+}
+
+// PermissionDeniedError reports an action the caller's role does not allow
+func PermissionDeniedError(role Role, p Permission) error {
+	return fmt.Errorf("%w: role %s does not have permission %s", ErrForbidden, role, p)
+}
+
+// LastOwnerError reports a change that would leave no active owner to manage roles
+func LastOwnerError() error {
+	return fmt.Errorf("%w: the last active owner cannot be demoted or deactivated", ErrConflict)
+}
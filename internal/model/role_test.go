@@ -0,0 +1,48 @@
+package model
+
+import "testing"
+
+func TestRole_Can(t *testing.T) {
+	tests := []struct {
+		role Role
+		p    Permission
+		want bool
+	}{
+		{RoleOwner, PermissionRolesManage, true},
+		{RoleManager, PermissionReportsRead, true},
+		{RoleManager, PermissionRolesManage, false},
+		{RoleManager, PermissionUsersWrite, false},
+		{RoleCashier, PermissionCheckout, true},
+		{RoleCashier, PermissionProductsDelete, false},
+		{RoleCashier, PermissionReportsRead, false},
+		{RoleCashier, PermissionTransactionsVoid, false},
+		{RoleStockClerk, PermissionProductsWrite, true},
+		{RoleStockClerk, PermissionCheckout, false},
+		{Role("guest"), PermissionProductsRead, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.Can(tt.p); got != tt.want {
+			t.Errorf("%s.Can(%s) = %v, want %v", tt.role, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestRolePermissions_SubsetOfAll(t *testing.T) {
+	for role, permissions := range RolePermissions {
+		for _, p := range permissions {
+			if !RoleOwner.Can(p) {
+				t.Errorf("%s holds %s, which is missing from AllPermissions", role, p)
+			}
+		}
+	}
+}
+
+func TestRoleRequest_Validate(t *testing.T) {
+	if err := (RoleRequest{Role: RoleStockClerk}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (RoleRequest{Role: "admin"}).Validate(); !IsValidationError(err) {
+		t.Errorf("Validate() with an unknown role error = %v, want validation", err)
+	}
+}
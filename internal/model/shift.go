@@ -0,0 +1,212 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/validation"
+)
+
+type ShiftStatus string
+
+const (
+	ShiftStatusOpen   ShiftStatus = "open"
+	ShiftStatusClosed ShiftStatus = "closed"
+)
+
+// Shift is a cashier's session at an outlet's till. Checkouts are tied to the
+// outlet's open shift, and closing it compares the counted cash with what the
+// drawer should hold.
+type Shift struct {
+	ID           int            `json:"id"`
+	OutletID     int            `json:"outlet_id"`
+	Status       ShiftStatus    `json:"status"`
+	OpenedBy     string         `json:"opened_by"`
+	OpeningFloat int            `json:"opening_float"`
+	OpenedAt     time.Time      `json:"opened_at"`
+	ClosedBy     string         `json:"closed_by,omitempty"`
+	ClosedAt     *time.Time     `json:"closed_at,omitempty"`
+	CountedCash  *int           `json:"counted_cash,omitempty"`
+	ExpectedCash *int           `json:"expected_cash,omitempty"` // fixed when the shift is closed
+	Difference   *int           `json:"difference,omitempty"`    // counted minus expected: positive is an overage, negative a shortage
+	Note         string         `json:"note,omitempty"`
+	Movements    []CashMovement `json:"cash_movements"`
+	Summary      *ShiftSummary  `json:"summary,omitempty"`
+}
+
+type CashMovementType string
+
+const (
+	// CashMovementIn is cash put into the drawer, e.g. extra change
+	CashMovementIn CashMovementType = "in"
+	// CashMovementOut is cash taken out of the drawer, e.g. petty cash or a safe drop
+	CashMovementOut CashMovementType = "out"
+)
+
+type CashMovement struct {
+	ID          int              `json:"id"`
+	ShiftID     int              `json:"shift_id"`
+	Type        CashMovementType `json:"type"`
+	Amount      int              `json:"amount"`
+	Reason      string           `json:"reason"`
+	PerformedBy string           `json:"performed_by"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// PaymentTotal is the amount taken with one payment method
+type PaymentTotal struct {
+	Method PaymentMethod `json:"method"`
+	Amount int           `json:"amount"`
+}
+
+// ShiftSummary adds up the completed sales of a shift and its cash movements.
+// Cash sales are net of change given and cash refunds are taken out, so
+// ExpectedCash is what the drawer should hold.
+type ShiftSummary struct {
+	TransactionCount int            `json:"transaction_count"`
+	TotalSales       int            `json:"total_sales"`
+	Payments         []PaymentTotal `json:"payments"`
+	CashSales        int            `json:"cash_sales"`
+	CashRefunds      int            `json:"cash_refunds"` // handed back for returns, and for voids and refunds of other shifts' sales
+	CashIn           int            `json:"cash_in"`
+	CashOut          int            `json:"cash_out"`
+	ExpectedCash     int            `json:"expected_cash"`
+}
+
+// NewShiftSummary builds the summary from per-method payment totals (cash already
+// net of change), the cash refunded during the shift and its cash movements
+func NewShiftSummary(openingFloat, transactionCount, totalSales int, payments map[PaymentMethod]int, cashRefunds int, movements []CashMovement) *ShiftSummary {
+	s := &ShiftSummary{
+		TransactionCount: transactionCount,
+		TotalSales:       totalSales,
+		Payments:         make([]PaymentTotal, 0, len(payments)),
+		CashSales:        payments[PaymentMethodCash],
+		CashRefunds:      cashRefunds,
+	}
+
+	// Report methods in a fixed order
+	for _, method := range []PaymentMethod{PaymentMethodCash, PaymentMethodDebit, PaymentMethodCredit,
+		PaymentMethodQRIS, PaymentMethodEWallet, PaymentMethodTransfer} {
+		if amount, ok := payments[method]; ok {
+			s.Payments = append(s.Payments, PaymentTotal{Method: method, Amount: amount})
+		}
+	}
+
+	for _, m := range movements {
+		if m.Type == CashMovementIn {
+			s.CashIn += m.Amount
+		} else {
+			s.CashOut += m.Amount
+		}
+	}
+
+	s.ExpectedCash = openingFloat + s.CashSales - s.CashRefunds + s.CashIn - s.CashOut
+	return s
+}
+
+// CashRefund is the cash handed back for refunding amount of a sale that kept
+// cashKept in the drawer, once earlier refunds of it came to refunded. Refunds
+// are paid in cash first, up to what the sale kept; the rest goes back the way
+// it was paid.
+func CashRefund(cashKept, refunded, amount int) int {
+	return max(min(cashKept, refunded+amount)-min(cashKept, refunded), 0)
+}
+
+// Covers reports whether at falls while the shift was open
+func (s Shift) Covers(at time.Time) bool {
+	return !at.Before(s.OpenedAt) && (s.ClosedAt == nil || at.Before(*s.ClosedAt))
+}
+
+type OpenShiftRequest struct {
+	OpeningFloat int `json:"opening_float" validate:"min=0"`
+}
+
+func (r OpenShiftRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+type CashMovementRequest struct {
+	Type   CashMovementType `json:"type" validate:"required,oneof=in out"`
+	Amount int              `json:"amount" validate:"min=1"`
+	Reason string           `json:"reason" validate:"required,min=1,max=500"`
+}
+
+func (r CashMovementRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+type CloseShiftRequest struct {
+	CountedCash *int   `json:"counted_cash" validate:"required,min=0"`
+	Note        string `json:"note" validate:"max=500"`
+}
+
+func (r CloseShiftRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+// NewShift returns an open shift at outletID, opened by the actor of ctx
+func NewShift(ctx context.Context, outletID int, req OpenShiftRequest) Shift {
+	return Shift{
+		OutletID:     outletID,
+		Status:       ShiftStatusOpen,
+		OpenedBy:     actorName(ctx),
+		OpeningFloat: req.OpeningFloat,
+		OpenedAt:     time.Now(),
+		Movements:    []CashMovement{},
+	}
+}
+
+// NewCashMovement returns the movement of req in shiftID, performed by the actor of ctx
+func NewCashMovement(ctx context.Context, shiftID int, req CashMovementRequest) CashMovement {
+	return CashMovement{
+		ShiftID:     shiftID,
+		Type:        req.Type,
+		Amount:      req.Amount,
+		Reason:      req.Reason,
+		PerformedBy: actorName(ctx),
+		CreatedAt:   time.Now(),
+	}
+}
+
+// Close marks the shift closed by the actor of ctx with the cash counted in req
+// against the cash expected
+func (s *Shift) Close(ctx context.Context, req CloseShiftRequest, expected int, at time.Time) {
+	counted := *req.CountedCash
+	difference := counted - expected
+
+	s.Status = ShiftStatusClosed
+	s.ClosedBy = actorName(ctx)
+	s.ClosedAt = &at
+	s.CountedCash = &counted
+	s.ExpectedCash = &expected
+	s.Difference = &difference
+	s.Note = req.Note
+}
+
+// ShiftClosedError reports an operation on a shift that is no longer open
+func ShiftClosedError(id int) error {
+	return fmt.Errorf("%w: shift %d is already closed", ErrConflict, id)
+}
+
+// ErrNoOpenShift is returned by checkout when the outlet has no open shift
+var ErrNoOpenShift = fmt.Errorf("%w: no shift is open, open a shift before checkout", ErrConflict)
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/pkg/middleware"
+)
+
+func TestNewShiftSummary(t *testing.T) {
+	payments := map[PaymentMethod]int{
+		PaymentMethodCash: 40000,
+		PaymentMethodQRIS: 25000,
+	}
+	movements := []CashMovement{
+		{Type: CashMovementIn, Amount: 50000},
+		{Type: CashMovementOut, Amount: 20000},
+		{Type: CashMovementOut, Amount: 5000},
+	}
+
+	s := NewShiftSummary(100000, 3, 65000, payments, 8000, movements)
+
+	if s.CashSales != 40000 || s.CashRefunds != 8000 || s.CashIn != 50000 || s.CashOut != 25000 {
+		t.Errorf("NewShiftSummary() = %+v, want cash 40000, refunds 8000, in 50000, out 25000", s)
+	}
+	if s.ExpectedCash != 157000 {
+		t.Errorf("ExpectedCash = %d, want 157000", s.ExpectedCash)
+	}
+	if len(s.Payments) != 2 {
+		t.Errorf("Payments = %+v, want 2 methods", s.Payments)
+	}
+}
+
+func TestCashRefund(t *testing.T) {
+	tests := []struct {
+		name     string
+		cashKept int
+		refunded int
+		amount   int
+		want     int
+	}{
+		{"all cash", 10000, 0, 4000, 4000},
+		{"no cash", 0, 0, 4000, 0},
+		{"cash runs out", 3000, 0, 4000, 3000},
+		{"cash used up earlier", 3000, 3000, 4000, 0},
+		{"rest of the cash", 10000, 7000, 5000, 3000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CashRefund(tt.cashKept, tt.refunded, tt.amount); got != tt.want {
+				t.Errorf("CashRefund() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCloseShiftRequest_Validate(t *testing.T) {
+	zero, negative := 0, -1
+
+	tests := []struct {
+		name    string
+		req     CloseShiftRequest
+		wantErr bool
+	}{
+		{name: "valid request", req: CloseShiftRequest{CountedCash: &zero}, wantErr: false},
+		{name: "missing counted cash", req: CloseShiftRequest{}, wantErr: true},
+		{name: "negative counted cash", req: CloseShiftRequest{CountedCash: &negative}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShift_Close(t *testing.T) {
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{Username: "siti"})
+	counted := 95000
+
+	s := NewShift(ctx, 1, OpenShiftRequest{OpeningFloat: 100000})
+	s.Close(ctx, CloseShiftRequest{CountedCash: &counted, Note: "short"}, 100000, time.Now())
+
+	if s.OpenedBy != "siti" || s.ClosedBy != "siti" || s.Status != ShiftStatusClosed {
+		t.Errorf("Unexpected shift: %+v", s)
+	}
+	if s.Difference == nil || *s.Difference != -5000 {
+		t.Errorf("Difference = %v, want -5000", s.Difference)
+	}
+}
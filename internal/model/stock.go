@@ -0,0 +1,263 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/middleware"
+	"kasir-api/pkg/validation"
+)
+
+// StockMovementType is why a product's stock at an outlet changed
+type StockMovementType string
+
+const (
+	StockMovementSale           StockMovementType = "sale"
+	StockMovementReturn         StockMovementType = "return"          // goods back from a customer, including voids and refunds
+	StockMovementAdjustment     StockMovementType = "adjustment"      // stock corrected by hand
+	StockMovementReceipt        StockMovementType = "receipt"         // goods received into the outlet
+	StockMovementTransfer       StockMovementType = "transfer"        // goods moved between outlets, posted at both
+	StockMovementWriteOff       StockMovementType = "write_off"       // damaged, expired or lost goods
+	StockMovementPurchaseReturn StockMovementType = "purchase_return" // goods sent back to a supplier
+)
+
+// StockMovementTypes lists every movement type
+var StockMovementTypes = []StockMovementType{
+	StockMovementSale, StockMovementReturn, StockMovementAdjustment,
+	StockMovementReceipt, StockMovementTransfer, StockMovementWriteOff,
+	StockMovementPurchaseReturn,
+}
+
+// StockReason is the reason code an adjustment or write-off is booked under
+type StockReason string
+
+const (
+	StockReasonDamaged       StockReason = "damaged"
+	StockReasonExpired       StockReason = "expired"
+	StockReasonLost          StockReason = "lost"           // theft, shrinkage or an unexplained loss
+	StockReasonFound         StockReason = "found"          // units on hand that were not on record
+	StockReasonCountingError StockReason = "counting_error" // an earlier count or entry was wrong
+	StockReasonOther         StockReason = "other"
+)
+
+// StockReasons lists every reason code
+var StockReasons = []StockReason{
+	StockReasonDamaged, StockReasonExpired, StockReasonLost,
+	StockReasonFound, StockReasonCountingError, StockReasonOther,
+}
+
+// IsValid reports whether the reason is one of StockReasons
+func (r StockReason) IsValid() bool {
+	return slices.Contains(StockReasons, r)
+}
+
+// StockReferenceType names the kind of document a stock movement was posted for
+type StockReferenceType string
+
+const (
+	StockReferenceTransaction    StockReferenceType = "transaction"
+	StockReferenceReturn         StockReferenceType = "return"
+	StockReferenceOutlet         StockReferenceType = "outlet" // the other outlet of a transfer
+	StockReferenceStocktake      StockReferenceType = "stocktake"
+	StockReferenceReceipt        StockReferenceType = "receipt" // a goods receipt against a purchase order
+	StockReferencePurchaseReturn StockReferenceType = "purchase_return"
+)
+
+// StockMovement is one posting to the stock ledger. The stock of a product at an
+// outlet is the sum of its movements, and only changes by posting one; Balance is
+// that sum after the movement.
+type StockMovement struct {
+	ID            int                `json:"id"`
+	ProductID     int                `json:"product_id"`
+	OutletID      int                `json:"outlet_id"`
+	Type          StockMovementType  `json:"type"`
+	Quantity      int                `json:"quantity"` // positive into the outlet, negative out of it
+	Balance       int                `json:"balance"`
+	ReferenceType StockReferenceType `json:"reference_type,omitempty"`
+	ReferenceID   *int               `json:"reference_id,omitempty"`
+	Reason        StockReason        `json:"reason,omitempty"`
+	Note          string             `json:"note,omitempty"`
+	Actor         string             `json:"actor,omitempty"` // username or device name; empty for the system
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
+// NewStockMovement returns a movement of quantity posted by the actor of ctx
+func NewStockMovement(ctx context.Context, outletID, productID int, movementType StockMovementType, quantity int) StockMovement {
+	return StockMovement{
+		ProductID: productID,
+		OutletID:  outletID,
+		Type:      movementType,
+		Quantity:  quantity,
+		Actor:     actorName(ctx),
+	}
+}
+
+// actorName returns the username or device name of the principal of ctx, or ""
+func actorName(ctx context.Context) string {
+	if principal, ok := middleware.PrincipalFromContext(ctx); ok {
+		return principal.Username
+	}
+	return ""
+}
+
+// For returns the movement referencing the document it was posted for
+func (m StockMovement) For(referenceType StockReferenceType, referenceID int) StockMovement {
+	m.ReferenceType = referenceType
+	m.ReferenceID = &referenceID
+	return m
+}
+
+// StockMovementRequest posts stock by hand. Sales, returns and receipts are posted
+// by their own documents. Quantity is the signed change for an adjustment and the
+// number of units for a write-off or transfer. Adjustments and write-offs need a
+// reason code.
+type StockMovementRequest struct {
+	Type       StockMovementType `json:"type" validate:"required,oneof=adjustment write_off transfer"`
+	Quantity   int               `json:"quantity" validate:"required"`
+	ToOutletID int               `json:"to_outlet_id,omitempty"` // transfers only
+	Reason     StockReason       `json:"reason,omitempty"`
+	Note       string            `json:"note" validate:"max=500"`
+}
+
+func (r StockMovementRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+	if r.Type != StockMovementAdjustment && r.Quantity < 0 {
+		return errorsPkg.ValidationError(fmt.Sprintf("quantity of a %s must be positive", r.Type))
+	}
+	if r.Type == StockMovementTransfer && r.ToOutletID <= 0 {
+		return errorsPkg.ValidationError("to_outlet_id is required for a transfer")
+	}
+	if r.Type != StockMovementTransfer && r.ToOutletID != 0 {
+		return errorsPkg.ValidationError("to_outlet_id is only for transfers")
+	}
+	if r.Type != StockMovementTransfer && r.Reason == "" {
+		return errorsPkg.ValidationError(fmt.Sprintf("reason is required for a %s", r.Type))
+	}
+	if r.Reason != "" && !r.Reason.IsValid() {
+		return errorsPkg.ValidationError(fmt.Sprintf("unknown reason %q", r.Reason))
+	}
+
+	return nil
+}
+
+// Movements returns what the request posts for the product at the outlet of ctx:
+// one movement, or for a transfer one out of this outlet and one into the other
+func (r StockMovementRequest) Movements(ctx context.Context, productID int) []StockMovement {
+	outletID := OutletID(ctx)
+	switch r.Type {
+	case StockMovementWriteOff:
+		m := NewStockMovement(ctx, outletID, productID, r.Type, -r.Quantity)
+		m.Reason, m.Note = r.Reason, r.Note
+		return []StockMovement{m}
+	case StockMovementTransfer:
+		out := NewStockMovement(ctx, outletID, productID, r.Type, -r.Quantity).For(StockReferenceOutlet, r.ToOutletID)
+		in := NewStockMovement(ctx, r.ToOutletID, productID, r.Type, r.Quantity).For(StockReferenceOutlet, outletID)
+		out.Reason, in.Reason = r.Reason, r.Reason
+		out.Note, in.Note = r.Note, r.Note
+		return []StockMovement{out, in}
+	default:
+		m := NewStockMovement(ctx, outletID, productID, r.Type, r.Quantity)
+		m.Reason, m.Note = r.Reason, r.Note
+		return []StockMovement{m}
+	}
+}
+
+// InsufficientStockError reports a movement that would take the stock below zero
+func InsufficientStockError(productID, outletID, available, quantity int) error {
+	return fmt.Errorf("%w: insufficient stock for product %d at outlet %d (available: %d, requested: %d)",
+		ErrValidation, productID, outletID, available, -quantity)
+}
+
+// TransferToSameOutletError reports a transfer whose destination is its source
+func TransferToSameOutletError(outletID int) error {
+	return fmt.Errorf("%w: cannot transfer stock from outlet %d to itself", ErrValidation, outletID)
+}
+
+// Page sizes for listing stock movements
+const (
+	DefaultStockMovementPageSize = 50
+	MaxStockMovementPageSize     = 200
+)
+
+// StockMovementFilter selects stock movements. A zero ProductID or OutletID
+// matches every product or outlet.
+type StockMovementFilter struct {
+	ProductID int    `json:"product_id"`
+	OutletID  int    `json:"outlet_id"`
+	Type      string `json:"type,omitempty"`
+	Page      int    `json:"page"`
+	Limit     int    `json:"limit"`
+}
+
+// WithDefaults returns a copy of the filter with page and limit defaults applied
+func (f StockMovementFilter) WithDefaults() StockMovementFilter {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Limit <= 0 {
+		f.Limit = DefaultStockMovementPageSize
+	}
+	if f.Limit > MaxStockMovementPageSize {
+		f.Limit = MaxStockMovementPageSize
+	}
+	return f
+}
+
+func (f StockMovementFilter) Validate() error {
+	if f.Type != "" && !slices.Contains(StockMovementTypes, StockMovementType(f.Type)) {
+		return errorsPkg.ValidationError(fmt.Sprintf("unknown stock movement type %q", f.Type))
+	}
+	if f.OutletID < 0 {
+		return errorsPkg.ValidationError("outlet_id must be positive")
+	}
+	return nil
+}
+
+// Offset returns the number of rows to skip for the current page
+func (f StockMovementFilter) Offset() int {
+	if f.Page <= 1 {
+		return 0
+	}
+	return (f.Page - 1) * f.Limit
+}
+
+// Matches reports whether the movement passes the filter
+func (f StockMovementFilter) Matches(m StockMovement) bool {
+	if f.ProductID != 0 && m.ProductID != f.ProductID {
+		return false
+	}
+	if f.OutletID != 0 && m.OutletID != f.OutletID {
+		return false
+	}
+	if f.Type != "" && string(m.Type) != f.Type {
+		return false
+	}
+	return true
+}
+
+// StockDiscrepancy is a place where the stock does not agree with its ledger:
+// either the stock of a product at an outlet differs from the sum of its
+// movements, or a movement's balance differs from the running sum up to it
+type StockDiscrepancy struct {
+	OutletID   int `json:"outlet_id"`
+	ProductID  int `json:"product_id"`
+	MovementID int `json:"movement_id,omitempty"` // set for a wrong balance
+	Recorded   int `json:"recorded"`
+	Computed   int `json:"computed"` // recomputed from the ledger
+}
+
+func (d StockDiscrepancy) String() string {
+	if d.MovementID != 0 {
+		return fmt.Sprintf("outlet %d product %d: movement %d has balance %d, ledger sums to %d",
+			d.OutletID, d.ProductID, d.MovementID, d.Recorded, d.Computed)
+	}
+	return fmt.Sprintf("outlet %d product %d: stock is %d, ledger sums to %d",
+		d.OutletID, d.ProductID, d.Recorded, d.Computed)
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+)
+
+// StockAlert records a sale that took a product's stock at an outlet to or below
+// its reorder point
+type StockAlert struct {
+	ID            int       `json:"id"`
+	OutletID      int       `json:"outlet_id"`
+	ProductID     int       `json:"product_id"`
+	ProductName   string    `json:"product_name"`
+	Stock         int       `json:"stock"` // stock left after the sale
+	ReorderPoint  int       `json:"reorder_point"`
+	ReorderQty    int       `json:"reorder_qty"`
+	TransactionID *int      `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// NewStockAlert returns the alert for a product that a transaction took down to
+// stock at the outlet, without an ID yet
+func NewStockAlert(p Product, outletID, stock, transactionID int) StockAlert {
+	return StockAlert{
+		OutletID:      outletID,
+		ProductID:     p.ID,
+		ProductName:   p.Name,
+		Stock:         stock,
+		ReorderPoint:  p.ReorderPoint,
+		ReorderQty:    p.ReorderQty,
+		TransactionID: &transactionID,
+	}
+}
+
+// LowStockItem is a product whose stock at an outlet is at or below its reorder point
+type LowStockItem struct {
+	ProductID    int    `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	Stock        int    `json:"stock"`
+	ReorderPoint int    `json:"reorder_point"`
+	ReorderQty   int    `json:"reorder_qty"`
+}
+
+// NewLowStockItem returns the product as a low stock item, with Stock as read for the outlet
+func NewLowStockItem(p Product) LowStockItem {
+	return LowStockItem{
+		ProductID:    p.ID,
+		ProductName:  p.Name,
+		Stock:        p.Stock,
+		ReorderPoint: p.ReorderPoint,
+		ReorderQty:   p.ReorderQty,
+	}
+}
+
+// Page size for listing stock alerts
+const (
+	DefaultStockAlertLimit = 50
+	MaxStockAlertLimit     = 200
+)
+
+// StockAlertFilter selects the newest alerts of the outlet of the context. A zero
+// ProductID matches every product.
+type StockAlertFilter struct {
+	ProductID int `json:"product_id"`
+	Limit     int `json:"limit"`
+}
+
+// WithDefaults returns a copy of the filter with the limit default applied
+func (f StockAlertFilter) WithDefaults() StockAlertFilter {
+	if f.Limit <= 0 {
+		f.Limit = DefaultStockAlertLimit
+	}
+	if f.Limit > MaxStockAlertLimit {
+		f.Limit = MaxStockAlertLimit
+	}
+	return f
+}
+
+func (f StockAlertFilter) Validate() error {
+	if f.ProductID < 0 {
+		return errorsPkg.ValidationError("product_id must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/pkg/middleware"
+)
+
+func TestStockMovementRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     StockMovementRequest
+		wantErr bool
+	}{
+		{name: "adjustment up", req: StockMovementRequest{Type: StockMovementAdjustment, Quantity: 5, Reason: StockReasonFound}, wantErr: false},
+		{name: "adjustment down", req: StockMovementRequest{Type: StockMovementAdjustment, Quantity: -5, Reason: StockReasonLost}, wantErr: false},
+		{name: "write-off", req: StockMovementRequest{Type: StockMovementWriteOff, Quantity: 2, Reason: StockReasonExpired}, wantErr: false},
+		{name: "transfer", req: StockMovementRequest{Type: StockMovementTransfer, Quantity: 2, ToOutletID: 2}, wantErr: false},
+		{name: "zero quantity", req: StockMovementRequest{Type: StockMovementAdjustment}, wantErr: true},
+		{name: "sale", req: StockMovementRequest{Type: StockMovementSale, Quantity: -1}, wantErr: true},
+		{name: "negative write-off", req: StockMovementRequest{Type: StockMovementWriteOff, Quantity: -2}, wantErr: true},
+		{name: "transfer without outlet", req: StockMovementRequest{Type: StockMovementTransfer, Quantity: 2}, wantErr: true},
+		{name: "outlet on an adjustment", req: StockMovementRequest{Type: StockMovementAdjustment, Quantity: 2, ToOutletID: 2, Reason: StockReasonFound}, wantErr: true},
+		{name: "adjustment without reason", req: StockMovementRequest{Type: StockMovementAdjustment, Quantity: 2}, wantErr: true},
+		{name: "write-off without reason", req: StockMovementRequest{Type: StockMovementWriteOff, Quantity: 2}, wantErr: true},
+		{name: "unknown reason", req: StockMovementRequest{Type: StockMovementWriteOff, Quantity: 2, Reason: "theft"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStockMovementRequest_Movements(t *testing.T) {
+	ctx := middleware.WithOutletID(context.Background(), 1)
+	ctx = middleware.WithPrincipal(ctx, &middleware.Principal{UserID: 2, Username: "rina"})
+
+	req := StockMovementRequest{Type: StockMovementTransfer, Quantity: 4, ToOutletID: 3, Note: "restock branch"}
+	movements := req.Movements(ctx, 7)
+	if len(movements) != 2 {
+		t.Fatalf("Movements() = %+v, want 2", movements)
+	}
+
+	out, in := movements[0], movements[1]
+	if out.OutletID != 1 || out.Quantity != -4 || out.ReferenceType != StockReferenceOutlet || *out.ReferenceID != 3 {
+		t.Errorf("Unexpected movement out: %+v", out)
+	}
+	if in.OutletID != 3 || in.Quantity != 4 || *in.ReferenceID != 1 || in.ProductID != 7 {
+		t.Errorf("Unexpected movement in: %+v", in)
+	}
+	if out.Actor != "rina" || in.Note != "restock branch" {
+		t.Errorf("Expected actor and note on both movements, got %+v", movements)
+	}
+
+	writeOff := StockMovementRequest{Type: StockMovementWriteOff, Quantity: 2, Reason: StockReasonDamaged}.Movements(ctx, 7)
+	if len(writeOff) != 1 || writeOff[0].Quantity != -2 || writeOff[0].ReferenceID != nil || writeOff[0].Reason != StockReasonDamaged {
+		t.Errorf("Unexpected write-off: %+v", writeOff)
+	}
+}
@@ -0,0 +1,250 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/validation"
+)
+
+type StocktakeStatus string
+
+const (
+	StocktakeStatusOpen      StocktakeStatus = "open"
+	StocktakeStatusApproved  StocktakeStatus = "approved"
+	StocktakeStatusCancelled StocktakeStatus = "cancelled"
+)
+
+// Stocktake is a stock count (stock opname) at an outlet, of every product or of
+// one category. Each line keeps the system stock when the stocktake opened, and
+// counts are compared against that snapshot: approving posts counted - snapshot
+// as an adjustment on top of the current stock, so whatever sold while the
+// shelves were being counted is neither lost nor counted twice.
+type Stocktake struct {
+	ID         int               `json:"id"`
+	OutletID   int               `json:"outlet_id"`
+	CategoryID *int              `json:"category_id,omitempty"` // nil counts every product
+	Status     StocktakeStatus   `json:"status"`
+	Note       string            `json:"note,omitempty"`
+	OpenedBy   string            `json:"opened_by,omitempty"`
+	OpenedAt   time.Time         `json:"opened_at"`
+	ClosedBy   string            `json:"closed_by,omitempty"` // who approved or cancelled it
+	ClosedAt   *time.Time        `json:"closed_at,omitempty"`
+	Lines      []StocktakeLine   `json:"lines,omitempty"` // left out of lists
+	Summary    *StocktakeSummary `json:"summary,omitempty"`
+}
+
+// StocktakeLine is one product to count. Variance and VarianceValue are set by
+// Reviewed once the product has been counted.
+type StocktakeLine struct {
+	ProductID       int         `json:"product_id"`
+	ProductName     string      `json:"product_name"`
+	UnitCost        int         `json:"unit_cost"`      // product cost when the stocktake opened
+	SnapshotStock   int         `json:"snapshot_stock"` // system stock when the stocktake opened
+	CountedQuantity *int        `json:"counted_quantity"`
+	Variance        *int        `json:"variance,omitempty"`       // counted minus snapshot
+	VarianceValue   *int        `json:"variance_value,omitempty"` // variance at unit cost
+	Reason          StockReason `json:"reason,omitempty"`
+	CountedBy       string      `json:"counted_by,omitempty"`
+	CountedAt       *time.Time  `json:"counted_at,omitempty"`
+}
+
+// StocktakeSummary totals the variance of the counted lines. Uncounted lines are
+// left alone on approval.
+type StocktakeSummary struct {
+	Lines            int `json:"lines"`
+	Counted          int `json:"counted"`
+	Uncounted        int `json:"uncounted"`
+	WithVariance     int `json:"with_variance"`
+	VarianceQuantity int `json:"variance_quantity"` // net units gained (+) or lost (-)
+	VarianceValue    int `json:"variance_value"`    // net value at unit cost
+	GainValue        int `json:"gain_value"`
+	LossValue        int `json:"loss_value"` // positive amount
+}
+
+// NewStocktake returns an open stocktake at outletID of the lines snapshotted
+// for req, opened by the actor of ctx
+func NewStocktake(ctx context.Context, outletID int, req OpenStocktakeRequest, lines []StocktakeLine) (Stocktake, error) {
+	if len(lines) == 0 {
+		return Stocktake{}, ErrNothingToCount
+	}
+	return Stocktake{
+		OutletID:   outletID,
+		CategoryID: req.CategoryID,
+		Status:     StocktakeStatusOpen,
+		Note:       req.Note,
+		OpenedBy:   actorName(ctx),
+		OpenedAt:   time.Now(),
+		Lines:      lines,
+	}, nil
+}
+
+// AuditRecord returns the stocktake as the audit log keeps it: without its lines,
+// since counts are logged as they are submitted
+func (st Stocktake) AuditRecord() Stocktake {
+	st.Lines, st.Summary = nil, nil
+	return st
+}
+
+// Close marks an open stocktake approved or cancelled by the actor of ctx
+func (st *Stocktake) Close(ctx context.Context, status StocktakeStatus, at time.Time) error {
+	if st.Status != StocktakeStatusOpen {
+		return StocktakeClosedError(st.ID, st.Status)
+	}
+	st.Status = status
+	st.ClosedBy = actorName(ctx)
+	st.ClosedAt = &at
+	return nil
+}
+
+// Reviewed returns the stocktake with the variance of each counted line and the summary filled in
+func (st Stocktake) Reviewed() Stocktake {
+	summary := &StocktakeSummary{Lines: len(st.Lines)}
+	lines := make([]StocktakeLine, len(st.Lines))
+	for i, line := range st.Lines {
+		line.Variance, line.VarianceValue = nil, nil
+		if line.CountedQuantity == nil {
+			summary.Uncounted++
+			lines[i] = line
+			continue
+		}
+
+		variance := *line.CountedQuantity - line.SnapshotStock
+		value := variance * line.UnitCost
+		line.Variance, line.VarianceValue = &variance, &value
+		lines[i] = line
+
+		summary.Counted++
+		if variance != 0 {
+			summary.WithVariance++
+		}
+		summary.VarianceQuantity += variance
+		summary.VarianceValue += value
+		if value > 0 {
+			summary.GainValue += value
+		} else {
+			summary.LossValue -= value
+		}
+	}
+	st.Lines, st.Summary = lines, summary
+	return st
+}
+
+// ApplyCounts records counts on the lines of an open stocktake. A product counted
+// again, e.g. from another device, takes the latest count.
+func (st *Stocktake) ApplyCounts(ctx context.Context, items []StocktakeCountItem, at time.Time) error {
+	if st.Status != StocktakeStatusOpen {
+		return StocktakeClosedError(st.ID, st.Status)
+	}
+
+	index := make(map[int]int, len(st.Lines))
+	for i, line := range st.Lines {
+		index[line.ProductID] = i
+	}
+	for _, item := range items {
+		if _, ok := index[item.ProductID]; !ok {
+			return errorsPkg.ValidationError(fmt.Sprintf("product %d is not part of stocktake %d", item.ProductID, st.ID))
+		}
+	}
+
+	actor := actorName(ctx)
+	for _, item := range items {
+		line := &st.Lines[index[item.ProductID]]
+		counted := *item.CountedQuantity
+		line.CountedQuantity = &counted
+		line.Reason = item.Reason
+		line.CountedBy = actor
+		line.CountedAt = &at
+	}
+	return nil
+}
+
+// Adjustments returns the movements that approving the stocktake posts: one
+// adjustment of the variance for each counted line that has one. Every such line
+// needs a reason code.
+func (st Stocktake) Adjustments(ctx context.Context) ([]StockMovement, error) {
+	var missing []string
+	var movements []StockMovement
+	for _, line := range st.Reviewed().Lines {
+		if line.Variance == nil || *line.Variance == 0 {
+			continue
+		}
+		if line.Reason == "" {
+			missing = append(missing, line.ProductName)
+			continue
+		}
+		m := NewStockMovement(ctx, st.OutletID, line.ProductID, StockMovementAdjustment, *line.Variance).For(StockReferenceStocktake, st.ID)
+		m.Reason = line.Reason
+		m.Note = fmt.Sprintf("stocktake %d", st.ID)
+		movements = append(movements, m)
+	}
+
+	if len(missing) > 0 {
+		return nil, errorsPkg.ValidationError("a reason is required for every variance: " + strings.Join(missing, ", "))
+	}
+	return movements, nil
+}
+
+type OpenStocktakeRequest struct {
+	CategoryID *int   `json:"category_id,omitempty" validate:"omitempty,min=1"`
+	Note       string `json:"note" validate:"max=500"`
+}
+
+func (r OpenStocktakeRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+// StocktakeCountRequest submits counted quantities. Several devices can submit
+// counts for the same stocktake.
+type StocktakeCountRequest struct {
+	Items []StocktakeCountItem `json:"items" validate:"required,min=1,dive"`
+}
+
+type StocktakeCountItem struct {
+	ProductID       int         `json:"product_id" validate:"required,min=1"`
+	CountedQuantity *int        `json:"counted_quantity" validate:"required,min=0"`
+	Reason          StockReason `json:"reason,omitempty"` // required on approval if the count differs
+}
+
+func (r StocktakeCountRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	seen := make(map[int]bool, len(r.Items))
+	for _, item := range r.Items {
+		if seen[item.ProductID] {
+			return errorsPkg.ValidationError(fmt.Sprintf("product %d is counted twice", item.ProductID))
+		}
+		seen[item.ProductID] = true
+		if item.Reason != "" && !item.Reason.IsValid() {
+			return errorsPkg.ValidationError(fmt.Sprintf("unknown reason %q", item.Reason))
+		}
+	}
+
+	return nil
+}
+
+// StocktakeOpenError reports a stocktake opened while another is open at the outlet
+func StocktakeOpenError(id int) error {
+	return fmt.Errorf("%w: stocktake %d is still open at this outlet", ErrConflict, id)
+}
+
+// StocktakeClosedError reports an operation on a stocktake that is no longer open
+func StocktakeClosedError(id int, status StocktakeStatus) error {
+	return fmt.Errorf("%w: stocktake %d is already %s", ErrConflict, id, status)
+}
+
+// ErrNothingToCount is returned when a stocktake would have no products to count
+var ErrNothingToCount = fmt.Errorf("%w: there are no products to count", ErrValidation)
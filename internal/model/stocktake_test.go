@@ -0,0 +1,126 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/pkg/middleware"
+)
+
+func newTestStocktake() Stocktake {
+	return Stocktake{
+		ID:       3,
+		OutletID: 1,
+		Status:   StocktakeStatusOpen,
+		Lines: []StocktakeLine{
+			{ProductID: 1, ProductName: "Indomie", UnitCost: 2500, SnapshotStock: 10},
+			{ProductID: 2, ProductName: "Teh Botol", UnitCost: 3000, SnapshotStock: 5},
+			{ProductID: 3, ProductName: "Aqua", UnitCost: 2000, SnapshotStock: 8},
+		},
+	}
+}
+
+func TestStocktake_Reviewed(t *testing.T) {
+	st := newTestStocktake()
+	st.Lines[0].CountedQuantity = intPtr(8) // 2 short
+	st.Lines[1].CountedQuantity = intPtr(6) // 1 over
+	reviewed := st.Reviewed()
+
+	if v := reviewed.Lines[0]; *v.Variance != -2 || *v.VarianceValue != -5000 {
+		t.Errorf("Unexpected first line: %+v", v)
+	}
+	if reviewed.Lines[2].Variance != nil {
+		t.Errorf("Expected no variance for an uncounted line, got %d", *reviewed.Lines[2].Variance)
+	}
+
+	want := StocktakeSummary{Lines: 3, Counted: 2, Uncounted: 1, WithVariance: 2, VarianceQuantity: -1, VarianceValue: -2000, GainValue: 3000, LossValue: 5000}
+	if *reviewed.Summary != want {
+		t.Errorf("Summary = %+v, want %+v", *reviewed.Summary, want)
+	}
+	if st.Lines[0].Variance != nil {
+		t.Error("Reviewed() changed the lines of the original")
+	}
+}
+
+func TestStocktake_ApplyCounts(t *testing.T) {
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{Username: "kasir-1"})
+	st := newTestStocktake()
+	now := time.Now()
+
+	if err := st.ApplyCounts(ctx, []StocktakeCountItem{{ProductID: 1, CountedQuantity: intPtr(9)}}, now); err != nil {
+		t.Fatalf("ApplyCounts() error = %v", err)
+	}
+	// A second device recounts the same product; the latest count wins
+	if err := st.ApplyCounts(ctx, []StocktakeCountItem{{ProductID: 1, CountedQuantity: intPtr(7), Reason: StockReasonDamaged}}, now); err != nil {
+		t.Fatalf("ApplyCounts() error = %v", err)
+	}
+	if line := st.Lines[0]; *line.CountedQuantity != 7 || line.Reason != StockReasonDamaged || line.CountedBy != "kasir-1" {
+		t.Errorf("Unexpected line: %+v", line)
+	}
+
+	err := st.ApplyCounts(ctx, []StocktakeCountItem{{ProductID: 2, CountedQuantity: intPtr(1)}, {ProductID: 9, CountedQuantity: intPtr(1)}}, now)
+	if !IsValidationError(err) {
+		t.Errorf("ApplyCounts() for a product outside the stocktake error = %v, want validation", err)
+	}
+	if st.Lines[1].CountedQuantity != nil {
+		t.Error("Expected no count recorded when part of the request is refused")
+	}
+
+	st.Status = StocktakeStatusApproved
+	if err := st.ApplyCounts(ctx, []StocktakeCountItem{{ProductID: 1, CountedQuantity: intPtr(1)}}, now); !IsConflictError(err) {
+		t.Errorf("ApplyCounts() on an approved stocktake error = %v, want conflict", err)
+	}
+}
+
+func TestStocktake_Adjustments(t *testing.T) {
+	ctx := context.Background()
+	st := newTestStocktake()
+	st.Lines[0].CountedQuantity = intPtr(8)
+	st.Lines[1].CountedQuantity = intPtr(5) // no variance, no reason needed
+
+	if _, err := st.Adjustments(ctx); !IsValidationError(err) {
+		t.Errorf("Adjustments() without a reason error = %v, want validation", err)
+	}
+
+	st.Lines[0].Reason = StockReasonLost
+	movements, err := st.Adjustments(ctx)
+	if err != nil {
+		t.Fatalf("Adjustments() error = %v", err)
+	}
+	if len(movements) != 1 {
+		t.Fatalf("Expected 1 movement, got %+v", movements)
+	}
+	m := movements[0]
+	if m.ProductID != 1 || m.Quantity != -2 || m.Type != StockMovementAdjustment || m.Reason != StockReasonLost {
+		t.Errorf("Unexpected movement: %+v", m)
+	}
+	if m.ReferenceType != StockReferenceStocktake || *m.ReferenceID != 3 {
+		t.Errorf("Expected a reference to stocktake 3, got %+v", m)
+	}
+}
+
+func TestStocktakeCountRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     StocktakeCountRequest
+		wantErr bool
+	}{
+		{name: "valid", req: StocktakeCountRequest{Items: []StocktakeCountItem{{ProductID: 1, CountedQuantity: intPtr(0)}}}, wantErr: false},
+		{name: "with reason", req: StocktakeCountRequest{Items: []StocktakeCountItem{{ProductID: 1, CountedQuantity: intPtr(3), Reason: StockReasonExpired}}}, wantErr: false},
+		{name: "no items", req: StocktakeCountRequest{}, wantErr: true},
+		{name: "no quantity", req: StocktakeCountRequest{Items: []StocktakeCountItem{{ProductID: 1}}}, wantErr: true},
+		{name: "negative quantity", req: StocktakeCountRequest{Items: []StocktakeCountItem{{ProductID: 1, CountedQuantity: intPtr(-1)}}}, wantErr: true},
+		{name: "unknown reason", req: StocktakeCountRequest{Items: []StocktakeCountItem{{ProductID: 1, CountedQuantity: intPtr(1), Reason: "theft"}}}, wantErr: true},
+		{name: "counted twice", req: StocktakeCountRequest{Items: []StocktakeCountItem{{ProductID: 1, CountedQuantity: intPtr(1)}, {ProductID: 1, CountedQuantity: intPtr(2)}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/validation"
+)
+
+// Supplier is a distributor the business buys stock from
+type Supplier struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	ContactName string    `json:"contact_name,omitempty"`
+	Phone       string    `json:"phone,omitempty"`
+	Email       string    `json:"email,omitempty"`
+	Address     string    `json:"address,omitempty"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SupplierRequest is the input for creating or updating a supplier. Suppliers are
+// never deleted because purchase orders refer to them; set Active to false instead.
+type SupplierRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=255"`
+	ContactName string `json:"contact_name" validate:"max=255"`
+	Phone       string `json:"phone" validate:"max=20"`
+	Email       string `json:"email" validate:"omitempty,email,max=255"`
+	Address     string `json:"address" validate:"max=500"`
+	Active      *bool  `json:"active"`
+}
+
+// Normalize trims the fields and lower-cases the email
+func (r SupplierRequest) Normalize() SupplierRequest {
+	r.Name = strings.TrimSpace(r.Name)
+	r.ContactName = strings.TrimSpace(r.ContactName)
+	r.Phone = strings.TrimSpace(r.Phone)
+	r.Email = strings.ToLower(strings.TrimSpace(r.Email))
+	r.Address = strings.TrimSpace(r.Address)
+	return r
+}
+
+func (r SupplierRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+// Supplier returns the supplier described by the request. A new supplier is
+// active unless the request says otherwise.
+func (r SupplierRequest) Supplier() Supplier {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return Supplier{
+		Name:        r.Name,
+		ContactName: r.ContactName,
+		Phone:       r.Phone,
+		Email:       r.Email,
+		Address:     r.Address,
+		Active:      active,
+	}
+}
+
+// SupplierNotFoundError reports a supplier that does not exist
+func SupplierNotFoundError(id int) error {
+	return fmt.Errorf("%w: supplier id %d not found", ErrNotFound, id)
+}
+
+// SupplierInactiveError reports an order to a supplier that has been deactivated
+func SupplierInactiveError(name string) error {
+	return fmt.Errorf("%w: supplier %s is not active", ErrConflict, name)
+}
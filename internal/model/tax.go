@@ -0,0 +1,29 @@
+package model
+
+// BasisPointsPerUnit is the basis point value of a 100% rate
+const BasisPointsPerUnit = 10000
+
+// ApplyTax computes PPN for every line at rateBPS basis points (1100 = 11%) of the
+// discounted line amount. Exclusive lines have the tax added on top; for inclusive
+// lines the tax is the portion already contained in the amount. Each line is
+// rounded half up on its own so the transaction tax is the sum of its lines.
+func ApplyTax(lines []BasketLine, rateBPS int) {
+	for i := range lines {
+		lines[i].Tax = 0
+		if rateBPS <= 0 || lines[i].TaxExempt {
+			continue
+		}
+
+		net := lines[i].Net()
+		if lines[i].TaxInclusive {
+			lines[i].Tax = divRoundHalfUp(net*rateBPS, BasisPointsPerUnit+rateBPS)
+		} else {
+			lines[i].Tax = divRoundHalfUp(net*rateBPS, BasisPointsPerUnit)
+		}
+	}
+}
+
+// divRoundHalfUp divides two non-negative integers, rounding halves up
+func divRoundHalfUp(a, b int) int {
+	return (2*a + b) / (2 * b)
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestApplyTax(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      BasketLine
+		rate      int
+		wantTax   int
+		wantTotal int
+	}{
+		{
+			name:      "exclusive price adds tax",
+			line:      BasketLine{Gross: 10000},
+			rate:      1100,
+			wantTax:   1100,
+			wantTotal: 11100,
+		},
+		{
+			name:      "inclusive price extracts tax",
+			line:      BasketLine{Gross: 11100, TaxInclusive: true},
+			rate:      1100,
+			wantTax:   1100,
+			wantTotal: 11100,
+		},
+		{
+			name:      "tax is computed after discounts",
+			line:      BasketLine{Gross: 10000, Discount: 1000},
+			rate:      1100,
+			wantTax:   990,
+			wantTotal: 9990,
+		},
+		{
+			name:      "half rounds up",
+			line:      BasketLine{Gross: 50},
+			rate:      1100, // 5.5
+			wantTax:   6,
+			wantTotal: 56,
+		},
+		{
+			name:      "inclusive tax rounds to nearest",
+			line:      BasketLine{Gross: 3500, TaxInclusive: true},
+			rate:      1100, // 346.85...
+			wantTax:   347,
+			wantTotal: 3500,
+		},
+		{
+			name:      "below half rounds down",
+			line:      BasketLine{Gross: 40},
+			rate:      1100, // 4.4
+			wantTax:   4,
+			wantTotal: 44,
+		},
+		{
+			name:      "exempt product",
+			line:      BasketLine{Gross: 10000, TaxExempt: true},
+			rate:      1100,
+			wantTax:   0,
+			wantTotal: 10000,
+		},
+		{
+			name:      "tax disabled",
+			line:      BasketLine{Gross: 10000},
+			rate:      0,
+			wantTax:   0,
+			wantTotal: 10000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := []BasketLine{tt.line}
+			ApplyTax(lines, tt.rate)
+
+			if lines[0].Tax != tt.wantTax {
+				t.Errorf("Tax = %d, want %d", lines[0].Tax, tt.wantTax)
+			}
+			if lines[0].Total() != tt.wantTotal {
+				t.Errorf("Total() = %d, want %d", lines[0].Total(), tt.wantTotal)
+			}
+		})
+	}
+}
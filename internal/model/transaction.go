@@ -1,36 +1,127 @@
 package model
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	errorsPkg "kasir-api/pkg/errors"
 	"kasir-api/pkg/validation"
 	"time"
 )
 
+const (
+	DefaultTransactionPageSize = 20
+	MaxTransactionPageSize     = 100
+)
+
+// TransactionStatus is the lifecycle state of a transaction
+type TransactionStatus string
+
+const (
+	TransactionStatusCompleted TransactionStatus = "completed"
+	TransactionStatusVoided    TransactionStatus = "voided"
+	TransactionStatusRefunded  TransactionStatus = "refunded"
+)
+
+// IsValid reports whether the status is one of the known transaction statuses
+func (s TransactionStatus) IsValid() bool {
+	switch s {
+	case TransactionStatusCompleted, TransactionStatusVoided, TransactionStatusRefunded:
+		return true
+	}
+	return false
+}
+
 type Transaction struct {
-	ID          int                 `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details"`
+	ID             int                 `json:"id"`
+	InvoiceNumber  string              `json:"invoice_number,omitempty"`
+	OutletID       int                 `json:"outlet_id"`
+	ShiftID        *int                `json:"shift_id,omitempty"`
+	CustomerID     *int                `json:"customer_id,omitempty"`
+	GrossAmount    int                 `json:"gross_amount"`
+	DiscountAmount int                 `json:"discount_amount"`
+	TaxAmount      int                 `json:"tax_amount"`
+	TotalAmount    int                 `json:"total_amount"`
+	PaidAmount     int                 `json:"paid_amount"`
+	ChangeAmount   int                 `json:"change_amount"`
+	Status         TransactionStatus   `json:"status"`
+	CreatedAt      time.Time           `json:"created_at"`
+	PointsEarned   int                 `json:"points_earned,omitempty"`
+	PointsRedeemed int                 `json:"points_redeemed,omitempty"`
+	PointsDiscount int                 `json:"points_discount,omitempty"` // part of discount_amount given for redeemed points
+	CancelledAt    *time.Time          `json:"cancelled_at,omitempty"`
+	CancelledBy    string              `json:"cancelled_by,omitempty"`
+	CancelReason   string              `json:"cancel_reason,omitempty"`
+	Details        []TransactionDetail `json:"details"`
+	Payments       []Payment           `json:"payments"`
+	Promotions     []AppliedPromotion  `json:"promotions"`
+	LowStock       []StockAlert        `json:"low_stock,omitempty"` // products the checkout took to their reorder point; only on the checkout response
 }
 
+// TransactionDetail is a sold line. Product name, unit price, category and cost
+// are copied from the product at sale time so receipts and reports keep showing
+// what was sold even after the product is edited.
 type TransactionDetail struct {
-	ID            int    `json:"id"`
-	TransactionID int    `json:"transaction_id"`
-	ProductID     int    `json:"product_id"`
-	ProductName   string `json:"product_name,omitempty"`
-	Quantity      int    `json:"quantity"`
-	Subtotal      int    `json:"subtotal"`
+	ID                int    `json:"id"`
+	TransactionID     int    `json:"transaction_id"`
+	ProductID         int    `json:"product_id"` // the variant, when a variant was sold
+	ProductName       string `json:"product_name,omitempty"`
+	ParentProductID   *int   `json:"parent_product_id,omitempty"` // product the variant sold belongs to, for reports
+	ParentProductName string `json:"parent_product_name,omitempty"`
+	CategoryID        *int   `json:"category_id,omitempty"`
+	CategoryName      string `json:"category_name,omitempty"`
+	UnitPrice         int    `json:"unit_price"`
+	UnitCost          int    `json:"unit_cost"`
+	Quantity          int    `json:"quantity"`
+	GrossAmount       int    `json:"gross_amount"`
+	DiscountAmount    int    `json:"discount_amount"`
+	TaxAmount         int    `json:"tax_amount"`
+	Subtotal          int    `json:"subtotal"` // charged for the line after discounts, including tax
 }
 
+// CheckoutItem sells a product, or with VariantID one of its variants. ProductID
+// may be left out for a variant; when given it must be the variant's product.
 type CheckoutItem struct {
-	ProductID int `json:"product_id" validate:"min=1"`
-	Quantity  int `json:"quantity" validate:"min=1"`
+	ProductID int  `json:"product_id" validate:"min=0"`
+	VariantID *int `json:"variant_id,omitempty" validate:"omitempty,min=1"`
+	Quantity  int  `json:"quantity" validate:"min=1"`
+}
+
+// CatalogID returns the ID of the catalog entry the item sells and takes stock
+// from: the variant when one is given, otherwise the product
+func (i CheckoutItem) CatalogID() int {
+	if i.VariantID != nil {
+		return *i.VariantID
+	}
+	return i.ProductID
+}
+
+// CheckProduct checks that p, the catalog entry of the item, can be sold as asked.
+// A product with variants is sold through one of them.
+func (i CheckoutItem) CheckProduct(p Product, hasVariants bool) error {
+	if i.VariantID != nil {
+		if p.ParentID == nil {
+			return errorsPkg.ValidationError(fmt.Sprintf("product %d is not a variant", p.ID))
+		}
+		if i.ProductID != 0 && *p.ParentID != i.ProductID {
+			return errorsPkg.ValidationError(fmt.Sprintf("variant %d is not a variant of product %d", p.ID, i.ProductID))
+		}
+	}
+	if hasVariants {
+		return errorsPkg.ValidationError(fmt.Sprintf("product %s has variants; give the variant_id to sell", p.Name))
+	}
+	if !p.Active {
+		return fmt.Errorf("%w: product %s is not active", ErrValidation, p.Name)
+	}
+	return nil
 }
 
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items" validate:"required,min=1,dive"`
+	Items        []CheckoutItem    `json:"items" validate:"required,min=1,dive"`
+	Payments     []CheckoutPayment `json:"payments,omitempty" validate:"dive"`
+	CustomerID   *int              `json:"customer_id,omitempty" validate:"omitempty,min=1"`
+	RedeemPoints int               `json:"redeem_points,omitempty" validate:"min=0"`
 }
 
 func (c CheckoutRequest) Validate() error {
@@ -48,7 +139,7 @@ func (c CheckoutRequest) Validate() error {
 	}
 
 	for i, item := range c.Items {
-		if item.ProductID <= 0 {
+		if item.CatalogID() <= 0 {
 			return errorsPkg.ValidationError(fmt.Sprintf("item[%d] product_id must be positive", i))
 		}
 		if item.Quantity <= 0 {
@@ -56,5 +147,182 @@ func (c CheckoutRequest) Validate() error {
 		}
 	}
 
+	for i, payment := range c.Payments {
+		if !payment.Method.IsValid() {
+			return errorsPkg.ValidationError(fmt.Sprintf("payments[%d] method must be one of [cash debit credit qris ewallet transfer]", i))
+		}
+	}
+
+	if c.RedeemPoints > 0 && c.CustomerID == nil {
+		return errorsPkg.ValidationError("redeem_points requires customer_id")
+	}
+
+	return nil
+}
+
+// CheckoutOptions carries the server-side inputs used to price a checkout
+type CheckoutOptions struct {
+	Promotions []Promotion
+	TaxRate    int // PPN in basis points, 0 disables tax
+	Invoice    InvoiceNumbering
+	ShiftID    *int // open shift the sale belongs to; checkout fails if it has been closed
+	Loyalty    LoyaltyRule
+	// Idempotency is the reserved Idempotency-Key completed with the sale, in the
+	// same database transaction; nil when the request carries none
+	Idempotency *IdempotencyClaim
+}
+
+// CancelRequest is the input for voiding or refunding a transaction
+type CancelRequest struct {
+	Reason string `json:"reason" validate:"required,min=1,max=500"`
+}
+
+func (c CancelRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(c); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+// Cancel marks the transaction voided or refunded by the actor of ctx
+func (t *Transaction) Cancel(ctx context.Context, status TransactionStatus, req CancelRequest, at time.Time) {
+	t.Status = status
+	t.CancelledAt = &at
+	t.CancelledBy = actorName(ctx)
+	t.CancelReason = req.Reason
+}
+
+// CashKept is the cash the sale left in the drawer: cash tendered less change
+func (t Transaction) CashKept() int {
+	cash := -t.ChangeAmount
+	for _, p := range t.Payments {
+		if p.Method == PaymentMethodCash {
+			cash += p.Amount
+		}
+	}
+	return cash
+}
+
+// TransactionFilter holds the filter and pagination options for listing transactions
+type TransactionFilter struct {
+	StartDate  string `json:"start_date,omitempty"`
+	EndDate    string `json:"end_date,omitempty"`
+	MinAmount  *int   `json:"min_amount,omitempty"`
+	MaxAmount  *int   `json:"max_amount,omitempty"`
+	ProductID  *int   `json:"product_id,omitempty"`
+	CustomerID *int   `json:"customer_id,omitempty"`
+	OutletID   *int   `json:"outlet_id,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Invoice    string `json:"invoice_number,omitempty"` // case-insensitive substring of the invoice number
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+}
+
+// WithDefaults returns a copy of the filter with page and limit defaults applied
+func (f TransactionFilter) WithDefaults() TransactionFilter {
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Limit <= 0 {
+		f.Limit = DefaultTransactionPageSize
+	}
+	if f.Limit > MaxTransactionPageSize {
+		f.Limit = MaxTransactionPageSize
+	}
+	return f
+}
+
+// Offset returns the number of rows to skip for the current page
+func (f TransactionFilter) Offset() int {
+	if f.Page <= 1 {
+		return 0
+	}
+	return (f.Page - 1) * f.Limit
+}
+
+func (f TransactionFilter) Validate() error {
+	var start, end time.Time
+	var err error
+
+	if f.StartDate != "" {
+		if start, err = time.Parse(time.DateOnly, f.StartDate); err != nil {
+			return errorsPkg.ValidationError("start_date must be in YYYY-MM-DD format")
+		}
+	}
+	if f.EndDate != "" {
+		if end, err = time.Parse(time.DateOnly, f.EndDate); err != nil {
+			return errorsPkg.ValidationError("end_date must be in YYYY-MM-DD format")
+		}
+	}
+	if f.StartDate != "" && f.EndDate != "" && end.Before(start) {
+		return errorsPkg.ValidationError("end_date must not be before start_date")
+	}
+
+	if f.MinAmount != nil && *f.MinAmount < 0 {
+		return errorsPkg.ValidationError("min_amount must not be negative")
+	}
+	if f.MaxAmount != nil && *f.MaxAmount < 0 {
+		return errorsPkg.ValidationError("max_amount must not be negative")
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil && *f.MaxAmount < *f.MinAmount {
+		return errorsPkg.ValidationError("max_amount must not be less than min_amount")
+	}
+	if f.ProductID != nil && *f.ProductID <= 0 {
+		return errorsPkg.ValidationError("product_id must be positive")
+	}
+	if f.CustomerID != nil && *f.CustomerID <= 0 {
+		return errorsPkg.ValidationError("customer_id must be positive")
+	}
+	if f.OutletID != nil && *f.OutletID <= 0 {
+		return errorsPkg.ValidationError("outlet_id must be positive")
+	}
+	if f.Status != "" && !TransactionStatus(f.Status).IsValid() {
+		return errorsPkg.ValidationError("status must be one of [completed voided refunded]")
+	}
+	if len(f.Invoice) > MaxInvoiceNumberLength {
+		return errorsPkg.ValidationError(fmt.Sprintf("invoice_number must be at most %d characters", MaxInvoiceNumberLength))
+	}
+
 	return nil
 }
+
+// Matches reports whether the transaction satisfies the date, amount and product filters
+func (f TransactionFilter) Matches(t Transaction) bool {
+	day := t.CreatedAt.Format(time.DateOnly)
+	if f.StartDate != "" && day < f.StartDate {
+		return false
+	}
+	if f.EndDate != "" && day > f.EndDate {
+		return false
+	}
+	if f.MinAmount != nil && t.TotalAmount < *f.MinAmount {
+		return false
+	}
+	if f.MaxAmount != nil && t.TotalAmount > *f.MaxAmount {
+		return false
+	}
+	if f.Status != "" && string(t.Status) != f.Status {
+		return false
+	}
+	if f.CustomerID != nil && (t.CustomerID == nil || *t.CustomerID != *f.CustomerID) {
+		return false
+	}
+	if f.OutletID != nil && t.OutletID != *f.OutletID {
+		return false
+	}
+	if f.Invoice != "" && !strings.Contains(strings.ToLower(t.InvoiceNumber), strings.ToLower(f.Invoice)) {
+		return false
+	}
+	if f.ProductID != nil {
+		for _, d := range t.Details {
+			if d.ProductID == *f.ProductID || (d.ParentProductID != nil && *d.ParentProductID == *f.ProductID) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestTransactionFilter_Validate(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name    string
+		filter  TransactionFilter
+		wantErr bool
+	}{
+		{
+			name:    "empty filter",
+			filter:  TransactionFilter{},
+			wantErr: false,
+		},
+		{
+			name: "valid date and amount range",
+			filter: TransactionFilter{
+				StartDate: "2026-01-01",
+				EndDate:   "2026-01-31",
+				MinAmount: intPtr(1000),
+				MaxAmount: intPtr(50000),
+				ProductID: intPtr(1),
+			},
+			wantErr: false,
+		},
+		{
+			name:    "invalid start date",
+			filter:  TransactionFilter{StartDate: "01-01-2026"},
+			wantErr: true,
+		},
+		{
+			name:    "end date before start date",
+			filter:  TransactionFilter{StartDate: "2026-02-01", EndDate: "2026-01-01"},
+			wantErr: true,
+		},
+		{
+			name:    "max amount below min amount",
+			filter:  TransactionFilter{MinAmount: intPtr(5000), MaxAmount: intPtr(1000)},
+			wantErr: true,
+		},
+		{
+			name:    "non-positive product id",
+			filter:  TransactionFilter{ProductID: intPtr(0)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TransactionFilter.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTransactionFilter_WithDefaults(t *testing.T) {
+	f := TransactionFilter{}.WithDefaults()
+	if f.Page != 1 || f.Limit != DefaultTransactionPageSize {
+		t.Errorf("WithDefaults() = page %d limit %d, want page 1 limit %d", f.Page, f.Limit, DefaultTransactionPageSize)
+	}
+
+	f = TransactionFilter{Page: 3, Limit: 1000}.WithDefaults()
+	if f.Limit != MaxTransactionPageSize {
+		t.Errorf("Limit = %d, want %d", f.Limit, MaxTransactionPageSize)
+	}
+	if f.Offset() != 2*MaxTransactionPageSize {
+		t.Errorf("Offset() = %d, want %d", f.Offset(), 2*MaxTransactionPageSize)
+	}
+}
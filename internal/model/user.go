@@ -0,0 +1,212 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/validation"
+)
+
+// User is a person who signs in to the API. The password is only ever kept as a
+// bcrypt hash and never leaves the server. Role decides what the user may do.
+type User struct {
+	ID           int        `json:"id"`
+	Username     string     `json:"username"`
+	Name         string     `json:"name"`
+	Role         Role       `json:"role"`
+	Active       bool       `json:"active"`
+	PasswordHash string     `json:"-"`
+	FailedLogins int        `json:"-"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// IsActiveOwner reports whether the user is an owner who can sign in
+func (u User) IsActiveOwner() bool {
+	return u.Active && u.Role == RoleOwner
+}
+
+// IsLocked reports whether the account is locked out at the given time
+func (u User) IsLocked(at time.Time) bool {
+	return u.LockedUntil != nil && at.Before(*u.LockedUntil)
+}
+
+// LockoutPolicy locks an account out after repeated failed logins
+type LockoutPolicy struct {
+	MaxFailedLogins int           // failed logins in a row that lock the account; 0 disables lockout
+	Duration        time.Duration // how long the account stays locked
+}
+
+// RegisterFailure records a failed login on the user. Reaching the limit locks
+// the account and starts the count again, so the user gets a fresh set of
+// attempts once the lockout ends.
+func (p LockoutPolicy) RegisterFailure(u *User, at time.Time) {
+	u.FailedLogins++
+	if p.MaxFailedLogins > 0 && u.FailedLogins >= p.MaxFailedLogins {
+		lockedUntil := at.Add(p.Duration)
+		u.LockedUntil = &lockedUntil
+		u.FailedLogins = 0
+	}
+	u.UpdatedAt = at
+}
+
+// RegisterLogin records a successful login on the user and clears any failures
+func RegisterLogin(u *User, at time.Time) {
+	u.FailedLogins = 0
+	u.LockedUntil = nil
+	u.LastLoginAt = &at
+	u.UpdatedAt = at
+}
+
+// MinPasswordLength and MaxPasswordLength bound passwords; bcrypt ignores
+// anything past 72 bytes
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9._-]+$`)
+
+// UserRequest is the input for creating or updating a user. Password is required
+// when creating; on update it is optional and, when given, replaces the password
+// and lifts any lockout.
+type UserRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=50"`
+	Name     string `json:"name" validate:"required,min=1,max=255"`
+	Password string `json:"password"`
+	Active   *bool  `json:"active"`
+}
+
+// Normalize trims the fields and lower-cases the username
+func (r UserRequest) Normalize() UserRequest {
+	r.Username = strings.ToLower(strings.TrimSpace(r.Username))
+	r.Name = strings.TrimSpace(r.Name)
+	return r
+}
+
+func (r UserRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	if !usernamePattern.MatchString(r.Username) {
+		return errorsPkg.ValidationError("username may only contain letters, digits, dots, dashes and underscores")
+	}
+
+	if r.Password != "" {
+		return ValidatePassword(r.Password)
+	}
+
+	return nil
+}
+
+// ValidatePassword checks a new password's length
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return errorsPkg.ValidationError(fmt.Sprintf("password must be between %d and %d bytes", MinPasswordLength, MaxPasswordLength))
+	}
+	return nil
+}
+
+// LoginRequest is the input for signing in with a password
+type LoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (r LoginRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+// RefreshRequest exchanges a refresh token for a new pair of tokens
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+func (r RefreshRequest) Validate() error {
+	validator := validation.NewValidator()
+
+	if err := validator.ValidateStruct(r); err != nil {
+		return errorsPkg.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+// TokenResponse is returned by login and refresh. The access token goes in the
+// Authorization header; the refresh token can be used once to get the next pair.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // seconds until the access token expires
+	RefreshToken string `json:"refresh_token"`
+	User         User   `json:"user"`
+}
+
+// Session is one sign-in of a user. Access and refresh tokens are tied to it, so
+// revoking the session signs the user out everywhere those tokens are used.
+type Session struct {
+	ID        string     `json:"id"`
+	UserID    int        `json:"user_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsActive reports whether the session can still be used at the given time
+func (s Session) IsActive(at time.Time) bool {
+	return s.RevokedAt == nil && at.Before(s.ExpiresAt)
+}
+
+// RefreshToken is a single-use token of a session. Only its SHA-256 hash is stored.
+type RefreshToken struct {
+	SessionID string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// UsernameTakenError reports a username already used by another user
+func UsernameTakenError(username string) error {
+	return fmt.Errorf("%w: username %s is already in use", ErrConflict, username)
+}
+
+// InvalidCredentialsError reports a login with an unknown username or a wrong
+// password, without telling which
+func InvalidCredentialsError() error {
+	return fmt.Errorf("%w: invalid username or password", ErrUnauthorized)
+}
+
+// AccountLockedError reports a login to an account locked out after failed attempts
+func AccountLockedError(until time.Time) error {
+	return fmt.Errorf("%w: account is locked until %s", ErrUnauthorized, until.Format(time.RFC3339))
+}
+
+// InvalidSessionError reports a token whose session has expired or been revoked
+func InvalidSessionError() error {
+	return fmt.Errorf("%w: session has expired or been revoked", ErrUnauthorized)
+}
+
+// RefreshTokenReusedError reports a refresh token presented a second time. Its
+// session is revoked, because one of the two holders must have stolen it.
+func RefreshTokenReusedError() error {
+	return fmt.Errorf("%w: refresh token has already been used; the session has been revoked", ErrUnauthorized)
+}
+
+// InvalidTokenError reports an access token that is malformed, badly signed or expired
+func InvalidTokenError() error {
+	return fmt.Errorf("%w: invalid or expired access token", ErrUnauthorized)
+}
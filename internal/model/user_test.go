@@ -0,0 +1,66 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     UserRequest
+		wantErr bool
+	}{
+		{name: "valid", req: UserRequest{Username: "Siti.K", Name: "Siti", Password: "rahasia123"}},
+		{name: "no password on update", req: UserRequest{Username: "siti", Name: "Siti"}},
+		{name: "short username", req: UserRequest{Username: "si", Name: "Siti"}, wantErr: true},
+		{name: "space in username", req: UserRequest{Username: "siti k", Name: "Siti"}, wantErr: true},
+		{name: "missing name", req: UserRequest{Username: "siti"}, wantErr: true},
+		{name: "short password", req: UserRequest{Username: "siti", Name: "Siti", Password: "1234567"}, wantErr: true},
+		{name: "password past bcrypt limit", req: UserRequest{Username: "siti", Name: "Siti", Password: strings.Repeat("a", 73)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Normalize().Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLockoutPolicy_RegisterFailure(t *testing.T) {
+	policy := LockoutPolicy{MaxFailedLogins: 3, Duration: 15 * time.Minute}
+	now := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	var u User
+
+	policy.RegisterFailure(&u, now)
+	policy.RegisterFailure(&u, now)
+	if u.IsLocked(now) || u.FailedLogins != 2 {
+		t.Fatalf("after 2 failures: locked = %v, failed = %d, want unlocked with 2", u.IsLocked(now), u.FailedLogins)
+	}
+
+	policy.RegisterFailure(&u, now)
+	if !u.IsLocked(now) || u.FailedLogins != 0 {
+		t.Fatalf("after 3 failures: locked = %v, failed = %d, want locked with the count reset", u.IsLocked(now), u.FailedLogins)
+	}
+	if u.IsLocked(now.Add(15 * time.Minute)) {
+		t.Error("IsLocked() after the lockout ended = true")
+	}
+
+	RegisterLogin(&u, now.Add(time.Hour))
+	if u.LockedUntil != nil || u.LastLoginAt == nil {
+		t.Errorf("after login: LockedUntil = %v, LastLoginAt = %v, want cleared and set", u.LockedUntil, u.LastLoginAt)
+	}
+
+	// Without a limit accounts never lock
+	var unlimited User
+	for range 10 {
+		LockoutPolicy{}.RegisterFailure(&unlimited, now)
+	}
+	if unlimited.IsLocked(now) {
+		t.Error("IsLocked() without a limit = true")
+	}
+}
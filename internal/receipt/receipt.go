@@ -0,0 +1,322 @@
+// Package receipt renders transactions as printable receipts. Layouts are Go
+// templates; the defaults are embedded and can be replaced per store by putting
+// receipt.txt.tmpl and/or receipt.html.tmpl in the configured template directory.
+package receipt
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	"unicode/utf8"
+
+	"kasir-api/internal/model"
+	errorsPkg "kasir-api/pkg/errors"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+const (
+	textTemplateName = "receipt.txt.tmpl"
+	htmlTemplateName = "receipt.html.tmpl"
+)
+
+// Format is the output format of a receipt
+type Format string
+
+const (
+	FormatText   Format = "text"
+	FormatESCPOS Format = "escpos"
+	FormatHTML   Format = "html"
+)
+
+// ContentType returns the HTTP content type for the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatESCPOS:
+		return "application/octet-stream"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Paper widths in millimetres and the characters per line they fit in the
+// printer's default font
+const (
+	Width58 = 58
+	Width80 = 80
+)
+
+var columnsByWidth = map[int]int{Width58: 32, Width80: 48}
+
+// Options selects how a receipt is rendered
+type Options struct {
+	Format Format
+	Width  int
+}
+
+// ParseOptions validates the format and paper width, defaulting to text on 58mm paper
+func ParseOptions(format, width string) (Options, error) {
+	opts := Options{Format: FormatText, Width: Width58}
+
+	if format != "" {
+		opts.Format = Format(format)
+	}
+	switch opts.Format {
+	case FormatText, FormatESCPOS, FormatHTML:
+	default:
+		return opts, errorsPkg.ValidationError("format must be one of [text escpos html]")
+	}
+
+	if width != "" {
+		w, err := strconv.Atoi(width)
+		if err != nil || columnsByWidth[w] == 0 {
+			return opts, errorsPkg.ValidationError("width must be 58 or 80")
+		}
+		opts.Width = w
+	}
+
+	return opts, nil
+}
+
+// Store is the header and footer printed on every receipt
+type Store struct {
+	Name    string
+	Address string
+	Phone   string
+	Footer  string
+}
+
+// ForOutlet returns the header of the outlet a sale was made at, keeping the
+// store's details where the outlet has none and the store's footer
+func (s Store) ForOutlet(o model.Outlet) Store {
+	if o.Name != "" {
+		s.Name = o.Name
+	}
+	if o.Address != "" {
+		s.Address = o.Address
+	}
+	if o.Phone != "" {
+		s.Phone = o.Phone
+	}
+	return s
+}
+
+// Data is what receipt templates are executed with
+type Data struct {
+	Store         Store
+	Transaction   model.Transaction
+	Width         int // paper width in millimetres
+	Columns       int // characters per line
+	AmountInWords string
+}
+
+// Center pads s so it sits in the middle of the line
+func (d Data) Center(s string) string {
+	n := utf8.RuneCountInString(s)
+	if n >= d.Columns {
+		return s
+	}
+	return strings.Repeat(" ", (d.Columns-n)/2) + s
+}
+
+// Rule returns a full-width separator line
+func (d Data) Rule() string {
+	return strings.Repeat("-", d.Columns)
+}
+
+// Row puts left and right on one line with the right part flush to the edge,
+// moving right to its own line when both do not fit
+func (d Data) Row(left, right string) string {
+	gap := d.Columns - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+	if gap < 1 {
+		return left + "\n" + strings.Repeat(" ", max(d.Columns-utf8.RuneCountInString(right), 0)) + right
+	}
+	return left + strings.Repeat(" ", gap) + right
+}
+
+// Wrap breaks s into lines that fit the paper width
+func (d Data) Wrap(s string) string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > d.Columns {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+var funcs = map[string]any{
+	"rupiah": Rupiah,
+	"method": MethodLabel,
+	"date":   func(t time.Time) string { return t.Local().Format("02/01/2006 15:04") },
+}
+
+// Renderer renders receipts for one store
+type Renderer struct {
+	store Store
+	text  *texttemplate.Template
+	html  *htmltemplate.Template
+}
+
+// NewRenderer parses the receipt templates, preferring files in templateDir over
+// the embedded defaults. An empty templateDir uses the defaults only.
+func NewRenderer(store Store, templateDir string) (*Renderer, error) {
+	textSrc, err := loadTemplate(templateDir, textTemplateName)
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.New(textTemplateName).Funcs(funcs).Parse(textSrc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", textTemplateName, err)
+	}
+
+	htmlSrc, err := loadTemplate(templateDir, htmlTemplateName)
+	if err != nil {
+		return nil, err
+	}
+	html, err := htmltemplate.New(htmlTemplateName).Funcs(funcs).Parse(htmlSrc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", htmlTemplateName, err)
+	}
+
+	return &Renderer{store: store, text: text, html: html}, nil
+}
+
+// ForOutlet returns a renderer that prints the outlet's header instead of the store's
+func (r *Renderer) ForOutlet(o model.Outlet) *Renderer {
+	return &Renderer{store: r.store.ForOutlet(o), text: r.text, html: r.html}
+}
+
+func loadTemplate(dir, name string) (string, error) {
+	if dir != "" {
+		src, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return string(src), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
+	}
+
+	src, err := defaultTemplates.ReadFile("templates/" + name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read default %s: %w", name, err)
+	}
+	return string(src), nil
+}
+
+// Render produces the receipt for a transaction in the requested format
+func (r *Renderer) Render(t model.Transaction, opts Options) ([]byte, error) {
+	data := Data{
+		Store:         r.store,
+		Transaction:   t,
+		Width:         opts.Width,
+		Columns:       columnsByWidth[opts.Width],
+		AmountInWords: capitalize(Terbilang(t.TotalAmount)) + " rupiah",
+	}
+
+	var buf bytes.Buffer
+	switch opts.Format {
+	case FormatHTML:
+		if err := r.html.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render receipt: %w", err)
+		}
+		return buf.Bytes(), nil
+	case FormatESCPOS:
+		if err := r.text.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render receipt: %w", err)
+		}
+		return escpos(buf.String()), nil
+	default:
+		if err := r.text.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render receipt: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// ESC/POS commands understood by common 58mm and 80mm thermal printers
+var (
+	escInit    = []byte{0x1b, 0x40}       // ESC @: reset the printer
+	escFeed    = []byte{0x1b, 0x64, 0x04} // ESC d 4: feed four lines so the cut clears the text
+	escCutPart = []byte{0x1d, 0x56, 0x01} // GS V 1: partial cut
+)
+
+// escpos wraps the text layout in printer commands. Printers use a single-byte
+// code page, so characters outside ASCII are replaced with '?'.
+func escpos(text string) []byte {
+	var buf bytes.Buffer
+	buf.Write(escInit)
+	for _, r := range text {
+		switch {
+		case r == '\n':
+			buf.WriteByte('\n')
+		case r < 0x20 || r > 0x7e:
+			buf.WriteByte('?')
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	buf.Write(escFeed)
+	buf.Write(escCutPart)
+	return buf.Bytes()
+}
+
+// Rupiah formats an amount with dot thousand separators, e.g. 36500 becomes "36.500"
+func Rupiah(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	s := strconv.Itoa(amount)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "." + s[i:]
+	}
+	return sign + s
+}
+
+// MethodLabel returns the name printed for a payment method
+func MethodLabel(m model.PaymentMethod) string {
+	switch m {
+	case model.PaymentMethodCash:
+		return "Tunai"
+	case model.PaymentMethodDebit:
+		return "Kartu Debit"
+	case model.PaymentMethodCredit:
+		return "Kartu Kredit"
+	case model.PaymentMethodQRIS:
+		return "QRIS"
+	case model.PaymentMethodEWallet:
+		return "E-Wallet"
+	case model.PaymentMethodTransfer:
+		return "Transfer"
+	}
+	return string(m)
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,175 @@
+package receipt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+func TestTerbilang(t *testing.T) {
+	tests := []struct {
+		amount int
+		want   string
+	}{
+		{0, "nol"},
+		{11, "sebelas"},
+		{15, "lima belas"},
+		{100, "seratus"},
+		{1000, "seribu"},
+		{1111, "seribu seratus sebelas"},
+		{36500, "tiga puluh enam ribu lima ratus"},
+		{1_250_000, "satu juta dua ratus lima puluh ribu"},
+		{2_000_000_000, "dua miliar"},
+	}
+
+	for _, tt := range tests {
+		if got := Terbilang(tt.amount); got != tt.want {
+			t.Errorf("Terbilang(%d) = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestRupiah(t *testing.T) {
+	tests := map[int]string{0: "0", 500: "500", 3500: "3.500", 1250000: "1.250.000", -12000: "-12.000"}
+	for amount, want := range tests {
+		if got := Rupiah(amount); got != want {
+			t.Errorf("Rupiah(%d) = %q, want %q", amount, got, want)
+		}
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	opts, err := ParseOptions("", "")
+	if err != nil || opts.Format != FormatText || opts.Width != Width58 {
+		t.Errorf("ParseOptions() = %+v, %v, want text on 58mm", opts, err)
+	}
+
+	if _, err := ParseOptions("pdf", ""); !model.IsValidationError(err) {
+		t.Errorf("ParseOptions(pdf) error = %v, want validation error", err)
+	}
+	if _, err := ParseOptions("text", "76"); !model.IsValidationError(err) {
+		t.Errorf("ParseOptions(width 76) error = %v, want validation error", err)
+	}
+}
+
+func testTransaction() model.Transaction {
+	return model.Transaction{
+		ID:             42,
+		InvoiceNumber:  "INV/MAIN/20260102/00042",
+		GrossAmount:    36000,
+		DiscountAmount: 3600,
+		TotalAmount:    32400,
+		PaidAmount:     50000,
+		ChangeAmount:   17600,
+		Status:         model.TransactionStatusCompleted,
+		CreatedAt:      time.Date(2026, 1, 2, 10, 30, 0, 0, time.Local),
+		Details: []model.TransactionDetail{
+			{ProductName: "Kopi Susu", UnitPrice: 12000, Quantity: 3, GrossAmount: 36000, DiscountAmount: 3600, Subtotal: 32400},
+		},
+		Payments: []model.Payment{{Method: model.PaymentMethodCash, Amount: 50000}},
+	}
+}
+
+func TestRenderer_Text(t *testing.T) {
+	r, err := NewRenderer(Store{Name: "Toko Maju", Footer: "Terima kasih"}, "")
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+
+	for _, width := range []int{Width58, Width80} {
+		out, err := r.Render(testTransaction(), Options{Format: FormatText, Width: width})
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+
+		text := string(out)
+		flat := strings.Join(strings.Fields(text), " ")
+		for _, want := range []string{"Toko Maju", "INV/MAIN/20260102/00042", "3 x 12.000", "-3.600", "32.400", "Tunai", "Kembali", "Tiga puluh dua ribu empat ratus rupiah", "Terima kasih"} {
+			if !strings.Contains(flat, want) {
+				t.Errorf("width %d: receipt does not contain %q:\n%s", width, want, text)
+			}
+		}
+		for _, line := range strings.Split(text, "\n") {
+			if len([]rune(line)) > columnsByWidth[width] {
+				t.Errorf("width %d: line %q is wider than %d columns", width, line, columnsByWidth[width])
+			}
+		}
+	}
+}
+
+func TestRenderer_ESCPOS(t *testing.T) {
+	r, _ := NewRenderer(Store{Name: "Toko Maju"}, "")
+
+	out, err := r.Render(testTransaction(), Options{Format: FormatESCPOS, Width: Width58})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !bytes.HasPrefix(out, escInit) || !bytes.HasSuffix(out, escCutPart) {
+		t.Errorf("ESC/POS output should start with init and end with a cut: %q", out)
+	}
+}
+
+func TestRenderer_HTML_Escapes(t *testing.T) {
+	r, _ := NewRenderer(Store{Name: "Toko <Maju>"}, "")
+
+	out, err := r.Render(testTransaction(), Options{Format: FormatHTML, Width: Width80})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(string(out), "Toko &lt;Maju&gt;") || !strings.Contains(string(out), "width: 80mm") {
+		t.Errorf("unexpected HTML receipt:\n%s", out)
+	}
+}
+
+func TestNewRenderer_TemplateOverride(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, textTemplateName), []byte("custom {{.Transaction.ID}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRenderer(Store{}, dir)
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+	out, _ := r.Render(testTransaction(), Options{Format: FormatText, Width: Width58})
+	if string(out) != "custom 42" {
+		t.Errorf("Render() = %q, want the custom template", out)
+	}
+
+	// The HTML template falls back to the default
+	if _, err := r.Render(testTransaction(), Options{Format: FormatHTML, Width: Width58}); err != nil {
+		t.Errorf("Render(html) error = %v", err)
+	}
+}
+
+func TestRenderer_ForOutlet(t *testing.T) {
+	r, err := NewRenderer(Store{Name: "Toko Maju", Address: "Jl. Merdeka 1", Phone: "021-555", Footer: "Terima kasih"}, "")
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+
+	out, err := r.ForOutlet(model.Outlet{Name: "Toko Maju Bandung", Address: "Jl. Braga 5"}).
+		Render(testTransaction(), Options{Format: FormatText, Width: Width58})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	for _, want := range []string{"Toko Maju Bandung", "Jl. Braga 5", "Telp. 021-555", "Terima kasih"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("Render() missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(string(out), "Merdeka") {
+		t.Errorf("Render() printed the store address:\n%s", out)
+	}
+
+	// The store's own renderer is unchanged
+	out, _ = r.Render(testTransaction(), Options{Format: FormatText, Width: Width58})
+	if !strings.Contains(string(out), "Jl. Merdeka 1") {
+		t.Errorf("Render() missing the store address:\n%s", out)
+	}
+}
@@ -0,0 +1,49 @@
+package receipt
+
+import "strings"
+
+var digitWords = []string{"", "satu", "dua", "tiga", "empat", "lima", "enam", "tujuh", "delapan", "sembilan", "sepuluh", "sebelas"}
+
+// Terbilang spells out an amount in Indonesian words, e.g. 36500 becomes
+// "tiga puluh enam ribu lima ratus"
+func Terbilang(n int) string {
+	if n == 0 {
+		return "nol"
+	}
+	if n < 0 {
+		return "minus " + Terbilang(-n)
+	}
+	return strings.TrimSpace(spell(n))
+}
+
+func spell(n int) string {
+	switch {
+	case n < 12:
+		return digitWords[n]
+	case n < 20:
+		return spell(n-10) + " belas"
+	case n < 100:
+		return join(spell(n/10)+" puluh", spell(n%10))
+	case n < 200:
+		return join("seratus", spell(n-100))
+	case n < 1000:
+		return join(spell(n/100)+" ratus", spell(n%100))
+	case n < 2000:
+		return join("seribu", spell(n-1000))
+	case n < 1_000_000:
+		return join(spell(n/1000)+" ribu", spell(n%1000))
+	case n < 1_000_000_000:
+		return join(spell(n/1_000_000)+" juta", spell(n%1_000_000))
+	case n < 1_000_000_000_000:
+		return join(spell(n/1_000_000_000)+" miliar", spell(n%1_000_000_000))
+	default:
+		return join(spell(n/1_000_000_000_000)+" triliun", spell(n%1_000_000_000_000))
+	}
+}
+
+func join(head, rest string) string {
+	if rest == "" {
+		return head
+	}
+	return head + " " + rest
+}
@@ -3,20 +3,59 @@ package repository
 import (
 	"context"
 	"kasir-api/internal/model"
+	"time"
 )
 
-// ProductReader defines read operations for products
+// Writes through the Writer interfaces and DeviceStore append an entry to the
+// audit log in the same transaction as the change, with the actor and request ID
+// taken from the context. Held carts, which are drafts until checkout, and the
+// login and usage bookkeeping of users and devices are not logged.
+
+// ProductReader defines read operations for products. Product.Stock is the stock
+// held by the outlet of the context. Products are read with their variants, which
+// are also products of their own with ParentID set.
 type ProductReader interface {
 	FindByID(ctx context.Context, id int) (*model.Product, error)
 	FindAll(ctx context.Context) ([]model.Product, error)
 	FindByFilters(ctx context.Context, name string, active *bool) ([]model.Product, error)
 }
 
-// ProductWriter defines write operations for products
+// ProductWriter defines write operations for products. The catalog is shared by
+// all outlets; Product.Stock is written to the outlet of the context only, by
+// posting an adjustment for the difference to the stock ledger. Update keeps the
+// parent and attributes of a variant, and deleting a product deletes its variants.
 type ProductWriter interface {
 	Create(ctx context.Context, p model.Product) (*model.Product, error)
 	Update(ctx context.Context, id int, p model.Product) (*model.Product, error)
 	Delete(ctx context.Context, id int) error
+	// CreateVariant adds a variant to the product, built with Product.NewVariant while
+	// the product is locked. A SKU or barcode already in use returns model.ErrConflict.
+	CreateVariant(ctx context.Context, productID int, req model.ProductVariantRequest) (*model.Product, error)
+}
+
+// StockReader defines read operations for the stock ledger. Every change to a
+// product's stock at an outlet is posted as a movement in the same transaction
+// as the document behind it: sales and restocks by checkout, cancellation and
+// returns, adjustments by product writes and StockWriter.
+type StockReader interface {
+	// FindMovements returns the movements matching the filter, newest first, and
+	// the total number of matches before pagination
+	FindMovements(ctx context.Context, filter model.StockMovementFilter) ([]model.StockMovement, int, error)
+	// CheckLedger recomputes every balance from the ledger and returns the places
+	// where the stock or a movement's balance disagrees with it
+	CheckLedger(ctx context.Context) ([]model.StockDiscrepancy, error)
+	// FindLowStock returns the active products whose stock at the outlet of the
+	// context is at or below their reorder point, lowest stock first
+	FindLowStock(ctx context.Context) ([]model.LowStockItem, error)
+	// FindAlerts returns the newest alerts raised at the outlet of the context by
+	// checkouts that took a product to its reorder point
+	FindAlerts(ctx context.Context, filter model.StockAlertFilter) ([]model.StockAlert, error)
+}
+
+// StockWriter posts movements by hand. All movements are posted or none; one
+// that would take the stock below zero returns model.ErrValidation.
+type StockWriter interface {
+	PostMovements(ctx context.Context, productID int, movements []model.StockMovement) ([]model.StockMovement, error)
 }
 
 // CategoryReader defines read operations for categories
@@ -32,13 +71,258 @@ type CategoryWriter interface {
 	Delete(ctx context.Context, id int) error
 }
 
-// TransactionWriter defines write operations for transactions
+// PromotionReader defines read operations for promotions
+type PromotionReader interface {
+	FindByID(ctx context.Context, id int) (*model.Promotion, error)
+	FindAll(ctx context.Context) ([]model.Promotion, error)
+	FindActive(ctx context.Context, at time.Time) ([]model.Promotion, error)
+}
+
+// PromotionWriter defines write operations for promotions
+type PromotionWriter interface {
+	Create(ctx context.Context, p model.Promotion) (*model.Promotion, error)
+	Update(ctx context.Context, id int, p model.Promotion) (*model.Promotion, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// TransactionReader defines read operations for transactions
+type TransactionReader interface {
+	FindByID(ctx context.Context, id int) (*model.Transaction, error)
+	FindAll(ctx context.Context, filter model.TransactionFilter) ([]model.Transaction, int, error)
+}
+
+// TransactionWriter defines write operations for transactions. Checkout sells from
+// the stock of the outlet of the context; cancellation restocks the outlet that made the sale.
 type TransactionWriter interface {
-	CreateTransaction(ctx context.Context, items []model.CheckoutItem) (*model.Transaction, error)
+	CreateTransaction(ctx context.Context, req model.CheckoutRequest, opts model.CheckoutOptions) (*model.Transaction, error)
+	CancelTransaction(ctx context.Context, id int, status model.TransactionStatus, req model.CancelRequest) (*model.Transaction, error)
+}
+
+// IdempotencyStore persists Idempotency-Key reservations for checkout
+type IdempotencyStore interface {
+	// Reserve claims the key for the request hash until ttl elapses. If the key is
+	// already held by an unexpired record, that record is returned and nothing changes.
+	Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*model.IdempotencyRecord, error)
+	Complete(ctx context.Context, key string, transactionID int) error
+	Release(ctx context.Context, key string) error
+}
+
+// CartReader defines read operations for held carts
+type CartReader interface {
+	FindByID(ctx context.Context, id int) (*model.Cart, error)
+	FindHeld(ctx context.Context, at time.Time) ([]model.Cart, error)
+}
+
+// CartWriter defines write operations for held carts. Changes to the lines or
+// status only succeed while the cart is in the expected state, otherwise
+// model.ErrConflict is returned.
+type CartWriter interface {
+	Create(ctx context.Context, c model.Cart) (*model.Cart, error)
+	SaveItems(ctx context.Context, id int, items []model.CartItem, expiresAt time.Time) error
+	UpdateStatus(ctx context.Context, id int, from, to model.CartStatus, transactionID *int) error
+	Delete(ctx context.Context, id int) error
+}
+
+// ShiftReader defines read operations for cashier shifts. FindByID and FindOpen
+// include the cash movements and the sales summary; FindOpen looks at the outlet
+// of the context. FindOpenID only looks up the ID of that shift, for checkout.
+type ShiftReader interface {
+	FindByID(ctx context.Context, id int) (*model.Shift, error)
+	FindOpen(ctx context.Context) (*model.Shift, error)
+	FindOpenID(ctx context.Context) (int, error)
+	FindAll(ctx context.Context) ([]model.Shift, error)
+}
+
+// ShiftWriter defines write operations for cashier shifts. Open starts a shift at
+// the outlet of the context. Each outlet can have one shift open at a time; opening
+// another or changing a closed one returns model.ErrConflict.
+type ShiftWriter interface {
+	Open(ctx context.Context, req model.OpenShiftRequest) (*model.Shift, error)
+	AddCashMovement(ctx context.Context, shiftID int, req model.CashMovementRequest) (*model.CashMovement, error)
+	Close(ctx context.Context, id int, req model.CloseShiftRequest) (*model.Shift, error)
+}
+
+// StocktakeReader defines read operations for stocktakes. FindByID includes the
+// lines with their variance and the summary; FindAll lists the stocktakes of the
+// outlet of the context without lines.
+type StocktakeReader interface {
+	FindByID(ctx context.Context, id int) (*model.Stocktake, error)
+	FindAll(ctx context.Context) ([]model.Stocktake, error)
+}
+
+// StocktakeWriter defines write operations for stocktakes. Open snapshots the
+// stock at the outlet of the context; each outlet can have one stocktake open at
+// a time. Approve posts the variances to the stock ledger in one go. Opening a
+// second stocktake or changing a closed one returns model.ErrConflict.
+type StocktakeWriter interface {
+	Open(ctx context.Context, req model.OpenStocktakeRequest) (*model.Stocktake, error)
+	SaveCounts(ctx context.Context, id int, req model.StocktakeCountRequest) (*model.Stocktake, error)
+	Approve(ctx context.Context, id int) (*model.Stocktake, error)
+	Cancel(ctx context.Context, id int) (*model.Stocktake, error)
+}
+
+// SupplierReader defines read operations for suppliers
+type SupplierReader interface {
+	FindByID(ctx context.Context, id int) (*model.Supplier, error)
+	FindAll(ctx context.Context) ([]model.Supplier, error)
+}
+
+// SupplierWriter defines write operations for suppliers. Suppliers are not
+// deleted; purchase orders keep referring to them.
+type SupplierWriter interface {
+	Create(ctx context.Context, s model.Supplier) (*model.Supplier, error)
+	Update(ctx context.Context, id int, s model.Supplier) (*model.Supplier, error)
+}
+
+// PurchaseOrderReader defines read operations for purchase orders. Orders come
+// with their items, receipts and totals; FindAll lists the orders of the outlet
+// of the context matching the filter, newest first.
+type PurchaseOrderReader interface {
+	FindByID(ctx context.Context, id int) (*model.PurchaseOrder, error)
+	FindAll(ctx context.Context, filter model.PurchaseOrderFilter) ([]model.PurchaseOrder, error)
+}
+
+// PurchaseOrderWriter defines write operations for purchase orders. Only a draft
+// can be updated or sent. Receive posts the delivered stock at the order's outlet
+// and takes the receipt's unit cost as the product's cost, all in one go. A
+// change the order's status does not allow returns model.ErrConflict.
+type PurchaseOrderWriter interface {
+	Create(ctx context.Context, po model.PurchaseOrder) (*model.PurchaseOrder, error)
+	Update(ctx context.Context, id int, po model.PurchaseOrder) (*model.PurchaseOrder, error)
+	Send(ctx context.Context, id int) (*model.PurchaseOrder, error)
+	Receive(ctx context.Context, id int, req model.GoodsReceiptRequest) (*model.PurchaseOrder, error)
+	Close(ctx context.Context, id int) (*model.PurchaseOrder, error)
+}
+
+// PurchaseReturnReader defines read operations for purchase returns. Returns come
+// with their items and credit totals; FindAll lists the returns of the outlet of
+// the context matching the filter, newest first.
+type PurchaseReturnReader interface {
+	FindByID(ctx context.Context, id int) (*model.PurchaseReturn, error)
+	FindAll(ctx context.Context, filter model.PurchaseReturnFilter) ([]model.PurchaseReturn, error)
+}
+
+// PurchaseReturnWriter defines write operations for purchase returns. Create takes
+// the returned stock out of the outlet of the context in the same go, refusing to
+// return more than the outlet holds or, against a goods receipt, more than the
+// receipt has left to return. RecordCredit on a credited return returns
+// model.ErrConflict.
+type PurchaseReturnWriter interface {
+	Create(ctx context.Context, req model.PurchaseReturnRequest) (*model.PurchaseReturn, error)
+	RecordCredit(ctx context.Context, id int, req model.SupplierCreditRequest) (*model.PurchaseReturn, error)
+}
+
+// CustomerReader defines read operations for the customer directory. FindAll
+// leaves out erased customers and matches search against name, phone and member code.
+type CustomerReader interface {
+	FindByID(ctx context.Context, id int) (*model.Customer, error)
+	FindAll(ctx context.Context, search string) ([]model.Customer, error)
+	FindLedger(ctx context.Context, customerID int) ([]model.LoyaltyEntry, error)
+}
+
+// CustomerWriter defines write operations for the customer directory. A phone
+// number or member code already in use returns model.ErrConflict. Points change
+// only through checkout and cancellation, never through Update.
+type CustomerWriter interface {
+	Create(ctx context.Context, c model.Customer) (*model.Customer, error)
+	Update(ctx context.Context, id int, c model.Customer) (*model.Customer, error)
+	Erase(ctx context.Context, id int) (*model.Customer, error)
+}
+
+// OutletReader defines read operations for outlets
+type OutletReader interface {
+	FindByID(ctx context.Context, id int) (*model.Outlet, error)
+	FindAll(ctx context.Context) ([]model.Outlet, error)
+}
+
+// OutletWriter defines write operations for outlets. A code already used by another
+// outlet returns model.ErrConflict. There is no Delete; outlets are deactivated instead.
+type OutletWriter interface {
+	Create(ctx context.Context, o model.Outlet) (*model.Outlet, error)
+	Update(ctx context.Context, id int, o model.Outlet) (*model.Outlet, error)
+}
+
+// UserReader defines read operations for users. FindByUsername is used to sign
+// in and includes the password hash.
+type UserReader interface {
+	FindByID(ctx context.Context, id int) (*model.User, error)
+	FindByUsername(ctx context.Context, username string) (*model.User, error)
+	FindAll(ctx context.Context) ([]model.User, error)
+	Count(ctx context.Context) (int, error)
+}
+
+// UserWriter defines write operations for users. A username already in use
+// returns model.ErrConflict. Users are deactivated rather than deleted, and the
+// last active owner can be neither deactivated nor demoted.
+type UserWriter interface {
+	Create(ctx context.Context, u model.User) (*model.User, error)
+	// Update saves the profile, password hash and lockout state of the user; the
+	// role only changes through SetRole
+	Update(ctx context.Context, id int, u model.User) (*model.User, error)
+	SetRole(ctx context.Context, id int, role model.Role) (*model.User, error)
+	// RecordLoginFailure counts a failed login under the policy and returns the
+	// user as it is afterwards, which may be locked
+	RecordLoginFailure(ctx context.Context, id int, policy model.LockoutPolicy, at time.Time) (*model.User, error)
+	// RecordLogin clears failed logins and any lockout and stamps the login time
+	RecordLogin(ctx context.Context, id int, at time.Time) error
+}
+
+// SessionStore persists sign-in sessions and their refresh tokens
+type SessionStore interface {
+	// Create saves a new session together with its first refresh token
+	Create(ctx context.Context, s model.Session, refresh model.RefreshToken) error
+	FindByID(ctx context.Context, id string) (*model.Session, error)
+	// Rotate marks the refresh token with the given hash as used, saves next in the
+	// same session and extends the session to next's expiry, returning the session. A token that was already used
+	// revokes its session; unknown or expired tokens and inactive sessions return
+	// model.ErrUnauthorized.
+	Rotate(ctx context.Context, tokenHash string, next model.RefreshToken, at time.Time) (*model.Session, error)
+	Revoke(ctx context.Context, id string, at time.Time) error
+	// RevokeAllForUser revokes every active session of the user
+	RevokeAllForUser(ctx context.Context, userID int, at time.Time) error
+}
+
+// DeviceStore persists POS devices with the hashes of their pairing codes and API
+// keys. Devices are revoked rather than deleted, so their usage stays visible.
+type DeviceStore interface {
+	// Create saves a device waiting to be paired with its pairing code hash
+	Create(ctx context.Context, d model.Device) (*model.Device, error)
+	FindByID(ctx context.Context, id int) (*model.Device, error)
+	FindAll(ctx context.Context) ([]model.Device, error)
+	// Pair stores the key hash on the device whose pairing code has the given hash
+	// and clears the code, so each code works once. Unknown, expired, used or
+	// revoked codes return model.ErrUnauthorized.
+	Pair(ctx context.Context, codeHash, keyHash, keyPrefix string, at time.Time) (*model.Device, error)
+	FindByKeyHash(ctx context.Context, keyHash string) (*model.Device, error)
+	// RecordUse stamps the time the device was last seen and counts the request
+	RecordUse(ctx context.Context, id int, at time.Time) error
+	Revoke(ctx context.Context, id int, at time.Time) (*model.Device, error)
+}
+
+// ReturnReader defines read operations for customer returns
+type ReturnReader interface {
+	FindByID(ctx context.Context, id int) (*model.Return, error)
+	FindByTransactionID(ctx context.Context, transactionID int) ([]model.Return, error)
+}
+
+// ReturnWriter defines write operations for customer returns
+type ReturnWriter interface {
+	CreateReturn(ctx context.Context, transactionID int, req model.ReturnRequest) (*model.Return, error)
+}
+
+// AuditReader defines read operations for the audit log. The log is append-only:
+// entries are written by the repositories as part of each change, and there is
+// no way to change or remove them.
+type AuditReader interface {
+	// FindAll returns the entries matching the filter, newest first, and the
+	// total number of matches before pagination
+	FindAll(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, int, error)
 }
 
-// ReportReader defines read operations for reports
+// ReportReader defines read operations for reports. A context scoped to an outlet
+// reports that outlet only; otherwise the report consolidates all outlets.
 type ReportReader interface {
 	GetTodayReport(ctx context.Context) (*model.ReportSummary, error)
 	GetReportByDateRange(ctx context.Context, startDate, endDate string) (*model.ReportSummary, error)
+	GetInventoryValuation(ctx context.Context) (*model.InventoryValuation, error)
 }
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"kasir-api/internal/model"
+)
+
+// AuditRepository keeps the audit log. The other repositories append to it while
+// holding their own lock, so an entry is stored together with its change. There
+// is deliberately no way to change or remove an entry.
+type AuditRepository struct {
+	mu     sync.RWMutex
+	data   []model.AuditEntry
+	nextID int
+}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{
+		data:   make([]model.AuditEntry, 0),
+		nextID: 1,
+	}
+}
+
+func (r *AuditRepository) FindAll(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matched := make([]model.AuditEntry, 0)
+	for _, e := range r.data {
+		if filter.Matches(e) {
+			matched = append(matched, e)
+		}
+	}
+
+	// Newest first, same as the PostgreSQL implementation
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].ID > matched[j].ID
+	})
+
+	total := len(matched)
+	start := min(filter.Offset(), total)
+	end := total
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, total)
+	}
+
+	return matched[start:end], total, nil
+}
+
+// record appends an entry for a change to the log. It does nothing on a nil
+// repository, so the other repositories work without an audit log wired in.
+func (r *AuditRepository) record(ctx context.Context, entity model.AuditEntity, entityID int, action model.AuditAction, before, after any) error {
+	if r == nil {
+		return nil
+	}
+
+	entry, err := model.NewAuditEntry(ctx, entity, entityID, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.ID = r.nextID
+	r.nextID++
+	r.data = append(r.data, entry)
+	return nil
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/middleware"
+)
+
+func TestAuditRepository_RecordsProductChanges(t *testing.T) {
+	audit := NewAuditRepository()
+	repo := NewProductRepository()
+	repo.SetAuditLog(audit)
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDCtxKey, "req-7")
+	ctx = middleware.WithPrincipal(ctx, &middleware.Principal{UserID: 3, Username: "siti", Role: string(model.RoleManager)})
+
+	created, _ := repo.Create(ctx, model.Product{Name: "Indomie", Price: 3500})
+	updated := *created
+	updated.Price = 4000
+	if _, err := repo.Update(ctx, created.ID, updated); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := repo.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	entries, total, err := audit.FindAll(ctx, model.AuditFilter{Entity: string(model.AuditEntityProduct)}.WithDefaults())
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if total != 3 || len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", total)
+	}
+
+	// Newest first
+	if entries[0].Action != model.AuditActionDelete || entries[1].Action != model.AuditActionUpdate || entries[2].Action != model.AuditActionCreate {
+		t.Errorf("Unexpected actions: %s, %s, %s", entries[0].Action, entries[1].Action, entries[2].Action)
+	}
+
+	update := entries[1]
+	if update.ActorType != model.AuditActorUser || update.ActorID != 3 || update.Actor != "siti" || update.RequestID != "req-7" {
+		t.Errorf("Unexpected actor or request: %+v", update)
+	}
+	if len(update.Changes) != 1 {
+		t.Fatalf("Expected only price to change, got %v", update.Changes)
+	}
+	if update.Changes["price"].Before != json.Number("3500") || update.Changes["price"].After != json.Number("4000") {
+		t.Errorf("Unexpected price change: %+v", update.Changes["price"])
+	}
+}
+
+func TestAuditRepository_FindAll_Filter(t *testing.T) {
+	audit := NewAuditRepository()
+	categories := NewCategoryRepository()
+	categories.SetAuditLog(audit)
+	ctx := context.Background()
+
+	first, _ := categories.Create(ctx, model.Category{Name: "Makanan"})
+	categories.Create(ctx, model.Category{Name: "Minuman"})
+	categories.Update(ctx, first.ID, model.Category{Name: "Makanan Ringan"})
+
+	entries, total, _ := audit.FindAll(ctx, model.AuditFilter{Entity: "category", EntityID: &first.ID}.WithDefaults())
+	if total != 2 || len(entries) != 2 {
+		t.Fatalf("Expected 2 entries for category %d, got %d", first.ID, total)
+	}
+
+	entries, total, _ = audit.FindAll(ctx, model.AuditFilter{Page: 2, Limit: 2})
+	if total != 3 || len(entries) != 1 || entries[0].Action != model.AuditActionCreate || entries[0].EntityID != first.ID {
+		t.Errorf("Unexpected second page: total %d, entries %+v", total, entries)
+	}
+}
+
+func TestAuditRepository_NotWiredRecordsNothing(t *testing.T) {
+	repo := NewCategoryRepository()
+	if _, err := repo.Create(context.Background(), model.Category{Name: "Makanan"}); err != nil {
+		t.Fatalf("Create() without an audit log error = %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type CartRepository struct {
+	mu     sync.RWMutex
+	data   []model.Cart
+	nextID int
+	audit  *AuditRepository
+}
+
+func NewCartRepository() *CartRepository {
+	return &CartRepository{
+		data:   make([]model.Cart, 0),
+		nextID: 1,
+	}
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *CartRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
+func (r *CartRepository) FindByID(ctx context.Context, id int) (*model.Cart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	result := copyCart(r.data[idx])
+	return &result, nil
+}
+
+func (r *CartRepository) FindHeld(ctx context.Context, at time.Time) ([]model.Cart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	carts := make([]model.Cart, 0)
+	for _, c := range r.data {
+		if c.Status == model.CartStatusHeld && !c.IsExpired(at) {
+			carts = append(carts, copyCart(c))
+		}
+	}
+	return carts, nil
+}
+
+func (r *CartRepository) Create(ctx context.Context, c model.Cart) (*model.Cart, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	c.ID = r.nextID
+	c.Status = model.CartStatusHeld
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	if err := r.audit.record(ctx, model.AuditEntityCart, c.ID, model.AuditActionCreate, nil, c); err != nil {
+		return nil, err
+	}
+	r.nextID++
+	r.data = append(r.data, copyCart(c))
+
+	result := copyCart(c)
+	return &result, nil
+}
+
+func (r *CartRepository) SaveItems(ctx context.Context, id int, items []model.CartItem, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return model.ErrNotFound
+	}
+	cart := &r.data[idx]
+
+	now := time.Now()
+	if cart.Status != model.CartStatusHeld || cart.IsExpired(now) {
+		return model.CartNotHeldError(*cart, now)
+	}
+
+	after := copyCart(*cart)
+	after.Items = make([]model.CartItem, len(items))
+	copy(after.Items, items)
+	after.UpdatedAt = now
+	after.ExpiresAt = expiresAt
+	if err := r.audit.record(ctx, model.AuditEntityCart, id, model.AuditActionUpdate, *cart, after); err != nil {
+		return err
+	}
+	*cart = after
+	return nil
+}
+
+func (r *CartRepository) UpdateStatus(ctx context.Context, id int, from, to model.CartStatus, transactionID *int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return model.ErrNotFound
+	}
+	cart := &r.data[idx]
+
+	now := time.Now()
+	if cart.Status != from || cart.IsExpired(now) {
+		return fmt.Errorf("%w: cart %d is %s, expected %s", model.ErrConflict, id, cart.Status, from)
+	}
+
+	after := copyCart(*cart)
+	after.Status = to
+	after.TransactionID = transactionID
+	after.UpdatedAt = now
+	if err := r.audit.record(ctx, model.AuditEntityCart, id, model.AuditActionUpdate, *cart, after); err != nil {
+		return err
+	}
+	*cart = after
+	return nil
+}
+
+func (r *CartRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return model.ErrNotFound
+	}
+	if err := r.audit.record(ctx, model.AuditEntityCart, id, model.AuditActionDelete, r.data[idx], nil); err != nil {
+		return err
+	}
+	r.data = append(r.data[:idx], r.data[idx+1:]...)
+	return nil
+}
+
+// indexOf returns the slice index of the cart with the given ID, or -1.
+// Callers must hold r.mu.
+func (r *CartRepository) indexOf(id int) int {
+	for i := range r.data {
+		if r.data[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyCart returns a copy that does not share the items slice with the store
+func copyCart(c model.Cart) model.Cart {
+	items := make([]model.CartItem, len(c.Items))
+	copy(items, c.Items)
+	c.Items = items
+	return c
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+func TestCartRepository_FindHeld_SkipsExpired(t *testing.T) {
+	repo := NewCartRepository()
+	ctx := context.Background()
+	now := time.Now()
+
+	repo.Create(ctx, model.Cart{Label: "Table 1", ExpiresAt: now.Add(time.Hour)})
+	repo.Create(ctx, model.Cart{Label: "Table 2", ExpiresAt: now.Add(-time.Minute)})
+
+	held, err := repo.FindHeld(ctx, now)
+	if err != nil {
+		t.Fatalf("FindHeld() error = %v", err)
+	}
+	if len(held) != 1 || held[0].Label != "Table 1" {
+		t.Errorf("FindHeld() = %+v, want only Table 1", held)
+	}
+
+	err = repo.SaveItems(ctx, 2, []model.CartItem{{ProductID: 1, Quantity: 1}}, now.Add(time.Hour))
+	if !model.IsConflictError(err) {
+		t.Errorf("SaveItems() on expired cart error = %v, want conflict", err)
+	}
+}
+
+func TestCartRepository_UpdateStatus(t *testing.T) {
+	repo := NewCartRepository()
+	ctx := context.Background()
+
+	cart, _ := repo.Create(ctx, model.Cart{ExpiresAt: time.Now().Add(time.Hour)})
+
+	if err := repo.UpdateStatus(ctx, cart.ID, model.CartStatusHeld, model.CartStatusCheckingOut, nil); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+
+	// A second terminal trying to claim the same cart must lose
+	err := repo.UpdateStatus(ctx, cart.ID, model.CartStatusHeld, model.CartStatusCheckingOut, nil)
+	if !model.IsConflictError(err) {
+		t.Errorf("UpdateStatus() second claim error = %v, want conflict", err)
+	}
+
+	transactionID := 7
+	if err := repo.UpdateStatus(ctx, cart.ID, model.CartStatusCheckingOut, model.CartStatusCheckedOut, &transactionID); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+
+	found, _ := repo.FindByID(ctx, cart.ID)
+	if found.Status != model.CartStatusCheckedOut || found.TransactionID == nil || *found.TransactionID != 7 {
+		t.Errorf("FindByID() = %+v, want checked_out with transaction 7", found)
+	}
+}
+
+func TestCartRepository_RecordsAudit(t *testing.T) {
+	audit := NewAuditRepository()
+	repo := NewCartRepository()
+	repo.SetAuditLog(audit)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	cart, _ := repo.Create(ctx, model.Cart{Label: "Table 1", ExpiresAt: expiresAt})
+	if err := repo.SaveItems(ctx, cart.ID, []model.CartItem{{ProductID: 1, Quantity: 2}}, expiresAt); err != nil {
+		t.Fatalf("SaveItems() error = %v", err)
+	}
+	if err := repo.UpdateStatus(ctx, cart.ID, model.CartStatusHeld, model.CartStatusCheckingOut, nil); err != nil {
+		t.Fatalf("UpdateStatus() error = %v", err)
+	}
+	if err := repo.Delete(ctx, cart.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	entries, total, _ := audit.FindAll(ctx, model.AuditFilter{Entity: string(model.AuditEntityCart), EntityID: &cart.ID}.WithDefaults())
+	if total != 4 {
+		t.Fatalf("Expected 4 entries, got %d", total)
+	}
+	if entries[0].Action != model.AuditActionDelete || entries[3].Action != model.AuditActionCreate {
+		t.Errorf("Unexpected actions: %s first, %s last", entries[0].Action, entries[3].Action)
+	}
+	if _, ok := entries[2].Changes["items"]; !ok {
+		t.Errorf("Saving items changes = %v, want items", entries[2].Changes)
+	}
+	if change := entries[1].Changes["status"]; change.Before != string(model.CartStatusHeld) || change.After != string(model.CartStatusCheckingOut) {
+		t.Errorf("Status change = %+v", change)
+	}
+}
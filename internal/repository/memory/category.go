@@ -8,9 +8,11 @@ import (
 )
 
 type CategoryRepository struct {
-	mu     sync.RWMutex
-	data   []model.Category
-	nextID int
+	mu         sync.RWMutex
+	data       []model.Category
+	nextID     int
+	promotions *PromotionRepository
+	audit      *AuditRepository
 }
 
 func NewCategoryRepository() *CategoryRepository {
@@ -20,6 +22,16 @@ func NewCategoryRepository() *CategoryRepository {
 	}
 }
 
+// SetPromotionRepo wires the promotions that keep their categories from being deleted
+func (r *CategoryRepository) SetPromotionRepo(promotions *PromotionRepository) {
+	r.promotions = promotions
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *CategoryRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
 func (r *CategoryRepository) FindByID(ctx context.Context, id int) (*model.Category, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -44,6 +56,9 @@ func (r *CategoryRepository) Create(ctx context.Context, c model.Category) (*mod
 	defer r.mu.Unlock()
 
 	c.ID = r.nextID
+	if err := r.audit.record(ctx, model.AuditEntityCategory, c.ID, model.AuditActionCreate, nil, c); err != nil {
+		return nil, err
+	}
 	r.nextID++
 	r.data = append(r.data, c)
 	return &c, nil
@@ -56,6 +71,9 @@ func (r *CategoryRepository) Update(ctx context.Context, id int, c model.Categor
 	for i := range r.data {
 		if r.data[i].ID == id {
 			c.ID = id
+			if err := r.audit.record(ctx, model.AuditEntityCategory, id, model.AuditActionUpdate, r.data[i], c); err != nil {
+				return nil, err
+			}
 			r.data[i] = c
 			return &c, nil
 		}
@@ -69,6 +87,17 @@ func (r *CategoryRepository) Delete(ctx context.Context, id int) error {
 
 	for i, c := range r.data {
 		if c.ID == id {
+			if r.promotions != nil {
+				r.promotions.mu.RLock()
+				promoted := r.promotions.targets(nil, id)
+				r.promotions.mu.RUnlock()
+				if promoted {
+					return model.CategoryPromotedError(id)
+				}
+			}
+			if err := r.audit.record(ctx, model.AuditEntityCategory, id, model.AuditActionDelete, c, nil); err != nil {
+				return err
+			}
 			r.data = append(r.data[:i], r.data[i+1:]...)
 			return nil
 		}
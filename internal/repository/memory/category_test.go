@@ -119,6 +119,23 @@ func TestCategoryRepository_Delete(t *testing.T) {
 	}
 }
 
+func TestCategoryRepository_Delete_Promoted(t *testing.T) {
+	repo := NewCategoryRepository()
+	promotions := NewPromotionRepository()
+	repo.SetPromotionRepo(promotions)
+	ctx := context.Background()
+
+	created, _ := repo.Create(ctx, model.Category{Name: "Minuman"})
+	promotions.Create(ctx, model.Promotion{Name: "Drinks 10%", Type: model.PromotionTypePercent, Value: 10, CategoryID: &created.ID, Active: true})
+
+	if err := repo.Delete(ctx, created.ID); !model.IsConflictError(err) {
+		t.Errorf("Delete() of a promoted category error = %v, want conflict", err)
+	}
+	if _, err := repo.FindByID(ctx, created.ID); err != nil {
+		t.Errorf("FindByID() error = %v, want the category kept", err)
+	}
+}
+
 func TestCategoryRepository_Delete_NotFound(t *testing.T) {
 	repo := NewCategoryRepository()
 	ctx := context.Background()
@@ -0,0 +1,218 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type CustomerRepository struct {
+	mu          sync.RWMutex
+	data        []model.Customer
+	ledger      []model.LoyaltyEntry
+	nextID      int
+	nextEntryID int
+	audit       *AuditRepository
+}
+
+func NewCustomerRepository() *CustomerRepository {
+	return &CustomerRepository{
+		data:        make([]model.Customer, 0),
+		ledger:      make([]model.LoyaltyEntry, 0),
+		nextID:      1,
+		nextEntryID: 1,
+	}
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *CustomerRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
+func (r *CustomerRepository) FindByID(ctx context.Context, id int) (*model.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	result := r.data[idx]
+	return &result, nil
+}
+
+func (r *CustomerRepository) FindAll(ctx context.Context, search string) ([]model.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	search = strings.ToLower(search)
+	phone := model.NormalizePhone(search)
+
+	results := make([]model.Customer, 0)
+	for _, c := range r.data {
+		if c.IsErased() {
+			continue
+		}
+		if search != "" &&
+			!strings.Contains(strings.ToLower(c.Name), search) &&
+			!strings.Contains(strings.ToLower(c.MemberCode), search) &&
+			(phone == "" || !strings.Contains(c.Phone, phone)) {
+			continue
+		}
+		results = append(results, c)
+	}
+	return results, nil
+}
+
+func (r *CustomerRepository) FindLedger(ctx context.Context, customerID int) ([]model.LoyaltyEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.indexOf(customerID) < 0 {
+		return nil, model.ErrNotFound
+	}
+
+	entries := make([]model.LoyaltyEntry, 0)
+	for _, e := range r.ledger {
+		if e.CustomerID == customerID {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (r *CustomerRepository) Create(ctx context.Context, c model.Customer) (*model.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c.ID = r.nextID
+	if c.MemberCode == "" {
+		c.MemberCode = model.MemberCodeFor(c.ID)
+	}
+	if err := r.checkUnique(c); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	c.Points = 0
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	c.ErasedAt = nil
+	if err := r.audit.record(ctx, model.AuditEntityCustomer, c.ID, model.AuditActionCreate, nil, c.AuditRecord()); err != nil {
+		return nil, err
+	}
+	r.nextID++
+	r.data = append(r.data, c)
+	return &c, nil
+}
+
+func (r *CustomerRepository) Update(ctx context.Context, id int, c model.Customer) (*model.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	existing := &r.data[idx]
+	if existing.IsErased() {
+		return nil, model.CustomerErasedError(id)
+	}
+
+	c.ID = id
+	if c.MemberCode == "" {
+		c.MemberCode = existing.MemberCode
+	}
+	if err := r.checkUnique(c); err != nil {
+		return nil, err
+	}
+
+	updated := *existing
+	updated.Name = c.Name
+	updated.Phone = c.Phone
+	updated.Email = c.Email
+	updated.MemberCode = c.MemberCode
+	updated.Notes = c.Notes
+	updated.UpdatedAt = time.Now()
+	if err := r.audit.record(ctx, model.AuditEntityCustomer, id, model.AuditActionUpdate, existing.AuditRecord(), updated.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	*existing = updated
+	result := updated
+	return &result, nil
+}
+
+func (r *CustomerRepository) Erase(ctx context.Context, id int) (*model.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	if r.data[idx].IsErased() {
+		return nil, model.CustomerErasedError(id)
+	}
+
+	erased := r.data[idx]
+	erased.Erase(time.Now())
+	if err := r.audit.record(ctx, model.AuditEntityCustomer, id, model.AuditActionErase, r.data[idx].AuditRecord(), erased.AuditRecord()); err != nil {
+		return nil, err
+	}
+	r.data[idx] = erased
+	result := erased
+	return &result, nil
+}
+
+// checkUnique returns a conflict when another customer already has c's phone or
+// member code. Callers must hold r.mu.
+func (r *CustomerRepository) checkUnique(c model.Customer) error {
+	for _, other := range r.data {
+		if other.ID == c.ID {
+			continue
+		}
+		if c.Phone != "" && other.Phone == c.Phone {
+			return model.PhoneTakenError(c.Phone)
+		}
+		if c.MemberCode != "" && other.MemberCode == c.MemberCode {
+			return model.MemberCodeTakenError(c.MemberCode)
+		}
+	}
+	return nil
+}
+
+// addPoints changes the customer's balance and records the ledger entry.
+// Callers must hold r.mu and pass a valid index.
+func (r *CustomerRepository) addPoints(idx int, entryType model.LoyaltyEntryType, points int, transactionID int, at time.Time) {
+	if points == 0 {
+		return
+	}
+	c := &r.data[idx]
+	c.Points += points
+
+	r.ledger = append(r.ledger, model.LoyaltyEntry{
+		ID:            r.nextEntryID,
+		CustomerID:    c.ID,
+		TransactionID: &transactionID,
+		Type:          entryType,
+		Points:        points,
+		Balance:       c.Points,
+		CreatedAt:     at,
+	})
+	r.nextEntryID++
+}
+
+// indexOf returns the slice index of the customer with the given ID, or -1.
+// Callers must hold r.mu.
+func (r *CustomerRepository) indexOf(id int) int {
+	for i := range r.data {
+		if r.data[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+)
+
+func TestCustomerRepository_UniquePhone(t *testing.T) {
+	repo := NewCustomerRepository()
+	ctx := context.Background()
+
+	budi, err := repo.Create(ctx, model.Customer{Name: "Budi", Phone: "081234567890"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if budi.MemberCode != "MBR000001" {
+		t.Errorf("MemberCode = %q, want MBR000001", budi.MemberCode)
+	}
+
+	if _, err := repo.Create(ctx, model.Customer{Name: "Siti", Phone: "081234567890"}); !model.IsConflictError(err) {
+		t.Errorf("Create() with taken phone error = %v, want conflict", err)
+	}
+
+	siti, _ := repo.Create(ctx, model.Customer{Name: "Siti", Phone: "081111111111"})
+	if _, err := repo.Update(ctx, siti.ID, model.Customer{Name: "Siti", Phone: "081234567890"}); !model.IsConflictError(err) {
+		t.Errorf("Update() to taken phone error = %v, want conflict", err)
+	}
+
+	// Erasing frees the phone number and hides the customer from the directory
+	erased, err := repo.Erase(ctx, budi.ID)
+	if err != nil {
+		t.Fatalf("Erase() error = %v", err)
+	}
+	if erased.Name != "" || erased.Phone != "" || !erased.IsErased() {
+		t.Errorf("Erase() = %+v, want personal data cleared", erased)
+	}
+	if _, err := repo.Create(ctx, model.Customer{Name: "Andi", Phone: "081234567890"}); err != nil {
+		t.Errorf("Create() with phone of erased customer error = %v", err)
+	}
+	if _, err := repo.Update(ctx, budi.ID, model.Customer{Name: "Budi"}); !model.IsConflictError(err) {
+		t.Errorf("Update() of erased customer error = %v, want conflict", err)
+	}
+
+	found, _ := repo.FindAll(ctx, "0812")
+	if len(found) != 1 || found[0].Name != "Andi" {
+		t.Errorf("FindAll() = %+v, want only Andi", found)
+	}
+
+	// A search without digits does not match every phone number
+	if found, _ := repo.FindAll(ctx, "-"); len(found) != 0 {
+		t.Errorf("FindAll(-) = %+v, want none", found)
+	}
+}
+
+func TestTransactionRepository_CreateTransaction_Loyalty(t *testing.T) {
+	repo, _ := newTestTransactionRepo(t)
+	customerRepo := NewCustomerRepository()
+	repo.SetCustomerRepo(customerRepo)
+	ctx := context.Background()
+
+	customer, _ := customerRepo.Create(ctx, model.Customer{Name: "Budi"})
+	opts := model.CheckoutOptions{Loyalty: model.LoyaltyRule{SpendPerPoint: 1000, PointValue: 100}}
+
+	first, err := repo.CreateTransaction(ctx, model.CheckoutRequest{
+		Items:      []model.CheckoutItem{{ProductID: 1, Quantity: 2}},
+		CustomerID: &customer.ID,
+	}, opts)
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+	if first.PointsEarned != 7 {
+		t.Errorf("PointsEarned = %d, want 7", first.PointsEarned)
+	}
+
+	_, err = repo.CreateTransaction(ctx, model.CheckoutRequest{
+		Items:        []model.CheckoutItem{{ProductID: 2, Quantity: 1}},
+		CustomerID:   &customer.ID,
+		RedeemPoints: 8,
+	}, opts)
+	if !model.IsValidationError(err) {
+		t.Errorf("CreateTransaction() redeeming more than the balance error = %v, want validation error", err)
+	}
+
+	second, err := repo.CreateTransaction(ctx, model.CheckoutRequest{
+		Items:        []model.CheckoutItem{{ProductID: 2, Quantity: 1}},
+		CustomerID:   &customer.ID,
+		RedeemPoints: 5,
+	}, opts)
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+	// 5000 - 500 for the points, earning 4 points on the 4500 paid
+	if second.TotalAmount != 4500 || second.PointsDiscount != 500 || second.PointsEarned != 4 {
+		t.Errorf("Transaction = total %d, points discount %d, earned %d, want 4500, 500, 4",
+			second.TotalAmount, second.PointsDiscount, second.PointsEarned)
+	}
+
+	found, _ := customerRepo.FindByID(ctx, customer.ID)
+	if found.Points != 6 {
+		t.Errorf("Points = %d, want 6", found.Points)
+	}
+
+	if _, err := repo.CancelTransaction(ctx, second.ID, model.TransactionStatusVoided, model.CancelRequest{Reason: "mistake"}); err != nil {
+		t.Fatalf("CancelTransaction() error = %v", err)
+	}
+	found, _ = customerRepo.FindByID(ctx, customer.ID)
+	if found.Points != 7 {
+		t.Errorf("Points after void = %d, want 7", found.Points)
+	}
+
+	ledger, _ := customerRepo.FindLedger(ctx, customer.ID)
+	if len(ledger) != 4 || ledger[3].Type != model.LoyaltyEntryReversal || ledger[3].Points != 1 || ledger[3].Balance != 7 {
+		t.Errorf("Ledger = %+v, want earn, redeem, earn, reversal of +1", ledger)
+	}
+
+	history, total, _ := repo.FindAll(ctx, model.TransactionFilter{CustomerID: &customer.ID})
+	if total != 2 || len(history) != 2 {
+		t.Errorf("FindAll() by customer = %d transactions, want 2", total)
+	}
+}
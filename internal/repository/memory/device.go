@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type DeviceRepository struct {
+	mu     sync.Mutex
+	data   []model.Device
+	nextID int
+	audit  *AuditRepository
+}
+
+func NewDeviceRepository() *DeviceRepository {
+	return &DeviceRepository{
+		data:   make([]model.Device, 0),
+		nextID: 1,
+	}
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *DeviceRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
+func (r *DeviceRepository) Create(ctx context.Context, d model.Device) (*model.Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d.ID = r.nextID
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = d.CreatedAt
+	if err := r.audit.record(ctx, model.AuditEntityDevice, d.ID, model.AuditActionCreate, nil, d); err != nil {
+		return nil, err
+	}
+	r.nextID++
+	r.data = append(r.data, d)
+	return &d, nil
+}
+
+func (r *DeviceRepository) FindByID(ctx context.Context, id int) (*model.Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	result := r.data[idx]
+	return &result, nil
+}
+
+func (r *DeviceRepository) FindAll(ctx context.Context) ([]model.Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]model.Device, len(r.data))
+	copy(results, r.data)
+	return results, nil
+}
+
+func (r *DeviceRepository) Pair(ctx context.Context, codeHash, keyHash, keyPrefix string, at time.Time) (*model.Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.data {
+		d := &r.data[i]
+		if d.PairingCodeHash != codeHash {
+			continue
+		}
+		if !d.CanPair(at) {
+			return nil, model.InvalidPairingCodeError()
+		}
+		paired := *d
+		paired.PairingCodeHash = ""
+		paired.KeyHash = keyHash
+		paired.KeyPrefix = keyPrefix
+		paired.PairedAt = &at
+		paired.UpdatedAt = at
+		if err := r.audit.record(ctx, model.AuditEntityDevice, d.ID, model.AuditActionPair, *d, paired); err != nil {
+			return nil, err
+		}
+		*d = paired
+		return &paired, nil
+	}
+	return nil, model.InvalidPairingCodeError()
+}
+
+func (r *DeviceRepository) FindByKeyHash(ctx context.Context, keyHash string) (*model.Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range r.data {
+		if d.KeyHash != "" && d.KeyHash == keyHash {
+			return &d, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+func (r *DeviceRepository) RecordUse(ctx context.Context, id int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return model.ErrNotFound
+	}
+	r.data[idx].LastSeenAt = &at
+	r.data[idx].RequestCount++
+	return nil
+}
+
+func (r *DeviceRepository) Revoke(ctx context.Context, id int, at time.Time) (*model.Device, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	d := &r.data[idx]
+	if d.RevokedAt == nil {
+		revoked := *d
+		revoked.RevokedAt = &at
+		revoked.PairingCodeHash = ""
+		revoked.UpdatedAt = at
+		if err := r.audit.record(ctx, model.AuditEntityDevice, id, model.AuditActionRevoke, *d, revoked); err != nil {
+			return nil, err
+		}
+		*d = revoked
+	}
+	result := *d
+	return &result, nil
+}
+
+// indexOf returns the slice index of the device with the given ID, or -1.
+// Callers must hold r.mu.
+func (r *DeviceRepository) indexOf(id int) int {
+	for i, d := range r.data {
+		if d.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type IdempotencyRepository struct {
+	mu   sync.Mutex
+	data map[string]model.IdempotencyRecord
+}
+
+func NewIdempotencyRepository() *IdempotencyRepository {
+	return &IdempotencyRepository{
+		data: make(map[string]model.IdempotencyRecord),
+	}
+}
+
+func (r *IdempotencyRepository) Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*model.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	// Drop expired keys so the map does not grow without bound
+	for k, record := range r.data {
+		if !record.ExpiresAt.After(now) {
+			delete(r.data, k)
+		}
+	}
+
+	if record, exists := r.data[key]; exists {
+		return &record, nil
+	}
+
+	r.data[key] = model.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	return nil, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, transactionID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, exists := r.data[key]
+	if !exists {
+		return model.IdempotencyReservationLostError()
+	}
+	record.TransactionID = &transactionID
+	r.data[key] = record
+	return nil
+}
+
+// checkClaim reports whether the claimed key is still reserved for its request
+// and not yet completed. Callers must hold r.mu.
+func (r *IdempotencyRepository) checkClaim(claim model.IdempotencyClaim) error {
+	record, exists := r.data[claim.Key]
+	if !exists || record.RequestHash != claim.RequestHash || record.TransactionID != nil {
+		return model.IdempotencyReservationLostError()
+	}
+	return nil
+}
+
+// complete records the transaction against a key checkClaim accepted.
+// Callers must hold r.mu.
+func (r *IdempotencyRepository) complete(key string, transactionID int) {
+	record := r.data[key]
+	record.TransactionID = &transactionID
+	r.data[key] = record
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, exists := r.data[key]; exists && record.TransactionID == nil {
+		delete(r.data, key)
+	}
+	return nil
+}
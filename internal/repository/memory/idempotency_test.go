@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+func TestIdempotencyRepository_Reserve(t *testing.T) {
+	repo := NewIdempotencyRepository()
+	ctx := context.Background()
+
+	existing, err := repo.Reserve(ctx, "key", "hash", time.Hour)
+	if err != nil || existing != nil {
+		t.Fatalf("Reserve() = %v, %v, want nil, nil", existing, err)
+	}
+
+	if err := repo.Complete(ctx, "key", 7); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+
+	existing, err = repo.Reserve(ctx, "key", "other", time.Hour)
+	if err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if existing == nil || existing.RequestHash != "hash" || existing.TransactionID == nil || *existing.TransactionID != 7 {
+		t.Errorf("Reserve() existing = %+v, want hash with transaction 7", existing)
+	}
+
+	// Completed keys are kept by Release
+	repo.Release(ctx, "key")
+	if existing, _ := repo.Reserve(ctx, "key", "hash", time.Hour); existing == nil {
+		t.Error("Release() removed a completed key")
+	}
+}
+
+func TestIdempotencyRepository_Expiry(t *testing.T) {
+	repo := NewIdempotencyRepository()
+	ctx := context.Background()
+
+	repo.Reserve(ctx, "key", "hash", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	existing, err := repo.Reserve(ctx, "key", "new", time.Hour)
+	if err != nil || existing != nil {
+		t.Errorf("Reserve() after expiry = %v, %v, want nil, nil", existing, err)
+	}
+}
+
+func TestIdempotencyRepository_CompleteLostReservation(t *testing.T) {
+	repo := NewIdempotencyRepository()
+	ctx := context.Background()
+
+	if err := repo.Complete(ctx, "key", 7); !model.IsConflictError(err) {
+		t.Errorf("Complete() without a reservation error = %v, want conflict", err)
+	}
+}
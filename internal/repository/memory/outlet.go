@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+// OutletRepository keeps the outlets. It starts with the default outlet, the same
+// as the PostgreSQL schema.
+type OutletRepository struct {
+	mu     sync.RWMutex
+	data   []model.Outlet
+	nextID int
+	audit  *AuditRepository
+}
+
+func NewOutletRepository(main model.Outlet) *OutletRepository {
+	main.ID = model.DefaultOutletID
+	if main.Name == "" {
+		main.Name = "Main"
+	}
+	main.Active = true
+	main.CreatedAt = time.Now()
+	return &OutletRepository{
+		data:   []model.Outlet{main},
+		nextID: model.DefaultOutletID + 1,
+	}
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *OutletRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
+func (r *OutletRepository) FindByID(ctx context.Context, id int) (*model.Outlet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	result := r.data[idx]
+	return &result, nil
+}
+
+func (r *OutletRepository) FindAll(ctx context.Context) ([]model.Outlet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := make([]model.Outlet, len(r.data))
+	copy(results, r.data)
+	return results, nil
+}
+
+func (r *OutletRepository) Create(ctx context.Context, o model.Outlet) (*model.Outlet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.codeTaken(o.Code, 0) {
+		return nil, model.OutletCodeTakenError(o.Code)
+	}
+
+	o.ID = r.nextID
+	o.CreatedAt = time.Now()
+	if err := r.audit.record(ctx, model.AuditEntityOutlet, o.ID, model.AuditActionCreate, nil, o); err != nil {
+		return nil, err
+	}
+	r.nextID++
+	r.data = append(r.data, o)
+	return &o, nil
+}
+
+func (r *OutletRepository) Update(ctx context.Context, id int, o model.Outlet) (*model.Outlet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	if r.codeTaken(o.Code, id) {
+		return nil, model.OutletCodeTakenError(o.Code)
+	}
+
+	o.ID = id
+	o.CreatedAt = r.data[idx].CreatedAt
+	if err := r.audit.record(ctx, model.AuditEntityOutlet, id, model.AuditActionUpdate, r.data[idx], o); err != nil {
+		return nil, err
+	}
+	r.data[idx] = o
+	return &o, nil
+}
+
+// codeTaken reports whether another outlet than exceptID uses the code.
+// Callers must hold r.mu.
+func (r *OutletRepository) codeTaken(code string, exceptID int) bool {
+	for _, o := range r.data {
+		if o.ID != exceptID && o.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// indexOf returns the slice index of the outlet with the given ID, or -1.
+// Callers must hold r.mu.
+func (r *OutletRepository) indexOf(id int) int {
+	for i := range r.data {
+		if r.data[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/middleware"
+)
+
+// newTestOutletRepos wires a second outlet, "BDG", next to the default one and
+// stocks product 1 there
+func newTestOutletRepos(t *testing.T) (*TransactionRepository, *ProductRepository, context.Context) {
+	t.Helper()
+
+	transactionRepo, productRepo := newTestTransactionRepo(t)
+	outletRepo := NewOutletRepository(model.Outlet{Code: "MAIN"})
+	transactionRepo.SetOutletRepo(outletRepo)
+
+	branch, err := outletRepo.Create(context.Background(), model.Outlet{Code: "BDG", Name: "Bandung", Active: true})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	branchCtx := middleware.WithOutletID(context.Background(), branch.ID)
+
+	product, _ := productRepo.FindByID(branchCtx, 1)
+	product.Stock = 3
+	if _, err := productRepo.Update(branchCtx, 1, *product); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	return transactionRepo, productRepo, branchCtx
+}
+
+func TestOutletRepository_UniqueCode(t *testing.T) {
+	repo := NewOutletRepository(model.Outlet{Code: "MAIN"})
+	ctx := context.Background()
+
+	main, err := repo.FindByID(ctx, model.DefaultOutletID)
+	if err != nil || main.Code != "MAIN" || main.Name != "Main" || !main.Active {
+		t.Fatalf("FindByID(default) = %+v, %v, want active MAIN outlet", main, err)
+	}
+
+	if _, err := repo.Create(ctx, model.Outlet{Code: "MAIN", Name: "Another"}); !model.IsConflictError(err) {
+		t.Errorf("Create() with a used code error = %v, want conflict", err)
+	}
+
+	branch, _ := repo.Create(ctx, model.Outlet{Code: "BDG", Name: "Bandung"})
+	if _, err := repo.Update(ctx, branch.ID, model.Outlet{Code: "MAIN", Name: "Bandung"}); !model.IsConflictError(err) {
+		t.Errorf("Update() to a used code error = %v, want conflict", err)
+	}
+	if _, err := repo.Update(ctx, branch.ID, model.Outlet{Code: "BDG", Name: "Bandung Dago"}); err != nil {
+		t.Errorf("Update() keeping its own code error = %v", err)
+	}
+}
+
+func TestProductRepository_StockPerOutlet(t *testing.T) {
+	_, productRepo, branchCtx := newTestOutletRepos(t)
+	ctx := context.Background()
+
+	atMain, _ := productRepo.FindByID(ctx, 1)
+	atBranch, _ := productRepo.FindByID(branchCtx, 1)
+	if atMain.Stock != 10 || atBranch.Stock != 3 {
+		t.Errorf("stock = %d at main, %d at branch, want 10 and 3", atMain.Stock, atBranch.Stock)
+	}
+
+	// A product created at one outlet starts empty everywhere else
+	created, _ := productRepo.Create(branchCtx, model.Product{Name: "Aqua", Price: 3000, Stock: 7, Active: true})
+	products, _ := productRepo.FindAll(ctx)
+	for _, p := range products {
+		if p.ID == created.ID && p.Stock != 0 {
+			t.Errorf("new product stock at main = %d, want 0", p.Stock)
+		}
+	}
+}
+
+func TestTransactionRepository_CreateTransaction_AtOutlet(t *testing.T) {
+	transactionRepo, productRepo, branchCtx := newTestOutletRepos(t)
+	ctx := context.Background()
+	opts := model.CheckoutOptions{Invoice: model.DefaultInvoiceNumbering()}
+	today := time.Now().Format("20060102")
+
+	// The branch only holds 3, even though the main outlet holds 10
+	if _, err := transactionRepo.CreateTransaction(branchCtx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 4}}}, opts); !model.IsValidationError(err) {
+		t.Fatalf("CreateTransaction() beyond branch stock error = %v, want validation", err)
+	}
+
+	sale, err := transactionRepo.CreateTransaction(branchCtx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}}}, opts)
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+	if sale.OutletID != 2 || sale.InvoiceNumber != "INV/BDG/"+today+"/00001" {
+		t.Errorf("transaction = outlet %d, invoice %q, want outlet 2 numbered INV/BDG/.../00001", sale.OutletID, sale.InvoiceNumber)
+	}
+
+	atMain, _ := productRepo.FindByID(ctx, 1)
+	atBranch, _ := productRepo.FindByID(branchCtx, 1)
+	if atMain.Stock != 10 || atBranch.Stock != 1 {
+		t.Errorf("stock after sale = %d at main, %d at branch, want 10 and 1", atMain.Stock, atBranch.Stock)
+	}
+
+	// Cancelling from a request without an outlet still restocks the branch
+	if _, err := transactionRepo.CancelTransaction(ctx, sale.ID, model.TransactionStatusVoided, model.CancelRequest{Reason: "test"}); err != nil {
+		t.Fatalf("CancelTransaction() error = %v", err)
+	}
+	atBranch, _ = productRepo.FindByID(branchCtx, 1)
+	if atBranch.Stock != 3 {
+		t.Errorf("branch stock after void = %d, want 3", atBranch.Stock)
+	}
+
+	outletID := 2
+	if _, total, _ := transactionRepo.FindAll(ctx, model.TransactionFilter{OutletID: &outletID}); total != 1 {
+		t.Errorf("FindAll(outlet 2) total = %d, want 1", total)
+	}
+
+	if _, err := transactionRepo.CreateTransaction(middleware.WithOutletID(ctx, 9), model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}}, opts); !model.IsNotFoundError(err) {
+		t.Errorf("CreateTransaction() at unknown outlet error = %v, want not found", err)
+	}
+}
+
+func TestTransactionRepository_CreateTransaction_InactiveOutlet(t *testing.T) {
+	transactionRepo, _, branchCtx := newTestOutletRepos(t)
+	transactionRepo.outletRepo.Update(branchCtx, 2, model.Outlet{Code: "BDG", Name: "Bandung", Active: false})
+
+	_, err := transactionRepo.CreateTransaction(branchCtx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}}, model.CheckoutOptions{})
+	if !model.IsConflictError(err) {
+		t.Errorf("CreateTransaction() at inactive outlet error = %v, want conflict", err)
+	}
+}
+
+func TestReturnRepository_CreateReturn_RestocksSellingOutlet(t *testing.T) {
+	transactionRepo, productRepo, branchCtx := newTestOutletRepos(t)
+	returnRepo := NewReturnRepository(transactionRepo)
+	transactionRepo.SetReturnRepo(returnRepo)
+
+	sale, _ := transactionRepo.CreateTransaction(branchCtx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}}}, model.CheckoutOptions{})
+	_, err := returnRepo.CreateReturn(context.Background(), sale.ID, model.ReturnRequest{
+		Items:   []model.ReturnRequestItem{{ProductID: 1, Quantity: 1}},
+		Reason:  "damaged",
+		Restock: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateReturn() error = %v", err)
+	}
+
+	atMain, _ := productRepo.FindByID(context.Background(), 1)
+	atBranch, _ := productRepo.FindByID(branchCtx, 1)
+	if atMain.Stock != 10 || atBranch.Stock != 2 {
+		t.Errorf("stock after return = %d at main, %d at branch, want 10 and 2", atMain.Stock, atBranch.Stock)
+	}
+}
+
+func TestShiftRepository_OneOpenShiftPerOutlet(t *testing.T) {
+	transactionRepo, _, branchCtx := newTestOutletRepos(t)
+	repo := NewShiftRepository(transactionRepo)
+	ctx := context.Background()
+
+	if _, err := repo.Open(ctx, model.OpenShiftRequest{}); err != nil {
+		t.Fatalf("Open() at main error = %v", err)
+	}
+	branchShift, err := repo.Open(branchCtx, model.OpenShiftRequest{})
+	if err != nil {
+		t.Fatalf("Open() at branch error = %v", err)
+	}
+	if branchShift.OutletID != 2 {
+		t.Errorf("OutletID = %d, want 2", branchShift.OutletID)
+	}
+	if _, err := repo.Open(branchCtx, model.OpenShiftRequest{}); !model.IsConflictError(err) {
+		t.Errorf("second Open() at branch error = %v, want conflict", err)
+	}
+
+	open, err := repo.FindOpen(branchCtx)
+	if err != nil || open.ID != branchShift.ID {
+		t.Errorf("FindOpen(branch) = %+v, %v, want shift %d", open, err, branchShift.ID)
+	}
+}
+
+func TestReportRepository_PerOutlet(t *testing.T) {
+	transactionRepo, _, branchCtx := newTestOutletRepos(t)
+	repo := NewReportRepository(transactionRepo)
+	ctx := context.Background()
+
+	transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 2, Quantity: 1}}}, model.CheckoutOptions{})
+	transactionRepo.CreateTransaction(branchCtx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}}}, model.CheckoutOptions{})
+
+	branch, _ := repo.GetTodayReport(branchCtx)
+	if branch.OutletID == nil || *branch.OutletID != 2 || branch.TotalRevenue != 7000 || branch.TotalTransaction != 1 || branch.Outlets != nil {
+		t.Errorf("branch report = %+v, want outlet 2 with one sale of 7000 and no breakdown", branch)
+	}
+	if branch.TopProduct == nil || branch.TopProduct.Name != "Indomie" {
+		t.Errorf("branch TopProduct = %+v, want Indomie", branch.TopProduct)
+	}
+
+	all, _ := repo.GetTodayReport(ctx)
+	if all.OutletID != nil || all.TotalRevenue != 12000 || all.TotalTransaction != 2 {
+		t.Errorf("consolidated report = %+v, want two sales totalling 12000", all)
+	}
+	if len(all.Outlets) != 2 || all.Outlets[0].OutletCode != "MAIN" || all.Outlets[0].TotalRevenue != 5000 ||
+		all.Outlets[1].OutletCode != "BDG" || all.Outlets[1].TotalRevenue != 7000 {
+		t.Errorf("Outlets = %+v, want MAIN 5000 and BDG 7000", all.Outlets)
+	}
+}
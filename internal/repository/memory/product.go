@@ -2,23 +2,46 @@ package memory
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"sync"
 
 	"kasir-api/internal/model"
 )
 
+// ProductRepository keeps the shared catalog, the stock each outlet holds of it,
+// the ledger of stock movements that stock is the sum of and the low stock alerts
+// raised by checkouts. Product.Stock in
+// data is unused; reads fill it in for the outlet of the context.
 type ProductRepository struct {
-	mu      sync.RWMutex
-	data    []model.Product
-	nextID  int
-	catRepo *CategoryRepository
+	mu             sync.RWMutex
+	data           []model.Product
+	stock          map[stockKey]int
+	movements      []model.StockMovement
+	nextID         int
+	nextMovementID int
+	alerts         []model.StockAlert
+	nextAlertID    int
+	catRepo        *CategoryRepository
+	promotionRepo  *PromotionRepository
+	audit          *AuditRepository
+}
+
+// stockKey identifies a product's stock at one outlet
+type stockKey struct {
+	outletID  int
+	productID int
 }
 
 func NewProductRepository() *ProductRepository {
 	return &ProductRepository{
-		data:   make([]model.Product, 0),
-		nextID: 1,
+		data:           make([]model.Product, 0),
+		stock:          make(map[stockKey]int),
+		movements:      make([]model.StockMovement, 0),
+		nextID:         1,
+		nextMovementID: 1,
+		alerts:         make([]model.StockAlert, 0),
+		nextAlertID:    1,
 	}
 }
 
@@ -26,13 +49,25 @@ func (r *ProductRepository) SetCategoryRepo(catRepo *CategoryRepository) {
 	r.catRepo = catRepo
 }
 
+// SetPromotionRepo wires the promotions whose products are kept when deleted
+func (r *ProductRepository) SetPromotionRepo(promotionRepo *PromotionRepository) {
+	r.promotionRepo = promotionRepo
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *ProductRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
 func (r *ProductRepository) FindByID(ctx context.Context, id int) (*model.Product, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	outletID := model.OutletID(ctx)
 	for _, p := range r.data {
 		if p.ID == id {
 			result := p
+			result.Stock = r.stockAt(outletID, p.ID)
 
 			// Get category if category_id exists
 			if p.CategoryID != nil && r.catRepo != nil {
@@ -40,6 +75,7 @@ func (r *ProductRepository) FindByID(ctx context.Context, id int) (*model.Produc
 					result.Category = cat
 				}
 			}
+			result.Variants = r.variantsOf(outletID, p.ID)
 
 			return &result, nil
 		}
@@ -51,9 +87,11 @@ func (r *ProductRepository) FindAll(ctx context.Context) ([]model.Product, error
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	outletID := model.OutletID(ctx)
 	results := make([]model.Product, 0, len(r.data))
 	for _, p := range r.data {
 		result := p
+		result.Stock = r.stockAt(outletID, p.ID)
 
 		// Get category if category_id exists
 		if p.CategoryID != nil && r.catRepo != nil {
@@ -61,6 +99,7 @@ func (r *ProductRepository) FindAll(ctx context.Context) ([]model.Product, error
 				result.Category = cat
 			}
 		}
+		result.Variants = r.variantsOf(outletID, p.ID)
 
 		results = append(results, result)
 	}
@@ -72,6 +111,7 @@ func (r *ProductRepository) FindByFilters(ctx context.Context, name string, acti
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	outletID := model.OutletID(ctx)
 	results := make([]model.Product, 0)
 	for _, p := range r.data {
 		if name != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(name)) {
@@ -82,11 +122,13 @@ func (r *ProductRepository) FindByFilters(ctx context.Context, name string, acti
 		}
 
 		result := p
+		result.Stock = r.stockAt(outletID, p.ID)
 		if p.CategoryID != nil && r.catRepo != nil {
 			if cat, err := r.catRepo.FindByID(ctx, *p.CategoryID); err == nil {
 				result.Category = cat
 			}
 		}
+		result.Variants = r.variantsOf(outletID, p.ID)
 		results = append(results, result)
 	}
 
@@ -97,35 +139,212 @@ func (r *ProductRepository) Create(ctx context.Context, p model.Product) (*model
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	return r.create(ctx, p)
+}
+
+// create stores a new product. Callers must hold r.mu for writing.
+func (r *ProductRepository) create(ctx context.Context, p model.Product) (*model.Product, error) {
+	if err := r.checkUnique(p); err != nil {
+		return nil, err
+	}
+
+	// The stock given is what the outlet of the context holds; other outlets start empty
 	p.ID = r.nextID
+	if err := r.audit.record(ctx, model.AuditEntityProduct, p.ID, model.AuditActionCreate, nil, p.AuditRecord()); err != nil {
+		return nil, err
+	}
 	r.nextID++
-	r.data = append(r.data, p)
+	if p.Stock != 0 {
+		r.post(model.NewStockMovement(ctx, model.OutletID(ctx), p.ID, model.StockMovementAdjustment, p.Stock))
+	}
+	stored := p
+	stored.Stock = 0
+	stored.Variants = nil
+	r.data = append(r.data, stored)
 	return &p, nil
 }
 
+func (r *ProductRepository) CreateVariant(ctx context.Context, productID int, req model.ProductVariantRequest) (*model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(productID)
+	if i < 0 {
+		return nil, model.ErrNotFound
+	}
+	parent := r.data[i]
+	parent.Variants = r.variantsOf(model.OutletID(ctx), productID)
+
+	variant, err := parent.NewVariant(req)
+	if err != nil {
+		return nil, err
+	}
+	return r.create(ctx, variant)
+}
+
 func (r *ProductRepository) Update(ctx context.Context, id int, p model.Product) (*model.Product, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	for i := range r.data {
-		if r.data[i].ID == id {
-			p.ID = id
-			r.data[i] = p
-			return &p, nil
-		}
+	i := r.indexOf(id)
+	if i < 0 {
+		return nil, model.ErrNotFound
 	}
-	return nil, model.ErrNotFound
+
+	p.ID = id
+	before := r.data[i]
+	before.Stock = r.stockAt(model.OutletID(ctx), id)
+	p.ParentID, p.Attributes = before.ParentID, before.Attributes
+	if err := r.checkUnique(p); err != nil {
+		return nil, err
+	}
+	if err := r.audit.record(ctx, model.AuditEntityProduct, id, model.AuditActionUpdate, before.AuditRecord(), p.AuditRecord()); err != nil {
+		return nil, err
+	}
+	// The stock given replaces what the outlet holds, through an adjustment for the difference
+	if delta := p.Stock - before.Stock; delta != 0 {
+		r.post(model.NewStockMovement(ctx, model.OutletID(ctx), id, model.StockMovementAdjustment, delta))
+	}
+	stored := p
+	stored.Stock = 0
+	stored.Variants = nil
+	r.data[i] = stored
+	return &p, nil
 }
 
 func (r *ProductRepository) Delete(ctx context.Context, id int) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	for i, p := range r.data {
-		if p.ID == id {
-			r.data = append(r.data[:i], r.data[i+1:]...)
-			return nil
+	i := r.indexOf(id)
+	if i < 0 {
+		return model.ErrNotFound
+	}
+
+	// Variants go with the product, along with their stock
+	deleted := func(p model.Product) bool {
+		return p.ID == id || (p.ParentID != nil && *p.ParentID == id)
+	}
+	ids := make(map[int]bool)
+	for _, p := range r.data {
+		if deleted(p) {
+			ids[p.ID] = true
+		}
+	}
+
+	before := r.data[i]
+	before.Stock = r.stockAt(model.OutletID(ctx), id)
+
+	// The ledger is kept for good, so a product that has ever moved stock is
+	// deactivated instead, as the PostgreSQL implementation does. So is one that
+	// promotions still target.
+	for _, m := range r.movements {
+		if ids[m.ProductID] {
+			return r.deactivate(ctx, before, ids)
+		}
+	}
+	if r.promotionRepo != nil {
+		r.promotionRepo.mu.RLock()
+		promoted := r.promotionRepo.targets(ids, 0)
+		r.promotionRepo.mu.RUnlock()
+		if promoted {
+			return r.deactivate(ctx, before, ids)
 		}
 	}
-	return model.ErrNotFound
+
+	if err := r.audit.record(ctx, model.AuditEntityProduct, id, model.AuditActionDelete, before.AuditRecord(), nil); err != nil {
+		return err
+	}
+	r.data = slices.DeleteFunc(r.data, deleted)
+	for key := range r.stock {
+		if ids[key.productID] {
+			delete(r.stock, key)
+		}
+	}
+	return nil
+}
+
+// deactivate takes a product that cannot be deleted, and its variants, out of sale.
+// Callers must hold r.mu.
+func (r *ProductRepository) deactivate(ctx context.Context, before model.Product, ids map[int]bool) error {
+	after := before
+	after.Active = false
+	if err := r.audit.record(ctx, model.AuditEntityProduct, before.ID, model.AuditActionUpdate, before.AuditRecord(), after.AuditRecord()); err != nil {
+		return err
+	}
+	for i := range r.data {
+		if ids[r.data[i].ID] {
+			r.data[i].Active = false
+		}
+	}
+	return nil
+}
+
+// indexOf returns the slice index of the product with the given ID, or -1.
+// Callers must hold r.mu.
+func (r *ProductRepository) indexOf(id int) int {
+	for i := range r.data {
+		if r.data[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// variantsOf returns the variants of a product with their stock at the outlet, or
+// nil when it has none. Callers must hold r.mu.
+func (r *ProductRepository) variantsOf(outletID, id int) []model.ProductVariant {
+	var variants []model.ProductVariant
+	for _, p := range r.data {
+		if p.ParentID != nil && *p.ParentID == id {
+			p.Stock = r.stockAt(outletID, p.ID)
+			variants = append(variants, p.Variant())
+		}
+	}
+	return variants
+}
+
+// hasVariants reports whether the product has variants. Callers must hold r.mu.
+func (r *ProductRepository) hasVariants(id int) bool {
+	for _, p := range r.data {
+		if p.ParentID != nil && *p.ParentID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// checkUnique returns a conflict when another product already has p's SKU or
+// barcode. Callers must hold r.mu.
+func (r *ProductRepository) checkUnique(p model.Product) error {
+	for _, other := range r.data {
+		if other.ID == p.ID {
+			continue
+		}
+		if p.SKU != "" && other.SKU == p.SKU {
+			return model.SKUTakenError(p.SKU)
+		}
+		if p.Barcode != "" && other.Barcode == p.Barcode {
+			return model.BarcodeTakenError(p.Barcode)
+		}
+	}
+	return nil
+}
+
+// stockAt returns the stock of a product at an outlet. Callers must hold r.mu.
+func (r *ProductRepository) stockAt(outletID, productID int) int {
+	return r.stock[stockKey{outletID, productID}]
+}
+
+// categoryName returns the name of the product's category, or "" when it has none
+// or categories are not wired
+func (r *ProductRepository) categoryName(ctx context.Context, categoryID *int) string {
+	if categoryID == nil || r.catRepo == nil {
+		return ""
+	}
+	cat, err := r.catRepo.FindByID(ctx, *categoryID)
+	if err != nil {
+		return ""
+	}
+	return cat.Name
 }
@@ -109,7 +109,8 @@ func TestProductRepository_Delete(t *testing.T) {
 	repo := NewProductRepository()
 	ctx := context.Background()
 
-	product := model.Product{Name: "Indomie", Price: 3500, Stock: 100}
+	// The opening stock is on the ledger, so the product is kept but taken out of sale
+	product := model.Product{Name: "Indomie", Price: 3500, Stock: 100, Active: true}
 	created, _ := repo.Create(ctx, product)
 
 	err := repo.Delete(ctx, created.ID)
@@ -117,12 +118,64 @@ func TestProductRepository_Delete(t *testing.T) {
 		t.Fatalf("Delete() error = %v", err)
 	}
 
-	_, err = repo.FindByID(ctx, created.ID)
+	found, err := repo.FindByID(ctx, created.ID)
+	if err != nil || found.Active {
+		t.Errorf("After delete, FindByID() = %+v, %v, want the product kept inactive", found, err)
+	}
+	if movements, total, _ := repo.FindMovements(ctx, model.StockMovementFilter{ProductID: created.ID}.WithDefaults()); total != 1 || len(movements) != 1 {
+		t.Errorf("Ledger holds %d movements, want the opening balance kept", total)
+	}
+
+	// A variant that moved stock keeps its product, and both go out of sale
+	parent, _ := repo.Create(ctx, model.Product{
+		Name: "Aqua", Price: 3000, Active: true,
+		Options: []model.ProductOption{{Name: "size", Values: []string{"1L"}}},
+	})
+	variant, _ := repo.CreateVariant(ctx, parent.ID, model.ProductVariantRequest{Attributes: map[string]string{"size": "1L"}, Stock: 6})
+	if err := repo.Delete(ctx, parent.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	for _, id := range []int{parent.ID, variant.ID} {
+		if found, err := repo.FindByID(ctx, id); err != nil || found.Active {
+			t.Errorf("After delete, FindByID(%d) = %+v, %v, want it kept inactive", id, found, err)
+		}
+	}
+}
+
+func TestProductRepository_Delete_WithoutHistory(t *testing.T) {
+	repo := NewProductRepository()
+	ctx := context.Background()
+
+	created, _ := repo.Create(ctx, model.Product{Name: "Indomie", Price: 3500})
+
+	if err := repo.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	_, err := repo.FindByID(ctx, created.ID)
 	if err != model.ErrNotFound {
 		t.Errorf("After delete, FindByID() error = %v, want %v", err, model.ErrNotFound)
 	}
 }
 
+func TestProductRepository_Delete_Promoted(t *testing.T) {
+	repo := NewProductRepository()
+	promotions := NewPromotionRepository()
+	repo.SetPromotionRepo(promotions)
+	ctx := context.Background()
+
+	created, _ := repo.Create(ctx, model.Product{Name: "Indomie", Price: 3500, Active: true})
+	promotions.Create(ctx, model.Promotion{Name: "Buy 2 get 1", Type: model.PromotionTypeBuyXGetY, BuyQty: 2, GetQty: 1, ProductID: &created.ID, Active: true})
+
+	if err := repo.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	found, err := repo.FindByID(ctx, created.ID)
+	if err != nil || found.Active {
+		t.Errorf("After delete, FindByID() = %+v, %v, want the promoted product kept inactive", found, err)
+	}
+}
+
 func TestProductRepository_Delete_NotFound(t *testing.T) {
 	repo := NewProductRepository()
 	ctx := context.Background()
@@ -132,3 +185,51 @@ func TestProductRepository_Delete_NotFound(t *testing.T) {
 		t.Errorf("Delete() error = %v, want %v", err, model.ErrNotFound)
 	}
 }
+
+func TestProductRepository_CreateVariant(t *testing.T) {
+	repo := NewProductRepository()
+	ctx := context.Background()
+
+	parent, _ := repo.Create(ctx, model.Product{
+		Name: "Aqua", Price: 3000, Active: true,
+		Options: []model.ProductOption{{Name: "size", Values: []string{"1L", "2L"}}},
+	})
+	price := 5500
+	variant, err := repo.CreateVariant(ctx, parent.ID, model.ProductVariantRequest{
+		SKU: "AQ-2L", Barcode: "8886008101053", Attributes: map[string]string{"size": "2L"}, Price: &price, Stock: 6,
+	})
+	if err != nil {
+		t.Fatalf("CreateVariant() error = %v", err)
+	}
+	if variant.Name != "Aqua 2L" || variant.Price != 5500 || variant.Stock != 6 || variant.ParentID == nil {
+		t.Errorf("Unexpected variant: %+v", variant)
+	}
+
+	found, _ := repo.FindByID(ctx, parent.ID)
+	if len(found.Variants) != 1 || found.Variants[0].ID != variant.ID || found.Variants[0].Stock != 6 {
+		t.Errorf("Variants = %+v, want the 2L variant with 6 in stock", found.Variants)
+	}
+
+	if _, err := repo.CreateVariant(ctx, parent.ID, model.ProductVariantRequest{Attributes: map[string]string{"size": "2L"}}); !model.IsConflictError(err) {
+		t.Errorf("CreateVariant() with taken attributes error = %v, want a conflict", err)
+	}
+	if _, err := repo.CreateVariant(ctx, parent.ID, model.ProductVariantRequest{SKU: "AQ-2L", Attributes: map[string]string{"size": "1L"}}); !model.IsConflictError(err) {
+		t.Errorf("CreateVariant() with a taken SKU error = %v, want a conflict", err)
+	}
+	if _, err := repo.Create(ctx, model.Product{Name: "Le Minerale", Price: 3000, Barcode: "8886008101053"}); !model.IsConflictError(err) {
+		t.Errorf("Create() with a taken barcode error = %v, want a conflict", err)
+	}
+
+	// Variants that never moved stock go with their product
+	other, _ := repo.Create(ctx, model.Product{
+		Name: "Le Minerale", Price: 3000,
+		Options: []model.ProductOption{{Name: "size", Values: []string{"600ml"}}},
+	})
+	empty, _ := repo.CreateVariant(ctx, other.ID, model.ProductVariantRequest{Attributes: map[string]string{"size": "600ml"}})
+	if err := repo.Delete(ctx, other.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.FindByID(ctx, empty.ID); err != model.ErrNotFound {
+		t.Errorf("FindByID() of a deleted product's variant error = %v, want %v", err, model.ErrNotFound)
+	}
+}
@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type PromotionRepository struct {
+	mu         sync.RWMutex
+	data       []model.Promotion
+	nextID     int
+	products   *ProductRepository
+	categories *CategoryRepository
+	audit      *AuditRepository
+}
+
+func NewPromotionRepository() *PromotionRepository {
+	return &PromotionRepository{
+		data:   make([]model.Promotion, 0),
+		nextID: 1,
+	}
+}
+
+// SetTargets wires the products and categories promotions may be aimed at
+func (r *PromotionRepository) SetTargets(products *ProductRepository, categories *CategoryRepository) {
+	r.products = products
+	r.categories = categories
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *PromotionRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
+func (r *PromotionRepository) FindByID(ctx context.Context, id int) (*model.Promotion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.data {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+func (r *PromotionRepository) FindAll(ctx context.Context) ([]model.Promotion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	promotions := make([]model.Promotion, len(r.data))
+	copy(promotions, r.data)
+	return promotions, nil
+}
+
+func (r *PromotionRepository) FindActive(ctx context.Context, at time.Time) ([]model.Promotion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	promotions := make([]model.Promotion, 0)
+	for _, p := range r.data {
+		if p.IsActiveAt(at) {
+			promotions = append(promotions, p)
+		}
+	}
+	return promotions, nil
+}
+
+func (r *PromotionRepository) Create(ctx context.Context, p model.Promotion) (*model.Promotion, error) {
+	if err := r.checkTarget(ctx, p); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p.ID = r.nextID
+	p.CreatedAt = time.Now()
+	if err := r.audit.record(ctx, model.AuditEntityPromotion, p.ID, model.AuditActionCreate, nil, p); err != nil {
+		return nil, err
+	}
+	r.nextID++
+	r.data = append(r.data, p)
+	return &p, nil
+}
+
+func (r *PromotionRepository) Update(ctx context.Context, id int, p model.Promotion) (*model.Promotion, error) {
+	if err := r.checkTarget(ctx, p); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.data {
+		if r.data[i].ID == id {
+			p.ID = id
+			p.CreatedAt = r.data[i].CreatedAt
+			if err := r.audit.record(ctx, model.AuditEntityPromotion, id, model.AuditActionUpdate, r.data[i], p); err != nil {
+				return nil, err
+			}
+			r.data[i] = p
+			return &p, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+func (r *PromotionRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, p := range r.data {
+		if p.ID == id {
+			if err := r.audit.record(ctx, model.AuditEntityPromotion, id, model.AuditActionDelete, p, nil); err != nil {
+				return err
+			}
+			r.data = append(r.data[:i], r.data[i+1:]...)
+			return nil
+		}
+	}
+	return model.ErrNotFound
+}
+
+// checkTarget refuses a promotion aimed at a product or category that does not
+// exist, as the foreign keys do in PostgreSQL. It runs before r.mu is taken, since
+// deleting a product or category looks at promotions while holding its own lock.
+func (r *PromotionRepository) checkTarget(ctx context.Context, p model.Promotion) error {
+	if p.ProductID != nil && r.products != nil {
+		if _, err := r.products.FindByID(ctx, *p.ProductID); model.IsNotFoundError(err) {
+			return model.PromotionTargetError("product_id", *p.ProductID)
+		}
+	}
+	if p.CategoryID != nil && r.categories != nil {
+		if _, err := r.categories.FindByID(ctx, *p.CategoryID); model.IsNotFoundError(err) {
+			return model.PromotionTargetError("category_id", *p.CategoryID)
+		}
+	}
+	return nil
+}
+
+// targets reports whether any promotion is aimed at one of the products or at the
+// category. Callers must hold r.mu.
+func (r *PromotionRepository) targets(productIDs map[int]bool, categoryID int) bool {
+	for _, p := range r.data {
+		if (p.ProductID != nil && productIDs[*p.ProductID]) || (p.CategoryID != nil && *p.CategoryID == categoryID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+func TestPromotionRepository_FindActive(t *testing.T) {
+	repo := NewPromotionRepository()
+	ctx := context.Background()
+	now := time.Now()
+	later := now.Add(24 * time.Hour)
+
+	repo.Create(ctx, model.Promotion{Name: "Running", Type: model.PromotionTypePercent, Value: 10, Active: true})
+	repo.Create(ctx, model.Promotion{Name: "Disabled", Type: model.PromotionTypePercent, Value: 10})
+	repo.Create(ctx, model.Promotion{Name: "Upcoming", Type: model.PromotionTypePercent, Value: 10, Active: true, StartsAt: &later})
+
+	active, err := repo.FindActive(ctx, now)
+	if err != nil {
+		t.Fatalf("FindActive() error = %v", err)
+	}
+	if len(active) != 1 || active[0].Name != "Running" {
+		t.Errorf("FindActive() = %+v, want only Running", active)
+	}
+}
+
+func TestPromotionRepository_UpdateDelete(t *testing.T) {
+	repo := NewPromotionRepository()
+	ctx := context.Background()
+
+	created, _ := repo.Create(ctx, model.Promotion{Name: "Promo", Type: model.PromotionTypeFixed, Value: 500, Active: true})
+
+	updated, err := repo.Update(ctx, created.ID, model.Promotion{Name: "Promo", Type: model.PromotionTypeFixed, Value: 1000})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.Value != 1000 || !updated.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("Update() = %+v, want value 1000 and original created_at", updated)
+	}
+
+	if err := repo.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.FindByID(ctx, created.ID); err != model.ErrNotFound {
+		t.Errorf("FindByID() after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPromotionRepository_UnknownTarget(t *testing.T) {
+	repo := NewPromotionRepository()
+	products := NewProductRepository()
+	categories := NewCategoryRepository()
+	repo.SetTargets(products, categories)
+	ctx := context.Background()
+
+	missing := 99
+	if _, err := repo.Create(ctx, model.Promotion{Name: "Ghost", Type: model.PromotionTypePercent, Value: 10, ProductID: &missing}); !model.IsValidationError(err) {
+		t.Errorf("Create() for a missing product error = %v, want validation error", err)
+	}
+
+	product, _ := products.Create(ctx, model.Product{Name: "Indomie", Price: 3500})
+	created, err := repo.Create(ctx, model.Promotion{Name: "Indomie 10%", Type: model.PromotionTypePercent, Value: 10, ProductID: &product.ID})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := repo.Update(ctx, created.ID, model.Promotion{Name: "Ghost", Type: model.PromotionTypePercent, Value: 10, CategoryID: &missing}); !model.IsValidationError(err) {
+		t.Errorf("Update() to a missing category error = %v, want validation error", err)
+	}
+}
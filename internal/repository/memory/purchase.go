@@ -0,0 +1,273 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+// PurchaseOrderRepository keeps purchase orders and their goods receipts. Locks
+// are always taken in the order product repository, supplier repository, then
+// purchase order repository, since receipts post stock and orders show the
+// supplier's name.
+type PurchaseOrderRepository struct {
+	mu            sync.RWMutex
+	data          []model.PurchaseOrder
+	nextID        int
+	nextReceiptID int
+	productRepo   *ProductRepository
+	supplierRepo  *SupplierRepository
+	audit         *AuditRepository
+}
+
+func NewPurchaseOrderRepository(productRepo *ProductRepository, supplierRepo *SupplierRepository) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{
+		data:          make([]model.PurchaseOrder, 0),
+		nextID:        1,
+		nextReceiptID: 1,
+		productRepo:   productRepo,
+		supplierRepo:  supplierRepo,
+	}
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *PurchaseOrderRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
+func (r *PurchaseOrderRepository) FindByID(ctx context.Context, id int) (*model.PurchaseOrder, error) {
+	r.supplierRepo.mu.RLock()
+	defer r.supplierRepo.mu.RUnlock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	result := r.result(r.data[idx])
+	return &result, nil
+}
+
+func (r *PurchaseOrderRepository) FindAll(ctx context.Context, filter model.PurchaseOrderFilter) ([]model.PurchaseOrder, error) {
+	r.supplierRepo.mu.RLock()
+	defer r.supplierRepo.mu.RUnlock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	outletID := model.OutletID(ctx)
+	orders := make([]model.PurchaseOrder, 0)
+	for _, po := range r.data {
+		if po.OutletID != outletID || !filter.Matches(po) {
+			continue
+		}
+		orders = append(orders, r.result(po))
+	}
+
+	// Newest first, same as the PostgreSQL implementation
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].ID > orders[j].ID
+	})
+	return orders, nil
+}
+
+func (r *PurchaseOrderRepository) Create(ctx context.Context, po model.PurchaseOrder) (*model.PurchaseOrder, error) {
+	r.productRepo.mu.RLock()
+	defer r.productRepo.mu.RUnlock()
+	r.supplierRepo.mu.RLock()
+	defer r.supplierRepo.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.nameItems(&po); err != nil {
+		return nil, err
+	}
+	po.ID = r.nextID
+	po.CreatedAt = time.Now()
+	po.Receipts = make([]model.GoodsReceipt, 0)
+	if err := r.audit.record(ctx, model.AuditEntityPurchaseOrder, po.ID, model.AuditActionCreate, nil, po.AuditRecord()); err != nil {
+		return nil, err
+	}
+	r.nextID++
+	r.data = append(r.data, po)
+
+	result := r.result(po)
+	return &result, nil
+}
+
+// Update replaces the supplier, note and items of a draft
+func (r *PurchaseOrderRepository) Update(ctx context.Context, id int, po model.PurchaseOrder) (*model.PurchaseOrder, error) {
+	r.productRepo.mu.RLock()
+	defer r.productRepo.mu.RUnlock()
+	r.supplierRepo.mu.RLock()
+	defer r.supplierRepo.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	existing := r.data[idx]
+	if existing.Status != model.PurchaseOrderStatusDraft {
+		return nil, model.PurchaseOrderStatusError(id, existing.Status, "changed")
+	}
+	if err := r.nameItems(&po); err != nil {
+		return nil, err
+	}
+
+	updated := copyPurchaseOrder(existing)
+	updated.SupplierID = po.SupplierID
+	updated.Note = po.Note
+	updated.Items = po.Items
+	if err := r.audit.record(ctx, model.AuditEntityPurchaseOrder, id, model.AuditActionUpdate, existing.AuditRecord(), updated.AuditRecord()); err != nil {
+		return nil, err
+	}
+	r.data[idx] = updated
+
+	result := r.result(updated)
+	return &result, nil
+}
+
+func (r *PurchaseOrderRepository) Send(ctx context.Context, id int) (*model.PurchaseOrder, error) {
+	return r.transition(ctx, id, model.AuditActionSend, (*model.PurchaseOrder).Send)
+}
+
+func (r *PurchaseOrderRepository) Close(ctx context.Context, id int) (*model.PurchaseOrder, error) {
+	return r.transition(ctx, id, model.AuditActionClose, (*model.PurchaseOrder).Close)
+}
+
+func (r *PurchaseOrderRepository) transition(ctx context.Context, id int, action model.AuditAction, apply func(*model.PurchaseOrder, time.Time) error) (*model.PurchaseOrder, error) {
+	r.supplierRepo.mu.RLock()
+	defer r.supplierRepo.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	po := copyPurchaseOrder(r.data[idx])
+	before := po.AuditRecord()
+	if err := apply(&po, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := r.audit.record(ctx, model.AuditEntityPurchaseOrder, id, action, before, po.AuditRecord()); err != nil {
+		return nil, err
+	}
+	r.data[idx] = po
+
+	result := r.result(po)
+	return &result, nil
+}
+
+// Receive books a delivery: the received stock is posted at the order's outlet
+// and each product's cost becomes the unit cost of the delivery
+func (r *PurchaseOrderRepository) Receive(ctx context.Context, id int, req model.GoodsReceiptRequest) (*model.PurchaseOrder, error) {
+	r.productRepo.mu.Lock()
+	defer r.productRepo.mu.Unlock()
+	r.supplierRepo.mu.RLock()
+	defer r.supplierRepo.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	po := copyPurchaseOrder(r.data[idx])
+	now := time.Now()
+	receipt, err := po.Receive(ctx, req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Products deleted since the order was placed cannot take stock
+	type costChange struct {
+		idx           int
+		before, after model.Product
+	}
+	changes := make([]costChange, 0)
+	for _, item := range receipt.Items {
+		pi := r.productRepo.indexOf(item.ProductID)
+		if pi < 0 {
+			return nil, fmt.Errorf("%w: product id %d not found", model.ErrNotFound, item.ProductID)
+		}
+		before := r.productRepo.data[pi]
+		if before.Cost == item.UnitCost {
+			continue
+		}
+		before.Stock = r.productRepo.stockAt(po.OutletID, before.ID)
+		after := before
+		after.Cost = item.UnitCost
+		changes = append(changes, costChange{pi, before, after})
+	}
+
+	receipt.ID = r.nextReceiptID
+	if err := r.audit.record(ctx, model.AuditEntityPurchaseOrder, id, model.AuditActionReceive, nil, receipt); err != nil {
+		return nil, err
+	}
+	for _, c := range changes {
+		if err := r.productRepo.audit.record(ctx, model.AuditEntityProduct, c.before.ID, model.AuditActionUpdate, c.before.AuditRecord(), c.after.AuditRecord()); err != nil {
+			return nil, err
+		}
+	}
+
+	r.nextReceiptID++
+	for _, m := range receipt.Movements(ctx) {
+		m.CreatedAt = now
+		r.productRepo.post(m)
+	}
+	for _, c := range changes {
+		r.productRepo.data[c.idx].Cost = c.after.Cost
+	}
+	po.Receipts = append(po.Receipts, receipt)
+	r.data[idx] = po
+
+	result := r.result(po)
+	return &result, nil
+}
+
+// nameItems fills in the product names of the order's items, refusing products
+// that do not exist. Callers must hold r.productRepo.mu.
+func (r *PurchaseOrderRepository) nameItems(po *model.PurchaseOrder) error {
+	for i, item := range po.Items {
+		pi := r.productRepo.indexOf(item.ProductID)
+		if pi < 0 {
+			return fmt.Errorf("%w: product id %d not found", model.ErrNotFound, item.ProductID)
+		}
+		po.Items[i].ProductName = r.productRepo.data[pi].Name
+	}
+	return nil
+}
+
+// result returns a copy of the order with the supplier's name and the totals
+// filled in. Callers must hold r.supplierRepo.mu.
+func (r *PurchaseOrderRepository) result(po model.PurchaseOrder) model.PurchaseOrder {
+	po = copyPurchaseOrder(po)
+	po.SupplierName = r.supplierRepo.name(po.SupplierID)
+	return po.WithTotals()
+}
+
+func (r *PurchaseOrderRepository) indexOf(id int) int {
+	for i, po := range r.data {
+		if po.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func copyPurchaseOrder(po model.PurchaseOrder) model.PurchaseOrder {
+	items := make([]model.PurchaseOrderItem, len(po.Items))
+	copy(items, po.Items)
+	po.Items = items
+	receipts := make([]model.GoodsReceipt, len(po.Receipts))
+	copy(receipts, po.Receipts)
+	po.Receipts = receipts
+	return po
+}
@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+// PurchaseReturnRepository keeps the goods returned to suppliers. Locks are
+// always taken in the order product repository, supplier repository, purchase
+// order repository, then purchase return repository, since returns take stock,
+// show the supplier's name and may reference a goods receipt.
+type PurchaseReturnRepository struct {
+	mu                sync.RWMutex
+	data              []model.PurchaseReturn
+	nextID            int
+	productRepo       *ProductRepository
+	supplierRepo      *SupplierRepository
+	purchaseOrderRepo *PurchaseOrderRepository
+	audit             *AuditRepository
+}
+
+func NewPurchaseReturnRepository(productRepo *ProductRepository, supplierRepo *SupplierRepository, purchaseOrderRepo *PurchaseOrderRepository) *PurchaseReturnRepository {
+	return &PurchaseReturnRepository{
+		data:              make([]model.PurchaseReturn, 0),
+		nextID:            1,
+		productRepo:       productRepo,
+		supplierRepo:      supplierRepo,
+		purchaseOrderRepo: purchaseOrderRepo,
+	}
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *PurchaseReturnRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
+func (r *PurchaseReturnRepository) FindByID(ctx context.Context, id int) (*model.PurchaseReturn, error) {
+	r.supplierRepo.mu.RLock()
+	defer r.supplierRepo.mu.RUnlock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	result := r.result(r.data[idx])
+	return &result, nil
+}
+
+func (r *PurchaseReturnRepository) FindAll(ctx context.Context, filter model.PurchaseReturnFilter) ([]model.PurchaseReturn, error) {
+	r.supplierRepo.mu.RLock()
+	defer r.supplierRepo.mu.RUnlock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	outletID := model.OutletID(ctx)
+	returns := make([]model.PurchaseReturn, 0)
+	for _, pr := range r.data {
+		if pr.OutletID != outletID || !filter.Matches(pr) {
+			continue
+		}
+		returns = append(returns, r.result(pr))
+	}
+
+	// Newest first, same as the PostgreSQL implementation
+	sort.SliceStable(returns, func(i, j int) bool {
+		return returns[i].ID > returns[j].ID
+	})
+	return returns, nil
+}
+
+// Create books the return and takes the returned stock out of the outlet
+func (r *PurchaseReturnRepository) Create(ctx context.Context, req model.PurchaseReturnRequest) (*model.PurchaseReturn, error) {
+	r.productRepo.mu.Lock()
+	defer r.productRepo.mu.Unlock()
+	r.supplierRepo.mu.RLock()
+	defer r.supplierRepo.mu.RUnlock()
+	r.purchaseOrderRepo.mu.RLock()
+	defer r.purchaseOrderRepo.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	products := make(map[int]model.Product, len(req.Items))
+	for _, item := range req.Items {
+		if pi := r.productRepo.indexOf(item.ProductID); pi >= 0 {
+			products[item.ProductID] = r.productRepo.data[pi]
+		}
+	}
+
+	var receipt *model.ReturnableReceipt
+	if req.GoodsReceiptID != nil {
+		var err error
+		if receipt, err = r.returnableReceipt(*req.GoodsReceiptID); err != nil {
+			return nil, err
+		}
+	}
+
+	pr, err := model.NewPurchaseReturn(ctx, req, products, receipt)
+	if err != nil {
+		return nil, err
+	}
+	pr.ID = r.nextID
+	pr.CreatedAt = time.Now()
+
+	movements := pr.Movements(ctx)
+	for _, m := range movements {
+		if available := r.productRepo.stockAt(m.OutletID, m.ProductID); available+m.Quantity < 0 {
+			return nil, model.InsufficientStockError(m.ProductID, m.OutletID, available, m.Quantity)
+		}
+	}
+	if err := r.audit.record(ctx, model.AuditEntityPurchaseReturn, pr.ID, model.AuditActionCreate, nil, pr.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	r.nextID++
+	for _, m := range movements {
+		m.CreatedAt = pr.CreatedAt
+		r.productRepo.post(m)
+	}
+	r.data = append(r.data, pr)
+
+	result := r.result(pr)
+	return &result, nil
+}
+
+func (r *PurchaseReturnRepository) RecordCredit(ctx context.Context, id int, req model.SupplierCreditRequest) (*model.PurchaseReturn, error) {
+	r.supplierRepo.mu.RLock()
+	defer r.supplierRepo.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	pr := copyPurchaseReturn(r.data[idx])
+	before := pr.AuditRecord()
+	if err := pr.RecordCredit(req.Amount, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := r.audit.record(ctx, model.AuditEntityPurchaseReturn, id, model.AuditActionCredit, before, pr.AuditRecord()); err != nil {
+		return nil, err
+	}
+	r.data[idx] = pr
+
+	result := r.result(pr)
+	return &result, nil
+}
+
+// pendingCredit returns the credit still owed on the open returns of an outlet,
+// or of every outlet when scoped is false. Callers must hold r.mu.
+func (r *PurchaseReturnRepository) pendingCredit(outletID int, scoped bool) int {
+	total := 0
+	for _, pr := range r.data {
+		if pr.Status != model.PurchaseReturnStatusOpen || (scoped && pr.OutletID != outletID) {
+			continue
+		}
+		total += pr.WithTotals().CreditOwed
+	}
+	return total
+}
+
+// returnableReceipt finds a goods receipt with the supplier of its order and what
+// earlier returns took back of it. Callers must hold r.purchaseOrderRepo.mu and r.mu.
+func (r *PurchaseReturnRepository) returnableReceipt(receiptID int) (*model.ReturnableReceipt, error) {
+	for _, po := range r.purchaseOrderRepo.data {
+		for _, g := range po.Receipts {
+			if g.ID != receiptID {
+				continue
+			}
+			receipt := &model.ReturnableReceipt{GoodsReceipt: g, SupplierID: po.SupplierID, Returned: make(map[int]int)}
+			for _, pr := range r.data {
+				if pr.GoodsReceiptID == nil || *pr.GoodsReceiptID != receiptID {
+					continue
+				}
+				for _, item := range pr.Items {
+					receipt.Returned[item.ProductID] += item.Quantity
+				}
+			}
+			return receipt, nil
+		}
+	}
+	return nil, model.GoodsReceiptNotFoundError(receiptID)
+}
+
+// result returns a copy of the return with the supplier's name and the credit
+// totals filled in. Callers must hold r.supplierRepo.mu.
+func (r *PurchaseReturnRepository) result(pr model.PurchaseReturn) model.PurchaseReturn {
+	pr = copyPurchaseReturn(pr)
+	pr.SupplierName = r.supplierRepo.name(pr.SupplierID)
+	return pr.WithTotals()
+}
+
+func (r *PurchaseReturnRepository) indexOf(id int) int {
+	for i, pr := range r.data {
+		if pr.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func copyPurchaseReturn(pr model.PurchaseReturn) model.PurchaseReturn {
+	items := make([]model.PurchaseReturnItem, len(pr.Items))
+	copy(items, pr.Items)
+	pr.Items = items
+	return pr
+}
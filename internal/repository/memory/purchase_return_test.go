@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/middleware"
+)
+
+func TestPurchaseReturnRepository(t *testing.T) {
+	txRepo, productRepo := newTestTransactionRepo(t)
+	suppliers := NewSupplierRepository()
+	orders := NewPurchaseOrderRepository(productRepo, suppliers)
+	audit := NewAuditRepository()
+	repo := NewPurchaseReturnRepository(productRepo, suppliers, orders)
+	repo.SetAuditLog(audit)
+	reports := NewReportRepository(txRepo)
+	reports.SetPurchaseReturnRepo(repo)
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{UserID: 2, Username: "rina"})
+
+	supplier, _ := suppliers.Create(ctx, model.Supplier{Name: "CV Sumber Rejeki", Active: true})
+	req := model.PurchaseOrderRequest{SupplierID: supplier.ID, Items: []model.PurchaseOrderItemRequest{{ProductID: 1, Quantity: 20, UnitCost: 2500}}}
+	po, _ := orders.Create(ctx, req.PurchaseOrder(ctx))
+	orders.Send(ctx, po.ID)
+	po, err := orders.Receive(ctx, po.ID, model.GoodsReceiptRequest{Items: []model.GoodsReceiptItemRequest{{ProductID: 1, Quantity: 20}}})
+	if err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	receiptID := po.Receipts[0].ID
+
+	// Six of the delivered noodles are past their date
+	pr, err := repo.Create(ctx, model.PurchaseReturnRequest{SupplierID: supplier.ID, GoodsReceiptID: &receiptID, Items: []model.PurchaseReturnItemRequest{
+		{ProductID: 1, Quantity: 6, Reason: model.StockReasonExpired},
+	}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if pr.SupplierName != "CV Sumber Rejeki" || pr.CreditTotal != 15000 || pr.CreditOwed != 15000 || pr.CreatedBy != "rina" {
+		t.Errorf("Unexpected return: %+v", pr)
+	}
+	if product, _ := productRepo.FindByID(ctx, 1); product.Stock != 24 {
+		t.Errorf("Stock = %d, want 24", product.Stock)
+	}
+	movements, _, _ := productRepo.FindMovements(ctx, model.StockMovementFilter{ProductID: 1})
+	if m := movements[0]; m.Type != model.StockMovementPurchaseReturn || m.Quantity != -6 || m.Reason != model.StockReasonExpired || *m.ReferenceID != pr.ID {
+		t.Errorf("Unexpected movement: %+v", m)
+	}
+
+	// Only 14 of the receipt are left to return
+	over := model.PurchaseReturnRequest{SupplierID: supplier.ID, GoodsReceiptID: &receiptID, Items: []model.PurchaseReturnItemRequest{{ProductID: 1, Quantity: 15, Reason: model.StockReasonDamaged}}}
+	if _, err := repo.Create(ctx, over); !model.IsValidationError(err) {
+		t.Errorf("Create() over the receipt error = %v, want validation", err)
+	}
+	missing := 99
+	over.GoodsReceiptID = &missing
+	if _, err := repo.Create(ctx, over); !model.IsNotFoundError(err) {
+		t.Errorf("Create() against a missing receipt error = %v, want not found", err)
+	}
+	// Teh Botol has 5 in stock
+	if _, err := repo.Create(ctx, model.PurchaseReturnRequest{SupplierID: supplier.ID, Items: []model.PurchaseReturnItemRequest{{ProductID: 2, Quantity: 6, Reason: model.StockReasonDamaged}}}); !model.IsValidationError(err) {
+		t.Errorf("Create() over the stock error = %v, want validation", err)
+	}
+
+	valuation, _ := reports.GetInventoryValuation(ctx)
+	if valuation.PendingSupplierCredit != 15000 || valuation.TotalValue != 24*2500 {
+		t.Errorf("Unexpected valuation: %+v", valuation)
+	}
+
+	pr, err = repo.RecordCredit(ctx, pr.ID, model.SupplierCreditRequest{Amount: 15000})
+	if err != nil {
+		t.Fatalf("RecordCredit() error = %v", err)
+	}
+	if pr.Status != model.PurchaseReturnStatusCredited || pr.CreditOwed != 0 {
+		t.Errorf("Unexpected return after the credit: %+v", pr)
+	}
+	if open, _ := repo.FindAll(ctx, model.PurchaseReturnFilter{Status: model.PurchaseReturnStatusOpen}); len(open) != 0 {
+		t.Errorf("Expected no open returns, got %+v", open)
+	}
+	if valuation, _ := reports.GetInventoryValuation(ctx); valuation.PendingSupplierCredit != 0 {
+		t.Errorf("PendingSupplierCredit = %d, want 0", valuation.PendingSupplierCredit)
+	}
+
+	if discrepancies, _ := productRepo.CheckLedger(ctx); len(discrepancies) != 0 {
+		t.Errorf("CheckLedger() = %v, want none", discrepancies)
+	}
+	if _, total, _ := audit.FindAll(ctx, model.AuditFilter{Entity: string(model.AuditEntityPurchaseReturn)}.WithDefaults()); total != 2 {
+		t.Errorf("Expected create and credit entries, got %d", total)
+	}
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/middleware"
+)
+
+func TestPurchaseOrderRepository_Receive(t *testing.T) {
+	_, productRepo := newTestTransactionRepo(t)
+	suppliers := NewSupplierRepository()
+	audit := NewAuditRepository()
+	repo := NewPurchaseOrderRepository(productRepo, suppliers)
+	repo.SetAuditLog(audit)
+	productRepo.SetAuditLog(audit)
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{UserID: 2, Username: "rina"})
+
+	supplier, _ := suppliers.Create(ctx, model.Supplier{Name: "CV Sumber Rejeki", Active: true})
+	req := model.PurchaseOrderRequest{SupplierID: supplier.ID, Items: []model.PurchaseOrderItemRequest{
+		{ProductID: 1, Quantity: 20, UnitCost: 2500},
+		{ProductID: 2, Quantity: 12, UnitCost: 3000},
+	}}
+	po, err := repo.Create(ctx, req.PurchaseOrder(ctx))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if po.SupplierName != "CV Sumber Rejeki" || po.Items[0].ProductName != "Indomie" || po.TotalCost != 86000 || po.CreatedBy != "rina" {
+		t.Errorf("Unexpected order: %+v", po)
+	}
+
+	if _, err := repo.Send(ctx, po.ID); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if _, err := repo.Update(ctx, po.ID, req.PurchaseOrder(ctx)); !model.IsConflictError(err) {
+		t.Errorf("Update() after sending error = %v, want conflict", err)
+	}
+
+	cost := 2700
+	po, err = repo.Receive(ctx, po.ID, model.GoodsReceiptRequest{Items: []model.GoodsReceiptItemRequest{{ProductID: 1, Quantity: 15, UnitCost: &cost}}})
+	if err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	if po.Status != model.PurchaseOrderStatusPartiallyReceived || po.Items[0].Outstanding != 5 || len(po.Receipts) != 1 || po.ReceivedCost != 40500 {
+		t.Errorf("Unexpected order after the first delivery: %+v", po)
+	}
+
+	product, _ := productRepo.FindByID(ctx, 1)
+	if product.Stock != 25 || product.Cost != 2700 {
+		t.Errorf("Product = stock %d cost %d, want 25 at 2700", product.Stock, product.Cost)
+	}
+	movements, _, _ := productRepo.FindMovements(ctx, model.StockMovementFilter{ProductID: 1})
+	if m := movements[0]; m.Type != model.StockMovementReceipt || m.Quantity != 15 || m.ReferenceType != model.StockReferenceReceipt || *m.ReferenceID != po.Receipts[0].ID {
+		t.Errorf("Unexpected movement: %+v", m)
+	}
+
+	if open, _ := repo.FindAll(ctx, model.PurchaseOrderFilter{ProductID: 2, Open: true}); len(open) != 1 {
+		t.Errorf("Expected the order open for product 2, got %+v", open)
+	}
+
+	po, err = repo.Receive(ctx, po.ID, model.GoodsReceiptRequest{Items: []model.GoodsReceiptItemRequest{{ProductID: 1, Quantity: 5}, {ProductID: 2, Quantity: 12}}})
+	if err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	if po.Status != model.PurchaseOrderStatusClosed {
+		t.Errorf("Status = %s, want closed once everything arrived", po.Status)
+	}
+	if open, _ := repo.FindAll(ctx, model.PurchaseOrderFilter{SupplierID: supplier.ID, Open: true}); len(open) != 0 {
+		t.Errorf("Expected no open orders, got %+v", open)
+	}
+	if discrepancies, _ := productRepo.CheckLedger(ctx); len(discrepancies) != 0 {
+		t.Errorf("CheckLedger() = %v, want none", discrepancies)
+	}
+
+	entries, total, _ := audit.FindAll(ctx, model.AuditFilter{Entity: string(model.AuditEntityPurchaseOrder)}.WithDefaults())
+	if total != 4 || entries[0].Action != model.AuditActionReceive {
+		t.Errorf("Expected create, send and two receive entries, got %d: %+v", total, entries)
+	}
+	// Indomie went to 2700 and back to the ordered 2500; Teh Botol from 0 to 3000
+	if _, total, _ := audit.FindAll(ctx, model.AuditFilter{Entity: string(model.AuditEntityProduct)}.WithDefaults()); total != 3 {
+		t.Errorf("Expected three product entries for the cost changes, got %d", total)
+	}
+}
+
+func TestPurchaseOrderRepository_UpdateDraft(t *testing.T) {
+	_, productRepo := newTestTransactionRepo(t)
+	suppliers := NewSupplierRepository()
+	repo := NewPurchaseOrderRepository(productRepo, suppliers)
+	ctx := context.Background()
+
+	supplier, _ := suppliers.Create(ctx, model.Supplier{Name: "PT Maju", Active: true})
+	req := model.PurchaseOrderRequest{SupplierID: supplier.ID, Items: []model.PurchaseOrderItemRequest{{ProductID: 1, Quantity: 10}}}
+	po, _ := repo.Create(ctx, req.PurchaseOrder(ctx))
+
+	req.Items = []model.PurchaseOrderItemRequest{{ProductID: 2, Quantity: 6, UnitCost: 3000}}
+	updated, err := repo.Update(ctx, po.ID, req.PurchaseOrder(ctx))
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if len(updated.Items) != 1 || updated.Items[0].ProductName != "Teh Botol" || updated.Status != model.PurchaseOrderStatusDraft {
+		t.Errorf("Unexpected draft: %+v", updated)
+	}
+
+	req.Items = []model.PurchaseOrderItemRequest{{ProductID: 99, Quantity: 1}}
+	if _, err := repo.Update(ctx, po.ID, req.PurchaseOrder(ctx)); !model.IsNotFoundError(err) {
+		t.Errorf("Update() with a missing product error = %v, want not found", err)
+	}
+	if _, err := repo.Receive(ctx, po.ID, model.GoodsReceiptRequest{Items: []model.GoodsReceiptItemRequest{{ProductID: 2, Quantity: 6}}}); !model.IsConflictError(err) {
+		t.Errorf("Receive() on a draft error = %v, want conflict", err)
+	}
+
+	closed, err := repo.Close(ctx, po.ID)
+	if err != nil || closed.Status != model.PurchaseOrderStatusClosed {
+		t.Fatalf("Close() = %+v, %v", closed, err)
+	}
+	if product, _ := productRepo.FindByID(ctx, 2); product.Stock != 5 {
+		t.Errorf("Stock = %d, want it unchanged at 5", product.Stock)
+	}
+}
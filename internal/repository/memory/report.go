@@ -0,0 +1,220 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type ReportRepository struct {
+	transactionRepo    *TransactionRepository
+	purchaseReturnRepo *PurchaseReturnRepository
+}
+
+func NewReportRepository(transactionRepo *TransactionRepository) *ReportRepository {
+	return &ReportRepository{transactionRepo: transactionRepo}
+}
+
+// SetPurchaseReturnRepo wires the purchase returns whose pending credit the
+// inventory valuation reports
+func (r *ReportRepository) SetPurchaseReturnRepo(purchaseReturnRepo *PurchaseReturnRepository) {
+	r.purchaseReturnRepo = purchaseReturnRepo
+}
+
+func (r *ReportRepository) GetTodayReport(ctx context.Context) (*model.ReportSummary, error) {
+	today := time.Now().Format(time.DateOnly)
+	return r.summarize(ctx, today, today), nil
+}
+
+func (r *ReportRepository) GetReportByDateRange(ctx context.Context, startDate, endDate string) (*model.ReportSummary, error) {
+	return r.summarize(ctx, startDate, endDate), nil
+}
+
+// summarize aggregates revenue, transaction count and the best selling product
+// for completed transactions created between startDate and endDate (inclusive, YYYY-MM-DD),
+// at the outlet of the context or, when it names none, at every outlet
+func (r *ReportRepository) summarize(ctx context.Context, startDate, endDate string) *model.ReportSummary {
+	r.transactionRepo.mu.RLock()
+	defer r.transactionRepo.mu.RUnlock()
+
+	summary := &model.ReportSummary{}
+	outletID, scoped := model.ScopedOutletID(ctx)
+	if scoped {
+		summary.OutletID = &outletID
+	}
+	outlets := r.outletBreakdown()
+	soldQty := make(map[int]int)
+	names := make(map[int]string)
+
+	for _, t := range r.transactionRepo.data {
+		day := t.CreatedAt.Format(time.DateOnly)
+		if day < startDate || day > endDate || t.Status != model.TransactionStatusCompleted {
+			continue
+		}
+		if scoped && t.OutletID != outletID {
+			continue
+		}
+
+		summary.GrossSales += t.GrossAmount
+		summary.TotalDiscount += t.DiscountAmount
+		summary.TotalTax += t.TaxAmount
+		summary.TotalRevenue += t.TotalAmount
+		summary.TotalTransaction++
+
+		sales := outlets.get(t.OutletID)
+		sales.GrossSales += t.GrossAmount
+		sales.TotalDiscount += t.DiscountAmount
+		sales.TotalTax += t.TaxAmount
+		sales.TotalRevenue += t.TotalAmount
+		sales.TotalTransaction++
+
+		// Variants count towards their product, under its name at the time of sale
+		for _, d := range t.Details {
+			id, name := d.ProductID, d.ProductName
+			if d.ParentProductID != nil {
+				id, name = *d.ParentProductID, d.ParentProductName
+			}
+			soldQty[id] += d.Quantity
+			names[id] = name
+		}
+	}
+
+	// Returns count on the day they were made, as long as the sale itself still stands
+	if returnRepo := r.transactionRepo.returnRepo; returnRepo != nil {
+		returnRepo.mu.RLock()
+		for _, ret := range returnRepo.data {
+			day := ret.CreatedAt.Format(time.DateOnly)
+			if day < startDate || day > endDate {
+				continue
+			}
+			idx := r.transactionRepo.indexOf(ret.TransactionID)
+			if idx < 0 || r.transactionRepo.data[idx].Status != model.TransactionStatusCompleted {
+				continue
+			}
+			saleOutletID := r.transactionRepo.data[idx].OutletID
+			if scoped && saleOutletID != outletID {
+				continue
+			}
+			summary.TotalReturns -= ret.TotalAmount
+			summary.TotalTax += ret.TaxAmount
+			sales := outlets.get(saleOutletID)
+			sales.TotalReturns -= ret.TotalAmount
+			sales.TotalTax += ret.TaxAmount
+		}
+		returnRepo.mu.RUnlock()
+	}
+	summary.TotalRevenue -= summary.TotalReturns
+
+	if !scoped {
+		summary.Outlets = outlets.list()
+	}
+
+	topID := 0
+	for id, qty := range soldQty {
+		if topID == 0 || qty > soldQty[topID] || (qty == soldQty[topID] && id < topID) {
+			topID = id
+		}
+	}
+	if topID != 0 {
+		summary.TopProduct = &model.TopProduct{
+			Name:    names[topID],
+			SoldQty: soldQty[topID],
+		}
+	}
+
+	return summary
+}
+
+// GetInventoryValuation values the stock of the outlet of the context or, when it
+// names none, of every outlet at each product's current cost. Products without
+// stock are left out.
+func (r *ReportRepository) GetInventoryValuation(ctx context.Context) (*model.InventoryValuation, error) {
+	productRepo := r.transactionRepo.productRepo
+	productRepo.mu.RLock()
+	defer productRepo.mu.RUnlock()
+
+	valuation := &model.InventoryValuation{Products: make([]model.InventoryValuationLine, 0)}
+	outletID, scoped := model.ScopedOutletID(ctx)
+	if scoped {
+		valuation.OutletID = &outletID
+	}
+
+	stock := make(map[int]int)
+	for key, qty := range productRepo.stock {
+		if scoped && key.outletID != outletID {
+			continue
+		}
+		stock[key.productID] += qty
+	}
+
+	for _, p := range productRepo.data {
+		if stock[p.ID] == 0 {
+			continue
+		}
+		line := model.InventoryValuationLine{
+			ProductID:       p.ID,
+			ProductName:     p.Name,
+			ParentProductID: p.ParentID,
+			Stock:           stock[p.ID],
+			UnitCost:        p.Cost,
+			Value:           stock[p.ID] * p.Cost,
+		}
+		valuation.TotalUnits += line.Stock
+		valuation.TotalValue += line.Value
+		valuation.Products = append(valuation.Products, line)
+	}
+	sort.Slice(valuation.Products, func(i, j int) bool {
+		return valuation.Products[i].ProductID < valuation.Products[j].ProductID
+	})
+
+	if r.purchaseReturnRepo != nil {
+		r.purchaseReturnRepo.mu.RLock()
+		valuation.PendingSupplierCredit = r.purchaseReturnRepo.pendingCredit(outletID, scoped)
+		r.purchaseReturnRepo.mu.RUnlock()
+	}
+
+	return valuation, nil
+}
+
+// outletSales collects the per-outlet figures of a consolidated report
+type outletSales struct {
+	byID map[int]*model.OutletSales
+}
+
+// outletBreakdown starts the breakdown with every known outlet, so outlets without
+// sales are listed too
+func (r *ReportRepository) outletBreakdown() outletSales {
+	breakdown := outletSales{byID: make(map[int]*model.OutletSales)}
+	if outletRepo := r.transactionRepo.outletRepo; outletRepo != nil {
+		outletRepo.mu.RLock()
+		for _, o := range outletRepo.data {
+			breakdown.byID[o.ID] = &model.OutletSales{OutletID: o.ID, OutletCode: o.Code}
+		}
+		outletRepo.mu.RUnlock()
+	}
+	return breakdown
+}
+
+func (b outletSales) get(outletID int) *model.OutletSales {
+	sales, ok := b.byID[outletID]
+	if !ok {
+		sales = &model.OutletSales{OutletID: outletID}
+		b.byID[outletID] = sales
+	}
+	return sales
+}
+
+// list returns the figures ordered by outlet ID, with revenue net of returns
+func (b outletSales) list() []model.OutletSales {
+	results := make([]model.OutletSales, 0, len(b.byID))
+	for _, sales := range b.byID {
+		sales.TotalRevenue -= sales.TotalReturns
+		results = append(results, *sales)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].OutletID < results[j].OutletID
+	})
+	return results
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+func TestReportRepository_GetTodayReport(t *testing.T) {
+	transactionRepo, _ := newTestTransactionRepo(t)
+	repo := NewReportRepository(transactionRepo)
+	ctx := context.Background()
+
+	transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}}}, model.CheckoutOptions{})
+	transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}, {ProductID: 2, Quantity: 1}}}, model.CheckoutOptions{})
+
+	report, err := repo.GetTodayReport(ctx)
+	if err != nil {
+		t.Fatalf("GetTodayReport() error = %v", err)
+	}
+
+	if report.TotalRevenue != 15500 {
+		t.Errorf("TotalRevenue = %v, want 15500", report.TotalRevenue)
+	}
+	if report.TotalTransaction != 2 {
+		t.Errorf("TotalTransaction = %v, want 2", report.TotalTransaction)
+	}
+	if report.TopProduct == nil || report.TopProduct.Name != "Indomie" || report.TopProduct.SoldQty != 3 {
+		t.Errorf("TopProduct = %+v, want Indomie with 3 sold", report.TopProduct)
+	}
+}
+
+func TestReportRepository_GetReportByDateRange(t *testing.T) {
+	transactionRepo, _ := newTestTransactionRepo(t)
+	repo := NewReportRepository(transactionRepo)
+	ctx := context.Background()
+
+	transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}}, model.CheckoutOptions{})
+
+	today := time.Now().Format(time.DateOnly)
+	report, err := repo.GetReportByDateRange(ctx, today, today)
+	if err != nil {
+		t.Fatalf("GetReportByDateRange() error = %v", err)
+	}
+	if report.TotalTransaction != 1 {
+		t.Errorf("TotalTransaction = %v, want 1", report.TotalTransaction)
+	}
+
+	report, _ = repo.GetReportByDateRange(ctx, "2000-01-01", "2000-01-31")
+	if report.TotalTransaction != 0 || report.TopProduct != nil {
+		t.Errorf("Expected empty report, got %+v", report)
+	}
+}
+
+func TestReportRepository_ExcludesCancelledTransactions(t *testing.T) {
+	transactionRepo, _ := newTestTransactionRepo(t)
+	repo := NewReportRepository(transactionRepo)
+	ctx := context.Background()
+
+	transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}}, model.CheckoutOptions{})
+	voided, _ := transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 2, Quantity: 2}}}, model.CheckoutOptions{})
+	transactionRepo.CancelTransaction(ctx, voided.ID, model.TransactionStatusVoided, model.CancelRequest{Reason: "test"})
+
+	report, _ := repo.GetTodayReport(ctx)
+	if report.TotalTransaction != 1 || report.TotalRevenue != 3500 {
+		t.Errorf("Report = %+v, want 1 transaction with revenue 3500", report)
+	}
+	if report.TopProduct == nil || report.TopProduct.Name != "Indomie" {
+		t.Errorf("TopProduct = %+v, want Indomie", report.TopProduct)
+	}
+}
+
+func TestReportRepository_NetsOutReturns(t *testing.T) {
+	returnRepo, transactionRepo, _ := newTestReturnRepo(t)
+	repo := NewReportRepository(transactionRepo)
+	ctx := context.Background()
+
+	transaction, _ := transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}}}, model.CheckoutOptions{})
+	returnRepo.CreateReturn(ctx, transaction.ID, model.ReturnRequest{
+		Items:  []model.ReturnRequestItem{{ProductID: 1, Quantity: 1}},
+		Reason: "damaged",
+	})
+
+	report, _ := repo.GetTodayReport(ctx)
+	if report.TotalRevenue != 3500 || report.TotalReturns != 3500 {
+		t.Errorf("Report = %+v, want revenue 3500 and returns 3500", report)
+	}
+}
+
+func TestReportRepository_NetsOutReturnedTax(t *testing.T) {
+	returnRepo, transactionRepo, _ := newTestReturnRepo(t)
+	repo := NewReportRepository(transactionRepo)
+	ctx := context.Background()
+
+	// 3 x 3500 with 11% PPN on top: 1155 tax, 11655 charged
+	transaction, _ := transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 3}}}, model.CheckoutOptions{TaxRate: 1100})
+	if transaction.TaxAmount != 1155 || transaction.TotalAmount != 11655 {
+		t.Fatalf("Transaction tax = %d, total = %d, want 1155 and 11655", transaction.TaxAmount, transaction.TotalAmount)
+	}
+
+	ret, err := returnRepo.CreateReturn(ctx, transaction.ID, model.ReturnRequest{
+		Items:  []model.ReturnRequestItem{{ProductID: 1, Quantity: 1}},
+		Reason: "damaged",
+	})
+	if err != nil {
+		t.Fatalf("CreateReturn() error = %v", err)
+	}
+	if ret.TotalAmount != -3885 || ret.TaxAmount != -385 {
+		t.Errorf("Return amount = %d, tax = %d, want -3885 and -385", ret.TotalAmount, ret.TaxAmount)
+	}
+
+	report, _ := repo.GetTodayReport(ctx)
+	if report.TotalRevenue != 7770 || report.TotalTax != 770 {
+		t.Errorf("Report revenue = %d, tax = %d, want 7770 and 770", report.TotalRevenue, report.TotalTax)
+	}
+	if len(report.Outlets) != 1 || report.Outlets[0].TotalTax != 770 {
+		t.Errorf("Outlets = %+v, want the outlet's tax netted to 770", report.Outlets)
+	}
+
+	// The last unit takes whatever tax is left, so the full refund nets the tax to zero
+	returnRepo.CreateReturn(ctx, transaction.ID, model.ReturnRequest{
+		Items:  []model.ReturnRequestItem{{ProductID: 1, Quantity: 2}},
+		Reason: "damaged",
+	})
+	report, _ = repo.GetTodayReport(ctx)
+	if report.TotalRevenue != 0 || report.TotalTax != 0 {
+		t.Errorf("Report revenue = %d, tax = %d after returning everything, want 0", report.TotalRevenue, report.TotalTax)
+	}
+}
+
+func TestReportRepository_TopProduct_RollsUpVariants(t *testing.T) {
+	transactionRepo, productRepo := newTestTransactionRepo(t)
+	repo := NewReportRepository(transactionRepo)
+	ctx := context.Background()
+
+	parent, _ := productRepo.Create(ctx, model.Product{
+		Name: "Aqua", Price: 3000, Active: true,
+		Options: []model.ProductOption{{Name: "size", Values: []string{"1L", "2L"}}},
+	})
+	small, _ := productRepo.CreateVariant(ctx, parent.ID, model.ProductVariantRequest{Attributes: map[string]string{"size": "1L"}, Stock: 10})
+	large, _ := productRepo.CreateVariant(ctx, parent.ID, model.ProductVariantRequest{Attributes: map[string]string{"size": "2L"}, Stock: 10})
+
+	// Indomie sells 3; neither Aqua variant beats it alone, but together they sell 4
+	transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 3}}}, model.CheckoutOptions{})
+	transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{
+		{VariantID: &small.ID, Quantity: 2},
+		{VariantID: &large.ID, Quantity: 2},
+	}}, model.CheckoutOptions{})
+
+	// Renaming the product later does not rewrite what was sold
+	renamed := *parent
+	renamed.Name = "Aqua Botol"
+	if _, err := productRepo.Update(ctx, parent.ID, renamed); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	report, err := repo.GetTodayReport(ctx)
+	if err != nil {
+		t.Fatalf("GetTodayReport() error = %v", err)
+	}
+	if report.TopProduct == nil || report.TopProduct.Name != "Aqua" || report.TopProduct.SoldQty != 4 {
+		t.Errorf("TopProduct = %+v, want Aqua with 4 sold", report.TopProduct)
+	}
+
+	valuation, _ := repo.GetInventoryValuation(ctx)
+	for _, line := range valuation.Products {
+		if line.ProductID == large.ID && (line.ParentProductID == nil || *line.ParentProductID != parent.ID) {
+			t.Errorf("Valuation line = %+v, want it to name its parent", line)
+		}
+	}
+}
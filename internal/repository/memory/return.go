@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"kasir-api/internal/model"
+)
+
+type ReturnRepository struct {
+	mu              sync.RWMutex
+	data            []model.Return
+	nextID          int
+	nextItemID      int
+	transactionRepo *TransactionRepository
+	audit           *AuditRepository
+}
+
+func NewReturnRepository(transactionRepo *TransactionRepository) *ReturnRepository {
+	return &ReturnRepository{
+		data:            make([]model.Return, 0),
+		nextID:          1,
+		nextItemID:      1,
+		transactionRepo: transactionRepo,
+	}
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *ReturnRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
+func (r *ReturnRepository) CreateReturn(ctx context.Context, transactionID int, req model.ReturnRequest) (*model.Return, error) {
+	// Same lock order as checkout: products, transactions, then returns
+	productRepo := r.transactionRepo.productRepo
+	productRepo.mu.Lock()
+	defer productRepo.mu.Unlock()
+
+	r.transactionRepo.mu.RLock()
+	defer r.transactionRepo.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.transactionRepo.indexOf(transactionID)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	transaction := r.transactionRepo.data[idx]
+
+	if transaction.Status != model.TransactionStatusCompleted {
+		return nil, fmt.Errorf("%w: cannot return items of a %s transaction", model.ErrConflict, transaction.Status)
+	}
+
+	returned := r.returnedByDetail()
+	lines := make([]model.ReturnableLine, 0, len(transaction.Details))
+	for _, d := range transaction.Details {
+		lines = append(lines, model.ReturnableLine{
+			DetailID:       d.ID,
+			ProductID:      d.ProductID,
+			ProductName:    d.ProductName,
+			Quantity:       d.Quantity,
+			Amount:         d.Subtotal,
+			Tax:            d.TaxAmount,
+			ReturnedQty:    returned[d.ID].Quantity,
+			ReturnedAmount: returned[d.ID].Amount,
+			ReturnedTax:    returned[d.ID].TaxAmount,
+		})
+	}
+
+	items, err := model.AllocateReturn(lines, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := model.NewReturn(ctx, transactionID, req, items)
+	ret.ID = r.nextID
+	r.nextID++
+
+	for i := range items {
+		items[i].ID = r.nextItemID
+		items[i].ReturnID = ret.ID
+		r.nextItemID++
+
+		if req.Restock {
+			// Returned goods go back on the shelf of the outlet that sold them
+			if productRepo.indexOf(items[i].ProductID) >= 0 {
+				productRepo.post(model.NewStockMovement(ctx, transaction.OutletID, items[i].ProductID, model.StockMovementReturn, items[i].Quantity).
+					For(model.StockReferenceReturn, ret.ID))
+			}
+		}
+	}
+	if err := r.audit.record(ctx, model.AuditEntityReturn, ret.ID, model.AuditActionCreate, nil, ret); err != nil {
+		return nil, err
+	}
+	r.data = append(r.data, ret)
+
+	result := copyReturn(ret)
+	return &result, nil
+}
+
+func (r *ReturnRepository) FindByID(ctx context.Context, id int) (*model.Return, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, ret := range r.data {
+		if ret.ID == id {
+			result := copyReturn(ret)
+			return &result, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+func (r *ReturnRepository) FindByTransactionID(ctx context.Context, transactionID int) ([]model.Return, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := make([]model.Return, 0)
+	for _, ret := range r.data {
+		if ret.TransactionID == transactionID {
+			results = append(results, copyReturn(ret))
+		}
+	}
+	return results, nil
+}
+
+// returnedByDetail sums returned quantities and amounts per transaction detail ID.
+// Callers must hold r.mu.
+func (r *ReturnRepository) returnedByDetail() map[int]model.ReturnItem {
+	returned := make(map[int]model.ReturnItem)
+	for _, ret := range r.data {
+		for _, item := range ret.Items {
+			sum := returned[item.TransactionDetailID]
+			sum.Quantity += item.Quantity
+			sum.Amount += item.Amount
+			sum.TaxAmount += item.TaxAmount
+			returned[item.TransactionDetailID] = sum
+		}
+	}
+	return returned
+}
+
+// copyReturn returns a copy that does not share the items slice with the store
+func copyReturn(ret model.Return) model.Return {
+	items := make([]model.ReturnItem, len(ret.Items))
+	copy(items, ret.Items)
+	ret.Items = items
+	return ret
+}
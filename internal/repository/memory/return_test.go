@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+)
+
+func newTestReturnRepo(t *testing.T) (*ReturnRepository, *TransactionRepository, *ProductRepository) {
+	t.Helper()
+
+	transactionRepo, productRepo := newTestTransactionRepo(t)
+	returnRepo := NewReturnRepository(transactionRepo)
+	transactionRepo.SetReturnRepo(returnRepo)
+
+	return returnRepo, transactionRepo, productRepo
+}
+
+func TestReturnRepository_CreateReturn(t *testing.T) {
+	repo, transactionRepo, productRepo := newTestReturnRepo(t)
+	ctx := context.Background()
+
+	transaction, _ := transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 3}, {ProductID: 2, Quantity: 1}}}, model.CheckoutOptions{})
+
+	ret, err := repo.CreateReturn(ctx, transaction.ID, model.ReturnRequest{
+		Items:   []model.ReturnRequestItem{{ProductID: 1, Quantity: 2}},
+		Reason:  "wrong flavour",
+		Restock: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateReturn() error = %v", err)
+	}
+	if ret.ID == 0 || ret.TotalAmount != -7000 || len(ret.Items) != 1 {
+		t.Errorf("Unexpected return: %+v", ret)
+	}
+
+	product, _ := productRepo.FindByID(ctx, 1)
+	if product.Stock != 9 {
+		t.Errorf("Stock = %v, want 9 after restocking 2", product.Stock)
+	}
+
+	// Only one unit of product 1 is left to return
+	_, err = repo.CreateReturn(ctx, transaction.ID, model.ReturnRequest{
+		Items:  []model.ReturnRequestItem{{ProductID: 1, Quantity: 2}},
+		Reason: "again",
+	})
+	if !model.IsValidationError(err) {
+		t.Errorf("CreateReturn() error = %v, want validation error", err)
+	}
+
+	returns, _ := repo.FindByTransactionID(ctx, transaction.ID)
+	if len(returns) != 1 {
+		t.Errorf("FindByTransactionID() returned %d returns, want 1", len(returns))
+	}
+}
+
+func TestReturnRepository_CreateReturn_WithoutRestock(t *testing.T) {
+	repo, transactionRepo, productRepo := newTestReturnRepo(t)
+	ctx := context.Background()
+
+	transaction, _ := transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}}}, model.CheckoutOptions{})
+	repo.CreateReturn(ctx, transaction.ID, model.ReturnRequest{
+		Items:  []model.ReturnRequestItem{{ProductID: 1, Quantity: 1}},
+		Reason: "damaged",
+	})
+
+	product, _ := productRepo.FindByID(ctx, 1)
+	if product.Stock != 8 {
+		t.Errorf("Stock = %v, want 8", product.Stock)
+	}
+
+	// Refunding the rest must only restore the unit that was not returned
+	transactionRepo.CancelTransaction(ctx, transaction.ID, model.TransactionStatusRefunded, model.CancelRequest{Reason: "refund"})
+	product, _ = productRepo.FindByID(ctx, 1)
+	if product.Stock != 9 {
+		t.Errorf("Stock = %v, want 9", product.Stock)
+	}
+
+	_, err := repo.CreateReturn(ctx, transaction.ID, model.ReturnRequest{
+		Items:  []model.ReturnRequestItem{{ProductID: 1, Quantity: 1}},
+		Reason: "late",
+	})
+	if !model.IsConflictError(err) {
+		t.Errorf("CreateReturn() on refunded transaction error = %v, want conflict", err)
+	}
+}
+
+func TestReturnRepository_FindByID_NotFound(t *testing.T) {
+	repo, _, _ := newTestReturnRepo(t)
+
+	_, err := repo.FindByID(context.Background(), 999)
+	if err != model.ErrNotFound {
+		t.Errorf("FindByID() error = %v, want %v", err, model.ErrNotFound)
+	}
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type SessionRepository struct {
+	mu       sync.Mutex
+	sessions map[string]model.Session
+	tokens   map[string]model.RefreshToken // by token hash
+}
+
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{
+		sessions: make(map[string]model.Session),
+		tokens:   make(map[string]model.RefreshToken),
+	}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, s model.Session, refresh model.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.dropExpired(s.CreatedAt)
+	r.sessions[s.ID] = s
+	refresh.SessionID = s.ID
+	r.tokens[refresh.TokenHash] = refresh
+	return nil
+}
+
+func (r *SessionRepository) FindByID(ctx context.Context, id string) (*model.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[id]
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	return &s, nil
+}
+
+func (r *SessionRepository) Rotate(ctx context.Context, tokenHash string, next model.RefreshToken, at time.Time) (*model.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, model.InvalidSessionError()
+	}
+	session := r.sessions[token.SessionID]
+
+	if token.UsedAt != nil {
+		if session.RevokedAt == nil {
+			session.RevokedAt = &at
+			r.sessions[session.ID] = session
+		}
+		return nil, model.RefreshTokenReusedError()
+	}
+	if !at.Before(token.ExpiresAt) || !session.IsActive(at) {
+		return nil, model.InvalidSessionError()
+	}
+
+	token.UsedAt = &at
+	r.tokens[tokenHash] = token
+
+	session.ExpiresAt = next.ExpiresAt
+	r.sessions[session.ID] = session
+	next.SessionID = session.ID
+	r.tokens[next.TokenHash] = next
+	return &session, nil
+}
+
+func (r *SessionRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[id]
+	if !ok {
+		return model.ErrNotFound
+	}
+	if s.RevokedAt == nil {
+		s.RevokedAt = &at
+		r.sessions[id] = s
+	}
+	return nil
+}
+
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, s := range r.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			s.RevokedAt = &at
+			r.sessions[id] = s
+		}
+	}
+	return nil
+}
+
+// dropExpired forgets sessions past their expiry and their tokens so the maps do
+// not grow without bound. Callers must hold r.mu.
+func (r *SessionRepository) dropExpired(at time.Time) {
+	for hash, token := range r.tokens {
+		if !at.Before(r.sessions[token.SessionID].ExpiresAt) {
+			delete(r.tokens, hash)
+		}
+	}
+	for id, s := range r.sessions {
+		if !at.Before(s.ExpiresAt) {
+			delete(r.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+func TestSessionRepository_Rotate(t *testing.T) {
+	repo := NewSessionRepository()
+	ctx := context.Background()
+	now := time.Now()
+
+	session := model.Session{ID: "s1", UserID: 1, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	if err := repo.Create(ctx, session, model.RefreshToken{TokenHash: "first", ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Rotating extends the session to the new token's expiry
+	rotated, err := repo.Rotate(ctx, "first", model.RefreshToken{TokenHash: "second", ExpiresAt: now.Add(2 * time.Hour)}, now)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if rotated.ID != "s1" || !rotated.ExpiresAt.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("Rotate() = %+v, want session s1 extended by the new token", rotated)
+	}
+
+	if _, err := repo.Rotate(ctx, "unknown", model.RefreshToken{TokenHash: "x", ExpiresAt: now.Add(time.Hour)}, now); !model.IsUnauthorizedError(err) {
+		t.Errorf("Rotate() with an unknown token error = %v, want unauthorized", err)
+	}
+
+	// Using the first token again means it leaked: the whole session goes
+	if _, err := repo.Rotate(ctx, "first", model.RefreshToken{TokenHash: "third", ExpiresAt: now.Add(time.Hour)}, now); !model.IsUnauthorizedError(err) {
+		t.Fatalf("Rotate() with a used token error = %v, want unauthorized", err)
+	}
+	found, _ := repo.FindByID(ctx, "s1")
+	if found.IsActive(now) {
+		t.Error("session still active after its refresh token was reused")
+	}
+	if _, err := repo.Rotate(ctx, "second", model.RefreshToken{TokenHash: "fourth", ExpiresAt: now.Add(time.Hour)}, now); !model.IsUnauthorizedError(err) {
+		t.Errorf("Rotate() in a revoked session error = %v, want unauthorized", err)
+	}
+}
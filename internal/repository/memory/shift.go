@@ -0,0 +1,268 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+// ShiftRepository keeps cashier shifts. Locks are always taken in the order
+// transaction repository, then shift repository, matching checkout.
+type ShiftRepository struct {
+	mu              sync.RWMutex
+	data            []model.Shift
+	nextID          int
+	nextMovementID  int
+	transactionRepo *TransactionRepository
+	audit           *AuditRepository
+}
+
+func NewShiftRepository(transactionRepo *TransactionRepository) *ShiftRepository {
+	return &ShiftRepository{
+		data:            make([]model.Shift, 0),
+		nextID:          1,
+		nextMovementID:  1,
+		transactionRepo: transactionRepo,
+	}
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *ShiftRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
+func (r *ShiftRepository) FindByID(ctx context.Context, id int) (*model.Shift, error) {
+	r.transactionRepo.mu.RLock()
+	defer r.transactionRepo.mu.RUnlock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	result := r.withSummary(r.data[idx])
+	return &result, nil
+}
+
+func (r *ShiftRepository) FindOpen(ctx context.Context) (*model.Shift, error) {
+	r.transactionRepo.mu.RLock()
+	defer r.transactionRepo.mu.RUnlock()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx := r.openIndex(model.OutletID(ctx))
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	result := r.withSummary(r.data[idx])
+	return &result, nil
+}
+
+func (r *ShiftRepository) FindOpenID(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx := r.openIndex(model.OutletID(ctx))
+	if idx < 0 {
+		return 0, model.ErrNotFound
+	}
+	return r.data[idx].ID, nil
+}
+
+func (r *ShiftRepository) FindAll(ctx context.Context) ([]model.Shift, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	shifts := make([]model.Shift, 0, len(r.data))
+	for _, s := range r.data {
+		if model.InScope(ctx, s.OutletID) {
+			shifts = append(shifts, copyShift(s))
+		}
+	}
+
+	// Newest first, same as the PostgreSQL implementation
+	sort.SliceStable(shifts, func(i, j int) bool {
+		return shifts[i].ID > shifts[j].ID
+	})
+	return shifts, nil
+}
+
+func (r *ShiftRepository) Open(ctx context.Context, req model.OpenShiftRequest) (*model.Shift, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	outletID := model.OutletID(ctx)
+	if idx := r.openIndex(outletID); idx >= 0 {
+		return nil, fmt.Errorf("%w: shift %d is still open", model.ErrConflict, r.data[idx].ID)
+	}
+
+	shift := model.NewShift(ctx, outletID, req)
+	shift.ID = r.nextID
+	if err := r.audit.record(ctx, model.AuditEntityShift, shift.ID, model.AuditActionCreate, nil, shift); err != nil {
+		return nil, err
+	}
+	r.nextID++
+	r.data = append(r.data, shift)
+
+	result := copyShift(shift)
+	return &result, nil
+}
+
+func (r *ShiftRepository) AddCashMovement(ctx context.Context, shiftID int, req model.CashMovementRequest) (*model.CashMovement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(shiftID)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	if r.data[idx].Status != model.ShiftStatusOpen {
+		return nil, model.ShiftClosedError(shiftID)
+	}
+
+	movement := model.NewCashMovement(ctx, shiftID, req)
+	movement.ID = r.nextMovementID
+	if err := r.audit.record(ctx, model.AuditEntityShift, shiftID, model.AuditActionCashMovement, nil, movement); err != nil {
+		return nil, err
+	}
+	r.nextMovementID++
+	r.data[idx].Movements = append(r.data[idx].Movements, movement)
+
+	return &movement, nil
+}
+
+func (r *ShiftRepository) Close(ctx context.Context, id int, req model.CloseShiftRequest) (*model.Shift, error) {
+	// Hold the transaction lock so no checkout can land in the shift while it is totalled
+	r.transactionRepo.mu.RLock()
+	defer r.transactionRepo.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	shift := &r.data[idx]
+	if shift.Status != model.ShiftStatusOpen {
+		return nil, model.ShiftClosedError(id)
+	}
+
+	before := copyShift(*shift)
+	summary := r.summarize(*shift)
+	shift.Close(ctx, req, summary.ExpectedCash, time.Now())
+
+	result := copyShift(*shift)
+	result.Summary = summary
+	if err := r.audit.record(ctx, model.AuditEntityShift, id, model.AuditActionClose, before, result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// isOpen reports whether the shift exists and is open. Callers must hold r.mu.
+func (r *ShiftRepository) isOpen(id int) bool {
+	idx := r.indexOf(id)
+	return idx >= 0 && r.data[idx].Status == model.ShiftStatusOpen
+}
+
+// withSummary returns a copy of the shift with its sales summary.
+// Callers must hold r.transactionRepo.mu and r.mu.
+func (r *ShiftRepository) withSummary(s model.Shift) model.Shift {
+	result := copyShift(s)
+	result.Summary = r.summarize(s)
+	return result
+}
+
+// summarize totals the completed transactions, cash refunds and cash movements of
+// a shift. Callers must hold r.transactionRepo.mu and r.mu.
+func (r *ShiftRepository) summarize(s model.Shift) *model.ShiftSummary {
+	count, total := 0, 0
+	payments := make(map[model.PaymentMethod]int)
+	for _, t := range r.transactionRepo.data {
+		if t.ShiftID == nil || *t.ShiftID != s.ID || t.Status != model.TransactionStatusCompleted {
+			continue
+		}
+		count++
+		total += t.TotalAmount
+		for _, p := range t.Payments {
+			payments[p.Method] += p.Amount
+		}
+		if t.ChangeAmount > 0 {
+			payments[model.PaymentMethodCash] -= t.ChangeAmount
+		}
+	}
+	return model.NewShiftSummary(s.OpeningFloat, count, total, payments, r.cashRefunds(s), s.Movements)
+}
+
+// cashRefunds adds up the cash handed back at the shift's outlet while it was
+// open. A voided sale of the shift itself has already dropped out of its totals,
+// so neither the void nor the sale's returns count again.
+// Callers must hold r.transactionRepo.mu.
+func (r *ShiftRepository) cashRefunds(s model.Shift) int {
+	own := func(t model.Transaction) bool {
+		return t.ShiftID != nil && *t.ShiftID == s.ID
+	}
+
+	refunds := 0
+	returned := make(map[int]int) // transaction ID -> amount refunded by returns so far
+	if returnRepo := r.transactionRepo.returnRepo; returnRepo != nil {
+		returnRepo.mu.RLock()
+		for _, ret := range returnRepo.data {
+			idx := r.transactionRepo.indexOf(ret.TransactionID)
+			if idx < 0 {
+				continue
+			}
+			t := r.transactionRepo.data[idx]
+			before := returned[t.ID]
+			returned[t.ID] -= ret.TotalAmount
+			if t.OutletID != s.OutletID || !s.Covers(ret.CreatedAt) || (own(t) && t.Status != model.TransactionStatusCompleted) {
+				continue
+			}
+			refunds += model.CashRefund(t.CashKept(), before, -ret.TotalAmount)
+		}
+		returnRepo.mu.RUnlock()
+	}
+
+	for _, t := range r.transactionRepo.data {
+		if t.CancelledAt == nil || t.OutletID != s.OutletID || !s.Covers(*t.CancelledAt) || own(t) {
+			continue
+		}
+		refunds += model.CashRefund(t.CashKept(), returned[t.ID], t.TotalAmount-returned[t.ID])
+	}
+	return refunds
+}
+
+// openIndex returns the slice index of the outlet's open shift, or -1.
+// Callers must hold r.mu.
+func (r *ShiftRepository) openIndex(outletID int) int {
+	for i := range r.data {
+		if r.data[i].OutletID == outletID && r.data[i].Status == model.ShiftStatusOpen {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexOf returns the slice index of the shift with the given ID, or -1.
+// Callers must hold r.mu.
+func (r *ShiftRepository) indexOf(id int) int {
+	for i := range r.data {
+		if r.data[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyShift returns a copy that does not share its movements with the store
+func copyShift(s model.Shift) model.Shift {
+	movements := make([]model.CashMovement, len(s.Movements))
+	copy(movements, s.Movements)
+	s.Movements = movements
+	return s
+}
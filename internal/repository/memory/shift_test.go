@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/middleware"
+)
+
+func TestShiftRepository_Reconciliation(t *testing.T) {
+	transactionRepo, _ := newTestTransactionRepo(t)
+	repo := NewShiftRepository(transactionRepo)
+	transactionRepo.SetShiftRepo(repo)
+	ctx := context.Background()
+
+	shift, err := repo.Open(ctx, model.OpenShiftRequest{OpeningFloat: 100000})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if _, err := repo.Open(ctx, model.OpenShiftRequest{}); !model.IsConflictError(err) {
+		t.Errorf("Open() while a shift is open error = %v, want conflict", err)
+	}
+
+	opts := model.CheckoutOptions{ShiftID: &shift.ID}
+	_, err = transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{
+		Items:    []model.CheckoutItem{{ProductID: 1, Quantity: 2}},
+		Payments: []model.CheckoutPayment{{Method: model.PaymentMethodCash, Amount: 10000}},
+	}, opts)
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+	_, err = transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{
+		Items:    []model.CheckoutItem{{ProductID: 2, Quantity: 1}},
+		Payments: []model.CheckoutPayment{{Method: model.PaymentMethodQRIS, Amount: 5000}},
+	}, opts)
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	repo.AddCashMovement(ctx, shift.ID, model.CashMovementRequest{Type: model.CashMovementIn, Amount: 50000, Reason: "Change from safe"})
+	repo.AddCashMovement(ctx, shift.ID, model.CashMovementRequest{Type: model.CashMovementOut, Amount: 20000, Reason: "Petty cash"})
+
+	counted := 136000
+	closed, err := repo.Close(ctx, shift.ID, model.CloseShiftRequest{CountedCash: &counted})
+	if err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// 100000 float + 7000 cash kept (10000 tendered, 3000 change) + 50000 in - 20000 out
+	if closed.Status != model.ShiftStatusClosed || *closed.ExpectedCash != 137000 || *closed.Difference != -1000 {
+		t.Errorf("Close() = status %s, expected %d, difference %d, want closed, 137000, -1000",
+			closed.Status, *closed.ExpectedCash, *closed.Difference)
+	}
+	summary := closed.Summary
+	if summary.TransactionCount != 2 || summary.TotalSales != 12000 || summary.CashSales != 7000 || len(summary.Payments) != 2 {
+		t.Errorf("Summary = %+v, want 2 transactions, 12000 sales, 7000 cash, 2 payment methods", summary)
+	}
+
+	_, err = transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{
+		Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}},
+	}, opts)
+	if !model.IsConflictError(err) {
+		t.Errorf("CreateTransaction() into closed shift error = %v, want conflict", err)
+	}
+	if _, err := repo.AddCashMovement(ctx, shift.ID, model.CashMovementRequest{Type: model.CashMovementOut, Amount: 1000, Reason: "Late"}); !model.IsConflictError(err) {
+		t.Errorf("AddCashMovement() on closed shift error = %v, want conflict", err)
+	}
+	if _, err := repo.FindOpen(ctx); !model.IsNotFoundError(err) {
+		t.Errorf("FindOpen() after close error = %v, want not found", err)
+	}
+}
+
+func TestShiftRepository_CashRefunds(t *testing.T) {
+	returnRepo, transactionRepo, _ := newTestReturnRepo(t)
+	repo := NewShiftRepository(transactionRepo)
+	transactionRepo.SetShiftRepo(repo)
+	ctx := context.Background()
+
+	first, _ := repo.Open(ctx, model.OpenShiftRequest{OpeningFloat: 100000})
+	opts := model.CheckoutOptions{ShiftID: &first.ID}
+	cashSale, _ := transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{
+		Items:    []model.CheckoutItem{{ProductID: 1, Quantity: 2}},
+		Payments: []model.CheckoutPayment{{Method: model.PaymentMethodCash, Amount: 10000}},
+	}, opts)
+	qrisSale, _ := transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{
+		Items:    []model.CheckoutItem{{ProductID: 2, Quantity: 1}},
+		Payments: []model.CheckoutPayment{{Method: model.PaymentMethodQRIS, Amount: 5000}},
+	}, opts)
+	voided, _ := transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{
+		Items:    []model.CheckoutItem{{ProductID: 1, Quantity: 1}},
+		Payments: []model.CheckoutPayment{{Method: model.PaymentMethodCash, Amount: 3500}},
+	}, opts)
+
+	if _, err := returnRepo.CreateReturn(ctx, cashSale.ID, model.ReturnRequest{
+		Items: []model.ReturnRequestItem{{ProductID: 1, Quantity: 1}}, Reason: "expired",
+	}); err != nil {
+		t.Fatalf("CreateReturn() error = %v", err)
+	}
+	if _, err := transactionRepo.CancelTransaction(ctx, voided.ID, model.TransactionStatusVoided, model.CancelRequest{Reason: "mistake"}); err != nil {
+		t.Fatalf("CancelTransaction() error = %v", err)
+	}
+
+	// 100000 float + 7000 cash kept - 3500 returned; the void drops its sale out
+	counted := 103500
+	closed, err := repo.Close(ctx, first.ID, model.CloseShiftRequest{CountedCash: &counted})
+	if err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if closed.Summary.CashRefunds != 3500 || *closed.ExpectedCash != 103500 {
+		t.Errorf("First shift refunds %d, expected %d, want 3500 and 103500", closed.Summary.CashRefunds, *closed.ExpectedCash)
+	}
+
+	// Refunding earlier sales pays back the rest of their cash from the new drawer
+	second, _ := repo.Open(ctx, model.OpenShiftRequest{OpeningFloat: 50000})
+	for _, id := range []int{cashSale.ID, qrisSale.ID} {
+		if _, err := transactionRepo.CancelTransaction(ctx, id, model.TransactionStatusRefunded, model.CancelRequest{Reason: "complaint"}); err != nil {
+			t.Fatalf("CancelTransaction() error = %v", err)
+		}
+	}
+
+	open, err := repo.FindByID(ctx, second.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if open.Summary.CashRefunds != 3500 || open.Summary.ExpectedCash != 46500 {
+		t.Errorf("Second shift refunds %d, expected %d, want 3500 and 46500", open.Summary.CashRefunds, open.Summary.ExpectedCash)
+	}
+}
+
+func TestShiftRepository_OutletScope(t *testing.T) {
+	transactionRepo, _ := newTestTransactionRepo(t)
+	repo := NewShiftRepository(transactionRepo)
+	first := middleware.WithOutletID(context.Background(), 1)
+	second := middleware.WithOutletID(context.Background(), 2)
+
+	repo.Open(first, model.OpenShiftRequest{OpeningFloat: 100000})
+	opened, _ := repo.Open(second, model.OpenShiftRequest{OpeningFloat: 50000})
+
+	if id, err := repo.FindOpenID(second); err != nil || id != opened.ID {
+		t.Errorf("FindOpenID() at outlet 2 = %d, %v, want %d", id, err, opened.ID)
+	}
+	if _, err := repo.FindOpenID(middleware.WithOutletID(context.Background(), 3)); !model.IsNotFoundError(err) {
+		t.Errorf("FindOpenID() at an outlet without a shift error = %v, want not found", err)
+	}
+
+	shifts, _ := repo.FindAll(second)
+	if len(shifts) != 1 || shifts[0].OutletID != 2 {
+		t.Errorf("FindAll() at outlet 2 = %+v, want only its shift", shifts)
+	}
+	if shifts, _ := repo.FindAll(context.Background()); len(shifts) != 2 {
+		t.Errorf("FindAll() unscoped = %d shifts, want 2", len(shifts))
+	}
+}
@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+func (r *ProductRepository) FindMovements(ctx context.Context, filter model.StockMovementFilter) ([]model.StockMovement, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matched := make([]model.StockMovement, 0)
+	for _, m := range r.movements {
+		if filter.Matches(m) {
+			matched = append(matched, m)
+		}
+	}
+
+	// Newest first, same as the PostgreSQL implementation
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].ID > matched[j].ID
+	})
+
+	total := len(matched)
+	start := min(filter.Offset(), total)
+	end := total
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, total)
+	}
+
+	return matched[start:end], total, nil
+}
+
+func (r *ProductRepository) PostMovements(ctx context.Context, productID int, movements []model.StockMovement) ([]model.StockMovement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexOf(productID) < 0 {
+		return nil, model.ErrNotFound
+	}
+
+	// Work out every balance first, so either all movements are posted or none
+	now := time.Now()
+	balances := make(map[stockKey]int)
+	posted := make([]model.StockMovement, len(movements))
+	for i, m := range movements {
+		key := stockKey{m.OutletID, m.ProductID}
+		balance, ok := balances[key]
+		if !ok {
+			balance = r.stock[key]
+		}
+		if balance+m.Quantity < 0 {
+			return nil, model.InsufficientStockError(m.ProductID, m.OutletID, balance, m.Quantity)
+		}
+		balances[key] = balance + m.Quantity
+
+		m.ID = r.nextMovementID + i
+		m.Balance = balance + m.Quantity
+		m.CreatedAt = now
+		posted[i] = m
+	}
+
+	for _, m := range posted {
+		if err := r.audit.record(ctx, model.AuditEntityProduct, productID, model.AuditActionStockMovement, nil, m); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range posted {
+		r.post(m)
+	}
+
+	return posted, nil
+}
+
+func (r *ProductRepository) CheckLedger(ctx context.Context) ([]model.StockDiscrepancy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	discrepancies := make([]model.StockDiscrepancy, 0)
+	sums := make(map[stockKey]int)
+	for _, m := range r.movements {
+		key := stockKey{m.OutletID, m.ProductID}
+		sums[key] += m.Quantity
+		if m.Balance != sums[key] {
+			discrepancies = append(discrepancies, model.StockDiscrepancy{
+				OutletID: m.OutletID, ProductID: m.ProductID, MovementID: m.ID, Recorded: m.Balance, Computed: sums[key],
+			})
+		}
+	}
+
+	keys := make([]stockKey, 0, len(r.stock))
+	for key := range r.stock {
+		keys = append(keys, key)
+	}
+	for key := range sums {
+		if _, ok := r.stock[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].outletID != keys[j].outletID {
+			return keys[i].outletID < keys[j].outletID
+		}
+		return keys[i].productID < keys[j].productID
+	})
+	for _, key := range keys {
+		if r.stock[key] != sums[key] {
+			discrepancies = append(discrepancies, model.StockDiscrepancy{
+				OutletID: key.outletID, ProductID: key.productID, Recorded: r.stock[key], Computed: sums[key],
+			})
+		}
+	}
+
+	return discrepancies, nil
+}
+
+func (r *ProductRepository) FindLowStock(ctx context.Context) ([]model.LowStockItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	outletID := model.OutletID(ctx)
+	items := make([]model.LowStockItem, 0)
+	for _, p := range r.data {
+		p.Stock = r.stockAt(outletID, p.ID)
+		if p.Active && p.IsLowStock() {
+			items = append(items, model.NewLowStockItem(p))
+		}
+	}
+
+	// Lowest stock first, same as the PostgreSQL implementation
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Stock != items[j].Stock {
+			return items[i].Stock < items[j].Stock
+		}
+		return items[i].ProductID < items[j].ProductID
+	})
+
+	return items, nil
+}
+
+func (r *ProductRepository) FindAlerts(ctx context.Context, filter model.StockAlertFilter) ([]model.StockAlert, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	filter = filter.WithDefaults()
+	outletID := model.OutletID(ctx)
+	alerts := make([]model.StockAlert, 0)
+	for i := len(r.alerts) - 1; i >= 0 && len(alerts) < filter.Limit; i-- {
+		a := r.alerts[i]
+		if a.OutletID != outletID || (filter.ProductID != 0 && a.ProductID != filter.ProductID) {
+			continue
+		}
+		alerts = append(alerts, a)
+	}
+
+	return alerts, nil
+}
+
+// raiseAlert stores a low stock alert, returning it with its ID and time. Callers
+// must hold r.mu for writing.
+func (r *ProductRepository) raiseAlert(a model.StockAlert) model.StockAlert {
+	a.ID = r.nextAlertID
+	a.CreatedAt = time.Now()
+	r.nextAlertID++
+	r.alerts = append(r.alerts, a)
+	return a
+}
+
+// post appends a movement to the ledger and applies it to the stock, returning it
+// with its ID and balance. This is the only place stock changes. Callers must
+// hold r.mu for writing and have checked that the stock covers the movement.
+func (r *ProductRepository) post(m model.StockMovement) model.StockMovement {
+	key := stockKey{m.OutletID, m.ProductID}
+	m.ID = r.nextMovementID
+	m.Balance = r.stock[key] + m.Quantity
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
+	r.nextMovementID++
+	r.stock[key] = m.Balance
+	r.movements = append(r.movements, m)
+	return m
+}
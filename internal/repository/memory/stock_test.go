@@ -0,0 +1,187 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/middleware"
+)
+
+func TestProductRepository_StockLedger(t *testing.T) {
+	repo, transactionRepo, productRepo := newTestReturnRepo(t)
+	ctx := context.Background()
+
+	transaction, _ := transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 3}}}, model.CheckoutOptions{})
+	ret, _ := repo.CreateReturn(ctx, transaction.ID, model.ReturnRequest{
+		Items:   []model.ReturnRequestItem{{ProductID: 1, Quantity: 1}},
+		Reason:  "wrong flavour",
+		Restock: true,
+	})
+	transactionRepo.CancelTransaction(ctx, transaction.ID, model.TransactionStatusRefunded, model.CancelRequest{Reason: "refund"})
+
+	product, _ := productRepo.FindByID(ctx, 1)
+	product.Stock = 7
+	productRepo.Update(ctx, 1, *product)
+
+	movements, total, err := productRepo.FindMovements(ctx, model.StockMovementFilter{ProductID: 1}.WithDefaults())
+	if err != nil {
+		t.Fatalf("FindMovements() error = %v", err)
+	}
+	if total != 5 {
+		t.Fatalf("Expected 5 movements, got %d: %+v", total, movements)
+	}
+
+	// Newest first: the update, the refund, the return, the sale and the opening stock
+	want := []struct {
+		movementType  model.StockMovementType
+		quantity      int
+		balance       int
+		referenceType model.StockReferenceType
+		referenceID   int
+	}{
+		{model.StockMovementAdjustment, -3, 7, "", 0},
+		{model.StockMovementReturn, 2, 10, model.StockReferenceTransaction, transaction.ID},
+		{model.StockMovementReturn, 1, 8, model.StockReferenceReturn, ret.ID},
+		{model.StockMovementSale, -3, 7, model.StockReferenceTransaction, transaction.ID},
+		{model.StockMovementAdjustment, 10, 10, "", 0},
+	}
+	for i, w := range want {
+		m := movements[i]
+		if m.Type != w.movementType || m.Quantity != w.quantity || m.Balance != w.balance || m.ReferenceType != w.referenceType {
+			t.Errorf("movements[%d] = %+v, want %+v", i, m, w)
+		}
+		if w.referenceID != 0 && (m.ReferenceID == nil || *m.ReferenceID != w.referenceID) {
+			t.Errorf("movements[%d].ReferenceID = %v, want %d", i, m.ReferenceID, w.referenceID)
+		}
+	}
+
+	discrepancies, _ := productRepo.CheckLedger(ctx)
+	if len(discrepancies) != 0 {
+		t.Errorf("CheckLedger() = %v, want none", discrepancies)
+	}
+}
+
+func TestProductRepository_PostMovements(t *testing.T) {
+	audit := NewAuditRepository()
+	repo := NewProductRepository()
+	repo.SetAuditLog(audit)
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{UserID: 2, Username: "rina", Role: string(model.RoleStockClerk)})
+	product, _ := repo.Create(ctx, model.Product{Name: "Indomie", Price: 3500, Stock: 10})
+
+	transfer := model.StockMovementRequest{Type: model.StockMovementTransfer, Quantity: 4, ToOutletID: 2}
+	posted, err := repo.PostMovements(ctx, product.ID, transfer.Movements(ctx, product.ID))
+	if err != nil {
+		t.Fatalf("PostMovements() error = %v", err)
+	}
+	if len(posted) != 2 || posted[0].Balance != 6 || posted[1].OutletID != 2 || posted[1].Balance != 4 || posted[1].Actor != "rina" {
+		t.Errorf("Unexpected transfer: %+v", posted)
+	}
+
+	// A transfer the source cannot cover posts nothing at either outlet
+	transfer.Quantity = 7
+	if _, err := repo.PostMovements(ctx, product.ID, transfer.Movements(ctx, product.ID)); !model.IsValidationError(err) {
+		t.Errorf("PostMovements() error = %v, want validation error", err)
+	}
+	if _, total, _ := repo.FindMovements(ctx, model.StockMovementFilter{ProductID: product.ID}); total != 3 {
+		t.Errorf("Expected 3 movements after the refused transfer, got %d", total)
+	}
+
+	if _, err := repo.PostMovements(ctx, 99, nil); err != model.ErrNotFound {
+		t.Errorf("PostMovements() for a missing product error = %v, want not found", err)
+	}
+
+	entries, total, _ := audit.FindAll(ctx, model.AuditFilter{Action: string(model.AuditActionStockMovement)}.WithDefaults())
+	if total != 2 || entries[0].Actor != "rina" {
+		t.Errorf("Expected 2 audit entries by rina, got %d: %+v", total, entries)
+	}
+}
+
+func TestProductRepository_CheckLedger(t *testing.T) {
+	repo := NewProductRepository()
+	ctx := context.Background()
+	repo.Create(ctx, model.Product{Name: "Indomie", Price: 3500, Stock: 10})
+	repo.Create(ctx, model.Product{Name: "Teh Botol", Price: 5000, Stock: 5})
+
+	// Stock changed behind the ledger's back, and a balance that was tampered with
+	repo.stock[stockKey{model.DefaultOutletID, 1}] = 12
+	repo.movements[1].Balance = 6
+
+	discrepancies, err := repo.CheckLedger(ctx)
+	if err != nil {
+		t.Fatalf("CheckLedger() error = %v", err)
+	}
+	if len(discrepancies) != 2 {
+		t.Fatalf("Expected 2 discrepancies, got %v", discrepancies)
+	}
+	if d := discrepancies[0]; d.MovementID != 2 || d.Recorded != 6 || d.Computed != 5 {
+		t.Errorf("Unexpected balance discrepancy: %+v", d)
+	}
+	if d := discrepancies[1]; d.ProductID != 1 || d.MovementID != 0 || d.Recorded != 12 || d.Computed != 10 {
+		t.Errorf("Unexpected stock discrepancy: %+v", d)
+	}
+}
+
+func TestProductRepository_LowStockAlerts(t *testing.T) {
+	transactionRepo, productRepo := newTestTransactionRepo(t)
+	ctx := context.Background()
+
+	// Indomie has 10 in stock; reorder at 5
+	indomie, _ := productRepo.FindByID(ctx, 1)
+	indomie.ReorderPoint, indomie.ReorderQty = 5, 24
+	productRepo.Update(ctx, 1, *indomie)
+
+	items, _ := productRepo.FindLowStock(ctx)
+	if len(items) != 0 {
+		t.Fatalf("FindLowStock() = %+v, want none", items)
+	}
+
+	transaction, err := transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{
+		{ProductID: 1, Quantity: 3},
+		{ProductID: 2, Quantity: 1},
+		{ProductID: 1, Quantity: 3},
+	}}, model.CheckoutOptions{})
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+	if len(transaction.LowStock) != 1 {
+		t.Fatalf("LowStock = %+v, want one alert", transaction.LowStock)
+	}
+	alert := transaction.LowStock[0]
+	if alert.ID == 0 || alert.ProductID != 1 || alert.Stock != 4 || alert.ReorderQty != 24 || *alert.TransactionID != transaction.ID {
+		t.Errorf("Unexpected alert: %+v", alert)
+	}
+
+	// Selling more while already low does not alert again
+	transaction, _ = transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}}, model.CheckoutOptions{})
+	if len(transaction.LowStock) != 0 {
+		t.Errorf("LowStock = %+v, want none once already low", transaction.LowStock)
+	}
+	stored, _ := transactionRepo.FindByID(ctx, transaction.ID)
+	if stored.LowStock != nil {
+		t.Error("alerts should only be on the checkout response")
+	}
+
+	items, _ = productRepo.FindLowStock(ctx)
+	if len(items) != 1 || items[0].ProductID != 1 || items[0].Stock != 3 {
+		t.Errorf("FindLowStock() = %+v, want Indomie at 3", items)
+	}
+
+	alerts, _ := productRepo.FindAlerts(ctx, model.StockAlertFilter{})
+	if len(alerts) != 1 {
+		t.Errorf("FindAlerts() = %d alerts, want 1", len(alerts))
+	}
+	alerts, _ = productRepo.FindAlerts(ctx, model.StockAlertFilter{ProductID: 2})
+	if len(alerts) != 0 {
+		t.Errorf("FindAlerts(product 2) = %d alerts, want 0", len(alerts))
+	}
+
+	// Other outlets keep their own stock and alerts
+	other := middleware.WithOutletID(ctx, 2)
+	if items, _ := productRepo.FindLowStock(other); len(items) != 1 || items[0].Stock != 0 {
+		t.Errorf("FindLowStock(outlet 2) = %+v, want Indomie at 0", items)
+	}
+	if alerts, _ := productRepo.FindAlerts(other, model.StockAlertFilter{}); len(alerts) != 0 {
+		t.Errorf("FindAlerts(outlet 2) = %d alerts, want 0", len(alerts))
+	}
+}
@@ -0,0 +1,227 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+// StocktakeRepository keeps stocktakes. Locks are always taken in the order
+// product repository, then stocktake repository, since opening snapshots the
+// stock and approving posts to it.
+type StocktakeRepository struct {
+	mu          sync.RWMutex
+	data        []model.Stocktake
+	nextID      int
+	productRepo *ProductRepository
+	audit       *AuditRepository
+}
+
+func NewStocktakeRepository(productRepo *ProductRepository) *StocktakeRepository {
+	return &StocktakeRepository{
+		data:        make([]model.Stocktake, 0),
+		nextID:      1,
+		productRepo: productRepo,
+	}
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *StocktakeRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
+func (r *StocktakeRepository) FindByID(ctx context.Context, id int) (*model.Stocktake, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	result := copyStocktake(r.data[idx]).Reviewed()
+	return &result, nil
+}
+
+// FindAll returns the stocktakes of the outlet of ctx, newest first, without their lines
+func (r *StocktakeRepository) FindAll(ctx context.Context) ([]model.Stocktake, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	outletID := model.OutletID(ctx)
+	stocktakes := make([]model.Stocktake, 0)
+	for _, st := range r.data {
+		if st.OutletID != outletID {
+			continue
+		}
+		st.Lines = nil
+		stocktakes = append(stocktakes, st)
+	}
+
+	// Newest first, same as the PostgreSQL implementation
+	sort.SliceStable(stocktakes, func(i, j int) bool {
+		return stocktakes[i].ID > stocktakes[j].ID
+	})
+	return stocktakes, nil
+}
+
+func (r *StocktakeRepository) Open(ctx context.Context, req model.OpenStocktakeRequest) (*model.Stocktake, error) {
+	r.productRepo.mu.RLock()
+	defer r.productRepo.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	outletID := model.OutletID(ctx)
+	if idx := r.openIndex(outletID); idx >= 0 {
+		return nil, model.StocktakeOpenError(r.data[idx].ID)
+	}
+
+	// Snapshot the system stock of every product in scope
+	lines := make([]model.StocktakeLine, 0)
+	for _, p := range r.productRepo.data {
+		if req.CategoryID != nil && (p.CategoryID == nil || *p.CategoryID != *req.CategoryID) {
+			continue
+		}
+		lines = append(lines, model.StocktakeLine{
+			ProductID:     p.ID,
+			ProductName:   p.Name,
+			UnitCost:      p.Cost,
+			SnapshotStock: r.productRepo.stockAt(outletID, p.ID),
+		})
+	}
+	st, err := model.NewStocktake(ctx, outletID, req, lines)
+	if err != nil {
+		return nil, err
+	}
+	st.ID = r.nextID
+	if err := r.audit.record(ctx, model.AuditEntityStocktake, st.ID, model.AuditActionCreate, nil, st.AuditRecord()); err != nil {
+		return nil, err
+	}
+	r.nextID++
+	r.data = append(r.data, st)
+
+	result := copyStocktake(st).Reviewed()
+	return &result, nil
+}
+
+func (r *StocktakeRepository) SaveCounts(ctx context.Context, id int, req model.StocktakeCountRequest) (*model.Stocktake, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+
+	st := copyStocktake(r.data[idx])
+	if err := st.ApplyCounts(ctx, req.Items, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := r.audit.record(ctx, model.AuditEntityStocktake, id, model.AuditActionCount, nil, req); err != nil {
+		return nil, err
+	}
+	r.data[idx] = st
+
+	result := copyStocktake(st).Reviewed()
+	return &result, nil
+}
+
+// Approve posts the variance of every counted line as one adjustment each, all or
+// none, and closes the stocktake
+func (r *StocktakeRepository) Approve(ctx context.Context, id int) (*model.Stocktake, error) {
+	r.productRepo.mu.Lock()
+	defer r.productRepo.mu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	st := copyStocktake(r.data[idx])
+	before := st.AuditRecord()
+	now := time.Now()
+	if err := st.Close(ctx, model.StocktakeStatusApproved, now); err != nil {
+		return nil, err
+	}
+
+	movements, err := st.Adjustments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Products deleted since the stocktake opened have no stock left to adjust
+	movements = slices.DeleteFunc(movements, func(m model.StockMovement) bool {
+		return r.productRepo.indexOf(m.ProductID) < 0
+	})
+	for _, m := range movements {
+		if current := r.productRepo.stockAt(m.OutletID, m.ProductID); current+m.Quantity < 0 {
+			return nil, model.InsufficientStockError(m.ProductID, m.OutletID, current, m.Quantity)
+		}
+	}
+
+	if err := r.audit.record(ctx, model.AuditEntityStocktake, id, model.AuditActionApprove, before, st.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	for _, m := range movements {
+		m.CreatedAt = now
+		r.productRepo.post(m)
+	}
+	r.data[idx] = st
+
+	result := copyStocktake(st).Reviewed()
+	return &result, nil
+}
+
+func (r *StocktakeRepository) Cancel(ctx context.Context, id int) (*model.Stocktake, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	st := copyStocktake(r.data[idx])
+	before := st.AuditRecord()
+	if err := st.Close(ctx, model.StocktakeStatusCancelled, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := r.audit.record(ctx, model.AuditEntityStocktake, id, model.AuditActionCancel, before, st.AuditRecord()); err != nil {
+		return nil, err
+	}
+	r.data[idx] = st
+
+	result := copyStocktake(st).Reviewed()
+	return &result, nil
+}
+
+func (r *StocktakeRepository) indexOf(id int) int {
+	for i, st := range r.data {
+		if st.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// openIndex returns the index of the open stocktake at an outlet, or -1.
+// Callers must hold r.mu.
+func (r *StocktakeRepository) openIndex(outletID int) int {
+	for i, st := range r.data {
+		if st.OutletID == outletID && st.Status == model.StocktakeStatusOpen {
+			return i
+		}
+	}
+	return -1
+}
+
+func copyStocktake(st model.Stocktake) model.Stocktake {
+	lines := make([]model.StocktakeLine, len(st.Lines))
+	copy(lines, st.Lines)
+	st.Lines = lines
+	return st
+}
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/middleware"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestStocktakeRepository_Approve(t *testing.T) {
+	transactionRepo, productRepo := newTestTransactionRepo(t)
+	audit := NewAuditRepository()
+	repo := NewStocktakeRepository(productRepo)
+	repo.SetAuditLog(audit)
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{UserID: 2, Username: "rina"})
+
+	st, err := repo.Open(ctx, model.OpenStocktakeRequest{Note: "month end"})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if len(st.Lines) != 3 || st.Lines[0].SnapshotStock != 10 || st.OpenedBy != "rina" {
+		t.Errorf("Unexpected stocktake: %+v", st)
+	}
+	if _, err := repo.Open(ctx, model.OpenStocktakeRequest{}); !model.IsConflictError(err) {
+		t.Errorf("Open() while another is open error = %v, want conflict", err)
+	}
+
+	// Three Indomie sell while the shelf is being counted, after the counter saw 9 of the 10
+	transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 3}}}, model.CheckoutOptions{})
+
+	st, err = repo.SaveCounts(ctx, st.ID, model.StocktakeCountRequest{Items: []model.StocktakeCountItem{
+		{ProductID: 1, CountedQuantity: intPtr(9), Reason: model.StockReasonLost},
+		{ProductID: 2, CountedQuantity: intPtr(5)},
+	}})
+	if err != nil {
+		t.Fatalf("SaveCounts() error = %v", err)
+	}
+	if st.Summary.Counted != 2 || st.Summary.VarianceQuantity != -1 {
+		t.Errorf("Unexpected summary: %+v", st.Summary)
+	}
+
+	approved, err := repo.Approve(ctx, st.ID)
+	if err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	if approved.Status != model.StocktakeStatusApproved || approved.ClosedBy != "rina" {
+		t.Errorf("Unexpected approved stocktake: %+v", approved)
+	}
+
+	// The variance lands on top of the sales: 10 - 3 sold - 1 lost
+	if product, _ := productRepo.FindByID(ctx, 1); product.Stock != 6 {
+		t.Errorf("Stock = %d, want 6", product.Stock)
+	}
+	if product, _ := productRepo.FindByID(ctx, 3); product.Stock != 5 {
+		t.Errorf("Stock of the uncounted product = %d, want 5", product.Stock)
+	}
+
+	movements, _, _ := productRepo.FindMovements(ctx, model.StockMovementFilter{ProductID: 1})
+	if m := movements[0]; m.Quantity != -1 || m.Reason != model.StockReasonLost || m.ReferenceType != model.StockReferenceStocktake || *m.ReferenceID != st.ID {
+		t.Errorf("Unexpected adjustment: %+v", m)
+	}
+	if discrepancies, _ := productRepo.CheckLedger(ctx); len(discrepancies) != 0 {
+		t.Errorf("CheckLedger() = %v, want none", discrepancies)
+	}
+
+	if _, err := repo.Approve(ctx, st.ID); !model.IsConflictError(err) {
+		t.Errorf("Approve() twice error = %v, want conflict", err)
+	}
+	if _, err := repo.SaveCounts(ctx, st.ID, model.StocktakeCountRequest{Items: []model.StocktakeCountItem{{ProductID: 1, CountedQuantity: intPtr(1)}}}); !model.IsConflictError(err) {
+		t.Errorf("SaveCounts() after approval error = %v, want conflict", err)
+	}
+
+	entries, total, _ := audit.FindAll(ctx, model.AuditFilter{Entity: string(model.AuditEntityStocktake)}.WithDefaults())
+	if total != 3 || entries[0].Action != model.AuditActionApprove {
+		t.Errorf("Expected create, count and approve entries, got %d: %+v", total, entries)
+	}
+}
+
+func TestStocktakeRepository_ApproveNeedsReasons(t *testing.T) {
+	_, productRepo := newTestTransactionRepo(t)
+	repo := NewStocktakeRepository(productRepo)
+	ctx := context.Background()
+
+	st, _ := repo.Open(ctx, model.OpenStocktakeRequest{})
+	repo.SaveCounts(ctx, st.ID, model.StocktakeCountRequest{Items: []model.StocktakeCountItem{{ProductID: 2, CountedQuantity: intPtr(7)}}})
+
+	if _, err := repo.Approve(ctx, st.ID); !model.IsValidationError(err) {
+		t.Errorf("Approve() without a reason error = %v, want validation", err)
+	}
+	if product, _ := productRepo.FindByID(ctx, 2); product.Stock != 5 {
+		t.Errorf("Stock = %d, want it unchanged at 5", product.Stock)
+	}
+
+	cancelled, err := repo.Cancel(ctx, st.ID)
+	if err != nil || cancelled.Status != model.StocktakeStatusCancelled {
+		t.Fatalf("Cancel() = %+v, %v", cancelled, err)
+	}
+	// A new stocktake can open once the last one is closed
+	if _, err := repo.Open(ctx, model.OpenStocktakeRequest{}); err != nil {
+		t.Errorf("Open() after cancelling error = %v", err)
+	}
+	if list, _ := repo.FindAll(ctx); len(list) != 2 || list[0].Lines != nil {
+		t.Errorf("Unexpected list: %+v", list)
+	}
+}
+
+func TestStocktakeRepository_OpenCategory(t *testing.T) {
+	productRepo := NewProductRepository()
+	repo := NewStocktakeRepository(productRepo)
+	ctx := context.Background()
+	drinks := 2
+	productRepo.Create(ctx, model.Product{Name: "Indomie", Price: 3500, Stock: 10})
+	productRepo.Create(ctx, model.Product{Name: "Teh Botol", Price: 5000, Stock: 5, CategoryID: &drinks})
+
+	st, err := repo.Open(ctx, model.OpenStocktakeRequest{CategoryID: &drinks})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if len(st.Lines) != 1 || st.Lines[0].ProductName != "Teh Botol" {
+		t.Errorf("Expected only the drinks, got %+v", st.Lines)
+	}
+	if _, err := repo.SaveCounts(ctx, st.ID, model.StocktakeCountRequest{Items: []model.StocktakeCountItem{{ProductID: 1, CountedQuantity: intPtr(1)}}}); !model.IsValidationError(err) {
+		t.Errorf("SaveCounts() outside the category error = %v, want validation", err)
+	}
+	repo.Cancel(ctx, st.ID)
+
+	snacks := 5
+	if _, err := repo.Open(ctx, model.OpenStocktakeRequest{CategoryID: &snacks}); !model.IsValidationError(err) {
+		t.Errorf("Open() of an empty category error = %v, want validation", err)
+	}
+}
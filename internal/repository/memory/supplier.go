@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type SupplierRepository struct {
+	mu     sync.RWMutex
+	data   []model.Supplier
+	nextID int
+	audit  *AuditRepository
+}
+
+func NewSupplierRepository() *SupplierRepository {
+	return &SupplierRepository{
+		data:   make([]model.Supplier, 0),
+		nextID: 1,
+	}
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *SupplierRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
+func (r *SupplierRepository) FindByID(ctx context.Context, id int) (*model.Supplier, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	result := r.data[idx]
+	return &result, nil
+}
+
+func (r *SupplierRepository) FindAll(ctx context.Context) ([]model.Supplier, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := make([]model.Supplier, len(r.data))
+	copy(results, r.data)
+	return results, nil
+}
+
+func (r *SupplierRepository) Create(ctx context.Context, s model.Supplier) (*model.Supplier, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s.ID = r.nextID
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = s.CreatedAt
+	if err := r.audit.record(ctx, model.AuditEntitySupplier, s.ID, model.AuditActionCreate, nil, s); err != nil {
+		return nil, err
+	}
+	r.nextID++
+	r.data = append(r.data, s)
+	return &s, nil
+}
+
+func (r *SupplierRepository) Update(ctx context.Context, id int, s model.Supplier) (*model.Supplier, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+
+	s.ID = id
+	s.CreatedAt = r.data[idx].CreatedAt
+	s.UpdatedAt = time.Now()
+	if err := r.audit.record(ctx, model.AuditEntitySupplier, id, model.AuditActionUpdate, r.data[idx], s); err != nil {
+		return nil, err
+	}
+	r.data[idx] = s
+	return &s, nil
+}
+
+// name returns the name of a supplier, or "" when it does not exist.
+// Callers must hold r.mu.
+func (r *SupplierRepository) name(id int) string {
+	if idx := r.indexOf(id); idx >= 0 {
+		return r.data[idx].Name
+	}
+	return ""
+}
+
+// indexOf returns the slice index of the supplier with the given ID, or -1.
+// Callers must hold r.mu.
+func (r *SupplierRepository) indexOf(id int) int {
+	for i := range r.data {
+		if r.data[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,455 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type TransactionRepository struct {
+	mu           sync.RWMutex
+	data         []model.Transaction
+	nextID       int
+	nextDetailID int
+	nextPayID    int
+	sequences    map[string]int // invoice sequence per store and business day
+	productRepo  *ProductRepository
+	returnRepo   *ReturnRepository
+	shiftRepo    *ShiftRepository
+	customerRepo *CustomerRepository
+	outletRepo   *OutletRepository
+	audit        *AuditRepository
+	idempotency  *IdempotencyRepository
+}
+
+func NewTransactionRepository(productRepo *ProductRepository) *TransactionRepository {
+	return &TransactionRepository{
+		data:         make([]model.Transaction, 0),
+		nextID:       1,
+		nextDetailID: 1,
+		nextPayID:    1,
+		sequences:    make(map[string]int),
+		productRepo:  productRepo,
+	}
+}
+
+// SetReturnRepo wires the return repository so cancellations skip units that were already returned
+func (r *TransactionRepository) SetReturnRepo(returnRepo *ReturnRepository) {
+	r.returnRepo = returnRepo
+}
+
+// SetShiftRepo wires the shift repository so checkout can refuse sales into a closed shift
+func (r *TransactionRepository) SetShiftRepo(shiftRepo *ShiftRepository) {
+	r.shiftRepo = shiftRepo
+}
+
+// SetCustomerRepo wires the customer repository so checkout can earn and redeem loyalty points
+func (r *TransactionRepository) SetCustomerRepo(customerRepo *CustomerRepository) {
+	r.customerRepo = customerRepo
+}
+
+// SetOutletRepo wires the outlet repository so checkout refuses unknown or inactive
+// outlets and numbers invoices with the outlet code
+func (r *TransactionRepository) SetOutletRepo(outletRepo *OutletRepository) {
+	r.outletRepo = outletRepo
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *TransactionRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
+// SetIdempotencyStore wires the store whose reserved keys checkouts complete
+func (r *TransactionRepository) SetIdempotencyStore(idempotency *IdempotencyRepository) {
+	r.idempotency = idempotency
+}
+
+func (r *TransactionRepository) CreateTransaction(ctx context.Context, req model.CheckoutRequest, opts model.CheckoutOptions) (*model.Transaction, error) {
+	items := req.Items
+	outletID := model.OutletID(ctx)
+
+	if r.outletRepo != nil {
+		outlet, err := r.outletRepo.FindByID(ctx, outletID)
+		if err != nil {
+			return nil, model.OutletNotFoundError(outletID)
+		}
+		if !outlet.Active {
+			return nil, model.OutletInactiveError(outlet.Code)
+		}
+		opts.Invoice.StoreCode = outlet.Code
+	}
+
+	// Hold the product lock for the whole checkout, mirroring SELECT ... FOR UPDATE
+	r.productRepo.mu.Lock()
+	defer r.productRepo.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if opts.ShiftID != nil && r.shiftRepo != nil {
+		r.shiftRepo.mu.RLock()
+		open := r.shiftRepo.isOpen(*opts.ShiftID)
+		r.shiftRepo.mu.RUnlock()
+		if !open {
+			return nil, model.ShiftClosedError(*opts.ShiftID)
+		}
+	}
+
+	// The customer stays locked until the points are booked, mirroring SELECT ... FOR UPDATE
+	customerIdx := -1
+	if req.CustomerID != nil {
+		if r.customerRepo == nil {
+			return nil, fmt.Errorf("%w: customer id %d not found", model.ErrNotFound, *req.CustomerID)
+		}
+		r.customerRepo.mu.Lock()
+		defer r.customerRepo.mu.Unlock()
+
+		customerIdx = r.customerRepo.indexOf(*req.CustomerID)
+		if customerIdx < 0 {
+			return nil, fmt.Errorf("%w: customer id %d not found", model.ErrNotFound, *req.CustomerID)
+		}
+		customer := r.customerRepo.data[customerIdx]
+		if customer.IsErased() {
+			return nil, model.CustomerErasedError(customer.ID)
+		}
+		if req.RedeemPoints > customer.Points {
+			return nil, model.InsufficientPointsError(customer.Points, req.RedeemPoints)
+		}
+	}
+
+	itemMap := make(map[int]int) // product_id -> total quantity
+	for _, item := range items {
+		itemMap[item.CatalogID()] += item.Quantity // Sum quantities for duplicate products
+	}
+
+	// Validate all products exist and have sufficient stock
+	lines := make([]model.BasketLine, 0, len(items))
+	details := make([]model.TransactionDetail, 0, len(items))
+
+	for _, item := range items {
+		productID := item.CatalogID()
+		idx := r.productRepo.indexOf(productID)
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: product id %d not found", model.ErrNotFound, productID)
+		}
+		product := r.productRepo.data[idx]
+
+		if err := item.CheckProduct(product, r.productRepo.hasVariants(productID)); err != nil {
+			return nil, err
+		}
+
+		if stock := r.productRepo.stockAt(outletID, productID); stock < itemMap[productID] {
+			return nil, fmt.Errorf("%w: insufficient stock for product %s (available: %d, requested: %d)",
+				model.ErrValidation, product.Name, stock, itemMap[productID])
+		}
+
+		lines = append(lines, model.BasketLine{
+			ProductID:    productID,
+			ParentID:     product.ParentID,
+			CategoryID:   product.CategoryID,
+			UnitPrice:    product.Price,
+			Quantity:     item.Quantity,
+			TaxExempt:    product.TaxExempt,
+			TaxInclusive: product.TaxInclusive,
+			Gross:        product.Price * item.Quantity,
+		})
+		detail := model.TransactionDetail{
+			ProductID:       productID,
+			ProductName:     product.Name,
+			ParentProductID: product.ParentID,
+			CategoryID:      product.CategoryID,
+			CategoryName:    r.productRepo.categoryName(ctx, product.CategoryID),
+			UnitPrice:       product.Price,
+			UnitCost:        product.Cost,
+			Quantity:        item.Quantity,
+		}
+		if product.ParentID != nil {
+			if i := r.productRepo.indexOf(*product.ParentID); i >= 0 {
+				detail.ParentProductName = r.productRepo.data[i].Name
+			}
+		}
+		details = append(details, detail)
+	}
+
+	now := time.Now()
+	applied := model.ApplyPromotions(lines, opts.Promotions, now)
+
+	redeemed, pointsDiscount, err := model.RedeemPoints(lines, req.RedeemPoints, opts.Loyalty)
+	if err != nil {
+		return nil, err
+	}
+
+	model.ApplyTax(lines, opts.TaxRate)
+
+	grossAmount, discountAmount, taxAmount, totalAmount := 0, 0, 0, 0
+	for i, line := range lines {
+		grossAmount += line.Gross
+		discountAmount += line.Discount
+		taxAmount += line.Tax
+		totalAmount += line.Total()
+		details[i].GrossAmount = line.Gross
+		details[i].DiscountAmount = line.Discount
+		details[i].TaxAmount = line.Tax
+		details[i].Subtotal = line.Total()
+	}
+
+	payments, change, err := model.SettlePayments(totalAmount, req.Payments)
+	if err != nil {
+		return nil, err
+	}
+
+	invoiceNumber, sequenceKey := r.nextInvoiceNumber(opts.Invoice, now)
+	transaction := model.Transaction{
+		ID:             r.nextID,
+		InvoiceNumber:  invoiceNumber,
+		OutletID:       outletID,
+		ShiftID:        opts.ShiftID,
+		CustomerID:     req.CustomerID,
+		GrossAmount:    grossAmount,
+		DiscountAmount: discountAmount,
+		TaxAmount:      taxAmount,
+		TotalAmount:    totalAmount,
+		PaidAmount:     totalAmount + change,
+		ChangeAmount:   change,
+		PointsRedeemed: redeemed,
+		PointsDiscount: pointsDiscount,
+		Status:         model.TransactionStatusCompleted,
+		CreatedAt:      now,
+		Promotions:     applied,
+	}
+	if customerIdx >= 0 {
+		transaction.PointsEarned = opts.Loyalty.Earn(totalAmount)
+	}
+
+	for i := range details {
+		details[i].ID = r.nextDetailID + i
+		details[i].TransactionID = transaction.ID
+	}
+	transaction.Details = details
+
+	for i := range payments {
+		payments[i].ID = r.nextPayID + i
+		payments[i].TransactionID = transaction.ID
+		payments[i].CreatedAt = transaction.CreatedAt
+	}
+	transaction.Payments = payments
+
+	// The key and the audit entry are the last things that can fail, so they are
+	// checked before anything is changed
+	if opts.Idempotency != nil {
+		if r.idempotency == nil {
+			return nil, model.IdempotencyReservationLostError()
+		}
+		r.idempotency.mu.Lock()
+		defer r.idempotency.mu.Unlock()
+		if err := r.idempotency.checkClaim(*opts.Idempotency); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.audit.record(ctx, model.AuditEntityTransaction, transaction.ID, model.AuditActionCreate, nil, transaction); err != nil {
+		return nil, err
+	}
+	if opts.Idempotency != nil {
+		r.idempotency.complete(opts.Idempotency.Key, transaction.ID)
+	}
+
+	r.nextID++
+	r.nextDetailID += len(details)
+	r.nextPayID += len(payments)
+	if sequenceKey != "" {
+		r.sequences[sequenceKey]++
+	}
+
+	before := make(map[int]int, len(itemMap))
+	for productID := range itemMap {
+		before[productID] = r.productRepo.stockAt(outletID, productID)
+	}
+	for _, item := range items {
+		r.productRepo.post(model.NewStockMovement(ctx, outletID, item.CatalogID(), model.StockMovementSale, -item.Quantity).
+			For(model.StockReferenceTransaction, transaction.ID))
+	}
+
+	if customerIdx >= 0 {
+		r.customerRepo.addPoints(customerIdx, model.LoyaltyEntryRedeem, -redeemed, transaction.ID, now)
+		r.customerRepo.addPoints(customerIdx, model.LoyaltyEntryEarn, transaction.PointsEarned, transaction.ID, now)
+	}
+
+	r.data = append(r.data, transaction)
+
+	result := copyTransaction(transaction)
+	result.LowStock = r.raiseLowStockAlerts(outletID, transaction.ID, before)
+	return &result, nil
+}
+
+// raiseLowStockAlerts stores an alert for every product the transaction took to
+// its reorder point, given the stock each product had before it. Callers must
+// hold the product lock for writing.
+func (r *TransactionRepository) raiseLowStockAlerts(outletID, transactionID int, before map[int]int) []model.StockAlert {
+	var alerts []model.StockAlert
+	for _, p := range r.productRepo.data {
+		stock, sold := before[p.ID]
+		if !sold {
+			continue
+		}
+		after := r.productRepo.stockAt(outletID, p.ID)
+		if p.ReachesReorderPoint(stock, after) {
+			alerts = append(alerts, r.productRepo.raiseAlert(model.NewStockAlert(p, outletID, after, transactionID)))
+		}
+	}
+	return alerts
+}
+
+func (r *TransactionRepository) CancelTransaction(ctx context.Context, id int, status model.TransactionStatus, req model.CancelRequest) (*model.Transaction, error) {
+	r.productRepo.mu.Lock()
+	defer r.productRepo.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 || !model.InScope(ctx, r.data[idx].OutletID) {
+		return nil, model.ErrNotFound
+	}
+	transaction := &r.data[idx]
+
+	if transaction.Status != model.TransactionStatusCompleted {
+		return nil, fmt.Errorf("%w: transaction %d is already %s", model.ErrConflict, id, transaction.Status)
+	}
+
+	now := time.Now()
+	if status == model.TransactionStatusVoided && transaction.CreatedAt.Format(time.DateOnly) != now.Format(time.DateOnly) {
+		return nil, fmt.Errorf("%w: transaction %d can only be voided on the day it was created, use refund instead", model.ErrValidation, id)
+	}
+
+	before := copyTransaction(*transaction)
+	result := copyTransaction(*transaction)
+	result.Cancel(ctx, status, req, now)
+	if err := r.audit.record(ctx, model.AuditEntityTransaction, id, model.CancelAuditAction(status), before, result); err != nil {
+		return nil, err
+	}
+
+	// Put back every sold unit that has not already been returned
+	returned := make(map[int]model.ReturnItem)
+	if r.returnRepo != nil {
+		r.returnRepo.mu.RLock()
+		returned = r.returnRepo.returnedByDetail()
+		r.returnRepo.mu.RUnlock()
+	}
+	for _, d := range transaction.Details {
+		if quantity := d.Quantity - returned[d.ID].Quantity; quantity > 0 && r.productRepo.indexOf(d.ProductID) >= 0 {
+			r.productRepo.post(model.NewStockMovement(ctx, transaction.OutletID, d.ProductID, model.StockMovementReturn, quantity).
+				For(model.StockReferenceTransaction, id))
+		}
+	}
+
+	// Take back the points the sale earned and give back the ones it used
+	if transaction.CustomerID != nil && r.customerRepo != nil {
+		r.customerRepo.mu.Lock()
+		if cIdx := r.customerRepo.indexOf(*transaction.CustomerID); cIdx >= 0 {
+			r.customerRepo.addPoints(cIdx, model.LoyaltyEntryReversal, transaction.PointsRedeemed-transaction.PointsEarned, id, now)
+		}
+		r.customerRepo.mu.Unlock()
+	}
+
+	transaction.Cancel(ctx, status, req, now)
+	return &result, nil
+}
+
+func (r *TransactionRepository) FindByID(ctx context.Context, id int) (*model.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, t := range r.data {
+		if t.ID == id && model.InScope(ctx, t.OutletID) {
+			result := copyTransaction(t)
+			return &result, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+func (r *TransactionRepository) FindAll(ctx context.Context, filter model.TransactionFilter) ([]model.Transaction, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matched := make([]model.Transaction, 0)
+	for _, t := range r.data {
+		if filter.Matches(t) {
+			matched = append(matched, t)
+		}
+	}
+
+	// Newest first, same as the PostgreSQL implementation
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].ID > matched[j].ID
+		}
+		return matched[i].CreatedAt.After(matched[j].CreatedAt)
+	})
+
+	total := len(matched)
+	start := min(filter.Offset(), total)
+	end := total
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, total)
+	}
+
+	results := make([]model.Transaction, 0, end-start)
+	for _, t := range matched[start:end] {
+		results = append(results, copyTransaction(t))
+	}
+
+	return results, total, nil
+}
+
+// nextInvoiceNumber returns the next number in the store's sequence for the
+// business day, and the key of that sequence. Callers must hold r.mu and advance
+// r.sequences[key] only once checkout can no longer fail, which keeps the
+// sequence gapless. Both are empty when invoice numbering is off.
+func (r *TransactionRepository) nextInvoiceNumber(numbering model.InvoiceNumbering, now time.Time) (string, string) {
+	if numbering.Pattern.IsZero() {
+		return "", ""
+	}
+	key := numbering.StoreCode + "/" + model.BusinessDate(now)
+	return numbering.Pattern.Format(numbering.StoreCode, now, r.sequences[key]+1), key
+}
+
+// indexOf returns the slice index of the transaction with the given ID, or -1.
+// Callers must hold r.mu.
+func (r *TransactionRepository) indexOf(id int) int {
+	for i := range r.data {
+		if r.data[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyTransaction returns a copy that does not share its slices with the store
+func copyTransaction(t model.Transaction) model.Transaction {
+	details := make([]model.TransactionDetail, len(t.Details))
+	copy(details, t.Details)
+	t.Details = details
+
+	payments := make([]model.Payment, len(t.Payments))
+	copy(payments, t.Payments)
+	t.Payments = payments
+
+	if t.ShiftID != nil {
+		shiftID := *t.ShiftID
+		t.ShiftID = &shiftID
+	}
+	if t.CustomerID != nil {
+		customerID := *t.CustomerID
+		t.CustomerID = &customerID
+	}
+
+	promotions := make([]model.AppliedPromotion, len(t.Promotions))
+	copy(promotions, t.Promotions)
+	t.Promotions = promotions
+	return t
+}
@@ -0,0 +1,436 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/middleware"
+)
+
+func newTestTransactionRepo(t *testing.T) (*TransactionRepository, *ProductRepository) {
+	t.Helper()
+
+	productRepo := NewProductRepository()
+	ctx := context.Background()
+	productRepo.Create(ctx, model.Product{Name: "Indomie", Price: 3500, Stock: 10, Active: true})
+	productRepo.Create(ctx, model.Product{Name: "Teh Botol", Price: 5000, Stock: 5, Active: true})
+	productRepo.Create(ctx, model.Product{Name: "Discontinued", Price: 1000, Stock: 5, Active: false})
+
+	return NewTransactionRepository(productRepo), productRepo
+}
+
+func TestTransactionRepository_CreateTransaction(t *testing.T) {
+	repo, productRepo := newTestTransactionRepo(t)
+	ctx := context.Background()
+
+	transaction, err := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 1},
+	}}, model.CheckoutOptions{})
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	if transaction.ID == 0 {
+		t.Error("Created transaction should have ID")
+	}
+	if transaction.TotalAmount != 12000 {
+		t.Errorf("TotalAmount = %v, want 12000", transaction.TotalAmount)
+	}
+	if len(transaction.Details) != 2 {
+		t.Fatalf("Details = %d, want 2", len(transaction.Details))
+	}
+	if transaction.Details[0].ProductName != "Indomie" || transaction.Details[0].TransactionID != transaction.ID {
+		t.Errorf("Unexpected detail: %+v", transaction.Details[0])
+	}
+
+	product, _ := productRepo.FindByID(ctx, 1)
+	if product.Stock != 8 {
+		t.Errorf("Stock = %v, want 8", product.Stock)
+	}
+}
+
+func TestTransactionRepository_CreateTransaction_Payments(t *testing.T) {
+	repo, productRepo := newTestTransactionRepo(t)
+	ctx := context.Background()
+
+	transaction, err := repo.CreateTransaction(ctx, model.CheckoutRequest{
+		Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}},
+		Payments: []model.CheckoutPayment{
+			{Method: model.PaymentMethodQRIS, Amount: 5000, Reference: "QR-1"},
+			{Method: model.PaymentMethodCash, Amount: 5000},
+		},
+	}, model.CheckoutOptions{})
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+	if transaction.PaidAmount != 10000 || transaction.ChangeAmount != 3000 {
+		t.Errorf("Paid/Change = %v/%v, want 10000/3000", transaction.PaidAmount, transaction.ChangeAmount)
+	}
+	if len(transaction.Payments) != 2 || transaction.Payments[0].TransactionID != transaction.ID {
+		t.Errorf("Unexpected payments: %+v", transaction.Payments)
+	}
+
+	// Underpayment is rejected before stock moves
+	_, err = repo.CreateTransaction(ctx, model.CheckoutRequest{
+		Items:    []model.CheckoutItem{{ProductID: 1, Quantity: 1}},
+		Payments: []model.CheckoutPayment{{Method: model.PaymentMethodCash, Amount: 1000}},
+	}, model.CheckoutOptions{})
+	if !model.IsValidationError(err) {
+		t.Errorf("CreateTransaction() error = %v, want validation error", err)
+	}
+	product, _ := productRepo.FindByID(ctx, 1)
+	if product.Stock != 8 {
+		t.Errorf("Stock = %v, want 8", product.Stock)
+	}
+}
+
+func TestTransactionRepository_CreateTransaction_Tax(t *testing.T) {
+	repo, productRepo := newTestTransactionRepo(t)
+	ctx := context.Background()
+
+	// Indomie stays tax-exclusive, Teh Botol is priced with PPN included, rice is exempt
+	productRepo.Update(ctx, 2, model.Product{Name: "Teh Botol", Price: 5550, Stock: 5, Active: true, TaxInclusive: true})
+	rice, _ := productRepo.Create(ctx, model.Product{Name: "Beras", Price: 12000, Stock: 5, Active: true, TaxExempt: true})
+
+	transaction, err := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 1},
+		{ProductID: rice.ID, Quantity: 1},
+	}}, model.CheckoutOptions{TaxRate: 1100})
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	wantTax := []int{770, 550, 0}
+	for i, want := range wantTax {
+		if transaction.Details[i].TaxAmount != want {
+			t.Errorf("line %d tax = %d, want %d", i, transaction.Details[i].TaxAmount, want)
+		}
+	}
+	if transaction.TaxAmount != 1320 {
+		t.Errorf("TaxAmount = %d, want 1320", transaction.TaxAmount)
+	}
+	// 7000 + 770 exclusive tax, 5550 inclusive, 12000 exempt
+	if transaction.TotalAmount != 25320 {
+		t.Errorf("TotalAmount = %d, want 25320", transaction.TotalAmount)
+	}
+}
+
+func TestTransactionRepository_CreateTransaction_InvoiceNumber(t *testing.T) {
+	repo, _ := newTestTransactionRepo(t)
+	ctx := context.Background()
+	opts := model.CheckoutOptions{Invoice: model.DefaultInvoiceNumbering()}
+	today := time.Now().Format("20060102")
+
+	first, err := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}}, opts)
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	// A failed checkout must not use up a number
+	if _, err := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 2, Quantity: 99}}}, opts); err == nil {
+		t.Fatal("CreateTransaction() expected insufficient stock error")
+	}
+
+	second, err := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}}, opts)
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	if first.InvoiceNumber != "INV/MAIN/"+today+"/00001" || second.InvoiceNumber != "INV/MAIN/"+today+"/00002" {
+		t.Errorf("invoice numbers = %q, %q, want 00001 and 00002", first.InvoiceNumber, second.InvoiceNumber)
+	}
+
+	found, total, _ := repo.FindAll(ctx, model.TransactionFilter{Invoice: "/00002"})
+	if total != 1 || found[0].ID != second.ID {
+		t.Errorf("FindAll(invoice) = %+v, want only the second transaction", found)
+	}
+}
+
+func TestTransactionRepository_CreateTransaction_Snapshot(t *testing.T) {
+	repo, productRepo := newTestTransactionRepo(t)
+	ctx := context.Background()
+
+	categoryRepo := NewCategoryRepository()
+	category, _ := categoryRepo.Create(ctx, model.Category{Name: "Food"})
+	productRepo.SetCategoryRepo(categoryRepo)
+	productRepo.Update(ctx, 1, model.Product{Name: "Indomie", Price: 3500, Cost: 2800, Stock: 10, Active: true, CategoryID: &category.ID})
+
+	transaction, err := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}}}, model.CheckoutOptions{})
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	// Renaming and repricing the product afterwards must not rewrite history
+	productRepo.Update(ctx, 1, model.Product{Name: "Indomie Goreng", Price: 4000, Cost: 3000, Stock: 8, Active: true})
+
+	found, _ := repo.FindByID(ctx, transaction.ID)
+	d := found.Details[0]
+	if d.ProductName != "Indomie" || d.UnitPrice != 3500 || d.UnitCost != 2800 || d.CategoryName != "Food" ||
+		d.CategoryID == nil || *d.CategoryID != category.ID {
+		t.Errorf("detail = %+v, want the product as it was at sale time", d)
+	}
+
+	report, _ := NewReportRepository(repo).GetTodayReport(ctx)
+	if report.TopProduct == nil || report.TopProduct.Name != "Indomie" {
+		t.Errorf("TopProduct = %+v, want the name at sale time", report.TopProduct)
+	}
+}
+
+func TestTransactionRepository_CreateTransaction_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []model.CheckoutItem
+		checkFn func(error) bool
+	}{
+		{
+			name:    "product not found",
+			items:   []model.CheckoutItem{{ProductID: 999, Quantity: 1}},
+			checkFn: model.IsNotFoundError,
+		},
+		{
+			name:    "inactive product",
+			items:   []model.CheckoutItem{{ProductID: 3, Quantity: 1}},
+			checkFn: model.IsValidationError,
+		},
+		{
+			name:    "insufficient stock",
+			items:   []model.CheckoutItem{{ProductID: 2, Quantity: 6}},
+			checkFn: model.IsValidationError,
+		},
+		{
+			name:    "insufficient stock across duplicate lines",
+			items:   []model.CheckoutItem{{ProductID: 2, Quantity: 3}, {ProductID: 2, Quantity: 3}},
+			checkFn: model.IsValidationError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, productRepo := newTestTransactionRepo(t)
+			ctx := context.Background()
+
+			_, err := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: append([]model.CheckoutItem{{ProductID: 1, Quantity: 1}}, tt.items...)}, model.CheckoutOptions{})
+			if !tt.checkFn(err) {
+				t.Errorf("CreateTransaction() error = %v", err)
+			}
+
+			// A failed checkout must not touch stock
+			product, _ := productRepo.FindByID(ctx, 1)
+			if product.Stock != 10 {
+				t.Errorf("Stock = %v, want 10", product.Stock)
+			}
+		})
+	}
+}
+
+func TestTransactionRepository_CreateTransaction_Concurrent(t *testing.T) {
+	repo, productRepo := newTestTransactionRepo(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 2, Quantity: 1}}}, model.CheckoutOptions{}); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 5 {
+		t.Errorf("succeeded = %d, want 5", succeeded)
+	}
+	product, _ := productRepo.FindByID(ctx, 2)
+	if product.Stock != 0 {
+		t.Errorf("Stock = %v, want 0", product.Stock)
+	}
+}
+
+func TestTransactionRepository_FindByID(t *testing.T) {
+	repo, _ := newTestTransactionRepo(t)
+	ctx := context.Background()
+
+	created, _ := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}}, model.CheckoutOptions{})
+
+	found, err := repo.FindByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if found.ID != created.ID || len(found.Details) != 1 {
+		t.Errorf("Unexpected transaction: %+v", found)
+	}
+
+	_, err = repo.FindByID(ctx, 999)
+	if err != model.ErrNotFound {
+		t.Errorf("FindByID() error = %v, want %v", err, model.ErrNotFound)
+	}
+}
+
+func TestTransactionRepository_FindAll(t *testing.T) {
+	repo, _ := newTestTransactionRepo(t)
+	ctx := context.Background()
+
+	repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}}, model.CheckoutOptions{})
+	repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 2, Quantity: 1}}}, model.CheckoutOptions{})
+	repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}}}, model.CheckoutOptions{})
+
+	all, total, err := repo.FindAll(ctx, model.TransactionFilter{}.WithDefaults())
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if total != 3 || len(all) != 3 {
+		t.Fatalf("FindAll() total = %d len = %d, want 3", total, len(all))
+	}
+	if all[0].ID != 3 {
+		t.Errorf("First transaction ID = %d, want 3 (newest first)", all[0].ID)
+	}
+
+	productID := 1
+	byProduct, total, _ := repo.FindAll(ctx, model.TransactionFilter{ProductID: &productID}.WithDefaults())
+	if total != 2 || len(byProduct) != 2 {
+		t.Errorf("FindAll(product_id=1) total = %d, want 2", total)
+	}
+
+	minAmount := 5000
+	byAmount, total, _ := repo.FindAll(ctx, model.TransactionFilter{MinAmount: &minAmount}.WithDefaults())
+	if total != 2 || len(byAmount) != 2 {
+		t.Errorf("FindAll(min_amount=5000) total = %d, want 2", total)
+	}
+
+	page, total, _ := repo.FindAll(ctx, model.TransactionFilter{Page: 2, Limit: 2})
+	if total != 3 || len(page) != 1 {
+		t.Errorf("FindAll(page=2, limit=2) total = %d len = %d, want 3 and 1", total, len(page))
+	}
+}
+
+func TestTransactionRepository_CancelTransaction(t *testing.T) {
+	repo, productRepo := newTestTransactionRepo(t)
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{UserID: 3, Username: "supervisor"})
+
+	created, _ := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 3}, {ProductID: 1, Quantity: 1}}}, model.CheckoutOptions{})
+
+	cancelled, err := repo.CancelTransaction(ctx, created.ID, model.TransactionStatusVoided, model.CancelRequest{Reason: "wrong item"})
+	if err != nil {
+		t.Fatalf("CancelTransaction() error = %v", err)
+	}
+	if cancelled.Status != model.TransactionStatusVoided || cancelled.CancelledAt == nil || cancelled.CancelledBy != "supervisor" {
+		t.Errorf("Unexpected transaction: %+v", cancelled)
+	}
+
+	product, _ := productRepo.FindByID(ctx, 1)
+	if product.Stock != 10 {
+		t.Errorf("Stock = %v, want 10", product.Stock)
+	}
+
+	// A second cancellation must not restore stock twice
+	_, err = repo.CancelTransaction(ctx, created.ID, model.TransactionStatusRefunded, model.CancelRequest{Reason: "again"})
+	if !model.IsConflictError(err) {
+		t.Errorf("CancelTransaction() error = %v, want conflict", err)
+	}
+	product, _ = productRepo.FindByID(ctx, 1)
+	if product.Stock != 10 {
+		t.Errorf("Stock = %v, want 10", product.Stock)
+	}
+
+	_, err = repo.CancelTransaction(ctx, 999, model.TransactionStatusRefunded, model.CancelRequest{})
+	if err != model.ErrNotFound {
+		t.Errorf("CancelTransaction() error = %v, want %v", err, model.ErrNotFound)
+	}
+}
+
+func TestTransactionRepository_OutletScope(t *testing.T) {
+	repo, productRepo := newTestTransactionRepo(t)
+	ctx := middleware.WithOutletID(context.Background(), 1)
+	other := middleware.WithOutletID(context.Background(), 2)
+
+	created, _ := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 3}}}, model.CheckoutOptions{})
+
+	if _, err := repo.FindByID(other, created.ID); err != model.ErrNotFound {
+		t.Errorf("FindByID() from another outlet error = %v, want %v", err, model.ErrNotFound)
+	}
+	if _, err := repo.CancelTransaction(other, created.ID, model.TransactionStatusVoided, model.CancelRequest{Reason: "not ours"}); err != model.ErrNotFound {
+		t.Errorf("CancelTransaction() from another outlet error = %v, want %v", err, model.ErrNotFound)
+	}
+	product, _ := productRepo.FindByID(ctx, 1)
+	if product.Stock != 7 {
+		t.Errorf("Stock = %v, want 7", product.Stock)
+	}
+
+	// An unscoped context sees every outlet
+	if _, err := repo.FindByID(context.Background(), created.ID); err != nil {
+		t.Errorf("FindByID() unscoped error = %v", err)
+	}
+}
+
+func TestTransactionRepository_CancelTransaction_VoidOnlySameDay(t *testing.T) {
+	repo, _ := newTestTransactionRepo(t)
+	ctx := context.Background()
+
+	created, _ := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}}, model.CheckoutOptions{})
+	repo.data[0].CreatedAt = time.Now().AddDate(0, 0, -1)
+
+	req := model.CancelRequest{Reason: "late"}
+	_, err := repo.CancelTransaction(ctx, created.ID, model.TransactionStatusVoided, req)
+	if !model.IsValidationError(err) {
+		t.Errorf("CancelTransaction(voided) error = %v, want validation error", err)
+	}
+
+	refunded, err := repo.CancelTransaction(ctx, created.ID, model.TransactionStatusRefunded, req)
+	if err != nil {
+		t.Fatalf("CancelTransaction(refunded) error = %v", err)
+	}
+	if refunded.Status != model.TransactionStatusRefunded {
+		t.Errorf("Status = %v, want refunded", refunded.Status)
+	}
+}
+
+func TestTransactionRepository_CreateTransaction_Variant(t *testing.T) {
+	repo, productRepo := newTestTransactionRepo(t)
+	ctx := context.Background()
+
+	parent, _ := productRepo.Create(ctx, model.Product{
+		Name: "Mie Sedaap", Price: 3200, Active: true,
+		Options: []model.ProductOption{{Name: "flavour", Values: []string{"Goreng", "Soto"}}},
+	})
+	soto, _ := productRepo.CreateVariant(ctx, parent.ID, model.ProductVariantRequest{Attributes: map[string]string{"flavour": "Soto"}, Stock: 4})
+
+	transaction, err := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{
+		{ProductID: parent.ID, VariantID: &soto.ID, Quantity: 3},
+	}}, model.CheckoutOptions{})
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+	d := transaction.Details[0]
+	if d.ProductID != soto.ID || d.ProductName != "Mie Sedaap Soto" || d.ParentProductID == nil || *d.ParentProductID != parent.ID || d.ParentProductName != "Mie Sedaap" {
+		t.Errorf("Unexpected detail: %+v", d)
+	}
+	if p, _ := productRepo.FindByID(ctx, soto.ID); p.Stock != 1 {
+		t.Errorf("Variant stock = %d, want 1", p.Stock)
+	}
+
+	// Transactions filtered by the product include sales of its variants
+	_, total, _ := repo.FindAll(ctx, model.TransactionFilter{ProductID: &parent.ID}.WithDefaults())
+	if total != 1 {
+		t.Errorf("FindAll() by product = %d transactions, want 1", total)
+	}
+
+	// A product with variants is sold through one of them
+	if _, err := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: parent.ID, Quantity: 1}}}, model.CheckoutOptions{}); !model.IsValidationError(err) {
+		t.Errorf("CreateTransaction() of a product with variants error = %v, want a validation error", err)
+	}
+	other := 1
+	if _, err := repo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: other, VariantID: &soto.ID, Quantity: 1}}}, model.CheckoutOptions{}); !model.IsValidationError(err) {
+		t.Errorf("CreateTransaction() of another product's variant error = %v, want a validation error", err)
+	}
+}
@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type UserRepository struct {
+	mu     sync.RWMutex
+	data   []model.User
+	nextID int
+	audit  *AuditRepository
+}
+
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		data:   make([]model.User, 0),
+		nextID: 1,
+	}
+}
+
+// SetAuditLog wires the audit log that changes are appended to
+func (r *UserRepository) SetAuditLog(audit *AuditRepository) {
+	r.audit = audit
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id int) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	result := r.data[idx]
+	return &result, nil
+}
+
+func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.data {
+		if u.Username == username {
+			return &u, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+func (r *UserRepository) FindAll(ctx context.Context) ([]model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := make([]model.User, len(r.data))
+	copy(results, r.data)
+	return results, nil
+}
+
+func (r *UserRepository) Count(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.data), nil
+}
+
+func (r *UserRepository) Create(ctx context.Context, u model.User) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.usernameTaken(u.Username, 0) {
+		return nil, model.UsernameTakenError(u.Username)
+	}
+
+	now := time.Now()
+	u.ID = r.nextID
+	u.CreatedAt = now
+	u.UpdatedAt = now
+	if err := r.audit.record(ctx, model.AuditEntityUser, u.ID, model.AuditActionCreate, nil, u); err != nil {
+		return nil, err
+	}
+	r.nextID++
+	r.data = append(r.data, u)
+	return &u, nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, id int, u model.User) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	if r.usernameTaken(u.Username, id) {
+		return nil, model.UsernameTakenError(u.Username)
+	}
+
+	current := r.data[idx]
+	if current.IsActiveOwner() && !u.Active && r.activeOwners() == 1 {
+		return nil, model.LastOwnerError()
+	}
+
+	u.ID = id
+	u.Role = current.Role
+	u.LastLoginAt = current.LastLoginAt
+	u.CreatedAt = current.CreatedAt
+	u.UpdatedAt = time.Now()
+	if err := r.audit.record(ctx, model.AuditEntityUser, id, model.AuditActionUpdate, current, u); err != nil {
+		return nil, err
+	}
+	r.data[idx] = u
+	return &u, nil
+}
+
+func (r *UserRepository) SetRole(ctx context.Context, id int, role model.Role) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	if r.data[idx].IsActiveOwner() && role != model.RoleOwner && r.activeOwners() == 1 {
+		return nil, model.LastOwnerError()
+	}
+
+	updated := r.data[idx]
+	updated.Role = role
+	updated.UpdatedAt = time.Now()
+	if err := r.audit.record(ctx, model.AuditEntityUser, id, model.AuditActionAssignRole, r.data[idx], updated); err != nil {
+		return nil, err
+	}
+	r.data[idx] = updated
+	return &updated, nil
+}
+
+func (r *UserRepository) RecordLoginFailure(ctx context.Context, id int, policy model.LockoutPolicy, at time.Time) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return nil, model.ErrNotFound
+	}
+	policy.RegisterFailure(&r.data[idx], at)
+	result := r.data[idx]
+	return &result, nil
+}
+
+func (r *UserRepository) RecordLogin(ctx context.Context, id int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idx := r.indexOf(id)
+	if idx < 0 {
+		return model.ErrNotFound
+	}
+	model.RegisterLogin(&r.data[idx], at)
+	return nil
+}
+
+// usernameTaken reports whether another user than exceptID uses the username.
+// Callers must hold r.mu.
+func (r *UserRepository) usernameTaken(username string, exceptID int) bool {
+	for _, u := range r.data {
+		if u.ID != exceptID && u.Username == username {
+			return true
+		}
+	}
+	return false
+}
+
+// activeOwners counts the users who are active owners. Callers must hold r.mu.
+func (r *UserRepository) activeOwners() int {
+	count := 0
+	for _, u := range r.data {
+		if u.IsActiveOwner() {
+			count++
+		}
+	}
+	return count
+}
+
+// indexOf returns the slice index of the user with the given ID, or -1.
+// Callers must hold r.mu.
+func (r *UserRepository) indexOf(id int) int {
+	for i := range r.data {
+		if r.data[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+func TestUserRepository_RecordLoginFailure(t *testing.T) {
+	repo := NewUserRepository()
+	ctx := context.Background()
+	now := time.Now()
+	policy := model.LockoutPolicy{MaxFailedLogins: 2, Duration: time.Minute}
+
+	user, _ := repo.Create(ctx, model.User{Username: "siti", Name: "Siti", Active: true})
+	if _, err := repo.Create(ctx, model.User{Username: "siti", Name: "Other"}); !model.IsConflictError(err) {
+		t.Errorf("Create() with a used username error = %v, want conflict", err)
+	}
+
+	repo.RecordLoginFailure(ctx, user.ID, policy, now)
+	locked, err := repo.RecordLoginFailure(ctx, user.ID, policy, now)
+	if err != nil || !locked.IsLocked(now) {
+		t.Fatalf("RecordLoginFailure() = %+v, %v, want a locked user", locked, err)
+	}
+
+	if err := repo.RecordLogin(ctx, user.ID, now.Add(time.Minute)); err != nil {
+		t.Fatalf("RecordLogin() error = %v", err)
+	}
+	found, _ := repo.FindByUsername(ctx, "siti")
+	if found.LockedUntil != nil || found.LastLoginAt == nil {
+		t.Errorf("after login = %+v, want unlocked with a login time", found)
+	}
+}
+
+func TestUserRepository_KeepsLastOwner(t *testing.T) {
+	repo := NewUserRepository()
+	ctx := context.Background()
+
+	owner, _ := repo.Create(ctx, model.User{Username: "owner", Name: "Owner", Role: model.RoleOwner, Active: true})
+	second, _ := repo.Create(ctx, model.User{Username: "budi", Name: "Budi", Role: model.RoleCashier, Active: true})
+
+	if _, err := repo.SetRole(ctx, owner.ID, model.RoleManager); !model.IsConflictError(err) {
+		t.Errorf("SetRole() demoting the last owner error = %v, want conflict", err)
+	}
+	deactivated := *owner
+	deactivated.Active = false
+	if _, err := repo.Update(ctx, owner.ID, deactivated); !model.IsConflictError(err) {
+		t.Errorf("Update() deactivating the last owner error = %v, want conflict", err)
+	}
+
+	// With a second owner the first can step down
+	if _, err := repo.SetRole(ctx, second.ID, model.RoleOwner); err != nil {
+		t.Fatalf("SetRole() error = %v", err)
+	}
+	demoted, err := repo.SetRole(ctx, owner.ID, model.RoleManager)
+	if err != nil || demoted.Role != model.RoleManager {
+		t.Errorf("SetRole() = %+v, %v, want a manager", demoted, err)
+	}
+
+	// Update never changes the role
+	renamed := *demoted
+	renamed.Role = model.RoleOwner
+	updated, _ := repo.Update(ctx, owner.ID, renamed)
+	if updated.Role != model.RoleManager {
+		t.Errorf("Update() role = %s, want it kept as manager", updated.Role)
+	}
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"kasir-api/internal/model"
+)
+
+// AuditRepository reads the audit log. Entries are written by the other
+// repositories through recordAudit, inside the transaction of each change; the
+// table rejects updates and deletes.
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+const auditColumns = "id, occurred_at, actor_type, COALESCE(actor_id, 0), COALESCE(actor, ''), COALESCE(request_id, ''), entity, entity_id, action, changes"
+
+func scanAuditEntry(row rowScanner) (*model.AuditEntry, error) {
+	var e model.AuditEntry
+	var changes []byte
+	if err := row.Scan(&e.ID, &e.OccurredAt, &e.ActorType, &e.ActorID, &e.Actor, &e.RequestID, &e.Entity, &e.EntityID, &e.Action, &changes); err != nil {
+		return nil, err
+	}
+	// Numbers stay json.Number, the same as when the entry was written
+	decoder := json.NewDecoder(bytes.NewReader(changes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&e.Changes); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *AuditRepository) FindAll(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, int, error) {
+	where := " WHERE 1=1"
+	args := []any{}
+	argPos := 1
+
+	if filter.Entity != "" {
+		where += fmt.Sprintf(" AND entity = $%d", argPos)
+		args = append(args, filter.Entity)
+		argPos++
+	}
+	if filter.EntityID != nil {
+		where += fmt.Sprintf(" AND entity_id = $%d", argPos)
+		args = append(args, *filter.EntityID)
+		argPos++
+	}
+	if filter.Action != "" {
+		where += fmt.Sprintf(" AND action = $%d", argPos)
+		args = append(args, filter.Action)
+		argPos++
+	}
+	if filter.Actor != "" {
+		where += fmt.Sprintf(" AND actor = $%d", argPos)
+		args = append(args, filter.Actor)
+		argPos++
+	}
+	if filter.RequestID != "" {
+		where += fmt.Sprintf(" AND request_id = $%d", argPos)
+		args = append(args, filter.RequestID)
+		argPos++
+	}
+	if filter.StartDate != "" {
+		where += fmt.Sprintf(" AND DATE(occurred_at) >= $%d", argPos)
+		args = append(args, filter.StartDate)
+		argPos++
+	}
+	if filter.EndDate != "" {
+		where += fmt.Sprintf(" AND DATE(occurred_at) <= $%d", argPos)
+		args = append(args, filter.EndDate)
+		argPos++
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_log" + where +
+		fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	args = append(args, filter.Limit, filter.Offset())
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := make([]model.AuditEntry, 0, filter.Limit)
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// recordAudit appends an entry for a change to the audit log within tx, so the
+// entry is only kept if the change is committed
+func recordAudit(ctx context.Context, tx *sql.Tx, entity model.AuditEntity, entityID int, action model.AuditAction, before, after any) error {
+	entry, err := model.NewAuditEntry(ctx, entity, entityID, action, before, after)
+	if err != nil {
+		return err
+	}
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO audit_log (occurred_at, actor_type, actor_id, actor, request_id, entity, entity_id, action, changes)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9)`,
+		entry.OccurredAt, entry.ActorType, entry.ActorID, entry.Actor, entry.RequestID, entry.Entity, entry.EntityID, entry.Action, changes)
+	return err
+}
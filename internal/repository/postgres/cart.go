@@ -0,0 +1,337 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type CartRepository struct {
+	db *sql.DB
+}
+
+func NewCartRepository(db *sql.DB) *CartRepository {
+	return &CartRepository{db: db}
+}
+
+const cartColumns = "id, label, status, transaction_id, created_at, updated_at, expires_at"
+
+func scanCart(row rowScanner) (*model.Cart, error) {
+	var c model.Cart
+	var transactionID sql.NullInt64
+	var createdAt, updatedAt sql.NullTime
+	if err := row.Scan(&c.ID, &c.Label, &c.Status, &transactionID, &createdAt, &updatedAt, &c.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	if transactionID.Valid {
+		id := int(transactionID.Int64)
+		c.TransactionID = &id
+	}
+	c.CreatedAt = createdAt.Time
+	c.UpdatedAt = updatedAt.Time
+
+	return &c, nil
+}
+
+func (r *CartRepository) FindByID(ctx context.Context, id int) (*model.Cart, error) {
+	c, err := scanCart(r.db.QueryRowContext(ctx, "SELECT "+cartColumns+" FROM carts WHERE id = $1", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+
+	items, err := findCartItems(ctx, r.db, []int{c.ID})
+	if err != nil {
+		return nil, err
+	}
+	c.Items = items[c.ID]
+	if c.Items == nil {
+		c.Items = []model.CartItem{}
+	}
+
+	return c, nil
+}
+
+func (r *CartRepository) FindHeld(ctx context.Context, at time.Time) ([]model.Cart, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+cartColumns+" FROM carts WHERE status = $1 AND expires_at > $2 ORDER BY updated_at DESC, id DESC",
+		model.CartStatusHeld, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	carts := make([]model.Cart, 0)
+	ids := make([]int, 0)
+	for rows.Next() {
+		c, err := scanCart(rows)
+		if err != nil {
+			return nil, err
+		}
+		carts = append(carts, *c)
+		ids = append(ids, c.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	items, err := findCartItems(ctx, r.db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range carts {
+		carts[i].Items = items[carts[i].ID]
+		if carts[i].Items == nil {
+			carts[i].Items = []model.CartItem{}
+		}
+	}
+
+	return carts, nil
+}
+
+func (r *CartRepository) Create(ctx context.Context, c model.Cart) (*model.Cart, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var createdAt, updatedAt sql.NullTime
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO carts (label, status, expires_at) VALUES ($1, $2, $3) RETURNING id, created_at, updated_at",
+		c.Label, model.CartStatusHeld, c.ExpiresAt).Scan(&c.ID, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertCartItems(ctx, tx, c.ID, c.Items); err != nil {
+		return nil, err
+	}
+
+	c.Status = model.CartStatusHeld
+	c.CreatedAt = createdAt.Time
+	c.UpdatedAt = updatedAt.Time
+	if err := recordAudit(ctx, tx, model.AuditEntityCart, c.ID, model.AuditActionCreate, nil, c); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *CartRepository) SaveItems(ctx context.Context, id int, items []model.CartItem, expiresAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockCart(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	// Only a cart that is still held can change, checked under the row lock
+	result, err := tx.ExecContext(ctx, `
+		UPDATE carts SET updated_at = CURRENT_TIMESTAMP, expires_at = $1
+		WHERE id = $2 AND status = $3 AND expires_at > CURRENT_TIMESTAMP`,
+		expiresAt, id, model.CartStatusHeld)
+	if err != nil {
+		return err
+	}
+	if err := r.checkUpdated(ctx, tx, result, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM cart_items WHERE cart_id = $1", id); err != nil {
+		return err
+	}
+	if err := insertCartItems(ctx, tx, id, items); err != nil {
+		return err
+	}
+
+	after := *before
+	after.Items = items
+	after.ExpiresAt = expiresAt
+	if err := recordAudit(ctx, tx, model.AuditEntityCart, id, model.AuditActionUpdate, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *CartRepository) UpdateStatus(ctx context.Context, id int, from, to model.CartStatus, transactionID *int) error {
+	query := "UPDATE carts SET status = $1, transaction_id = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3 AND status = $4"
+	if from == model.CartStatusHeld {
+		query += " AND expires_at > CURRENT_TIMESTAMP"
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockCart(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, to, transactionID, id, from)
+	if err != nil {
+		return err
+	}
+	if err := r.checkUpdated(ctx, tx, result, id); err != nil {
+		return err
+	}
+
+	after := *before
+	after.Status = to
+	after.TransactionID = transactionID
+	if err := recordAudit(ctx, tx, model.AuditEntityCart, id, model.AuditActionUpdate, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *CartRepository) Delete(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := lockCart(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM carts WHERE id = $1", id); err != nil {
+		return err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityCart, id, model.AuditActionDelete, before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockCart loads a cart with its items and locks its row until tx ends, so the
+// audit log gets the state the change was made to
+func lockCart(ctx context.Context, tx *sql.Tx, id int) (*model.Cart, error) {
+	c, err := scanCart(tx.QueryRowContext(ctx, "SELECT "+cartColumns+" FROM carts WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+
+	items, err := findCartItems(ctx, tx, []int{c.ID})
+	if err != nil {
+		return nil, err
+	}
+	c.Items = items[c.ID]
+	if c.Items == nil {
+		c.Items = []model.CartItem{}
+	}
+	return c, nil
+}
+
+// checkUpdated turns a conditional update that matched no rows into ErrNotFound
+// or ErrConflict depending on whether the cart exists
+func (r *CartRepository) checkUpdated(ctx context.Context, tx *sql.Tx, result sql.Result, id int) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows > 0 {
+		return nil
+	}
+
+	var status model.CartStatus
+	var expired bool
+	err = tx.QueryRowContext(ctx, "SELECT status, expires_at <= CURRENT_TIMESTAMP FROM carts WHERE id = $1", id).Scan(&status, &expired)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrNotFound
+		}
+		return err
+	}
+	if status == model.CartStatusHeld && expired {
+		status = model.CartStatusExpired
+	}
+	return fmt.Errorf("%w: cart %d is %s", model.ErrConflict, id, status)
+}
+
+func insertCartItems(ctx context.Context, tx *sql.Tx, cartID int, items []model.CartItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO cart_items (cart_id, product_id, quantity, position) VALUES "
+	args := make([]any, 0, len(items)*4)
+	for i, item := range items {
+		if i > 0 {
+			query += ", "
+		}
+		offset := i * 4
+		query += fmt.Sprintf("($%d, $%d, $%d, $%d)", offset+1, offset+2, offset+3, offset+4)
+		args = append(args, cartID, item.ProductID, item.Quantity, i)
+	}
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+// findCartItems loads the lines of the given carts, grouped by cart ID
+func findCartItems(ctx context.Context, q queryer, cartIDs []int) (map[int][]model.CartItem, error) {
+	result := make(map[int][]model.CartItem, len(cartIDs))
+	if len(cartIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := ""
+	args := make([]any, 0, len(cartIDs))
+	for i, id := range cartIDs {
+		if i > 0 {
+			placeholders += ", "
+		}
+		placeholders += fmt.Sprintf("$%d", i+1)
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT cart_id, product_id, quantity
+		FROM cart_items
+		WHERE cart_id IN (%s)
+		ORDER BY cart_id, position`, placeholders)
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cartID int
+		var item model.CartItem
+		if err := rows.Scan(&cartID, &item.ProductID, &item.Quantity); err != nil {
+			return nil, err
+		}
+		result[cartID] = append(result[cartID], item)
+	}
+
+	return result, rows.Err()
+}
@@ -17,10 +17,15 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 }
 
 func (r *CategoryRepository) FindByID(ctx context.Context, id int) (*model.Category, error) {
-	query := `SELECT id, name, description FROM categories WHERE id = $1`
+	return findCategory(ctx, r.db, id, "")
+}
+
+// findCategory loads a category; lock is appended to the query, such as "FOR UPDATE"
+func findCategory(ctx context.Context, q queryer, id int, lock string) (*model.Category, error) {
+	query := `SELECT id, name, description FROM categories WHERE id = $1 ` + lock
 
 	var c model.Category
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&c.ID, &c.Name, &c.Description)
+	err := q.QueryRowContext(ctx, query, id).Scan(&c.ID, &c.Name, &c.Description)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, model.ErrNotFound
@@ -56,52 +61,80 @@ func (r *CategoryRepository) FindAll(ctx context.Context) ([]model.Category, err
 }
 
 func (r *CategoryRepository) Create(ctx context.Context, c model.Category) (*model.Category, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	query := `INSERT INTO categories (name, description) VALUES ($1, $2) RETURNING id`
 
-	err := r.db.QueryRowContext(ctx, query, c.Name, c.Description).Scan(&c.ID)
+	err = tx.QueryRowContext(ctx, query, c.Name, c.Description).Scan(&c.ID)
 	if err != nil {
 		return nil, err
 	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityCategory, c.ID, model.AuditActionCreate, nil, c); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return &c, nil
 }
 
 func (r *CategoryRepository) Update(ctx context.Context, id int, c model.Category) (*model.Category, error) {
-	query := `UPDATE categories SET name = $1, description = $2 WHERE id = $3`
-
-	result, err := r.db.ExecContext(ctx, query, c.Name, c.Description, id)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	before, err := findCategory(ctx, tx, id, "FOR UPDATE")
 	if err != nil {
 		return nil, err
 	}
 
-	if rows == 0 {
-		return nil, model.ErrNotFound
+	query := `UPDATE categories SET name = $1, description = $2 WHERE id = $3`
+
+	if _, err := tx.ExecContext(ctx, query, c.Name, c.Description, id); err != nil {
+		return nil, err
 	}
 
 	c.ID = id
+	if err := recordAudit(ctx, tx, model.AuditEntityCategory, id, model.AuditActionUpdate, before, c); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return &c, nil
 }
 
 func (r *CategoryRepository) Delete(ctx context.Context, id int) error {
-	query := `DELETE FROM categories WHERE id = $1`
-
-	result, err := r.db.ExecContext(ctx, query, id)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	before, err := findCategory(ctx, tx, id, "FOR UPDATE")
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return model.ErrNotFound
+	if _, err := tx.ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, id); err != nil {
+		if isForeignKeyViolation(err, "promotions_category_id_fkey") {
+			return model.CategoryPromotedError(id)
+		}
+		return err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityCategory, id, model.AuditActionDelete, before, nil); err != nil {
+		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
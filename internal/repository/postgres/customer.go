@@ -0,0 +1,294 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"kasir-api/internal/model"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+const customerColumns = "id, name, phone, email, member_code, notes, points, created_at, updated_at, erased_at"
+
+func scanCustomer(row rowScanner) (*model.Customer, error) {
+	var c model.Customer
+	var phone, email, memberCode sql.NullString
+	var createdAt, updatedAt, erasedAt sql.NullTime
+	if err := row.Scan(&c.ID, &c.Name, &phone, &email, &memberCode, &c.Notes, &c.Points, &createdAt, &updatedAt, &erasedAt); err != nil {
+		return nil, err
+	}
+
+	c.Phone = phone.String
+	c.Email = email.String
+	c.MemberCode = memberCode.String
+	c.CreatedAt = createdAt.Time
+	c.UpdatedAt = updatedAt.Time
+	if erasedAt.Valid {
+		c.ErasedAt = &erasedAt.Time
+	}
+
+	return &c, nil
+}
+
+func (r *CustomerRepository) FindByID(ctx context.Context, id int) (*model.Customer, error) {
+	c, err := scanCustomer(r.db.QueryRowContext(ctx, "SELECT "+customerColumns+" FROM customers WHERE id = $1", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *CustomerRepository) FindAll(ctx context.Context, search string) ([]model.Customer, error) {
+	query := "SELECT " + customerColumns + " FROM customers WHERE erased_at IS NULL"
+	args := []any{}
+	if search != "" {
+		// A search with no digits would match every phone, so it only looks at names and codes
+		phone := "FALSE"
+		args = append(args, search)
+		if digits := model.NormalizePhone(search); digits != "" {
+			phone = "STRPOS(phone, $2) > 0"
+			args = append(args, digits)
+		}
+		query += " AND (STRPOS(LOWER(name), LOWER($1)) > 0 OR STRPOS(LOWER(member_code), LOWER($1)) > 0 OR " + phone + ")"
+	}
+	query += " ORDER BY name, id"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := make([]model.Customer, 0)
+	for rows.Next() {
+		c, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, *c)
+	}
+
+	return customers, rows.Err()
+}
+
+func (r *CustomerRepository) FindLedger(ctx context.Context, customerID int) ([]model.LoyaltyEntry, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM customers WHERE id = $1)", customerID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, model.ErrNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, customer_id, transaction_id, type, points, balance, created_at
+		FROM loyalty_ledger
+		WHERE customer_id = $1
+		ORDER BY id`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]model.LoyaltyEntry, 0)
+	for rows.Next() {
+		var e model.LoyaltyEntry
+		var transactionID sql.NullInt64
+		var createdAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.CustomerID, &transactionID, &e.Type, &e.Points, &e.Balance, &createdAt); err != nil {
+			return nil, err
+		}
+		if transactionID.Valid {
+			id := int(transactionID.Int64)
+			e.TransactionID = &id
+		}
+		e.CreatedAt = createdAt.Time
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *CustomerRepository) Create(ctx context.Context, c model.Customer) (*model.Customer, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO customers (name, phone, email, member_code, notes) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`, c.Name, nullString(c.Phone), nullString(c.Email), nullString(c.MemberCode), c.Notes).Scan(&id)
+	if err != nil {
+		return nil, customerConflict(err, c)
+	}
+
+	// Customers registered without a card get a member code derived from their ID
+	if c.MemberCode == "" {
+		c.MemberCode = model.MemberCodeFor(id)
+		if _, err := tx.ExecContext(ctx, "UPDATE customers SET member_code = $1 WHERE id = $2", c.MemberCode, id); err != nil {
+			return nil, customerConflict(err, c)
+		}
+	}
+
+	created, err := scanCustomer(tx.QueryRowContext(ctx, "SELECT "+customerColumns+" FROM customers WHERE id = $1", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityCustomer, id, model.AuditActionCreate, nil, created.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (r *CustomerRepository) Update(ctx context.Context, id int, c model.Customer) (*model.Customer, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := lockCustomer(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.ID = id
+	updated, err := scanCustomer(tx.QueryRowContext(ctx, `
+		UPDATE customers
+		SET name = $1, phone = $2, email = $3, member_code = COALESCE($4, member_code), notes = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+		RETURNING `+customerColumns, c.Name, nullString(c.Phone), nullString(c.Email), nullString(c.MemberCode), c.Notes, id))
+	if err != nil {
+		return nil, customerConflict(err, c)
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityCustomer, id, model.AuditActionUpdate, before.AuditRecord(), updated.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (r *CustomerRepository) Erase(ctx context.Context, id int) (*model.Customer, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := lockCustomer(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	erased, err := scanCustomer(tx.QueryRowContext(ctx, `
+		UPDATE customers
+		SET name = '', phone = NULL, email = NULL, member_code = NULL, notes = '',
+			updated_at = CURRENT_TIMESTAMP, erased_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+customerColumns, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityCustomer, id, model.AuditActionErase, before.AuditRecord(), erased.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return erased, nil
+}
+
+// lockCustomer locks the customer row in tx for a change, which erased customers
+// can no longer have
+func lockCustomer(ctx context.Context, tx *sql.Tx, id int) (*model.Customer, error) {
+	c, err := scanCustomer(tx.QueryRowContext(ctx, "SELECT "+customerColumns+" FROM customers WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	if c.IsErased() {
+		return nil, model.CustomerErasedError(id)
+	}
+	return c, nil
+}
+
+// customerConflict turns a unique violation on the phone or member code into model.ErrConflict
+func customerConflict(err error, c model.Customer) error {
+	switch {
+	case isUniqueViolation(err, "idx_customers_phone"):
+		return model.PhoneTakenError(c.Phone)
+	case isUniqueViolation(err, "idx_customers_member_code"):
+		return model.MemberCodeTakenError(c.MemberCode)
+	}
+	return err
+}
+
+// addPoints changes a locked customer's balance inside tx and records the ledger entry
+func addPoints(ctx context.Context, tx *sql.Tx, customerID int, entryType model.LoyaltyEntryType, points, transactionID int, at time.Time) error {
+	if points == 0 {
+		return nil
+	}
+
+	var balance int
+	err := tx.QueryRowContext(ctx, "UPDATE customers SET points = points + $1 WHERE id = $2 RETURNING points", points, customerID).Scan(&balance)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO loyalty_ledger (customer_id, transaction_id, type, points, balance, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`, customerID, transactionID, entryType, points, balance, at)
+	return err
+}
+
+// isUniqueViolation reports whether err is a unique violation of the named constraint or index
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
+
+func isForeignKeyViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503" && pgErr.ConstraintName == constraint
+}
+
+// isReferenced reports a delete refused because other rows still refer to the record
+func isReferenced(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+// nullString stores an empty string as NULL so optional unique columns do not collide
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+)
+
+func TestCustomerRepository_FindAll_Search(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	db.Exec("DELETE FROM customers")
+
+	repo := NewCustomerRepository(db)
+	ctx := context.Background()
+
+	repo.Create(ctx, model.Customer{Name: "Budi", Phone: "081234567890"})
+	repo.Create(ctx, model.Customer{Name: "Siti", Phone: "085700001111"})
+
+	tests := []struct {
+		search string
+		want   int
+	}{
+		{"budi", 1},
+		{"0812-345", 1},
+		{"-", 0},
+		{" ", 0},
+	}
+	for _, tt := range tests {
+		found, err := repo.FindAll(ctx, tt.search)
+		if err != nil {
+			t.Fatalf("FindAll(%q) error = %v", tt.search, err)
+		}
+		if len(found) != tt.want {
+			t.Errorf("FindAll(%q) = %d customers, want %d", tt.search, len(found), tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type DeviceRepository struct {
+	db *sql.DB
+}
+
+func NewDeviceRepository(db *sql.DB) *DeviceRepository {
+	return &DeviceRepository{db: db}
+}
+
+const deviceColumns = "id, name, outlet_id, COALESCE(pairing_code_hash, ''), pairing_expires_at, COALESCE(key_hash, ''), COALESCE(key_prefix, ''), paired_at, last_seen_at, request_count, revoked_at, COALESCE(created_by, ''), created_at, updated_at"
+
+func scanDevice(row rowScanner) (*model.Device, error) {
+	var d model.Device
+	var pairingExpiresAt, pairedAt, lastSeenAt, revokedAt, createdAt, updatedAt sql.NullTime
+	if err := row.Scan(&d.ID, &d.Name, &d.OutletID, &d.PairingCodeHash, &pairingExpiresAt, &d.KeyHash, &d.KeyPrefix,
+		&pairedAt, &lastSeenAt, &d.RequestCount, &revokedAt, &d.CreatedBy, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	if pairingExpiresAt.Valid {
+		d.PairingExpiresAt = &pairingExpiresAt.Time
+	}
+	if pairedAt.Valid {
+		d.PairedAt = &pairedAt.Time
+	}
+	if lastSeenAt.Valid {
+		d.LastSeenAt = &lastSeenAt.Time
+	}
+	if revokedAt.Valid {
+		d.RevokedAt = &revokedAt.Time
+	}
+	d.CreatedAt = createdAt.Time
+	d.UpdatedAt = updatedAt.Time
+	return &d, nil
+}
+
+func (r *DeviceRepository) findOne(ctx context.Context, condition string, arg any) (*model.Device, error) {
+	d, err := scanDevice(r.db.QueryRowContext(ctx, "SELECT "+deviceColumns+" FROM devices WHERE "+condition, arg))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *DeviceRepository) Create(ctx context.Context, d model.Device) (*model.Device, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created, err := scanDevice(tx.QueryRowContext(ctx, `
+		INSERT INTO devices (name, outlet_id, pairing_code_hash, pairing_expires_at, created_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING `+deviceColumns,
+		d.Name, d.OutletID, d.PairingCodeHash, d.PairingExpiresAt, d.CreatedBy))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityDevice, created.ID, model.AuditActionCreate, nil, created); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (r *DeviceRepository) FindByID(ctx context.Context, id int) (*model.Device, error) {
+	return r.findOne(ctx, "id = $1", id)
+}
+
+func (r *DeviceRepository) FindAll(ctx context.Context) ([]model.Device, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+deviceColumns+" FROM devices ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := make([]model.Device, 0)
+	for rows.Next() {
+		d, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, *d)
+	}
+	return devices, rows.Err()
+}
+
+func (r *DeviceRepository) Pair(ctx context.Context, codeHash, keyHash, keyPrefix string, at time.Time) (*model.Device, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Clearing the code in the same statement that checks it means two devices
+	// racing with one code cannot both get a key
+	d, err := scanDevice(tx.QueryRowContext(ctx, `
+		UPDATE devices
+		SET pairing_code_hash = NULL, key_hash = $2, key_prefix = $3, paired_at = $4, updated_at = $4
+		WHERE pairing_code_hash = $1 AND pairing_expires_at > $4 AND paired_at IS NULL AND revoked_at IS NULL
+		RETURNING `+deviceColumns,
+		codeHash, keyHash, keyPrefix, at))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.InvalidPairingCodeError()
+		}
+		return nil, err
+	}
+
+	// Only an unpaired device matches, so before pairing it had no key
+	before := *d
+	before.KeyPrefix, before.PairedAt = "", nil
+	if err := recordAudit(ctx, tx, model.AuditEntityDevice, d.ID, model.AuditActionPair, before, d); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *DeviceRepository) FindByKeyHash(ctx context.Context, keyHash string) (*model.Device, error) {
+	return r.findOne(ctx, "key_hash = $1", keyHash)
+}
+
+func (r *DeviceRepository) RecordUse(ctx context.Context, id int, at time.Time) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE devices SET last_seen_at = $2, request_count = request_count + 1 WHERE id = $1", id, at)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+func (r *DeviceRepository) Revoke(ctx context.Context, id int, at time.Time) (*model.Device, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := scanDevice(tx.QueryRowContext(ctx, "SELECT "+deviceColumns+" FROM devices WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	if before.RevokedAt != nil {
+		return before, nil
+	}
+
+	d, err := scanDevice(tx.QueryRowContext(ctx, `
+		UPDATE devices
+		SET revoked_at = $2, pairing_code_hash = NULL, updated_at = $2
+		WHERE id = $1
+		RETURNING `+deviceColumns,
+		id, at))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityDevice, id, model.AuditActionRevoke, before, d); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
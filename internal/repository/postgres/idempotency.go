@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+func (r *IdempotencyRepository) Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*model.IdempotencyRecord, error) {
+	// Insert the key, or take over a row whose TTL has run out
+	var reserved string
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO idempotency_keys (key, request_hash, expires_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, transaction_id = NULL,
+			created_at = CURRENT_TIMESTAMP, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= CURRENT_TIMESTAMP
+		RETURNING key`, key, requestHash, ttl.Milliseconds()).Scan(&reserved)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// The key is held by an unexpired record
+	var record model.IdempotencyRecord
+	var transactionID sql.NullInt64
+	var createdAt sql.NullTime
+	err = r.db.QueryRowContext(ctx, `
+		SELECT key, request_hash, transaction_id, created_at, expires_at
+		FROM idempotency_keys WHERE key = $1`, key).
+		Scan(&record.Key, &record.RequestHash, &transactionID, &createdAt, &record.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	record.CreatedAt = createdAt.Time
+	if transactionID.Valid {
+		id := int(transactionID.Int64)
+		record.TransactionID = &id
+	}
+
+	return &record, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, transactionID int) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE idempotency_keys SET transaction_id = $1 WHERE key = $2", transactionID, key)
+	if err != nil {
+		return err
+	}
+	return checkIdempotencyCompleted(result)
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE key = $1 AND transaction_id IS NULL", key)
+	return err
+}
+
+// completeIdempotencyKey records the transaction against its reserved key within
+// the checkout's own transaction, so the sale and the key commit together
+func completeIdempotencyKey(ctx context.Context, tx *sql.Tx, claim model.IdempotencyClaim, transactionID int) error {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE idempotency_keys SET transaction_id = $1
+		WHERE key = $2 AND request_hash = $3 AND transaction_id IS NULL`,
+		transactionID, claim.Key, claim.RequestHash)
+	if err != nil {
+		return err
+	}
+	return checkIdempotencyCompleted(result)
+}
+
+// checkIdempotencyCompleted turns an update that matched no reservation into an error
+func checkIdempotencyCompleted(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return model.IdempotencyReservationLostError()
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"kasir-api/internal/model"
+)
+
+type OutletRepository struct {
+	db *sql.DB
+}
+
+func NewOutletRepository(db *sql.DB) *OutletRepository {
+	return &OutletRepository{db: db}
+}
+
+const outletColumns = "id, code, name, address, phone, active, created_at"
+
+func scanOutlet(row rowScanner) (*model.Outlet, error) {
+	var o model.Outlet
+	var createdAt sql.NullTime
+	if err := row.Scan(&o.ID, &o.Code, &o.Name, &o.Address, &o.Phone, &o.Active, &createdAt); err != nil {
+		return nil, err
+	}
+	o.CreatedAt = createdAt.Time
+	return &o, nil
+}
+
+func (r *OutletRepository) FindByID(ctx context.Context, id int) (*model.Outlet, error) {
+	o, err := scanOutlet(r.db.QueryRowContext(ctx, "SELECT "+outletColumns+" FROM outlets WHERE id = $1", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	return o, nil
+}
+
+func (r *OutletRepository) FindAll(ctx context.Context) ([]model.Outlet, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+outletColumns+" FROM outlets ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outlets := make([]model.Outlet, 0)
+	for rows.Next() {
+		o, err := scanOutlet(rows)
+		if err != nil {
+			return nil, err
+		}
+		outlets = append(outlets, *o)
+	}
+	return outlets, rows.Err()
+}
+
+func (r *OutletRepository) Create(ctx context.Context, o model.Outlet) (*model.Outlet, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created, err := scanOutlet(tx.QueryRowContext(ctx, `
+		INSERT INTO outlets (code, name, address, phone, active) VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+outletColumns, o.Code, o.Name, o.Address, o.Phone, o.Active))
+	if err != nil {
+		if isUniqueViolation(err, "idx_outlets_code") {
+			return nil, model.OutletCodeTakenError(o.Code)
+		}
+		return nil, err
+	}
+
+	// A new outlet starts with an empty stock row for every product
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO product_stocks (outlet_id, product_id, stock)
+		SELECT $1, p.id, 0 FROM products p`, created.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityOutlet, created.ID, model.AuditActionCreate, nil, created); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (r *OutletRepository) Update(ctx context.Context, id int, o model.Outlet) (*model.Outlet, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := scanOutlet(tx.QueryRowContext(ctx, "SELECT "+outletColumns+" FROM outlets WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+
+	updated, err := scanOutlet(tx.QueryRowContext(ctx, `
+		UPDATE outlets SET code = $1, name = $2, address = $3, phone = $4, active = $5
+		WHERE id = $6
+		RETURNING `+outletColumns, o.Code, o.Name, o.Address, o.Phone, o.Active, id))
+	if err != nil {
+		if isUniqueViolation(err, "idx_outlets_code") {
+			return nil, model.OutletCodeTakenError(o.Code)
+		}
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityOutlet, id, model.AuditActionUpdate, before, updated); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
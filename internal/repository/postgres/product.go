@@ -3,8 +3,10 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"kasir-api/internal/model"
 )
@@ -17,24 +19,34 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (r *ProductRepository) FindByID(ctx context.Context, id int) (*model.Product, error) {
-	query := `
-		SELECT p.id, p.name, p.price, p.stock, p.active, p.category_id, c.id, c.name, c.description
-		FROM products p
-		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE p.id = $1`
+// productColumns are read by scanProduct. Queries select them from products p
+// joined to categories c and to the outlet's product_stocks ps.
+const productColumns = `p.id, p.name, p.price, p.cost, COALESCE(ps.stock, 0), p.active, p.tax_exempt, p.tax_inclusive,
+		p.reorder_point, p.reorder_qty, COALESCE(p.sku, ''), COALESCE(p.barcode, ''), p.options, p.parent_id, p.attributes,
+		p.category_id, c.id, c.name, c.description`
 
+func scanProduct(row rowScanner) (*model.Product, error) {
 	var p model.Product
+	var options, attributes []byte
 	var catID sql.NullInt64
 	var catName, catDesc sql.NullString
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.Active, &p.CategoryID, &catID, &catName, &catDesc)
+	err := row.Scan(&p.ID, &p.Name, &p.Price, &p.Cost, &p.Stock, &p.Active, &p.TaxExempt, &p.TaxInclusive,
+		&p.ReorderPoint, &p.ReorderQty, &p.SKU, &p.Barcode, &options, &p.ParentID, &attributes,
+		&p.CategoryID, &catID, &catName, &catDesc)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, model.ErrNotFound
-		}
 		return nil, err
 	}
 
+	if options != nil {
+		if err := json.Unmarshal(options, &p.Options); err != nil {
+			return nil, err
+		}
+	}
+	if attributes != nil {
+		if err := json.Unmarshal(attributes, &p.Attributes); err != nil {
+			return nil, err
+		}
+	}
 	if catID.Valid {
 		p.Category = &model.Category{
 			ID:          int(catID.Int64),
@@ -46,55 +58,104 @@ func (r *ProductRepository) FindByID(ctx context.Context, id int) (*model.Produc
 	return &p, nil
 }
 
-func (r *ProductRepository) FindAll(ctx context.Context) ([]model.Product, error) {
+func (r *ProductRepository) FindByID(ctx context.Context, id int) (*model.Product, error) {
+	p, err := findProduct(ctx, r.db, id, "")
+	if err != nil {
+		return nil, err
+	}
+	products := []model.Product{*p}
+	if err := loadVariants(ctx, r.db, products); err != nil {
+		return nil, err
+	}
+	return &products[0], nil
+}
+
+// findProduct loads the product with the stock of the outlet of the context. lock
+// is appended to the query, such as "FOR UPDATE OF p" to hold the row in a transaction.
+func findProduct(ctx context.Context, q queryer, id int, lock string) (*model.Product, error) {
 	query := `
-		SELECT p.id, p.name, p.price, p.stock, p.active, p.category_id, c.id, c.name, c.description
+		SELECT ` + productColumns + `
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.id
-		ORDER BY p.id`
+		LEFT JOIN product_stocks ps ON ps.product_id = p.id AND ps.outlet_id = $2
+		WHERE p.id = $1 ` + lock
 
-	rows, err := r.db.QueryContext(ctx, query)
+	p, err := scanProduct(q.QueryRowContext(ctx, query, id, model.OutletID(ctx)))
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
 		return nil, err
 	}
-	defer rows.Close()
-
-	var products []model.Product
-	for rows.Next() {
-		var p model.Product
-		var catID sql.NullInt64
-		var catName, catDesc sql.NullString
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.Active, &p.CategoryID, &catID, &catName, &catDesc); err != nil {
-			return nil, err
-		}
+	return p, nil
+}
 
-		if catID.Valid {
-			p.Category = &model.Category{
-				ID:          int(catID.Int64),
-				Name:        catName.String,
-				Description: catDesc.String,
-			}
+// loadVariants fills in the variants of the products, with the stock of the outlet
+// of the context. Variants themselves have none, so only the others are looked up.
+func loadVariants(ctx context.Context, q queryer, products []model.Product) error {
+	args := []any{model.OutletID(ctx)}
+	placeholders := make([]string, 0, len(products))
+	for _, p := range products {
+		if p.ParentID == nil {
+			args = append(args, p.ID)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
 		}
+	}
+	if len(placeholders) == 0 {
+		return nil
+	}
 
-		products = append(products, p)
+	rows, err := q.QueryContext(ctx, `
+		SELECT `+productColumns+`
+		FROM products p
+		LEFT JOIN categories c ON p.category_id = c.id
+		LEFT JOIN product_stocks ps ON ps.product_id = p.id AND ps.outlet_id = $1
+		WHERE p.parent_id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY p.id`, args...)
+	if err != nil {
+		return err
 	}
+	defer rows.Close()
 
+	variants := make(map[int][]model.ProductVariant)
+	for rows.Next() {
+		v, err := scanProduct(rows)
+		if err != nil {
+			return err
+		}
+		variants[*v.ParentID] = append(variants[*v.ParentID], v.Variant())
+	}
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return err
 	}
 
-	return products, nil
+	for i := range products {
+		products[i].Variants = variants[products[i].ID]
+	}
+	return nil
+}
+
+func (r *ProductRepository) FindAll(ctx context.Context) ([]model.Product, error) {
+	query := `
+		SELECT ` + productColumns + `
+		FROM products p
+		LEFT JOIN categories c ON p.category_id = c.id
+		LEFT JOIN product_stocks ps ON ps.product_id = p.id AND ps.outlet_id = $1
+		ORDER BY p.id`
+
+	return r.findProducts(ctx, query, model.OutletID(ctx))
 }
 
 func (r *ProductRepository) FindByFilters(ctx context.Context, name string, active *bool) ([]model.Product, error) {
 	query := `
-		SELECT p.id, p.name, p.price, p.stock, p.active, p.category_id, c.id, c.name, c.description
+		SELECT ` + productColumns + `
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.id
+		LEFT JOIN product_stocks ps ON ps.product_id = p.id AND ps.outlet_id = $1
 		WHERE 1=1`
 
-	args := []any{}
-	argPos := 1
+	args := []any{model.OutletID(ctx)}
+	argPos := 2
 
 	if name != "" {
 		query += fmt.Sprintf(" AND p.name ILIKE $%d", argPos)
@@ -109,6 +170,12 @@ func (r *ProductRepository) FindByFilters(ctx context.Context, name string, acti
 
 	query += " ORDER BY p.id"
 
+	return r.findProducts(ctx, query, args...)
+}
+
+// findProducts runs a query selecting productColumns and loads the variants of the
+// products it returns
+func (r *ProductRepository) findProducts(ctx context.Context, query string, args ...any) ([]model.Product, error) {
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
@@ -117,74 +184,227 @@ func (r *ProductRepository) FindByFilters(ctx context.Context, name string, acti
 
 	var products []model.Product
 	for rows.Next() {
-		var p model.Product
-		var catID sql.NullInt64
-		var catName, catDesc sql.NullString
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.Active, &p.CategoryID, &catID, &catName, &catDesc); err != nil {
+		p, err := scanProduct(rows)
+		if err != nil {
 			return nil, err
 		}
+		products = append(products, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
 
-		if catID.Valid {
-			p.Category = &model.Category{
-				ID:          int(catID.Int64),
-				Name:        catName.String,
-				Description: catDesc.String,
-			}
-		}
+	if err := loadVariants(ctx, r.db, products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *ProductRepository) Create(ctx context.Context, p model.Product) (*model.Product, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created, err := createProduct(ctx, tx, p)
+	if err != nil {
+		return nil, err
+	}
 
-		products = append(products, p)
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (r *ProductRepository) CreateVariant(ctx context.Context, productID int, req model.ProductVariantRequest) (*model.Product, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Locking the product serializes its new variants, so no two get the same attributes
+	parent, err := findProduct(ctx, tx, productID, "FOR UPDATE OF p")
+	if err != nil {
+		return nil, err
+	}
+	products := []model.Product{*parent}
+	if err := loadVariants(ctx, tx, products); err != nil {
+		return nil, err
+	}
+
+	variant, err := products[0].NewVariant(req)
+	if err != nil {
+		return nil, err
+	}
+	created, err := createProduct(ctx, tx, variant)
+	if err != nil {
+		return nil, err
 	}
 
-	return products, rows.Err()
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
-func (r *ProductRepository) Create(ctx context.Context, p model.Product) (*model.Product, error) {
-	query := `INSERT INTO products (name, price, stock, active, category_id) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+// createProduct inserts a product within tx, gives every outlet a stock row and
+// posts the stock given to the outlet of the context
+func createProduct(ctx context.Context, tx *sql.Tx, p model.Product) (*model.Product, error) {
+	options, attributes, err := productJSON(p)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO products (name, price, cost, active, tax_exempt, tax_inclusive, reorder_point, reorder_qty, sku, barcode, options, parent_id, attributes, category_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id`
+
+	err = tx.QueryRowContext(ctx, query, p.Name, p.Price, p.Cost, p.Active, p.TaxExempt, p.TaxInclusive, p.ReorderPoint, p.ReorderQty,
+		nullString(p.SKU), nullString(p.Barcode), options, p.ParentID, attributes, p.CategoryID).Scan(&p.ID)
+	if err != nil {
+		return nil, productConflict(err, p)
+	}
 
-	err := r.db.QueryRowContext(ctx, query, p.Name, p.Price, p.Stock, p.Active, p.CategoryID).Scan(&p.ID)
+	// Every outlet starts empty; the stock given is posted to the outlet of the context
+	_, err = tx.ExecContext(ctx, `INSERT INTO product_stocks (outlet_id, product_id, stock) SELECT o.id, $1, 0 FROM outlets o`, p.ID)
 	if err != nil {
 		return nil, err
 	}
+	if p.Stock != 0 {
+		if _, err := postStock(ctx, tx, model.NewStockMovement(ctx, model.OutletID(ctx), p.ID, model.StockMovementAdjustment, p.Stock)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityProduct, p.ID, model.AuditActionCreate, nil, p.AuditRecord()); err != nil {
+		return nil, err
+	}
 	return &p, nil
 }
 
 func (r *ProductRepository) Update(ctx context.Context, id int, p model.Product) (*model.Product, error) {
-	query := `UPDATE products SET name = $1, price = $2, stock = $3, active = $4, category_id = $5 WHERE id = $6`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-	result, err := r.db.ExecContext(ctx, query, p.Name, p.Price, p.Stock, p.Active, p.CategoryID, id)
+	before, err := findProduct(ctx, tx, id, "FOR UPDATE OF p")
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := result.RowsAffected()
+	// A variant keeps its parent and attributes
+	p.ParentID, p.Attributes = before.ParentID, before.Attributes
+	options, _, err := productJSON(p)
 	if err != nil {
 		return nil, err
 	}
 
-	if rows == 0 {
-		return nil, model.ErrNotFound
+	query := `UPDATE products SET name = $1, price = $2, cost = $3, active = $4, tax_exempt = $5, tax_inclusive = $6, reorder_point = $7, reorder_qty = $8,
+		sku = $9, barcode = $10, options = $11, category_id = $12 WHERE id = $13`
+
+	_, err = tx.ExecContext(ctx, query, p.Name, p.Price, p.Cost, p.Active, p.TaxExempt, p.TaxInclusive, p.ReorderPoint, p.ReorderQty,
+		nullString(p.SKU), nullString(p.Barcode), options, p.CategoryID, id)
+	if err != nil {
+		return nil, productConflict(err, p)
+	}
+
+	// The stock given replaces what the outlet holds, through an adjustment for the difference
+	if delta := p.Stock - before.Stock; delta != 0 {
+		if _, err := postStock(ctx, tx, model.NewStockMovement(ctx, model.OutletID(ctx), id, model.StockMovementAdjustment, delta)); err != nil {
+			return nil, err
+		}
 	}
 
 	p.ID = id
+	if err := recordAudit(ctx, tx, model.AuditEntityProduct, id, model.AuditActionUpdate, before.AuditRecord(), p.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return &p, nil
 }
 
 func (r *ProductRepository) Delete(ctx context.Context, id int) error {
-	query := `DELETE FROM products WHERE id = $1`
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	before, err := findProduct(ctx, tx, id, "FOR UPDATE OF p")
 	if err != nil {
 		return err
 	}
 
-	rows, err := result.RowsAffected()
+	// The stock ledger, sales and purchasing documents keep referring to a product
+	// for good, so one that appears in any of them is deactivated instead
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT delete_product"); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM products WHERE id = $1`, id); err != nil {
+		if !isReferenced(err) {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT delete_product"); err != nil {
+			return err
+		}
+		return r.deactivate(ctx, tx, before)
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityProduct, id, model.AuditActionDelete, before.AuditRecord(), nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// deactivate takes a product that cannot be deleted, and its variants, out of sale
+func (r *ProductRepository) deactivate(ctx context.Context, tx *sql.Tx, before *model.Product) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE products SET active = FALSE WHERE id = $1 OR parent_id = $1`, before.ID); err != nil {
+		return err
+	}
+
+	after, err := findProduct(ctx, tx, before.ID, "")
 	if err != nil {
 		return err
 	}
+	if err := recordAudit(ctx, tx, model.AuditEntityProduct, before.ID, model.AuditActionUpdate, before.AuditRecord(), after.AuditRecord()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
 
-	if rows == 0 {
-		return model.ErrNotFound
+// productJSON encodes the options and attributes of a product for their JSONB
+// columns, as NULL when there are none
+func productJSON(p model.Product) (options, attributes []byte, err error) {
+	if len(p.Options) > 0 {
+		if options, err = json.Marshal(p.Options); err != nil {
+			return nil, nil, err
+		}
 	}
+	if len(p.Attributes) > 0 {
+		if attributes, err = json.Marshal(p.Attributes); err != nil {
+			return nil, nil, err
+		}
+	}
+	return options, attributes, nil
+}
 
-	return nil
+// productConflict turns a unique violation on the SKU or barcode into model.ErrConflict
+func productConflict(err error, p model.Product) error {
+	switch {
+	case isUniqueViolation(err, "idx_products_sku"):
+		return model.SKUTakenError(p.SKU)
+	case isUniqueViolation(err, "idx_products_barcode"):
+		return model.BarcodeTakenError(p.Barcode)
+	}
+	return err
 }
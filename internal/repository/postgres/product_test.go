@@ -25,6 +25,8 @@ func setupTestDB(t *testing.T) *sql.DB {
 	}
 
 	// Clean up tables
+	// The ledger refers to products without cascading, so it goes first
+	db.Exec("DELETE FROM stock_movements")
 	db.Exec("DELETE FROM products")
 	db.Exec("DELETE FROM categories")
 
@@ -157,7 +159,8 @@ func TestProductRepository_Delete(t *testing.T) {
 	repo := NewProductRepository(db)
 	ctx := context.Background()
 
-	product := model.Product{Name: "To Delete", Price: 5000, Stock: 50}
+	// The opening stock is on the ledger, so the product is kept but taken out of sale
+	product := model.Product{Name: "To Delete", Price: 5000, Stock: 50, Active: true}
 	created, _ := repo.Create(ctx, product)
 
 	err := repo.Delete(ctx, created.ID)
@@ -165,7 +168,29 @@ func TestProductRepository_Delete(t *testing.T) {
 		t.Fatalf("Delete() error = %v", err)
 	}
 
-	_, err = repo.FindByID(ctx, created.ID)
+	found, err := repo.FindByID(ctx, created.ID)
+	if err != nil || found.Active {
+		t.Errorf("After delete, FindByID() = %+v, %v, want the product kept inactive", found, err)
+	}
+	if _, total, _ := repo.FindMovements(ctx, model.StockMovementFilter{ProductID: created.ID}.WithDefaults()); total != 1 {
+		t.Errorf("Ledger holds %d movements, want the opening balance kept", total)
+	}
+}
+
+func TestProductRepository_Delete_WithoutHistory(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewProductRepository(db)
+	ctx := context.Background()
+
+	created, _ := repo.Create(ctx, model.Product{Name: "To Delete", Price: 5000})
+
+	if err := repo.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	_, err := repo.FindByID(ctx, created.ID)
 	if err != model.ErrNotFound {
 		t.Errorf("After delete, FindByID() error = %v, want %v", err, model.ErrNotFound)
 	}
@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type PromotionRepository struct {
+	db *sql.DB
+}
+
+func NewPromotionRepository(db *sql.DB) *PromotionRepository {
+	return &PromotionRepository{db: db}
+}
+
+const promotionColumns = `id, name, type, value, product_id, category_id, buy_qty, get_qty, min_spend,
+	priority, stackable, active, starts_at, ends_at, created_at`
+
+func scanPromotion(row rowScanner) (*model.Promotion, error) {
+	var p model.Promotion
+	var productID, categoryID sql.NullInt64
+	var startsAt, endsAt, createdAt sql.NullTime
+	err := row.Scan(&p.ID, &p.Name, &p.Type, &p.Value, &productID, &categoryID, &p.BuyQty, &p.GetQty, &p.MinSpend,
+		&p.Priority, &p.Stackable, &p.Active, &startsAt, &endsAt, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if productID.Valid {
+		id := int(productID.Int64)
+		p.ProductID = &id
+	}
+	if categoryID.Valid {
+		id := int(categoryID.Int64)
+		p.CategoryID = &id
+	}
+	if startsAt.Valid {
+		p.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		p.EndsAt = &endsAt.Time
+	}
+	p.CreatedAt = createdAt.Time
+
+	return &p, nil
+}
+
+func (r *PromotionRepository) FindByID(ctx context.Context, id int) (*model.Promotion, error) {
+	return findPromotion(ctx, r.db, id, "")
+}
+
+// findPromotion loads a promotion; lock is appended to the query, such as "FOR UPDATE"
+func findPromotion(ctx context.Context, q queryer, id int, lock string) (*model.Promotion, error) {
+	p, err := scanPromotion(q.QueryRowContext(ctx, "SELECT "+promotionColumns+" FROM promotions WHERE id = $1 "+lock, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *PromotionRepository) FindAll(ctx context.Context) ([]model.Promotion, error) {
+	return r.query(ctx, "SELECT "+promotionColumns+" FROM promotions ORDER BY id")
+}
+
+func (r *PromotionRepository) FindActive(ctx context.Context, at time.Time) ([]model.Promotion, error) {
+	return r.query(ctx, `
+		SELECT `+promotionColumns+` FROM promotions
+		WHERE active = TRUE
+			AND (starts_at IS NULL OR starts_at <= $1)
+			AND (ends_at IS NULL OR ends_at > $1)
+		ORDER BY priority DESC, id`, at)
+}
+
+func (r *PromotionRepository) query(ctx context.Context, query string, args ...any) ([]model.Promotion, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := make([]model.Promotion, 0)
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, *p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+func (r *PromotionRepository) Create(ctx context.Context, p model.Promotion) (*model.Promotion, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO promotions (name, type, value, product_id, category_id, buy_qty, get_qty, min_spend,
+			priority, stackable, active, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at`
+
+	err = tx.QueryRowContext(ctx, query, p.Name, p.Type, p.Value, p.ProductID, p.CategoryID, p.BuyQty, p.GetQty, p.MinSpend,
+		p.Priority, p.Stackable, p.Active, p.StartsAt, p.EndsAt).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return nil, promotionTargetError(err, p)
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityPromotion, p.ID, model.AuditActionCreate, nil, p); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *PromotionRepository) Update(ctx context.Context, id int, p model.Promotion) (*model.Promotion, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := findPromotion(ctx, tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE promotions
+		SET name = $1, type = $2, value = $3, product_id = $4, category_id = $5, buy_qty = $6, get_qty = $7,
+			min_spend = $8, priority = $9, stackable = $10, active = $11, starts_at = $12, ends_at = $13
+		WHERE id = $14`
+
+	_, err = tx.ExecContext(ctx, query, p.Name, p.Type, p.Value, p.ProductID, p.CategoryID, p.BuyQty, p.GetQty,
+		p.MinSpend, p.Priority, p.Stackable, p.Active, p.StartsAt, p.EndsAt, id)
+	if err != nil {
+		return nil, promotionTargetError(err, p)
+	}
+
+	p.ID = id
+	p.CreatedAt = before.CreatedAt
+	if err := recordAudit(ctx, tx, model.AuditEntityPromotion, id, model.AuditActionUpdate, before, p); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *PromotionRepository) Delete(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := scanPromotion(tx.QueryRowContext(ctx, "DELETE FROM promotions WHERE id = $1 RETURNING "+promotionColumns, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrNotFound
+		}
+		return err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityPromotion, id, model.AuditActionDelete, before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// promotionTargetError reports a promotion aimed at a product or category that
+// does not exist as a validation error, passing other errors through
+func promotionTargetError(err error, p model.Promotion) error {
+	switch {
+	case isForeignKeyViolation(err, "promotions_product_id_fkey"):
+		return model.PromotionTargetError("product_id", *p.ProductID)
+	case isForeignKeyViolation(err, "promotions_category_id_fkey"):
+		return model.PromotionTargetError("category_id", *p.CategoryID)
+	}
+	return err
+}
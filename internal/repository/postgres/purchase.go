@@ -0,0 +1,392 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type PurchaseOrderRepository struct {
+	db *sql.DB
+}
+
+func NewPurchaseOrderRepository(db *sql.DB) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{db: db}
+}
+
+const purchaseOrderColumns = "po.id, po.supplier_id, s.name, po.outlet_id, po.status, po.note, COALESCE(po.created_by, ''), po.created_at, po.sent_at, po.closed_at"
+
+const purchaseOrderFrom = " FROM purchase_orders po JOIN suppliers s ON s.id = po.supplier_id"
+
+func scanPurchaseOrder(row rowScanner) (*model.PurchaseOrder, error) {
+	var po model.PurchaseOrder
+	var sentAt, closedAt sql.NullTime
+	if err := row.Scan(&po.ID, &po.SupplierID, &po.SupplierName, &po.OutletID, &po.Status, &po.Note, &po.CreatedBy, &po.CreatedAt, &sentAt, &closedAt); err != nil {
+		return nil, err
+	}
+	if sentAt.Valid {
+		po.SentAt = &sentAt.Time
+	}
+	if closedAt.Valid {
+		po.ClosedAt = &closedAt.Time
+	}
+	return &po, nil
+}
+
+func (r *PurchaseOrderRepository) FindByID(ctx context.Context, id int) (*model.PurchaseOrder, error) {
+	po, err := findPurchaseOrder(ctx, r.db, id, "")
+	if err != nil {
+		return nil, err
+	}
+	result := po.WithTotals()
+	return &result, nil
+}
+
+func (r *PurchaseOrderRepository) FindAll(ctx context.Context, filter model.PurchaseOrderFilter) ([]model.PurchaseOrder, error) {
+	where := " WHERE po.outlet_id = $1"
+	args := []any{model.OutletID(ctx)}
+	argPos := 2
+
+	if filter.SupplierID != 0 {
+		where += fmt.Sprintf(" AND po.supplier_id = $%d", argPos)
+		args = append(args, filter.SupplierID)
+		argPos++
+	}
+	if filter.Status != "" {
+		where += fmt.Sprintf(" AND po.status = $%d", argPos)
+		args = append(args, filter.Status)
+		argPos++
+	}
+	if filter.Open {
+		where += fmt.Sprintf(" AND po.status IN ($%d, $%d)", argPos, argPos+1)
+		args = append(args, model.PurchaseOrderStatusSent, model.PurchaseOrderStatusPartiallyReceived)
+		argPos += 2
+	}
+	if filter.ProductID != 0 {
+		where += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM purchase_order_items i WHERE i.purchase_order_id = po.id AND i.product_id = $%d)", argPos)
+		args = append(args, filter.ProductID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+purchaseOrderColumns+purchaseOrderFrom+where+" ORDER BY po.id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	orders := make([]model.PurchaseOrder, 0)
+	for rows.Next() {
+		po, err := scanPurchaseOrder(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		orders = append(orders, *po)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range orders {
+		if err := loadPurchaseOrderDetails(ctx, r.db, &orders[i]); err != nil {
+			return nil, err
+		}
+		orders[i] = orders[i].WithTotals()
+	}
+	return orders, nil
+}
+
+func (r *PurchaseOrderRepository) Create(ctx context.Context, po model.PurchaseOrder) (*model.PurchaseOrder, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := namePurchaseOrderItems(ctx, tx, &po); err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO purchase_orders (supplier_id, outlet_id, status, note, created_by) VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id, created_at`, po.SupplierID, po.OutletID, po.Status, po.Note, po.CreatedBy).Scan(&po.ID, &po.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := insertPurchaseOrderItems(ctx, tx, po); err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityPurchaseOrder, po.ID, model.AuditActionCreate, nil, po.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, po.ID)
+}
+
+// Update replaces the supplier, note and items of a draft
+func (r *PurchaseOrderRepository) Update(ctx context.Context, id int, po model.PurchaseOrder) (*model.PurchaseOrder, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	existing, err := findPurchaseOrder(ctx, tx, id, "FOR UPDATE OF po")
+	if err != nil {
+		return nil, err
+	}
+	if existing.Status != model.PurchaseOrderStatusDraft {
+		return nil, model.PurchaseOrderStatusError(id, existing.Status, "changed")
+	}
+	if err := namePurchaseOrderItems(ctx, tx, &po); err != nil {
+		return nil, err
+	}
+
+	updated := *existing
+	updated.SupplierID = po.SupplierID
+	updated.Note = po.Note
+	updated.Items = po.Items
+	if _, err := tx.ExecContext(ctx, "UPDATE purchase_orders SET supplier_id = $1, note = $2 WHERE id = $3", updated.SupplierID, updated.Note, id); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM purchase_order_items WHERE purchase_order_id = $1", id); err != nil {
+		return nil, err
+	}
+	if err := insertPurchaseOrderItems(ctx, tx, updated); err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityPurchaseOrder, id, model.AuditActionUpdate, existing.AuditRecord(), updated.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *PurchaseOrderRepository) Send(ctx context.Context, id int) (*model.PurchaseOrder, error) {
+	return r.transition(ctx, id, model.AuditActionSend, (*model.PurchaseOrder).Send)
+}
+
+func (r *PurchaseOrderRepository) Close(ctx context.Context, id int) (*model.PurchaseOrder, error) {
+	return r.transition(ctx, id, model.AuditActionClose, (*model.PurchaseOrder).Close)
+}
+
+func (r *PurchaseOrderRepository) transition(ctx context.Context, id int, action model.AuditAction, apply func(*model.PurchaseOrder, time.Time) error) (*model.PurchaseOrder, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	po, err := findPurchaseOrder(ctx, tx, id, "FOR UPDATE OF po")
+	if err != nil {
+		return nil, err
+	}
+	before := po.AuditRecord()
+	if err := apply(po, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := updatePurchaseOrderStatus(ctx, tx, po); err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityPurchaseOrder, id, action, before, po.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// Receive books a delivery: the received stock is posted at the order's outlet
+// and each product's cost becomes the unit cost of the delivery, all in the same
+// transaction
+func (r *PurchaseOrderRepository) Receive(ctx context.Context, id int, req model.GoodsReceiptRequest) (*model.PurchaseOrder, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Deliveries booked from several devices queue on the order row
+	po, err := findPurchaseOrder(ctx, tx, id, "FOR UPDATE OF po")
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := po.Receive(ctx, req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO goods_receipts (purchase_order_id, outlet_id, note, received_by) VALUES ($1, $2, $3, NULLIF($4, ''))
+		RETURNING id, received_at`, id, receipt.OutletID, receipt.Note, receipt.ReceivedBy).Scan(&receipt.ID, &receipt.ReceivedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range receipt.Items {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO goods_receipt_items (goods_receipt_id, product_id, quantity, unit_cost, over_delivered)
+			VALUES ($1, $2, $3, $4, $5)`, receipt.ID, item.ProductID, item.Quantity, item.UnitCost, item.OverDelivered)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE purchase_order_items SET received_quantity = received_quantity + $1
+			WHERE purchase_order_id = $2 AND product_id = $3`, item.Quantity, id, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		// The latest purchase cost becomes the product's cost
+		before, err := findProduct(ctx, tx, item.ProductID, "FOR UPDATE OF p")
+		if err != nil {
+			if errors.Is(err, model.ErrNotFound) {
+				return nil, fmt.Errorf("%w: product id %d not found", model.ErrNotFound, item.ProductID)
+			}
+			return nil, err
+		}
+		if before.Cost != item.UnitCost {
+			if _, err := tx.ExecContext(ctx, "UPDATE products SET cost = $1 WHERE id = $2", item.UnitCost, item.ProductID); err != nil {
+				return nil, err
+			}
+			after := *before
+			after.Cost = item.UnitCost
+			if err := recordAudit(ctx, tx, model.AuditEntityProduct, item.ProductID, model.AuditActionUpdate, before.AuditRecord(), after.AuditRecord()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, m := range receipt.Movements(ctx) {
+		if _, err := postStock(ctx, tx, m); err != nil {
+			return nil, err
+		}
+	}
+	if err := updatePurchaseOrderStatus(ctx, tx, po); err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityPurchaseOrder, id, model.AuditActionReceive, nil, receipt); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// namePurchaseOrderItems fills in the product names of the order's items, refusing products
+// that do not exist
+func namePurchaseOrderItems(ctx context.Context, tx *sql.Tx, po *model.PurchaseOrder) error {
+	for i, item := range po.Items {
+		err := tx.QueryRowContext(ctx, "SELECT name FROM products WHERE id = $1", item.ProductID).Scan(&po.Items[i].ProductName)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: product id %d not found", model.ErrNotFound, item.ProductID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertPurchaseOrderItems(ctx context.Context, tx *sql.Tx, po model.PurchaseOrder) error {
+	for _, item := range po.Items {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO purchase_order_items (purchase_order_id, product_id, product_name, quantity, unit_cost)
+			VALUES ($1, $2, $3, $4, $5)`, po.ID, item.ProductID, item.ProductName, item.Quantity, item.UnitCost)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func updatePurchaseOrderStatus(ctx context.Context, tx *sql.Tx, po *model.PurchaseOrder) error {
+	_, err := tx.ExecContext(ctx, "UPDATE purchase_orders SET status = $1, sent_at = $2, closed_at = $3 WHERE id = $4",
+		po.Status, po.SentAt, po.ClosedAt, po.ID)
+	return err
+}
+
+// findPurchaseOrder reads a purchase order with its items and receipts, locking
+// the order row with the given clause
+func findPurchaseOrder(ctx context.Context, q queryer, id int, lock string) (*model.PurchaseOrder, error) {
+	po, err := scanPurchaseOrder(q.QueryRowContext(ctx, "SELECT "+purchaseOrderColumns+purchaseOrderFrom+" WHERE po.id = $1 "+lock, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	if err := loadPurchaseOrderDetails(ctx, q, po); err != nil {
+		return nil, err
+	}
+	return po, nil
+}
+
+// loadPurchaseOrderDetails reads the items and receipts of an order
+func loadPurchaseOrderDetails(ctx context.Context, q queryer, po *model.PurchaseOrder) error {
+	rows, err := q.QueryContext(ctx, `
+		SELECT product_id, product_name, quantity, unit_cost, received_quantity
+		FROM purchase_order_items
+		WHERE purchase_order_id = $1
+		ORDER BY product_id`, po.ID)
+	if err != nil {
+		return err
+	}
+	po.Items = make([]model.PurchaseOrderItem, 0)
+	for rows.Next() {
+		var item model.PurchaseOrderItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.Quantity, &item.UnitCost, &item.ReceivedQuantity); err != nil {
+			rows.Close()
+			return err
+		}
+		po.Items = append(po.Items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT g.id, g.outlet_id, g.note, COALESCE(g.received_by, ''), g.received_at, i.product_id, i.quantity, i.unit_cost, i.over_delivered
+		FROM goods_receipts g
+		JOIN goods_receipt_items i ON i.goods_receipt_id = g.id
+		WHERE g.purchase_order_id = $1
+		ORDER BY g.id, i.product_id`, po.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	po.Receipts = make([]model.GoodsReceipt, 0)
+	for rows.Next() {
+		var g model.GoodsReceipt
+		var item model.GoodsReceiptItem
+		if err := rows.Scan(&g.ID, &g.OutletID, &g.Note, &g.ReceivedBy, &g.ReceivedAt, &item.ProductID, &item.Quantity, &item.UnitCost, &item.OverDelivered); err != nil {
+			return err
+		}
+		if n := len(po.Receipts); n == 0 || po.Receipts[n-1].ID != g.ID {
+			g.PurchaseOrderID = po.ID
+			po.Receipts = append(po.Receipts, g)
+		}
+		last := &po.Receipts[len(po.Receipts)-1]
+		last.Items = append(last.Items, item)
+	}
+
+	return rows.Err()
+}
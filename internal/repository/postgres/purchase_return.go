@@ -0,0 +1,285 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type PurchaseReturnRepository struct {
+	db *sql.DB
+}
+
+func NewPurchaseReturnRepository(db *sql.DB) *PurchaseReturnRepository {
+	return &PurchaseReturnRepository{db: db}
+}
+
+const purchaseReturnColumns = "pr.id, pr.supplier_id, s.name, pr.outlet_id, pr.goods_receipt_id, pr.status, pr.note, pr.credit_received, COALESCE(pr.created_by, ''), pr.created_at, pr.credited_at"
+
+const purchaseReturnFrom = " FROM purchase_returns pr JOIN suppliers s ON s.id = pr.supplier_id"
+
+func scanPurchaseReturn(row rowScanner) (*model.PurchaseReturn, error) {
+	var pr model.PurchaseReturn
+	var receiptID sql.NullInt64
+	var creditedAt sql.NullTime
+	if err := row.Scan(&pr.ID, &pr.SupplierID, &pr.SupplierName, &pr.OutletID, &receiptID, &pr.Status, &pr.Note, &pr.CreditReceived, &pr.CreatedBy, &pr.CreatedAt, &creditedAt); err != nil {
+		return nil, err
+	}
+	if receiptID.Valid {
+		id := int(receiptID.Int64)
+		pr.GoodsReceiptID = &id
+	}
+	if creditedAt.Valid {
+		pr.CreditedAt = &creditedAt.Time
+	}
+	return &pr, nil
+}
+
+func (r *PurchaseReturnRepository) FindByID(ctx context.Context, id int) (*model.PurchaseReturn, error) {
+	pr, err := findPurchaseReturn(ctx, r.db, id, "")
+	if err != nil {
+		return nil, err
+	}
+	result := pr.WithTotals()
+	return &result, nil
+}
+
+func (r *PurchaseReturnRepository) FindAll(ctx context.Context, filter model.PurchaseReturnFilter) ([]model.PurchaseReturn, error) {
+	where := " WHERE pr.outlet_id = $1"
+	args := []any{model.OutletID(ctx)}
+	argPos := 2
+
+	if filter.SupplierID != 0 {
+		where += fmt.Sprintf(" AND pr.supplier_id = $%d", argPos)
+		args = append(args, filter.SupplierID)
+		argPos++
+	}
+	if filter.Status != "" {
+		where += fmt.Sprintf(" AND pr.status = $%d", argPos)
+		args = append(args, filter.Status)
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+purchaseReturnColumns+purchaseReturnFrom+where+" ORDER BY pr.id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	returns := make([]model.PurchaseReturn, 0)
+	for rows.Next() {
+		pr, err := scanPurchaseReturn(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		returns = append(returns, *pr)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range returns {
+		if err := loadPurchaseReturnItems(ctx, r.db, &returns[i]); err != nil {
+			return nil, err
+		}
+		returns[i] = returns[i].WithTotals()
+	}
+	return returns, nil
+}
+
+// Create books the return and takes the returned stock out of the outlet, all in
+// the same transaction
+func (r *PurchaseReturnRepository) Create(ctx context.Context, req model.PurchaseReturnRequest) (*model.PurchaseReturn, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	products := make(map[int]model.Product, len(req.Items))
+	for _, item := range req.Items {
+		p, err := findProduct(ctx, tx, item.ProductID, "")
+		if errors.Is(err, model.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		products[item.ProductID] = *p
+	}
+
+	var receipt *model.ReturnableReceipt
+	if req.GoodsReceiptID != nil {
+		if receipt, err = findReturnableReceipt(ctx, tx, *req.GoodsReceiptID); err != nil {
+			return nil, err
+		}
+	}
+
+	pr, err := model.NewPurchaseReturn(ctx, req, products, receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO purchase_returns (supplier_id, outlet_id, goods_receipt_id, status, note, created_by) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		RETURNING id, created_at`, pr.SupplierID, pr.OutletID, pr.GoodsReceiptID, pr.Status, pr.Note, pr.CreatedBy).Scan(&pr.ID, &pr.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range pr.Items {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO purchase_return_items (purchase_return_id, product_id, product_name, quantity, unit_cost, reason)
+			VALUES ($1, $2, $3, $4, $5, $6)`, pr.ID, item.ProductID, item.ProductName, item.Quantity, item.UnitCost, item.Reason)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, m := range pr.Movements(ctx) {
+		if _, err := postStock(ctx, tx, m); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityPurchaseReturn, pr.ID, model.AuditActionCreate, nil, pr.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, pr.ID)
+}
+
+func (r *PurchaseReturnRepository) RecordCredit(ctx context.Context, id int, req model.SupplierCreditRequest) (*model.PurchaseReturn, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	pr, err := findPurchaseReturn(ctx, tx, id, "FOR UPDATE OF pr")
+	if err != nil {
+		return nil, err
+	}
+	before := pr.AuditRecord()
+	if err := pr.RecordCredit(req.Amount, time.Now()); err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE purchase_returns SET status = $1, credit_received = $2, credited_at = $3 WHERE id = $4",
+		pr.Status, pr.CreditReceived, pr.CreditedAt, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityPurchaseReturn, id, model.AuditActionCredit, before, pr.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// findReturnableReceipt reads a goods receipt with the supplier of its order and
+// what earlier returns took back of it. The receipt row is locked, so returns
+// against the same receipt queue on it.
+func findReturnableReceipt(ctx context.Context, tx *sql.Tx, receiptID int) (*model.ReturnableReceipt, error) {
+	receipt := &model.ReturnableReceipt{Returned: make(map[int]int)}
+	err := tx.QueryRowContext(ctx, `
+		SELECT g.id, g.purchase_order_id, g.outlet_id, po.supplier_id
+		FROM goods_receipts g
+		JOIN purchase_orders po ON po.id = g.purchase_order_id
+		WHERE g.id = $1
+		FOR UPDATE OF g`, receiptID).Scan(&receipt.ID, &receipt.PurchaseOrderID, &receipt.OutletID, &receipt.SupplierID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.GoodsReceiptNotFoundError(receiptID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT product_id, quantity, unit_cost FROM goods_receipt_items
+		WHERE goods_receipt_id = $1
+		ORDER BY product_id`, receiptID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var item model.GoodsReceiptItem
+		if err := rows.Scan(&item.ProductID, &item.Quantity, &item.UnitCost); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		receipt.Items = append(receipt.Items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, `
+		SELECT i.product_id, SUM(i.quantity)
+		FROM purchase_returns pr
+		JOIN purchase_return_items i ON i.purchase_return_id = pr.id
+		WHERE pr.goods_receipt_id = $1
+		GROUP BY i.product_id`, receiptID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var productID, quantity int
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		receipt.Returned[productID] = quantity
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// findPurchaseReturn reads a purchase return with its items, locking the return
+// row with the given clause
+func findPurchaseReturn(ctx context.Context, q queryer, id int, lock string) (*model.PurchaseReturn, error) {
+	pr, err := scanPurchaseReturn(q.QueryRowContext(ctx, "SELECT "+purchaseReturnColumns+purchaseReturnFrom+" WHERE pr.id = $1 "+lock, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	if err := loadPurchaseReturnItems(ctx, q, pr); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+func loadPurchaseReturnItems(ctx context.Context, q queryer, pr *model.PurchaseReturn) error {
+	rows, err := q.QueryContext(ctx, `
+		SELECT product_id, product_name, quantity, unit_cost, reason
+		FROM purchase_return_items
+		WHERE purchase_return_id = $1
+		ORDER BY product_id`, pr.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	pr.Items = make([]model.PurchaseReturnItem, 0)
+	for rows.Next() {
+		var item model.PurchaseReturnItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.Quantity, &item.UnitCost, &item.Reason); err != nil {
+			return err
+		}
+		pr.Items = append(pr.Items, item)
+	}
+	return rows.Err()
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"kasir-api/internal/model"
 )
@@ -16,83 +17,185 @@ func NewReportRepository(db *sql.DB) *ReportRepository {
 }
 
 func (r *ReportRepository) GetTodayReport(ctx context.Context) (*model.ReportSummary, error) {
-	var totalRevenue, totalTransaction int
-	err := r.db.QueryRowContext(ctx, `
-		SELECT COALESCE(SUM(total_amount), 0), COUNT(*)
-		FROM transactions
-		WHERE DATE(created_at) = CURRENT_DATE
-	`).Scan(&totalRevenue, &totalTransaction)
+	return r.summarize(ctx, "DATE(%s.created_at) = CURRENT_DATE")
+}
+
+func (r *ReportRepository) GetReportByDateRange(ctx context.Context, startDate, endDate string) (*model.ReportSummary, error) {
+	return r.summarize(ctx, "DATE(%s.created_at) BETWEEN $1 AND $2", startDate, endDate)
+}
+
+// summarize aggregates completed sales, returns and the best selling product for the
+// period, at the outlet of the context or, when it names none, at every outlet. The
+// period condition has a %s placeholder for the table alias and uses args as $1, $2...
+func (r *ReportRepository) summarize(ctx context.Context, period string, args ...any) (*model.ReportSummary, error) {
+	summary := &model.ReportSummary{}
+
+	outletCondition := ""
+	if outletID, ok := model.ScopedOutletID(ctx); ok {
+		summary.OutletID = &outletID
+		args = append(args, outletID)
+		outletCondition = fmt.Sprintf(" AND o.id = $%d", len(args))
+	}
+
+	// One row per outlet, including outlets without sales
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT o.id, o.code, COALESCE(SUM(t.gross_amount), 0), COALESCE(SUM(t.discount_amount), 0),
+			COALESCE(SUM(t.tax_amount), 0), COALESCE(SUM(t.total_amount), 0), COUNT(t.id)
+		FROM outlets o
+		LEFT JOIN transactions t ON t.outlet_id = o.id AND t.status = 'completed' AND `+fmt.Sprintf(period, "t")+`
+		WHERE 1=1`+outletCondition+`
+		GROUP BY o.id, o.code
+		ORDER BY o.id`, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	outlets := make([]model.OutletSales, 0)
+	for rows.Next() {
+		var o model.OutletSales
+		if err := rows.Scan(&o.OutletID, &o.OutletCode, &o.GrossSales, &o.TotalDiscount, &o.TotalTax, &o.TotalRevenue, &o.TotalTransaction); err != nil {
+			return nil, err
+		}
+		outlets = append(outlets, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	returns, err := r.sumReturns(ctx, fmt.Sprintf(period, "r")+outletCondition, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range outlets {
+		o := &outlets[i]
+		o.TotalReturns = returns[o.OutletID].amount
+		o.TotalRevenue -= o.TotalReturns
+		o.TotalTax -= returns[o.OutletID].tax
+
+		summary.GrossSales += o.GrossSales
+		summary.TotalDiscount += o.TotalDiscount
+		summary.TotalTax += o.TotalTax
+		summary.TotalRevenue += o.TotalRevenue
+		summary.TotalReturns += o.TotalReturns
+		summary.TotalTransaction += o.TotalTransaction
+	}
+	if summary.OutletID == nil {
+		summary.Outlets = outlets
+	}
 
-	var topProduct *model.TopProduct
 	var name sql.NullString
 	var soldQty sql.NullInt64
 	err = r.db.QueryRowContext(ctx, `
-		SELECT p.name, SUM(td.quantity) as sold_qty
+		SELECT (ARRAY_AGG(COALESCE(td.parent_product_name, td.product_name) ORDER BY td.id DESC))[1], SUM(td.quantity) as sold_qty
 		FROM transaction_details td
-		JOIN products p ON td.product_id = p.id
 		JOIN transactions t ON td.transaction_id = t.id
-		WHERE DATE(t.created_at) = CURRENT_DATE
-		GROUP BY p.id, p.name
-		ORDER BY sold_qty DESC
+		JOIN outlets o ON t.outlet_id = o.id
+		WHERE t.status = 'completed' AND `+fmt.Sprintf(period, "t")+outletCondition+`
+		GROUP BY COALESCE(td.parent_product_id, td.product_id)
+		ORDER BY sold_qty DESC, COALESCE(td.parent_product_id, td.product_id)
 		LIMIT 1
-	`).Scan(&name, &soldQty)
+	`, args...).Scan(&name, &soldQty)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
 	if name.Valid {
-		topProduct = &model.TopProduct{
+		summary.TopProduct = &model.TopProduct{
 			Name:    name.String,
 			SoldQty: int(soldQty.Int64),
 		}
 	}
 
-	return &model.ReportSummary{
-		TotalRevenue:     totalRevenue,
-		TotalTransaction: totalTransaction,
-		TopProduct:       topProduct,
-	}, nil
+	return summary, nil
 }
 
-func (r *ReportRepository) GetReportByDateRange(ctx context.Context, startDate, endDate string) (*model.ReportSummary, error) {
-	var totalRevenue, totalTransaction int
-	err := r.db.QueryRowContext(ctx, `
-		SELECT COALESCE(SUM(total_amount), 0), COUNT(*)
-		FROM transactions
-		WHERE DATE(created_at) BETWEEN $1 AND $2
-	`, startDate, endDate).Scan(&totalRevenue, &totalTransaction)
+// returnTotals is what customer returns refunded, as positive numbers
+type returnTotals struct {
+	amount int
+	tax    int // PPN included in amount
+}
+
+// sumReturns returns the refunds per outlet of customer returns matching the
+// condition, ignoring returns of voided or refunded transactions
+func (r *ReportRepository) sumReturns(ctx context.Context, condition string, args ...any) (map[int]returnTotals, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT o.id, COALESCE(-SUM(r.total_amount), 0), COALESCE(-SUM(r.tax_amount), 0)
+		FROM returns r
+		JOIN transactions t ON r.transaction_id = t.id
+		JOIN outlets o ON t.outlet_id = o.id
+		WHERE t.status = 'completed' AND `+condition+`
+		GROUP BY o.id`, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	var topProduct *model.TopProduct
-	var name sql.NullString
-	var soldQty sql.NullInt64
-	err = r.db.QueryRowContext(ctx, `
-		SELECT p.name, SUM(td.quantity) as sold_qty
-		FROM transaction_details td
-		JOIN products p ON td.product_id = p.id
-		JOIN transactions t ON td.transaction_id = t.id
-		WHERE DATE(t.created_at) BETWEEN $1 AND $2
-		GROUP BY p.id, p.name
-		ORDER BY sold_qty DESC
-		LIMIT 1
-	`, startDate, endDate).Scan(&name, &soldQty)
-	if err != nil && err != sql.ErrNoRows {
+	totals := make(map[int]returnTotals)
+	for rows.Next() {
+		var outletID int
+		var t returnTotals
+		if err := rows.Scan(&outletID, &t.amount, &t.tax); err != nil {
+			return nil, err
+		}
+		totals[outletID] = t
+	}
+	return totals, rows.Err()
+}
+
+// GetInventoryValuation values the stock of the outlet of the context or, when it
+// names none, of every outlet at each product's current cost. Products without
+// stock are left out.
+func (r *ReportRepository) GetInventoryValuation(ctx context.Context) (*model.InventoryValuation, error) {
+	valuation := &model.InventoryValuation{Products: make([]model.InventoryValuationLine, 0)}
+
+	var args []any
+	stockCondition, returnCondition := "", ""
+	if outletID, ok := model.ScopedOutletID(ctx); ok {
+		valuation.OutletID = &outletID
+		args = append(args, outletID)
+		stockCondition = " AND ps.outlet_id = $1"
+		returnCondition = " AND pr.outlet_id = $1"
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.id, p.name, p.parent_id, SUM(ps.stock), p.cost
+		FROM products p
+		JOIN product_stocks ps ON ps.product_id = p.id`+stockCondition+`
+		GROUP BY p.id, p.name, p.parent_id, p.cost
+		HAVING SUM(ps.stock) <> 0
+		ORDER BY p.id`, args...)
+	if err != nil {
 		return nil, err
 	}
-	if name.Valid {
-		topProduct = &model.TopProduct{
-			Name:    name.String,
-			SoldQty: int(soldQty.Int64),
+	defer rows.Close()
+
+	for rows.Next() {
+		var line model.InventoryValuationLine
+		if err := rows.Scan(&line.ProductID, &line.ProductName, &line.ParentProductID, &line.Stock, &line.UnitCost); err != nil {
+			return nil, err
 		}
+		line.Value = line.Stock * line.UnitCost
+		valuation.TotalUnits += line.Stock
+		valuation.TotalValue += line.Value
+		valuation.Products = append(valuation.Products, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Credit owed on open returns: the returned value less the credit booked so far
+	err = r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(owed), 0) FROM (
+			SELECT GREATEST(SUM(i.quantity * i.unit_cost) - pr.credit_received, 0) AS owed
+			FROM purchase_returns pr
+			JOIN purchase_return_items i ON i.purchase_return_id = pr.id
+			WHERE pr.status = 'open'`+returnCondition+`
+			GROUP BY pr.id, pr.credit_received
+		) pending`, args...).Scan(&valuation.PendingSupplierCredit)
+	if err != nil {
+		return nil, err
 	}
 
-	return &model.ReportSummary{
-		TotalRevenue:     totalRevenue,
-		TotalTransaction: totalTransaction,
-		TopProduct:       topProduct,
-	}, nil
+	return valuation, nil
 }
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/pkg/middleware"
+)
+
+func TestReportRepository_NetsOutReturns(t *testing.T) {
+	db := setupSalesDB(t)
+	defer db.Close()
+
+	products := NewProductRepository(db)
+	transactions := NewTransactionRepository(db)
+	returns := NewReturnRepository(db)
+	repo := NewReportRepository(db)
+	ctx := context.Background()
+
+	indomie, _ := products.Create(ctx, model.Product{Name: "Indomie", Price: 3500, Stock: 10, Active: true})
+	teh, _ := products.Create(ctx, model.Product{Name: "Teh Botol", Price: 5000, Stock: 10, Active: true})
+
+	// 3 x 3500 with 11% PPN on top: 1155 tax, 11655 charged
+	sale, err := transactions.CreateTransaction(ctx, model.CheckoutRequest{
+		Items: []model.CheckoutItem{{ProductID: indomie.ID, Quantity: 3}},
+	}, model.CheckoutOptions{TaxRate: 1100})
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+	if sale.TaxAmount != 1155 || sale.TotalAmount != 11655 {
+		t.Fatalf("Transaction tax = %d, total = %d, want 1155 and 11655", sale.TaxAmount, sale.TotalAmount)
+	}
+	if _, err := returns.CreateReturn(ctx, sale.ID, model.ReturnRequest{
+		Items: []model.ReturnRequestItem{{ProductID: indomie.ID, Quantity: 1}}, Reason: "damaged",
+	}); err != nil {
+		t.Fatalf("CreateReturn() error = %v", err)
+	}
+
+	// A return of a sale that was refunded afterwards is already out of the report
+	refunded, _ := transactions.CreateTransaction(ctx, model.CheckoutRequest{
+		Items: []model.CheckoutItem{{ProductID: teh.ID, Quantity: 2}},
+	}, model.CheckoutOptions{})
+	returns.CreateReturn(ctx, refunded.ID, model.ReturnRequest{
+		Items: []model.ReturnRequestItem{{ProductID: teh.ID, Quantity: 1}}, Reason: "damaged",
+	})
+	if _, err := transactions.CancelTransaction(ctx, refunded.ID, model.TransactionStatusRefunded, model.CancelRequest{Reason: "complaint"}); err != nil {
+		t.Fatalf("CancelTransaction() error = %v", err)
+	}
+
+	report, err := repo.GetTodayReport(ctx)
+	if err != nil {
+		t.Fatalf("GetTodayReport() error = %v", err)
+	}
+	if report.TotalRevenue != 7770 || report.TotalReturns != 3885 || report.TotalTax != 770 {
+		t.Errorf("Report revenue = %d, returns = %d, tax = %d, want 7770, 3885 and 770", report.TotalRevenue, report.TotalReturns, report.TotalTax)
+	}
+	if report.TotalTransaction != 1 {
+		t.Errorf("TotalTransaction = %d, want 1", report.TotalTransaction)
+	}
+
+	var outlet *model.OutletSales
+	for i := range report.Outlets {
+		if report.Outlets[i].OutletID == model.DefaultOutletID {
+			outlet = &report.Outlets[i]
+		}
+	}
+	if outlet == nil || outlet.TotalRevenue != 7770 || outlet.TotalTax != 770 {
+		t.Errorf("Outlet sales = %+v, want revenue 7770 and tax 770", outlet)
+	}
+
+	scoped, err := repo.GetTodayReport(middleware.WithOutletID(ctx, model.DefaultOutletID))
+	if err != nil {
+		t.Fatalf("GetTodayReport() error = %v", err)
+	}
+	if scoped.TotalRevenue != 7770 || scoped.TotalTax != 770 {
+		t.Errorf("Scoped report revenue = %d, tax = %d, want 7770 and 770", scoped.TotalRevenue, scoped.TotalTax)
+	}
+}
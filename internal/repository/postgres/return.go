@@ -0,0 +1,206 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"kasir-api/internal/model"
+)
+
+type ReturnRepository struct {
+	db *sql.DB
+}
+
+func NewReturnRepository(db *sql.DB) *ReturnRepository {
+	return &ReturnRepository{db: db}
+}
+
+func (r *ReturnRepository) CreateReturn(ctx context.Context, transactionID int, req model.ReturnRequest) (*model.Return, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the transaction so concurrent returns and void/refund are serialized
+	var status model.TransactionStatus
+	var outletID int
+	err = tx.QueryRowContext(ctx, "SELECT status, outlet_id FROM transactions WHERE id = $1 FOR UPDATE", transactionID).Scan(&status, &outletID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	if status != model.TransactionStatusCompleted {
+		return nil, fmt.Errorf("%w: cannot return items of a %s transaction", model.ErrConflict, status)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT td.id, td.product_id, td.product_name, td.quantity, td.subtotal, td.tax_amount,
+			COALESCE(SUM(ri.quantity), 0), COALESCE(SUM(ri.amount), 0), COALESCE(SUM(ri.tax_amount), 0)
+		FROM transaction_details td
+		LEFT JOIN return_items ri ON ri.transaction_detail_id = td.id
+		WHERE td.transaction_id = $1
+		GROUP BY td.id, td.product_id, td.product_name, td.quantity, td.subtotal, td.tax_amount
+		ORDER BY td.id`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []model.ReturnableLine
+	for rows.Next() {
+		var l model.ReturnableLine
+		if err := rows.Scan(&l.DetailID, &l.ProductID, &l.ProductName, &l.Quantity, &l.Amount, &l.Tax, &l.ReturnedQty, &l.ReturnedAmount, &l.ReturnedTax); err != nil {
+			return nil, err
+		}
+		lines = append(lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	items, err := model.AllocateReturn(lines, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := model.NewReturn(ctx, transactionID, req, items)
+
+	var createdAt sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO returns (transaction_id, total_amount, tax_amount, reason, performed_by, restock)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		transactionID, ret.TotalAmount, ret.TaxAmount, ret.Reason, ret.PerformedBy, ret.Restock).Scan(&ret.ID, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	ret.CreatedAt = createdAt.Time
+
+	// Batch insert return items with RETURNING
+	query := "INSERT INTO return_items (return_id, transaction_detail_id, product_id, quantity, amount, tax_amount) VALUES "
+	args := make([]any, 0, len(items)*6)
+	for i, item := range items {
+		if i > 0 {
+			query += ", "
+		}
+		offset := i * 6
+		query += fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", offset+1, offset+2, offset+3, offset+4, offset+5, offset+6)
+		args = append(args, ret.ID, item.TransactionDetailID, item.ProductID, item.Quantity, item.Amount, item.TaxAmount)
+		ret.Items[i].ReturnID = ret.ID
+	}
+	query += " RETURNING id"
+
+	idRows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer idRows.Close()
+
+	i := 0
+	for idRows.Next() {
+		if err := idRows.Scan(&ret.Items[i].ID); err != nil {
+			return nil, err
+		}
+		i++
+	}
+	if err := idRows.Err(); err != nil {
+		return nil, err
+	}
+
+	if req.Restock {
+		for _, item := range items {
+			// Returned goods go back on the shelf of the outlet that sold them
+			m := model.NewStockMovement(ctx, outletID, item.ProductID, model.StockMovementReturn, item.Quantity).
+				For(model.StockReferenceReturn, ret.ID)
+			if _, err := postStock(ctx, tx, m); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityReturn, ret.ID, model.AuditActionCreate, nil, ret); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (r *ReturnRepository) FindByID(ctx context.Context, id int) (*model.Return, error) {
+	returns, err := r.find(ctx, "r.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(returns) == 0 {
+		return nil, model.ErrNotFound
+	}
+	return &returns[0], nil
+}
+
+func (r *ReturnRepository) FindByTransactionID(ctx context.Context, transactionID int) ([]model.Return, error) {
+	return r.find(ctx, "r.transaction_id = $1", transactionID)
+}
+
+// find loads returns matching the condition together with their items in two queries
+func (r *ReturnRepository) find(ctx context.Context, condition string, arg any) ([]model.Return, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT r.id, r.transaction_id, r.total_amount, r.tax_amount, r.reason, r.performed_by, r.restock, r.created_at
+		FROM returns r
+		WHERE `+condition+`
+		ORDER BY r.id`, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	returns := make([]model.Return, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		var ret model.Return
+		var createdAt sql.NullTime
+		if err := rows.Scan(&ret.ID, &ret.TransactionID, &ret.TotalAmount, &ret.TaxAmount, &ret.Reason, &ret.PerformedBy, &ret.Restock, &createdAt); err != nil {
+			return nil, err
+		}
+		ret.CreatedAt = createdAt.Time
+		ret.Items = []model.ReturnItem{}
+		index[ret.ID] = len(returns)
+		returns = append(returns, ret)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(returns) == 0 {
+		return returns, nil
+	}
+
+	itemRows, err := r.db.QueryContext(ctx, `
+		SELECT ri.id, ri.return_id, ri.transaction_detail_id, ri.product_id, td.product_name, ri.quantity, ri.amount, ri.tax_amount
+		FROM return_items ri
+		JOIN returns r ON ri.return_id = r.id
+		JOIN transaction_details td ON ri.transaction_detail_id = td.id
+		WHERE `+condition+`
+		ORDER BY ri.id`, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item model.ReturnItem
+		if err := itemRows.Scan(&item.ID, &item.ReturnID, &item.TransactionDetailID, &item.ProductID, &item.ProductName, &item.Quantity, &item.Amount, &item.TaxAmount); err != nil {
+			return nil, err
+		}
+		if i, ok := index[item.ReturnID]; ok {
+			returns[i].Items = append(returns[i].Items, item)
+		}
+	}
+
+	return returns, itemRows.Err()
+}
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, s model.Session, refresh model.RefreshToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Sessions are only ever looked up while they are active, so drop the ones
+	// past their expiry as new ones come in
+	if _, err := tx.ExecContext(ctx, "DELETE FROM auth_sessions WHERE expires_at <= $1", s.CreatedAt); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO auth_sessions (id, user_id, created_at, expires_at) VALUES ($1, $2, $3, $4)`,
+		s.ID, s.UserID, s.CreatedAt, s.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at) VALUES ($1, $2, $3)`,
+		s.ID, refresh.TokenHash, refresh.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SessionRepository) FindByID(ctx context.Context, id string) (*model.Session, error) {
+	var s model.Session
+	var createdAt, revokedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, created_at, expires_at, revoked_at FROM auth_sessions WHERE id = $1`, id).
+		Scan(&s.ID, &s.UserID, &createdAt, &s.ExpiresAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	s.CreatedAt = createdAt.Time
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+	return &s, nil
+}
+
+func (r *SessionRepository) Rotate(ctx context.Context, tokenHash string, next model.RefreshToken, at time.Time) (*model.Session, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the token so two refreshes with it cannot both succeed
+	var tokenID int
+	var tokenExpiresAt time.Time
+	var usedAt sql.NullTime
+	var s model.Session
+	var createdAt, revokedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		SELECT rt.id, rt.expires_at, rt.used_at, s.id, s.user_id, s.created_at, s.expires_at, s.revoked_at
+		FROM refresh_tokens rt
+		JOIN auth_sessions s ON s.id = rt.session_id
+		WHERE rt.token_hash = $1
+		FOR UPDATE OF rt, s`, tokenHash).
+		Scan(&tokenID, &tokenExpiresAt, &usedAt, &s.ID, &s.UserID, &createdAt, &s.ExpiresAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.InvalidSessionError()
+		}
+		return nil, err
+	}
+	s.CreatedAt = createdAt.Time
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+
+	if usedAt.Valid {
+		if _, err := tx.ExecContext(ctx, "UPDATE auth_sessions SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL", s.ID, at); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, model.RefreshTokenReusedError()
+	}
+	if !at.Before(tokenExpiresAt) || !s.IsActive(at) {
+		return nil, model.InvalidSessionError()
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET used_at = $2 WHERE id = $1", tokenID, at); err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at) VALUES ($1, $2, $3)`,
+		s.ID, next.TokenHash, next.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE auth_sessions SET expires_at = $2 WHERE id = $1", s.ID, next.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.ExpiresAt = next.ExpiresAt
+	return &s, nil
+}
+
+func (r *SessionRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE auth_sessions SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1", id, at)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID int, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE auth_sessions SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL", userID, at)
+	return err
+}
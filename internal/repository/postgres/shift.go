@@ -0,0 +1,357 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type ShiftRepository struct {
+	db *sql.DB
+}
+
+func NewShiftRepository(db *sql.DB) *ShiftRepository {
+	return &ShiftRepository{db: db}
+}
+
+const shiftColumns = "s.id, s.outlet_id, s.status, s.opened_by, s.opening_float, s.opened_at, s.closed_by, s.closed_at, s.counted_cash, s.expected_cash, s.note"
+
+func scanShift(row rowScanner) (*model.Shift, error) {
+	var s model.Shift
+	var openedAt, closedAt sql.NullTime
+	var closedBy, note sql.NullString
+	var counted, expected sql.NullInt64
+	if err := row.Scan(&s.ID, &s.OutletID, &s.Status, &s.OpenedBy, &s.OpeningFloat, &openedAt, &closedBy, &closedAt, &counted, &expected, &note); err != nil {
+		return nil, err
+	}
+
+	s.OpenedAt = openedAt.Time
+	s.ClosedBy = closedBy.String
+	s.Note = note.String
+	if closedAt.Valid {
+		s.ClosedAt = &closedAt.Time
+	}
+	if counted.Valid && expected.Valid {
+		c, e := int(counted.Int64), int(expected.Int64)
+		d := c - e
+		s.CountedCash, s.ExpectedCash, s.Difference = &c, &e, &d
+	}
+	s.Movements = []model.CashMovement{}
+
+	return &s, nil
+}
+
+func (r *ShiftRepository) FindByID(ctx context.Context, id int) (*model.Shift, error) {
+	return r.findOne(ctx, "s.id = $1", id)
+}
+
+func (r *ShiftRepository) FindOpen(ctx context.Context) (*model.Shift, error) {
+	return r.findOne(ctx, "s.outlet_id = $1 AND s.status = $2", model.OutletID(ctx), model.ShiftStatusOpen)
+}
+
+// FindOpenID skips the movements and summary; CreateTransaction locks the shift
+// and checks it is still open in its own transaction
+func (r *ShiftRepository) FindOpenID(ctx context.Context) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, "SELECT id FROM shifts WHERE outlet_id = $1 AND status = $2", model.OutletID(ctx), model.ShiftStatusOpen).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, model.ErrNotFound
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *ShiftRepository) findOne(ctx context.Context, condition string, args ...any) (*model.Shift, error) {
+	s, err := scanShift(r.db.QueryRowContext(ctx, "SELECT "+shiftColumns+" FROM shifts s WHERE "+condition, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+
+	if s.Movements, err = findCashMovements(ctx, r.db, s.ID); err != nil {
+		return nil, err
+	}
+	if s.Summary, err = summarizeShift(ctx, r.db, *s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (r *ShiftRepository) FindAll(ctx context.Context) ([]model.Shift, error) {
+	query, args := scopeToOutlet(ctx, "SELECT "+shiftColumns+" FROM shifts s WHERE 1=1", "s.outlet_id")
+	rows, err := r.db.QueryContext(ctx, query+" ORDER BY s.id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shifts := make([]model.Shift, 0)
+	for rows.Next() {
+		s, err := scanShift(rows)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, *s)
+	}
+
+	return shifts, rows.Err()
+}
+
+func (r *ShiftRepository) Open(ctx context.Context, req model.OpenShiftRequest) (*model.Shift, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The partial unique index allows a single open shift per outlet; losing the race inserts nothing
+	open := model.NewShift(ctx, model.OutletID(ctx), req)
+	s, err := scanShift(tx.QueryRowContext(ctx, `
+		INSERT INTO shifts AS s (outlet_id, status, opened_by, opening_float) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (outlet_id) WHERE status = 'open' DO NOTHING
+		RETURNING `+shiftColumns, open.OutletID, open.Status, open.OpenedBy, open.OpeningFloat))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: another shift is still open", model.ErrConflict)
+		}
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityShift, s.ID, model.AuditActionCreate, nil, s); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *ShiftRepository) AddCashMovement(ctx context.Context, shiftID int, req model.CashMovementRequest) (*model.CashMovement, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenShift(ctx, tx, shiftID, "FOR SHARE"); err != nil {
+		return nil, err
+	}
+
+	m := model.NewCashMovement(ctx, shiftID, req)
+	var createdAt sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO cash_movements (shift_id, type, amount, reason, performed_by) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`, shiftID, m.Type, m.Amount, m.Reason, m.PerformedBy).Scan(&m.ID, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	m.CreatedAt = createdAt.Time
+
+	if err := recordAudit(ctx, tx, model.AuditEntityShift, shiftID, model.AuditActionCashMovement, nil, m); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func (r *ShiftRepository) Close(ctx context.Context, id int, req model.CloseShiftRequest) (*model.Shift, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The exclusive lock waits for checkouts holding the shift and keeps new ones out
+	if err := lockOpenShift(ctx, tx, id, "FOR UPDATE"); err != nil {
+		return nil, err
+	}
+
+	shift, err := scanShift(tx.QueryRowContext(ctx, "SELECT "+shiftColumns+" FROM shifts s WHERE s.id = $1", id))
+	if err != nil {
+		return nil, err
+	}
+	if shift.Movements, err = findCashMovements(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	summary, err := summarizeShift(ctx, tx, *shift)
+	if err != nil {
+		return nil, err
+	}
+
+	closing := *shift
+	closing.Close(ctx, req, summary.ExpectedCash, time.Now())
+	_, err = tx.ExecContext(ctx, `
+		UPDATE shifts
+		SET status = $1, closed_by = $2, closed_at = CURRENT_TIMESTAMP, counted_cash = $3, expected_cash = $4, note = $5
+		WHERE id = $6`, closing.Status, closing.ClosedBy, *closing.CountedCash, *closing.ExpectedCash, closing.Note, id)
+	if err != nil {
+		return nil, err
+	}
+
+	closed, err := scanShift(tx.QueryRowContext(ctx, "SELECT "+shiftColumns+" FROM shifts s WHERE s.id = $1", id))
+	if err != nil {
+		return nil, err
+	}
+	closed.Movements = shift.Movements
+	closed.Summary = summary
+	if err := recordAudit(ctx, tx, model.AuditEntityShift, id, model.AuditActionClose, shift, closed); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.FindByID(ctx, id)
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// lockOpenShift locks the shift row with the given clause and checks that it is open
+func lockOpenShift(ctx context.Context, tx *sql.Tx, id int, lock string) error {
+	var status model.ShiftStatus
+	err := tx.QueryRowContext(ctx, "SELECT status FROM shifts WHERE id = $1 "+lock, id).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrNotFound
+		}
+		return err
+	}
+	if status != model.ShiftStatusOpen {
+		return model.ShiftClosedError(id)
+	}
+	return nil
+}
+
+func findCashMovements(ctx context.Context, q queryer, shiftID int) ([]model.CashMovement, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, shift_id, type, amount, reason, performed_by, created_at
+		FROM cash_movements
+		WHERE shift_id = $1
+		ORDER BY id`, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := make([]model.CashMovement, 0)
+	for rows.Next() {
+		var m model.CashMovement
+		var createdAt sql.NullTime
+		if err := rows.Scan(&m.ID, &m.ShiftID, &m.Type, &m.Amount, &m.Reason, &m.PerformedBy, &createdAt); err != nil {
+			return nil, err
+		}
+		m.CreatedAt = createdAt.Time
+		movements = append(movements, m)
+	}
+
+	return movements, rows.Err()
+}
+
+// summarizeShift totals the completed transactions of a shift by payment method,
+// with cash net of the change given back, and the cash refunded during it
+func summarizeShift(ctx context.Context, q queryer, s model.Shift) (*model.ShiftSummary, error) {
+	var count, total, change int
+	err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(total_amount), 0), COALESCE(SUM(change_amount), 0)
+		FROM transactions
+		WHERE shift_id = $1 AND status = 'completed'`, s.ID).Scan(&count, &total, &change)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT p.method, SUM(p.amount)
+		FROM payments p
+		JOIN transactions t ON p.transaction_id = t.id
+		WHERE t.shift_id = $1 AND t.status = 'completed'
+		GROUP BY p.method`, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := make(map[model.PaymentMethod]int)
+	for rows.Next() {
+		var method model.PaymentMethod
+		var amount int
+		if err := rows.Scan(&method, &amount); err != nil {
+			return nil, err
+		}
+		payments[method] = amount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if change > 0 {
+		payments[model.PaymentMethodCash] -= change
+	}
+
+	refunds, err := shiftCashRefunds(ctx, q, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.NewShiftSummary(s.OpeningFloat, count, total, payments, refunds, s.Movements), nil
+}
+
+// shiftCashRefunds adds up the cash handed back at the shift's outlet while it
+// was open. A voided sale of the shift itself has already dropped out of its
+// totals, so neither the void nor the sale's returns count again.
+func shiftCashRefunds(ctx context.Context, q queryer, s model.Shift) (int, error) {
+	// Each row is a refund: the cash its sale kept, what earlier returns of the
+	// sale refunded, and the amount refunded now
+	rows, err := q.QueryContext(ctx, `
+		WITH sale AS (
+			SELECT t.id, t.shift_id, t.status, t.total_amount, t.cancelled_at,
+				COALESCE((SELECT SUM(p.amount) FROM payments p WHERE p.transaction_id = t.id AND p.method = 'cash'), 0) - t.change_amount AS cash_kept
+			FROM transactions t
+			WHERE t.outlet_id = $1
+		)
+		SELECT sale.cash_kept,
+			COALESCE((SELECT -SUM(e.total_amount) FROM returns e WHERE e.transaction_id = r.transaction_id AND e.id < r.id), 0),
+			-r.total_amount
+		FROM returns r
+		JOIN sale ON sale.id = r.transaction_id
+		WHERE r.created_at >= $2 AND ($3::timestamp IS NULL OR r.created_at < $3)
+			AND NOT (sale.shift_id IS NOT DISTINCT FROM $4 AND sale.status <> 'completed')
+		UNION ALL
+		SELECT sale.cash_kept, returned.amount, sale.total_amount - returned.amount
+		FROM sale
+		CROSS JOIN LATERAL (SELECT COALESCE(-SUM(e.total_amount), 0) AS amount FROM returns e WHERE e.transaction_id = sale.id) returned
+		WHERE sale.cancelled_at >= $2 AND ($3::timestamp IS NULL OR sale.cancelled_at < $3)
+			AND sale.shift_id IS DISTINCT FROM $4`,
+		s.OutletID, s.OpenedAt, s.ClosedAt, s.ID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	refunds := 0
+	for rows.Next() {
+		var cashKept, refunded, amount int
+		if err := rows.Scan(&cashKept, &refunded, &amount); err != nil {
+			return 0, err
+		}
+		refunds += model.CashRefund(cashKept, refunded, amount)
+	}
+	return refunds, rows.Err()
+}
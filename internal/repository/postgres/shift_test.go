@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"kasir-api/internal/model"
+)
+
+// setupSalesDB is setupTestDB with the sales, shifts and returns cleared too, and
+// cleared again afterwards so other tests can delete the products they refer to
+func setupSalesDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db := setupTestDB(t)
+	clear := func() {
+		db.Exec("TRUNCATE returns, payments, transaction_details, transactions, cash_movements, shifts, stock_alerts CASCADE")
+	}
+	clear()
+	t.Cleanup(clear)
+
+	return db
+}
+
+func TestShiftRepository_CashRefunds(t *testing.T) {
+	db := setupSalesDB(t)
+	defer db.Close()
+
+	products := NewProductRepository(db)
+	transactions := NewTransactionRepository(db)
+	returns := NewReturnRepository(db)
+	repo := NewShiftRepository(db)
+	ctx := context.Background()
+
+	indomie, _ := products.Create(ctx, model.Product{Name: "Indomie", Price: 3500, Stock: 10, Active: true})
+	teh, _ := products.Create(ctx, model.Product{Name: "Teh Botol", Price: 5000, Stock: 10, Active: true})
+
+	first, err := repo.Open(ctx, model.OpenShiftRequest{OpeningFloat: 100000})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	opts := model.CheckoutOptions{ShiftID: &first.ID}
+	cashSale, err := transactions.CreateTransaction(ctx, model.CheckoutRequest{
+		Items:    []model.CheckoutItem{{ProductID: indomie.ID, Quantity: 2}},
+		Payments: []model.CheckoutPayment{{Method: model.PaymentMethodCash, Amount: 10000}},
+	}, opts)
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+	qrisSale, _ := transactions.CreateTransaction(ctx, model.CheckoutRequest{
+		Items:    []model.CheckoutItem{{ProductID: teh.ID, Quantity: 1}},
+		Payments: []model.CheckoutPayment{{Method: model.PaymentMethodQRIS, Amount: 5000}},
+	}, opts)
+	voided, _ := transactions.CreateTransaction(ctx, model.CheckoutRequest{
+		Items:    []model.CheckoutItem{{ProductID: indomie.ID, Quantity: 1}},
+		Payments: []model.CheckoutPayment{{Method: model.PaymentMethodCash, Amount: 3500}},
+	}, opts)
+
+	if _, err := returns.CreateReturn(ctx, cashSale.ID, model.ReturnRequest{
+		Items: []model.ReturnRequestItem{{ProductID: indomie.ID, Quantity: 1}}, Reason: "expired",
+	}); err != nil {
+		t.Fatalf("CreateReturn() error = %v", err)
+	}
+	if _, err := transactions.CancelTransaction(ctx, voided.ID, model.TransactionStatusVoided, model.CancelRequest{Reason: "mistake"}); err != nil {
+		t.Fatalf("CancelTransaction() error = %v", err)
+	}
+
+	// 100000 float + 7000 cash kept - 3500 returned; the void drops its sale out
+	counted := 103500
+	closed, err := repo.Close(ctx, first.ID, model.CloseShiftRequest{CountedCash: &counted})
+	if err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if closed.Summary.CashRefunds != 3500 || *closed.ExpectedCash != 103500 {
+		t.Errorf("First shift refunds %d, expected %d, want 3500 and 103500", closed.Summary.CashRefunds, *closed.ExpectedCash)
+	}
+
+	// Refunding the earlier shift's sales pays back the rest of their cash from
+	// the new drawer; the QRIS sale goes back to QRIS
+	second, err := repo.Open(ctx, model.OpenShiftRequest{OpeningFloat: 50000})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for _, id := range []int{cashSale.ID, qrisSale.ID} {
+		if _, err := transactions.CancelTransaction(ctx, id, model.TransactionStatusRefunded, model.CancelRequest{Reason: "complaint"}); err != nil {
+			t.Fatalf("CancelTransaction() error = %v", err)
+		}
+	}
+
+	open, err := repo.FindByID(ctx, second.ID)
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if open.Summary.CashRefunds != 3500 || open.Summary.ExpectedCash != 46500 {
+		t.Errorf("Second shift refunds %d, expected %d, want 3500 and 46500", open.Summary.CashRefunds, open.Summary.ExpectedCash)
+	}
+}
+
+func TestShiftRepository_MixedTenderRefund(t *testing.T) {
+	db := setupSalesDB(t)
+	defer db.Close()
+
+	products := NewProductRepository(db)
+	transactions := NewTransactionRepository(db)
+	returns := NewReturnRepository(db)
+	repo := NewShiftRepository(db)
+	ctx := context.Background()
+
+	teh, _ := products.Create(ctx, model.Product{Name: "Teh Botol", Price: 5000, Stock: 10, Active: true})
+
+	shift, err := repo.Open(ctx, model.OpenShiftRequest{OpeningFloat: 50000})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	// 10000 paid with 6000 cash and 4000 QRIS
+	sale, err := transactions.CreateTransaction(ctx, model.CheckoutRequest{
+		Items: []model.CheckoutItem{{ProductID: teh.ID, Quantity: 2}},
+		Payments: []model.CheckoutPayment{
+			{Method: model.PaymentMethodCash, Amount: 6000},
+			{Method: model.PaymentMethodQRIS, Amount: 4000},
+		},
+	}, model.CheckoutOptions{ShiftID: &shift.ID})
+	if err != nil {
+		t.Fatalf("CreateTransaction() error = %v", err)
+	}
+
+	// Refunds come out of the cash first: the return takes 5000 of the 6000, and
+	// refunding the rest only has 1000 of cash left to give back
+	if _, err := returns.CreateReturn(ctx, sale.ID, model.ReturnRequest{
+		Items: []model.ReturnRequestItem{{ProductID: teh.ID, Quantity: 1}}, Reason: "damaged",
+	}); err != nil {
+		t.Fatalf("CreateReturn() error = %v", err)
+	}
+	found, _ := repo.FindByID(ctx, shift.ID)
+	if found.Summary.CashRefunds != 5000 {
+		t.Errorf("CashRefunds after the return = %d, want 5000", found.Summary.CashRefunds)
+	}
+
+	counted := 51000
+	closed, err := repo.Close(ctx, shift.ID, model.CloseShiftRequest{CountedCash: &counted})
+	if err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	next, _ := repo.Open(ctx, model.OpenShiftRequest{OpeningFloat: 50000})
+	if _, err := transactions.CancelTransaction(ctx, sale.ID, model.TransactionStatusRefunded, model.CancelRequest{Reason: "complaint"}); err != nil {
+		t.Fatalf("CancelTransaction() error = %v", err)
+	}
+
+	found, _ = repo.FindByID(ctx, next.ID)
+	if found.Summary.CashRefunds != 1000 || found.Summary.ExpectedCash != 49000 {
+		t.Errorf("Next shift refunds %d, expected %d, want 1000 and 49000", found.Summary.CashRefunds, found.Summary.ExpectedCash)
+	}
+
+	// The closed shift keeps the sale's cash and the return it paid out
+	if closed.Summary.ExpectedCash != 51000 {
+		t.Errorf("Closed shift expected %d, want 51000", closed.Summary.ExpectedCash)
+	}
+}
@@ -0,0 +1,274 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"kasir-api/internal/model"
+)
+
+// The stock ledger is kept by ProductRepository, since every movement belongs to a
+// product. Movements are written through postStock by whichever repository owns
+// the document behind them.
+
+const stockMovementColumns = "id, product_id, outlet_id, type, quantity, balance, COALESCE(reference_type, ''), reference_id, COALESCE(reason, ''), note, COALESCE(actor, ''), created_at"
+
+func scanStockMovement(row rowScanner) (*model.StockMovement, error) {
+	var m model.StockMovement
+	var referenceID sql.NullInt64
+	if err := row.Scan(&m.ID, &m.ProductID, &m.OutletID, &m.Type, &m.Quantity, &m.Balance, &m.ReferenceType, &referenceID, &m.Reason, &m.Note, &m.Actor, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+	if referenceID.Valid {
+		id := int(referenceID.Int64)
+		m.ReferenceID = &id
+	}
+	return &m, nil
+}
+
+func (r *ProductRepository) FindMovements(ctx context.Context, filter model.StockMovementFilter) ([]model.StockMovement, int, error) {
+	where := " WHERE 1=1"
+	args := []any{}
+	argPos := 1
+
+	if filter.ProductID != 0 {
+		where += fmt.Sprintf(" AND product_id = $%d", argPos)
+		args = append(args, filter.ProductID)
+		argPos++
+	}
+	if filter.OutletID != 0 {
+		where += fmt.Sprintf(" AND outlet_id = $%d", argPos)
+		args = append(args, filter.OutletID)
+		argPos++
+	}
+	if filter.Type != "" {
+		where += fmt.Sprintf(" AND type = $%d", argPos)
+		args = append(args, filter.Type)
+		argPos++
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM stock_movements"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT " + stockMovementColumns + " FROM stock_movements" + where +
+		fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	args = append(args, filter.Limit, filter.Offset())
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	movements := make([]model.StockMovement, 0, filter.Limit)
+	for rows.Next() {
+		m, err := scanStockMovement(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		movements = append(movements, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return movements, total, nil
+}
+
+func (r *ProductRepository) PostMovements(ctx context.Context, productID int, movements []model.StockMovement) ([]model.StockMovement, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := findProduct(ctx, tx, productID, "FOR SHARE OF p"); err != nil {
+		return nil, err
+	}
+
+	posted := make([]model.StockMovement, 0, len(movements))
+	for _, m := range movements {
+		result, err := postStock(ctx, tx, m)
+		if err != nil {
+			return nil, err
+		}
+		if err := recordAudit(ctx, tx, model.AuditEntityProduct, productID, model.AuditActionStockMovement, nil, result); err != nil {
+			return nil, err
+		}
+		posted = append(posted, *result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return posted, nil
+}
+
+func (r *ProductRepository) CheckLedger(ctx context.Context) ([]model.StockDiscrepancy, error) {
+	discrepancies := make([]model.StockDiscrepancy, 0)
+
+	// Balances that differ from the running sum of their product and outlet
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT outlet_id, product_id, id, balance, computed
+		FROM (
+			SELECT id, outlet_id, product_id, balance,
+				SUM(quantity) OVER (PARTITION BY outlet_id, product_id ORDER BY id) AS computed
+			FROM stock_movements
+		) m
+		WHERE balance <> computed
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d model.StockDiscrepancy
+		if err := rows.Scan(&d.OutletID, &d.ProductID, &d.MovementID, &d.Recorded, &d.Computed); err != nil {
+			return nil, err
+		}
+		discrepancies = append(discrepancies, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Stock that differs from the sum of its movements, including either side missing
+	rows, err = r.db.QueryContext(ctx, `
+		SELECT COALESCE(ps.outlet_id, m.outlet_id), COALESCE(ps.product_id, m.product_id), COALESCE(ps.stock, 0), COALESCE(m.total, 0)
+		FROM product_stocks ps
+		FULL JOIN (
+			SELECT outlet_id, product_id, SUM(quantity) AS total
+			FROM stock_movements
+			GROUP BY outlet_id, product_id
+		) m ON m.outlet_id = ps.outlet_id AND m.product_id = ps.product_id
+		WHERE COALESCE(ps.stock, 0) <> COALESCE(m.total, 0)
+		ORDER BY 1, 2`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d model.StockDiscrepancy
+		if err := rows.Scan(&d.OutletID, &d.ProductID, &d.Recorded, &d.Computed); err != nil {
+			return nil, err
+		}
+		discrepancies = append(discrepancies, d)
+	}
+
+	return discrepancies, rows.Err()
+}
+
+func (r *ProductRepository) FindLowStock(ctx context.Context) ([]model.LowStockItem, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.id, p.name, COALESCE(ps.stock, 0), p.reorder_point, p.reorder_qty
+		FROM products p
+		LEFT JOIN product_stocks ps ON ps.product_id = p.id AND ps.outlet_id = $1
+		WHERE p.active AND p.reorder_point > 0 AND COALESCE(ps.stock, 0) <= p.reorder_point
+		ORDER BY 3, p.id`, model.OutletID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.LowStockItem, 0)
+	for rows.Next() {
+		var item model.LowStockItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.Stock, &item.ReorderPoint, &item.ReorderQty); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *ProductRepository) FindAlerts(ctx context.Context, filter model.StockAlertFilter) ([]model.StockAlert, error) {
+	filter = filter.WithDefaults()
+	where := " WHERE outlet_id = $1"
+	args := []any{model.OutletID(ctx)}
+	argPos := 2
+
+	if filter.ProductID != 0 {
+		where += fmt.Sprintf(" AND product_id = $%d", argPos)
+		args = append(args, filter.ProductID)
+		argPos++
+	}
+
+	query := `SELECT id, outlet_id, product_id, product_name, stock, reorder_point, reorder_qty, transaction_id, created_at
+		FROM stock_alerts` + where + fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", argPos)
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]model.StockAlert, 0, filter.Limit)
+	for rows.Next() {
+		var a model.StockAlert
+		var transactionID sql.NullInt64
+		if err := rows.Scan(&a.ID, &a.OutletID, &a.ProductID, &a.ProductName, &a.Stock, &a.ReorderPoint, &a.ReorderQty, &transactionID, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		if transactionID.Valid {
+			id := int(transactionID.Int64)
+			a.TransactionID = &id
+		}
+		alerts = append(alerts, a)
+	}
+
+	return alerts, rows.Err()
+}
+
+// postStock appends a movement to the ledger within tx and applies it to the
+// stock, returning it with its ID and balance. This is the only place stock
+// changes. A movement that would take the stock below zero returns a validation error.
+func postStock(ctx context.Context, tx *sql.Tx, m model.StockMovement) (*model.StockMovement, error) {
+	// The upsert creates a missing stock row and locks the row until tx ends, so
+	// concurrent postings to the same stock see each other's balance
+	var stock int
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO product_stocks (outlet_id, product_id, stock) VALUES ($1, $2, 0)
+		ON CONFLICT (outlet_id, product_id) DO UPDATE SET stock = product_stocks.stock
+		RETURNING stock`, m.OutletID, m.ProductID).Scan(&stock)
+	if err != nil {
+		return nil, err
+	}
+	if stock+m.Quantity < 0 {
+		return nil, model.InsufficientStockError(m.ProductID, m.OutletID, stock, m.Quantity)
+	}
+	m.Balance = stock + m.Quantity
+
+	_, err = tx.ExecContext(ctx, "UPDATE product_stocks SET stock = $1 WHERE outlet_id = $2 AND product_id = $3", m.Balance, m.OutletID, m.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO stock_movements (product_id, outlet_id, type, quantity, balance, reference_type, reference_id, reason, note, actor)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, NULLIF($8, ''), $9, NULLIF($10, '')) RETURNING id, created_at`,
+		m.ProductID, m.OutletID, m.Type, m.Quantity, m.Balance, m.ReferenceType, m.ReferenceID, m.Reason, m.Note, m.Actor).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// raiseStockAlert stores a low stock alert within tx, returning it with its ID and time
+func raiseStockAlert(ctx context.Context, tx *sql.Tx, a model.StockAlert) (*model.StockAlert, error) {
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO stock_alerts (outlet_id, product_id, product_name, stock, reorder_point, reorder_qty, transaction_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`,
+		a.OutletID, a.ProductID, a.ProductName, a.Stock, a.ReorderPoint, a.ReorderQty, a.TransactionID).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
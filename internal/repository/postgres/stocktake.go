@@ -0,0 +1,275 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type StocktakeRepository struct {
+	db *sql.DB
+}
+
+func NewStocktakeRepository(db *sql.DB) *StocktakeRepository {
+	return &StocktakeRepository{db: db}
+}
+
+const stocktakeColumns = "id, outlet_id, category_id, status, note, COALESCE(opened_by, ''), opened_at, COALESCE(closed_by, ''), closed_at"
+
+func scanStocktake(row rowScanner) (*model.Stocktake, error) {
+	var st model.Stocktake
+	var categoryID sql.NullInt64
+	var closedAt sql.NullTime
+	if err := row.Scan(&st.ID, &st.OutletID, &categoryID, &st.Status, &st.Note, &st.OpenedBy, &st.OpenedAt, &st.ClosedBy, &closedAt); err != nil {
+		return nil, err
+	}
+	if categoryID.Valid {
+		id := int(categoryID.Int64)
+		st.CategoryID = &id
+	}
+	if closedAt.Valid {
+		st.ClosedAt = &closedAt.Time
+	}
+	return &st, nil
+}
+
+func (r *StocktakeRepository) FindByID(ctx context.Context, id int) (*model.Stocktake, error) {
+	st, err := findStocktake(ctx, r.db, id, "")
+	if err != nil {
+		return nil, err
+	}
+	result := st.Reviewed()
+	return &result, nil
+}
+
+func (r *StocktakeRepository) FindAll(ctx context.Context) ([]model.Stocktake, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+stocktakeColumns+" FROM stocktakes WHERE outlet_id = $1 ORDER BY id DESC", model.OutletID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stocktakes := make([]model.Stocktake, 0)
+	for rows.Next() {
+		st, err := scanStocktake(rows)
+		if err != nil {
+			return nil, err
+		}
+		stocktakes = append(stocktakes, *st)
+	}
+
+	return stocktakes, rows.Err()
+}
+
+func (r *StocktakeRepository) Open(ctx context.Context, req model.OpenStocktakeRequest) (*model.Stocktake, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Snapshot the system stock of every product in scope
+	outletID := model.OutletID(ctx)
+	rows, err := tx.QueryContext(ctx, `
+		SELECT p.id, p.name, p.cost, COALESCE(ps.stock, 0)
+		FROM products p
+		LEFT JOIN product_stocks ps ON ps.product_id = p.id AND ps.outlet_id = $1
+		WHERE $2::int IS NULL OR p.category_id = $2
+		ORDER BY p.id`, outletID, req.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]model.StocktakeLine, 0)
+	for rows.Next() {
+		var line model.StocktakeLine
+		if err := rows.Scan(&line.ProductID, &line.ProductName, &line.UnitCost, &line.SnapshotStock); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	st, err := model.NewStocktake(ctx, outletID, req, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	// The partial unique index allows a single open stocktake per outlet; losing the race inserts nothing
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO stocktakes (outlet_id, category_id, status, note, opened_by) VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		ON CONFLICT (outlet_id) WHERE status = 'open' DO NOTHING
+		RETURNING id, opened_at`, st.OutletID, st.CategoryID, st.Status, st.Note, st.OpenedBy).Scan(&st.ID, &st.OpenedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		var openID int
+		if err := tx.QueryRowContext(ctx, "SELECT id FROM stocktakes WHERE outlet_id = $1 AND status = $2", outletID, model.StocktakeStatusOpen).Scan(&openID); err != nil {
+			return nil, err
+		}
+		return nil, model.StocktakeOpenError(openID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range st.Lines {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO stocktake_lines (stocktake_id, product_id, product_name, unit_cost, snapshot_stock)
+			VALUES ($1, $2, $3, $4, $5)`, st.ID, line.ProductID, line.ProductName, line.UnitCost, line.SnapshotStock)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityStocktake, st.ID, model.AuditActionCreate, nil, st.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, st.ID)
+}
+
+func (r *StocktakeRepository) SaveCounts(ctx context.Context, id int, req model.StocktakeCountRequest) (*model.Stocktake, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Counts from several devices queue on the stocktake row, so approval sees all of them
+	st, err := findStocktake(ctx, tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	if err := st.ApplyCounts(ctx, req.Items, time.Now()); err != nil {
+		return nil, err
+	}
+
+	for _, item := range req.Items {
+		line := st.Lines[slices.IndexFunc(st.Lines, func(l model.StocktakeLine) bool { return l.ProductID == item.ProductID })]
+		_, err := tx.ExecContext(ctx, `
+			UPDATE stocktake_lines
+			SET counted_quantity = $1, reason = NULLIF($2, ''), counted_by = NULLIF($3, ''), counted_at = $4
+			WHERE stocktake_id = $5 AND product_id = $6`,
+			*line.CountedQuantity, line.Reason, line.CountedBy, *line.CountedAt, id, line.ProductID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityStocktake, id, model.AuditActionCount, nil, req); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *StocktakeRepository) Approve(ctx context.Context, id int) (*model.Stocktake, error) {
+	return r.close(ctx, id, model.StocktakeStatusApproved)
+}
+
+func (r *StocktakeRepository) Cancel(ctx context.Context, id int) (*model.Stocktake, error) {
+	return r.close(ctx, id, model.StocktakeStatusCancelled)
+}
+
+// close approves or cancels a stocktake. Approving posts the variance of every
+// counted line to the ledger in the same transaction, so all are posted or none.
+func (r *StocktakeRepository) close(ctx context.Context, id int, status model.StocktakeStatus) (*model.Stocktake, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	st, err := findStocktake(ctx, tx, id, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	before := st.AuditRecord()
+	if err := st.Close(ctx, status, time.Now()); err != nil {
+		return nil, err
+	}
+
+	action := model.AuditActionCancel
+	if status == model.StocktakeStatusApproved {
+		action = model.AuditActionApprove
+		movements, err := st.Adjustments(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range movements {
+			if _, err := postStock(ctx, tx, m); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE stocktakes SET status = $1, closed_by = NULLIF($2, ''), closed_at = CURRENT_TIMESTAMP
+		WHERE id = $3`, st.Status, st.ClosedBy, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityStocktake, id, action, before, st.AuditRecord()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.FindByID(ctx, id)
+}
+
+// findStocktake reads a stocktake with its lines, locking the stocktake row with
+// the given clause
+func findStocktake(ctx context.Context, q queryer, id int, lock string) (*model.Stocktake, error) {
+	st, err := scanStocktake(q.QueryRowContext(ctx, "SELECT "+stocktakeColumns+" FROM stocktakes WHERE id = $1 "+lock, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT product_id, product_name, unit_cost, snapshot_stock, counted_quantity, COALESCE(reason, ''), COALESCE(counted_by, ''), counted_at
+		FROM stocktake_lines
+		WHERE stocktake_id = $1
+		ORDER BY product_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	st.Lines = make([]model.StocktakeLine, 0)
+	for rows.Next() {
+		var line model.StocktakeLine
+		var counted sql.NullInt64
+		var countedAt sql.NullTime
+		if err := rows.Scan(&line.ProductID, &line.ProductName, &line.UnitCost, &line.SnapshotStock, &counted, &line.Reason, &line.CountedBy, &countedAt); err != nil {
+			return nil, err
+		}
+		if counted.Valid {
+			c := int(counted.Int64)
+			line.CountedQuantity = &c
+		}
+		if countedAt.Valid {
+			line.CountedAt = &countedAt.Time
+		}
+		st.Lines = append(st.Lines, line)
+	}
+
+	return st, rows.Err()
+}
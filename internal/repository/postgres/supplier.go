@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"kasir-api/internal/model"
+)
+
+type SupplierRepository struct {
+	db *sql.DB
+}
+
+func NewSupplierRepository(db *sql.DB) *SupplierRepository {
+	return &SupplierRepository{db: db}
+}
+
+const supplierColumns = "id, name, contact_name, phone, email, address, active, created_at, updated_at"
+
+func scanSupplier(row rowScanner) (*model.Supplier, error) {
+	var s model.Supplier
+	if err := row.Scan(&s.ID, &s.Name, &s.ContactName, &s.Phone, &s.Email, &s.Address, &s.Active, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *SupplierRepository) FindByID(ctx context.Context, id int) (*model.Supplier, error) {
+	s, err := scanSupplier(r.db.QueryRowContext(ctx, "SELECT "+supplierColumns+" FROM suppliers WHERE id = $1", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *SupplierRepository) FindAll(ctx context.Context) ([]model.Supplier, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+supplierColumns+" FROM suppliers ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := make([]model.Supplier, 0)
+	for rows.Next() {
+		s, err := scanSupplier(rows)
+		if err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, *s)
+	}
+	return suppliers, rows.Err()
+}
+
+func (r *SupplierRepository) Create(ctx context.Context, s model.Supplier) (*model.Supplier, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created, err := scanSupplier(tx.QueryRowContext(ctx, `
+		INSERT INTO suppliers (name, contact_name, phone, email, address, active) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+supplierColumns, s.Name, s.ContactName, s.Phone, s.Email, s.Address, s.Active))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntitySupplier, created.ID, model.AuditActionCreate, nil, created); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (r *SupplierRepository) Update(ctx context.Context, id int, s model.Supplier) (*model.Supplier, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	before, err := scanSupplier(tx.QueryRowContext(ctx, "SELECT "+supplierColumns+" FROM suppliers WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+
+	updated, err := scanSupplier(tx.QueryRowContext(ctx, `
+		UPDATE suppliers SET name = $1, contact_name = $2, phone = $3, email = $4, address = $5, active = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7
+		RETURNING `+supplierColumns, s.Name, s.ContactName, s.Phone, s.Email, s.Address, s.Active, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntitySupplier, id, model.AuditActionUpdate, before, updated); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
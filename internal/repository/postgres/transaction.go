@@ -3,7 +3,9 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"kasir-api/internal/model"
 )
@@ -16,24 +18,69 @@ func NewTransactionRepository(db *sql.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
-func (r *TransactionRepository) CreateTransaction(ctx context.Context, items []model.CheckoutItem) (*model.Transaction, error) {
+func (r *TransactionRepository) CreateTransaction(ctx context.Context, req model.CheckoutRequest, opts model.CheckoutOptions) (*model.Transaction, error) {
+	items := req.Items
+	outletID := model.OutletID(ctx)
+
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
+	// The outlet code numbers the invoice, so each outlet keeps its own sequence
+	var outletCode string
+	var outletActive bool
+	err = tx.QueryRowContext(ctx, "SELECT code, active FROM outlets WHERE id = $1", outletID).Scan(&outletCode, &outletActive)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.OutletNotFoundError(outletID)
+		}
+		return nil, err
+	}
+	if !outletActive {
+		return nil, model.OutletInactiveError(outletCode)
+	}
+	opts.Invoice.StoreCode = outletCode
+
 	// Batch fetch products with FOR UPDATE to lock rows
 	productIDs := make([]any, 0, len(items))
 	itemMap := make(map[int]int) // product_id -> total quantity
 	seenIDs := make(map[int]bool)
 
 	for _, item := range items {
-		if !seenIDs[item.ProductID] {
-			productIDs = append(productIDs, item.ProductID)
-			seenIDs[item.ProductID] = true
+		id := item.CatalogID()
+		if !seenIDs[id] {
+			productIDs = append(productIDs, id)
+			seenIDs[id] = true
+		}
+		itemMap[id] += item.Quantity // Sum quantities for duplicate products
+	}
+
+	// Holding the shift row keeps it from being closed until this checkout commits
+	if opts.ShiftID != nil {
+		if err := lockOpenShift(ctx, tx, *opts.ShiftID, "FOR SHARE"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Lock the customer so concurrent checkouts cannot spend the same points
+	if req.CustomerID != nil {
+		var points int
+		var erasedAt sql.NullTime
+		err := tx.QueryRowContext(ctx, "SELECT points, erased_at FROM customers WHERE id = $1 FOR UPDATE", *req.CustomerID).Scan(&points, &erasedAt)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("%w: customer id %d not found", model.ErrNotFound, *req.CustomerID)
+			}
+			return nil, err
+		}
+		if erasedAt.Valid {
+			return nil, model.CustomerErasedError(*req.CustomerID)
+		}
+		if req.RedeemPoints > points {
+			return nil, model.InsufficientPointsError(points, req.RedeemPoints)
 		}
-		itemMap[item.ProductID] += item.Quantity // Sum quantities for duplicate products
 	}
 
 	placeholders := ""
@@ -41,29 +88,56 @@ func (r *TransactionRepository) CreateTransaction(ctx context.Context, items []m
 		if i > 0 {
 			placeholders += ", "
 		}
-		placeholders += fmt.Sprintf("$%d", i+1)
+		placeholders += fmt.Sprintf("$%d", i+2)
 	}
 
-	query := fmt.Sprintf("SELECT id, name, price, stock, active FROM products WHERE id IN (%s) FOR UPDATE", placeholders)
-	rows, err := tx.QueryContext(ctx, query, productIDs...)
+	// Only the outlet's stock rows are locked, so other outlets can sell the same products
+	query := fmt.Sprintf(`
+		SELECT p.id, p.name, p.price, p.cost, ps.stock, p.active, p.tax_exempt, p.tax_inclusive, p.reorder_point, p.reorder_qty, p.category_id, c.name,
+			p.parent_id, pp.name, EXISTS (SELECT 1 FROM products v WHERE v.parent_id = p.id)
+		FROM products p
+		JOIN product_stocks ps ON ps.product_id = p.id AND ps.outlet_id = $1
+		LEFT JOIN categories c ON p.category_id = c.id
+		LEFT JOIN products pp ON pp.id = p.parent_id
+		WHERE p.id IN (%s)
+		FOR UPDATE OF ps`, placeholders)
+	rows, err := tx.QueryContext(ctx, query, append([]any{outletID}, productIDs...)...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	type productInfo struct {
-		id     int
-		name   string
-		price  int
-		stock  int
-		active bool
+		id           int
+		name         string
+		price        int
+		cost         int
+		stock        int
+		active       bool
+		taxExempt    bool
+		taxInclusive bool
+		reorderPoint int
+		reorderQty   int
+		categoryID   *int
+		categoryName string
+		parentID     *int
+		parentName   string
+		hasVariants  bool
 	}
 	products := make(map[int]productInfo)
 	for rows.Next() {
 		var p productInfo
-		if err := rows.Scan(&p.id, &p.name, &p.price, &p.stock, &p.active); err != nil {
+		var categoryID sql.NullInt64
+		var categoryName, parentName sql.NullString
+		if err := rows.Scan(&p.id, &p.name, &p.price, &p.cost, &p.stock, &p.active, &p.taxExempt, &p.taxInclusive, &p.reorderPoint, &p.reorderQty, &categoryID, &categoryName, &p.parentID, &parentName, &p.hasVariants); err != nil {
 			return nil, err
 		}
+		if categoryID.Valid {
+			id := int(categoryID.Int64)
+			p.categoryID = &id
+		}
+		p.categoryName = categoryName.String
+		p.parentName = parentName.String
 		products[p.id] = p
 	}
 	if err := rows.Err(); err != nil {
@@ -71,63 +145,114 @@ func (r *TransactionRepository) CreateTransaction(ctx context.Context, items []m
 	}
 
 	// Validate all products exist and have sufficient stock
-	totalAmount := 0
+	lines := make([]model.BasketLine, 0, len(items))
 	details := make([]model.TransactionDetail, 0, len(items))
 
 	for _, item := range items {
-		product, exists := products[item.ProductID]
+		productID := item.CatalogID()
+		product, exists := products[productID]
 		if !exists {
-			return nil, fmt.Errorf("%w: product id %d not found", model.ErrNotFound, item.ProductID)
+			return nil, fmt.Errorf("%w: product id %d not found", model.ErrNotFound, productID)
 		}
 
-		if !product.active {
-			return nil, fmt.Errorf("%w: product %s is not active", model.ErrValidation, product.name)
+		sold := model.Product{ID: product.id, Name: product.name, Active: product.active, ParentID: product.parentID}
+		if err := item.CheckProduct(sold, product.hasVariants); err != nil {
+			return nil, err
 		}
 
-		if product.stock < item.Quantity {
+		if product.stock < itemMap[productID] {
 			return nil, fmt.Errorf("%w: insufficient stock for product %s (available: %d, requested: %d)",
-				model.ErrValidation, product.name, product.stock, item.Quantity)
+				model.ErrValidation, product.name, product.stock, itemMap[productID])
 		}
 
-		subtotal := product.price * item.Quantity
-		totalAmount += subtotal
-
+		lines = append(lines, model.BasketLine{
+			ProductID:    productID,
+			ParentID:     product.parentID,
+			CategoryID:   product.categoryID,
+			UnitPrice:    product.price,
+			Quantity:     item.Quantity,
+			TaxExempt:    product.taxExempt,
+			TaxInclusive: product.taxInclusive,
+			Gross:        product.price * item.Quantity,
+		})
 		details = append(details, model.TransactionDetail{
-			ProductID:   item.ProductID,
-			ProductName: product.name,
-			Quantity:    item.Quantity,
-			Subtotal:    subtotal,
+			ProductID:         productID,
+			ProductName:       product.name,
+			ParentProductID:   product.parentID,
+			ParentProductName: product.parentName,
+			CategoryID:        product.categoryID,
+			CategoryName:      product.categoryName,
+			UnitPrice:         product.price,
+			UnitCost:          product.cost,
+			Quantity:          item.Quantity,
 		})
 	}
 
-	// Batch update stock
-	for productID, quantity := range itemMap {
-		_, err = tx.ExecContext(ctx, "UPDATE products SET stock = stock - $1 WHERE id = $2", quantity, productID)
-		if err != nil {
-			return nil, err
-		}
+	now := time.Now()
+	applied := model.ApplyPromotions(lines, opts.Promotions, now)
+
+	redeemed, pointsDiscount, err := model.RedeemPoints(lines, req.RedeemPoints, opts.Loyalty)
+	if err != nil {
+		return nil, err
+	}
+
+	model.ApplyTax(lines, opts.TaxRate)
+
+	grossAmount, discountAmount, taxAmount, totalAmount := 0, 0, 0, 0
+	for i, line := range lines {
+		grossAmount += line.Gross
+		discountAmount += line.Discount
+		taxAmount += line.Tax
+		totalAmount += line.Total()
+		details[i].GrossAmount = line.Gross
+		details[i].DiscountAmount = line.Discount
+		details[i].TaxAmount = line.Tax
+		details[i].Subtotal = line.Total()
+	}
+
+	payments, change, err := model.SettlePayments(totalAmount, req.Payments)
+	if err != nil {
+		return nil, err
+	}
+
+	pointsEarned := 0
+	if req.CustomerID != nil {
+		pointsEarned = opts.Loyalty.Earn(totalAmount)
+	}
+
+	invoiceNumber, err := nextInvoiceNumber(ctx, tx, opts.Invoice, now)
+	if err != nil {
+		return nil, err
 	}
 
 	var transactionID int
 	var createdAt sql.NullTime
-	err = tx.QueryRowContext(ctx, "INSERT INTO transactions (total_amount) VALUES ($1) RETURNING id, created_at", totalAmount).
-		Scan(&transactionID, &createdAt)
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO transactions (invoice_number, outlet_id, shift_id, customer_id, gross_amount, discount_amount, tax_amount, total_amount,
+			paid_amount, change_amount, points_earned, points_redeemed, points_discount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id, created_at`,
+		sql.NullString{String: invoiceNumber, Valid: invoiceNumber != ""}, outletID, opts.ShiftID, req.CustomerID,
+		grossAmount, discountAmount, taxAmount, totalAmount, totalAmount+change, change, pointsEarned, redeemed, pointsDiscount).Scan(&transactionID, &createdAt)
 	if err != nil {
 		return nil, err
 	}
 
 	// Batch insert transaction details with RETURNING
 	if len(details) > 0 {
-		query := "INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES "
-		args := make([]any, 0, len(details)*4)
+		query := `INSERT INTO transaction_details (transaction_id, product_id, product_name, parent_product_id, parent_product_name, category_id, category_name,
+			unit_price, unit_cost, quantity, gross_amount, discount_amount, tax_amount, subtotal) VALUES `
+		args := make([]any, 0, len(details)*14)
 
 		for i, detail := range details {
 			if i > 0 {
 				query += ", "
 			}
-			offset := i * 4
-			query += fmt.Sprintf("($%d, $%d, $%d, $%d)", offset+1, offset+2, offset+3, offset+4)
-			args = append(args, transactionID, detail.ProductID, detail.Quantity, detail.Subtotal)
+			offset := i * 14
+			query += fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+				offset+1, offset+2, offset+3, offset+4, offset+5, offset+6, offset+7, offset+8, offset+9, offset+10, offset+11, offset+12, offset+13, offset+14)
+			args = append(args, transactionID, detail.ProductID, detail.ProductName, detail.ParentProductID, nullString(detail.ParentProductName), detail.CategoryID, detail.CategoryName,
+				detail.UnitPrice, detail.UnitCost, detail.Quantity,
+				detail.GrossAmount, detail.DiscountAmount, detail.TaxAmount, detail.Subtotal)
 			details[i].TransactionID = transactionID
 		}
 		query += " RETURNING id"
@@ -150,14 +275,577 @@ func (r *TransactionRepository) CreateTransaction(ctx context.Context, items []m
 		}
 	}
 
+	// Post the sale of each line to the ledger; the stock rows are already locked
+	for _, item := range items {
+		m := model.NewStockMovement(ctx, outletID, item.CatalogID(), model.StockMovementSale, -item.Quantity).
+			For(model.StockReferenceTransaction, transactionID)
+		if _, err := postStock(ctx, tx, m); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := insertPayments(ctx, tx, transactionID, createdAt.Time, payments); err != nil {
+		return nil, err
+	}
+
+	if req.CustomerID != nil {
+		if err := addPoints(ctx, tx, *req.CustomerID, model.LoyaltyEntryRedeem, -redeemed, transactionID, createdAt.Time); err != nil {
+			return nil, err
+		}
+		if err := addPoints(ctx, tx, *req.CustomerID, model.LoyaltyEntryEarn, pointsEarned, transactionID, createdAt.Time); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, promotion := range applied {
+		_, err = tx.ExecContext(ctx, "INSERT INTO transaction_promotions (transaction_id, promotion_id, name, amount) VALUES ($1, $2, $3, $4)",
+			transactionID, promotion.PromotionID, promotion.Name, promotion.Amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	transaction := &model.Transaction{
+		ID:             transactionID,
+		InvoiceNumber:  invoiceNumber,
+		OutletID:       outletID,
+		ShiftID:        opts.ShiftID,
+		CustomerID:     req.CustomerID,
+		GrossAmount:    grossAmount,
+		DiscountAmount: discountAmount,
+		TaxAmount:      taxAmount,
+		TotalAmount:    totalAmount,
+		PaidAmount:     totalAmount + change,
+		ChangeAmount:   change,
+		PointsEarned:   pointsEarned,
+		PointsRedeemed: redeemed,
+		PointsDiscount: pointsDiscount,
+		Status:         model.TransactionStatusCompleted,
+		CreatedAt:      createdAt.Time,
+		Details:        details,
+		Payments:       payments,
+		Promotions:     applied,
+	}
+	if err := recordAudit(ctx, tx, model.AuditEntityTransaction, transactionID, model.AuditActionCreate, nil, transaction); err != nil {
+		return nil, err
+	}
+
+	if opts.Idempotency != nil {
+		if err := completeIdempotencyKey(ctx, tx, *opts.Idempotency, transactionID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Alert on every product the sale took to its reorder point; the stock read
+	// above is what the outlet held before it, since the rows are locked
+	var alerts []model.StockAlert
+	for _, id := range productIDs {
+		info := products[id.(int)]
+		product := model.Product{ID: info.id, Name: info.name, ReorderPoint: info.reorderPoint, ReorderQty: info.reorderQty}
+		after := info.stock - itemMap[info.id]
+		if !product.ReachesReorderPoint(info.stock, after) {
+			continue
+		}
+		alert, err := raiseStockAlert(ctx, tx, model.NewStockAlert(product, outletID, after, transactionID))
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	transaction.LowStock = alerts
+	return transaction, nil
+}
+
+// nextInvoiceNumber allocates the next number in the store's sequence for the
+// business day. The upsert locks the sequence row until the checkout commits, so
+// concurrent checkouts wait their turn and a rollback gives the number back.
+func nextInvoiceNumber(ctx context.Context, tx *sql.Tx, numbering model.InvoiceNumbering, now time.Time) (string, error) {
+	if numbering.Pattern.IsZero() {
+		return "", nil
+	}
+
+	var seq int
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO invoice_sequences (store_code, business_date, last_value) VALUES ($1, $2, 1)
+		ON CONFLICT (store_code, business_date) DO UPDATE SET last_value = invoice_sequences.last_value + 1
+		RETURNING last_value`, numbering.StoreCode, model.BusinessDate(now)).Scan(&seq)
+	if err != nil {
+		return "", err
+	}
+
+	return numbering.Pattern.Format(numbering.StoreCode, now, seq), nil
+}
+
+// insertPayments batch inserts the tenders of a new transaction and fills in their IDs
+func insertPayments(ctx context.Context, tx *sql.Tx, transactionID int, createdAt time.Time, payments []model.Payment) error {
+	if len(payments) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO payments (transaction_id, method, amount, reference) VALUES "
+	args := make([]any, 0, len(payments)*4)
+	for i, payment := range payments {
+		if i > 0 {
+			query += ", "
+		}
+		offset := i * 4
+		query += fmt.Sprintf("($%d, $%d, $%d, $%d)", offset+1, offset+2, offset+3, offset+4)
+		args = append(args, transactionID, payment.Method, payment.Amount, payment.Reference)
+		payments[i].TransactionID = transactionID
+		payments[i].CreatedAt = createdAt
+	}
+	query += " RETURNING id"
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	i := 0
+	for rows.Next() {
+		if err := rows.Scan(&payments[i].ID); err != nil {
+			return err
+		}
+		i++
+	}
+	return rows.Err()
+}
+
+func (r *TransactionRepository) CancelTransaction(ctx context.Context, id int, status model.TransactionStatus, req model.CancelRequest) (*model.Transaction, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the transaction row so concurrent void/refund requests cannot both restore stock
+	var current model.TransactionStatus
+	var sameDay bool
+	var customerID sql.NullInt64
+	var pointsEarned, pointsRedeemed int
+	query, args := scopeToOutlet(ctx, `
+		SELECT status, DATE(created_at) = CURRENT_DATE, customer_id, points_earned, points_redeemed
+		FROM transactions WHERE id = $1`, "outlet_id", id)
+	err = tx.QueryRowContext(ctx, query+" FOR UPDATE", args...).
+		Scan(&current, &sameDay, &customerID, &pointsEarned, &pointsRedeemed)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+
+	if current != model.TransactionStatusCompleted {
+		return nil, fmt.Errorf("%w: transaction %d is already %s", model.ErrConflict, id, current)
+	}
+	if status == model.TransactionStatusVoided && !sameDay {
+		return nil, fmt.Errorf("%w: transaction %d can only be voided on the day it was created, use refund instead", model.ErrValidation, id)
+	}
+
+	before, err := scanTransaction(tx.QueryRowContext(ctx, "SELECT "+transactionColumns+" FROM transactions t WHERE t.id = $1", id))
+	if err != nil {
+		return nil, err
+	}
+
+	// Put back every sold unit that has not already been returned at the outlet that sold it
+	rows, err := tx.QueryContext(ctx, `
+		SELECT t.outlet_id, td.product_id, SUM(td.quantity - COALESCE(ri.quantity, 0))
+		FROM transaction_details td
+		JOIN transactions t ON t.id = td.transaction_id
+		LEFT JOIN (
+			SELECT transaction_detail_id, SUM(quantity) AS quantity
+			FROM return_items
+			GROUP BY transaction_detail_id
+		) ri ON ri.transaction_detail_id = td.id
+		WHERE td.transaction_id = $1 AND td.product_id IS NOT NULL
+		GROUP BY t.outlet_id, td.product_id
+		HAVING SUM(td.quantity - COALESCE(ri.quantity, 0)) > 0
+		ORDER BY td.product_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	var restock []model.StockMovement
+	for rows.Next() {
+		var outletID, productID, quantity int
+		if err := rows.Scan(&outletID, &productID, &quantity); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		restock = append(restock, model.NewStockMovement(ctx, outletID, productID, model.StockMovementReturn, quantity).
+			For(model.StockReferenceTransaction, id))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, m := range restock {
+		if _, err := postStock(ctx, tx, m); err != nil {
+			return nil, err
+		}
+	}
+
+	// Take back the points the sale earned and give back the ones it used
+	if customerID.Valid {
+		err = addPoints(ctx, tx, int(customerID.Int64), model.LoyaltyEntryReversal, pointsRedeemed-pointsEarned, id, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cancelled := *before
+	cancelled.Cancel(ctx, status, req, time.Now())
+	_, err = tx.ExecContext(ctx, `
+		UPDATE transactions
+		SET status = $1, cancelled_at = CURRENT_TIMESTAMP, cancelled_by = $2, cancel_reason = $3
+		WHERE id = $4`, status, cancelled.CancelledBy, cancelled.CancelReason, id)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := scanTransaction(tx.QueryRowContext(ctx, "SELECT "+transactionColumns+" FROM transactions t WHERE t.id = $1", id))
+	if err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, model.AuditEntityTransaction, id, model.CancelAuditAction(status), before, after); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 
-	return &model.Transaction{
-		ID:          transactionID,
-		TotalAmount: totalAmount,
-		CreatedAt:   createdAt.Time,
-		Details:     details,
-	}, nil
+	return r.FindByID(ctx, id)
+}
+
+func (r *TransactionRepository) FindByID(ctx context.Context, id int) (*model.Transaction, error) {
+	query, args := scopeToOutlet(ctx, "SELECT "+transactionColumns+" FROM transactions t WHERE t.id = $1", "t.outlet_id", id)
+	t, err := scanTransaction(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+
+	details, err := r.findDetails(ctx, []int{t.ID})
+	if err != nil {
+		return nil, err
+	}
+	t.Details = details[t.ID]
+	if t.Details == nil {
+		t.Details = []model.TransactionDetail{}
+	}
+
+	payments, err := r.findPayments(ctx, []int{t.ID})
+	if err != nil {
+		return nil, err
+	}
+	t.Payments = payments[t.ID]
+	if t.Payments == nil {
+		t.Payments = []model.Payment{}
+	}
+
+	promotions, err := r.findPromotions(ctx, []int{t.ID})
+	if err != nil {
+		return nil, err
+	}
+	t.Promotions = promotions[t.ID]
+	if t.Promotions == nil {
+		t.Promotions = []model.AppliedPromotion{}
+	}
+
+	return t, nil
+}
+
+func (r *TransactionRepository) FindAll(ctx context.Context, filter model.TransactionFilter) ([]model.Transaction, int, error) {
+	where := " WHERE 1=1"
+	args := []any{}
+	argPos := 1
+
+	if filter.StartDate != "" {
+		where += fmt.Sprintf(" AND DATE(t.created_at) >= $%d", argPos)
+		args = append(args, filter.StartDate)
+		argPos++
+	}
+	if filter.EndDate != "" {
+		where += fmt.Sprintf(" AND DATE(t.created_at) <= $%d", argPos)
+		args = append(args, filter.EndDate)
+		argPos++
+	}
+	if filter.MinAmount != nil {
+		where += fmt.Sprintf(" AND t.total_amount >= $%d", argPos)
+		args = append(args, *filter.MinAmount)
+		argPos++
+	}
+	if filter.MaxAmount != nil {
+		where += fmt.Sprintf(" AND t.total_amount <= $%d", argPos)
+		args = append(args, *filter.MaxAmount)
+		argPos++
+	}
+	if filter.Status != "" {
+		where += fmt.Sprintf(" AND t.status = $%d", argPos)
+		args = append(args, filter.Status)
+		argPos++
+	}
+	if filter.Invoice != "" {
+		where += fmt.Sprintf(" AND STRPOS(LOWER(t.invoice_number), LOWER($%d)) > 0", argPos)
+		args = append(args, filter.Invoice)
+		argPos++
+	}
+	if filter.CustomerID != nil {
+		where += fmt.Sprintf(" AND t.customer_id = $%d", argPos)
+		args = append(args, *filter.CustomerID)
+		argPos++
+	}
+	if filter.OutletID != nil {
+		where += fmt.Sprintf(" AND t.outlet_id = $%d", argPos)
+		args = append(args, *filter.OutletID)
+		argPos++
+	}
+	if filter.ProductID != nil {
+		where += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM transaction_details td WHERE td.transaction_id = t.id AND $%[1]d IN (td.product_id, td.parent_product_id))", argPos)
+		args = append(args, *filter.ProductID)
+		argPos++
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM transactions t"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT " + transactionColumns + " FROM transactions t" + where +
+		fmt.Sprintf(" ORDER BY t.created_at DESC, t.id DESC LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	args = append(args, filter.Limit, filter.Offset())
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	transactions := make([]model.Transaction, 0, filter.Limit)
+	ids := make([]int, 0, filter.Limit)
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		transactions = append(transactions, *t)
+		ids = append(ids, t.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// Batch fetch details, payments and promotions for the whole page to avoid N+1 queries
+	details, err := r.findDetails(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	payments, err := r.findPayments(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	promotions, err := r.findPromotions(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range transactions {
+		transactions[i].Details = details[transactions[i].ID]
+		if transactions[i].Details == nil {
+			transactions[i].Details = []model.TransactionDetail{}
+		}
+		transactions[i].Payments = payments[transactions[i].ID]
+		if transactions[i].Payments == nil {
+			transactions[i].Payments = []model.Payment{}
+		}
+		transactions[i].Promotions = promotions[transactions[i].ID]
+		if transactions[i].Promotions == nil {
+			transactions[i].Promotions = []model.AppliedPromotion{}
+		}
+	}
+
+	return transactions, total, nil
+}
+
+// scopeToOutlet narrows a query on a single record to the context outlet, if the
+// context names one, so other outlets' records read as not found
+func scopeToOutlet(ctx context.Context, query, column string, args ...any) (string, []any) {
+	if outletID, ok := model.ScopedOutletID(ctx); ok {
+		args = append(args, outletID)
+		query += fmt.Sprintf(" AND %s = $%d", column, len(args))
+	}
+	return query, args
+}
+
+// transactionColumns is the column list read by scanTransaction, for queries aliasing transactions as t
+const transactionColumns = "t.id, t.invoice_number, t.outlet_id, t.shift_id, t.customer_id, t.gross_amount, t.discount_amount, t.tax_amount, t.total_amount, t.paid_amount, t.change_amount, t.points_earned, t.points_redeemed, t.points_discount, t.status, t.created_at, t.cancelled_at, t.cancelled_by, t.cancel_reason"
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTransaction(row rowScanner) (*model.Transaction, error) {
+	var t model.Transaction
+	var createdAt, cancelledAt sql.NullTime
+	var invoiceNumber, cancelledBy, cancelReason sql.NullString
+	var shiftID, customerID sql.NullInt64
+	if err := row.Scan(&t.ID, &invoiceNumber, &t.OutletID, &shiftID, &customerID, &t.GrossAmount, &t.DiscountAmount, &t.TaxAmount, &t.TotalAmount, &t.PaidAmount, &t.ChangeAmount,
+		&t.PointsEarned, &t.PointsRedeemed, &t.PointsDiscount, &t.Status, &createdAt, &cancelledAt, &cancelledBy, &cancelReason); err != nil {
+		return nil, err
+	}
+
+	t.InvoiceNumber = invoiceNumber.String
+	if shiftID.Valid {
+		id := int(shiftID.Int64)
+		t.ShiftID = &id
+	}
+	if customerID.Valid {
+		id := int(customerID.Int64)
+		t.CustomerID = &id
+	}
+	t.CreatedAt = createdAt.Time
+	if cancelledAt.Valid {
+		t.CancelledAt = &cancelledAt.Time
+	}
+	t.CancelledBy = cancelledBy.String
+	t.CancelReason = cancelReason.String
+
+	return &t, nil
+}
+
+// findDetails loads the line items of the given transactions, grouped by transaction ID
+func (r *TransactionRepository) findDetails(ctx context.Context, transactionIDs []int) (map[int][]model.TransactionDetail, error) {
+	result := make(map[int][]model.TransactionDetail, len(transactionIDs))
+	if len(transactionIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := ""
+	args := make([]any, 0, len(transactionIDs))
+	for i, id := range transactionIDs {
+		if i > 0 {
+			placeholders += ", "
+		}
+		placeholders += fmt.Sprintf("$%d", i+1)
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT td.id, td.transaction_id, td.product_id, td.product_name, td.parent_product_id, COALESCE(td.parent_product_name, ''), td.category_id, td.category_name,
+			td.unit_price, td.unit_cost, td.quantity, td.gross_amount, td.discount_amount, td.tax_amount, td.subtotal
+		FROM transaction_details td
+		WHERE td.transaction_id IN (%s)
+		ORDER BY td.transaction_id, td.id`, placeholders)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d model.TransactionDetail
+		var categoryID sql.NullInt64
+		if err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.ParentProductID, &d.ParentProductName, &categoryID, &d.CategoryName,
+			&d.UnitPrice, &d.UnitCost, &d.Quantity, &d.GrossAmount, &d.DiscountAmount, &d.TaxAmount, &d.Subtotal); err != nil {
+			return nil, err
+		}
+		if categoryID.Valid {
+			id := int(categoryID.Int64)
+			d.CategoryID = &id
+		}
+		result[d.TransactionID] = append(result[d.TransactionID], d)
+	}
+
+	return result, rows.Err()
+}
+
+// findPayments loads the tenders of the given transactions, grouped by transaction ID
+func (r *TransactionRepository) findPayments(ctx context.Context, transactionIDs []int) (map[int][]model.Payment, error) {
+	result := make(map[int][]model.Payment, len(transactionIDs))
+	if len(transactionIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := ""
+	args := make([]any, 0, len(transactionIDs))
+	for i, id := range transactionIDs {
+		if i > 0 {
+			placeholders += ", "
+		}
+		placeholders += fmt.Sprintf("$%d", i+1)
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, transaction_id, method, amount, COALESCE(reference, ''), created_at
+		FROM payments
+		WHERE transaction_id IN (%s)
+		ORDER BY transaction_id, id`, placeholders)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p model.Payment
+		var createdAt sql.NullTime
+		if err := rows.Scan(&p.ID, &p.TransactionID, &p.Method, &p.Amount, &p.Reference, &createdAt); err != nil {
+			return nil, err
+		}
+		p.CreatedAt = createdAt.Time
+		result[p.TransactionID] = append(result[p.TransactionID], p)
+	}
+
+	return result, rows.Err()
+}
+
+// findPromotions loads the promotions applied to the given transactions, grouped by transaction ID
+func (r *TransactionRepository) findPromotions(ctx context.Context, transactionIDs []int) (map[int][]model.AppliedPromotion, error) {
+	result := make(map[int][]model.AppliedPromotion, len(transactionIDs))
+	if len(transactionIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := ""
+	args := make([]any, 0, len(transactionIDs))
+	for i, id := range transactionIDs {
+		if i > 0 {
+			placeholders += ", "
+		}
+		placeholders += fmt.Sprintf("$%d", i+1)
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT transaction_id, COALESCE(promotion_id, 0), name, amount
+		FROM transaction_promotions
+		WHERE transaction_id IN (%s)
+		ORDER BY transaction_id, id`, placeholders)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transactionID int
+		var p model.AppliedPromotion
+		if err := rows.Scan(&transactionID, &p.PromotionID, &p.Name, &p.Amount); err != nil {
+			return nil, err
+		}
+		result[transactionID] = append(result[transactionID], p)
+	}
+
+	return result, rows.Err()
 }
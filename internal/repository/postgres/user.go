@@ -0,0 +1,260 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+const userColumns = "id, username, name, role, password_hash, active, failed_logins, locked_until, last_login_at, created_at, updated_at"
+
+func scanUser(row rowScanner) (*model.User, error) {
+	var u model.User
+	var lockedUntil, lastLoginAt, createdAt, updatedAt sql.NullTime
+	if err := row.Scan(&u.ID, &u.Username, &u.Name, &u.Role, &u.PasswordHash, &u.Active, &u.FailedLogins, &lockedUntil, &lastLoginAt, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	if lockedUntil.Valid {
+		u.LockedUntil = &lockedUntil.Time
+	}
+	if lastLoginAt.Valid {
+		u.LastLoginAt = &lastLoginAt.Time
+	}
+	u.CreatedAt = createdAt.Time
+	u.UpdatedAt = updatedAt.Time
+	return &u, nil
+}
+
+func (r *UserRepository) findOne(ctx context.Context, condition string, arg any) (*model.User, error) {
+	u, err := scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE "+condition, arg))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id int) (*model.User, error) {
+	return r.findOne(ctx, "id = $1", id)
+}
+
+func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*model.User, error) {
+	return r.findOne(ctx, "username = $1", username)
+}
+
+func (r *UserRepository) FindAll(ctx context.Context) ([]model.User, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0)
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+func (r *UserRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&count)
+	return count, err
+}
+
+func (r *UserRepository) Create(ctx context.Context, u model.User) (*model.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created, err := scanUser(tx.QueryRowContext(ctx, `
+		INSERT INTO users (username, name, role, password_hash, active) VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+userColumns, u.Username, u.Name, u.Role, u.PasswordHash, u.Active))
+	if err != nil {
+		if isUniqueViolation(err, "idx_users_username") {
+			return nil, model.UsernameTakenError(u.Username)
+		}
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityUser, created.ID, model.AuditActionCreate, nil, created); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, id int, u model.User) (*model.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if !u.Active {
+		if err := ensureOtherActiveOwner(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	before, err := lockUser(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := scanUser(tx.QueryRowContext(ctx, `
+		UPDATE users SET username = $1, name = $2, password_hash = $3, active = $4,
+			failed_logins = $5, locked_until = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7
+		RETURNING `+userColumns, u.Username, u.Name, u.PasswordHash, u.Active, u.FailedLogins, u.LockedUntil, id))
+	if err != nil {
+		if isUniqueViolation(err, "idx_users_username") {
+			return nil, model.UsernameTakenError(u.Username)
+		}
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityUser, id, model.AuditActionUpdate, before, updated); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (r *UserRepository) SetRole(ctx context.Context, id int, role model.Role) (*model.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if role != model.RoleOwner {
+		if err := ensureOtherActiveOwner(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	before, err := lockUser(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := scanUser(tx.QueryRowContext(ctx, `
+		UPDATE users SET role = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+		RETURNING `+userColumns, role, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, tx, model.AuditEntityUser, id, model.AuditActionAssignRole, before, updated); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// lockUser locks the user row in tx for a change
+func lockUser(ctx context.Context, tx *sql.Tx, id int) (*model.User, error) {
+	u, err := scanUser(tx.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+// ensureOtherActiveOwner returns model.LastOwnerError when the user is the only
+// active owner. The owners stay locked until tx ends, so two owners cannot demote
+// each other at the same time.
+func ensureOtherActiveOwner(ctx context.Context, tx *sql.Tx, userID int) error {
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM users WHERE role = 'owner' AND active ORDER BY id FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	isOwner, owners := false, 0
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		isOwner = isOwner || id == userID
+		owners++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if isOwner && owners == 1 {
+		return model.LastOwnerError()
+	}
+	return nil
+}
+
+// RecordLoginFailure counts the failure in a single statement so concurrent
+// attempts cannot slip past the limit; see model.LockoutPolicy.RegisterFailure
+func (r *UserRepository) RecordLoginFailure(ctx context.Context, id int, policy model.LockoutPolicy, at time.Time) (*model.User, error) {
+	u, err := scanUser(r.db.QueryRowContext(ctx, `
+		UPDATE users SET
+			locked_until = CASE WHEN $2 > 0 AND failed_logins + 1 >= $2 THEN $3 ELSE locked_until END,
+			failed_logins = CASE WHEN $2 > 0 AND failed_logins + 1 >= $2 THEN 0 ELSE failed_logins + 1 END,
+			updated_at = $4
+		WHERE id = $1
+		RETURNING `+userColumns, id, policy.MaxFailedLogins, at.Add(policy.Duration), at))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+func (r *UserRepository) RecordLogin(ctx context.Context, id int, at time.Time) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE users SET failed_logins = 0, locked_until = NULL, last_login_at = $2, updated_at = $2
+		WHERE id = $1`, id, at)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return model.ErrNotFound
+	}
+	return nil
+}
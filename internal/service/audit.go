@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+// AuditService reads the audit log. There is nothing to write here: the
+// repositories append an entry with every change they make.
+type AuditService struct {
+	reader repository.AuditReader
+}
+
+func NewAuditService(reader repository.AuditReader) *AuditService {
+	return &AuditService{reader: reader}
+}
+
+// GetAll returns a page of the audit entries matching the filter, newest first,
+// and the total number of matches
+func (s *AuditService) GetAll(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, int, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "AuditService.GetAll", filter)
+	defer spanEnd(nil, nil)
+
+	if err := filter.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, 0, err
+	}
+
+	entries, total, err := s.reader.FindAll(ctx, filter.WithDefaults())
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, 0, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get audit entries")
+	}
+
+	spanEnd(map[string]interface{}{"count": len(entries), "total": total}, nil)
+	return entries, total, nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+	"kasir-api/pkg/middleware"
+)
+
+func TestAuditService_GetAll(t *testing.T) {
+	audit := memory.NewAuditRepository()
+	customers := memory.NewCustomerRepository()
+	customers.SetAuditLog(audit)
+	customerSvc := NewCustomerService(customers, customers, nil)
+	svc := NewAuditService(audit)
+
+	ctx := middleware.WithPrincipal(context.Background(), &middleware.Principal{UserID: 1, Username: "admin", Role: string(model.RoleOwner)})
+	customer, err := customerSvc.Create(ctx, model.CustomerRequest{Name: "Budi", Phone: "081234567890"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := customerSvc.Erase(ctx, customer.ID); err != nil {
+		t.Fatalf("Erase() error = %v", err)
+	}
+
+	entries, total, err := svc.GetAll(ctx, model.AuditFilter{Entity: "customer", Actor: "admin"})
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if total != 2 || entries[0].Action != model.AuditActionErase {
+		t.Fatalf("Expected create and erase entries, got %+v", entries)
+	}
+
+	// The log cannot be erased, so it must not hold what the erase removed
+	for _, e := range entries {
+		for field, change := range e.Changes {
+			if change.Before == "Budi" || change.After == "Budi" || change.Before == "081234567890" || change.After == "081234567890" {
+				t.Errorf("Entry %d logs personal data in %s: %+v", e.ID, field, change)
+			}
+		}
+	}
+
+	if _, _, err := svc.GetAll(ctx, model.AuditFilter{EndDate: "yesterday"}); !model.IsValidationError(err) {
+		t.Errorf("GetAll() with a bad date error = %v, want validation", err)
+	}
+}
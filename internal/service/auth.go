@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	"kasir-api/pkg/jwt"
+	"kasir-api/pkg/middleware"
+	"kasir-api/pkg/tracing"
+	"kasir-api/pkg/uuid"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultAccessTTL  = 15 * time.Minute
+	defaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+// AuthService signs users in with their password and issues short-lived access
+// tokens together with single-use refresh tokens. Every token belongs to a
+// session, which logout revokes.
+type AuthService struct {
+	users      repository.UserReader
+	userWriter repository.UserWriter
+	sessions   repository.SessionStore
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	lockout    model.LockoutPolicy
+}
+
+func NewAuthService(users repository.UserReader, userWriter repository.UserWriter, sessions repository.SessionStore, secret []byte) *AuthService {
+	return &AuthService{
+		users:      users,
+		userWriter: userWriter,
+		sessions:   sessions,
+		secret:     secret,
+		accessTTL:  defaultAccessTTL,
+		refreshTTL: defaultRefreshTTL,
+	}
+}
+
+// SetTokenTTLs sets how long access tokens are valid and how long a session may
+// go unrefreshed before it expires
+func (s *AuthService) SetTokenTTLs(access, refresh time.Duration) {
+	if access > 0 {
+		s.accessTTL = access
+	}
+	if refresh > 0 {
+		s.refreshTTL = refresh
+	}
+}
+
+// SetLockoutPolicy sets when repeated failed logins lock an account out
+func (s *AuthService) SetLockoutPolicy(policy model.LockoutPolicy) {
+	s.lockout = policy
+}
+
+func (s *AuthService) Login(ctx context.Context, req model.LoginRequest) (*model.TokenResponse, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "AuthService.Login", map[string]interface{}{"username": req.Username})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	now := time.Now()
+	user, err := s.users.FindByUsername(ctx, strings.ToLower(strings.TrimSpace(req.Username)))
+	if err != nil && !model.IsNotFoundError(err) {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to sign in")
+	}
+	if user == nil || !user.Active {
+		// Spend the time a password check takes so unknown usernames can't be told apart
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(req.Password))
+		err := model.InvalidCredentialsError()
+		spanEnd(nil, err)
+		return nil, err
+	}
+	if user.IsLocked(now) {
+		err := model.AccountLockedError(*user.LockedUntil)
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		err := model.InvalidCredentialsError()
+		failed, recordErr := s.userWriter.RecordLoginFailure(ctx, user.ID, s.lockout, now)
+		if recordErr != nil {
+			err = wrapError(recordErr, "failed to sign in")
+		} else if failed.IsLocked(now) {
+			err = model.AccountLockedError(*failed.LockedUntil)
+		}
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	if err := s.userWriter.RecordLogin(ctx, user.ID, now); err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to sign in")
+	}
+	model.RegisterLogin(user, now)
+
+	session := model.Session{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}
+	refreshToken, refresh := s.newRefreshToken(now)
+	if err := s.sessions.Create(ctx, session, refresh); err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to sign in")
+	}
+
+	resp, err := s.tokenResponse(*user, session.ID, refreshToken, now)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to sign in")
+	}
+
+	spanEnd(map[string]interface{}{"user_id": user.ID, "session_id": session.ID}, nil)
+	return resp, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and the next refresh
+// token. Each refresh token works once; presenting one again revokes the session.
+func (s *AuthService) Refresh(ctx context.Context, req model.RefreshRequest) (*model.TokenResponse, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "AuthService.Refresh", nil)
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	now := time.Now()
+	refreshToken, next := s.newRefreshToken(now)
+	session, err := s.sessions.Rotate(ctx, hashToken(req.RefreshToken), next, now)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to refresh session")
+	}
+
+	user, err := s.users.FindByID(ctx, session.UserID)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to refresh session")
+	}
+	if !user.Active {
+		s.sessions.Revoke(ctx, session.ID, now)
+		err := model.InvalidSessionError()
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	resp, err := s.tokenResponse(*user, session.ID, refreshToken, now)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to refresh session")
+	}
+
+	spanEnd(map[string]interface{}{"user_id": user.ID, "session_id": session.ID}, nil)
+	return resp, nil
+}
+
+// Logout revokes the session of the authenticated caller, so neither its access
+// token nor its refresh token can be used again
+func (s *AuthService) Logout(ctx context.Context) error {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "AuthService.Logout", nil)
+	defer spanEnd(nil, nil)
+
+	principal, ok := middleware.PrincipalFromContext(ctx)
+	if !ok {
+		spanEnd(nil, model.ErrUnauthorized)
+		return model.ErrUnauthorized
+	}
+	if principal.IsDevice() {
+		err := model.UserOnlyError()
+		spanEnd(nil, err)
+		return err
+	}
+
+	if err := s.sessions.Revoke(ctx, principal.SessionID, time.Now()); err != nil {
+		spanEnd(nil, err)
+		return wrapError(err, "failed to sign out")
+	}
+
+	spanEnd(nil, nil)
+	return nil
+}
+
+// Me returns the authenticated caller
+func (s *AuthService) Me(ctx context.Context) (*model.User, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "AuthService.Me", nil)
+	defer spanEnd(nil, nil)
+
+	principal, ok := middleware.PrincipalFromContext(ctx)
+	if !ok {
+		spanEnd(nil, model.ErrUnauthorized)
+		return nil, model.ErrUnauthorized
+	}
+	if principal.IsDevice() {
+		err := model.UserOnlyError()
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	user, err := s.users.FindByID(ctx, principal.UserID)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to get user")
+	}
+
+	spanEnd(user, nil)
+	return user, nil
+}
+
+// Authenticate checks an access token for middleware.AuthMiddleware. Besides the
+// signature and expiry, the token's session must not be revoked and its user must
+// still be active, so logout and deactivation take effect immediately.
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*middleware.Principal, error) {
+	now := time.Now()
+	claims, err := jwt.Parse(token, s.secret, now)
+	if err != nil {
+		return nil, model.InvalidTokenError()
+	}
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, model.InvalidTokenError()
+	}
+
+	session, err := s.sessions.FindByID(ctx, claims.SessionID)
+	if model.IsNotFoundError(err) || (err == nil && (!session.IsActive(now) || session.UserID != userID)) {
+		return nil, model.InvalidSessionError()
+	}
+	if err != nil {
+		return nil, wrapError(err, "failed to authenticate")
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if model.IsNotFoundError(err) || (err == nil && !user.Active) {
+		return nil, model.InvalidSessionError()
+	}
+	if err != nil {
+		return nil, wrapError(err, "failed to authenticate")
+	}
+
+	return &middleware.Principal{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      string(user.Role),
+		SessionID: session.ID,
+	}, nil
+}
+
+func (s *AuthService) tokenResponse(user model.User, sessionID, refreshToken string, now time.Time) (*model.TokenResponse, error) {
+	accessToken, err := jwt.Sign(jwt.Claims{
+		Subject:   strconv.Itoa(user.ID),
+		SessionID: sessionID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.accessTTL).Unix(),
+	}, s.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+		RefreshToken: refreshToken,
+		User:         user,
+	}, nil
+}
+
+// newRefreshToken returns a random refresh token and the record stored for it
+func (s *AuthService) newRefreshToken(now time.Time) (string, model.RefreshToken) {
+	token := randomToken()
+	return token, model.RefreshToken{
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(s.refreshTTL),
+	}
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashPassword returns the bcrypt hash stored for a password
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// dummyPasswordHash is compared against when there is no real hash to check
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte(randomToken()), bcrypt.DefaultCost)
+	})
+	return dummyHash
+}
+
+// GenerateSecret returns a random secret for signing access tokens, for when none
+// is configured
+func GenerateSecret() []byte {
+	return []byte(randomToken())
+}
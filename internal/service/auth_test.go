@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+	"kasir-api/pkg/middleware"
+)
+
+func newTestAuthServices(t *testing.T) (*AuthService, *UserService) {
+	t.Helper()
+
+	users := memory.NewUserRepository()
+	sessions := memory.NewSessionRepository()
+	userSvc := NewUserService(users, users, sessions)
+	authSvc := NewAuthService(users, users, sessions, []byte("test-secret"))
+	authSvc.SetLockoutPolicy(model.LockoutPolicy{MaxFailedLogins: 3, Duration: time.Minute})
+
+	created, err := userSvc.CreateInitial(context.Background(), model.UserRequest{Username: "Siti", Name: "Siti", Password: "rahasia123"})
+	if err != nil || !created {
+		t.Fatalf("CreateInitial() = %v, %v, want the user created", created, err)
+	}
+	return authSvc, userSvc
+}
+
+// authenticated returns ctx carrying the principal of the access token, as the middleware would
+func authenticated(t *testing.T, svc *AuthService, accessToken string) context.Context {
+	t.Helper()
+
+	principal, err := svc.Authenticate(context.Background(), accessToken)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	return middleware.WithPrincipal(context.Background(), principal)
+}
+
+func TestAuthService_LoginAndLogout(t *testing.T) {
+	svc, _ := newTestAuthServices(t)
+	ctx := context.Background()
+
+	resp, err := svc.Login(ctx, model.LoginRequest{Username: " SITI ", Password: "rahasia123"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if resp.TokenType != "Bearer" || resp.ExpiresIn != 900 || resp.RefreshToken == "" || resp.User.Username != "siti" {
+		t.Errorf("Login() = %+v, want a 15 minute bearer token for siti", resp)
+	}
+
+	authCtx := authenticated(t, svc, resp.AccessToken)
+	me, err := svc.Me(authCtx)
+	if err != nil || me.Username != "siti" || me.LastLoginAt == nil {
+		t.Errorf("Me() = %+v, %v, want siti with a login time", me, err)
+	}
+
+	if _, err := svc.Authenticate(ctx, resp.AccessToken+"x"); !model.IsUnauthorizedError(err) {
+		t.Errorf("Authenticate() with a tampered token error = %v, want unauthorized", err)
+	}
+
+	// Logging out revokes the session, so the unexpired access token stops working too
+	if err := svc.Logout(authCtx); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, resp.AccessToken); !model.IsUnauthorizedError(err) {
+		t.Errorf("Authenticate() after logout error = %v, want unauthorized", err)
+	}
+	if _, err := svc.Refresh(ctx, model.RefreshRequest{RefreshToken: resp.RefreshToken}); !model.IsUnauthorizedError(err) {
+		t.Errorf("Refresh() after logout error = %v, want unauthorized", err)
+	}
+}
+
+func TestAuthService_Lockout(t *testing.T) {
+	svc, userSvc := newTestAuthServices(t)
+	ctx := context.Background()
+
+	if _, err := svc.Login(ctx, model.LoginRequest{Username: "nobody", Password: "rahasia123"}); !model.IsUnauthorizedError(err) {
+		t.Errorf("Login() with an unknown user error = %v, want unauthorized", err)
+	}
+
+	for range 3 {
+		if _, err := svc.Login(ctx, model.LoginRequest{Username: "siti", Password: "wrong-password"}); !model.IsUnauthorizedError(err) {
+			t.Fatalf("Login() with a wrong password error = %v, want unauthorized", err)
+		}
+	}
+
+	// The right password is refused while the account is locked
+	_, err := svc.Login(ctx, model.LoginRequest{Username: "siti", Password: "rahasia123"})
+	if !model.IsUnauthorizedError(err) {
+		t.Fatalf("Login() while locked error = %v, want unauthorized", err)
+	}
+	user, _ := userSvc.GetByID(ctx, 1)
+	if !user.IsLocked(time.Now()) {
+		t.Fatalf("user = %+v, want locked", user)
+	}
+
+	// Setting a new password lifts the lockout
+	if _, err := userSvc.Update(ctx, 1, model.UserRequest{Username: "siti", Name: "Siti", Password: "baru-rahasia"}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, err := svc.Login(ctx, model.LoginRequest{Username: "siti", Password: "baru-rahasia"}); err != nil {
+		t.Errorf("Login() after a password reset error = %v", err)
+	}
+}
+
+func TestAuthService_RefreshRotates(t *testing.T) {
+	svc, userSvc := newTestAuthServices(t)
+	ctx := context.Background()
+
+	login, _ := svc.Login(ctx, model.LoginRequest{Username: "siti", Password: "rahasia123"})
+	refreshed, err := svc.Refresh(ctx, model.RefreshRequest{RefreshToken: login.RefreshToken})
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Error("Refresh() returned the same refresh token")
+	}
+	authenticated(t, svc, refreshed.AccessToken)
+
+	// Replaying the old refresh token revokes the session it belonged to
+	if _, err := svc.Refresh(ctx, model.RefreshRequest{RefreshToken: login.RefreshToken}); !model.IsUnauthorizedError(err) {
+		t.Fatalf("Refresh() with a used token error = %v, want unauthorized", err)
+	}
+	if _, err := svc.Authenticate(ctx, refreshed.AccessToken); !model.IsUnauthorizedError(err) {
+		t.Errorf("Authenticate() after token reuse error = %v, want unauthorized", err)
+	}
+
+	// Deactivating a user signs them out everywhere
+	budi, _ := userSvc.Create(ctx, model.UserRequest{Username: "budi", Name: "Budi", Password: "rahasia123"})
+	second, _ := svc.Login(ctx, model.LoginRequest{Username: "budi", Password: "rahasia123"})
+	inactive := false
+	if _, err := userSvc.Update(ctx, budi.ID, model.UserRequest{Username: "budi", Name: "Budi", Active: &inactive}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, second.AccessToken); !model.IsUnauthorizedError(err) {
+		t.Errorf("Authenticate() after deactivation error = %v, want unauthorized", err)
+	}
+	if _, err := svc.Login(ctx, model.LoginRequest{Username: "budi", Password: "rahasia123"}); !model.IsUnauthorizedError(err) {
+		t.Errorf("Login() of a deactivated user error = %v, want unauthorized", err)
+	}
+}
+
+func TestUserService_CreateInitialOnlyOnce(t *testing.T) {
+	_, userSvc := newTestAuthServices(t)
+	ctx := context.Background()
+
+	created, err := userSvc.CreateInitial(ctx, model.UserRequest{Username: "admin", Name: "Admin", Password: "rahasia123"})
+	if err != nil || created {
+		t.Errorf("CreateInitial() with users present = %v, %v, want nothing created", created, err)
+	}
+	if _, err := userSvc.Create(ctx, model.UserRequest{Username: "budi", Name: "Budi"}); !model.IsValidationError(err) {
+		t.Errorf("Create() without a password error = %v, want validation", err)
+	}
+	if _, err := userSvc.Create(ctx, model.UserRequest{Username: "SITI", Name: "Siti", Password: "rahasia123"}); !model.IsConflictError(err) {
+		t.Errorf("Create() with a used username error = %v, want conflict", err)
+	}
+}
+
+func TestUserService_AssignRole(t *testing.T) {
+	svc, userSvc := newTestAuthServices(t)
+	ctx := context.Background()
+
+	budi, _ := userSvc.Create(ctx, model.UserRequest{Username: "budi", Name: "Budi", Password: "rahasia123"})
+	if budi.Role != model.RoleCashier {
+		t.Errorf("Create() role = %s, want cashier", budi.Role)
+	}
+	owner, _ := userSvc.GetByID(ctx, 1)
+	if owner.Role != model.RoleOwner {
+		t.Errorf("initial user role = %s, want owner", owner.Role)
+	}
+
+	if _, err := userSvc.AssignRole(ctx, budi.ID, model.RoleRequest{Role: "admin"}); !model.IsValidationError(err) {
+		t.Errorf("AssignRole() with an unknown role error = %v, want validation", err)
+	}
+	if _, err := userSvc.AssignRole(ctx, owner.ID, model.RoleRequest{Role: model.RoleManager}); !model.IsConflictError(err) {
+		t.Errorf("AssignRole() demoting the last owner error = %v, want conflict", err)
+	}
+
+	// A new role shows up on the next request without signing in again
+	login, _ := svc.Login(ctx, model.LoginRequest{Username: "budi", Password: "rahasia123"})
+	if _, err := userSvc.AssignRole(ctx, budi.ID, model.RoleRequest{Role: model.RoleStockClerk}); err != nil {
+		t.Fatalf("AssignRole() error = %v", err)
+	}
+	principal, err := svc.Authenticate(ctx, login.AccessToken)
+	if err != nil || principal.Role != string(model.RoleStockClerk) {
+		t.Errorf("Authenticate() = %+v, %v, want a stock clerk", principal, err)
+	}
+}
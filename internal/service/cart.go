@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+// CartCheckout is the part of TransactionService used to check out a cart
+type CartCheckout interface {
+	CheckoutWithKey(ctx context.Context, key string, req model.CheckoutRequest) (*model.Transaction, bool, error)
+}
+
+type CartService struct {
+	reader   repository.CartReader
+	writer   repository.CartWriter
+	products repository.ProductReader
+	checkout CartCheckout
+	ttl      time.Duration
+}
+
+func NewCartService(reader repository.CartReader, writer repository.CartWriter, products repository.ProductReader, checkout CartCheckout, ttl time.Duration) *CartService {
+	return &CartService{
+		reader:   reader,
+		writer:   writer,
+		products: products,
+		checkout: checkout,
+		ttl:      ttl,
+	}
+}
+
+func (s *CartService) Create(ctx context.Context, req model.CartRequest) (*model.Cart, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CartService.Create", req)
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	items := model.MergeCartItems(req.Items)
+	for _, item := range items {
+		if err := s.checkProduct(ctx, item.ProductID); err != nil {
+			spanEnd(nil, err)
+			return nil, err
+		}
+	}
+
+	cart, err := s.writer.Create(ctx, model.Cart{
+		Label:     req.Label,
+		Items:     items,
+		ExpiresAt: time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to create cart")
+	}
+
+	if err := s.price(ctx, cart); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(cart, nil)
+	return cart, nil
+}
+
+// GetByID returns the cart re-priced from the current products
+func (s *CartService) GetByID(ctx context.Context, id int) (*model.Cart, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CartService.GetByID", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	cart, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	if err := s.price(ctx, cart); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(cart, nil)
+	return cart, nil
+}
+
+// GetHeld lists the carts that are parked and not yet expired
+func (s *CartService) GetHeld(ctx context.Context) ([]model.Cart, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CartService.GetHeld", nil)
+	defer spanEnd(nil, nil)
+
+	carts, err := s.reader.FindHeld(ctx, time.Now())
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get held carts")
+	}
+
+	for i := range carts {
+		if err := s.price(ctx, &carts[i]); err != nil {
+			spanEnd(nil, err)
+			return nil, err
+		}
+	}
+
+	spanEnd(carts, nil)
+	return carts, nil
+}
+
+// AddItem adds a product to the cart, increasing the quantity if it is already there
+func (s *CartService) AddItem(ctx context.Context, id int, req model.CartItemRequest) (*model.Cart, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CartService.AddItem", map[string]interface{}{"id": id, "request": req})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+	if req.ProductID <= 0 {
+		err := errorsPkg.ValidationError("product_id must be positive")
+		spanEnd(nil, err)
+		return nil, err
+	}
+	if err := s.checkProduct(ctx, req.ProductID); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	cart, err := s.modify(ctx, id, func(items []model.CartItem) ([]model.CartItem, error) {
+		for i := range items {
+			if items[i].ProductID == req.ProductID {
+				items[i].Quantity += req.Quantity
+				return items, nil
+			}
+		}
+		return append(items, model.CartItem{ProductID: req.ProductID, Quantity: req.Quantity}), nil
+	})
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(cart, nil)
+	return cart, nil
+}
+
+// UpdateItem sets the quantity of a product already in the cart
+func (s *CartService) UpdateItem(ctx context.Context, id, productID int, req model.CartItemRequest) (*model.Cart, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CartService.UpdateItem", map[string]interface{}{"id": id, "product_id": productID, "request": req})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	cart, err := s.modify(ctx, id, func(items []model.CartItem) ([]model.CartItem, error) {
+		for i := range items {
+			if items[i].ProductID == productID {
+				items[i].Quantity = req.Quantity
+				return items, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: product id %d is not in cart %d", model.ErrNotFound, productID, id)
+	})
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(cart, nil)
+	return cart, nil
+}
+
+// RemoveItem drops a product from the cart
+func (s *CartService) RemoveItem(ctx context.Context, id, productID int) (*model.Cart, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CartService.RemoveItem", map[string]interface{}{"id": id, "product_id": productID})
+	defer spanEnd(nil, nil)
+
+	cart, err := s.modify(ctx, id, func(items []model.CartItem) ([]model.CartItem, error) {
+		for i := range items {
+			if items[i].ProductID == productID {
+				return append(items[:i], items[i+1:]...), nil
+			}
+		}
+		return nil, fmt.Errorf("%w: product id %d is not in cart %d", model.ErrNotFound, productID, id)
+	})
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(cart, nil)
+	return cart, nil
+}
+
+func (s *CartService) Delete(ctx context.Context, id int) error {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CartService.Delete", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	if err := s.writer.Delete(ctx, id); err != nil {
+		spanEnd(nil, err)
+		return wrapError(err, "failed to delete cart")
+	}
+
+	spanEnd(nil, nil)
+	return nil
+}
+
+// Checkout converts the cart into a checkout request and runs it through the
+// transaction service at current prices. The cart is claimed first so two
+// terminals cannot check out the same cart; it goes back to held only if checkout
+// fails before a sale is recorded, and otherwise stays checking_out for follow-up.
+func (s *CartService) Checkout(ctx context.Context, id int, key string, req model.CartCheckoutRequest) (*model.Transaction, bool, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CartService.Checkout", map[string]interface{}{"id": id, "request": req})
+	defer spanEnd(nil, nil)
+
+	cart, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, false, err
+	}
+	now := time.Now()
+	if cart.Status != model.CartStatusHeld || cart.IsExpired(now) {
+		err := model.CartNotHeldError(*cart, now)
+		spanEnd(nil, err)
+		return nil, false, err
+	}
+	if len(cart.Items) == 0 {
+		err := errorsPkg.ValidationError("cart is empty")
+		spanEnd(nil, err)
+		return nil, false, err
+	}
+
+	if err := s.writer.UpdateStatus(ctx, id, model.CartStatusHeld, model.CartStatusCheckingOut, nil); err != nil {
+		spanEnd(nil, err)
+		return nil, false, wrapError(err, "failed to claim cart")
+	}
+
+	transaction, replayed, err := s.checkout.CheckoutWithKey(ctx, key, cart.CheckoutRequest(req.Payments))
+	if err != nil {
+		if isSaleRecorded(err) {
+			slog.Warn("cart left checking out after its sale was recorded", "cart_id", id, "error", err)
+			spanEnd(nil, err)
+			return nil, false, err
+		}
+		if releaseErr := s.writer.UpdateStatus(ctx, id, model.CartStatusCheckingOut, model.CartStatusHeld, nil); releaseErr != nil {
+			slog.Warn("failed to release cart after checkout error", "cart_id", id, "error", releaseErr)
+		}
+		spanEnd(nil, err)
+		return nil, false, err
+	}
+
+	if err := s.writer.UpdateStatus(ctx, id, model.CartStatusCheckingOut, model.CartStatusCheckedOut, &transaction.ID); err != nil {
+		slog.Warn("failed to mark cart checked out", "cart_id", id, "transaction_id", transaction.ID, "error", err)
+	}
+
+	spanEnd(transaction, nil)
+	return transaction, replayed, nil
+}
+
+// modify applies change to the lines of a held cart, saves them and returns the re-priced cart
+func (s *CartService) modify(ctx context.Context, id int, change func([]model.CartItem) ([]model.CartItem, error)) (*model.Cart, error) {
+	cart, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if cart.Status != model.CartStatusHeld || cart.IsExpired(now) {
+		return nil, model.CartNotHeldError(*cart, now)
+	}
+
+	items, err := change(cart.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := now.Add(s.ttl)
+	if err := s.writer.SaveItems(ctx, id, items, expiresAt); err != nil {
+		return nil, wrapError(err, "failed to save cart")
+	}
+
+	cart.Items = items
+	cart.UpdatedAt = now
+	cart.ExpiresAt = expiresAt
+	if err := s.price(ctx, cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// checkProduct ensures a product can be put in a cart
+func (s *CartService) checkProduct(ctx context.Context, productID int) error {
+	product, err := s.products.FindByID(ctx, productID)
+	if err != nil {
+		if model.IsNotFoundError(err) {
+			return fmt.Errorf("%w: product id %d not found", model.ErrNotFound, productID)
+		}
+		return wrapError(err, "failed to get product")
+	}
+	if len(product.Variants) > 0 {
+		return fmt.Errorf("%w: product %s has variants; add one of them by its id", model.ErrValidation, product.Name)
+	}
+	if !product.Active {
+		return fmt.Errorf("%w: product %s is not active", model.ErrValidation, product.Name)
+	}
+	return nil
+}
+
+// price fills in names and prices from the current products, so a resumed cart
+// reflects price changes made while it was parked
+func (s *CartService) price(ctx context.Context, cart *model.Cart) error {
+	if cart.IsExpired(time.Now()) {
+		cart.Status = model.CartStatusExpired
+	}
+
+	cart.EstimatedTotal = 0
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		product, err := s.products.FindByID(ctx, item.ProductID)
+		if err != nil {
+			if model.IsNotFoundError(err) {
+				item.Available = false
+				continue
+			}
+			return wrapError(err, "failed to price cart")
+		}
+
+		item.ProductName = product.Name
+		item.UnitPrice = product.Price
+		item.Subtotal = product.Price * item.Quantity
+		item.Available = product.Active
+		if item.Available {
+			cart.EstimatedTotal += item.Subtotal
+		}
+	}
+
+	return nil
+}
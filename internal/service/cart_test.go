@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+)
+
+func newTestCartService(ttl time.Duration) (*CartService, *memory.ProductRepository) {
+	productRepo := memory.NewProductRepository()
+	transactionRepo := memory.NewTransactionRepository(productRepo)
+	transactionService := NewTransactionService(transactionRepo, transactionRepo)
+	cartRepo := memory.NewCartRepository()
+	return NewCartService(cartRepo, cartRepo, productRepo, transactionService, ttl), productRepo
+}
+
+func TestCartService_RepricesOnResume(t *testing.T) {
+	svc, productRepo := newTestCartService(time.Hour)
+	ctx := context.Background()
+	productRepo.Create(ctx, model.Product{Name: "Kopi", Price: 10000, Stock: 10, Active: true})
+
+	cart, err := svc.Create(ctx, model.CartRequest{
+		Label: "Table 3",
+		Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}, {ProductID: 1, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(cart.Items) != 1 || cart.Items[0].Quantity != 2 || cart.EstimatedTotal != 20000 {
+		t.Errorf("Create() = %+v, want one line of 2 totalling 20000", cart)
+	}
+
+	productRepo.Update(ctx, 1, model.Product{Name: "Kopi", Price: 12000, Stock: 10, Active: true})
+
+	resumed, err := svc.GetByID(ctx, cart.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if resumed.Items[0].UnitPrice != 12000 || resumed.EstimatedTotal != 24000 {
+		t.Errorf("GetByID() = %+v, want repriced at 12000", resumed)
+	}
+}
+
+func TestCartService_EditLines(t *testing.T) {
+	svc, productRepo := newTestCartService(time.Hour)
+	ctx := context.Background()
+	productRepo.Create(ctx, model.Product{Name: "Kopi", Price: 10000, Stock: 10, Active: true})
+	productRepo.Create(ctx, model.Product{Name: "Roti", Price: 5000, Stock: 10, Active: true})
+
+	cart, _ := svc.Create(ctx, model.CartRequest{})
+
+	if _, err := svc.AddItem(ctx, cart.ID, model.CartItemRequest{ProductID: 1, Quantity: 1}); err != nil {
+		t.Fatalf("AddItem() error = %v", err)
+	}
+	if _, err := svc.AddItem(ctx, cart.ID, model.CartItemRequest{ProductID: 2, Quantity: 1}); err != nil {
+		t.Fatalf("AddItem() error = %v", err)
+	}
+	if _, err := svc.UpdateItem(ctx, cart.ID, 1, model.CartItemRequest{Quantity: 3}); err != nil {
+		t.Fatalf("UpdateItem() error = %v", err)
+	}
+	updated, err := svc.RemoveItem(ctx, cart.ID, 2)
+	if err != nil {
+		t.Fatalf("RemoveItem() error = %v", err)
+	}
+	if len(updated.Items) != 1 || updated.Items[0].Quantity != 3 || updated.EstimatedTotal != 30000 {
+		t.Errorf("RemoveItem() = %+v, want 3 x Kopi", updated)
+	}
+
+	if _, err := svc.RemoveItem(ctx, cart.ID, 2); !model.IsNotFoundError(err) {
+		t.Errorf("RemoveItem() missing line error = %v, want not found", err)
+	}
+	if _, err := svc.AddItem(ctx, cart.ID, model.CartItemRequest{ProductID: 99, Quantity: 1}); !model.IsNotFoundError(err) {
+		t.Errorf("AddItem() unknown product error = %v, want not found", err)
+	}
+}
+
+func TestCartService_Checkout(t *testing.T) {
+	svc, productRepo := newTestCartService(time.Hour)
+	ctx := context.Background()
+	productRepo.Create(ctx, model.Product{Name: "Kopi", Price: 10000, Stock: 10, Active: true})
+
+	cart, _ := svc.Create(ctx, model.CartRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}}})
+
+	transaction, _, err := svc.Checkout(ctx, cart.ID, "", model.CartCheckoutRequest{
+		Payments: []model.CheckoutPayment{{Method: model.PaymentMethodCash, Amount: 50000}},
+	})
+	if err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+	if transaction.TotalAmount != 20000 || transaction.ChangeAmount != 30000 {
+		t.Errorf("Checkout() = %+v, want total 20000 and change 30000", transaction)
+	}
+
+	product, _ := productRepo.FindByID(ctx, 1)
+	if product.Stock != 8 {
+		t.Errorf("Stock = %v, want 8", product.Stock)
+	}
+
+	checkedOut, _ := svc.GetByID(ctx, cart.ID)
+	if checkedOut.Status != model.CartStatusCheckedOut || checkedOut.TransactionID == nil || *checkedOut.TransactionID != transaction.ID {
+		t.Errorf("GetByID() = %+v, want checked_out with transaction %d", checkedOut, transaction.ID)
+	}
+
+	if _, _, err := svc.Checkout(ctx, cart.ID, "", model.CartCheckoutRequest{}); !model.IsConflictError(err) {
+		t.Errorf("Checkout() twice error = %v, want conflict", err)
+	}
+}
+
+func TestCartService_Checkout_FailureKeepsCartHeld(t *testing.T) {
+	svc, productRepo := newTestCartService(time.Hour)
+	ctx := context.Background()
+	productRepo.Create(ctx, model.Product{Name: "Kopi", Price: 10000, Stock: 1, Active: true})
+
+	cart, _ := svc.Create(ctx, model.CartRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}}})
+
+	if _, _, err := svc.Checkout(ctx, cart.ID, "", model.CartCheckoutRequest{}); !model.IsValidationError(err) {
+		t.Fatalf("Checkout() error = %v, want validation error", err)
+	}
+
+	held, _ := svc.GetByID(ctx, cart.ID)
+	if held.Status != model.CartStatusHeld {
+		t.Errorf("Status = %v, want held", held.Status)
+	}
+}
+
+func TestCartService_Checkout_RecordedSaleKeepsCartClaimed(t *testing.T) {
+	productRepo := memory.NewProductRepository()
+	transactionRepo := memory.NewTransactionRepository(productRepo)
+	transactionService := NewTransactionService(lostTransactionReader{transactionRepo}, transactionRepo)
+	enableIdempotency(transactionService)
+	cartRepo := memory.NewCartRepository()
+	svc := NewCartService(cartRepo, cartRepo, productRepo, transactionService, time.Hour)
+	ctx := context.Background()
+	productRepo.Create(ctx, model.Product{Name: "Kopi", Price: 10000, Stock: 10, Active: true})
+
+	first, _ := svc.Create(ctx, model.CartRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}})
+	if _, _, err := svc.Checkout(ctx, first.ID, "key-1", model.CartCheckoutRequest{}); err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+
+	// The retry matches a recorded sale that cannot be loaded back
+	retry, _ := svc.Create(ctx, model.CartRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}})
+	if _, _, err := svc.Checkout(ctx, retry.ID, "key-1", model.CartCheckoutRequest{}); err == nil {
+		t.Fatal("Checkout() error = nil, want the replay failure")
+	}
+
+	claimed, _ := svc.GetByID(ctx, retry.ID)
+	if claimed.Status != model.CartStatusCheckingOut {
+		t.Errorf("Status = %v, want checking_out", claimed.Status)
+	}
+}
+
+// lostTransactionReader fails to load any transaction back
+type lostTransactionReader struct {
+	*memory.TransactionRepository
+}
+
+func (lostTransactionReader) FindByID(ctx context.Context, id int) (*model.Transaction, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestCartService_Expired(t *testing.T) {
+	svc, productRepo := newTestCartService(-time.Minute)
+	ctx := context.Background()
+	productRepo.Create(ctx, model.Product{Name: "Kopi", Price: 10000, Stock: 10, Active: true})
+
+	cart, _ := svc.Create(ctx, model.CartRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}})
+	if cart.Status != model.CartStatusExpired {
+		t.Errorf("Status = %v, want expired", cart.Status)
+	}
+
+	held, _ := svc.GetHeld(ctx)
+	if len(held) != 0 {
+		t.Errorf("GetHeld() = %+v, want none", held)
+	}
+
+	if _, _, err := svc.Checkout(ctx, cart.ID, "", model.CartCheckoutRequest{}); !model.IsConflictError(err) {
+		t.Errorf("Checkout() error = %v, want conflict", err)
+	}
+}
+
+func TestCartService_Variants(t *testing.T) {
+	svc, productRepo := newTestCartService(time.Hour)
+	ctx := context.Background()
+	parent, _ := productRepo.Create(ctx, model.Product{
+		Name: "Aqua", Price: 3000, Active: true,
+		Options: []model.ProductOption{{Name: "size", Values: []string{"1L", "2L"}}},
+	})
+	price := 5500
+	large, _ := productRepo.CreateVariant(ctx, parent.ID, model.ProductVariantRequest{Attributes: map[string]string{"size": "2L"}, Price: &price, Stock: 5})
+
+	cart, err := svc.Create(ctx, model.CartRequest{Items: []model.CheckoutItem{{VariantID: &large.ID, Quantity: 2}}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if len(cart.Items) != 1 || cart.Items[0].ProductID != large.ID || cart.EstimatedTotal != 11000 {
+		t.Errorf("Create() = %+v, want 2 x Aqua 2L", cart)
+	}
+
+	if _, err := svc.AddItem(ctx, cart.ID, model.CartItemRequest{ProductID: parent.ID, Quantity: 1}); !model.IsValidationError(err) {
+		t.Errorf("AddItem() of a product with variants error = %v, want a validation error", err)
+	}
+}
@@ -94,7 +94,7 @@ func (s *CategoryService) Delete(ctx context.Context, id int) error {
 	err := s.writer.Delete(ctx, id)
 	if err != nil {
 		spanEnd(nil, err)
-		return errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to delete category")
+		return wrapError(err, "failed to delete category")
 	}
 
 	spanEnd(nil, nil)
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+type CustomerService struct {
+	reader       repository.CustomerReader
+	writer       repository.CustomerWriter
+	transactions repository.TransactionReader
+}
+
+func NewCustomerService(reader repository.CustomerReader, writer repository.CustomerWriter, transactions repository.TransactionReader) *CustomerService {
+	return &CustomerService{
+		reader:       reader,
+		writer:       writer,
+		transactions: transactions,
+	}
+}
+
+func (s *CustomerService) Create(ctx context.Context, req model.CustomerRequest) (*model.Customer, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CustomerService.Create", req)
+	defer spanEnd(nil, nil)
+
+	req = req.Normalize()
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	customer, err := s.writer.Create(ctx, req.Customer())
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to create customer")
+	}
+
+	spanEnd(customer, nil)
+	return customer, nil
+}
+
+func (s *CustomerService) GetByID(ctx context.Context, id int) (*model.Customer, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CustomerService.GetByID", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	customer, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(customer, nil)
+	return customer, nil
+}
+
+func (s *CustomerService) GetAll(ctx context.Context, search string) ([]model.Customer, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CustomerService.GetAll", map[string]interface{}{"search": search})
+	defer spanEnd(nil, nil)
+
+	customers, err := s.reader.FindAll(ctx, search)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get customers")
+	}
+
+	spanEnd(customers, nil)
+	return customers, nil
+}
+
+func (s *CustomerService) Update(ctx context.Context, id int, req model.CustomerRequest) (*model.Customer, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CustomerService.Update", map[string]interface{}{"id": id, "request": req})
+	defer spanEnd(nil, nil)
+
+	req = req.Normalize()
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	customer, err := s.writer.Update(ctx, id, req.Customer())
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to update customer")
+	}
+
+	spanEnd(customer, nil)
+	return customer, nil
+}
+
+// Erase removes the customer's personal data for a privacy request. The record,
+// its points ledger and its transactions stay, but no longer identify anyone.
+func (s *CustomerService) Erase(ctx context.Context, id int) (*model.Customer, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CustomerService.Erase", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	customer, err := s.writer.Erase(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to erase customer")
+	}
+
+	spanEnd(customer, nil)
+	return customer, nil
+}
+
+func (s *CustomerService) GetLedger(ctx context.Context, id int) ([]model.LoyaltyEntry, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CustomerService.GetLedger", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	entries, err := s.reader.FindLedger(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to get points ledger")
+	}
+
+	spanEnd(entries, nil)
+	return entries, nil
+}
+
+// GetTransactions returns the customer's purchase history, newest first
+func (s *CustomerService) GetTransactions(ctx context.Context, id int, filter model.TransactionFilter) ([]model.Transaction, int, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "CustomerService.GetTransactions", map[string]interface{}{"id": id, "filter": filter})
+	defer spanEnd(nil, nil)
+
+	if _, err := s.reader.FindByID(ctx, id); err != nil {
+		spanEnd(nil, err)
+		return nil, 0, err
+	}
+
+	filter.CustomerID = &id
+	if err := filter.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, 0, err
+	}
+
+	transactions, total, err := s.transactions.FindAll(ctx, filter.WithDefaults())
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, 0, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get transactions")
+	}
+
+	spanEnd(transactions, nil)
+	return transactions, total, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+)
+
+func TestCustomerService_CreateNormalizesPhone(t *testing.T) {
+	customerRepo := memory.NewCustomerRepository()
+	transactionRepo := memory.NewTransactionRepository(memory.NewProductRepository())
+	svc := NewCustomerService(customerRepo, customerRepo, transactionRepo)
+	ctx := context.Background()
+
+	if _, err := svc.Create(ctx, model.CustomerRequest{Name: "Budi", Phone: "+62 812-3456-7890"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	_, err := svc.Create(ctx, model.CustomerRequest{Name: "Siti", Phone: "081234567890"})
+	if !model.IsConflictError(err) {
+		t.Errorf("Create() with the same number written differently error = %v, want conflict", err)
+	}
+}
+
+func TestCustomerService_GetTransactions(t *testing.T) {
+	productRepo := memory.NewProductRepository()
+	ctx := context.Background()
+	productRepo.Create(ctx, model.Product{Name: "Indomie", Price: 3500, Stock: 10, Active: true})
+
+	transactionRepo := memory.NewTransactionRepository(productRepo)
+	customerRepo := memory.NewCustomerRepository()
+	transactionRepo.SetCustomerRepo(customerRepo)
+
+	transactionService := NewTransactionService(transactionRepo, transactionRepo)
+	svc := NewCustomerService(customerRepo, customerRepo, transactionRepo)
+
+	customer, _ := svc.Create(ctx, model.CustomerRequest{Name: "Budi"})
+	transactionService.Checkout(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}, CustomerID: &customer.ID})
+	transactionService.Checkout(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}})
+
+	history, total, err := svc.GetTransactions(ctx, customer.ID, model.TransactionFilter{})
+	if err != nil {
+		t.Fatalf("GetTransactions() error = %v", err)
+	}
+	if total != 1 || len(history) != 1 || *history[0].CustomerID != customer.ID {
+		t.Errorf("GetTransactions() = %d transactions, want only the customer's one", total)
+	}
+
+	if _, _, err := svc.GetTransactions(ctx, 99, model.TransactionFilter{}); !model.IsNotFoundError(err) {
+		t.Errorf("GetTransactions() for unknown customer error = %v, want not found", err)
+	}
+}
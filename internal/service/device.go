@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"time"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	"kasir-api/pkg/middleware"
+	"kasir-api/pkg/tracing"
+)
+
+const (
+	defaultPairingCodeTTL = 10 * time.Minute
+
+	// pairingCodeAlphabet leaves out 0, 1, I and O, which are easily mixed up when
+	// typed on a tablet. Its 32 letters divide 256, so every one is equally likely.
+	pairingCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	pairingCodeLength   = 8
+
+	// deviceKeyPrefix marks device keys so they are recognisable in configs and logs
+	deviceKeyPrefix = "kdk_"
+	// deviceKeyPrefixLength is how much of a key is kept in the clear to identify it
+	deviceKeyPrefixLength = 12
+)
+
+// DeviceService pairs POS devices with an outlet. An admin registers a device and
+// gets a short-lived pairing code; the device exchanges the code once for a
+// long-lived API key, which works until the device is revoked.
+type DeviceService struct {
+	devices    repository.DeviceStore
+	outlets    repository.OutletReader
+	pairingTTL time.Duration
+}
+
+func NewDeviceService(devices repository.DeviceStore, outlets repository.OutletReader) *DeviceService {
+	return &DeviceService{
+		devices:    devices,
+		outlets:    outlets,
+		pairingTTL: defaultPairingCodeTTL,
+	}
+}
+
+// SetPairingCodeTTL sets how long a pairing code can be used
+func (s *DeviceService) SetPairingCodeTTL(ttl time.Duration) {
+	if ttl > 0 {
+		s.pairingTTL = ttl
+	}
+}
+
+// Register adds a device waiting to be paired and returns its pairing code. The
+// device is bound to the requested outlet, or else the outlet the request is
+// scoped to.
+func (s *DeviceService) Register(ctx context.Context, req model.DeviceRequest) (*model.PairingCodeResponse, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "DeviceService.Register", map[string]interface{}{"name": req.Name, "outlet_id": req.OutletID})
+	defer spanEnd(nil, nil)
+
+	req.Name = strings.TrimSpace(req.Name)
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	outletID := req.OutletID
+	if outletID == 0 {
+		outletID = model.OutletID(ctx)
+	}
+	outlet, err := s.outlets.FindByID(ctx, outletID)
+	if err != nil {
+		if model.IsNotFoundError(err) {
+			err = model.OutletNotFoundError(outletID)
+		}
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to register device")
+	}
+	if !outlet.Active {
+		err := model.OutletInactiveError(outlet.Code)
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	now := time.Now()
+	code := newPairingCode()
+	expiresAt := now.Add(s.pairingTTL)
+	device := model.Device{
+		Name:             req.Name,
+		OutletID:         outletID,
+		PairingCodeHash:  hashToken(code),
+		PairingExpiresAt: &expiresAt,
+	}
+	if principal, ok := middleware.PrincipalFromContext(ctx); ok {
+		device.CreatedBy = principal.Username
+	}
+
+	created, err := s.devices.Create(ctx, device)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to register device")
+	}
+	created.Status = created.StatusAt(now)
+
+	spanEnd(created, nil)
+	return &model.PairingCodeResponse{
+		Device:      *created,
+		PairingCode: code[:pairingCodeLength/2] + "-" + code[pairingCodeLength/2:],
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// Pair exchanges a pairing code for the device's API key. The key is returned
+// here only; the server keeps just its hash.
+func (s *DeviceService) Pair(ctx context.Context, req model.PairRequest) (*model.PairResponse, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "DeviceService.Pair", nil)
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	now := time.Now()
+	key := deviceKeyPrefix + randomToken()
+	device, err := s.devices.Pair(ctx, hashToken(model.NormalizePairingCode(req.PairingCode)), hashToken(key), key[:deviceKeyPrefixLength], now)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to pair device")
+	}
+	device.Status = device.StatusAt(now)
+
+	spanEnd(map[string]interface{}{"device_id": device.ID, "outlet_id": device.OutletID}, nil)
+	return &model.PairResponse{APIKey: key, Device: *device}, nil
+}
+
+func (s *DeviceService) GetByID(ctx context.Context, id int) (*model.Device, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "DeviceService.GetByID", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	device, err := s.devices.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to get device")
+	}
+	device.Status = device.StatusAt(time.Now())
+
+	spanEnd(device, nil)
+	return device, nil
+}
+
+// GetAll returns every device with its status and usage
+func (s *DeviceService) GetAll(ctx context.Context) ([]model.Device, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "DeviceService.GetAll", nil)
+	defer spanEnd(nil, nil)
+
+	devices, err := s.devices.FindAll(ctx)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to get devices")
+	}
+	now := time.Now()
+	for i := range devices {
+		devices[i].Status = devices[i].StatusAt(now)
+	}
+
+	spanEnd(devices, nil)
+	return devices, nil
+}
+
+// Revoke shuts a device out for good: its key, or its pairing code if it was not
+// paired yet, stops working on the next request
+func (s *DeviceService) Revoke(ctx context.Context, id int) (*model.Device, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "DeviceService.Revoke", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	now := time.Now()
+	device, err := s.devices.Revoke(ctx, id, now)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to revoke device")
+	}
+	device.Status = device.StatusAt(now)
+
+	spanEnd(device, nil)
+	return device, nil
+}
+
+// Authenticate checks a device key for middleware.AuthMiddleware and records the
+// request against the device. Devices act with model.DeviceRole and are bound to
+// their outlet.
+func (s *DeviceService) Authenticate(ctx context.Context, key string) (*middleware.Principal, error) {
+	if !strings.HasPrefix(key, deviceKeyPrefix) {
+		return nil, model.InvalidDeviceKeyError()
+	}
+
+	device, err := s.devices.FindByKeyHash(ctx, hashToken(key))
+	if model.IsNotFoundError(err) || (err == nil && device.RevokedAt != nil) {
+		return nil, model.InvalidDeviceKeyError()
+	}
+	if err != nil {
+		return nil, wrapError(err, "failed to authenticate device")
+	}
+
+	if err := s.devices.RecordUse(ctx, device.ID, time.Now()); err != nil {
+		return nil, wrapError(err, "failed to authenticate device")
+	}
+
+	return &middleware.Principal{
+		Username: device.Name,
+		Role:     string(model.DeviceRole),
+		DeviceID: device.ID,
+		OutletID: device.OutletID,
+	}, nil
+}
+
+// newPairingCode returns a random code of pairingCodeLength letters from
+// pairingCodeAlphabet
+func newPairingCode() string {
+	b := make([]byte, pairingCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	for i := range b {
+		b[i] = pairingCodeAlphabet[int(b[i])%len(pairingCodeAlphabet)]
+	}
+	return string(b)
+}
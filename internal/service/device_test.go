@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+)
+
+func TestDeviceService_PairAndRevoke(t *testing.T) {
+	svc := NewDeviceService(memory.NewDeviceRepository(), memory.NewOutletRepository(model.Outlet{Code: "MAIN"}))
+	ctx := context.Background()
+
+	registered, err := svc.Register(ctx, model.DeviceRequest{Name: " Till 1 "})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if registered.Device.Name != "Till 1" || registered.Device.OutletID != model.DefaultOutletID || registered.Device.Status != model.DeviceStatusPending {
+		t.Errorf("Register() device = %+v, want a pending device at the default outlet", registered.Device)
+	}
+	if len(registered.PairingCode) != 9 || registered.PairingCode[4] != '-' {
+		t.Errorf("Register() pairing code = %q, want XXXX-XXXX", registered.PairingCode)
+	}
+
+	// Codes are accepted however they are typed, but only once
+	paired, err := svc.Pair(ctx, model.PairRequest{PairingCode: " " + registered.PairingCode[:4] + registered.PairingCode[5:] + " "})
+	if err != nil {
+		t.Fatalf("Pair() error = %v", err)
+	}
+	if paired.Device.Status != model.DeviceStatusActive || paired.Device.KeyPrefix != paired.APIKey[:12] {
+		t.Errorf("Pair() device = %+v, want active with the key's prefix", paired.Device)
+	}
+	if _, err := svc.Pair(ctx, model.PairRequest{PairingCode: registered.PairingCode}); !model.IsUnauthorizedError(err) {
+		t.Errorf("Pair() with a used code error = %v, want unauthorized", err)
+	}
+
+	principal, err := svc.Authenticate(ctx, paired.APIKey)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if !principal.IsDevice() || principal.OutletID != model.DefaultOutletID || principal.Role != string(model.DeviceRole) {
+		t.Errorf("Authenticate() = %+v, want a cashier device bound to the default outlet", principal)
+	}
+	if _, err := svc.Authenticate(ctx, paired.APIKey+"x"); !model.IsUnauthorizedError(err) {
+		t.Errorf("Authenticate() with a wrong key error = %v, want unauthorized", err)
+	}
+
+	device, _ := svc.GetByID(ctx, paired.Device.ID)
+	if device.RequestCount != 1 || device.LastSeenAt == nil {
+		t.Errorf("device usage = %d requests, last seen %v, want 1 request recorded", device.RequestCount, device.LastSeenAt)
+	}
+
+	if _, err := svc.Revoke(ctx, device.ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, paired.APIKey); !model.IsUnauthorizedError(err) {
+		t.Errorf("Authenticate() with a revoked key error = %v, want unauthorized", err)
+	}
+}
+
+func TestDeviceService_PairingCodeExpires(t *testing.T) {
+	svc := NewDeviceService(memory.NewDeviceRepository(), memory.NewOutletRepository(model.Outlet{Code: "MAIN"}))
+	svc.SetPairingCodeTTL(time.Millisecond)
+	ctx := context.Background()
+
+	if _, err := svc.Register(ctx, model.DeviceRequest{Name: "Till 1", OutletID: 9}); !model.IsNotFoundError(err) {
+		t.Errorf("Register() at an unknown outlet error = %v, want not found", err)
+	}
+
+	registered, err := svc.Register(ctx, model.DeviceRequest{Name: "Till 1"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	if _, err := svc.Pair(ctx, model.PairRequest{PairingCode: registered.PairingCode}); !model.IsUnauthorizedError(err) {
+		t.Errorf("Pair() with an expired code error = %v, want unauthorized", err)
+	}
+	devices, _ := svc.GetAll(ctx)
+	if len(devices) != 1 || devices[0].Status != model.DeviceStatusExpired {
+		t.Errorf("GetAll() = %+v, want one expired device", devices)
+	}
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"errors"
+
+	errorsPkg "kasir-api/pkg/errors"
+)
+
+// saleRecordedError marks a checkout error raised after the sale was committed,
+// so callers must not undo their own state as if nothing was sold
+type saleRecordedError struct {
+	error
+}
+
+func (e saleRecordedError) Unwrap() error {
+	return e.error
+}
+
+// isSaleRecorded reports whether err was raised after the sale was committed
+func isSaleRecorded(err error) bool {
+	var recorded saleRecordedError
+	return errors.As(err, &recorded)
+}
+
+// wrapError keeps domain errors (validation, not found, conflict, ...) intact so
+// handlers can map them to the right status code, and wraps anything else as an
+// internal error with the given message.
+func wrapError(err error, message string) error {
+	var appErr errorsPkg.AppError
+	if errorsPkg.As(err, &appErr) && appErr.Type != errorsPkg.ErrorTypeInternal {
+		return err
+	}
+	return errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, message)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+type OutletService struct {
+	reader repository.OutletReader
+	writer repository.OutletWriter
+}
+
+func NewOutletService(reader repository.OutletReader, writer repository.OutletWriter) *OutletService {
+	return &OutletService{
+		reader: reader,
+		writer: writer,
+	}
+}
+
+func (s *OutletService) Create(ctx context.Context, req model.OutletRequest) (*model.Outlet, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "OutletService.Create", req)
+	defer spanEnd(nil, nil)
+
+	req = req.Normalize()
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	outlet, err := s.writer.Create(ctx, req.Outlet())
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to create outlet")
+	}
+
+	spanEnd(outlet, nil)
+	return outlet, nil
+}
+
+func (s *OutletService) GetByID(ctx context.Context, id int) (*model.Outlet, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "OutletService.GetByID", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	outlet, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(outlet, nil)
+	return outlet, nil
+}
+
+func (s *OutletService) GetAll(ctx context.Context) ([]model.Outlet, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "OutletService.GetAll", nil)
+	defer spanEnd(nil, nil)
+
+	outlets, err := s.reader.FindAll(ctx)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get outlets")
+	}
+
+	spanEnd(outlets, nil)
+	return outlets, nil
+}
+
+// Update changes an outlet. Leaving active out of the request keeps the outlet's
+// current state.
+func (s *OutletService) Update(ctx context.Context, id int, req model.OutletRequest) (*model.Outlet, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "OutletService.Update", map[string]interface{}{"id": id, "request": req})
+	defer spanEnd(nil, nil)
+
+	req = req.Normalize()
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	if req.Active == nil {
+		current, err := s.reader.FindByID(ctx, id)
+		if err != nil {
+			spanEnd(nil, err)
+			return nil, wrapError(err, "failed to update outlet")
+		}
+		req.Active = &current.Active
+	}
+
+	outlet, err := s.writer.Update(ctx, id, req.Outlet())
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to update outlet")
+	}
+
+	spanEnd(outlet, nil)
+	return outlet, nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+)
+
+func TestOutletService_CreateAndUpdate(t *testing.T) {
+	repo := memory.NewOutletRepository(model.Outlet{Code: "MAIN"})
+	svc := NewOutletService(repo, repo)
+	ctx := context.Background()
+
+	outlet, err := svc.Create(ctx, model.OutletRequest{Code: " bdg ", Name: "Bandung"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if outlet.Code != "BDG" || !outlet.Active {
+		t.Errorf("Create() = %+v, want active outlet coded BDG", outlet)
+	}
+
+	if _, err := svc.Create(ctx, model.OutletRequest{Code: "main", Name: "Duplicate"}); !model.IsConflictError(err) {
+		t.Errorf("Create() with a used code error = %v, want conflict", err)
+	}
+	if _, err := svc.Create(ctx, model.OutletRequest{Code: "B/DG", Name: "Bandung"}); !model.IsValidationError(err) {
+		t.Errorf("Create() with a slash in the code error = %v, want validation", err)
+	}
+
+	inactive := false
+	if _, err := svc.Update(ctx, outlet.ID, model.OutletRequest{Code: "BDG", Name: "Bandung", Active: &inactive}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// Leaving active out keeps the outlet deactivated
+	updated, err := svc.Update(ctx, outlet.ID, model.OutletRequest{Code: "BDG", Name: "Bandung Dago"})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.Active || updated.Name != "Bandung Dago" {
+		t.Errorf("Update() = %+v, want inactive outlet renamed", updated)
+	}
+
+	if _, err := svc.Update(ctx, 99, model.OutletRequest{Code: "X", Name: "Missing"}); !model.IsNotFoundError(err) {
+		t.Errorf("Update() of a missing outlet error = %v, want not found", err)
+	}
+}
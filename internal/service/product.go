@@ -71,11 +71,13 @@ func (s *ProductService) Create(ctx context.Context, p model.Product) (*model.Pr
 		spanEnd(nil, err)
 		return nil, err
 	}
+	// Variants are added through CreateVariant
+	p.ParentID, p.Attributes = nil, nil
 
 	created, err := s.writer.Create(ctx, p)
 	if err != nil {
 		spanEnd(nil, err)
-		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to create product")
+		return nil, wrapError(err, "failed to create product")
 	}
 
 	spanEnd(created, nil)
@@ -91,16 +93,65 @@ func (s *ProductService) Update(ctx context.Context, id int, p model.Product) (*
 		return nil, err
 	}
 
+	existing, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to update product")
+	}
+	if err := existing.CheckUpdate(p); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
 	updated, err := s.writer.Update(ctx, id, p)
 	if err != nil {
 		spanEnd(nil, err)
-		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to update product")
+		return nil, wrapError(err, "failed to update product")
 	}
 
 	spanEnd(updated, nil)
 	return updated, nil
 }
 
+// CreateVariant adds a variant to the product with the given id, which must have options
+func (s *ProductService) CreateVariant(ctx context.Context, productID int, req model.ProductVariantRequest) (*model.Product, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "ProductService.CreateVariant", map[string]interface{}{"product_id": productID, "request": req})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	created, err := s.writer.CreateVariant(ctx, productID, req)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to create product variant")
+	}
+
+	spanEnd(created, nil)
+	return created, nil
+}
+
+// GetVariants returns the variants of the product with the given id
+func (s *ProductService) GetVariants(ctx context.Context, productID int) ([]model.ProductVariant, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "ProductService.GetVariants", map[string]interface{}{"product_id": productID})
+	defer spanEnd(nil, nil)
+
+	product, err := s.reader.FindByID(ctx, productID)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to get product variants")
+	}
+
+	variants := product.Variants
+	if variants == nil {
+		variants = []model.ProductVariant{}
+	}
+	spanEnd(variants, nil)
+	return variants, nil
+}
+
 func (s *ProductService) Delete(ctx context.Context, id int) error {
 	ctx, spanEnd := tracing.TraceRequest(ctx, "ProductService.Delete", map[string]interface{}{"id": id})
 	defer spanEnd(nil, nil)
@@ -108,7 +159,7 @@ func (s *ProductService) Delete(ctx context.Context, id int) error {
 	err := s.writer.Delete(ctx, id)
 	if err != nil {
 		spanEnd(nil, err)
-		return errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to delete product")
+		return wrapError(err, "failed to delete product")
 	}
 
 	spanEnd(nil, nil)
@@ -132,7 +132,7 @@ func TestProductService_Delete(t *testing.T) {
 	svc := NewProductService(repo, repo)
 	ctx := context.Background()
 
-	product := model.Product{Name: "Indomie", Price: 3500, Stock: 100}
+	product := model.Product{Name: "Indomie", Price: 3500}
 	created, _ := svc.Create(ctx, product)
 
 	err := svc.Delete(ctx, created.ID)
@@ -145,3 +145,49 @@ func TestProductService_Delete(t *testing.T) {
 		t.Errorf("After delete, GetByID() error = %v, want %v", err, model.ErrNotFound)
 	}
 }
+
+func TestProductService_CreateVariant(t *testing.T) {
+	repo := memory.NewProductRepository()
+	svc := NewProductService(repo, repo)
+	ctx := context.Background()
+
+	// A parent_id given on create is ignored; variants come through CreateVariant
+	strayParent := 99
+	parent, err := svc.Create(ctx, model.Product{
+		Name: "Indomie", Price: 3500, Active: true, ParentID: &strayParent,
+		Options: []model.ProductOption{{Name: "flavour", Values: []string{"Goreng", "Soto", "Kari"}}},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if parent.ParentID != nil {
+		t.Errorf("ParentID = %v, want nil", *parent.ParentID)
+	}
+
+	if _, err := svc.CreateVariant(ctx, parent.ID, model.ProductVariantRequest{}); !model.IsValidationError(err) {
+		t.Errorf("CreateVariant() without attributes error = %v, want a validation error", err)
+	}
+	goreng, err := svc.CreateVariant(ctx, parent.ID, model.ProductVariantRequest{Attributes: map[string]string{"flavour": "Goreng"}, Stock: 20})
+	if err != nil {
+		t.Fatalf("CreateVariant() error = %v", err)
+	}
+	if _, err := svc.CreateVariant(ctx, parent.ID, model.ProductVariantRequest{Attributes: map[string]string{"flavour": "Goreng"}}); !model.IsConflictError(err) {
+		t.Errorf("CreateVariant() twice error = %v, want a conflict", err)
+	}
+
+	variants, err := svc.GetVariants(ctx, parent.ID)
+	if err != nil || len(variants) != 1 || variants[0].ID != goreng.ID {
+		t.Errorf("GetVariants() = %+v, %v; want the Goreng variant", variants, err)
+	}
+
+	// Options may grow, but not drop a value a variant has
+	update := *parent
+	update.Options = []model.ProductOption{{Name: "flavour", Values: []string{"Soto", "Kari"}}}
+	if _, err := svc.Update(ctx, parent.ID, update); !model.IsConflictError(err) {
+		t.Errorf("Update() dropping Goreng error = %v, want a conflict", err)
+	}
+	update.Options = []model.ProductOption{{Name: "flavour", Values: []string{"Goreng", "Soto", "Kari", "Rendang"}}}
+	if _, err := svc.Update(ctx, parent.ID, update); err != nil {
+		t.Errorf("Update() adding Rendang error = %v", err)
+	}
+}
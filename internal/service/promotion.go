@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+type PromotionService struct {
+	reader repository.PromotionReader
+	writer repository.PromotionWriter
+}
+
+func NewPromotionService(reader repository.PromotionReader, writer repository.PromotionWriter) *PromotionService {
+	return &PromotionService{
+		reader: reader,
+		writer: writer,
+	}
+}
+
+func (s *PromotionService) GetByID(ctx context.Context, id int) (*model.Promotion, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PromotionService.GetByID", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	promotion, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(promotion, nil)
+	return promotion, nil
+}
+
+func (s *PromotionService) GetAll(ctx context.Context) ([]model.Promotion, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PromotionService.GetAll", nil)
+	defer spanEnd(nil, nil)
+
+	promotions, err := s.reader.FindAll(ctx)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get all promotions")
+	}
+
+	spanEnd(promotions, nil)
+	return promotions, nil
+}
+
+func (s *PromotionService) Create(ctx context.Context, p model.Promotion) (*model.Promotion, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PromotionService.Create", p)
+	defer spanEnd(nil, nil)
+
+	if err := p.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	created, err := s.writer.Create(ctx, p)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to create promotion")
+	}
+
+	spanEnd(created, nil)
+	return created, nil
+}
+
+func (s *PromotionService) Update(ctx context.Context, id int, p model.Promotion) (*model.Promotion, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PromotionService.Update", map[string]interface{}{"id": id, "promotion": p})
+	defer spanEnd(nil, nil)
+
+	if err := p.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	updated, err := s.writer.Update(ctx, id, p)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to update promotion")
+	}
+
+	spanEnd(updated, nil)
+	return updated, nil
+}
+
+func (s *PromotionService) Delete(ctx context.Context, id int) error {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PromotionService.Delete", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	if err := s.writer.Delete(ctx, id); err != nil {
+		spanEnd(nil, err)
+		return wrapError(err, "failed to delete promotion")
+	}
+
+	spanEnd(nil, nil)
+	return nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+// PurchaseOrderService orders stock from suppliers: draft an order, send it, book
+// the deliveries as goods receipts, and close it when nothing more will come
+type PurchaseOrderService struct {
+	reader    repository.PurchaseOrderReader
+	writer    repository.PurchaseOrderWriter
+	suppliers repository.SupplierReader
+}
+
+func NewPurchaseOrderService(reader repository.PurchaseOrderReader, writer repository.PurchaseOrderWriter, suppliers repository.SupplierReader) *PurchaseOrderService {
+	return &PurchaseOrderService{
+		reader:    reader,
+		writer:    writer,
+		suppliers: suppliers,
+	}
+}
+
+// Create drafts an order for the outlet of ctx
+func (s *PurchaseOrderService) Create(ctx context.Context, req model.PurchaseOrderRequest) (*model.PurchaseOrder, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PurchaseOrderService.Create", req)
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+	if err := s.checkSupplier(ctx, req.SupplierID); err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to create purchase order")
+	}
+
+	po, err := s.writer.Create(ctx, req.PurchaseOrder(ctx))
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to create purchase order")
+	}
+
+	spanEnd(map[string]interface{}{"id": po.ID, "total_cost": po.TotalCost}, nil)
+	return po, nil
+}
+
+func (s *PurchaseOrderService) GetByID(ctx context.Context, id int) (*model.PurchaseOrder, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PurchaseOrderService.GetByID", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	po, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(map[string]interface{}{"id": po.ID, "status": po.Status}, nil)
+	return po, nil
+}
+
+// GetAll lists the orders of the outlet of ctx, such as the open orders of one
+// supplier or those waiting on a product
+func (s *PurchaseOrderService) GetAll(ctx context.Context, filter model.PurchaseOrderFilter) ([]model.PurchaseOrder, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PurchaseOrderService.GetAll", filter)
+	defer spanEnd(nil, nil)
+
+	if err := filter.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	orders, err := s.reader.FindAll(ctx, filter)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get purchase orders")
+	}
+
+	spanEnd(map[string]interface{}{"count": len(orders)}, nil)
+	return orders, nil
+}
+
+// Update replaces the supplier, note and items of a draft
+func (s *PurchaseOrderService) Update(ctx context.Context, id int, req model.PurchaseOrderRequest) (*model.PurchaseOrder, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PurchaseOrderService.Update", map[string]interface{}{"id": id, "request": req})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+	if err := s.checkSupplier(ctx, req.SupplierID); err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to update purchase order")
+	}
+
+	po, err := s.writer.Update(ctx, id, req.PurchaseOrder(ctx))
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to update purchase order")
+	}
+
+	spanEnd(map[string]interface{}{"id": po.ID, "total_cost": po.TotalCost}, nil)
+	return po, nil
+}
+
+func (s *PurchaseOrderService) Send(ctx context.Context, id int) (*model.PurchaseOrder, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PurchaseOrderService.Send", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	po, err := s.writer.Send(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to send purchase order")
+	}
+
+	spanEnd(map[string]interface{}{"id": po.ID, "status": po.Status}, nil)
+	return po, nil
+}
+
+// Receive books a delivery against a sent order and posts the stock
+func (s *PurchaseOrderService) Receive(ctx context.Context, id int, req model.GoodsReceiptRequest) (*model.PurchaseOrder, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PurchaseOrderService.Receive", map[string]interface{}{"id": id, "request": req})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	po, err := s.writer.Receive(ctx, id, req)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to receive goods")
+	}
+
+	spanEnd(map[string]interface{}{"id": po.ID, "status": po.Status, "received_cost": po.ReceivedCost}, nil)
+	return po, nil
+}
+
+func (s *PurchaseOrderService) Close(ctx context.Context, id int) (*model.PurchaseOrder, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PurchaseOrderService.Close", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	po, err := s.writer.Close(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to close purchase order")
+	}
+
+	spanEnd(map[string]interface{}{"id": po.ID, "status": po.Status}, nil)
+	return po, nil
+}
+
+// checkSupplier refuses orders to suppliers that do not exist or are inactive
+func (s *PurchaseOrderService) checkSupplier(ctx context.Context, id int) error {
+	supplier, err := s.suppliers.FindByID(ctx, id)
+	if err != nil {
+		if model.IsNotFoundError(err) {
+			return model.SupplierNotFoundError(id)
+		}
+		return err
+	}
+	if !supplier.Active {
+		return model.SupplierInactiveError(supplier.Name)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+// PurchaseReturnService sends goods back to suppliers and tracks the credit they
+// owe for them until it is booked
+type PurchaseReturnService struct {
+	reader    repository.PurchaseReturnReader
+	writer    repository.PurchaseReturnWriter
+	suppliers repository.SupplierReader
+}
+
+func NewPurchaseReturnService(reader repository.PurchaseReturnReader, writer repository.PurchaseReturnWriter, suppliers repository.SupplierReader) *PurchaseReturnService {
+	return &PurchaseReturnService{
+		reader:    reader,
+		writer:    writer,
+		suppliers: suppliers,
+	}
+}
+
+// Create returns goods to a supplier from the outlet of ctx. Inactive suppliers
+// still take back what they delivered.
+func (s *PurchaseReturnService) Create(ctx context.Context, req model.PurchaseReturnRequest) (*model.PurchaseReturn, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PurchaseReturnService.Create", req)
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+	if _, err := s.suppliers.FindByID(ctx, req.SupplierID); err != nil {
+		if model.IsNotFoundError(err) {
+			err = model.SupplierNotFoundError(req.SupplierID)
+		}
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to create purchase return")
+	}
+
+	pr, err := s.writer.Create(ctx, req)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to create purchase return")
+	}
+
+	spanEnd(map[string]interface{}{"id": pr.ID, "credit_total": pr.CreditTotal}, nil)
+	return pr, nil
+}
+
+func (s *PurchaseReturnService) GetByID(ctx context.Context, id int) (*model.PurchaseReturn, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PurchaseReturnService.GetByID", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	pr, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(map[string]interface{}{"id": pr.ID, "status": pr.Status}, nil)
+	return pr, nil
+}
+
+// GetAll lists the returns of the outlet of ctx, such as those a supplier still
+// owes credit for
+func (s *PurchaseReturnService) GetAll(ctx context.Context, filter model.PurchaseReturnFilter) ([]model.PurchaseReturn, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PurchaseReturnService.GetAll", filter)
+	defer spanEnd(nil, nil)
+
+	if err := filter.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	returns, err := s.reader.FindAll(ctx, filter)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get purchase returns")
+	}
+
+	spanEnd(map[string]interface{}{"count": len(returns)}, nil)
+	return returns, nil
+}
+
+// RecordCredit books credit received from the supplier against a return
+func (s *PurchaseReturnService) RecordCredit(ctx context.Context, id int, req model.SupplierCreditRequest) (*model.PurchaseReturn, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "PurchaseReturnService.RecordCredit", map[string]interface{}{"id": id, "request": req})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	pr, err := s.writer.RecordCredit(ctx, id, req)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to record supplier credit")
+	}
+
+	spanEnd(map[string]interface{}{"id": pr.ID, "status": pr.Status, "credit_owed": pr.CreditOwed}, nil)
+	return pr, nil
+}
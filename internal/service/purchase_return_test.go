@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+)
+
+func TestPurchaseReturnService(t *testing.T) {
+	products := memory.NewProductRepository()
+	suppliers := memory.NewSupplierRepository()
+	orders := memory.NewPurchaseOrderRepository(products, suppliers)
+	returns := memory.NewPurchaseReturnRepository(products, suppliers, orders)
+	svc := NewPurchaseReturnService(returns, returns, suppliers)
+	ctx := context.Background()
+
+	product, _ := products.Create(ctx, model.Product{Name: "Susu UHT", Price: 6000, Cost: 4500, Stock: 12})
+	supplier, _ := suppliers.Create(ctx, model.Supplier{Name: "PT Susu Segar", Active: false})
+
+	items := []model.PurchaseReturnItemRequest{{ProductID: product.ID, Quantity: 3, Reason: model.StockReasonDamaged}}
+	if _, err := svc.Create(ctx, model.PurchaseReturnRequest{SupplierID: 99, Items: items}); !model.IsNotFoundError(err) {
+		t.Errorf("Create() for a missing supplier error = %v, want not found", err)
+	}
+	if _, err := svc.Create(ctx, model.PurchaseReturnRequest{SupplierID: supplier.ID}); !model.IsValidationError(err) {
+		t.Errorf("Create() without items error = %v, want validation", err)
+	}
+
+	// A deactivated supplier still takes back its goods
+	pr, err := svc.Create(ctx, model.PurchaseReturnRequest{SupplierID: supplier.ID, Items: items})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if pr.CreditOwed != 13500 {
+		t.Errorf("CreditOwed = %d, want 13500", pr.CreditOwed)
+	}
+	if p, _ := products.FindByID(ctx, product.ID); p.Stock != 9 {
+		t.Errorf("Stock = %d, want 9", p.Stock)
+	}
+
+	if _, err := svc.RecordCredit(ctx, pr.ID, model.SupplierCreditRequest{}); !model.IsValidationError(err) {
+		t.Errorf("RecordCredit() of nothing error = %v, want validation", err)
+	}
+	if _, err := svc.GetAll(ctx, model.PurchaseReturnFilter{Status: "lost"}); !model.IsValidationError(err) {
+		t.Errorf("GetAll() with an unknown status error = %v, want validation", err)
+	}
+	open, err := svc.GetAll(ctx, model.PurchaseReturnFilter{SupplierID: supplier.ID, Status: model.PurchaseReturnStatusOpen})
+	if err != nil || len(open) != 1 {
+		t.Errorf("GetAll() open = %+v, %v", open, err)
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+)
+
+func TestPurchaseOrderService(t *testing.T) {
+	products := memory.NewProductRepository()
+	suppliers := memory.NewSupplierRepository()
+	orders := memory.NewPurchaseOrderRepository(products, suppliers)
+	supplierSvc := NewSupplierService(suppliers, suppliers)
+	svc := NewPurchaseOrderService(orders, orders, suppliers)
+	ctx := context.Background()
+
+	product, _ := products.Create(ctx, model.Product{Name: "Aqua 600ml", Price: 4000, Cost: 2000, Stock: 0})
+	supplier, err := supplierSvc.Create(ctx, model.SupplierRequest{Name: "  CV Tirta  ", Email: "Order@Tirta.co.id"})
+	if err != nil {
+		t.Fatalf("Create() supplier error = %v", err)
+	}
+	if supplier.Name != "CV Tirta" || supplier.Email != "order@tirta.co.id" || !supplier.Active {
+		t.Errorf("Unexpected supplier: %+v", supplier)
+	}
+
+	req := model.PurchaseOrderRequest{SupplierID: supplier.ID, Items: []model.PurchaseOrderItemRequest{{ProductID: product.ID, Quantity: 48, UnitCost: 2000}}}
+	if _, err := svc.Create(ctx, model.PurchaseOrderRequest{SupplierID: 99, Items: req.Items}); !model.IsNotFoundError(err) {
+		t.Errorf("Create() for a missing supplier error = %v, want not found", err)
+	}
+
+	po, err := svc.Create(ctx, req)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := svc.Send(ctx, po.ID); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if _, err := svc.Receive(ctx, po.ID, model.GoodsReceiptRequest{}); !model.IsValidationError(err) {
+		t.Errorf("Receive() without items error = %v, want validation", err)
+	}
+	if _, err := svc.Receive(ctx, po.ID, model.GoodsReceiptRequest{Items: []model.GoodsReceiptItemRequest{{ProductID: product.ID, Quantity: 24}}}); err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	if p, _ := products.FindByID(ctx, product.ID); p.Stock != 24 {
+		t.Errorf("Stock = %d, want 24", p.Stock)
+	}
+
+	if _, err := svc.GetAll(ctx, model.PurchaseOrderFilter{Status: "lost"}); !model.IsValidationError(err) {
+		t.Errorf("GetAll() with an unknown status error = %v, want validation", err)
+	}
+	open, err := svc.GetAll(ctx, model.PurchaseOrderFilter{SupplierID: supplier.ID, Open: true})
+	if err != nil || len(open) != 1 || open[0].Items[0].Outstanding != 24 {
+		t.Errorf("GetAll() open = %+v, %v", open, err)
+	}
+
+	// A deactivated supplier keeps its orders but takes no new ones
+	inactive := false
+	if _, err := supplierSvc.Update(ctx, supplier.ID, model.SupplierRequest{Name: "CV Tirta", Active: &inactive}); err != nil {
+		t.Fatalf("Update() supplier error = %v", err)
+	}
+	if _, err := svc.Create(ctx, req); !model.IsConflictError(err) {
+		t.Errorf("Create() for an inactive supplier error = %v, want conflict", err)
+	}
+	if closed, err := svc.Close(ctx, po.ID); err != nil || closed.Status != model.PurchaseOrderStatusClosed {
+		t.Errorf("Close() = %+v, %v", closed, err)
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/receipt"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+type ReceiptService struct {
+	reader   repository.TransactionReader
+	outlets  repository.OutletReader
+	renderer *receipt.Renderer
+}
+
+func NewReceiptService(reader repository.TransactionReader, outlets repository.OutletReader, renderer *receipt.Renderer) *ReceiptService {
+	return &ReceiptService{reader: reader, outlets: outlets, renderer: renderer}
+}
+
+// Render returns the receipt of a transaction in the requested format
+func (s *ReceiptService) Render(ctx context.Context, transactionID int, opts receipt.Options) ([]byte, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "ReceiptService.Render", map[string]interface{}{"transaction_id": transactionID, "options": opts})
+	defer spanEnd(nil, nil)
+
+	transaction, err := s.reader.FindByID(ctx, transactionID)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	// The receipt carries the header of the outlet that made the sale, or the
+	// store's when the outlet is gone
+	renderer := s.renderer
+	outlet, err := s.outlets.FindByID(ctx, transaction.OutletID)
+	switch {
+	case err == nil:
+		renderer = renderer.ForOutlet(*outlet)
+	case !model.IsNotFoundError(err):
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to load outlet")
+	}
+
+	out, err := renderer.Render(*transaction, opts)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to render receipt")
+	}
+
+	spanEnd(nil, nil)
+	return out, nil
+}
@@ -44,3 +44,19 @@ func (s *ReportService) GetReportByDateRange(ctx context.Context, startDate, end
 	spanEnd(report, nil)
 	return report, nil
 }
+
+// GetInventoryValuation values the stock on hand at current cost, with the credit
+// suppliers still owe for returned goods
+func (s *ReportService) GetInventoryValuation(ctx context.Context) (*model.InventoryValuation, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "ReportService.GetInventoryValuation", nil)
+	defer spanEnd(nil, nil)
+
+	valuation, err := s.reader.GetInventoryValuation(ctx)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get inventory valuation")
+	}
+
+	spanEnd(map[string]interface{}{"total_value": valuation.TotalValue, "pending_supplier_credit": valuation.PendingSupplierCredit}, nil)
+	return valuation, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+type ReturnService struct {
+	reader repository.ReturnReader
+	writer repository.ReturnWriter
+}
+
+func NewReturnService(reader repository.ReturnReader, writer repository.ReturnWriter) *ReturnService {
+	return &ReturnService{
+		reader: reader,
+		writer: writer,
+	}
+}
+
+func (s *ReturnService) Create(ctx context.Context, transactionID int, req model.ReturnRequest) (*model.Return, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "ReturnService.Create", map[string]interface{}{"transactionID": transactionID, "request": req})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	ret, err := s.writer.CreateReturn(ctx, transactionID, req)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to create return")
+	}
+
+	spanEnd(ret, nil)
+	return ret, nil
+}
+
+func (s *ReturnService) GetByID(ctx context.Context, id int) (*model.Return, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "ReturnService.GetByID", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	ret, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(ret, nil)
+	return ret, nil
+}
+
+func (s *ReturnService) GetByTransactionID(ctx context.Context, transactionID int) ([]model.Return, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "ReturnService.GetByTransactionID", map[string]interface{}{"transactionID": transactionID})
+	defer spanEnd(nil, nil)
+
+	returns, err := s.reader.FindByTransactionID(ctx, transactionID)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get returns")
+	}
+
+	spanEnd(returns, nil)
+	return returns, nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+)
+
+func TestReturnService_Create(t *testing.T) {
+	productRepo := memory.NewProductRepository()
+	ctx := context.Background()
+	productRepo.Create(ctx, model.Product{Name: "Indomie", Price: 3500, Stock: 10, Active: true})
+
+	transactionRepo := memory.NewTransactionRepository(productRepo)
+	returnRepo := memory.NewReturnRepository(transactionRepo)
+	transactionRepo.SetReturnRepo(returnRepo)
+	svc := NewReturnService(returnRepo, returnRepo)
+
+	transaction, _ := transactionRepo.CreateTransaction(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}}}, model.CheckoutOptions{})
+
+	_, err := svc.Create(ctx, transaction.ID, model.ReturnRequest{})
+	if !model.IsValidationError(err) {
+		t.Errorf("Create() error = %v, want validation error", err)
+	}
+
+	ret, err := svc.Create(ctx, transaction.ID, model.ReturnRequest{
+		Items:  []model.ReturnRequestItem{{ProductID: 1, Quantity: 1}},
+		Reason: "damaged",
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	found, err := svc.GetByID(ctx, ret.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if found.TotalAmount != -3500 {
+		t.Errorf("TotalAmount = %v, want -3500", found.TotalAmount)
+	}
+
+	_, err = svc.Create(ctx, 999, model.ReturnRequest{
+		Items:  []model.ReturnRequestItem{{ProductID: 1, Quantity: 1}},
+		Reason: "damaged",
+	})
+	if !model.IsNotFoundError(err) {
+		t.Errorf("Create() error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+type ShiftService struct {
+	reader repository.ShiftReader
+	writer repository.ShiftWriter
+}
+
+func NewShiftService(reader repository.ShiftReader, writer repository.ShiftWriter) *ShiftService {
+	return &ShiftService{
+		reader: reader,
+		writer: writer,
+	}
+}
+
+func (s *ShiftService) Open(ctx context.Context, req model.OpenShiftRequest) (*model.Shift, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "ShiftService.Open", req)
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	shift, err := s.writer.Open(ctx, req)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to open shift")
+	}
+
+	spanEnd(shift, nil)
+	return shift, nil
+}
+
+func (s *ShiftService) GetCurrent(ctx context.Context) (*model.Shift, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "ShiftService.GetCurrent", nil)
+	defer spanEnd(nil, nil)
+
+	shift, err := s.reader.FindOpen(ctx)
+	if err != nil {
+		spanEnd(nil, err)
+		if model.IsNotFoundError(err) {
+			return nil, errorsPkg.NotFoundError("no shift is open")
+		}
+		return nil, wrapError(err, "failed to get the open shift")
+	}
+
+	spanEnd(shift, nil)
+	return shift, nil
+}
+
+func (s *ShiftService) GetByID(ctx context.Context, id int) (*model.Shift, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "ShiftService.GetByID", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	shift, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(shift, nil)
+	return shift, nil
+}
+
+func (s *ShiftService) GetAll(ctx context.Context) ([]model.Shift, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "ShiftService.GetAll", nil)
+	defer spanEnd(nil, nil)
+
+	shifts, err := s.reader.FindAll(ctx)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get shifts")
+	}
+
+	spanEnd(shifts, nil)
+	return shifts, nil
+}
+
+func (s *ShiftService) AddCashMovement(ctx context.Context, shiftID int, req model.CashMovementRequest) (*model.CashMovement, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "ShiftService.AddCashMovement", map[string]interface{}{"shiftID": shiftID, "request": req})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	movement, err := s.writer.AddCashMovement(ctx, shiftID, req)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to record cash movement")
+	}
+
+	spanEnd(movement, nil)
+	return movement, nil
+}
+
+func (s *ShiftService) Close(ctx context.Context, id int, req model.CloseShiftRequest) (*model.Shift, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "ShiftService.Close", map[string]interface{}{"id": id, "request": req})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	shift, err := s.writer.Close(ctx, id, req)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to close shift")
+	}
+
+	spanEnd(shift, nil)
+	return shift, nil
+}
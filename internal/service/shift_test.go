@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+)
+
+func TestShiftService_CheckoutRequiresOpenShift(t *testing.T) {
+	productRepo := memory.NewProductRepository()
+	ctx := context.Background()
+	productRepo.Create(ctx, model.Product{Name: "Indomie", Price: 3500, Stock: 10, Active: true})
+
+	transactionRepo := memory.NewTransactionRepository(productRepo)
+	shiftRepo := memory.NewShiftRepository(transactionRepo)
+	transactionRepo.SetShiftRepo(shiftRepo)
+
+	transactionService := NewTransactionService(transactionRepo, transactionRepo)
+	transactionService.SetShiftReader(shiftRepo)
+	svc := NewShiftService(shiftRepo, shiftRepo)
+
+	req := model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}}
+	if _, err := transactionService.Checkout(ctx, req); !model.IsConflictError(err) {
+		t.Fatalf("Checkout() without shift error = %v, want conflict", err)
+	}
+
+	shift, err := svc.Open(ctx, model.OpenShiftRequest{OpeningFloat: 50000})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	transaction, err := transactionService.Checkout(ctx, req)
+	if err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+	if transaction.ShiftID == nil || *transaction.ShiftID != shift.ID {
+		t.Errorf("ShiftID = %v, want %d", transaction.ShiftID, shift.ID)
+	}
+
+	counted := 53500
+	closed, err := svc.Close(ctx, shift.ID, model.CloseShiftRequest{CountedCash: &counted})
+	if err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if *closed.Difference != 0 {
+		t.Errorf("Difference = %d, want 0", *closed.Difference)
+	}
+
+	if _, err := svc.GetCurrent(ctx); !model.IsNotFoundError(err) {
+		t.Errorf("GetCurrent() after close error = %v, want not found", err)
+	}
+	if _, err := transactionService.Checkout(ctx, req); !model.IsConflictError(err) {
+		t.Errorf("Checkout() after close error = %v, want conflict", err)
+	}
+}
+
+func TestShiftService_Close_ValidationError(t *testing.T) {
+	transactionRepo := memory.NewTransactionRepository(memory.NewProductRepository())
+	shiftRepo := memory.NewShiftRepository(transactionRepo)
+	svc := NewShiftService(shiftRepo, shiftRepo)
+
+	_, err := svc.Close(context.Background(), 1, model.CloseShiftRequest{})
+	if !model.IsValidationError(err) {
+		t.Errorf("Close() without counted cash error = %v, want validation error", err)
+	}
+}
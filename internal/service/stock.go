@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+// StockService reads the stock ledger and posts movements by hand. Sales,
+// returns and product writes post their own movements through the repositories.
+type StockService struct {
+	reader   repository.StockReader
+	writer   repository.StockWriter
+	products repository.ProductReader
+	outlets  repository.OutletReader
+}
+
+func NewStockService(reader repository.StockReader, writer repository.StockWriter, products repository.ProductReader, outlets repository.OutletReader) *StockService {
+	return &StockService{
+		reader:   reader,
+		writer:   writer,
+		products: products,
+		outlets:  outlets,
+	}
+}
+
+// GetMovements returns a page of the product's movements matching the filter,
+// newest first, and the total number of matches
+func (s *StockService) GetMovements(ctx context.Context, productID int, filter model.StockMovementFilter) ([]model.StockMovement, int, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "StockService.GetMovements", map[string]interface{}{"productID": productID, "filter": filter})
+	defer spanEnd(nil, nil)
+
+	if err := filter.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, 0, err
+	}
+	if _, err := s.products.FindByID(ctx, productID); err != nil {
+		spanEnd(nil, err)
+		return nil, 0, err
+	}
+
+	filter.ProductID = productID
+	movements, total, err := s.reader.FindMovements(ctx, filter.WithDefaults())
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, 0, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get stock movements")
+	}
+
+	spanEnd(map[string]interface{}{"count": len(movements), "total": total}, nil)
+	return movements, total, nil
+}
+
+// Post posts an adjustment, write-off or transfer of the product at the outlet of
+// the context, returning the movements posted
+func (s *StockService) Post(ctx context.Context, productID int, req model.StockMovementRequest) ([]model.StockMovement, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "StockService.Post", map[string]interface{}{"productID": productID, "request": req})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	if req.Type == model.StockMovementTransfer {
+		if req.ToOutletID == model.OutletID(ctx) {
+			err := model.TransferToSameOutletError(req.ToOutletID)
+			spanEnd(nil, err)
+			return nil, err
+		}
+		outlet, err := s.outlets.FindByID(ctx, req.ToOutletID)
+		if err != nil {
+			if model.IsNotFoundError(err) {
+				err = model.OutletNotFoundError(req.ToOutletID)
+			}
+			spanEnd(nil, err)
+			return nil, wrapError(err, "failed to post stock movement")
+		}
+		if !outlet.Active {
+			err := model.OutletInactiveError(outlet.Code)
+			spanEnd(nil, err)
+			return nil, err
+		}
+	}
+
+	movements, err := s.writer.PostMovements(ctx, productID, req.Movements(ctx, productID))
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to post stock movement")
+	}
+
+	spanEnd(movements, nil)
+	return movements, nil
+}
+
+// Check recomputes the stock from the ledger and returns where they disagree;
+// an empty result means the ledger is consistent
+func (s *StockService) Check(ctx context.Context) ([]model.StockDiscrepancy, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "StockService.Check", nil)
+	defer spanEnd(nil, nil)
+
+	discrepancies, err := s.reader.CheckLedger(ctx)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to check stock ledger")
+	}
+
+	spanEnd(map[string]interface{}{"discrepancies": len(discrepancies)}, nil)
+	return discrepancies, nil
+}
+
+// GetLowStock returns the active products at or below their reorder point at the
+// outlet of the context
+func (s *StockService) GetLowStock(ctx context.Context) ([]model.LowStockItem, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "StockService.GetLowStock", nil)
+	defer spanEnd(nil, nil)
+
+	items, err := s.reader.FindLowStock(ctx)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get low stock products")
+	}
+
+	spanEnd(map[string]interface{}{"count": len(items)}, nil)
+	return items, nil
+}
+
+// GetAlerts returns the newest low stock alerts raised at the outlet of the context
+func (s *StockService) GetAlerts(ctx context.Context, filter model.StockAlertFilter) ([]model.StockAlert, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "StockService.GetAlerts", filter)
+	defer spanEnd(nil, nil)
+
+	if err := filter.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	alerts, err := s.reader.FindAlerts(ctx, filter.WithDefaults())
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get stock alerts")
+	}
+
+	spanEnd(map[string]interface{}{"count": len(alerts)}, nil)
+	return alerts, nil
+}
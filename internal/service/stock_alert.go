@@ -0,0 +1,89 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+// StockAlertHook is told about the products a checkout took to their reorder
+// point, after the checkout and its alerts are stored. It must not block the sale.
+type StockAlertHook interface {
+	LowStock(ctx context.Context, alerts []model.StockAlert)
+}
+
+// StockAlertEvent is the body posted to the alert webhook
+type StockAlertEvent struct {
+	Event  string             `json:"event"`
+	Alerts []model.StockAlert `json:"alerts"`
+}
+
+// StockAlertEventLowStock names the event posted when checkout leaves products low
+const StockAlertEventLowStock = "stock.low"
+
+// StockAlertNotifier logs low stock alerts and, when a webhook URL is set, posts
+// them to it in the background
+type StockAlertNotifier struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func NewStockAlertNotifier(webhookURL string, timeout time.Duration) *StockAlertNotifier {
+	return &StockAlertNotifier{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: timeout},
+	}
+}
+
+func (n *StockAlertNotifier) LowStock(ctx context.Context, alerts []model.StockAlert) {
+	for _, a := range alerts {
+		attrs := []any{
+			"outlet_id", a.OutletID, "product_id", a.ProductID, "product_name", a.ProductName,
+			"stock", a.Stock, "reorder_point", a.ReorderPoint, "reorder_qty", a.ReorderQty,
+		}
+		if a.TransactionID != nil {
+			attrs = append(attrs, "transaction_id", *a.TransactionID)
+		}
+		slog.Warn("product reached its reorder point", attrs...)
+	}
+	if n.webhookURL == "" || len(alerts) == 0 {
+		return
+	}
+
+	// The checkout is already committed, so the webhook outlives the request
+	go func(ctx context.Context) {
+		if err := n.post(ctx, StockAlertEvent{Event: StockAlertEventLowStock, Alerts: alerts}); err != nil {
+			slog.Warn("failed to send stock alert webhook", "url", n.webhookURL, "alerts", len(alerts), "error", err)
+		}
+	}(context.WithoutCancel(ctx))
+}
+
+func (n *StockAlertNotifier) post(ctx context.Context, event StockAlertEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+)
+
+type recordingStockAlertHook struct {
+	calls [][]model.StockAlert
+}
+
+func (h *recordingStockAlertHook) LowStock(ctx context.Context, alerts []model.StockAlert) {
+	h.calls = append(h.calls, alerts)
+}
+
+func TestTransactionService_StockAlertHook(t *testing.T) {
+	svc, productRepo := newTestTransactionService(t)
+	enableIdempotency(svc)
+	hook := &recordingStockAlertHook{}
+	svc.SetStockAlertHook(hook)
+	ctx := context.Background()
+
+	teh, _ := productRepo.FindByID(ctx, 2)
+	teh.ReorderPoint = 3
+	productRepo.Update(ctx, 2, *teh)
+
+	// Teh Botol goes from 5 to 4, above its reorder point
+	if _, err := svc.Checkout(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 2, Quantity: 1}}}); err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+	if len(hook.calls) != 0 {
+		t.Fatalf("hook called %d times, want 0", len(hook.calls))
+	}
+
+	// Then from 4 to 2 through the idempotent path; a replay does not alert again
+	req := model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 2, Quantity: 2}}}
+	for range 2 {
+		if _, _, err := svc.CheckoutWithKey(ctx, "key-1", req); err != nil {
+			t.Fatalf("CheckoutWithKey() error = %v", err)
+		}
+	}
+	if len(hook.calls) != 1 || len(hook.calls[0]) != 1 || hook.calls[0][0].ProductID != 2 || hook.calls[0][0].Stock != 2 {
+		t.Errorf("hook calls = %+v, want one alert for Teh Botol at 2", hook.calls)
+	}
+}
+
+func TestStockAlertNotifier_Webhook(t *testing.T) {
+	received := make(chan StockAlertEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event StockAlertEvent
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected %s request with content type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&event)
+		received <- event
+	}))
+	defer server.Close()
+
+	notifier := NewStockAlertNotifier(server.URL, time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	notifier.LowStock(ctx, []model.StockAlert{{ID: 1, OutletID: 1, ProductID: 2, Stock: 2, ReorderPoint: 3}})
+	// The request has ended by the time the webhook is sent
+	cancel()
+
+	select {
+	case event := <-received:
+		if event.Event != StockAlertEventLowStock || len(event.Alerts) != 1 || event.Alerts[0].ProductID != 2 {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+	"kasir-api/pkg/middleware"
+)
+
+func TestStockService_Post(t *testing.T) {
+	products := memory.NewProductRepository()
+	outlets := memory.NewOutletRepository(model.Outlet{Code: "MAIN"})
+	svc := NewStockService(products, products, products, outlets)
+	ctx := context.Background()
+
+	product, _ := products.Create(ctx, model.Product{Name: "Indomie", Price: 3500, Stock: 10})
+	branch, _ := outlets.Create(ctx, model.Outlet{Code: "BDG", Name: "Bandung", Active: true})
+	closed, _ := outlets.Create(ctx, model.Outlet{Code: "SBY", Name: "Surabaya"})
+
+	posted, err := svc.Post(ctx, product.ID, model.StockMovementRequest{Type: model.StockMovementWriteOff, Quantity: 2, Reason: model.StockReasonExpired, Note: "expired"})
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if len(posted) != 1 || posted[0].Quantity != -2 || posted[0].Balance != 8 || posted[0].Reason != model.StockReasonExpired {
+		t.Errorf("Unexpected write-off: %+v", posted)
+	}
+
+	if _, err := svc.Post(ctx, product.ID, model.StockMovementRequest{Type: model.StockMovementTransfer, Quantity: 3, ToOutletID: branch.ID}); err != nil {
+		t.Fatalf("Post() transfer error = %v", err)
+	}
+	stock, _ := products.FindByID(middleware.WithOutletID(ctx, branch.ID), product.ID)
+	if stock.Stock != 3 {
+		t.Errorf("Stock at the branch = %d, want 3", stock.Stock)
+	}
+
+	tests := []struct {
+		name  string
+		id    int
+		req   model.StockMovementRequest
+		check func(error) bool
+	}{
+		{"sale by hand", product.ID, model.StockMovementRequest{Type: model.StockMovementSale, Quantity: -1}, model.IsValidationError},
+		{"negative write-off", product.ID, model.StockMovementRequest{Type: model.StockMovementWriteOff, Quantity: -1, Reason: model.StockReasonDamaged}, model.IsValidationError},
+		{"more than in stock", product.ID, model.StockMovementRequest{Type: model.StockMovementAdjustment, Quantity: -6, Reason: model.StockReasonLost}, model.IsValidationError},
+		{"transfer to itself", product.ID, model.StockMovementRequest{Type: model.StockMovementTransfer, Quantity: 1, ToOutletID: model.DefaultOutletID}, model.IsValidationError},
+		{"transfer to a missing outlet", product.ID, model.StockMovementRequest{Type: model.StockMovementTransfer, Quantity: 1, ToOutletID: 99}, model.IsNotFoundError},
+		{"transfer to an inactive outlet", product.ID, model.StockMovementRequest{Type: model.StockMovementTransfer, Quantity: 1, ToOutletID: closed.ID}, model.IsConflictError},
+		{"missing product", 99, model.StockMovementRequest{Type: model.StockMovementAdjustment, Quantity: 1, Reason: model.StockReasonFound}, model.IsNotFoundError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.Post(ctx, tt.id, tt.req); !tt.check(err) {
+				t.Errorf("Post() error = %v", err)
+			}
+		})
+	}
+
+	movements, total, err := svc.GetMovements(ctx, product.ID, model.StockMovementFilter{OutletID: model.DefaultOutletID})
+	if err != nil {
+		t.Fatalf("GetMovements() error = %v", err)
+	}
+	if total != 3 || movements[0].Type != model.StockMovementTransfer || movements[0].Balance != 5 {
+		t.Errorf("Unexpected movements at the main outlet: %+v", movements)
+	}
+
+	if _, _, err := svc.GetMovements(ctx, 99, model.StockMovementFilter{}); !model.IsNotFoundError(err) {
+		t.Errorf("GetMovements() for a missing product error = %v, want not found", err)
+	}
+	if _, _, err := svc.GetMovements(ctx, product.ID, model.StockMovementFilter{Type: "theft"}); !model.IsValidationError(err) {
+		t.Errorf("GetMovements() with an unknown type error = %v, want validation", err)
+	}
+
+	if discrepancies, err := svc.Check(ctx); err != nil || len(discrepancies) != 0 {
+		t.Errorf("Check() = %v, %v, want a consistent ledger", discrepancies, err)
+	}
+}
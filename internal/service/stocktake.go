@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+// StocktakeService runs stock counts: open a stocktake, submit counts, review the
+// variance, then approve it to post the adjustments or cancel it
+type StocktakeService struct {
+	reader     repository.StocktakeReader
+	writer     repository.StocktakeWriter
+	categories repository.CategoryReader
+}
+
+func NewStocktakeService(reader repository.StocktakeReader, writer repository.StocktakeWriter, categories repository.CategoryReader) *StocktakeService {
+	return &StocktakeService{
+		reader:     reader,
+		writer:     writer,
+		categories: categories,
+	}
+}
+
+func (s *StocktakeService) Open(ctx context.Context, req model.OpenStocktakeRequest) (*model.Stocktake, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "StocktakeService.Open", req)
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+	if req.CategoryID != nil {
+		if _, err := s.categories.FindByID(ctx, *req.CategoryID); err != nil {
+			if model.IsNotFoundError(err) {
+				err = fmt.Errorf("%w: category id %d not found", model.ErrNotFound, *req.CategoryID)
+			}
+			spanEnd(nil, err)
+			return nil, wrapError(err, "failed to open stocktake")
+		}
+	}
+
+	stocktake, err := s.writer.Open(ctx, req)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to open stocktake")
+	}
+
+	spanEnd(map[string]interface{}{"id": stocktake.ID, "lines": len(stocktake.Lines)}, nil)
+	return stocktake, nil
+}
+
+// GetByID returns the stocktake for review: every line with its variance against
+// the snapshot, and the summary
+func (s *StocktakeService) GetByID(ctx context.Context, id int) (*model.Stocktake, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "StocktakeService.GetByID", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	stocktake, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(stocktake.Summary, nil)
+	return stocktake, nil
+}
+
+func (s *StocktakeService) GetAll(ctx context.Context) ([]model.Stocktake, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "StocktakeService.GetAll", nil)
+	defer spanEnd(nil, nil)
+
+	stocktakes, err := s.reader.FindAll(ctx)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get stocktakes")
+	}
+
+	spanEnd(stocktakes, nil)
+	return stocktakes, nil
+}
+
+func (s *StocktakeService) SaveCounts(ctx context.Context, id int, req model.StocktakeCountRequest) (*model.Stocktake, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "StocktakeService.SaveCounts", map[string]interface{}{"id": id, "request": req})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	stocktake, err := s.writer.SaveCounts(ctx, id, req)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to save counts")
+	}
+
+	spanEnd(stocktake.Summary, nil)
+	return stocktake, nil
+}
+
+func (s *StocktakeService) Approve(ctx context.Context, id int) (*model.Stocktake, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "StocktakeService.Approve", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	stocktake, err := s.writer.Approve(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to approve stocktake")
+	}
+
+	spanEnd(stocktake.Summary, nil)
+	return stocktake, nil
+}
+
+func (s *StocktakeService) Cancel(ctx context.Context, id int) (*model.Stocktake, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "StocktakeService.Cancel", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	stocktake, err := s.writer.Cancel(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to cancel stocktake")
+	}
+
+	spanEnd(map[string]interface{}{"id": stocktake.ID}, nil)
+	return stocktake, nil
+}
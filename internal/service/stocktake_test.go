@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+)
+
+func TestStocktakeService(t *testing.T) {
+	products := memory.NewProductRepository()
+	categories := memory.NewCategoryRepository()
+	stocktakes := memory.NewStocktakeRepository(products)
+	svc := NewStocktakeService(stocktakes, stocktakes, categories)
+	ctx := context.Background()
+
+	drinks, _ := categories.Create(ctx, model.Category{Name: "Minuman"})
+	product, _ := products.Create(ctx, model.Product{Name: "Teh Botol", Price: 5000, Cost: 3000, Stock: 5, CategoryID: &drinks.ID})
+
+	if _, err := svc.Open(ctx, model.OpenStocktakeRequest{CategoryID: new(int)}); !model.IsValidationError(err) {
+		t.Errorf("Open() with category 0 error = %v, want validation", err)
+	}
+	missing := 99
+	if _, err := svc.Open(ctx, model.OpenStocktakeRequest{CategoryID: &missing}); !model.IsNotFoundError(err) {
+		t.Errorf("Open() with a missing category error = %v, want not found", err)
+	}
+
+	st, err := svc.Open(ctx, model.OpenStocktakeRequest{CategoryID: &drinks.ID})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if _, err := svc.SaveCounts(ctx, st.ID, model.StocktakeCountRequest{}); !model.IsValidationError(err) {
+		t.Errorf("SaveCounts() without items error = %v, want validation", err)
+	}
+	counted := 3
+	st, err = svc.SaveCounts(ctx, st.ID, model.StocktakeCountRequest{Items: []model.StocktakeCountItem{{ProductID: product.ID, CountedQuantity: &counted, Reason: model.StockReasonDamaged}}})
+	if err != nil {
+		t.Fatalf("SaveCounts() error = %v", err)
+	}
+	if st.Summary.LossValue != 6000 {
+		t.Errorf("LossValue = %d, want 6000", st.Summary.LossValue)
+	}
+
+	if _, err := svc.Approve(ctx, st.ID); err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	if p, _ := products.FindByID(ctx, product.ID); p.Stock != 3 {
+		t.Errorf("Stock = %d, want 3", p.Stock)
+	}
+
+	if _, err := svc.Cancel(ctx, st.ID); !model.IsConflictError(err) {
+		t.Errorf("Cancel() after approval error = %v, want conflict", err)
+	}
+	if _, err := svc.GetByID(ctx, 99); !model.IsNotFoundError(err) {
+		t.Errorf("GetByID() error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+type SupplierService struct {
+	reader repository.SupplierReader
+	writer repository.SupplierWriter
+}
+
+func NewSupplierService(reader repository.SupplierReader, writer repository.SupplierWriter) *SupplierService {
+	return &SupplierService{
+		reader: reader,
+		writer: writer,
+	}
+}
+
+func (s *SupplierService) Create(ctx context.Context, req model.SupplierRequest) (*model.Supplier, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "SupplierService.Create", req)
+	defer spanEnd(nil, nil)
+
+	req = req.Normalize()
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	supplier, err := s.writer.Create(ctx, req.Supplier())
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to create supplier")
+	}
+
+	spanEnd(supplier, nil)
+	return supplier, nil
+}
+
+func (s *SupplierService) GetByID(ctx context.Context, id int) (*model.Supplier, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "SupplierService.GetByID", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	supplier, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(supplier, nil)
+	return supplier, nil
+}
+
+func (s *SupplierService) GetAll(ctx context.Context) ([]model.Supplier, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "SupplierService.GetAll", nil)
+	defer spanEnd(nil, nil)
+
+	suppliers, err := s.reader.FindAll(ctx)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get suppliers")
+	}
+
+	spanEnd(suppliers, nil)
+	return suppliers, nil
+}
+
+// Update changes a supplier. Leaving active out of the request keeps the supplier's
+// current state.
+func (s *SupplierService) Update(ctx context.Context, id int, req model.SupplierRequest) (*model.Supplier, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "SupplierService.Update", map[string]interface{}{"id": id, "request": req})
+	defer spanEnd(nil, nil)
+
+	req = req.Normalize()
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	if req.Active == nil {
+		current, err := s.reader.FindByID(ctx, id)
+		if err != nil {
+			spanEnd(nil, err)
+			return nil, wrapError(err, "failed to update supplier")
+		}
+		req.Active = &current.Active
+	}
+
+	supplier, err := s.writer.Update(ctx, id, req.Supplier())
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to update supplier")
+	}
+
+	spanEnd(supplier, nil)
+	return supplier, nil
+}
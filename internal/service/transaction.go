@@ -2,6 +2,12 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"kasir-api/internal/model"
 	"kasir-api/internal/repository"
@@ -10,11 +16,24 @@ import (
 )
 
 type TransactionService struct {
-	writer repository.TransactionWriter
+	reader         repository.TransactionReader
+	writer         repository.TransactionWriter
+	idempotency    repository.IdempotencyStore
+	idempotencyTTL time.Duration
+	promotions     repository.PromotionReader
+	taxRate        int
+	invoice        model.InvoiceNumbering
+	shifts         repository.ShiftReader
+	loyalty        model.LoyaltyRule
+	stockAlerts    StockAlertHook
 }
 
-func NewTransactionService(writer repository.TransactionWriter) *TransactionService {
-	return &TransactionService{writer: writer}
+func NewTransactionService(reader repository.TransactionReader, writer repository.TransactionWriter) *TransactionService {
+	return &TransactionService{
+		reader:  reader,
+		writer:  writer,
+		invoice: model.DefaultInvoiceNumbering(),
+	}
 }
 
 func (s *TransactionService) Checkout(ctx context.Context, req model.CheckoutRequest) (*model.Transaction, error) {
@@ -26,12 +45,241 @@ func (s *TransactionService) Checkout(ctx context.Context, req model.CheckoutReq
 		return nil, err
 	}
 
-	transaction, err := s.writer.CreateTransaction(ctx, req.Items)
+	opts, err := s.checkoutOptions(ctx)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	transaction, err := s.writer.CreateTransaction(ctx, req, opts)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to create transaction")
+	}
+	s.notifyLowStock(ctx, transaction)
+
+	spanEnd(transaction, nil)
+	return transaction, nil
+}
+
+// SetIdempotencyStore enables Idempotency-Key handling for checkout
+func (s *TransactionService) SetIdempotencyStore(store repository.IdempotencyStore, ttl time.Duration) {
+	s.idempotency = store
+	s.idempotencyTTL = ttl
+}
+
+// SetPromotionReader enables automatic promotions at checkout
+func (s *TransactionService) SetPromotionReader(reader repository.PromotionReader) {
+	s.promotions = reader
+}
+
+// SetTaxRate sets the store-wide PPN rate in basis points applied at checkout
+func (s *TransactionService) SetTaxRate(rateBPS int) {
+	s.taxRate = rateBPS
+}
+
+// SetInvoiceNumbering sets the invoice number pattern and store code used at checkout
+func (s *TransactionService) SetInvoiceNumbering(numbering model.InvoiceNumbering) {
+	s.invoice = numbering
+}
+
+// SetLoyaltyRule sets how checkouts with a customer earn and redeem points
+func (s *TransactionService) SetLoyaltyRule(rule model.LoyaltyRule) {
+	s.loyalty = rule
+}
+
+// SetShiftReader ties every checkout to the open shift and refuses checkout when none is open
+func (s *TransactionService) SetShiftReader(reader repository.ShiftReader) {
+	s.shifts = reader
+}
+
+// SetStockAlertHook passes the alerts of checkouts that take products to their
+// reorder point to hook
+func (s *TransactionService) SetStockAlertHook(hook StockAlertHook) {
+	s.stockAlerts = hook
+}
+
+func (s *TransactionService) notifyLowStock(ctx context.Context, transaction *model.Transaction) {
+	if s.stockAlerts != nil && len(transaction.LowStock) > 0 {
+		s.stockAlerts.LowStock(ctx, transaction.LowStock)
+	}
+}
+
+// checkoutOptions gathers the pricing inputs for a checkout happening now
+func (s *TransactionService) checkoutOptions(ctx context.Context) (model.CheckoutOptions, error) {
+	opts := model.CheckoutOptions{TaxRate: s.taxRate, Invoice: s.invoice, Loyalty: s.loyalty}
+
+	if s.promotions != nil {
+		promotions, err := s.promotions.FindActive(ctx, time.Now())
+		if err != nil {
+			return opts, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to load promotions")
+		}
+		opts.Promotions = promotions
+	}
+
+	if s.shifts != nil {
+		shiftID, err := s.shifts.FindOpenID(ctx)
+		if err != nil {
+			if model.IsNotFoundError(err) {
+				return opts, model.ErrNoOpenShift
+			}
+			return opts, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to load the open shift")
+		}
+		opts.ShiftID = &shiftID
+	}
+
+	return opts, nil
+}
+
+// CheckoutWithKey runs checkout at most once per Idempotency-Key of the caller and
+// outlet. A retry with the same key and body returns the original transaction
+// with replayed set to true.
+func (s *TransactionService) CheckoutWithKey(ctx context.Context, key string, req model.CheckoutRequest) (transaction *model.Transaction, replayed bool, err error) {
+	if s.idempotency == nil || key == "" {
+		transaction, err = s.Checkout(ctx, req)
+		return transaction, false, err
+	}
+
+	ctx, spanEnd := tracing.TraceRequest(ctx, "TransactionService.CheckoutWithKey", map[string]interface{}{"key": key, "request": req})
+	defer spanEnd(nil, nil)
+
+	if len(key) > model.MaxIdempotencyKeyLength {
+		err = errorsPkg.ValidationError(fmt.Sprintf("Idempotency-Key must be at most %d characters", model.MaxIdempotencyKeyLength))
+		spanEnd(nil, err)
+		return nil, false, err
+	}
+	if err = req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, false, err
+	}
+
+	key = model.ScopedIdempotencyKey(ctx, key)
+	hash, err := hashRequest(req)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, false, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to hash request")
+	}
+
+	existing, err := s.idempotency.Reserve(ctx, key, hash, s.idempotencyTTL)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, false, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to reserve idempotency key")
+	}
+	if existing != nil {
+		transaction, err = s.replay(ctx, existing, hash)
+		if err != nil {
+			spanEnd(nil, err)
+			return nil, false, err
+		}
+		spanEnd(transaction, nil)
+		return transaction, true, nil
+	}
+
+	// The key is completed in the same database transaction as the sale, so a
+	// committed sale can always be replayed
+	opts, err := s.checkoutOptions(ctx)
+	if err == nil {
+		opts.Idempotency = &model.IdempotencyClaim{Key: key, RequestHash: hash}
+		transaction, err = s.writer.CreateTransaction(ctx, req, opts)
+	}
+	if err != nil {
+		// Nothing was sold, so free the key for a retry once the problem is fixed
+		if releaseErr := s.idempotency.Release(ctx, key); releaseErr != nil {
+			slog.Warn("failed to release idempotency key", "key", key, "error", releaseErr)
+		}
+		spanEnd(nil, err)
+		return nil, false, wrapError(err, "failed to create transaction")
+	}
+
+	s.notifyLowStock(ctx, transaction)
+
+	spanEnd(transaction, nil)
+	return transaction, false, nil
+}
+
+func (s *TransactionService) replay(ctx context.Context, record *model.IdempotencyRecord, hash string) (*model.Transaction, error) {
+	if record.RequestHash != hash {
+		return nil, fmt.Errorf("%w: Idempotency-Key was already used with a different request", model.ErrConflict)
+	}
+	if record.TransactionID == nil {
+		return nil, fmt.Errorf("%w: a request with this Idempotency-Key is still being processed", model.ErrConflict)
+	}
+
+	transaction, err := s.reader.FindByID(ctx, *record.TransactionID)
+	if err != nil {
+		return nil, saleRecordedError{wrapError(err, "failed to load original transaction")}
+	}
+	return transaction, nil
+}
+
+// hashRequest fingerprints a request body so a reused key can be matched to it
+func hashRequest(req interface{}) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Void cancels a same-day transaction and puts its stock back
+func (s *TransactionService) Void(ctx context.Context, id int, req model.CancelRequest) (*model.Transaction, error) {
+	return s.cancel(ctx, "TransactionService.Void", id, model.TransactionStatusVoided, req)
+}
+
+// Refund fully refunds a transaction and puts its stock back
+func (s *TransactionService) Refund(ctx context.Context, id int, req model.CancelRequest) (*model.Transaction, error) {
+	return s.cancel(ctx, "TransactionService.Refund", id, model.TransactionStatusRefunded, req)
+}
+
+func (s *TransactionService) cancel(ctx context.Context, operation string, id int, status model.TransactionStatus, req model.CancelRequest) (*model.Transaction, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, operation, map[string]interface{}{"id": id, "request": req})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	transaction, err := s.writer.CancelTransaction(ctx, id, status, req)
 	if err != nil {
 		spanEnd(nil, err)
-		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to create transaction")
+		return nil, wrapError(err, "failed to cancel transaction")
 	}
 
 	spanEnd(transaction, nil)
 	return transaction, nil
 }
+
+func (s *TransactionService) GetByID(ctx context.Context, id int) (*model.Transaction, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "TransactionService.GetByID", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	transaction, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(transaction, nil)
+	return transaction, nil
+}
+
+func (s *TransactionService) GetAll(ctx context.Context, filter model.TransactionFilter) ([]model.Transaction, int, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "TransactionService.GetAll", filter)
+	defer spanEnd(nil, nil)
+
+	if err := filter.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, 0, err
+	}
+
+	transactions, total, err := s.reader.FindAll(ctx, filter.WithDefaults())
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, 0, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get transactions")
+	}
+
+	spanEnd(transactions, nil)
+	return transactions, total, nil
+}
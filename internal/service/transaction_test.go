@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository/memory"
+	"kasir-api/pkg/middleware"
+)
+
+func newTestTransactionService(t *testing.T) (*TransactionService, *memory.ProductRepository) {
+	t.Helper()
+
+	productRepo := memory.NewProductRepository()
+	ctx := context.Background()
+	productRepo.Create(ctx, model.Product{Name: "Indomie", Price: 3500, Stock: 10, Active: true})
+	productRepo.Create(ctx, model.Product{Name: "Teh Botol", Price: 5000, Stock: 5, Active: true})
+
+	transactionRepo := memory.NewTransactionRepository(productRepo)
+	return NewTransactionService(transactionRepo, transactionRepo), productRepo
+}
+
+// enableIdempotency gives the service a store whose keys its repository completes
+func enableIdempotency(svc *TransactionService) *memory.IdempotencyRepository {
+	store := memory.NewIdempotencyRepository()
+	svc.writer.(*memory.TransactionRepository).SetIdempotencyStore(store)
+	svc.SetIdempotencyStore(store, time.Hour)
+	return store
+}
+
+func TestTransactionService_Checkout(t *testing.T) {
+	svc, _ := newTestTransactionService(t)
+	ctx := context.Background()
+
+	transaction, err := svc.Checkout(ctx, model.CheckoutRequest{
+		Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+
+	if transaction.TotalAmount != 7000 {
+		t.Errorf("TotalAmount = %v, want 7000", transaction.TotalAmount)
+	}
+}
+
+func TestTransactionService_CheckoutWithKey(t *testing.T) {
+	svc, productRepo := newTestTransactionService(t)
+	enableIdempotency(svc)
+	ctx := context.Background()
+
+	req := model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}}}
+	first, replayed, err := svc.CheckoutWithKey(ctx, "key-1", req)
+	if err != nil {
+		t.Fatalf("CheckoutWithKey() error = %v", err)
+	}
+	if replayed {
+		t.Error("first CheckoutWithKey() should not be a replay")
+	}
+
+	second, replayed, err := svc.CheckoutWithKey(ctx, "key-1", req)
+	if err != nil {
+		t.Fatalf("CheckoutWithKey() retry error = %v", err)
+	}
+	if !replayed || second.ID != first.ID {
+		t.Errorf("retry = (id %d, replayed %v), want (id %d, replayed true)", second.ID, replayed, first.ID)
+	}
+
+	product, _ := productRepo.FindByID(ctx, 1)
+	if product.Stock != 8 {
+		t.Errorf("Stock = %v, want 8 (decremented once)", product.Stock)
+	}
+
+	_, _, err = svc.CheckoutWithKey(ctx, "key-1", model.CheckoutRequest{
+		Items: []model.CheckoutItem{{ProductID: 1, Quantity: 3}},
+	})
+	if !model.IsConflictError(err) {
+		t.Errorf("CheckoutWithKey() with different body error = %v, want conflict", err)
+	}
+}
+
+func TestTransactionService_CheckoutWithKey_ReleasedOnFailure(t *testing.T) {
+	svc, _ := newTestTransactionService(t)
+	enableIdempotency(svc)
+	ctx := context.Background()
+
+	req := model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 2, Quantity: 6}}}
+	if _, _, err := svc.CheckoutWithKey(ctx, "key-2", req); !model.IsValidationError(err) {
+		t.Fatalf("CheckoutWithKey() error = %v, want validation error", err)
+	}
+
+	// The failed attempt must not block a retry with the same key
+	if _, _, err := svc.CheckoutWithKey(ctx, "key-2", req); !model.IsValidationError(err) {
+		t.Errorf("CheckoutWithKey() retry error = %v, want validation error", err)
+	}
+}
+
+func TestTransactionService_CheckoutWithKey_ScopedToCaller(t *testing.T) {
+	svc, productRepo := newTestTransactionService(t)
+	enableIdempotency(svc)
+
+	req := model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}}
+	callers := []context.Context{
+		middleware.WithPrincipal(context.Background(), &middleware.Principal{UserID: 2, Username: "siti"}),
+		middleware.WithPrincipal(context.Background(), &middleware.Principal{UserID: 3, Username: "budi"}),
+	}
+
+	// The same key from another cashier is a new checkout, not a replay
+	for i, ctx := range callers {
+		if _, replayed, err := svc.CheckoutWithKey(ctx, "key-1", req); err != nil || replayed {
+			t.Fatalf("CheckoutWithKey() for caller %d = replayed %v, error %v, want a new checkout", i, replayed, err)
+		}
+	}
+
+	product, _ := productRepo.FindByID(context.Background(), 1)
+	if product.Stock != 8 {
+		t.Errorf("Stock = %v, want 8 (one sale per caller)", product.Stock)
+	}
+}
+
+func TestTransactionService_CheckoutWithKey_ReservationLost(t *testing.T) {
+	svc, productRepo := newTestTransactionService(t)
+	store := enableIdempotency(svc)
+	ctx := context.Background()
+
+	// The reservation expires while checkout is still pricing the sale
+	svc.SetIdempotencyStore(expiringStore{store}, time.Hour)
+
+	req := model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 2}}}
+	if _, _, err := svc.CheckoutWithKey(ctx, "key-3", req); !model.IsConflictError(err) {
+		t.Fatalf("CheckoutWithKey() error = %v, want conflict", err)
+	}
+
+	// The key is completed with the sale or not at all, so nothing was sold
+	product, _ := productRepo.FindByID(ctx, 1)
+	if product.Stock != 10 {
+		t.Errorf("Stock = %v, want 10 (nothing sold)", product.Stock)
+	}
+	transactions, total, _ := svc.GetAll(ctx, model.TransactionFilter{}.WithDefaults())
+	if total != 0 {
+		t.Errorf("GetAll() = %d transactions, want none: %+v", total, transactions)
+	}
+}
+
+// expiringStore drops every reservation as soon as it is made
+type expiringStore struct {
+	*memory.IdempotencyRepository
+}
+
+func (s expiringStore) Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*model.IdempotencyRecord, error) {
+	existing, err := s.IdempotencyRepository.Reserve(ctx, key, requestHash, ttl)
+	if existing == nil && err == nil {
+		err = s.IdempotencyRepository.Release(ctx, key)
+	}
+	return existing, err
+}
+
+func TestTransactionService_Checkout_Promotions(t *testing.T) {
+	svc, _ := newTestTransactionService(t)
+	ctx := context.Background()
+
+	promotionRepo := memory.NewPromotionRepository()
+	productID := 1
+	promotionRepo.Create(ctx, model.Promotion{
+		Name: "Indomie B2G1", Type: model.PromotionTypeBuyXGetY, BuyQty: 2, GetQty: 1, ProductID: &productID, Active: true,
+	})
+	svc.SetPromotionReader(promotionRepo)
+
+	transaction, err := svc.Checkout(ctx, model.CheckoutRequest{
+		Items: []model.CheckoutItem{{ProductID: 1, Quantity: 3}, {ProductID: 2, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Checkout() error = %v", err)
+	}
+
+	if transaction.GrossAmount != 15500 || transaction.DiscountAmount != 3500 || transaction.TotalAmount != 12000 {
+		t.Errorf("gross/discount/total = %d/%d/%d, want 15500/3500/12000",
+			transaction.GrossAmount, transaction.DiscountAmount, transaction.TotalAmount)
+	}
+	if transaction.Details[0].DiscountAmount != 3500 || transaction.Details[0].Subtotal != 7000 {
+		t.Errorf("Unexpected discounted line: %+v", transaction.Details[0])
+	}
+	if len(transaction.Promotions) != 1 || transaction.Promotions[0].Name != "Indomie B2G1" {
+		t.Errorf("Promotions = %+v, want Indomie B2G1", transaction.Promotions)
+	}
+}
+
+func TestTransactionService_Checkout_ValidationError(t *testing.T) {
+	svc, _ := newTestTransactionService(t)
+	ctx := context.Background()
+
+	_, err := svc.Checkout(ctx, model.CheckoutRequest{})
+	if !model.IsValidationError(err) {
+		t.Errorf("Checkout() error = %v, want validation error", err)
+	}
+}
+
+func TestTransactionService_Checkout_InsufficientStock(t *testing.T) {
+	svc, _ := newTestTransactionService(t)
+	ctx := context.Background()
+
+	_, err := svc.Checkout(ctx, model.CheckoutRequest{
+		Items: []model.CheckoutItem{{ProductID: 2, Quantity: 6}},
+	})
+	if !model.IsValidationError(err) {
+		t.Errorf("Checkout() error = %v, want validation error", err)
+	}
+}
+
+func TestTransactionService_GetByID(t *testing.T) {
+	svc, _ := newTestTransactionService(t)
+	ctx := context.Background()
+
+	created, _ := svc.Checkout(ctx, model.CheckoutRequest{
+		Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}},
+	})
+
+	found, err := svc.GetByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if found.ID != created.ID {
+		t.Errorf("ID = %v, want %v", found.ID, created.ID)
+	}
+
+	_, err = svc.GetByID(ctx, 999)
+	if err != model.ErrNotFound {
+		t.Errorf("GetByID() error = %v, want %v", err, model.ErrNotFound)
+	}
+}
+
+func TestTransactionService_GetAll(t *testing.T) {
+	svc, _ := newTestTransactionService(t)
+	ctx := context.Background()
+
+	svc.Checkout(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}})
+	svc.Checkout(ctx, model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 2, Quantity: 1}}})
+
+	transactions, total, err := svc.GetAll(ctx, model.TransactionFilter{})
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if total != 2 || len(transactions) != 2 {
+		t.Errorf("GetAll() total = %d len = %d, want 2", total, len(transactions))
+	}
+
+	_, _, err = svc.GetAll(ctx, model.TransactionFilter{StartDate: "bad-date"})
+	if !model.IsValidationError(err) {
+		t.Errorf("GetAll() error = %v, want validation error", err)
+	}
+}
+
+func TestTransactionService_VoidAndRefund(t *testing.T) {
+	svc, productRepo := newTestTransactionService(t)
+	ctx := context.Background()
+
+	created, _ := svc.Checkout(ctx, model.CheckoutRequest{
+		Items: []model.CheckoutItem{{ProductID: 1, Quantity: 4}},
+	})
+
+	_, err := svc.Void(ctx, created.ID, model.CancelRequest{})
+	if !model.IsValidationError(err) {
+		t.Errorf("Void() error = %v, want validation error for missing reason", err)
+	}
+
+	voided, err := svc.Void(ctx, created.ID, model.CancelRequest{Reason: "customer changed mind"})
+	if err != nil {
+		t.Fatalf("Void() error = %v", err)
+	}
+	if voided.Status != model.TransactionStatusVoided {
+		t.Errorf("Status = %v, want voided", voided.Status)
+	}
+
+	product, _ := productRepo.FindByID(ctx, 1)
+	if product.Stock != 10 {
+		t.Errorf("Stock = %v, want 10", product.Stock)
+	}
+
+	_, err = svc.Refund(ctx, created.ID, model.CancelRequest{Reason: "again"})
+	if !model.IsConflictError(err) {
+		t.Errorf("Refund() error = %v, want conflict error", err)
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"kasir-api/internal/model"
+	"kasir-api/internal/repository"
+	errorsPkg "kasir-api/pkg/errors"
+	"kasir-api/pkg/tracing"
+)
+
+type UserService struct {
+	reader   repository.UserReader
+	writer   repository.UserWriter
+	sessions repository.SessionStore
+}
+
+func NewUserService(reader repository.UserReader, writer repository.UserWriter, sessions repository.SessionStore) *UserService {
+	return &UserService{
+		reader:   reader,
+		writer:   writer,
+		sessions: sessions,
+	}
+}
+
+// Create adds a user with the default role; owners assign other roles with AssignRole
+func (s *UserService) Create(ctx context.Context, req model.UserRequest) (*model.User, error) {
+	return s.create(ctx, req, model.DefaultRole)
+}
+
+func (s *UserService) create(ctx context.Context, req model.UserRequest, role model.Role) (*model.User, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "UserService.Create", map[string]interface{}{"username": req.Username, "role": role})
+	defer spanEnd(nil, nil)
+
+	req = req.Normalize()
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+	if req.Password == "" {
+		err := errorsPkg.ValidationError("password is required")
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to create user")
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	user, err := s.writer.Create(ctx, model.User{
+		Username:     req.Username,
+		Name:         req.Name,
+		Role:         role,
+		Active:       active,
+		PasswordHash: hash,
+	})
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to create user")
+	}
+
+	spanEnd(user, nil)
+	return user, nil
+}
+
+// CreateInitial creates the first user, as an owner, when there are none yet, so a
+// fresh install can be signed in to. It reports whether the user was created.
+func (s *UserService) CreateInitial(ctx context.Context, req model.UserRequest) (bool, error) {
+	count, err := s.reader.Count(ctx)
+	if err != nil {
+		return false, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to count users")
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	if _, err := s.create(ctx, req, model.RoleOwner); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *UserService) GetByID(ctx context.Context, id int) (*model.User, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "UserService.GetByID", map[string]interface{}{"id": id})
+	defer spanEnd(nil, nil)
+
+	user, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	spanEnd(user, nil)
+	return user, nil
+}
+
+func (s *UserService) GetAll(ctx context.Context) ([]model.User, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "UserService.GetAll", nil)
+	defer spanEnd(nil, nil)
+
+	users, err := s.reader.FindAll(ctx)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to get users")
+	}
+
+	spanEnd(users, nil)
+	return users, nil
+}
+
+// Update changes a user. Leaving active out keeps the current state. A new
+// password replaces the old one and lifts any lockout; it and deactivation both
+// sign the user out of every session.
+func (s *UserService) Update(ctx context.Context, id int, req model.UserRequest) (*model.User, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "UserService.Update", map[string]interface{}{"id": id, "username": req.Username})
+	defer spanEnd(nil, nil)
+
+	req = req.Normalize()
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	user, err := s.reader.FindByID(ctx, id)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to update user")
+	}
+
+	user.Username = req.Username
+	user.Name = req.Name
+	if req.Active != nil {
+		user.Active = *req.Active
+	}
+	if req.Password != "" {
+		hash, err := hashPassword(req.Password)
+		if err != nil {
+			spanEnd(nil, err)
+			return nil, errorsPkg.Wrap(err, errorsPkg.ErrorTypeInternal, "failed to update user")
+		}
+		user.PasswordHash = hash
+		user.FailedLogins = 0
+		user.LockedUntil = nil
+	}
+
+	updated, err := s.writer.Update(ctx, id, *user)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to update user")
+	}
+
+	if req.Password != "" || !updated.Active {
+		if err := s.sessions.RevokeAllForUser(ctx, id, time.Now()); err != nil {
+			spanEnd(nil, err)
+			return nil, wrapError(err, "failed to sign out user")
+		}
+	}
+
+	spanEnd(updated, nil)
+	return updated, nil
+}
+
+// AssignRole changes the role of a user. It takes effect on the user's next
+// request, without signing them out.
+func (s *UserService) AssignRole(ctx context.Context, id int, req model.RoleRequest) (*model.User, error) {
+	ctx, spanEnd := tracing.TraceRequest(ctx, "UserService.AssignRole", map[string]interface{}{"id": id, "role": req.Role})
+	defer spanEnd(nil, nil)
+
+	if err := req.Validate(); err != nil {
+		spanEnd(nil, err)
+		return nil, err
+	}
+
+	user, err := s.writer.SetRole(ctx, id, req.Role)
+	if err != nil {
+		spanEnd(nil, err)
+		return nil, wrapError(err, "failed to assign role")
+	}
+
+	spanEnd(user, nil)
+	return user, nil
+}
+
+// GetRoles returns the permission matrix
+func (s *UserService) GetRoles(ctx context.Context) []model.RoleInfo {
+	roles := make([]model.RoleInfo, 0, len(model.Roles))
+	for _, role := range model.Roles {
+		roles = append(roles, model.RoleInfo{Role: role, Permissions: role.Permissions()})
+	}
+	return roles
+}
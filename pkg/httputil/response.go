@@ -46,6 +46,31 @@ func ParseID(r *http.Request) (int, error) {
 	return id, nil
 }
 
+// ParsePathID parses an integer path wildcard registered on the route, e.g. {id}
+func ParsePathID(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		return 0, errorsPkg.ValidationError(fmt.Sprintf("invalid %s", name))
+	}
+
+	return id, nil
+}
+
+// QueryInt parses an optional integer query parameter, returning nil when it is absent
+func QueryInt(r *http.Request, key string) (*int, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, errorsPkg.ValidationError(fmt.Sprintf("%s must be an integer", key))
+	}
+
+	return &value, nil
+}
+
 func ErrorStatus(err error) int {
 	// Check if it's an AppError
 	var appErr errorsPkg.AppError
@@ -70,6 +95,10 @@ func ErrorStatus(err error) int {
 func HandleError(w http.ResponseWriter, err error) {
 	var appErr errorsPkg.AppError
 	if errorsPkg.As(err, &appErr) {
+		// Keep the context added when a domain error was wrapped with fmt.Errorf
+		if _, ok := err.(*errorsPkg.AppError); !ok && appErr.Type != errorsPkg.ErrorTypeInternal {
+			appErr.Message = err.Error()
+		}
 		WriteAppError(w, &appErr)
 		return
 	}
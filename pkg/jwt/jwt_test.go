@@ -0,0 +1,77 @@
+package jwt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("test-secret")
+
+// forge builds a token from a raw header and payload, signed with secret as HS256
+func forge(headerJSON, payloadJSON string, secret []byte) string {
+	unsigned := encode([]byte(headerJSON)) + "." + encode([]byte(payloadJSON))
+	return unsigned + "." + encode(signature(unsigned, secret))
+}
+
+func TestSignAndParse(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	claims := Claims{Subject: "2", SessionID: "s-1", IssuedAt: now.Unix(), ExpiresAt: now.Add(15 * time.Minute).Unix()}
+
+	token, err := Sign(claims, testSecret)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	got, err := Parse(token, testSecret, now)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if *got != claims {
+		t.Errorf("Parse() = %+v, want %+v", *got, claims)
+	}
+}
+
+func TestParse_Rejects(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	valid, _ := Sign(Claims{Subject: "2", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}, testSecret)
+	parts := strings.Split(valid, ".")
+	payload := `{"sub":"2","iat":1700000000,"exp":1700000060}`
+
+	tests := []struct {
+		name  string
+		token string
+		at    time.Time
+		want  error
+	}{
+		{"expired", valid, now.Add(time.Minute), ErrExpiredToken},
+		{"signed with another secret", forge(`{"alg":"HS256","typ":"JWT"}`, payload, []byte("other-secret")), now, ErrInvalidToken},
+		{"payload changed after signing", parts[0] + "." + encode([]byte(`{"sub":"1","iat":1700000000,"exp":1700000060}`)) + "." + parts[2], now, ErrInvalidToken},
+		{"signature stripped", parts[0] + "." + parts[1] + ".", now, ErrInvalidToken},
+		{"alg none", encode([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + ".", now, ErrInvalidToken},
+		{"alg none with a valid signature", forge(`{"alg":"none","typ":"JWT"}`, payload, testSecret), now, ErrInvalidToken},
+		{"alg HS512", forge(`{"alg":"HS512","typ":"JWT"}`, payload, testSecret), now, ErrInvalidToken},
+		{"alg RS256", forge(`{"alg":"RS256","typ":"JWT"}`, payload, testSecret), now, ErrInvalidToken},
+		{"empty", "", now, ErrInvalidToken},
+		{"two segments", parts[0] + "." + parts[1], now, ErrInvalidToken},
+		{"four segments", valid + "." + parts[2], now, ErrInvalidToken},
+		{"header not base64", "!!!." + parts[1] + "." + parts[2], now, ErrInvalidToken},
+		{"header not JSON", forge(`HS256`, payload, testSecret), now, ErrInvalidToken},
+		{"signature not base64", parts[0] + "." + parts[1] + ".!!!", now, ErrInvalidToken},
+		{"payload not JSON", forge(`{"alg":"HS256","typ":"JWT"}`, `not json`, testSecret), now, ErrInvalidToken},
+		{"no expiry", forge(`{"alg":"HS256","typ":"JWT"}`, `{"sub":"2"}`, testSecret), now, ErrExpiredToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := Parse(tt.token, testSecret, tt.at)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.want)
+			}
+			if claims != nil {
+				t.Errorf("Parse() claims = %+v, want none", claims)
+			}
+		})
+	}
+}
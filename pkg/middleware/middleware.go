@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	errorsPkg "kasir-api/pkg/errors"
 	"kasir-api/pkg/logger"
 	"kasir-api/pkg/uuid"
 )
@@ -16,6 +18,25 @@ type RequestIDKey string
 
 const RequestIDCtxKey RequestIDKey = "request_id"
 
+// OutletIDKey is the key used to store the outlet ID in context
+type OutletIDKey string
+
+const OutletIDCtxKey OutletIDKey = "outlet_id"
+
+// OutletHeader selects the outlet a request works against
+const OutletHeader = "X-Outlet-ID"
+
+// WithOutletID returns a copy of ctx scoped to the outlet
+func WithOutletID(ctx context.Context, outletID int) context.Context {
+	return context.WithValue(ctx, OutletIDCtxKey, outletID)
+}
+
+// OutletIDFromContext returns the outlet the request is scoped to, if any
+func OutletIDFromContext(ctx context.Context) (int, bool) {
+	outletID, ok := ctx.Value(OutletIDCtxKey).(int)
+	return outletID, ok
+}
+
 // LoggingMiddleware logs incoming requests and responses
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -69,7 +90,7 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, Idempotency-Key, X-Outlet-ID")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -80,38 +101,138 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// AuthMiddleware provides basic authentication
-func AuthMiddleware(next http.Handler) http.Handler {
+// OutletMiddleware scopes the request to the outlet named in the X-Outlet-ID header.
+// Requests without the header are left unscoped, unless the principal is bound to
+// an outlet: then the request is scoped to it and other outlets are refused.
+func OutletMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Skip auth for health and docs endpoints
-		if strings.HasPrefix(r.URL.Path, "/health") ||
-			strings.HasPrefix(r.URL.Path, "/docs/") ||
-			r.URL.Path == "/" {
+		boundOutletID := 0
+		if principal, ok := PrincipalFromContext(r.Context()); ok {
+			boundOutletID = principal.OutletID
+		}
+
+		header := r.Header.Get(OutletHeader)
+		if header == "" {
+			if boundOutletID != 0 {
+				r = r.WithContext(WithOutletID(r.Context(), boundOutletID))
+			}
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+		outletID, err := strconv.Atoi(header)
+		if err != nil || outletID < 1 {
+			http.Error(w, "Invalid X-Outlet-ID header", http.StatusBadRequest)
 			return
 		}
-
-		// Basic validation - in real app, validate token properly
-		if !isValidToken(authHeader) {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+		if boundOutletID != 0 && outletID != boundOutletID {
+			http.Error(w, "Device is bound to another outlet", http.StatusForbidden)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(WithOutletID(r.Context(), outletID)))
 	})
 }
 
-// isValidToken validates the authentication token
-func isValidToken(token string) bool {
-	// In a real application, this would validate against a database or JWT
-	// For now, we'll accept any token that starts with "Bearer "
-	return strings.HasPrefix(token, "Bearer ")
+// Principal is the authenticated caller of a request: a user signed in with an
+// access token, or a paired device using its API key
+type Principal struct {
+	UserID    int
+	Username  string
+	Role      string
+	SessionID string
+	DeviceID  int // set for devices, which have no user or session
+	OutletID  int // outlet a device is bound to; 0 when the caller may pick any
+}
+
+// IsDevice reports whether the caller is a device rather than a user
+func (p Principal) IsDevice() bool {
+	return p.DeviceID != 0
+}
+
+// PrincipalKey is the key used to store the authenticated principal in context
+type PrincipalKey string
+
+const PrincipalCtxKey PrincipalKey = "principal"
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, PrincipalCtxKey, p)
+}
+
+// PrincipalFromContext returns the authenticated principal of the request, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(PrincipalCtxKey).(*Principal)
+	return p, ok && p != nil
+}
+
+// Authenticator resolves a bearer token or a device key to the principal it was
+// issued to
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+// DeviceKeyHeader carries the API key of a paired device, in place of an
+// Authorization header
+const DeviceKeyHeader = "X-API-Key"
+
+// publicPaths can be reached without a token: health checks, docs and the
+// endpoints that hand out tokens in the first place
+var publicPaths = map[string]bool{
+	"/":                 true,
+	"/health":           true,
+	"/api/auth/login":   true,
+	"/api/auth/refresh": true,
+	"/api/devices/pair": true,
+}
+
+// AuthMiddleware requires a valid bearer token from users, or a device key in the
+// X-API-Key header, on every request except the public paths, and puts the
+// authenticated principal into the request context. Device keys are refused when
+// devices is nil.
+func AuthMiddleware(users, devices Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if publicPaths[r.URL.Path] || strings.HasPrefix(r.URL.Path, "/docs/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			auth, token := users, ""
+			if key := r.Header.Get(DeviceKeyHeader); key != "" && devices != nil {
+				auth, token = devices, key
+			} else {
+				authHeader := r.Header.Get("Authorization")
+				if authHeader == "" {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					http.Error(w, "Authorization header required", http.StatusUnauthorized)
+					return
+				}
+
+				var ok bool
+				token, ok = strings.CutPrefix(authHeader, "Bearer ")
+				if !ok || token == "" {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					http.Error(w, "Invalid token", http.StatusUnauthorized)
+					return
+				}
+			}
+
+			principal, err := auth.Authenticate(r.Context(), token)
+			if err != nil && !errorsPkg.IsType(err, errorsPkg.ErrorTypeUnauthorized) {
+				logger.Error("Authentication failed", "error", err.Error(), "url", r.URL.Path)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
 }
 
 // RecoveryMiddleware recovers from panics